@@ -44,6 +44,26 @@ const (
 	// a static IP address.
 	WaitingForStaticIPAllocationReason = "WaitingForStaticIPAllocation"
 
+	// WaitingForBootstrapApprovalReason (Severity=Info) documents a ProxmoxMachine whose VM is configured,
+	// but which waits for the approval of its bootstrap.
+	WaitingForBootstrapApprovalReason = "WaitingForBootstrapApproval"
+
+	// WaitingForHooksReason (Severity=Info) documents a ProxmoxMachine held by the annotations of
+	// pre-clone or pre-start hooks.
+	WaitingForHooksReason = "WaitingForHooks"
+
+	// MachineSizeNotFoundReason (Severity=Warning) documents a ProxmoxMachine selecting a size
+	// which the ProxmoxCluster does not define.
+	MachineSizeNotFoundReason = "MachineSizeNotFound"
+
+	// WaitingForPersistentDiskReason (Severity=Info) documents a ProxmoxMachine waiting for a ProxmoxDisk
+	// which can be attached to its VM.
+	WaitingForPersistentDiskReason = "WaitingForPersistentDisk"
+
+	// DownloadingImageReason documents (Severity=Info) a ProxmoxMachine waiting for the node to download
+	// the image which the VM is created from.
+	DownloadingImageReason = "DownloadingImage"
+
 	// CloningReason documents (Severity=Info) a ProxmoxMachine/ProxmoxVM currently executing the clone operation.
 	CloningReason = "Cloning"
 
@@ -60,6 +80,10 @@ const (
 	// are automatically re-tried by the controller.
 	PoweringOnFailedReason = "PoweringOnFailed"
 
+	// SuspendingReason (Severity=Info) documents a ProxmoxMachine/ProxmoxVM being suspended or hibernated
+	// according to its desired power state.
+	SuspendingReason = "Suspending"
+
 	// VMProvisionStarted used for starting vm provisioning.
 	VMProvisionStarted = "VMProvisionStarted"
 
@@ -67,6 +91,14 @@ const (
 	// retry the operation, but a user intervention might be required to fix the problem.
 	TaskFailure = "TaskFailure"
 
+	// ProvisioningTimeoutReason (Severity=Warning) documents a ProxmoxMachine which did not finish
+	// provisioning in time. The VM is recreated until the retries are exhausted.
+	ProvisioningTimeoutReason = "ProvisioningTimeout"
+
+	// DeletingTimedOutVMReason (Severity=Info) documents a ProxmoxMachine waiting for the VM which did not finish
+	// provisioning in time to be deleted, before it is recreated.
+	DeletingTimedOutVMReason = "DeletingTimedOutVM"
+
 	// WaitingForNetworkAddressesReason (Severity=Info) documents a ProxmoxMachine waiting for the the machine network
 	// settings to be reported after machine being powered on.
 	//
@@ -80,7 +112,270 @@ const (
 	UnknownReason = "Unknown"
 )
 
+const (
+	// VMConfigInSyncCondition documents whether the config of the VM of a ready ProxmoxMachine
+	// matches its spec.
+	VMConfigInSyncCondition clusterv1.ConditionType = "VMConfigInSync"
+
+	// VMConfigDriftedReason (Severity=Warning) documents a VM config which was changed outside of the provider.
+	VMConfigDriftedReason = "VMConfigDrifted"
+
+	// ReapplyingVMConfigReason (Severity=Info) documents a drifted VM config being configured
+	// according to the spec again.
+	ReapplyingVMConfigReason = "ReapplyingVMConfig"
+)
+
+const (
+	// VMResizedCondition documents whether the compute resources of the VM of a ready ProxmoxMachine
+	// match its spec.
+	VMResizedCondition clusterv1.ConditionType = "VMResized"
+
+	// ResizingReason (Severity=Info) documents the VM being configured with the compute resources of the spec.
+	ResizingReason = "Resizing"
+
+	// ResizePendingReason (Severity=Warning) documents a resize which could not be hotplugged
+	// and is applied on the next reboot of the VM.
+	ResizePendingReason = "ResizePending"
+
+	// RebootingReason (Severity=Info) documents the VM being rebooted to apply a resize.
+	RebootingReason = "Rebooting"
+)
+
+const (
+	// AgentHealthyCondition documents whether the QEMU guest agent of the VM of a ready ProxmoxMachine
+	// with an agent health check responds to pings.
+	AgentHealthyCondition clusterv1.ConditionType = "AgentHealthy"
+
+	// AgentNotRespondingReason (Severity=Warning) documents a QEMU guest agent which did not respond to a ping,
+	// e.g. because the guest froze.
+	AgentNotRespondingReason = "AgentNotResponding"
+)
+
+const (
+	// CloudInitCompletedCondition documents whether cloud-init finished in the VM of a ProxmoxMachine
+	// with a cloud-init check.
+	CloudInitCompletedCondition clusterv1.ConditionType = "CloudInitCompleted"
+
+	// WaitingForCloudInitReason (Severity=Info) documents cloud-init still running in the VM,
+	// or the QEMU guest agent not responding yet.
+	WaitingForCloudInitReason = "WaitingForCloudInit"
+
+	// CloudInitFailedReason (Severity=Error) documents cloud-init which finished with errors.
+	CloudInitFailedReason = "CloudInitFailed"
+)
+
+const (
+	// NodeJoinedCondition documents whether the node of a ProxmoxMachine with bootstrap log collection
+	// joined the cluster.
+	NodeJoinedCondition clusterv1.ConditionType = "NodeJoined"
+
+	// WaitingForNodeReason (Severity=Info) documents a ready ProxmoxMachine whose node did not join yet.
+	WaitingForNodeReason = "WaitingForNode"
+
+	// BootstrapFailedReason (Severity=Warning) documents a node which did not join the cluster within the deadline,
+	// or whose cloud-init failed. The message holds the last lines of the bootstrap logs of the VM.
+	BootstrapFailedReason = "BootstrapFailed"
+)
+
 const (
 	// ProxmoxClusterReady documents the status of ProxmoxCluster and its underlying resources.
 	ProxmoxClusterReady clusterv1.ConditionType = "ClusterReady"
 )
+
+const (
+	// IPPoolsDeletedCondition documents the deletion of the InClusterIPPools of a ProxmoxCluster
+	// which is being deleted.
+	IPPoolsDeletedCondition clusterv1.ConditionType = "IPPoolsDeleted"
+
+	// IPAddressesInUseReason (Severity=Warning) documents InClusterIPPools which are not deleted
+	// because IP addresses are still allocated from them.
+	IPAddressesInUseReason = "IPAddressesInUse"
+
+	// DeletingIPPoolsReason (Severity=Info) documents InClusterIPPools being deleted.
+	DeletingIPPoolsReason = "DeletingIPPools"
+)
+
+const (
+	// SDNVNetReadyCondition documents the status of the VNet of a ProxmoxCluster
+	// in the Proxmox VE software-defined network.
+	SDNVNetReadyCondition clusterv1.ConditionType = "SDNVNetReady"
+
+	// SDNZoneNotFoundReason (Severity=Error) documents a ProxmoxCluster referencing an SDN zone which does not exist.
+	SDNZoneNotFoundReason = "SDNZoneNotFound"
+
+	// SDNVNetConflictReason (Severity=Error) documents a VNet which exists in a different zone than configured.
+	SDNVNetConflictReason = "SDNVNetConflict"
+
+	// SDNVNetCreationFailedReason (Severity=Warning) documents an error while creating or applying the VNet.
+	SDNVNetCreationFailedReason = "SDNVNetCreationFailed"
+)
+
+const (
+	// BackupJobReadyCondition documents the status of the scheduled backup job of a ProxmoxCluster.
+	BackupJobReadyCondition clusterv1.ConditionType = "BackupJobReady"
+
+	// WaitingForVMsReason (Severity=Info) documents a backup job which is not created yet
+	// because the cluster has no VMs to back up.
+	WaitingForVMsReason = "WaitingForVMs"
+
+	// BackupJobFailedReason (Severity=Warning) documents an error while creating or updating the backup job.
+	BackupJobFailedReason = "BackupJobFailed"
+)
+
+const (
+	// DiskReadyCondition documents the allocation of the volume of a ProxmoxDisk.
+	DiskReadyCondition clusterv1.ConditionType = "DiskReady"
+
+	// DiskAllocationFailedReason (Severity=Warning) documents an error while allocating the volume of a ProxmoxDisk.
+	DiskAllocationFailedReason = "DiskAllocationFailed"
+
+	// DiskAttachedReason (Severity=Info) documents a ProxmoxDisk which is not deleted
+	// because its volume is still attached to a ProxmoxMachine.
+	DiskAttachedReason = "DiskAttached"
+)
+
+const (
+	// VMSnapshotReadyCondition documents the creation of the snapshot of a ProxmoxVMSnapshot.
+	VMSnapshotReadyCondition clusterv1.ConditionType = "VMSnapshotReady"
+
+	// WaitingForMachineVMReason (Severity=Info) documents a ProxmoxVMSnapshot whose ProxmoxMachine
+	// does not exist or has no VM yet.
+	WaitingForMachineVMReason = "WaitingForMachineVM"
+
+	// InvalidSnapshotNameReason (Severity=Error) documents a ProxmoxVMSnapshot without a snapshot name
+	// whose name is not a valid snapshot name.
+	InvalidSnapshotNameReason = "InvalidSnapshotName"
+
+	// VMSnapshotFailedReason (Severity=Warning) documents an error while creating the snapshot.
+	VMSnapshotFailedReason = "VMSnapshotFailed"
+)
+
+const (
+	// VMRolledBackCondition documents the last rollback of the VM to the snapshot of a ProxmoxVMSnapshot.
+	VMRolledBackCondition clusterv1.ConditionType = "VMRolledBack"
+
+	// RollbackFailedReason (Severity=Warning) documents an error while rolling the VM back to the snapshot.
+	// The rollback is retried.
+	RollbackFailedReason = "RollbackFailed"
+)
+
+const (
+	// TemplatesAvailableCondition documents whether the template VMs of the machine defaults and the
+	// ProxmoxMachineTemplates of a cluster exist.
+	TemplatesAvailableCondition clusterv1.ConditionType = "TemplatesAvailable"
+
+	// TemplateNotFoundReason (Severity=Warning) documents a template VM which does not exist on its source node,
+	// or a running VM which is not a template and cannot be cloned.
+	TemplateNotFoundReason = "TemplateNotFound"
+
+	// StoragesAvailableCondition documents whether the storages the machines of a cluster use exist on all
+	// eligible nodes with the content types they are used for.
+	StoragesAvailableCondition clusterv1.ConditionType = "StoragesAvailable"
+
+	// StorageNotFoundReason (Severity=Warning) documents a storage which does not exist or is disabled on an eligible node.
+	StorageNotFoundReason = "StorageNotFound"
+
+	// StorageContentMissingReason (Severity=Warning) documents a storage which does not support a content type
+	// it is used for, like images for VM disks or iso for cloud-init ISOs.
+	StorageContentMissingReason = "StorageContentMissing"
+
+	// BridgesAvailableCondition documents whether the bridges of the network devices of the machines
+	// of a cluster exist on all eligible nodes.
+	BridgesAvailableCondition clusterv1.ConditionType = "BridgesAvailable"
+
+	// BridgeNotFoundReason (Severity=Warning) documents a bridge which does not exist on an eligible node.
+	BridgeNotFoundReason = "BridgeNotFound"
+
+	// PreflightCheckFailedReason (Severity=Warning) documents an error of the Proxmox API while checking
+	// the templates, storages or bridges. The check is repeated.
+	PreflightCheckFailedReason = "PreflightCheckFailed"
+)
+
+const (
+	// TemplateImportedCondition documents the import of the template VM of a ProxmoxMachineTemplate
+	// from another Proxmox VE cluster.
+	TemplateImportedCondition clusterv1.ConditionType = "TemplateImported"
+
+	// ImportingTemplateReason (Severity=Info) documents the template VM being migrated from the source cluster.
+	ImportingTemplateReason = "ImportingTemplate"
+
+	// TemplateImportFailedReason (Severity=Warning) documents an error while importing the template VM.
+	// The import is retried.
+	TemplateImportFailedReason = "TemplateImportFailed"
+)
+
+const (
+	// CapacityAvailableCondition documents whether the pending machines of the MachineDeployments of
+	// a ProxmoxMachineTemplate fit on the eligible nodes of their cluster.
+	CapacityAvailableCondition clusterv1.ConditionType = "CapacityAvailable"
+
+	// InsufficientCapacityReason (Severity=Warning) documents pending machines which do not fit on the eligible
+	// nodes with the current reservable memory. They fail to schedule until memory is freed or nodes are added.
+	InsufficientCapacityReason = "InsufficientCapacity"
+)
+
+const (
+	// IPPoolsAvailableCondition documents whether the InClusterIPPools of a ProxmoxCluster have enough free
+	// addresses left for new machines.
+	IPPoolsAvailableCondition clusterv1.ConditionType = "IPPoolsAvailable"
+
+	// IPPoolNearlyExhaustedReason (Severity=Warning) documents an InClusterIPPool whose share of used addresses
+	// reached the exhaustion threshold of the controller.
+	IPPoolNearlyExhaustedReason = "IPPoolNearlyExhausted"
+
+	// IPPoolExhaustedReason (Severity=Error) documents an InClusterIPPool without free addresses.
+	// Machines which claim an address of the pool wait until addresses are released or added to the pool.
+	IPPoolExhaustedReason = "IPPoolExhausted"
+)
+
+const (
+	// ProxmoxAvailableCondition documents whether the Proxmox API responds. It is only set
+	// once the circuit breaker of the Proxmox API rejected calls of a reconcile.
+	ProxmoxAvailableCondition clusterv1.ConditionType = "ProxmoxAvailable"
+
+	// CircuitOpenReason (Severity=Warning) documents calls being rejected, because the Proxmox API
+	// failed repeatedly. Reconciling resumes once a request to the API succeeds again.
+	CircuitOpenReason = "CircuitOpen"
+)
+
+const (
+	// ProxmoxAPIReachableCondition documents whether the periodic probe of the version and the cluster status
+	// of the Proxmox API of a ProxmoxCluster succeeds.
+	ProxmoxAPIReachableCondition clusterv1.ConditionType = "ProxmoxAPIReachable"
+
+	// ProxmoxAPIUnreachableReason (Severity=Warning) documents a probe of the Proxmox API which failed,
+	// e.g. because the endpoint can not be connected to or the credentials are rejected.
+	ProxmoxAPIUnreachableReason = "ProxmoxAPIUnreachable"
+
+	// ProxmoxClusterNotQuorateReason (Severity=Warning) documents a Proxmox API which responds,
+	// but whose Proxmox cluster has lost quorum and therefore rejects changes.
+	ProxmoxClusterNotQuorateReason = "ProxmoxClusterNotQuorate"
+)
+
+// Conditions and reasons of the v1beta2 conditions in status.v1beta2.conditions, which are metav1.Conditions.
+// The v1beta1 conditions of the objects are mirrored there, with the reason derived from the condition type
+// if they have none.
+const (
+	// ReadyV1Beta2Condition summarizes the conditions of a ProxmoxCluster or ProxmoxMachine, like the
+	// v1beta1 Ready condition does.
+	ReadyV1Beta2Condition = "Ready"
+
+	// ReadyV1Beta2Reason surfaces that all summarized conditions are true.
+	ReadyV1Beta2Reason = "Ready"
+
+	// NotReadyV1Beta2Reason surfaces that at least one summarized condition is false.
+	NotReadyV1Beta2Reason = "NotReady"
+
+	// ReadyUnknownV1Beta2Reason surfaces that at least one summarized condition is unknown, or none is reported yet.
+	ReadyUnknownV1Beta2Reason = "ReadyUnknown"
+
+	// PausedV1Beta2Condition is true while the object or its cluster is paused, which stops its reconciliation.
+	// Unlike the other conditions, it has negative polarity.
+	PausedV1Beta2Condition = "Paused"
+
+	// PausedV1Beta2Reason surfaces that the object or its cluster is paused.
+	PausedV1Beta2Reason = "Paused"
+
+	// NotPausedV1Beta2Reason surfaces that the object is reconciled.
+	NotPausedV1Beta2Reason = "NotPaused"
+)
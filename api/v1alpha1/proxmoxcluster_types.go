@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
 	ipamicv1 "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
@@ -30,6 +31,8 @@ const (
 	// ClusterFinalizer allows cleaning up resources associated with
 	// ProxmoxCluster before removing it from the apiserver.
 	ClusterFinalizer = "proxmoxcluster.infrastructure.cluster.x-k8s.io"
+	// DefaultVolumeOwnerID is the default VMID which owns detachable volumes.
+	DefaultVolumeOwnerID = 9999
 )
 
 // ProxmoxClusterSpec defines the desired state of ProxmoxCluster.
@@ -44,23 +47,550 @@ type ProxmoxClusterSpec struct {
 	// +optional
 	AllowedNodes []string `json:"allowedNodes,omitempty"`
 
+	// AllowedNodesSelector selects Proxmox nodes which will be considered
+	// for operations in addition to AllowedNodes. The selector is resolved
+	// against the Proxmox API whenever a VM is scheduled, so nodes joining the
+	// selection become schedulable without updating the ProxmoxCluster.
+	// +optional
+	AllowedNodesSelector *NodeSelector `json:"allowedNodesSelector,omitempty"`
+
 	// IPv4Config contains information about available IPV4 address pools and the gateway.
 	// this can be combined with ipv6Config in order to enable dual stack.
-	// either IPv4Config or IPv6Config must be provided.
+	// either IPv4Config, IPv6Config or DHCPMode must be provided.
 	// +optional
 	// +kubebuilder:validation:XValidation:rule="self.addresses.size() > 0",message="IPv4Config addresses must be provided"
 	IPv4Config *ipamicv1.InClusterIPPoolSpec `json:"ipv4Config,omitempty"`
 
 	// IPv6Config contains information about available IPV6 address pools and the gateway.
 	// this can be combined with ipv4Config in order to enable dual stack.
-	// either IPv4Config or IPv6Config must be provided.
+	// either IPv4Config, IPv6Config or DHCPMode must be provided.
 	// +optional
 	// +kubebuilder:validation:XValidation:rule="self.addresses.size() > 0",message="IPv6Config addresses must be provided"
 	IPv6Config *ipamicv1.InClusterIPPoolSpec `json:"ipv6Config,omitempty"`
 
+	// AdditionalIPv4Configs are further IPv4 pools of the default network devices, for address plans
+	// which split the addresses of the machines over several ranges or subnets. The machines get their
+	// addresses from the pools in order, once ipv4Config and the pools before are exhausted.
+	// Each pool can have a prefix and a gateway of its own. Requires ipv4Config.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.all(config, config.addresses.size() > 0)",message="AdditionalIPv4Configs addresses must be provided"
+	AdditionalIPv4Configs []ipamicv1.InClusterIPPoolSpec `json:"additionalIPv4Configs,omitempty"`
+
+	// AdditionalIPv6Configs are further IPv6 pools of the default network devices, used in order
+	// once ipv6Config and the pools before are exhausted. Requires ipv6Config.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.all(config, config.addresses.size() > 0)",message="AdditionalIPv6Configs addresses must be provided"
+	AdditionalIPv6Configs []ipamicv1.InClusterIPPoolSpec `json:"additionalIPv6Configs,omitempty"`
+
+	// DHCPMode configures the default network devices of the machines by DHCP instead of
+	// addresses of ipv4Config and ipv6Config, which must not be set then. The cluster creates
+	// no InClusterIPPools, and the machines claim no IP addresses for their default devices.
+	// +kubebuilder:validation:Enum=IPv4;IPv6;DualStack
+	// +optional
+	DHCPMode DHCPMode `json:"dhcpMode,omitempty"`
+
+	// NodeIPPools assign the default network devices of machines on specific Proxmox nodes
+	// addresses from their own subnets instead of ipv4Config and ipv6Config, for networks
+	// which route a subnet per rack or host.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	NodeIPPools []NodeIPPool `json:"nodeIPPools,omitempty"`
+
 	// DNSServers contains information about nameservers used by machines network-config.
 	// +kubebuilder:validation:MinItems=1
 	DNSServers []string `json:"dnsServers"`
+
+	// IPv6DNSServers are the nameservers of the IPv6 configurations of the machines,
+	// which then use DNSServers only for their IPv4 configurations.
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	IPv6DNSServers []string `json:"ipv6DNSServers,omitempty"`
+
+	// SchedulerHints allows to influence the decision on where a VM will be scheduled.
+	// +optional
+	SchedulerHints *SchedulerHints `json:"schedulerHints,omitempty"`
+
+	// Proxy configures the HTTP proxy used by the machines of the cluster.
+	// The proxy is set in the environment of the machines and for containerd.
+	// +optional
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// CloudInitStorage is the storage which the cloud-init ISOs of the machines are uploaded to
+	// through the Proxmox API. It must support the content type iso on all allowed nodes.
+	// Defaults to the first shared storage for ISO images, or else the first one of the node.
+	// +optional
+	CloudInitStorage string `json:"cloudInitStorage,omitempty"`
+
+	// NodeAccess configures SSH access to the Proxmox nodes, which writes the cloud-init ISOs
+	// to the directory of their storage instead of uploading them through the Proxmox API.
+	// +optional
+	NodeAccess *NodeAccess `json:"nodeAccess,omitempty"`
+
+	// VolumeOwnerID is the VMID which owns the volumes of data disks with the Detach deletion policy.
+	// Proxmox VE only destroys the volumes a VM owns along with it, so it must not be the ID of a VM.
+	// Defaults to 9999.
+	// +kubebuilder:validation:Minimum=100
+	// +optional
+	VolumeOwnerID int32 `json:"volumeOwnerID,omitempty"`
+
+	// MachineDefaults are inherited by the ProxmoxMachines of the cluster,
+	// unless they set the respective fields themselves.
+	// +optional
+	MachineDefaults *MachineDefaults `json:"machineDefaults,omitempty"`
+
+	// MachineSizes define the compute resources of sizes of machines, like small or large,
+	// which ProxmoxMachines select by their size instead of setting the resources themselves.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	MachineSizes []MachineSize `json:"machineSizes,omitempty"`
+
+	// SDN configures a VNet of the Proxmox VE software-defined network for the cluster,
+	// which network devices of the machines reference by its name.
+	// +optional
+	SDN *SDNSpec `json:"sdn,omitempty"`
+
+	// Backup configures a scheduled Proxmox VE backup job of the VMs of the cluster.
+	// The job follows the machines as they are created and deleted, and it is deleted
+	// along with the cluster. The backups themselves are kept.
+	// +optional
+	Backup *BackupPolicy `json:"backup,omitempty"`
+}
+
+// NodeAccess configures SSH access to the Proxmox nodes, for operations which the Proxmox API
+// does not support for all storages, like writing files to the directories of local storages.
+type NodeAccess struct {
+	// SecretRef is a secret in the namespace of the ProxmoxCluster. The key ssh-privatekey holds the
+	// private key, and the key known_hosts the host keys of the nodes, which are verified strictly.
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+
+	// User is the user on the nodes, which must be able to run pvesm. Defaults to root.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// Port is the SSH port of the nodes. Defaults to 22.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Addresses are the hostnames or IP addresses of the nodes by their name.
+	// Nodes without an address are reached by their name.
+	// +optional
+	Addresses map[string]string `json:"addresses,omitempty"`
+}
+
+// NodeIPPool defines the IP pools of the default network devices of machines on a set of Proxmox nodes.
+// A pool can only define an address family which the cluster defines as well, so machines get
+// the same address families on every node.
+type NodeIPPool struct {
+	// Name identifies the pool, for example by the rack of the nodes.
+	// It is part of the names of the InClusterIPPools created for the pool.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=32
+	Name string `json:"name"`
+
+	// Nodes are the Proxmox nodes whose machines use the pool.
+	// +kubebuilder:validation:MinItems=1
+	Nodes []string `json:"nodes"`
+
+	// IPv4Config contains the IPv4 addresses and the gateway of the nodes.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.addresses.size() > 0",message="IPv4Config addresses must be provided"
+	IPv4Config *ipamicv1.InClusterIPPoolSpec `json:"ipv4Config,omitempty"`
+
+	// IPv6Config contains the IPv6 addresses and the gateway of the nodes.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.addresses.size() > 0",message="IPv6Config addresses must be provided"
+	IPv6Config *ipamicv1.InClusterIPPoolSpec `json:"ipv6Config,omitempty"`
+}
+
+// DHCPMode selects the address families which the default network devices configure by DHCP.
+type DHCPMode string
+
+const (
+	// DHCPModeIPv4 configures IPv4 addresses by DHCP.
+	DHCPModeIPv4 DHCPMode = "IPv4"
+
+	// DHCPModeIPv6 configures IPv6 addresses by DHCPv6.
+	DHCPModeIPv6 DHCPMode = "IPv6"
+
+	// DHCPModeDualStack configures IPv4 and IPv6 addresses by DHCP.
+	DHCPModeDualStack DHCPMode = "DualStack"
+)
+
+// Config returns the pool config of an address family, or nil if the pool does not define it.
+func (p *NodeIPPool) Config(format string) *ipamicv1.InClusterIPPoolSpec {
+	if format == IPV6Format {
+		return p.IPv6Config
+	}
+	return p.IPv4Config
+}
+
+// BackupPolicy defines the scheduled backups of the VMs of a cluster.
+type BackupPolicy struct {
+	// Schedule is a Proxmox VE calendar event, like daily or "mon..fri 02:30".
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Storage is the storage the backups are written to. It must support the content type backup.
+	// +kubebuilder:validation:MinLength=1
+	Storage string `json:"storage"`
+
+	// Mode defines how the VMs are treated while they are backed up.
+	// +kubebuilder:validation:Enum=snapshot;suspend;stop
+	// +kubebuilder:default=snapshot
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// Retention defines how many backups are kept.
+	// Defaults to the retention of the storage.
+	// +optional
+	Retention *BackupRetention `json:"retention,omitempty"`
+}
+
+// BackupRetention defines how many backups of each VM are kept, like the prune options of Proxmox VE.
+// +kubebuilder:validation:MinProperties=1
+type BackupRetention struct {
+	// KeepLast is the number of the most recent backups which are kept.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	KeepLast *int32 `json:"keepLast,omitempty"`
+
+	// KeepDaily is the number of days for which the last backup is kept.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	KeepDaily *int32 `json:"keepDaily,omitempty"`
+
+	// KeepWeekly is the number of weeks for which the last backup is kept.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	KeepWeekly *int32 `json:"keepWeekly,omitempty"`
+
+	// KeepMonthly is the number of months for which the last backup is kept.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	KeepMonthly *int32 `json:"keepMonthly,omitempty"`
+
+	// KeepYearly is the number of years for which the last backup is kept.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	KeepYearly *int32 `json:"keepYearly,omitempty"`
+}
+
+// SDNSpec defines the VNet of a cluster in the Proxmox VE software-defined network.
+type SDNSpec struct {
+	// Zone is the SDN zone of the VNet. The zone must exist.
+	// +kubebuilder:validation:MinLength=1
+	Zone string `json:"zone"`
+
+	// VNet is the name of the VNet. If it does not exist, it is created with the subnets
+	// of ipv4Config and ipv6Config, and it is deleted along with the cluster.
+	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9]{0,7}$`
+	VNet string `json:"vnet"`
+
+	// Tag is the VLAN or VXLAN ID of a created VNet. It is required by vlan, qinq and vxlan zones.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=16777215
+	// +optional
+	Tag *int32 `json:"tag,omitempty"`
+}
+
+// MachineDefaults defines defaults for the ProxmoxMachines of a cluster.
+type MachineDefaults struct {
+	// SourceNode is the node of the template VM.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	SourceNode string `json:"sourceNode,omitempty"`
+
+	// TemplateID is the vmid of the template VM.
+	// +optional
+	TemplateID *int32 `json:"templateID,omitempty"`
+
+	// Storage is the storage for full clones.
+	// +optional
+	Storage *string `json:"storage,omitempty"`
+
+	// Bridge is the network bridge of the default network device.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Bridge string `json:"bridge,omitempty"`
+
+	// BootVolume is the size of the boot volume.
+	// +optional
+	BootVolume *DiskSize `json:"bootVolume,omitempty"`
+
+	// Tags are added to the VMs.
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9_][a-z0-9_+.-]*$`
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// VMNameTemplate is the template for the names of the VMs in Proxmox.
+	// +optional
+	VMNameTemplate *string `json:"vmNameTemplate,omitempty"`
+
+	// Firewall configures the Proxmox VE firewall of the VMs.
+	// +optional
+	Firewall *FirewallSpec `json:"firewall,omitempty"`
+}
+
+// ApplyTo sets the defaults on all fields of the machine which are not set.
+func (d *MachineDefaults) ApplyTo(m *ProxmoxMachine) {
+	if d == nil {
+		return
+	}
+
+	spec := &m.Spec
+	if spec.SourceNode == "" {
+		spec.SourceNode = d.SourceNode
+	}
+	if spec.TemplateID == nil && spec.Image == nil && d.TemplateID != nil {
+		spec.TemplateID = ptr.To(*d.TemplateID)
+	}
+	if spec.Storage == nil && d.Storage != nil {
+		spec.Storage = ptr.To(*d.Storage)
+	}
+	if d.Bridge != "" && (spec.Network == nil || spec.Network.Default == nil) {
+		if spec.Network == nil {
+			spec.Network = &NetworkSpec{}
+		}
+		// the model is left to the default of the guest OS.
+		spec.Network.Default = &NetworkDevice{Bridge: d.Bridge}
+	}
+	if d.BootVolume != nil && (spec.Disks == nil || spec.Disks.BootVolume == nil) {
+		if spec.Disks == nil {
+			spec.Disks = &Storage{}
+		}
+		spec.Disks.BootVolume = d.BootVolume.DeepCopy()
+	}
+	if len(spec.Tags) == 0 && len(d.Tags) > 0 {
+		spec.Tags = append([]string(nil), d.Tags...)
+	}
+	if spec.VMNameTemplate == nil && d.VMNameTemplate != nil {
+		spec.VMNameTemplate = ptr.To(*d.VMNameTemplate)
+	}
+	if spec.Firewall == nil && d.Firewall != nil {
+		spec.Firewall = d.Firewall.DeepCopy()
+	}
+}
+
+// MachineSize defines the compute resources and the boot volume of a size of machines.
+type MachineSize struct {
+	// Name is the size which ProxmoxMachines select, for example small, medium or large.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// NumSockets is the number of CPU sockets of the machines.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumSockets int32 `json:"numSockets,omitempty"`
+
+	// NumCores is the number of cores per CPU socket of the machines.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumCores int32 `json:"numCores,omitempty"`
+
+	// MemoryMiB is the size of the memory of the machines, in MiB.
+	// +kubebuilder:validation:MultipleOf=8
+	// +optional
+	MemoryMiB int32 `json:"memoryMiB,omitempty"`
+
+	// BootVolume is the size of the boot volume of the machines.
+	// +optional
+	BootVolume *DiskSize `json:"bootVolume,omitempty"`
+}
+
+// ApplyTo sets the resources of the size on all fields of the machine which are not set.
+func (s *MachineSize) ApplyTo(m *ProxmoxMachine) {
+	if s == nil {
+		return
+	}
+
+	spec := &m.Spec
+	if spec.NumSockets == 0 {
+		spec.NumSockets = s.NumSockets
+	}
+	if spec.NumCores == 0 {
+		spec.NumCores = s.NumCores
+	}
+	if spec.MemoryMiB == 0 {
+		spec.MemoryMiB = s.MemoryMiB
+	}
+	if s.BootVolume != nil && (spec.Disks == nil || spec.Disks.BootVolume == nil) {
+		if spec.Disks == nil {
+			spec.Disks = &Storage{}
+		}
+		spec.Disks.BootVolume = s.BootVolume.DeepCopy()
+	}
+}
+
+// ProxyConfig defines the HTTP proxy settings of machines.
+// +kubebuilder:validation:XValidation:rule="has(self.httpProxy) || has(self.httpsProxy)",message="at least one of httpProxy or httpsProxy must be set"
+type ProxyConfig struct {
+	// HTTPProxy is the proxy used for HTTP requests, e.g. `http://proxy.example.com:3128`.
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the proxy used for HTTPS requests, e.g. `http://proxy.example.com:3128`.
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy lists the hosts, domains and CIDRs which are accessed without the proxy.
+	// This should contain the control plane endpoint, as well as the pod and service CIDRs.
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// NodeSelector selects Proxmox nodes dynamically. A node is selected
+// if it matches all of the given criteria.
+// +kubebuilder:validation:XValidation:rule="has(self.pool) || has(self.tags) || has(self.cpuModel) || has(self.minMemoryMiB)",message="at least one selection criterion must be set"
+type NodeSelector struct {
+	// Pool selects all nodes hosting a member of the given Proxmox resource pool.
+	// Members can be VMs, containers or storages. Adding the local storage of a
+	// node to the pool makes the node eligible.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Pool string `json:"pool,omitempty"`
+
+	// Tags selects nodes having all of the given tags.
+	// As Proxmox does not support tags on nodes, the tags are read from
+	// the notes of a node, where a line `tags: gpu;ssd` defines its tags.
+	// +kubebuilder:validation:MinItems=1
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// CPUModel selects nodes whose CPU model contains the given value, e.g. `EPYC`.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	CPUModel string `json:"cpuModel,omitempty"`
+
+	// MinMemoryMiB selects nodes with at least the given amount of total memory.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinMemoryMiB int32 `json:"minMemoryMiB,omitempty"`
+}
+
+// HasNodeAttributes returns true if the selector filters nodes by their attributes.
+func (s *NodeSelector) HasNodeAttributes() bool {
+	return len(s.Tags) > 0 || s.CPUModel != "" || s.MinMemoryMiB > 0
+}
+
+// HasNodeSelection returns true if the cluster selects nodes to schedule VMs on,
+// either statically or by a selector.
+func (c *ProxmoxCluster) HasNodeSelection() bool {
+	return len(c.Spec.AllowedNodes) > 0 || c.Spec.AllowedNodesSelector != nil
+}
+
+// UsesDHCP returns true if the default network devices of the machines configure
+// the addresses of the address family by DHCP.
+func (c *ProxmoxCluster) UsesDHCP(format string) bool {
+	switch c.Spec.DHCPMode {
+	case DHCPModeDualStack:
+		return true
+	case DHCPModeIPv6:
+		return format == IPV6Format
+	case DHCPModeIPv4:
+		return format == IPV4Format
+	}
+	return false
+}
+
+// NodeIPPoolOf returns the node IP pool which defines the addresses of an address family
+// for machines on the node, or nil if they use the pool of the cluster.
+func (c *ProxmoxCluster) NodeIPPoolOf(node, format string) *NodeIPPool {
+	for i := range c.Spec.NodeIPPools {
+		pool := &c.Spec.NodeIPPools[i]
+		if pool.Config(format) == nil {
+			continue
+		}
+		for _, n := range pool.Nodes {
+			if n == node {
+				return pool
+			}
+		}
+	}
+	return nil
+}
+
+// AdditionalIPPoolConfigs returns the additional pool configs of an address family.
+func (c *ProxmoxCluster) AdditionalIPPoolConfigs(format string) []ipamicv1.InClusterIPPoolSpec {
+	if format == IPV6Format {
+		return c.Spec.AdditionalIPv6Configs
+	}
+	return c.Spec.AdditionalIPv4Configs
+}
+
+// MachineSize returns the machine size of the given name, or nil if the cluster does not define it.
+func (c *ProxmoxCluster) MachineSize(name string) *MachineSize {
+	for i := range c.Spec.MachineSizes {
+		if c.Spec.MachineSizes[i].Name == name {
+			return &c.Spec.MachineSizes[i]
+		}
+	}
+	return nil
+}
+
+// MemoryAccounting defines how the memory of existing VMs is counted
+// against the capacity of a Proxmox node.
+// +kubebuilder:validation:Enum=MaxMemory;BalloonMinimum;Usage
+type MemoryAccounting string
+
+const (
+	// MemoryAccountingMaxMemory counts the maximum memory of every VM.
+	MemoryAccountingMaxMemory MemoryAccounting = "MaxMemory"
+
+	// MemoryAccountingBalloonMinimum counts the balloon minimum of every VM.
+	// VMs without ballooning are counted with their maximum memory.
+	MemoryAccountingBalloonMinimum MemoryAccounting = "BalloonMinimum"
+
+	// MemoryAccountingUsage counts the memory actually used by running VMs.
+	// VMs which are not running are counted with their maximum memory.
+	MemoryAccountingUsage MemoryAccounting = "Usage"
+)
+
+// SchedulerHints allows to pass the scheduler instructions on how
+// the resources of the Proxmox nodes should be accounted.
+type SchedulerHints struct {
+	// MemoryAccounting defines which memory value of existing VMs is
+	// subtracted from a node's memory to determine whether a new VM fits.
+	// Ballooned environments can use BalloonMinimum or Usage to avoid
+	// nodes being declared full while memory is still available.
+	// +kubebuilder:default=MaxMemory
+	// +optional
+	MemoryAccounting MemoryAccounting `json:"memoryAccounting,omitempty"`
+
+	// InterruptibleMemoryOvercommit is the percentage of the memory of a node which interruptible
+	// machines may reserve in addition to it. Regular machines are only scheduled on nodes with
+	// memory left, so they avoid the nodes overcommitted by interruptible machines. Defaults to 100,
+	// which lets interruptible machines reserve twice the memory of a node.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	InterruptibleMemoryOvercommit *int32 `json:"interruptibleMemoryOvercommit,omitempty"`
+}
+
+// DefaultInterruptibleMemoryOvercommit is the default percentage by which interruptible machines
+// may overcommit the memory of a node.
+const DefaultInterruptibleMemoryOvercommit = 100
+
+// GetMemoryAccounting returns the configured memory accounting mode,
+// defaulting to MemoryAccountingMaxMemory.
+func (sh *SchedulerHints) GetMemoryAccounting() MemoryAccounting {
+	if sh == nil || sh.MemoryAccounting == "" {
+		return MemoryAccountingMaxMemory
+	}
+	return sh.MemoryAccounting
+}
+
+// GetInterruptibleMemoryOvercommit returns the configured memory overcommit of interruptible machines,
+// defaulting to DefaultInterruptibleMemoryOvercommit.
+func (sh *SchedulerHints) GetInterruptibleMemoryOvercommit() int32 {
+	if sh == nil || sh.InterruptibleMemoryOvercommit == nil {
+		return DefaultInterruptibleMemoryOvercommit
+	}
+	return *sh.InterruptibleMemoryOvercommit
 }
 
 // ProxmoxClusterStatus defines the observed state of ProxmoxCluster.
@@ -79,19 +609,109 @@ type ProxmoxClusterStatus struct {
 	// +optional
 	NodeLocations *NodeLocations `json:"nodeLocations,omitempty"`
 
+	// Nodes summarizes the state and the allocated resources of the Proxmox nodes
+	// machines of the cluster can be scheduled on. It is refreshed periodically.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Nodes []NodeStatus `json:"nodes,omitempty"`
+
+	// NodesRefreshTime is the time the node summaries were last refreshed.
+	// +optional
+	NodesRefreshTime *metav1.Time `json:"nodesRefreshTime,omitempty"`
+
+	// DetachedVolumes are the volumes of data disks which were kept when their machine was deleted,
+	// and which are not attached to a replacement machine yet.
+	// +optional
+	DetachedVolumes []DetachedVolume `json:"detachedVolumes,omitempty"`
+
 	// Conditions defines current service state of the ProxmoxCluster.
+	// They are kept during the deprecation of the v1beta1 conditions, which are replaced by V1Beta2.Conditions.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// V1Beta2 groups the fields of the status according to the v1beta2 contract of Cluster API.
+	// +optional
+	V1Beta2 *ProxmoxClusterV1Beta2Status `json:"v1beta2,omitempty"`
+}
+
+// ProxmoxClusterV1Beta2Status groups the fields of the status of a ProxmoxCluster according to the v1beta2 contract of Cluster API.
+type ProxmoxClusterV1Beta2Status struct {
+	// Conditions represent the observations of the current state of the ProxmoxCluster.
+	// The Ready condition summarizes them, and each condition records the generation it was observed at.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DetachedVolume is the volume of a data disk of a deleted machine.
+type DetachedVolume struct {
+	// Group is the MachineDeployment or the control plane of the deleted machine,
+	// or the name of the machine if it belonged to neither.
+	Group string `json:"group"`
+
+	// Disk is the name of the disk device the volume was attached to.
+	Disk string `json:"disk"`
+
+	// Volume is the ID of the volume, in the format storage:name.
+	Volume string `json:"volume"`
+
+	// Node is the Proxmox node of the deleted machine.
+	Node string `json:"node"`
+}
+
+// NodeStatus summarizes the state and the allocated resources of a Proxmox node.
+type NodeStatus struct {
+	// Name is the name of the node.
+	Name string `json:"name"`
+
+	// Online indicates that the node is part of the quorate Proxmox cluster.
+	// The other fields are only reported for online nodes.
+	Online bool `json:"online"`
+
+	// Version is the Proxmox VE version of the node.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// CPUs is the number of logical CPUs of the node.
+	// +optional
+	CPUs int32 `json:"cpus,omitempty"`
+
+	// AllocatedCPUs is the sum of the vCPUs of all VMs on the node.
+	// +optional
+	AllocatedCPUs int32 `json:"allocatedCPUs,omitempty"`
+
+	// MemoryMiB is the total memory of the node.
+	// +optional
+	MemoryMiB int64 `json:"memoryMiB,omitempty"`
+
+	// AllocatedMemoryMiB is the sum of the maximum memory of all VMs on the node.
+	// +optional
+	AllocatedMemoryMiB int64 `json:"allocatedMemoryMiB,omitempty"`
 }
 
 // NodeLocations holds information about the deployment state of
 // control plane and worker nodes in Proxmox.
 type NodeLocations struct {
-	// ControlPlane contains all deployed control plane nodes
+	// ControlPlaneMachines maps the names of all deployed control plane
+	// machines to the Proxmox node of their VM.
+	// +optional
+	ControlPlaneMachines map[string]string `json:"controlPlaneMachines,omitempty"`
+
+	// WorkerMachines maps the names of all deployed worker
+	// machines to the Proxmox node of their VM.
+	// +optional
+	WorkerMachines map[string]string `json:"workerMachines,omitempty"`
+
+	// ControlPlane contains the control plane nodes recorded by earlier versions.
+	// Deprecated: the entries are moved to ControlPlaneMachines when the ProxmoxCluster is reconciled.
 	// +optional
 	ControlPlane []NodeLocation `json:"controlPlane,omitempty"`
 
-	// Workers contains all deployed worker nodes
+	// Workers contains the worker nodes recorded by earlier versions.
+	// Deprecated: the entries are moved to WorkerMachines when the ProxmoxCluster is reconciled.
 	// +optional
 	Workers []NodeLocation `json:"workers,omitempty"`
 }
@@ -118,7 +738,8 @@ type ProxmoxCluster struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	// +kubebuilder:validation:XValidation:rule="self.ipv4Config != null || self.ipv6Config != null",message="at least one ip config must be set, either ipv4Config or ipv6Config"
+	// +kubebuilder:validation:XValidation:rule="has(self.ipv4Config) || has(self.ipv6Config) || has(self.dhcpMode)",message="at least one ip config must be set, either ipv4Config or ipv6Config, unless dhcpMode is set"
+	// +kubebuilder:validation:XValidation:rule="!has(self.dhcpMode) || (!has(self.ipv4Config) && !has(self.ipv6Config) && !has(self.nodeIPPools))",message="dhcpMode is mutually exclusive with ipv4Config, ipv6Config and nodeIPPools"
 	Spec   ProxmoxClusterSpec   `json:"spec,omitempty"`
 	Status ProxmoxClusterStatus `json:"status,omitempty"`
 }
@@ -142,6 +763,22 @@ func (c *ProxmoxCluster) SetConditions(conditions clusterv1.Conditions) {
 	c.Status.Conditions = conditions
 }
 
+// GetV1Beta2Conditions returns the v1beta2 conditions of the ProxmoxCluster.
+func (c *ProxmoxCluster) GetV1Beta2Conditions() []metav1.Condition {
+	if c.Status.V1Beta2 == nil {
+		return nil
+	}
+	return c.Status.V1Beta2.Conditions
+}
+
+// SetV1Beta2Conditions sets the v1beta2 conditions of the ProxmoxCluster.
+func (c *ProxmoxCluster) SetV1Beta2Conditions(conditions []metav1.Condition) {
+	if c.Status.V1Beta2 == nil {
+		c.Status.V1Beta2 = &ProxmoxClusterV1Beta2Status{}
+	}
+	c.Status.V1Beta2.Conditions = conditions
+}
+
 // SetInClusterIPPoolRef will set the reference to the provided InClusterIPPool.
 // If nil was provided, the status field will be cleared.
 func (c *ProxmoxCluster) SetInClusterIPPoolRef(pool *ipamicv1.InClusterIPPool) {
@@ -167,44 +804,87 @@ func (c *ProxmoxCluster) SetInClusterIPPoolRef(pool *ipamicv1.InClusterIPPool) {
 	}
 }
 
-// AddNodeLocation will add a node location to either the control plane or worker
-// node locations based on the `isControlPlane` parameter.
-func (c *ProxmoxCluster) AddNodeLocation(loc NodeLocation, isControlPlane bool) {
+// GetNodeLocations returns the Proxmox nodes of either the control plane or worker
+// machines based on the `isControlPlane` parameter, keyed by machine name.
+// The returned map belongs to the status and must not be modified, use
+// UpdateNodeLocation and RemoveNodeLocation instead.
+func (c *ProxmoxCluster) GetNodeLocations(isControlPlane bool) map[string]string {
 	if c.Status.NodeLocations == nil {
-		c.Status.NodeLocations = new(NodeLocations)
+		return nil
 	}
 
-	if !c.HasMachine(loc.Machine.Name, isControlPlane) {
-		c.addNodeLocation(loc, isControlPlane)
+	if isControlPlane {
+		return c.Status.NodeLocations.ControlPlaneMachines
 	}
+	return c.Status.NodeLocations.WorkerMachines
 }
 
-// RemoveNodeLocation removes a node location from the status.
-func (c *ProxmoxCluster) RemoveNodeLocation(machineName string, isControlPlane bool) {
-	nodeLocations := c.Status.NodeLocations
+// GetVolumeOwnerID returns the VMID which owns the volumes of data disks with the Detach deletion policy.
+func (c *ProxmoxCluster) GetVolumeOwnerID() int64 {
+	if c.Spec.VolumeOwnerID == 0 {
+		return DefaultVolumeOwnerID
+	}
+	return int64(c.Spec.VolumeOwnerID)
+}
 
-	if nodeLocations == nil {
-		return
+// AddDetachedVolume records a detached volume in the status, unless it is recorded already.
+// It returns true if the volume was added.
+func (c *ProxmoxCluster) AddDetachedVolume(volume DetachedVolume) bool {
+	for _, v := range c.Status.DetachedVolumes {
+		if v.Volume == volume.Volume {
+			return false
+		}
 	}
+	c.Status.DetachedVolumes = append(c.Status.DetachedVolumes, volume)
+	return true
+}
+
+// RemoveDetachedVolume removes a detached volume from the status, once it is attached again.
+func (c *ProxmoxCluster) RemoveDetachedVolume(volume string) {
+	volumes := c.Status.DetachedVolumes[:0]
+	for _, v := range c.Status.DetachedVolumes {
+		if v.Volume != volume {
+			volumes = append(volumes, v)
+		}
+	}
+	c.Status.DetachedVolumes = volumes
+}
 
-	if !c.HasMachine(machineName, isControlPlane) {
+// RemoveNodeLocation removes a node location from the status.
+func (c *ProxmoxCluster) RemoveNodeLocation(machineName string, isControlPlane bool) {
+	if c.Status.NodeLocations == nil {
 		return
 	}
 
 	if isControlPlane {
-		for i, v := range nodeLocations.ControlPlane {
-			if v.Machine.Name == machineName {
-				nodeLocations.ControlPlane = append(nodeLocations.ControlPlane[:i], nodeLocations.ControlPlane[i+1:]...)
-			}
-		}
+		delete(c.Status.NodeLocations.ControlPlaneMachines, machineName)
 		return
 	}
+	delete(c.Status.NodeLocations.WorkerMachines, machineName)
+}
+
+// MigrateNodeLocations moves the node locations recorded in the deprecated lists by earlier versions
+// to the maps keyed by machine name. Locations in the maps take precedence.
+// The function returns true if locations were moved.
+func (c *ProxmoxCluster) MigrateNodeLocations() bool {
+	locations := c.Status.NodeLocations
+	if locations == nil || (len(locations.ControlPlane) == 0 && len(locations.Workers) == 0) {
+		return false
+	}
 
-	for i, v := range nodeLocations.Workers {
-		if v.Machine.Name == machineName {
-			nodeLocations.Workers = append(nodeLocations.Workers[:i], nodeLocations.Workers[i+1:]...)
+	for _, loc := range locations.ControlPlane {
+		if _, ok := locations.ControlPlaneMachines[loc.Machine.Name]; !ok {
+			c.UpdateNodeLocation(loc.Machine.Name, loc.Node, true)
 		}
 	}
+	for _, loc := range locations.Workers {
+		if _, ok := locations.WorkerMachines[loc.Machine.Name]; !ok {
+			c.UpdateNodeLocation(loc.Machine.Name, loc.Node, false)
+		}
+	}
+	locations.ControlPlane = nil
+	locations.Workers = nil
+	return true
 }
 
 // UpdateNodeLocation will update the node location based on the provided machine name.
@@ -212,32 +892,24 @@ func (c *ProxmoxCluster) RemoveNodeLocation(machineName string, isControlPlane b
 //
 // The function returns true if the value was added or updated, otherwise false.
 func (c *ProxmoxCluster) UpdateNodeLocation(machineName, node string, isControlPlane bool) bool {
-	if !c.HasMachine(machineName, isControlPlane) {
-		loc := NodeLocation{
-			Node:    node,
-			Machine: corev1.LocalObjectReference{Name: machineName},
-		}
-		c.AddNodeLocation(loc, isControlPlane)
-		return true
+	if current, ok := c.GetNodeLocations(isControlPlane)[machineName]; ok && current == node {
+		return false
 	}
 
-	locations := c.Status.NodeLocations.Workers
-	if isControlPlane {
-		locations = c.Status.NodeLocations.ControlPlane
+	if c.Status.NodeLocations == nil {
+		c.Status.NodeLocations = new(NodeLocations)
 	}
 
-	for i, loc := range locations {
-		if loc.Machine.Name == machineName {
-			if loc.Node != node {
-				locations[i].Node = node
-				return true
-			}
-
-			return false
-		}
+	locations := &c.Status.NodeLocations.WorkerMachines
+	if isControlPlane {
+		locations = &c.Status.NodeLocations.ControlPlaneMachines
+	}
+	if *locations == nil {
+		*locations = make(map[string]string)
 	}
 
-	return false
+	(*locations)[machineName] = node
+	return true
 }
 
 // HasMachine returns if true if a machine was found on any node.
@@ -247,34 +919,7 @@ func (c *ProxmoxCluster) HasMachine(machineName string, isControlPlane bool) boo
 
 // GetNode tries to return the Proxmox node for the provided machine name.
 func (c *ProxmoxCluster) GetNode(machineName string, isControlPlane bool) string {
-	if c.Status.NodeLocations == nil {
-		return ""
-	}
-
-	if isControlPlane {
-		for _, cpl := range c.Status.NodeLocations.ControlPlane {
-			if cpl.Machine.Name == machineName {
-				return cpl.Node
-			}
-		}
-	} else {
-		for _, wloc := range c.Status.NodeLocations.Workers {
-			if wloc.Machine.Name == machineName {
-				return wloc.Node
-			}
-		}
-	}
-
-	return ""
-}
-
-func (c *ProxmoxCluster) addNodeLocation(loc NodeLocation, isControlPlane bool) {
-	if isControlPlane {
-		c.Status.NodeLocations.ControlPlane = append(c.Status.NodeLocations.ControlPlane, loc)
-		return
-	}
-
-	c.Status.NodeLocations.Workers = append(c.Status.NodeLocations.Workers, loc)
+	return c.GetNodeLocations(isControlPlane)[machineName]
 }
 
 func init() {
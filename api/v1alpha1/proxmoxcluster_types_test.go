@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	ipamicv1 "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -36,41 +37,32 @@ func TestUpdateNodeLocation(t *testing.T) {
 
 	res := cl.UpdateNodeLocation("new", "n1", false)
 	require.NotNil(t, cl.Status.NodeLocations)
-	require.Len(t, cl.Status.NodeLocations.Workers, 1)
+	require.Equal(t, map[string]string{"new": "n1"}, cl.Status.NodeLocations.WorkerMachines)
 	require.True(t, res)
 
-	locs := &NodeLocations{
-		Workers: []NodeLocation{
-			{
-				Machine: corev1.LocalObjectReference{Name: "m1"},
-				Node:    "n1",
-			},
-			{
-				Machine: corev1.LocalObjectReference{Name: "m2"},
-				Node:    "n2",
-			},
-			{
-				Machine: corev1.LocalObjectReference{Name: "m3"},
-				Node:    "n3",
-			},
-		},
+	cl.Status.NodeLocations = &NodeLocations{
+		WorkerMachines: map[string]string{"m1": "n1", "m2": "n2", "m3": "n3"},
 	}
 
-	cl.Status.NodeLocations = locs
-
 	res = cl.UpdateNodeLocation("m1", "n2", false)
 	require.True(t, res)
-	require.Len(t, cl.Status.NodeLocations.Workers, 3)
-	require.Equal(t, cl.Status.NodeLocations.Workers[0].Node, "n2")
+	require.Len(t, cl.Status.NodeLocations.WorkerMachines, 3)
+	require.Equal(t, "n2", cl.GetNode("m1", false))
 
 	res = cl.UpdateNodeLocation("m4", "n4", false)
 	require.True(t, res)
-	require.Len(t, cl.Status.NodeLocations.Workers, 4)
-	require.Equal(t, cl.Status.NodeLocations.Workers[3].Node, "n4")
+	require.Len(t, cl.Status.NodeLocations.WorkerMachines, 4)
+	require.Equal(t, "n4", cl.GetNode("m4", false))
 
 	res = cl.UpdateNodeLocation("m2", "n2", false)
 	require.False(t, res)
-	require.Len(t, cl.Status.NodeLocations.Workers, 4)
+	require.Len(t, cl.Status.NodeLocations.WorkerMachines, 4)
+
+	// control plane and worker machines are tracked separately.
+	res = cl.UpdateNodeLocation("m2", "n2", true)
+	require.True(t, res)
+	require.Equal(t, map[string]string{"m2": "n2"}, cl.Status.NodeLocations.ControlPlaneMachines)
+	require.Len(t, cl.Status.NodeLocations.WorkerMachines, 4)
 }
 
 func defaultCluster() *ProxmoxCluster {
@@ -116,6 +108,19 @@ var _ = Describe("ProxmoxCluster Test", func() {
 			dc.Spec.IPv4Config = nil
 			Expect(k8sClient.Create(context.Background(), dc)).Should(MatchError(ContainSubstring("at least one ip config must be set")))
 		})
+
+		It("Should allow clusters using DHCP without ip config", func() {
+			dc := defaultCluster()
+			dc.Spec.IPv4Config = nil
+			dc.Spec.DHCPMode = DHCPModeIPv4
+			Expect(k8sClient.Create(context.Background(), dc)).To(Succeed())
+		})
+
+		It("Should not allow DHCP together with an ip config", func() {
+			dc := defaultCluster()
+			dc.Spec.DHCPMode = DHCPModeDualStack
+			Expect(k8sClient.Create(context.Background(), dc)).Should(MatchError(ContainSubstring("dhcpMode is mutually exclusive")))
+		})
 	})
 
 	It("Should not allow empty DNS servers", func() {
@@ -153,39 +158,154 @@ var _ = Describe("ProxmoxCluster Test", func() {
 	})
 })
 
+func TestMachineDefaultsApplyTo(t *testing.T) {
+	defaults := &MachineDefaults{
+		SourceNode:     "pve1",
+		TemplateID:     ptr.To[int32](100),
+		Storage:        ptr.To("local-lvm"),
+		Bridge:         "vmbr0",
+		BootVolume:     &DiskSize{Disk: "scsi0", SizeGB: 50},
+		Tags:           []string{"k8s"},
+		VMNameTemplate: ptr.To("{{.ClusterName}}-{{.Random}}"),
+		Firewall:       &FirewallSpec{Enabled: true, SecurityGroups: []string{"k8s"}},
+	}
+
+	m := &ProxmoxMachine{Spec: ProxmoxMachineSpec{
+		VirtualMachineCloneSpec: VirtualMachineCloneSpec{TemplateID: ptr.To[int32](200)},
+		Tags:                    []string{"gpu"},
+	}}
+	defaults.ApplyTo(m)
+
+	require.Equal(t, "pve1", m.Spec.SourceNode)
+	require.Equal(t, int32(200), *m.Spec.TemplateID)
+	require.Equal(t, "local-lvm", *m.Spec.Storage)
+	require.Equal(t, &NetworkDevice{Bridge: "vmbr0"}, m.Spec.Network.Default)
+	require.Equal(t, int32(50), m.Spec.Disks.BootVolume.SizeGB)
+	require.Equal(t, []string{"gpu"}, m.Spec.Tags)
+	require.Equal(t, "{{.ClusterName}}-{{.Random}}", *m.Spec.VMNameTemplate)
+	require.Equal(t, defaults.Firewall, m.Spec.Firewall)
+	require.NotSame(t, defaults.Firewall, m.Spec.Firewall)
+
+	// machines created from an image do not clone the default template.
+	m = &ProxmoxMachine{Spec: ProxmoxMachineSpec{
+		VirtualMachineCloneSpec: VirtualMachineCloneSpec{Image: &ImageSource{URL: "https://example.com/noble.qcow2", Storage: "local"}},
+	}}
+	defaults.ApplyTo(m)
+	require.Nil(t, m.Spec.TemplateID)
+
+	// nil defaults leave the machine unchanged.
+	var none *MachineDefaults
+	m = &ProxmoxMachine{}
+	none.ApplyTo(m)
+	require.Equal(t, &ProxmoxMachine{}, m)
+}
+
+func TestMachineSizeApplyTo(t *testing.T) {
+	cluster := &ProxmoxCluster{Spec: ProxmoxClusterSpec{MachineSizes: []MachineSize{
+		{Name: "small", NumSockets: 1, NumCores: 2, MemoryMiB: 4096},
+		{Name: "large", NumSockets: 2, NumCores: 4, MemoryMiB: 16384, BootVolume: &DiskSize{Disk: "scsi0", SizeGB: 100}},
+	}}}
+	require.Nil(t, cluster.MachineSize("medium"))
+
+	large := cluster.MachineSize("large")
+	require.Equal(t, "large", large.Name)
+
+	m := &ProxmoxMachine{Spec: ProxmoxMachineSpec{Size: "large", MemoryMiB: 32768}}
+	large.ApplyTo(m)
+
+	require.Equal(t, int32(2), m.Spec.NumSockets)
+	require.Equal(t, int32(4), m.Spec.NumCores)
+	require.Equal(t, int32(32768), m.Spec.MemoryMiB)
+	require.Equal(t, &DiskSize{Disk: "scsi0", SizeGB: 100}, m.Spec.Disks.BootVolume)
+	require.NotSame(t, large.BootVolume, m.Spec.Disks.BootVolume)
+
+	// an explicit boot volume is kept.
+	m = &ProxmoxMachine{Spec: ProxmoxMachineSpec{Disks: &Storage{BootVolume: &DiskSize{Disk: "scsi0", SizeGB: 20}}}}
+	large.ApplyTo(m)
+	require.Equal(t, int32(20), m.Spec.Disks.BootVolume.SizeGB)
+
+	// a nil size leaves the machine unchanged.
+	var none *MachineSize
+	m = &ProxmoxMachine{}
+	none.ApplyTo(m)
+	require.Equal(t, &ProxmoxMachine{}, m)
+}
+
+func TestUsesDHCP(t *testing.T) {
+	cl := ProxmoxCluster{}
+	require.False(t, cl.UsesDHCP(IPV4Format))
+
+	cl.Spec.DHCPMode = DHCPModeIPv6
+	require.False(t, cl.UsesDHCP(IPV4Format))
+	require.True(t, cl.UsesDHCP(IPV6Format))
+
+	cl.Spec.DHCPMode = DHCPModeDualStack
+	require.True(t, cl.UsesDHCP(IPV4Format))
+	require.True(t, cl.UsesDHCP(IPV6Format))
+}
+
 func TestRemoveNodeLocation(t *testing.T) {
-	cl := ProxmoxCluster{
-		Status: ProxmoxClusterStatus{NodeLocations: &NodeLocations{
-			Workers: []NodeLocation{
-				{
-					Machine: corev1.LocalObjectReference{Name: "m1"},
-					Node:    "n1",
-				},
-				{
-					Machine: corev1.LocalObjectReference{Name: "m2"},
-					Node:    "n2",
-				},
-				{
-					Machine: corev1.LocalObjectReference{Name: "m3"},
-					Node:    "n3",
-				},
-			},
-		}},
+	cl := ProxmoxCluster{}
+	cl.RemoveNodeLocation("m1", false)
+	require.Nil(t, cl.Status.NodeLocations)
+
+	cl.Status.NodeLocations = &NodeLocations{
+		WorkerMachines: map[string]string{"m1": "n1", "m2": "n2", "m3": "n3"},
 	}
 
 	cl.RemoveNodeLocation("m1", false)
 	require.NotNil(t, cl.Status.NodeLocations)
-	require.Len(t, cl.Status.NodeLocations.Workers, 2)
+	require.Equal(t, map[string]string{"m2": "n2", "m3": "n3"}, cl.Status.NodeLocations.WorkerMachines)
 
 	cl.RemoveNodeLocation("m1", false)
-	require.Len(t, cl.Status.NodeLocations.Workers, 2)
-	require.Equal(t, cl.Status.NodeLocations.Workers[0].Node, "n2")
+	require.Len(t, cl.Status.NodeLocations.WorkerMachines, 2)
 
 	cl.UpdateNodeLocation("m4", "n4", true)
-	require.Len(t, cl.Status.NodeLocations.ControlPlane, 1)
+	require.Len(t, cl.Status.NodeLocations.ControlPlaneMachines, 1)
 
 	cl.RemoveNodeLocation("m4", true)
-	require.Len(t, cl.Status.NodeLocations.ControlPlane, 0)
+	require.Len(t, cl.Status.NodeLocations.ControlPlaneMachines, 0)
+	require.False(t, cl.HasMachine("m4", true))
+}
+
+func TestMigrateNodeLocations(t *testing.T) {
+	cl := ProxmoxCluster{}
+	require.False(t, cl.MigrateNodeLocations())
+
+	// the status was recorded by an earlier version.
+	cl.Status.NodeLocations = &NodeLocations{
+		ControlPlane: []NodeLocation{{Machine: corev1.LocalObjectReference{Name: "cp1"}, Node: "n1"}},
+		Workers: []NodeLocation{
+			{Machine: corev1.LocalObjectReference{Name: "m1"}, Node: "n1"},
+			{Machine: corev1.LocalObjectReference{Name: "m2"}, Node: "n2"},
+		},
+		WorkerMachines: map[string]string{"m2": "n3"},
+	}
+
+	require.True(t, cl.MigrateNodeLocations())
+	require.Equal(t, map[string]string{"cp1": "n1"}, cl.Status.NodeLocations.ControlPlaneMachines)
+	require.Equal(t, map[string]string{"m1": "n1", "m2": "n3"}, cl.Status.NodeLocations.WorkerMachines)
+	require.Empty(t, cl.Status.NodeLocations.ControlPlane)
+	require.Empty(t, cl.Status.NodeLocations.Workers)
+
+	require.False(t, cl.MigrateNodeLocations())
+}
+
+func TestDetachedVolumes(t *testing.T) {
+	cl := ProxmoxCluster{}
+	require.Equal(t, int64(DefaultVolumeOwnerID), cl.GetVolumeOwnerID())
+	cl.Spec.VolumeOwnerID = 5000
+	require.Equal(t, int64(5000), cl.GetVolumeOwnerID())
+
+	volume := DetachedVolume{Group: "workers", Disk: "scsi1", Volume: "local-lvm:vm-5000-m1-scsi1", Node: "n1"}
+	require.True(t, cl.AddDetachedVolume(volume))
+	require.False(t, cl.AddDetachedVolume(volume))
+	require.Len(t, cl.Status.DetachedVolumes, 1)
+
+	cl.RemoveDetachedVolume("local-lvm:vm-5000-m2-scsi1")
+	require.Len(t, cl.Status.DetachedVolumes, 1)
+	cl.RemoveDetachedVolume(volume.Volume)
+	require.Empty(t, cl.Status.DetachedVolumes)
 }
 
 func TestSetInClusterIPPoolRef(t *testing.T) {
@@ -212,3 +332,17 @@ func TestSetInClusterIPPoolRef(t *testing.T) {
 	cl.SetInClusterIPPoolRef(pool)
 	require.Equal(t, cl.Status.InClusterIPPoolRef[0].Name, pool.GetName())
 }
+
+func TestNodeIPPoolOf(t *testing.T) {
+	cl := defaultCluster()
+	cl.Spec.NodeIPPools = []NodeIPPool{
+		{Name: "rack1", Nodes: []string{"pve1", "pve2"}, IPv4Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"10.10.11.0/24"}, Prefix: 24}},
+		{Name: "rack2", Nodes: []string{"pve3"}, IPv6Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"2001:db8::/64"}, Prefix: 64}},
+	}
+
+	require.Equal(t, "rack1", cl.NodeIPPoolOf("pve2", IPV4Format).Name)
+	require.Equal(t, "rack2", cl.NodeIPPoolOf("pve3", IPV6Format).Name)
+	require.Nil(t, cl.NodeIPPoolOf("pve1", IPV6Format))
+	require.Nil(t, cl.NodeIPPoolOf("pve3", IPV4Format))
+	require.Nil(t, cl.NodeIPPoolOf("pve4", IPV4Format))
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// DiskFinalizer allows cleaning up the volume of a ProxmoxDisk before removing it from the apiserver.
+	DiskFinalizer = "proxmoxdisk.infrastructure.cluster.x-k8s.io"
+)
+
+// ProxmoxDiskSpec defines the desired state of ProxmoxDisk.
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Value is immutable"
+type ProxmoxDiskSpec struct {
+	// Node is the Proxmox node the volume is allocated on. Volumes on shared storage
+	// can be attached to VMs on every node, others only to VMs on the same node.
+	// +kubebuilder:validation:MinLength=1
+	Node string `json:"node"`
+
+	// Storage is the storage the volume is allocated on.
+	// +kubebuilder:validation:MinLength=1
+	Storage string `json:"storage"`
+
+	// SizeGB is the size of the volume in gigabyte.
+	// +kubebuilder:validation:Minimum=1
+	SizeGB int32 `json:"sizeGb"`
+
+	// Format is the format of the volume. Only file based storages, like dir or nfs,
+	// support formats other than raw.
+	// +kubebuilder:validation:Enum=raw;qcow2;vmdk
+	// +kubebuilder:default=raw
+	// +optional
+	Format TargetFileStorageFormat `json:"format,omitempty"`
+}
+
+// ProxmoxDiskStatus defines the observed state of ProxmoxDisk.
+type ProxmoxDiskStatus struct {
+	// Ready indicates that the volume is allocated.
+	// +optional
+	Ready bool `json:"ready"`
+
+	// Volume is the ID of the volume, in the format storage:name.
+	// +optional
+	Volume string `json:"volume,omitempty"`
+
+	// Shared indicates that the volume is on shared storage.
+	// +optional
+	Shared bool `json:"shared,omitempty"`
+
+	// AttachedTo is the name of the ProxmoxMachine the volume is attached to.
+	// +optional
+	AttachedTo string `json:"attachedTo,omitempty"`
+
+	// Conditions defines current service state of the ProxmoxDisk.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=proxmoxdisks,scope=Namespaced,categories=proxmox,singular=proxmoxdisk
+//+kubebuilder:printcolumn:name="Node",type="string",JSONPath=".spec.node",description="Proxmox node the volume is allocated on"
+//+kubebuilder:printcolumn:name="Storage",type="string",JSONPath=".spec.storage",description="Storage of the volume"
+//+kubebuilder:printcolumn:name="Size",type="integer",JSONPath=".spec.sizeGb",description="Size of the volume in gigabyte"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Volume is allocated"
+//+kubebuilder:printcolumn:name="Attached_To",type="string",JSONPath=".status.attachedTo",description="ProxmoxMachine the volume is attached to"
+
+// ProxmoxDisk is the Schema for the proxmoxdisks API. It is a volume which does not belong to a VM,
+// so it outlives the machines it is attached to.
+type ProxmoxDisk struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProxmoxDiskSpec   `json:"spec,omitempty"`
+	Status ProxmoxDiskStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProxmoxDiskList contains a list of ProxmoxDisk.
+type ProxmoxDiskList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProxmoxDisk `json:"items"`
+}
+
+// GetConditions returns the observations of the operational state of the ProxmoxDisk resource.
+func (d *ProxmoxDisk) GetConditions() clusterv1.Conditions {
+	return d.Status.Conditions
+}
+
+// SetConditions sets the underlying service state of the ProxmoxDisk to the predescribed clusterv1.Conditions.
+func (d *ProxmoxDisk) SetConditions(conditions clusterv1.Conditions) {
+	d.Status.Conditions = conditions
+}
+
+// CanAttachTo returns true if the volume is allocated, not attached to another machine and
+// can be attached to a VM on the given node.
+func (d *ProxmoxDisk) CanAttachTo(machine, node string) bool {
+	if !d.Status.Ready || !d.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if d.Status.AttachedTo != "" && d.Status.AttachedTo != machine {
+		return false
+	}
+	return d.Status.Shared || d.Spec.Node == node
+}
+
+func init() {
+	SchemeBuilder.Register(&ProxmoxDisk{}, &ProxmoxDiskList{})
+}
@@ -34,6 +34,23 @@ const (
 	// ProxmoxMachine before removing it from the API Server.
 	MachineFinalizer = "proxmoxmachine.infrastructure.cluster.x-k8s.io"
 
+	// DryRunAnnotation makes the controller record the Proxmox operations it would perform for
+	// a ProxmoxMachine in its status, instead of performing them, while it is set to "true".
+	DryRunAnnotation = "infrastructure.cluster.x-k8s.io/dry-run"
+
+	// BootstrapApprovedAnnotation approves the bootstrap of a ProxmoxMachine which requires approval,
+	// while it is set to "true".
+	BootstrapApprovedAnnotation = "infrastructure.cluster.x-k8s.io/bootstrap-approved"
+
+	// PreCloneHookAnnotationPrefix prefixes the annotations of hooks, which hold a ProxmoxMachine
+	// before its VM is cloned while they are set, e.g. `pre-clone.hook.proxmoxmachine.infrastructure.cluster.x-k8s.io/cmdb`.
+	// The external system owning a hook removes its annotation once it finished.
+	PreCloneHookAnnotationPrefix = "pre-clone.hook.proxmoxmachine.infrastructure.cluster.x-k8s.io"
+
+	// PreStartHookAnnotationPrefix prefixes the annotations of hooks, which hold a ProxmoxMachine
+	// after its VM was cloned and configured, before it is started for the first time.
+	PreStartHookAnnotationPrefix = "pre-start.hook.proxmoxmachine.infrastructure.cluster.x-k8s.io"
+
 	// DefaultReconcilerRequeue is the default value for the reconcile retry.
 	DefaultReconcilerRequeue = 10 * time.Second
 
@@ -51,6 +68,10 @@ const (
 )
 
 // ProxmoxMachineSpec defines the desired state of ProxmoxMachine.
+// +kubebuilder:validation:XValidation:rule="!has(self.templateID) || !has(self.image)",message="templateID and image are mutually exclusive"
+// +kubebuilder:validation:XValidation:rule="!has(self.numVCPUs) || !has(self.numSockets) || !has(self.numCores) || self.numVCPUs <= self.numSockets * self.numCores",message="numVCPUs must not exceed numSockets * numCores"
+// +kubebuilder:validation:XValidation:rule="!has(self.smbios) || !has(self.smbios.serial) || !has(self.cloudInitFormat) || self.cloudInitFormat != 'NoCloudNet'",message="smbios.serial cannot be set with the NoCloudNet cloud-init format, which uses the serial"
+// +kubebuilder:validation:XValidation:rule="!has(self.isos) || self.isos.all(iso, iso.device != (has(self.cloudInitDevice) ? self.cloudInitDevice : (has(self.guestOS) && self.guestOS == 'Windows' ? 'sata5' : 'ide0')))",message="isos cannot use the device of the cloud-init ISO"
 type ProxmoxMachineSpec struct {
 	VirtualMachineCloneSpec `json:",inline"`
 
@@ -63,6 +84,12 @@ type ProxmoxMachineSpec struct {
 	// +optional
 	VirtualMachineID *int64 `json:"virtualMachineID,omitempty"`
 
+	// Size selects one of the machine sizes of the ProxmoxCluster, which sets NumSockets, NumCores,
+	// MemoryMiB and the boot volume, unless they are set as well.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Size string `json:"size,omitempty"`
+
 	// NumSockets is the number of CPU sockets in a virtual machine.
 	// Defaults to the property value in the template from which the virtual machine is cloned.
 	// +kubebuilder:validation:Minimum=1
@@ -75,12 +102,42 @@ type ProxmoxMachineSpec struct {
 	// +optional
 	NumCores int32 `json:"numCores,omitempty"`
 
+	// NumVCPUs is the number of vCPUs which are plugged in when the virtual machine starts.
+	// It must not exceed NumSockets * NumCores, the remaining vCPUs can be hotplugged later.
+	// Defaults to all cores of all sockets.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumVCPUs int32 `json:"numVCPUs,omitempty"`
+
+	// CPULimit limits the CPU time of a virtual machine to the given number of cores, e.g. "1.5".
+	// "0" disables the limit. Use it to cap bursty machines on shared nodes.
+	// Defaults to the property value in the template from which the virtual machine is cloned.
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +kubebuilder:validation:XValidation:rule="double(self) <= 128.0",message="cpuLimit must not exceed 128"
+	// +optional
+	CPULimit string `json:"cpuLimit,omitempty"`
+
+	// CPUUnits is the CPU weight of a virtual machine relative to the other VMs on the node.
+	// VMs with a lower weight get less CPU time when the node is under load.
+	// Defaults to the property value in the template from which the virtual machine is cloned.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=262144
+	// +optional
+	CPUUnits int32 `json:"cpuUnits,omitempty"`
+
 	// MemoryMiB is the size of a virtual machine's memory, in MiB.
 	// Defaults to the property value in the template from which the virtual machine is cloned.
 	// +kubebuilder:validation:MultipleOf=8
 	// +optional
 	MemoryMiB int32 `json:"memoryMiB,omitempty"`
 
+	// MemoryHotplug enables hotplug of memory and NUMA in the VM before it is started the first time,
+	// so increases of MemoryMiB, which are applied by the Hotplug or Reboot resize policy, take effect
+	// without a reboot if the guest supports it. Proxmox plugs the memory above 1024MiB in DIMMs of 512MiB,
+	// whose size doubles every 32 DIMMs, so MemoryMiB must be at least 1024 and a whole number of DIMMs.
+	// +optional
+	MemoryHotplug bool `json:"memoryHotplug,omitempty"`
+
 	// Disks contains a set of disk configuration options,
 	// which will be applied before the first startup.
 	//
@@ -90,9 +147,343 @@ type ProxmoxMachineSpec struct {
 	// Network is the network configuration for this machine's VM.
 	// +optional
 	Network *NetworkSpec `json:"network,omitempty"`
+
+	// Firewall configures the Proxmox VE firewall of the VM. It is applied before the VM is first started.
+	// +optional
+	Firewall *FirewallSpec `json:"firewall,omitempty"`
+
+	// Tags are added to the VM. Proxmox tags may only contain
+	// lowercase letters, digits and the characters `+-_.`.
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9_][a-z0-9_+.-]*$`
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// Files are written to the machine by cloud-init in addition to the files
+	// of the bootstrap data. This allows machine specific configuration files,
+	// like registry mirrors or sysctl settings, without changing the bootstrap config.
+	// +listType=map
+	// +listMapKey=path
+	// +optional
+	Files []File `json:"files,omitempty"`
+
+	// ProvisioningRemediation recreates VMs which do not finish provisioning in time,
+	// e.g. because a task died or the cloud-init ISO could not be injected.
+	// +optional
+	ProvisioningRemediation *ProvisioningRemediation `json:"provisioningRemediation,omitempty"`
+
+	// AgentHealthCheck pings the QEMU guest agent of the VM once the machine is ready, and reports
+	// the result in the AgentHealthy condition. The agent must be enabled in the template.
+	// +optional
+	AgentHealthCheck *AgentHealthCheck `json:"agentHealthCheck,omitempty"`
+
+	// CloudInitCheck runs `cloud-init status --wait` through the QEMU guest agent once the VM is running,
+	// and reports the result in the CloudInitCompleted condition, so VMs which never bootstrapped are
+	// detected. The agent must be enabled in the template.
+	// +optional
+	CloudInitCheck *CloudInitCheck `json:"cloudInitCheck,omitempty"`
+
+	// BootstrapLogCollection reads the bootstrap logs of the VM through the QEMU guest agent once its node did not
+	// join the cluster within the deadline, or the cloud-init check failed, and reports their last lines in an event
+	// and the NodeJoined condition. The agent must be enabled in the template.
+	// +optional
+	BootstrapLogCollection *BootstrapLogCollection `json:"bootstrapLogCollection,omitempty"`
+
+	// RequireBootstrapApproval holds the machine once its VM was cloned and configured, before the bootstrap
+	// data is injected and the VM is started, until the ProxmoxMachine is annotated with BootstrapApprovedAnnotation.
+	// +optional
+	RequireBootstrapApproval bool `json:"requireBootstrapApproval,omitempty"`
+
+	// ConfigDriftPolicy defines how changes to the VM config made outside of the provider,
+	// e.g. in the Proxmox UI, are handled once the machine is ready.
+	// Report sets the VMConfigInSync condition to false, Reapply additionally configures
+	// the VM according to the spec again. CPU and memory changes of a running VM
+	// only take effect after a reboot, unless hotplug is enabled for them.
+	// +kubebuilder:default=Report
+	// +optional
+	ConfigDriftPolicy ConfigDriftPolicy `json:"configDriftPolicy,omitempty"`
+
+	// ResizePolicy defines how changes of NumSockets, NumCores, NumVCPUs, CPULimit, CPUUnits and MemoryMiB are applied
+	// to the VM of a ready machine. Disabled does not change the VM. Hotplug applies
+	// the changes to the running VM, which requires hotplug of cpu and memory to be enabled
+	// in the template; changes which cannot be hotplugged are reported as pending.
+	// Reboot additionally reboots the VM if changes are pending.
+	// +kubebuilder:default=Disabled
+	// +optional
+	ResizePolicy ResizePolicy `json:"resizePolicy,omitempty"`
+
+	// PowerState is the desired power state of the VM of a ready machine.
+	// Suspended pauses the VM and keeps its state in memory, Hibernated suspends
+	// the VM to disk and stops it, which frees its memory on the node.
+	// Setting it to Running again resumes the VM. Machines which are not ready yet
+	// are always started, so they can finish provisioning.
+	// Note that the node of a suspended machine becomes NotReady, so the
+	// MachineHealthChecks of the cluster should be paused while it is suspended.
+	// +kubebuilder:default=Running
+	// +optional
+	PowerState PowerState `json:"powerState,omitempty"`
+
+	// CloudInitFormat is the format of the ISO with the cloud-init data of the VM.
+	// ConfigDrive2 is an OpenStack config drive, for images whose cloud-init datasources
+	// do not include NoCloud. NoCloudNet attaches no ISO, the VM fetches the NoCloud data
+	// from the metadata server of the manager, which its SMBIOS serial points to.
+	// The data, including join tokens and certificates, is served over plain HTTP and only
+	// protected by the token in its URL, so the metadata server must only be reachable from
+	// a trusted network.
+	// +kubebuilder:default=NoCloud
+	// +optional
+	CloudInitFormat CloudInitFormat `json:"cloudInitFormat,omitempty"`
+
+	// CloudInitDevice is the IDE or SATA device the cloud-init ISO is attached to.
+	// It defaults to ide0, or sata5 for Windows VMs. Set it if the template uses
+	// the default device already, for example for its disk or a cloud-init drive.
+	// +kubebuilder:validation:Pattern=`^(ide[0-3]|sata[0-5])$`
+	// +optional
+	CloudInitDevice string `json:"cloudInitDevice,omitempty"`
+
+	// GuestOS is the operating system of the VM. Windows VMs are provisioned by cloudbase-init,
+	// which reads the cloud-init data from an OpenStack config drive attached as SATA device,
+	// so the CloudInitFormat is ignored. Their network devices default to the e1000 model,
+	// which Windows supports without additional drivers.
+	// +kubebuilder:default=Linux
+	// +optional
+	GuestOS GuestOS `json:"guestOS,omitempty"`
+
+	// ISOs are additional CD-ROM devices of the VM, for example with virtio drivers for
+	// Windows or package media for airgapped environments. They are attached when the VM
+	// is configured, and ejected from running VMs once Detach is set.
+	// +listType=map
+	// +listMapKey=device
+	// +optional
+	ISOs []ISODevice `json:"isos,omitempty"`
+
+	// VMNameTemplate is a Go template for the name of the VM in Proxmox, to follow naming schemes
+	// of the datacenter. It defaults to the name of the ProxmoxMachine. The template can use
+	// .ClusterName, .MachineName, .Namespace, .Role, which is control-plane or worker, and .Random,
+	// five characters derived from the UID of the ProxmoxMachine, e.g. `{{.ClusterName}}-{{.Role}}-{{.Random}}`.
+	// +optional
+	VMNameTemplate *string `json:"vmNameTemplate,omitempty"`
+
+	// SMBIOS sets fields of the SMBIOS system information of the VM, which asset management
+	// and licensing agents in the guest read. They are set before the VM is started for the first time,
+	// the UUID of the VM is kept.
+	// +optional
+	SMBIOS *SMBIOS `json:"smbios,omitempty"`
+
+	// ExcludeFromBackup leaves the VM out of the backup job of the cluster, e.g. for workers
+	// without state which are replaced rather than restored.
+	// +optional
+	ExcludeFromBackup bool `json:"excludeFromBackup,omitempty"`
+
+	// Interruptible marks the machine as one which may be interrupted, like batch or CI workers.
+	// Its node gets the cluster.x-k8s.io/interruptible label, and the VM can be scheduled on nodes
+	// whose memory is overcommitted by up to the InterruptibleMemoryOvercommit of the cluster.
+	// +optional
+	Interruptible bool `json:"interruptible,omitempty"`
+}
+
+// SMBIOS are fields of the SMBIOS type 1 system information of a VM.
+type SMBIOS struct {
+	// Serial is the system serial number.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Serial string `json:"serial,omitempty"`
+
+	// Manufacturer is the system manufacturer.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Manufacturer string `json:"manufacturer,omitempty"`
+
+	// Product is the system product name.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Product string `json:"product,omitempty"`
+
+	// SKU is the system SKU number.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	SKU string `json:"sku,omitempty"`
+}
+
+// ISODevice is a CD-ROM device of a VM with an ISO image.
+type ISODevice struct {
+	// Device is the IDE or SATA device of the CD-ROM. It must differ from the device
+	// of the cloud-init ISO, see CloudInitDevice.
+	// +kubebuilder:validation:Pattern=`^(ide[0-3]|sata[0-5])$`
+	Device string `json:"device"`
+
+	// Image is the volume of the ISO image, in the format storage:iso/name.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z][a-zA-Z0-9._-]*:iso/.+$`
+	Image string `json:"image"`
+
+	// Detach ejects the ISO image, the CD-ROM device stays empty.
+	// +optional
+	Detach bool `json:"detach,omitempty"`
+}
+
+// GuestOS defines the operating system of a VM.
+// +kubebuilder:validation:Enum=Linux;Windows
+type GuestOS string
+
+const (
+	// GuestOSLinux is a Linux VM provisioned by cloud-init.
+	GuestOSLinux GuestOS = "Linux"
+
+	// GuestOSWindows is a Windows VM provisioned by cloudbase-init.
+	GuestOSWindows GuestOS = "Windows"
+)
+
+// CloudInitFormat defines the format of the cloud-init data of a VM.
+// +kubebuilder:validation:Enum=NoCloud;ConfigDrive2;NoCloudNet
+type CloudInitFormat string
+
+const (
+	// CloudInitFormatNoCloud provides the data for the cloud-init NoCloud datasource.
+	CloudInitFormatNoCloud CloudInitFormat = "NoCloud"
+
+	// CloudInitFormatConfigDrive2 provides the data for the cloud-init ConfigDrive datasource.
+	CloudInitFormatConfigDrive2 CloudInitFormat = "ConfigDrive2"
+
+	// CloudInitFormatNoCloudNet serves the data for the cloud-init NoCloud datasource over HTTP.
+	CloudInitFormatNoCloudNet CloudInitFormat = "NoCloudNet"
+)
+
+// PowerState defines the desired power state of a VM.
+// +kubebuilder:validation:Enum=Running;Suspended;Hibernated
+type PowerState string
+
+const (
+	// PowerStateRunning keeps the VM running.
+	PowerStateRunning PowerState = "Running"
+
+	// PowerStateSuspended pauses the VM in memory.
+	PowerStateSuspended PowerState = "Suspended"
+
+	// PowerStateHibernated suspends the VM to disk.
+	PowerStateHibernated PowerState = "Hibernated"
+)
+
+// ResizePolicy defines how compute resources of a running VM are changed.
+// +kubebuilder:validation:Enum=Disabled;Hotplug;Reboot
+type ResizePolicy string
+
+const (
+	// ResizePolicyDisabled does not resize a running VM.
+	ResizePolicyDisabled ResizePolicy = "Disabled"
+
+	// ResizePolicyHotplug resizes a running VM using hotplug.
+	ResizePolicyHotplug ResizePolicy = "Hotplug"
+
+	// ResizePolicyReboot resizes a running VM using hotplug, and reboots it if required.
+	ResizePolicyReboot ResizePolicy = "Reboot"
+)
+
+// ConfigDriftPolicy defines how drift of the VM config is handled.
+// +kubebuilder:validation:Enum=Report;Reapply
+type ConfigDriftPolicy string
+
+const (
+	// ConfigDriftPolicyReport only reports drift of the VM config.
+	ConfigDriftPolicyReport ConfigDriftPolicy = "Report"
+
+	// ConfigDriftPolicyReapply reapplies the spec to a drifted VM config.
+	ConfigDriftPolicyReapply ConfigDriftPolicy = "Reapply"
+)
+
+// ProvisioningRemediation defines how machines stuck in provisioning are remediated.
+type ProvisioningRemediation struct {
+	// Timeout is the maximum duration from the start of the provisioning until the machine is ready.
+	// When it is exceeded, the partially provisioned VM is deleted and provisioning starts over.
+	Timeout metav1.Duration `json:"timeout"`
+
+	// MaxRetries is the number of times the VM is recreated after a timeout,
+	// before the machine is marked as failed.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRetries int32 `json:"maxRetries,omitempty"`
+}
+
+// AgentHealthCheck defines how the QEMU guest agent of a machine is checked.
+type AgentHealthCheck struct {
+	// Interval is the interval in which the agent is pinged. It defaults to one minute.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// UnhealthyTimeout marks the machine as failed once its agent did not respond for this long,
+	// so a MachineHealthCheck of the cluster remediates it. Without it, the condition is only reported.
+	// +optional
+	UnhealthyTimeout *metav1.Duration `json:"unhealthyTimeout,omitempty"`
+}
+
+// CloudInitCheck defines how the completion of cloud-init in the VM of a machine is verified.
+type CloudInitCheck struct {
+	// Timeout marks the machine as failed once cloud-init failed, or did not finish this long after the
+	// check started, so a MachineHealthCheck of the cluster remediates it. Without it, the result is only reported.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// BootstrapLogCollection defines when and which bootstrap logs of a machine are collected.
+type BootstrapLogCollection struct {
+	// Deadline is the duration after the machine became ready within which its node must join the cluster.
+	Deadline metav1.Duration `json:"deadline"`
+
+	// Files are the log files in the VM whose last lines are collected. It defaults to
+	// /var/log/cloud-init-output.log, which includes the output of kubeadm, or to the log of
+	// cloudbase-init for Windows VMs.
+	// +optional
+	Files []string `json:"files,omitempty"`
+
+	// Lines is the number of last lines collected of each file. It defaults to 20.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Lines int32 `json:"lines,omitempty"`
+}
+
+// File defines a file written to the machine by cloud-init.
+// +kubebuilder:validation:XValidation:rule="has(self.content) != has(self.contentFrom)",message="exactly one of content or contentFrom must be set"
+type File struct {
+	// Path is the absolute path of the file on the machine.
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path"`
+
+	// Content is the inline content of the file.
+	// +optional
+	Content string `json:"content,omitempty"`
+
+	// ContentFrom references the content of the file in a secret.
+	// +optional
+	ContentFrom *FileSource `json:"contentFrom,omitempty"`
+
+	// Permissions is the octal mode of the file, e.g. `0644`.
+	// +kubebuilder:validation:Pattern=`^0?[0-7]{3}$`
+	// +optional
+	Permissions string `json:"permissions,omitempty"`
+
+	// Owner is the owner of the file in the form `user:group`, e.g. `root:root`.
+	// +optional
+	Owner string `json:"owner,omitempty"`
+}
+
+// FileSource is the source of the content of a file.
+type FileSource struct {
+	// Secret references a key of a secret in the namespace of the machine.
+	Secret SecretKeySelector `json:"secret"`
+}
+
+// SecretKeySelector selects a key of a secret.
+type SecretKeySelector struct {
+	// Name is the name of the secret.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key is the key of the secret holding the value.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
 }
 
 // Storage is the physical storage on the node.
+// +kubebuilder:validation:XValidation:rule="!has(self.bootVolume) || !has(self.additionalVolumes) || self.additionalVolumes.all(v, v.disk != self.bootVolume.disk)",message="additional volumes may not use the disk of the boot volume"
+// +kubebuilder:validation:XValidation:rule="!has(self.bootVolume) || !has(self.persistentDisks) || self.persistentDisks.all(d, d.disk != self.bootVolume.disk)",message="persistent disks may not use the disk of the boot volume"
 type Storage struct {
 	// BootVolume defines the storage size for the boot volume.
 	// This field is optional, and should only be set if you want
@@ -101,9 +492,127 @@ type Storage struct {
 	// +optional
 	BootVolume *DiskSize `json:"bootVolume,omitempty"`
 
-	// TODO Intended to add handling for additional volumes,
-	// which will be added to the node.
-	// e.g. AdditionalVolumes []DiskSize.
+	// AdditionalVolumes are data disks which are added to the VM before it is started.
+	// +listType=map
+	// +listMapKey=disk
+	// +optional
+	AdditionalVolumes []DataDisk `json:"additionalVolumes,omitempty"`
+
+	// PersistentDisks attach ProxmoxDisks to the VM before it is started. The volumes of ProxmoxDisks do not belong
+	// to the VM, they are detached when the machine is deleted and can be attached to a replacement machine.
+	// They may not use the disks of additional volumes.
+	// +listType=map
+	// +listMapKey=disk
+	// +optional
+	PersistentDisks []PersistentDisk `json:"persistentDisks,omitempty"`
+}
+
+// PersistentDisk attaches a ProxmoxDisk to a disk device of a VM.
+// +kubebuilder:validation:XValidation:rule="has(self.name) != has(self.selector)",message="exactly one of name or selector must be set"
+type PersistentDisk struct {
+	// Disk is the name of the disk device, e.g. scsi1.
+	// +kubebuilder:validation:Pattern=`^(scsi([1-9]|[12][0-9]|30)|virtio([0-9]|1[0-5])|sata[0-4])$`
+	Disk string `json:"disk"`
+
+	// Name is the name of a ProxmoxDisk in the namespace of the machine.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Selector selects one of the ProxmoxDisks in the namespace of the machine which is not attached to
+	// another machine, so that every machine of a MachineDeployment attaches a disk of the same pool.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// DataDisk is a data disk of a VM.
+type DataDisk struct {
+	// Disk is the name of the disk device, e.g. scsi1.
+	// +kubebuilder:validation:Pattern=`^(scsi([1-9]|[12][0-9]|30)|virtio([0-9]|1[0-5])|sata[0-4])$`
+	Disk string `json:"disk"`
+
+	// Storage is the storage the volume of the disk is allocated on.
+	// +kubebuilder:validation:MinLength=1
+	Storage string `json:"storage"`
+
+	// SizeGB is the size of the disk in gigabyte.
+	// +kubebuilder:validation:Minimum=1
+	SizeGB int32 `json:"sizeGb"`
+
+	// DeletionPolicy defines what happens to the volume of the disk when the machine is deleted.
+	// Delete destroys it along with the VM. Detach keeps the volume and records it in the status
+	// of the ProxmoxCluster, so that a replacement machine of the same MachineDeployment or
+	// control plane attaches it instead of a new volume.
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DiskDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// DiskDeletionPolicy defines what happens to the volume of a data disk when its machine is deleted.
+// +kubebuilder:validation:Enum=Delete;Detach
+type DiskDeletionPolicy string
+
+const (
+	// DiskDeletionPolicyDelete destroys the volume along with the VM.
+	DiskDeletionPolicyDelete DiskDeletionPolicy = "Delete"
+
+	// DiskDeletionPolicyDetach keeps the volume for a replacement machine.
+	DiskDeletionPolicyDetach DiskDeletionPolicy = "Detach"
+)
+
+// MachineVolume is a volume attached to a data disk of a VM.
+type MachineVolume struct {
+	// Disk is the name of the disk device.
+	Disk string `json:"disk"`
+
+	// Volume is the ID of the volume, in the format storage:name.
+	Volume string `json:"volume"`
+}
+
+// MachinePlan describes the Proxmox operations the controller would perform for a ProxmoxMachine in dry-run mode.
+type MachinePlan struct {
+	// Clone describes the VM which would be created, if the machine does not have one yet.
+	// +optional
+	Clone *ClonePlan `json:"clone,omitempty"`
+
+	// ConfigChanges are the differences between the config of the VM and the spec which would be applied.
+	// +optional
+	ConfigChanges []string `json:"configChanges,omitempty"`
+
+	// DiskOperations are the disks which would be resized or added.
+	// +optional
+	DiskOperations []string `json:"diskOperations,omitempty"`
+
+	// PlannedTime is the time the plan last changed.
+	PlannedTime metav1.Time `json:"plannedTime"`
+}
+
+// ClonePlan describes the creation of the VM of a ProxmoxMachine.
+type ClonePlan struct {
+	// Name is the name of the VM.
+	Name string `json:"name"`
+
+	// SourceNode is the node of the template VM, or of the image.
+	SourceNode string `json:"sourceNode"`
+
+	// TemplateID is the vmid of the template VM which would be cloned.
+	// +optional
+	TemplateID *int32 `json:"templateID,omitempty"`
+
+	// ImageURL is the URL of the image the VM would be created from.
+	// +optional
+	ImageURL string `json:"imageURL,omitempty"`
+
+	// TargetNode is the node the VM would be created on.
+	// +optional
+	TargetNode string `json:"targetNode,omitempty"`
+
+	// Storage is the storage of the disks of the VM.
+	// +optional
+	Storage string `json:"storage,omitempty"`
+
+	// Full is true for a full clone.
+	// +optional
+	Full bool `json:"full,omitempty"`
 }
 
 // DiskSize is contains values for the disk device and size.
@@ -132,6 +641,19 @@ const (
 	TargetStorageFormatVmdk  TargetFileStorageFormat = "vmdk"
 )
 
+// TemplateReference identifies the template VM a VM was cloned from.
+type TemplateReference struct {
+	// SourceNode is the node of the template VM.
+	SourceNode string `json:"sourceNode"`
+
+	// TemplateID is the vmid of the template VM.
+	TemplateID int32 `json:"templateID"`
+
+	// SnapName is the snapshot of the template VM which was cloned.
+	// +optional
+	SnapName string `json:"snapName,omitempty"`
+}
+
 // VirtualMachineCloneSpec is information used to clone a virtual machine.
 type VirtualMachineCloneSpec struct {
 	// SourceNode is the initially selected proxmox node.
@@ -149,13 +671,22 @@ type VirtualMachineCloneSpec struct {
 	// If neither a `Target` nor `AllowedNodes` was set, the VM
 	// will be cloned onto the same node as SourceNode.
 	//
+	// Defaults to the SourceNode of the MachineDefaults of the ProxmoxCluster.
+	//
 	// +kubebuilder:validation:MinLength=1
-	SourceNode string `json:"sourceNode"`
+	// +optional
+	SourceNode string `json:"sourceNode,omitempty"`
 
 	// TemplateID the vm_template vmid used for cloning a new VM.
+	// It can reference a regular VM as well, which must be stopped and is always cloned as a full clone.
 	// +optional
 	TemplateID *int32 `json:"templateID,omitempty"`
 
+	// Image creates the VM from a cloud image instead of cloning a template VM.
+	// The boot volume is imported from the image to the Storage, or to the storage of the image if Storage is not set.
+	// +optional
+	Image *ImageSource `json:"image,omitempty"`
+
 	// Description for the new VM.
 	// +optional
 	Description *string `json:"description,omitempty"`
@@ -190,6 +721,34 @@ type VirtualMachineCloneSpec struct {
 	Target *string `json:"target,omitempty"`
 }
 
+// ImageSource is a cloud image which the node downloads to create VMs from.
+type ImageSource struct {
+	// URL of the qcow2, raw or vmdk image.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// Storage to which the image is downloaded. The storage must allow the import content type,
+	// which requires Proxmox VE 8.2 or newer.
+	// +kubebuilder:validation:MinLength=1
+	Storage string `json:"storage"`
+
+	// Filename of the image on the storage. Defaults to the last element of the path of the URL,
+	// with the extension .qcow2 appended unless it ends with .qcow2, .raw or .vmdk.
+	// +kubebuilder:validation:Pattern=`^[^/]+\.(qcow2|raw|vmdk)$`
+	// +optional
+	Filename string `json:"filename,omitempty"`
+
+	// Checksum of the image, which is verified after the download.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// ChecksumAlgorithm is the algorithm of the Checksum.
+	// +kubebuilder:validation:Enum=md5;sha1;sha224;sha256;sha384;sha512
+	// +kubebuilder:default=sha256
+	// +optional
+	ChecksumAlgorithm string `json:"checksumAlgorithm,omitempty"`
+}
+
 // NetworkSpec defines the virtual machine's network configuration.
 type NetworkSpec struct {
 	// Default is the default network device,
@@ -206,20 +765,68 @@ type NetworkSpec struct {
 }
 
 // NetworkDevice defines the required details of a virtual machine network device.
+// +kubebuilder:validation:XValidation:rule="has(self.bridge) != has(self.vnet)",message="exactly one of bridge or vnet must be set"
 type NetworkDevice struct {
 	// Bridge is the network bridge to attach to the machine.
 	// +kubebuilder:validation:MinLength=1
-	Bridge string `json:"bridge"`
+	// +optional
+	Bridge string `json:"bridge,omitempty"`
+
+	// VNet is the name of a VNet of the Proxmox VE software-defined network to attach to the machine.
+	// The VNet must exist when the machine is created, see the sdn settings of the ProxmoxCluster.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	VNet string `json:"vnet,omitempty"`
 
 	// Model is the network device model.
+	// Defaults to virtio, or e1000 for Windows VMs.
 	// +optional
 	// +kubebuilder:validation:Enum=e1000;virtio;rtl8139;vmxnet3
-	// +kubebuilder:default=virtio
 	Model *string `json:"model,omitempty"`
+
+	// RouteMetric is the metric of the default routes via the gateways of the network device.
+	// Routes with a lower metric are preferred. If multiple network devices have a gateway,
+	// those without a metric get the metric 100 for the default device, and 200, 300 and so on
+	// for the additional devices in their order.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RouteMetric *int32 `json:"routeMetric,omitempty"`
+
+	// DHCP6 enables DHCPv6 on the network device, for sites where IPv6 addresses are managed
+	// by DHCPv6 instead of IP pools or SLAAC.
+	// +optional
+	DHCP6 bool `json:"dhcp6,omitempty"`
+
+	// VLAN is the VLAN tag of the network device on its bridge.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	// +optional
+	VLAN int32 `json:"vlan,omitempty"`
+
+	// MTU is the MTU of the network device, which is configured in the guest as well.
+	// 1 makes the device inherit the MTU of its bridge, without configuring it in the guest.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65520
+	// +optional
+	MTU int32 `json:"mtu,omitempty"`
+}
+
+// BridgeName returns the bridge the network device is attached to. Proxmox VE provides
+// every VNet as a bridge of the same name on the nodes of its zone.
+func (d NetworkDevice) BridgeName() string {
+	if d.VNet != "" {
+		return d.VNet
+	}
+	return d.Bridge
 }
 
 // AdditionalNetworkDevice the definition of a Proxmox network device.
-// +kubebuilder:validation:XValidation:rule="self.ipv4PoolRef != null || self.ipv6PoolRef != null",message="at least one pool reference must be set, either ipv4PoolRef or ipv6PoolRef"
+// +kubebuilder:validation:XValidation:rule="self.ipv4PoolRef != null || self.ipv6PoolRef != null || has(self.linkLocal) || (has(self.dhcp6) && self.dhcp6) || (has(self.unmanaged) && self.unmanaged) || has(self.profile)",message="at least one pool reference must be set, either ipv4PoolRef or ipv6PoolRef, unless linkLocal, dhcp6, unmanaged or profile is set"
+// +kubebuilder:validation:XValidation:rule="!has(self.linkLocal) || (self.ipv4PoolRef == null && self.ipv6PoolRef == null && !(has(self.dhcp6) && self.dhcp6))",message="linkLocal is mutually exclusive with ipv4PoolRef, ipv6PoolRef and dhcp6"
+// +kubebuilder:validation:XValidation:rule="!(has(self.dhcp6) && self.dhcp6) || self.ipv6PoolRef == null",message="dhcp6 is mutually exclusive with ipv6PoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.additionalIPv4PoolRefs) || self.ipv4PoolRef != null",message="additionalIPv4PoolRefs requires ipv4PoolRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.additionalIPv6PoolRefs) || self.ipv6PoolRef != null",message="additionalIPv6PoolRefs requires ipv6PoolRef"
+// +kubebuilder:validation:XValidation:rule="!(has(self.unmanaged) && self.unmanaged) || (self.ipv4PoolRef == null && self.ipv6PoolRef == null && !has(self.linkLocal) && !(has(self.dhcp6) && self.dhcp6))",message="unmanaged is mutually exclusive with ipv4PoolRef, ipv6PoolRef, linkLocal and dhcp6"
 type AdditionalNetworkDevice struct {
 	NetworkDevice `json:",inline"`
 
@@ -245,11 +852,120 @@ type AdditionalNetworkDevice struct {
 	// +kubebuilder:validation:XValidation:rule="self.kind == 'InClusterIPPool' || self.kind == 'GlobalInClusterIPPool'",message="ipv6PoolRef allows either InClusterIPPool or GlobalInClusterIPPool"
 	IPv6PoolRef *corev1.TypedLocalObjectReference `json:"ipv6PoolRef,omitempty"`
 
+	// AdditionalIPv4PoolRefs are references to further IPAM pools of IPv4 addresses. The network device
+	// uses an address of these pools in order, once the pool of ipv4PoolRef and the pools before are exhausted.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.all(ref, ref.apiGroup == 'ipam.cluster.x-k8s.io')",message="additionalIPv4PoolRefs allows only IPAM apiGroup ipam.cluster.x-k8s.io"
+	// +kubebuilder:validation:XValidation:rule="self.all(ref, ref.kind == 'InClusterIPPool' || ref.kind == 'GlobalInClusterIPPool')",message="additionalIPv4PoolRefs allows either InClusterIPPool or GlobalInClusterIPPool"
+	AdditionalIPv4PoolRefs []corev1.TypedLocalObjectReference `json:"additionalIPv4PoolRefs,omitempty"`
+
+	// AdditionalIPv6PoolRefs are references to further IPAM pools of IPv6 addresses. The network device
+	// uses an address of these pools in order, once the pool of ipv6PoolRef and the pools before are exhausted.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.all(ref, ref.apiGroup == 'ipam.cluster.x-k8s.io')",message="additionalIPv6PoolRefs allows only IPAM apiGroup ipam.cluster.x-k8s.io"
+	// +kubebuilder:validation:XValidation:rule="self.all(ref, ref.kind == 'InClusterIPPool' || ref.kind == 'GlobalInClusterIPPool')",message="additionalIPv6PoolRefs allows either InClusterIPPool or GlobalInClusterIPPool"
+	AdditionalIPv6PoolRefs []corev1.TypedLocalObjectReference `json:"additionalIPv6PoolRefs,omitempty"`
+
 	// DNSServers contains information about nameservers to be used for this interface.
 	// If this field is not set, it will use the default dns servers from the ProxmoxCluster.
 	// +optional
 	// +kubebuilder:validation:MinItems=1
 	DNSServers []string `json:"dnsServers,omitempty"`
+
+	// IPv6DNSServers are the nameservers of the IPv6 configuration of this interface,
+	// which then uses DNSServers only for its IPv4 configuration.
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	IPv6DNSServers []string `json:"ipv6DNSServers,omitempty"`
+
+	// LinkLocal configures the network device without addresses from IP pools, for CNIs and
+	// protocols such as BGP unnumbered which only need layer 2 adjacency. No IP addresses are
+	// claimed for the device, which only has link-local addresses of the given mode.
+	// +optional
+	LinkLocal LinkLocalMode `json:"linkLocal,omitempty"`
+
+	// Unmanaged attaches the network device to the VM without configuring it in the network config
+	// of the machine, for devices which the CNI, Open vSwitch or an administrator configure later.
+	// No IP addresses are claimed for the device.
+	// +optional
+	Unmanaged bool `json:"unmanaged,omitempty"`
+
+	// Profile adapts the network config of the device to the use of its network.
+	// Traffic is meant for secondary networks of pod or storage traffic, like Ceph. The device gets
+	// no default routes, even if its IP pools have gateways, and no addresses without pool references.
+	// +optional
+	Profile NetworkDeviceProfile `json:"profile,omitempty"`
+}
+
+// NetworkDeviceProfile adapts the network config of a network device to the use of its network.
+// +kubebuilder:validation:Enum=Traffic
+type NetworkDeviceProfile string
+
+const (
+	// NetworkDeviceProfileTraffic configures a network device of a secondary network without default routes.
+	NetworkDeviceProfileTraffic NetworkDeviceProfile = "Traffic"
+)
+
+// LinkLocalMode defines the link-local addresses of a network device without IP addresses.
+// +kubebuilder:validation:Enum=None;IPv6
+type LinkLocalMode string
+
+const (
+	// LinkLocalModeNone configures the network device without any addresses.
+	LinkLocalModeNone LinkLocalMode = "None"
+
+	// LinkLocalModeIPv6 configures the network device with an IPv6 link-local address only.
+	LinkLocalModeIPv6 LinkLocalMode = "IPv6"
+)
+
+// FirewallSpec defines the Proxmox VE firewall of a VM. The rules of the VM are replaced
+// by the rules of the security groups, followed by the inbound rules.
+type FirewallSpec struct {
+	// Enabled enables the firewall of the VM and of its network devices.
+	Enabled bool `json:"enabled"`
+
+	// InboundPolicy is the policy for inbound traffic which matches no rule.
+	// Proxmox VE defaults to DROP.
+	// +kubebuilder:validation:Enum=ACCEPT;DROP;REJECT
+	// +optional
+	InboundPolicy string `json:"inboundPolicy,omitempty"`
+
+	// SecurityGroups are the names of security groups of the Proxmox VE cluster, whose rules apply to the VM.
+	// The groups must exist.
+	// +kubebuilder:validation:items:MinLength=1
+	// +optional
+	SecurityGroups []string `json:"securityGroups,omitempty"`
+
+	// InboundRules are rules for inbound traffic of the VM.
+	// +optional
+	InboundRules []FirewallRule `json:"inboundRules,omitempty"`
+}
+
+// FirewallRule defines a rule of the Proxmox VE firewall.
+// +kubebuilder:validation:XValidation:rule="!has(self.port) || has(self.protocol)",message="port requires a protocol"
+type FirewallRule struct {
+	// Action is applied to the traffic matching the rule.
+	// +kubebuilder:validation:Enum=ACCEPT;DROP;REJECT
+	Action string `json:"action"`
+
+	// Protocol matches the protocol, like tcp, udp or icmp.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+
+	// Port matches the destination port or port range, like 6443 or 30000:32767.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Port string `json:"port,omitempty"`
+
+	// Source matches the source address, like a CIDR, or an alias or IP set of the Proxmox VE cluster.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// Comment describes the rule.
+	// +optional
+	Comment string `json:"comment,omitempty"`
 }
 
 // ProxmoxMachineStatus defines the observed state of ProxmoxMachine.
@@ -258,6 +974,10 @@ type ProxmoxMachineStatus struct {
 	// +optional
 	Ready bool `json:"ready"`
 
+	// Interruptible reports an interruptible machine to Cluster API, which labels its node.
+	// +optional
+	Interruptible bool `json:"interruptible,omitempty"`
+
 	// Addresses contains the Proxmox VM instance associated addresses.
 	// +optional
 	Addresses []clusterv1.MachineAddress `json:"addresses,omitempty"`
@@ -274,6 +994,11 @@ type ProxmoxMachineStatus struct {
 	// +optional
 	IPAddresses map[string]IPAddress `json:"ipAddresses,omitempty"`
 
+	// IPAllocations are the states of the IP address claims of the network devices,
+	// which show the devices whose IP addresses are not allocated yet.
+	// +optional
+	IPAllocations []IPAllocation `json:"ipAllocations,omitempty"`
+
 	// Network returns the network status for each of the machine's configured
 	// network interfaces.
 	// +optional
@@ -284,6 +1009,44 @@ type ProxmoxMachineStatus struct {
 	// +optional
 	ProxmoxNode *string `json:"proxmoxNode,omitempty"`
 
+	// ObservedBootstrapSecretVersion is the resource version of the bootstrap data secret
+	// which the cloud-init data of the VM was rendered from.
+	// +optional
+	ObservedBootstrapSecretVersion string `json:"observedBootstrapSecretVersion,omitempty"`
+
+	// ObservedResizeGeneration is the generation of the ProxmoxMachine whose compute resources
+	// were last applied to the VM by the ResizePolicy. Changes of the VM which do not come from
+	// a change of the spec are left to the ConfigDriftPolicy.
+	// +optional
+	ObservedResizeGeneration int64 `json:"observedResizeGeneration,omitempty"`
+
+	// CloudInitInstanceID is the instance-id of the cloud-init meta-data of the VM, which is the UID of the
+	// ProxmoxMachine. It is kept when the cloud-init data is rendered again, so cloud-init does not take
+	// the VM for a new instance and run its per-instance modules again.
+	// +optional
+	CloudInitInstanceID string `json:"cloudInitInstanceID,omitempty"`
+
+	// CloudInitStatusPID is the process ID of the `cloud-init status --wait` command which the QEMU guest
+	// agent runs for the cloud-init check, until it exited.
+	// +optional
+	CloudInitStatusPID *int64 `json:"cloudInitStatusPID,omitempty"`
+
+	// ClonedFrom is the template which the VM was cloned from.
+	// +optional
+	ClonedFrom *TemplateReference `json:"clonedFrom,omitempty"`
+
+	// Volumes are the volumes of the data disks with the Detach deletion policy,
+	// which are kept when the machine is deleted.
+	// +optional
+	// +listType=map
+	// +listMapKey=disk
+	Volumes []MachineVolume `json:"volumes,omitempty"`
+
+	// Plan lists the Proxmox operations the controller would perform, while the
+	// infrastructure.cluster.x-k8s.io/dry-run annotation is set to "true".
+	// +optional
+	Plan *MachinePlan `json:"plan,omitempty"`
+
 	// TaskRef is a managed object reference to a Task related to the ProxmoxMachine.
 	// This value is set automatically at runtime and should not be set or
 	// modified by users.
@@ -294,6 +1057,16 @@ type ProxmoxMachineStatus struct {
 	// +optional
 	RetryAfter metav1.Time `json:"retryAfter,omitempty"`
 
+	// ProvisioningStartTime is the time the provisioning of the current VM started.
+	// It is only set if ProvisioningRemediation is enabled.
+	// +optional
+	ProvisioningStartTime *metav1.Time `json:"provisioningStartTime,omitempty"`
+
+	// ProvisioningRetries is the number of times the VM was recreated, because
+	// its provisioning timed out.
+	// +optional
+	ProvisioningRetries int32 `json:"provisioningRetries,omitempty"`
+
 	// FailureReason will be set in the event that there is a terminal problem
 	// reconciling the Machine and will contain a succinct value suitable
 	// for machine interpretation.
@@ -333,8 +1106,24 @@ type ProxmoxMachineStatus struct {
 	FailureMessage *string `json:"failureMessage,omitempty"`
 
 	// Conditions defines current service state of the ProxmoxMachine.
+	// They are kept during the deprecation of the v1beta1 conditions, which are replaced by V1Beta2.Conditions.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// V1Beta2 groups the fields of the status according to the v1beta2 contract of Cluster API.
+	// +optional
+	V1Beta2 *ProxmoxMachineV1Beta2Status `json:"v1beta2,omitempty"`
+}
+
+// ProxmoxMachineV1Beta2Status groups the fields of the status of a ProxmoxMachine according to the v1beta2 contract of Cluster API.
+type ProxmoxMachineV1Beta2Status struct {
+	// Conditions represent the observations of the current state of the ProxmoxMachine.
+	// The Ready condition summarizes them, and each condition records the generation it was observed at.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // IPAddress defines the IP addresses of a network interface.
@@ -348,6 +1137,48 @@ type IPAddress struct {
 	IPV6 string `json:"ipv6,omitempty"`
 }
 
+// IPAllocationState is the state of the allocation of an IP address.
+type IPAllocationState string
+
+const (
+	// IPAllocationStatePending means that the IP address was not allocated yet.
+	IPAllocationStatePending IPAllocationState = "Pending"
+
+	// IPAllocationStateBound means that the IP address is allocated.
+	IPAllocationStateBound IPAllocationState = "Bound"
+
+	// IPAllocationStateFailed means that the pool could not allocate an IP address, e.g. as it is exhausted.
+	IPAllocationStateFailed IPAllocationState = "Failed"
+)
+
+// IPAllocation is the state of the IP address claim of a network device.
+type IPAllocation struct {
+	// Device is the name of the network device.
+	Device string `json:"device"`
+
+	// Format is the IP format of the claim, v4 or v6.
+	Format string `json:"format"`
+
+	// ClaimName is the name of the IPAddressClaim.
+	ClaimName string `json:"claimName"`
+
+	// Pool is the name of the IP pool the claim allocates from, which changes to the next pool
+	// of the device once a pool is exhausted.
+	// +optional
+	Pool string `json:"pool,omitempty"`
+
+	// State is the state of the allocation.
+	State IPAllocationState `json:"state"`
+
+	// Address is the allocated IP address.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Message describes why the allocation failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:storageversion
 // +kubebuilder:subresource:status
@@ -387,6 +1218,22 @@ func (r *ProxmoxMachine) SetConditions(conditions clusterv1.Conditions) {
 	r.Status.Conditions = conditions
 }
 
+// GetV1Beta2Conditions returns the v1beta2 conditions of the ProxmoxMachine.
+func (r *ProxmoxMachine) GetV1Beta2Conditions() []metav1.Condition {
+	if r.Status.V1Beta2 == nil {
+		return nil
+	}
+	return r.Status.V1Beta2.Conditions
+}
+
+// SetV1Beta2Conditions sets the v1beta2 conditions of the ProxmoxMachine.
+func (r *ProxmoxMachine) SetV1Beta2Conditions(conditions []metav1.Condition) {
+	if r.Status.V1Beta2 == nil {
+		r.Status.V1Beta2 = &ProxmoxMachineV1Beta2Status{}
+	}
+	r.Status.V1Beta2.Conditions = conditions
+}
+
 // GetVirtualMachineID get the Proxmox "vmid".
 func (r *ProxmoxMachine) GetVirtualMachineID() int64 {
 	if r.Spec.VirtualMachineID != nil {
@@ -408,6 +1255,16 @@ func (r *ProxmoxMachine) GetNode() string {
 	return r.Spec.SourceNode
 }
 
+// IsDryRun returns whether the Proxmox operations for this machine are only planned.
+func (r *ProxmoxMachine) IsDryRun() bool {
+	return r.GetAnnotations()[DryRunAnnotation] == "true"
+}
+
+// IsBootstrapApproved returns whether the bootstrap of this machine may start.
+func (r *ProxmoxMachine) IsBootstrapApproved() bool {
+	return !r.Spec.RequireBootstrapApproval || r.GetAnnotations()[BootstrapApprovedAnnotation] == "true"
+}
+
 // FormatSize returns the format required for the Proxmox API.
 func (d *DiskSize) FormatSize() string {
 	return fmt.Sprintf("%dG", d.SizeGB)
@@ -56,11 +56,11 @@ var _ = Describe("ProxmoxMachine Test", func() {
 	})
 
 	Context("VirtualMachineCloneSpec", func() {
-		It("Should not allow empty source node", func() {
+		It("Should allow omitting the source node to inherit the machine defaults", func() {
 			dm := defaultMachine()
 			dm.Spec.SourceNode = ""
 
-			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("should be at least 1 chars long")))
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
 		})
 
 		It("Should not allow specifying format if full clone is disabled", func() {
@@ -69,6 +69,83 @@ var _ = Describe("ProxmoxMachine Test", func() {
 
 			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("Must set full=true when specifying format")))
 		})
+
+		It("Should not allow a template and an image at the same time", func() {
+			dm := defaultMachine()
+			dm.Spec.TemplateID = ptr.To[int32](100)
+			dm.Spec.Image = &ImageSource{URL: "https://example.com/noble.qcow2", Storage: "local"}
+
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("templateID and image are mutually exclusive")))
+		})
+
+		It("Should only allow image filenames with the extension of a disk image", func() {
+			dm := defaultMachine()
+			dm.Spec.Image = &ImageSource{URL: "https://example.com/noble.img", Storage: "local", Filename: "noble.img"}
+
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.image.filename")))
+		})
+	})
+
+	Context("CPU", func() {
+		It("Should allow vCPUs up to the number of cores of all sockets", func() {
+			dm := defaultMachine()
+			dm.Spec.NumSockets = 2
+			dm.Spec.NumCores = 4
+			dm.Spec.NumVCPUs = 8
+
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+		})
+
+		It("Should not allow more vCPUs than cores of all sockets", func() {
+			dm := defaultMachine()
+			dm.Spec.NumSockets = 2
+			dm.Spec.NumCores = 4
+			dm.Spec.NumVCPUs = 9
+
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("numVCPUs must not exceed numSockets * numCores")))
+		})
+
+		It("Should not allow an SMBIOS serial with the NoCloudNet cloud-init format", func() {
+			dm := defaultMachine()
+			dm.Spec.CloudInitFormat = CloudInitFormatNoCloudNet
+			dm.Spec.SMBIOS = &SMBIOS{Serial: "CZ-4711"}
+
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("smbios.serial cannot be set with the NoCloudNet cloud-init format")))
+		})
+
+		It("Should allow other SMBIOS fields with the NoCloudNet cloud-init format", func() {
+			dm := defaultMachine()
+			dm.Spec.CloudInitFormat = CloudInitFormatNoCloudNet
+			dm.Spec.SMBIOS = &SMBIOS{Manufacturer: "IONOS", Product: "Worker"}
+
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+		})
+
+		It("Should not allow ISOs on the device of the cloud-init ISO", func() {
+			dm := defaultMachine()
+			dm.Spec.ISOs = []ISODevice{{Device: "ide0", Image: "local:iso/virtio-win.iso"}}
+
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("isos cannot use the device of the cloud-init ISO")))
+
+			dm.Spec.CloudInitDevice = "ide3"
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+		})
+
+		It("Should only allow IDE and SATA devices for the cloud-init ISO", func() {
+			dm := defaultMachine()
+			dm.Spec.CloudInitDevice = "scsi0"
+
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.cloudInitDevice")))
+		})
+
+		It("Should only allow a CPU limit of up to 128 cores", func() {
+			dm := defaultMachine()
+			dm.Spec.CPULimit = "129"
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("cpuLimit must not exceed 128")))
+
+			dm.Spec.CPULimit = "one"
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("spec.cpuLimit")))
+		})
 	})
 
 	Context("Disks", func() {
@@ -99,7 +176,30 @@ var _ = Describe("ProxmoxMachine Test", func() {
 				},
 			}
 
-			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("should be at least 1 chars long")))
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("exactly one of bridge or vnet must be set")))
+		})
+
+		It("Should not allow bridge and vnet at the same time", func() {
+			dm := defaultMachine()
+			dm.Spec.Network = &NetworkSpec{
+				Default: &NetworkDevice{
+					Bridge: "vmbr0",
+					VNet:   "capmox",
+				},
+			}
+
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("exactly one of bridge or vnet must be set")))
+		})
+
+		It("Should allow a vnet", func() {
+			dm := defaultMachine()
+			dm.Spec.Network = &NetworkSpec{
+				Default: &NetworkDevice{
+					VNet: "capmox",
+				},
+			}
+
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
 		})
 
 		It("Should not allow net0 in additional network devices", func() {
@@ -120,7 +220,7 @@ var _ = Describe("ProxmoxMachine Test", func() {
 				},
 			}
 
-			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("should be at least 1 chars long")))
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("additional network devices doesn't allow net0")))
 		})
 
 		It("Should only allow IPAM pool resources in IPv4PoolRef apiGroup", func() {
@@ -198,7 +298,93 @@ var _ = Describe("ProxmoxMachine Test", func() {
 				},
 				},
 			}
-			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("at least one pool reference must be set, either ipv4PoolRef or ipv6PoolRef")))
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("at least one pool reference must be set, either ipv4PoolRef or ipv6PoolRef, unless linkLocal, dhcp6, unmanaged or profile is set")))
+		})
+
+		It("Should allow Machine with link-local additional devices without a pool ref", func() {
+			dm := defaultMachine()
+			dm.Spec.Network = &NetworkSpec{
+				AdditionalDevices: []AdditionalNetworkDevice{{
+					NetworkDevice: NetworkDevice{Bridge: "vmbr1"},
+					Name:          "net1",
+					LinkLocal:     LinkLocalModeIPv6,
+				},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+		})
+
+		It("Should not allow Machine with link-local additional devices with a pool ref", func() {
+			dm := defaultMachine()
+			dm.Spec.Network = &NetworkSpec{
+				AdditionalDevices: []AdditionalNetworkDevice{{
+					NetworkDevice: NetworkDevice{Bridge: "vmbr1"},
+					Name:          "net1",
+					LinkLocal:     LinkLocalModeNone,
+					IPv4PoolRef: &corev1.TypedLocalObjectReference{
+						APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+						Kind:     "InClusterIPPool",
+						Name:     "sample",
+					},
+				},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("linkLocal is mutually exclusive with ipv4PoolRef, ipv6PoolRef and dhcp6")))
+		})
+
+		It("Should allow Machine with DHCPv6 additional devices without a pool ref", func() {
+			dm := defaultMachine()
+			dm.Spec.Network = &NetworkSpec{
+				AdditionalDevices: []AdditionalNetworkDevice{{
+					NetworkDevice: NetworkDevice{Bridge: "vmbr1", DHCP6: true},
+					Name:          "net1",
+				},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+		})
+
+		It("Should not allow Machine with DHCPv6 additional devices with an IPv6 pool ref", func() {
+			dm := defaultMachine()
+			dm.Spec.Network = &NetworkSpec{
+				AdditionalDevices: []AdditionalNetworkDevice{{
+					NetworkDevice: NetworkDevice{Bridge: "vmbr1", DHCP6: true},
+					Name:          "net1",
+					IPv6PoolRef: &corev1.TypedLocalObjectReference{
+						APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+						Kind:     "InClusterIPPool",
+						Name:     "sample",
+					},
+				},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("dhcp6 is mutually exclusive with ipv6PoolRef")))
+		})
+
+		It("Should allow Machine with unmanaged additional devices without a pool ref", func() {
+			dm := defaultMachine()
+			dm.Spec.Network = &NetworkSpec{
+				AdditionalDevices: []AdditionalNetworkDevice{{
+					NetworkDevice: NetworkDevice{Bridge: "vmbr1"},
+					Name:          "net1",
+					Unmanaged:     true,
+				},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), dm)).To(Succeed())
+		})
+
+		It("Should not allow Machine with unmanaged additional devices with DHCPv6", func() {
+			dm := defaultMachine()
+			dm.Spec.Network = &NetworkSpec{
+				AdditionalDevices: []AdditionalNetworkDevice{{
+					NetworkDevice: NetworkDevice{Bridge: "vmbr1", DHCP6: true},
+					Name:          "net1",
+					Unmanaged:     true,
+				},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), dm)).Should(MatchError(ContainSubstring("unmanaged is mutually exclusive with ipv4PoolRef, ipv6PoolRef, linkLocal and dhcp6")))
 		})
 	})
 })
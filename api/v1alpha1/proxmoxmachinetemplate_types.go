@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -24,16 +25,87 @@ import (
 // ProxmoxMachineTemplateSpec defines the desired state of ProxmoxMachineTemplate.
 type ProxmoxMachineTemplateSpec struct {
 	Template ProxmoxMachineTemplateResource `json:"template"`
+
+	// TemplateSource imports the template VM of the machines from another Proxmox VE cluster,
+	// if it does not exist on the source node of this cluster yet.
+	// +optional
+	TemplateSource *TemplateSource `json:"templateSource,omitempty"`
+}
+
+// TemplateSource is a template VM on another Proxmox VE cluster, which is copied to the template ID
+// of the machines with a remote migration. The template VM is kept on the source cluster.
+type TemplateSource struct {
+	// URL is the URL of the Proxmox VE API of the source cluster.
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// Node is the node of the template VM on the source cluster.
+	// +kubebuilder:validation:MinLength=1
+	Node string `json:"node"`
+
+	// TemplateID is the VMID of the template VM on the source cluster.
+	TemplateID int32 `json:"templateID"`
+
+	// CredentialsRef is a secret in the namespace of the template. The keys token and secret
+	// contain an API token of the source cluster, and targetEndpoint the endpoint of this cluster
+	// as expected by qm remote-migrate, including an API token of this cluster.
+	CredentialsRef corev1.LocalObjectReference `json:"credentialsRef"`
+
+	// TargetStorage maps the storages of the disks of the template VM to the storages of this cluster,
+	// like the target-storage of qm remote-migrate, e.g. local-lvm or source=target.
+	// +kubebuilder:validation:MinLength=1
+	TargetStorage string `json:"targetStorage"`
+
+	// TargetBridge maps the bridges of the network devices of the template VM to the bridges of this cluster,
+	// like the target-bridge of qm remote-migrate, e.g. vmbr0 or source=target.
+	// +kubebuilder:validation:MinLength=1
+	TargetBridge string `json:"targetBridge"`
+}
+
+// ProxmoxMachineTemplateStatus defines the observed state of ProxmoxMachineTemplate.
+type ProxmoxMachineTemplateStatus struct {
+	// Capacity defines the resources of the machines created from the template,
+	// which the cluster autoscaler uses to scale MachineDeployments from zero replicas.
+	// It contains the cpu, memory and, if the boot volume is resized, ephemeral-storage.
+	// +optional
+	Capacity corev1.ResourceList `json:"capacity,omitempty"`
+
+	// NodeInfo describes the nodes of the machines created from the template.
+	// +optional
+	NodeInfo *NodeInfo `json:"nodeInfo,omitempty"`
+
+	// TemplateImportTaskRef is the task of the source cluster which imports the template VM.
+	// +optional
+	TemplateImportTaskRef *string `json:"templateImportTaskRef,omitempty"`
+
+	// Conditions defines current service state of the ProxmoxMachineTemplate.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// NodeInfo describes the nodes of machines, like the NodeSystemInfo of Kubernetes nodes.
+type NodeInfo struct {
+	// Architecture is the CPU architecture of the nodes.
+	// +kubebuilder:validation:Enum=amd64;arm64
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// OperatingSystem is the operating system of the nodes.
+	// +kubebuilder:validation:Enum=linux;windows
+	// +optional
+	OperatingSystem string `json:"operatingSystem,omitempty"`
 }
 
 //+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
 
 // ProxmoxMachineTemplate is the Schema for the proxmoxmachinetemplates API.
 type ProxmoxMachineTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec ProxmoxMachineTemplateSpec `json:"spec,omitempty"`
+	Spec   ProxmoxMachineTemplateSpec   `json:"spec,omitempty"`
+	Status ProxmoxMachineTemplateStatus `json:"status,omitempty"`
 }
 
 // ProxmoxMachineTemplateResource defines the spec and metadata for ProxmoxMachineTemplate supported by capi.
@@ -54,6 +126,16 @@ type ProxmoxMachineTemplateList struct {
 	Items           []ProxmoxMachineTemplate `json:"items"`
 }
 
+// GetConditions returns the observations of the operational state of the ProxmoxMachineTemplate resource.
+func (r *ProxmoxMachineTemplate) GetConditions() clusterv1.Conditions {
+	return r.Status.Conditions
+}
+
+// SetConditions sets the underlying service state of the ProxmoxMachineTemplate to the predescribed clusterv1.Conditions.
+func (r *ProxmoxMachineTemplate) SetConditions(conditions clusterv1.Conditions) {
+	r.Status.Conditions = conditions
+}
+
 func init() {
 	SchemeBuilder.Register(&ProxmoxMachineTemplate{}, &ProxmoxMachineTemplateList{})
 }
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// VMSnapshotFinalizer allows deleting the snapshot of a ProxmoxVMSnapshot before removing it from the apiserver.
+	VMSnapshotFinalizer = "proxmoxvmsnapshot.infrastructure.cluster.x-k8s.io"
+)
+
+// ProxmoxVMSnapshotSpec defines the desired state of ProxmoxVMSnapshot.
+type ProxmoxVMSnapshotSpec struct {
+	// MachineRef is the ProxmoxMachine in the same namespace whose VM is snapshotted.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="machineRef is immutable"
+	MachineRef corev1.LocalObjectReference `json:"machineRef"`
+
+	// SnapshotName is the name of the snapshot in Proxmox VE. Defaults to the name of the ProxmoxVMSnapshot,
+	// which must then be a valid snapshot name as well.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z][a-zA-Z0-9_-]{0,39}$`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="snapshotName is immutable"
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// Description is the description of the snapshot.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="description is immutable"
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// IncludeMemory saves the memory of a running VM in the snapshot, so the VM keeps running
+	// after a rollback. Otherwise, the VM is stopped by a rollback and started again by its ProxmoxMachine.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="includeMemory is immutable"
+	// +optional
+	IncludeMemory bool `json:"includeMemory,omitempty"`
+
+	// Rollback rolls the VM back to the snapshot whenever it is set to a value other than the one
+	// of the last rollback, like a timestamp or the ID of a change. A rollback discards all changes
+	// of the VM since the snapshot.
+	// +optional
+	Rollback string `json:"rollback,omitempty"`
+}
+
+// ProxmoxVMSnapshotStatus defines the observed state of ProxmoxVMSnapshot.
+type ProxmoxVMSnapshotStatus struct {
+	// Ready indicates that the snapshot is created.
+	// +optional
+	Ready bool `json:"ready"`
+
+	// SnapshotName is the name of the snapshot in Proxmox VE.
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// VirtualMachineID is the ID of the VM which was snapshotted.
+	// +optional
+	VirtualMachineID int64 `json:"virtualMachineID,omitempty"`
+
+	// CreationTime is the time the snapshot was created.
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+
+	// LastRollback is the value of spec.rollback when the VM was last rolled back to the snapshot.
+	// +optional
+	LastRollback string `json:"lastRollback,omitempty"`
+
+	// RollbackTime is the time the VM was last rolled back to the snapshot.
+	// +optional
+	RollbackTime *metav1.Time `json:"rollbackTime,omitempty"`
+
+	// Conditions defines current service state of the ProxmoxVMSnapshot.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=proxmoxvmsnapshots,scope=Namespaced,categories=proxmox,singular=proxmoxvmsnapshot
+//+kubebuilder:printcolumn:name="Machine",type="string",JSONPath=".spec.machineRef.name",description="ProxmoxMachine whose VM is snapshotted"
+//+kubebuilder:printcolumn:name="Snapshot",type="string",JSONPath=".status.snapshotName",description="Name of the snapshot in Proxmox VE"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Snapshot is created"
+//+kubebuilder:printcolumn:name="Created",type="date",JSONPath=".status.creationTime",description="Time the snapshot was created"
+
+// ProxmoxVMSnapshot is the Schema for the proxmoxvmsnapshots API. It is a named snapshot of the VM
+// of a ProxmoxMachine, which is deleted along with the ProxmoxVMSnapshot.
+type ProxmoxVMSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProxmoxVMSnapshotSpec   `json:"spec,omitempty"`
+	Status ProxmoxVMSnapshotStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProxmoxVMSnapshotList contains a list of ProxmoxVMSnapshot.
+type ProxmoxVMSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProxmoxVMSnapshot `json:"items"`
+}
+
+// GetConditions returns the observations of the operational state of the ProxmoxVMSnapshot resource.
+func (s *ProxmoxVMSnapshot) GetConditions() clusterv1.Conditions {
+	return s.Status.Conditions
+}
+
+// SetConditions sets the underlying service state of the ProxmoxVMSnapshot to the predescribed clusterv1.Conditions.
+func (s *ProxmoxVMSnapshot) SetConditions(conditions clusterv1.Conditions) {
+	s.Status.Conditions = conditions
+}
+
+// GetSnapshotName returns the name of the snapshot in Proxmox VE.
+func (s *ProxmoxVMSnapshot) GetSnapshotName() string {
+	if s.Spec.SnapshotName != "" {
+		return s.Spec.SnapshotName
+	}
+	return s.GetName()
+}
+
+// RollbackRequested returns true if the VM is to be rolled back to the snapshot.
+func (s *ProxmoxVMSnapshot) RollbackRequested() bool {
+	return s.Spec.Rollback != "" && s.Spec.Rollback != s.Status.LastRollback
+}
+
+func init() {
+	SchemeBuilder.Register(&ProxmoxVMSnapshot{}, &ProxmoxVMSnapshotList{})
+}
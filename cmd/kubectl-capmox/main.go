@@ -0,0 +1,159 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-capmox is a kubectl plugin which summarizes the state of a ProxmoxCluster
+// and checks its Proxmox VE endpoint.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/luthermonson/go-proxmox"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/env"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.).
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/diagnostics"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
+)
+
+const usage = `Usage: kubectl capmox <command> <cluster> [flags]
+
+Commands:
+  describe  Summarize the conditions, machines, node locations and IP address claims of a ProxmoxCluster.
+  check     Additionally check the Proxmox API, the eligible nodes, the templates and the tasks of the machines.
+
+The check command connects to Proxmox VE with the PROXMOX_URL, PROXMOX_TOKEN and PROXMOX_SECRET
+environment variables of the controller, unless the flags are set.
+
+Flags:
+`
+
+var (
+	kubeconfig    string
+	kubeContext   string
+	namespace     string
+	proxmoxURL    string
+	proxmoxToken  string
+	proxmoxSecret string
+	skipVerify    bool
+)
+
+func main() {
+	fs := pflag.NewFlagSet("kubectl-capmox", pflag.ExitOnError)
+	fs.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to the kubectl configuration.")
+	fs.StringVar(&kubeContext, "context", "", "The kubeconfig context to use.")
+	fs.StringVarP(&namespace, "namespace", "n", "", "The namespace of the ProxmoxCluster. Defaults to the namespace of the context.")
+	fs.StringVar(&proxmoxURL, "proxmox-url", env.GetString("PROXMOX_URL", ""), "The URL of the Proxmox VE API.")
+	fs.StringVar(&proxmoxToken, "proxmox-token", env.GetString("PROXMOX_TOKEN", ""), "The ID of the Proxmox VE API token.")
+	fs.StringVar(&proxmoxSecret, "proxmox-secret", env.GetString("PROXMOX_SECRET", ""), "The secret of the Proxmox VE API token.")
+	fs.BoolVar(&skipVerify, "insecure-skip-tls-verify", true, "Skip the verification of the certificate of the Proxmox VE API, like the controller.")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[1:])
+
+	if fs.NArg() != 2 || (fs.Arg(0) != "describe" && fs.Arg(0) != "check") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	failed, err := run(context.Background(), fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// run executes the command for the given cluster and reports whether any check failed.
+func run(ctx context.Context, command, cluster string) (failed bool, err error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext})
+
+	if namespace == "" {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return false, err
+		}
+	}
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return false, err
+	}
+
+	scheme := runtime.NewScheme()
+	if err := infrav1alpha1.AddToScheme(scheme); err != nil {
+		return false, err
+	}
+	if err := ipamv1.AddToScheme(scheme); err != nil {
+		return false, err
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return false, err
+	}
+
+	report, err := diagnostics.Collect(ctx, c, client.ObjectKey{Namespace: namespace, Name: cluster})
+	if err != nil {
+		return false, err
+	}
+	if err := report.Write(os.Stdout); err != nil {
+		return false, err
+	}
+	if command != "check" {
+		return false, nil
+	}
+
+	results := check(ctx, report)
+	fmt.Println()
+	if err := diagnostics.WriteChecks(os.Stdout, results); err != nil {
+		return false, err
+	}
+	return diagnostics.Failed(results), nil
+}
+
+func check(ctx context.Context, report *diagnostics.Report) []diagnostics.CheckResult {
+	if proxmoxURL == "" {
+		return []diagnostics.CheckResult{{Name: "Proxmox API", Err: errors.New("no URL set, use --proxmox-url or PROXMOX_URL")}}
+	}
+
+	opts := goproxmox.DefaultTransportOptions()
+	opts.TLSConfig = &tls.Config{InsecureSkipVerify: skipVerify} //nolint:gosec
+	httpClient := &http.Client{Transport: goproxmox.NewTransport(opts)}
+	pc, err := goproxmox.NewAPIClient(ctx, logr.Discard(), proxmoxURL, proxmox.WithHTTPClient(httpClient), proxmox.WithAPIToken(proxmoxToken, proxmoxSecret))
+	if err != nil {
+		return []diagnostics.CheckResult{{Name: "Proxmox API", Err: err}}
+	}
+
+	return diagnostics.RunChecks(ctx, pc, report)
+}
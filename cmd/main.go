@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/luthermonson/go-proxmox"
@@ -48,6 +49,10 @@ import (
 
 	infrastructurev1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/controller"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/extension"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/nocloud"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/vmservice"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/webhook"
 	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
@@ -63,12 +68,48 @@ var (
 	enableWebhooks       bool
 	probeAddr            string
 
+	schedulerCapacityRefreshInterval time.Duration
+	driftCheckInterval               time.Duration
+	nodeStatusRefreshInterval        time.Duration
+	apiProbeInterval                 time.Duration
+	ipPoolExhaustionThreshold        int
+	orphanedVMPolicy                 string
+	orphanedVMCheckInterval          time.Duration
+
+	clusterConcurrency         int
+	machineConcurrency         int
+	machineTemplateConcurrency int
+	diskConcurrency            int
+	snapshotConcurrency        int
+	reconcileRateLimit         controller.ControllerOptions
+
+	proxmoxRequestTimeout time.Duration
+	proxmoxCloneTimeout   time.Duration
+	proxmoxCallTimeout    time.Duration
+	proxmoxResourceTTL    time.Duration
+	proxmoxSlowCall       time.Duration
+	proxmoxBreakerFails   int
+	proxmoxBreakerWait    time.Duration
+	transportOptions      = goproxmox.DefaultTransportOptions()
+
+	metadataServerAddr      string
+	metadataServerURL       string
+	metadataServerNamespace string
+
+	runtimeExtensionPort int
+
 	// ProxmoxURL env variable that defines the Proxmox host.
 	ProxmoxURL string
 	// ProxmoxTokenID env variable that defines the Proxmox token id.
 	ProxmoxTokenID string
 	// ProxmoxSecret env variable that defines the Proxmox secret for the given token id.
 	ProxmoxSecret string
+	// ProxmoxUsername env variable that defines the Proxmox user, as an alternative to a token.
+	ProxmoxUsername string
+	// ProxmoxPassword env variable that defines the password of the Proxmox user.
+	ProxmoxPassword string
+	// ProxmoxTOTPSecret env variable that defines the TOTP secret of a Proxmox user with two-factor authentication.
+	ProxmoxTOTPSecret string
 )
 
 func init() {
@@ -88,6 +129,14 @@ func main() {
 	initFlagsAndEnv(pflag.CommandLine)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
+	if err := validateFlags(); err != nil {
+		setupLog.Error(err, "invalid flags")
+		os.Exit(1)
+	}
+
+	scheduler.SetCapacityRefreshInterval(schedulerCapacityRefreshInterval)
+	vmservice.SetMetadataServerURL(metadataServerURL)
+	vmservice.SetMetadataNamespace(metadataServerNamespace)
 
 	// Machine and cluster operations can create enough events to trigger the event recorder spam filter
 	// Setting the burst size higher ensures all events will be recorded and submitted to the API
@@ -139,11 +188,42 @@ func main() {
 		os.Exit(1)
 	}
 
+	if metadataServerAddr != "" {
+		if err := mgr.Add(&nocloud.Server{
+			Reader:      mgr.GetAPIReader(),
+			Namespace:   metadataServerNamespace,
+			BindAddress: metadataServerAddr,
+			Logger:      mgr.GetLogger().WithName("nocloud"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up NoCloud metadata server")
+			os.Exit(1)
+		}
+	}
+
+	if runtimeExtensionPort > 0 {
+		extensionServer, err := extension.NewServer(mgr.GetScheme(), runtimeExtensionPort)
+		if err == nil {
+			err = mgr.Add(extensionServer)
+		}
+		if err != nil {
+			setupLog.Error(err, "unable to set up runtime extension")
+			os.Exit(1)
+		}
+	}
+
 	if enableWebhooks {
 		if err = (&webhook.ProxmoxCluster{}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "ProxmoxCluster")
 			os.Exit(1)
 		}
+		if err = (&webhook.ProxmoxMachineTemplate{ProxmoxClient: pmoxClient}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ProxmoxMachineTemplate")
+			os.Exit(1)
+		}
+		if err = (&webhook.ProxmoxMachine{ProxmoxClient: pmoxClient}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ProxmoxMachine")
+			os.Exit(1)
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
@@ -165,37 +245,122 @@ func main() {
 
 func setupReconcilers(ctx context.Context, mgr ctrl.Manager, client capmox.Client) error {
 	if err := (&controller.ProxmoxClusterReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		Recorder:      mgr.GetEventRecorderFor("proxmoxcluster-controller"),
-		ProxmoxClient: client,
+		Client:                    mgr.GetClient(),
+		Scheme:                    mgr.GetScheme(),
+		Recorder:                  mgr.GetEventRecorderFor("proxmoxcluster-controller"),
+		ProxmoxClient:             client,
+		NodeStatusRefreshInterval: nodeStatusRefreshInterval,
+		APIProbeInterval:          apiProbeInterval,
+		IPPoolExhaustionThreshold: ipPoolExhaustionThreshold,
+		ControllerOptions:         controllerOptions(clusterConcurrency),
 	}).SetupWithManager(ctx, mgr); err != nil {
 		return fmt.Errorf("setting up ProxmoxCluster controller: %w", err)
 	}
 	if err := (&controller.ProxmoxMachineReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		Recorder:      mgr.GetEventRecorderFor("proxmoxmachine-controller"),
-		ProxmoxClient: client,
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("proxmoxmachine-controller"),
+		ProxmoxClient:      client,
+		DriftCheckInterval: driftCheckInterval,
+		ControllerOptions:  controllerOptions(machineConcurrency),
 	}).SetupWithManager(mgr); err != nil {
 		return fmt.Errorf("setting up ProxmoxMachine controller: %w", err)
 	}
+	if err := (&controller.ProxmoxMachineTemplateReconciler{
+		Client:            mgr.GetClient(),
+		ProxmoxClient:     client,
+		SourceClients:     capmox.NewClientPool(newSourceClient),
+		ControllerOptions: controllerOptions(machineTemplateConcurrency),
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("setting up ProxmoxMachineTemplate controller: %w", err)
+	}
+	if err := (&controller.ProxmoxDiskReconciler{
+		Client:            mgr.GetClient(),
+		ProxmoxClient:     client,
+		ControllerOptions: controllerOptions(diskConcurrency),
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("setting up ProxmoxDisk controller: %w", err)
+	}
+	if err := (&controller.ProxmoxVMSnapshotReconciler{
+		Client:            mgr.GetClient(),
+		ProxmoxClient:     client,
+		ControllerOptions: controllerOptions(snapshotConcurrency),
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("setting up ProxmoxVMSnapshot controller: %w", err)
+	}
+	if orphanedVMPolicy != "" {
+		if err := (&controller.OrphanedVMCollector{
+			Client:        mgr.GetClient(),
+			Recorder:      mgr.GetEventRecorderFor("orphaned-vm-collector"),
+			ProxmoxClient: client,
+			Policy:        controller.OrphanedVMPolicy(orphanedVMPolicy),
+			Interval:      orphanedVMCheckInterval,
+		}).SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("setting up orphaned VM collector: %w", err)
+		}
+	}
 
 	return nil
 }
 
+// controllerOptions returns the options of a controller reconciling the given number of objects in parallel.
+func controllerOptions(concurrency int) controller.ControllerOptions {
+	opts := reconcileRateLimit
+	opts.MaxConcurrentReconciles = concurrency
+	return opts
+}
+
 func setupProxmoxClient(ctx context.Context, logger logr.Logger) (capmox.Client, error) {
 	// TODO, check if we need to delete tls config
 	// You can disable security check for a client:
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+	transportOptions.TLSConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	tr := goproxmox.NewTransport(transportOptions)
+
+	timeoutTransport := goproxmox.NewTimeoutTransport(tr, proxmoxRequestTimeout, proxmoxCloneTimeout)
+	// the breaker wraps the timeouts, so requests to hung endpoints count as failures.
+	breakerTransport := goproxmox.NewCircuitBreakerTransport(timeoutTransport, proxmoxBreakerFails, proxmoxBreakerWait)
+	httpClient := &http.Client{Transport: breakerTransport}
+	options := []proxmox.Option{proxmox.WithHTTPClient(httpClient)}
+	if ProxmoxUsername == "" {
+		options = append(options, proxmox.WithAPIToken(ProxmoxTokenID, ProxmoxSecret))
+	} else {
+		// tickets expire, the transport renews them instead of go-proxmox logging in only once.
+		ticketTransport, err := goproxmox.NewTicketTransport(ProxmoxURL, proxmox.Credentials{
+			Username: ProxmoxUsername,
+			Password: ProxmoxPassword,
+		}, breakerTransport)
+		if err != nil {
+			return nil, err
+		}
+		ticketTransport.TOTPSecret = ProxmoxTOTPSecret
+		httpClient.Transport = ticketTransport
 	}
 
-	httpClient := &http.Client{Transport: tr}
-	return goproxmox.NewAPIClient(ctx, logger, ProxmoxURL,
-		proxmox.WithHTTPClient(httpClient),
-		proxmox.WithAPIToken(ProxmoxTokenID, ProxmoxSecret),
-	)
+	apiClient, err := goproxmox.NewAPIClient(ctx, logger, ProxmoxURL, options...)
+	if err != nil {
+		return nil, err
+	}
+	apiClient.ResourceCacheTTL = proxmoxResourceTTL
+
+	client := goproxmox.NewInstrumentedClient(apiClient, logger.WithName("proxmox"))
+	client.CallTimeout = proxmoxCallTimeout
+	if proxmoxCallTimeout > 0 && proxmoxCloneTimeout > 0 {
+		client.CloneTimeout = proxmoxCallTimeout + proxmoxCloneTimeout
+	} else {
+		client.CloneTimeout = 0
+	}
+	client.SlowCallThreshold = proxmoxSlowCall
+	return client, nil
+}
+
+// newSourceClient connects to the source cluster of a template VM, using the transport options of the Proxmox client.
+func newSourceClient(ctx context.Context, url, tokenID, secret string) (capmox.Client, error) {
+	httpClient := &http.Client{Transport: goproxmox.NewTimeoutTransport(goproxmox.NewTransport(transportOptions), proxmoxRequestTimeout, proxmoxCloneTimeout)}
+	apiClient, err := goproxmox.NewAPIClient(ctx, ctrl.LoggerFrom(ctx), url, proxmox.WithHTTPClient(httpClient), proxmox.WithAPIToken(tokenID, secret))
+	if err != nil {
+		return nil, err
+	}
+	return apiClient, nil
 }
 
 func initFlagsAndEnv(fs *pflag.FlagSet) {
@@ -204,6 +369,9 @@ func initFlagsAndEnv(fs *pflag.FlagSet) {
 	ProxmoxURL = env.GetString("PROXMOX_URL", "")
 	ProxmoxTokenID = env.GetString("PROXMOX_TOKEN", "")
 	ProxmoxSecret = env.GetString("PROXMOX_SECRET", "")
+	ProxmoxUsername = env.GetString("PROXMOX_USERNAME", "")
+	ProxmoxPassword = env.GetString("PROXMOX_PASSWORD", "")
+	ProxmoxTOTPSecret = env.GetString("PROXMOX_TOTP_SECRET", "")
 
 	fs.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	fs.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -212,6 +380,73 @@ func initFlagsAndEnv(fs *pflag.FlagSet) {
 			"Enabling this will ensure there is only one active controller manager.")
 	fs.BoolVar(&enableWebhooks, "enable-webhooks", true,
 		"If true, run webhook server alongside manager")
+	fs.DurationVar(&schedulerCapacityRefreshInterval, "scheduler-capacity-refresh-interval", scheduler.DefaultCapacityRefreshInterval,
+		"The interval after which the scheduler fetches the capacity and the inventory of the Proxmox nodes again. Set to 0 to disable caching.")
+	fs.DurationVar(&driftCheckInterval, "drift-check-interval", 5*time.Minute,
+		"The interval in which ready machines are checked for drift of their VM config. Set to 0 to disable periodic checks.")
+	fs.DurationVar(&nodeStatusRefreshInterval, "node-status-refresh-interval", 5*time.Minute,
+		"The interval in which the summaries of the eligible Proxmox nodes in the ProxmoxCluster status are refreshed. Set to 0 to disable them.")
+	fs.DurationVar(&apiProbeInterval, "proxmox-api-probe-interval", time.Minute,
+		"The interval in which the Proxmox API is probed to maintain the ProxmoxAPIReachable condition of ProxmoxClusters. Set to 0 to disable the probe.")
+	fs.IntVar(&ipPoolExhaustionThreshold, "ip-pool-exhaustion-threshold", controller.DefaultIPPoolExhaustionThreshold,
+		"The percentage of used addresses from which an IP pool of a ProxmoxCluster is reported as nearly exhausted. Set to 0 to only report pools without free addresses.")
+	fs.StringVar(&orphanedVMPolicy, "orphaned-vm-policy", "",
+		"Whether VMs tagged with a cluster but not referenced by any ProxmoxMachine are reported (Report) or deleted (Delete). Empty disables the check.")
+	fs.DurationVar(&orphanedVMCheckInterval, "orphaned-vm-check-interval", 10*time.Minute,
+		"The interval in which VMs are checked for missing ProxmoxMachines, if an orphaned VM policy is set.")
+	fs.IntVar(&clusterConcurrency, "proxmoxcluster-concurrency", 1,
+		"The number of ProxmoxClusters which are reconciled in parallel.")
+	fs.IntVar(&machineConcurrency, "proxmoxmachine-concurrency", 1,
+		"The number of ProxmoxMachines which are reconciled in parallel.")
+	fs.IntVar(&machineTemplateConcurrency, "proxmoxmachinetemplate-concurrency", 1,
+		"The number of ProxmoxMachineTemplates which are reconciled in parallel.")
+	fs.IntVar(&diskConcurrency, "proxmoxdisk-concurrency", 1,
+		"The number of ProxmoxDisks which are reconciled in parallel.")
+	fs.IntVar(&snapshotConcurrency, "proxmoxvmsnapshot-concurrency", 1,
+		"The number of ProxmoxVMSnapshots which are reconciled in parallel.")
+	fs.DurationVar(&reconcileRateLimit.BackoffBase, "reconcile-backoff-base", controller.DefaultReconcileBackoffBase,
+		"The delay after the first requeue of an object, which doubles with every further requeue.")
+	fs.DurationVar(&reconcileRateLimit.BackoffMax, "reconcile-backoff-max", controller.DefaultReconcileBackoffMax,
+		"The maximum delay between requeues of the same object.")
+	fs.Float64Var(&reconcileRateLimit.QPS, "reconcile-qps", controller.DefaultReconcileQPS,
+		"The overall number of requeues per second of each controller.")
+	fs.IntVar(&reconcileRateLimit.Burst, "reconcile-burst", controller.DefaultReconcileBurst,
+		"The number of requeues of each controller which may exceed the rate at once.")
+	fs.DurationVar(&transportOptions.ConnectTimeout, "proxmox-connect-timeout", goproxmox.DefaultConnectTimeout,
+		"The timeout for connecting to the Proxmox API. Set to 0 to disable the timeout.")
+	fs.DurationVar(&proxmoxRequestTimeout, "proxmox-request-timeout", goproxmox.DefaultRequestTimeout,
+		"The timeout of a request to the Proxmox API. Set to 0 to disable the timeout.")
+	fs.DurationVar(&proxmoxCloneTimeout, "proxmox-clone-timeout", goproxmox.DefaultCloneTimeout,
+		"The timeout of a request to clone a VM, which overrides the request timeout. Set to 0 to disable the timeout.")
+	fs.IntVar(&transportOptions.MaxIdleConnsPerHost, "proxmox-max-idle-conns-per-host", goproxmox.DefaultMaxIdleConnsPerHost,
+		"The number of idle connections kept to the Proxmox API.")
+	fs.DurationVar(&transportOptions.IdleConnTimeout, "proxmox-idle-conn-timeout", goproxmox.DefaultIdleConnTimeout,
+		"The duration an idle connection to the Proxmox API is kept open.")
+	fs.BoolVar(&transportOptions.DisableKeepAlives, "proxmox-disable-keep-alives", false,
+		"If true, a new connection is opened for every request to the Proxmox API.")
+	fs.BoolVar(&transportOptions.EnableHTTP2, "proxmox-enable-http2", false,
+		"If true, HTTP/2 is used to connect to the Proxmox API if the server supports it.")
+	fs.BoolVar(&transportOptions.ProxyFromEnvironment, "proxmox-proxy-from-environment", false,
+		"If true, the Proxmox API is connected to through the proxy of the HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables.")
+	fs.DurationVar(&proxmoxCallTimeout, "proxmox-call-timeout", goproxmox.DefaultCallTimeout,
+		"The deadline of a call to the Proxmox API client, which may consist of several requests. Set to 0 to disable the deadline.")
+	fs.DurationVar(&proxmoxSlowCall, "proxmox-slow-call-threshold", goproxmox.DefaultSlowCallThreshold,
+		"The duration after which a call to the Proxmox API client is logged as slow. Set to 0 to disable logging.")
+	fs.DurationVar(&proxmoxResourceTTL, "proxmox-resource-cache-ttl", goproxmox.DefaultResourceCacheTTL,
+		"The duration for which the list of all VMs of the Proxmox cluster is reused. Set to 0 to disable caching.")
+	fs.IntVar(&proxmoxBreakerFails, "proxmox-circuit-breaker-threshold", goproxmox.DefaultCircuitBreakerThreshold,
+		"The number of consecutive failed requests to the Proxmox API after which requests are rejected. Set to 0 to disable the circuit breaker.")
+	fs.DurationVar(&proxmoxBreakerWait, "proxmox-circuit-breaker-cooldown", goproxmox.DefaultCircuitBreakerCooldown,
+		"The duration requests to the Proxmox API are rejected, before a single request probes whether it recovered.")
+
+	fs.StringVar(&metadataServerAddr, "metadata-server-bind-address", "",
+		"The address the NoCloud metadata server binds to. The server is disabled if empty.")
+	fs.StringVar(&metadataServerURL, "metadata-server-url", "",
+		"The URL of the NoCloud metadata server as seen from the VMs, which is required for the NoCloudNet cloud-init format.")
+	fs.StringVar(&metadataServerNamespace, "metadata-server-namespace", env.GetString("POD_NAMESPACE", ""),
+		"The namespace of the secrets served by the NoCloud metadata server. It defaults to the namespace of the manager, which the manager may write secrets to.")
+	fs.IntVar(&runtimeExtensionPort, "runtime-extension-port", 0,
+		"The port the Cluster API Runtime Extension serves at, with the certificate of the webhooks. The extension is disabled if 0.")
 
 	feature.MutableGates.AddFlag(fs)
 
@@ -222,10 +457,38 @@ func initFlagsAndEnv(fs *pflag.FlagSet) {
 	}
 }
 
+// validateFlags checks the flags, which are only known after parsing them.
+func validateFlags() error {
+	for flag, concurrency := range map[string]int{
+		"proxmoxcluster-concurrency":         clusterConcurrency,
+		"proxmoxmachine-concurrency":         machineConcurrency,
+		"proxmoxmachinetemplate-concurrency": machineTemplateConcurrency,
+		"proxmoxdisk-concurrency":            diskConcurrency,
+		"proxmoxvmsnapshot-concurrency":      snapshotConcurrency,
+	} {
+		if concurrency < 1 {
+			return fmt.Errorf("flag `--%s` must be at least 1", flag)
+		}
+	}
+	if ipPoolExhaustionThreshold < 0 || ipPoolExhaustionThreshold > 100 {
+		return errors.New("flag `--ip-pool-exhaustion-threshold` must be between 0 and 100")
+	}
+	if (metadataServerAddr != "" || metadataServerURL != "") && metadataServerNamespace == "" {
+		return errors.New("flag `--metadata-server-namespace` or variable `POD_NAMESPACE` must be set for the NoCloud metadata server")
+	}
+	return nil
+}
+
 func validate() error {
 	if ProxmoxURL == "" {
 		return errors.New("required variable `PROXMOX_URL` is not set")
 	}
+	if ProxmoxUsername != "" {
+		if ProxmoxPassword == "" {
+			return errors.New("required variable `PROXMOX_PASSWORD` is not set")
+		}
+		return nil
+	}
 	if ProxmoxTokenID == "" {
 		return errors.New("required variable `PROXMOX_TOKEN` is not set")
 	}
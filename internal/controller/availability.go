@@ -0,0 +1,43 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// reconcileProxmoxAvailability sets the ProxmoxAvailable condition from the result of a reconcile.
+// Errors of calls which the circuit breaker rejected are not returned, so they neither flood the logs
+// nor requeue with backoff. Instead, the object is requeued once the breaker lets a request through again.
+func reconcileProxmoxAvailability(obj conditions.Setter, res ctrl.Result, err error) (ctrl.Result, error) {
+	if circuitErr := new(proxmox.CircuitOpenError); errors.As(err, &circuitErr) {
+		conditions.MarkFalse(obj, infrav1alpha1.ProxmoxAvailableCondition, infrav1alpha1.CircuitOpenReason, clusterv1.ConditionSeverityWarning,
+			"%s", circuitErr.Error())
+		return ctrl.Result{RequeueAfter: circuitErr.RetryAfter}, nil
+	}
+
+	if err == nil && conditions.Has(obj, infrav1alpha1.ProxmoxAvailableCondition) {
+		conditions.MarkTrue(obj, infrav1alpha1.ProxmoxAvailableCondition)
+	}
+	return res, err
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestReconcileProxmoxAvailability(t *testing.T) {
+	machine := &infrav1alpha1.ProxmoxMachine{}
+
+	// other results are passed through without setting the condition.
+	res, err := reconcileProxmoxAvailability(machine, ctrl.Result{Requeue: true}, nil)
+	require.NoError(t, err)
+	require.True(t, res.Requeue)
+	require.False(t, conditions.Has(machine, infrav1alpha1.ProxmoxAvailableCondition))
+
+	circuitErr := errors.Wrap(&proxmox.CircuitOpenError{RetryAfter: 20 * time.Second}, "failed to reconcile VM")
+	res, err = reconcileProxmoxAvailability(machine, ctrl.Result{}, circuitErr)
+	require.NoError(t, err)
+	require.Equal(t, 20*time.Second, res.RequeueAfter)
+	require.True(t, conditions.IsFalse(machine, infrav1alpha1.ProxmoxAvailableCondition))
+	require.Equal(t, infrav1alpha1.CircuitOpenReason, conditions.GetReason(machine, infrav1alpha1.ProxmoxAvailableCondition))
+
+	_, err = reconcileProxmoxAvailability(machine, ctrl.Result{}, errors.New("VM not found"))
+	require.Error(t, err)
+	require.True(t, conditions.IsFalse(machine, infrav1alpha1.ProxmoxAvailableCondition))
+
+	_, err = reconcileProxmoxAvailability(machine, ctrl.Result{}, nil)
+	require.NoError(t, err)
+	require.True(t, conditions.IsTrue(machine, infrav1alpha1.ProxmoxAvailableCondition))
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// backupJobID returns the ID of the backup job of a cluster. Proxmox VE does not allow dots in IDs.
+func backupJobID(clusterScope *scope.ClusterScope) string {
+	return strings.ReplaceAll(fmt.Sprintf("capmox-%s-%s", clusterScope.Namespace(), clusterScope.Name()), ".", "-")
+}
+
+// backupJobComment returns the comment of the backup job of a cluster, which marks it as owned by the cluster.
+func backupJobComment(clusterScope *scope.ClusterScope) string {
+	return fmt.Sprintf("managed by ProxmoxCluster %s/%s", clusterScope.Namespace(), clusterScope.Name())
+}
+
+// pruneBackups returns the retention as a prune-backups property string with sorted keys.
+func pruneBackups(retention *infrav1alpha1.BackupRetention) string {
+	if retention == nil {
+		return ""
+	}
+	var keep []string
+	for _, option := range []struct {
+		key   string
+		value *int32
+	}{
+		{"keep-daily", retention.KeepDaily},
+		{"keep-last", retention.KeepLast},
+		{"keep-monthly", retention.KeepMonthly},
+		{"keep-weekly", retention.KeepWeekly},
+		{"keep-yearly", retention.KeepYearly},
+	} {
+		if option.value != nil {
+			keep = append(keep, fmt.Sprintf("%s=%d", option.key, *option.value))
+		}
+	}
+	return strings.Join(keep, ",")
+}
+
+// sortedPropertyString sorts the properties of a property string, so it can be compared.
+func sortedPropertyString(s string) string {
+	if s == "" {
+		return ""
+	}
+	properties := strings.Split(s, ",")
+	sort.Strings(properties)
+	return strings.Join(properties, ",")
+}
+
+// backupVMIDs returns the sorted VMIDs of the machines which are backed up.
+func backupVMIDs(machines []infrav1alpha1.ProxmoxMachine) []int64 {
+	vmids := []int64{}
+	for i := range machines {
+		m := &machines[i]
+		if m.Spec.ExcludeFromBackup || !m.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if id := m.GetVirtualMachineID(); id > 0 {
+			vmids = append(vmids, id)
+		}
+	}
+	sort.Slice(vmids, func(i, j int) bool { return vmids[i] < vmids[j] })
+	return vmids
+}
+
+// backupSelectionChanged reports whether a machine got a VM or whether it was excluded from
+// or included in the backup job, which changes the VMs of the job.
+func backupSelectionChanged(oldObj, newObj client.Object) bool {
+	oldMachine, ok := oldObj.(*infrav1alpha1.ProxmoxMachine)
+	if !ok {
+		return false
+	}
+	newMachine, ok := newObj.(*infrav1alpha1.ProxmoxMachine)
+	if !ok {
+		return false
+	}
+	return oldMachine.GetVirtualMachineID() != newMachine.GetVirtualMachineID() ||
+		oldMachine.Spec.ExcludeFromBackup != newMachine.Spec.ExcludeFromBackup
+}
+
+// findBackupJob returns the backup job with the given ID, or nil if there is none.
+func findBackupJob(ctx context.Context, client proxmox.Client, id string) (*proxmox.BackupJob, error) {
+	jobs, err := client.ListBackupJobs(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list backup jobs")
+	}
+	for i := range jobs {
+		if jobs[i].ID == id {
+			return &jobs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// sameBackupJob reports whether an existing job has the desired settings.
+func sameBackupJob(existing, desired *proxmox.BackupJob) bool {
+	return existing.Schedule == desired.Schedule &&
+		existing.Storage == desired.Storage &&
+		existing.Mode == desired.Mode &&
+		reflect.DeepEqual(existing.VMIDs, desired.VMIDs) &&
+		sortedPropertyString(existing.PruneBackups) == desired.PruneBackups &&
+		existing.Comment == desired.Comment &&
+		existing.Enabled
+}
+
+// reconcileBackup makes sure the backup job of the cluster backs up the VMs of its machines
+// with the configured policy. The job is deleted if the policy is removed.
+func (r *ProxmoxClusterReconciler) reconcileBackup(ctx context.Context, clusterScope *scope.ClusterScope) error {
+	policy := clusterScope.ProxmoxCluster.Spec.Backup
+	if policy == nil {
+		if err := r.reconcileDeleteBackup(ctx, clusterScope); err != nil {
+			return err
+		}
+		conditions.Delete(clusterScope.ProxmoxCluster, infrav1alpha1.BackupJobReadyCondition)
+		return nil
+	}
+	client := clusterScope.ProxmoxClient
+
+	machines, err := r.listProxmoxMachinesForCluster(ctx, clusterScope)
+	if err != nil {
+		return errors.Wrap(err, "unable to list proxmox machines")
+	}
+
+	desired := proxmox.BackupJob{
+		ID:           backupJobID(clusterScope),
+		Schedule:     policy.Schedule,
+		Storage:      policy.Storage,
+		Mode:         proxmox.BackupMode(policy.Mode),
+		VMIDs:        backupVMIDs(machines),
+		PruneBackups: pruneBackups(policy.Retention),
+		Comment:      backupJobComment(clusterScope),
+		Enabled:      true,
+	}
+	if desired.Mode == "" {
+		desired.Mode = proxmox.BackupModeSnapshot
+	}
+
+	existing, err := findBackupJob(ctx, client, desired.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Comment != desired.Comment {
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.BackupJobReadyCondition, infrav1alpha1.BackupJobFailedReason, clusterv1.ConditionSeverityError,
+			"backup job %s exists, but is not managed by the cluster", desired.ID)
+		return errors.Errorf("backup job %s exists, but is not managed by the cluster", desired.ID)
+	}
+
+	switch {
+	case len(desired.VMIDs) == 0:
+		// proxmox rejects jobs without VMs.
+		if existing != nil {
+			clusterScope.Info("deleting backup job without vms", "job", desired.ID)
+			if err := client.DeleteBackupJob(ctx, desired.ID); err != nil {
+				return err
+			}
+		}
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.BackupJobReadyCondition, infrav1alpha1.WaitingForVMsReason, clusterv1.ConditionSeverityInfo, "")
+		return nil
+	case existing == nil:
+		clusterScope.Info("creating backup job", "job", desired.ID, "vmids", desired.VMIDs)
+		err = client.CreateBackupJob(ctx, desired)
+	case !sameBackupJob(existing, &desired):
+		clusterScope.Info("updating backup job", "job", desired.ID, "vmids", desired.VMIDs)
+		err = client.UpdateBackupJob(ctx, desired)
+	}
+	if err != nil {
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.BackupJobReadyCondition, infrav1alpha1.BackupJobFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return err
+	}
+
+	conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.BackupJobReadyCondition)
+	return nil
+}
+
+// reconcileDeleteBackup deletes the backup job of a cluster if it is managed by the cluster.
+func (r *ProxmoxClusterReconciler) reconcileDeleteBackup(ctx context.Context, clusterScope *scope.ClusterScope) error {
+	if clusterScope.ProxmoxCluster.Spec.Backup == nil && !conditions.Has(clusterScope.ProxmoxCluster, infrav1alpha1.BackupJobReadyCondition) {
+		// the cluster never had a backup job.
+		return nil
+	}
+	client := clusterScope.ProxmoxClient
+
+	existing, err := findBackupJob(ctx, client, backupJobID(clusterScope))
+	if err != nil {
+		return err
+	}
+	// jobs which were not created by the provider are left alone.
+	if existing == nil || existing.Comment != backupJobComment(clusterScope) {
+		return nil
+	}
+
+	clusterScope.Info("deleting backup job", "job", existing.ID)
+	return client.DeleteBackupJob(ctx, existing.ID)
+}
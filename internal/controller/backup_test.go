@@ -0,0 +1,147 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+func newBackupTest(t *testing.T, machines ...*infrav1.ProxmoxMachine) (*ProxmoxClusterReconciler, *scope.ClusterScope, *proxmoxtest.MockClient) {
+	objects := make([]client.Object, 0, len(machines))
+	for _, m := range machines {
+		objects = append(objects, m)
+	}
+	clusterScope, proxmoxClient := newTestClusterScope(t, infrav1.ProxmoxClusterSpec{
+		Backup: &infrav1.BackupPolicy{
+			Schedule:  "daily",
+			Storage:   "pbs",
+			Mode:      "snapshot",
+			Retention: &infrav1.BackupRetention{KeepLast: ptr.To[int32](3), KeepDaily: ptr.To[int32](7)},
+		},
+	})
+	return &ProxmoxClusterReconciler{Client: newTestClient(t, objects...)}, clusterScope, proxmoxClient
+}
+
+func newBackupMachine(name string, vmid int64, exclude bool) *infrav1.ProxmoxMachine {
+	return &infrav1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault, Labels: map[string]string{clusterv1.ClusterNameLabel: "test"}},
+		Spec:       infrav1.ProxmoxMachineSpec{VirtualMachineID: ptr.To(vmid), ExcludeFromBackup: exclude},
+	}
+}
+
+func TestReconcileBackup(t *testing.T) {
+	ctx := context.Background()
+	r, clusterScope, proxmoxClient := newBackupTest(t,
+		newBackupMachine("test-1", 101, false),
+		newBackupMachine("test-0", 100, false),
+		newBackupMachine("test-2", 102, true),
+	)
+
+	job := proxmox.BackupJob{
+		ID:           "capmox-default-test",
+		Schedule:     "daily",
+		Storage:      "pbs",
+		Mode:         proxmox.BackupModeSnapshot,
+		VMIDs:        []int64{100, 101},
+		PruneBackups: "keep-daily=7,keep-last=3",
+		Comment:      "managed by ProxmoxCluster default/test",
+		Enabled:      true,
+	}
+	proxmoxClient.EXPECT().ListBackupJobs(ctx).Return(nil, nil).Once()
+	proxmoxClient.EXPECT().CreateBackupJob(ctx, job).Return(nil).Once()
+	require.NoError(t, r.reconcileBackup(ctx, clusterScope))
+	require.True(t, conditions.IsTrue(clusterScope.ProxmoxCluster, infrav1.BackupJobReadyCondition))
+
+	// an unchanged job is not updated, regardless of the order of the retention.
+	existing := job
+	existing.PruneBackups = "keep-last=3,keep-daily=7"
+	proxmoxClient.EXPECT().ListBackupJobs(ctx).Return([]proxmox.BackupJob{existing}, nil).Once()
+	require.NoError(t, r.reconcileBackup(ctx, clusterScope))
+
+	clusterScope.ProxmoxCluster.Spec.Backup.Schedule = "sat 02:00"
+	job.Schedule = "sat 02:00"
+	proxmoxClient.EXPECT().ListBackupJobs(ctx).Return([]proxmox.BackupJob{existing}, nil).Once()
+	proxmoxClient.EXPECT().UpdateBackupJob(ctx, job).Return(nil).Once()
+	require.NoError(t, r.reconcileBackup(ctx, clusterScope))
+
+	// the job is deleted once the policy is removed.
+	clusterScope.ProxmoxCluster.Spec.Backup = nil
+	proxmoxClient.EXPECT().ListBackupJobs(ctx).Return([]proxmox.BackupJob{job}, nil).Once()
+	proxmoxClient.EXPECT().DeleteBackupJob(ctx, "capmox-default-test").Return(nil).Once()
+	require.NoError(t, r.reconcileBackup(ctx, clusterScope))
+	require.False(t, conditions.Has(clusterScope.ProxmoxCluster, infrav1.BackupJobReadyCondition))
+
+	// without a policy and a condition, the API is not called.
+	require.NoError(t, r.reconcileBackup(ctx, clusterScope))
+}
+
+func TestReconcileBackup_WaitingForVMs(t *testing.T) {
+	ctx := context.Background()
+	r, clusterScope, proxmoxClient := newBackupTest(t, newBackupMachine("test-0", 100, true))
+
+	// a job left without VMs is deleted, as Proxmox VE requires a selection of VMs.
+	proxmoxClient.EXPECT().ListBackupJobs(ctx).Return([]proxmox.BackupJob{{ID: "capmox-default-test", Comment: "managed by ProxmoxCluster default/test"}}, nil).Once()
+	proxmoxClient.EXPECT().DeleteBackupJob(ctx, "capmox-default-test").Return(nil).Once()
+	require.NoError(t, r.reconcileBackup(ctx, clusterScope))
+	require.Equal(t, infrav1.WaitingForVMsReason, conditions.GetReason(clusterScope.ProxmoxCluster, infrav1.BackupJobReadyCondition))
+}
+
+func TestReconcileBackup_Conflict(t *testing.T) {
+	ctx := context.Background()
+	r, clusterScope, proxmoxClient := newBackupTest(t, newBackupMachine("test-0", 100, false))
+
+	proxmoxClient.EXPECT().ListBackupJobs(ctx).Return([]proxmox.BackupJob{{ID: "capmox-default-test", Comment: "manual"}}, nil).Twice()
+	require.ErrorContains(t, r.reconcileBackup(ctx, clusterScope), "not managed by the cluster")
+	require.Equal(t, infrav1.BackupJobFailedReason, conditions.GetReason(clusterScope.ProxmoxCluster, infrav1.BackupJobReadyCondition))
+
+	// jobs which were not created for the cluster are kept.
+	require.NoError(t, r.reconcileDeleteBackup(ctx, clusterScope))
+}
+
+func TestBackupSelectionChanged(t *testing.T) {
+	machine := newBackupMachine("test-0", 100, false)
+	require.False(t, backupSelectionChanged(machine, machine.DeepCopy()))
+
+	changed := machine.DeepCopy()
+	changed.Spec.VirtualMachineID = ptr.To[int64](101)
+	require.True(t, backupSelectionChanged(machine, changed))
+
+	changed = machine.DeepCopy()
+	changed.Spec.ExcludeFromBackup = true
+	require.True(t, backupSelectionChanged(machine, changed))
+}
+
+func TestBackupJobID(t *testing.T) {
+	clusterScope := &scope.ClusterScope{
+		Cluster:        &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test.v2", Namespace: "team.a"}},
+		ProxmoxCluster: &infrav1.ProxmoxCluster{ObjectMeta: metav1.ObjectMeta{Name: "test.v2", Namespace: "team.a"}},
+	}
+	require.Equal(t, "capmox-team-a-test-v2", backupJobID(clusterScope))
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
+)
+
+// capacityRequeueInterval is the interval in which the placement of pending machines is simulated again.
+const capacityRequeueInterval = time.Minute
+
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments,verbs=get;list;watch
+
+// reconcileCapacity simulates the placement of the machines which the MachineDeployments of the template have yet
+// to create, so that a lack of memory shows before the machines fail to schedule one by one.
+// It returns whether machines are pending.
+func (r *ProxmoxMachineTemplateReconciler) reconcileCapacity(
+	ctx context.Context,
+	template *infrav1alpha1.ProxmoxMachineTemplate,
+	proxmoxCluster *infrav1alpha1.ProxmoxCluster,
+	machine *infrav1alpha1.ProxmoxMachine,
+) (bool, error) {
+	pending, err := r.pendingMachines(ctx, template)
+	if err != nil {
+		return false, err
+	}
+	if pending == 0 {
+		conditions.MarkTrue(template, infrav1alpha1.CapacityAvailableCondition)
+		return false, nil
+	}
+
+	nodes, err := scheduler.SimulatePlacement(ctx, r.ProxmoxClient, proxmoxCluster, machine, false, pending)
+	if errors.Is(err, scheduler.ErrNoEligibleNodes) {
+		conditions.MarkFalse(template, infrav1alpha1.CapacityAvailableCondition, infrav1alpha1.InsufficientCapacityReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return true, nil
+	}
+	if err != nil {
+		return true, errors.Wrap(err, "unable to simulate the placement of pending machines")
+	}
+
+	if len(nodes) < pending {
+		conditions.MarkFalse(template, infrav1alpha1.CapacityAvailableCondition, infrav1alpha1.InsufficientCapacityReason, clusterv1.ConditionSeverityWarning,
+			"only %d of %d pending machines fit on the eligible nodes", len(nodes), pending)
+		return true, nil
+	}
+	conditions.MarkTrue(template, infrav1alpha1.CapacityAvailableCondition)
+	return true, nil
+}
+
+// pendingMachines returns the number of replicas of the MachineDeployments using the template,
+// less the machines cloned from the template which have a VM already.
+func (r *ProxmoxMachineTemplateReconciler) pendingMachines(ctx context.Context, template *infrav1alpha1.ProxmoxMachineTemplate) (int, error) {
+	deployments := &clusterv1.MachineDeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(template.GetNamespace())); err != nil {
+		return 0, errors.Wrap(err, "unable to list MachineDeployments")
+	}
+	pending := 0
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if usesMachineTemplate(deployment, template.GetName()) && deployment.DeletionTimestamp.IsZero() {
+			pending += int(ptr.Deref(deployment.Spec.Replicas, 1))
+		}
+	}
+	if pending == 0 {
+		return 0, nil
+	}
+
+	machines := &infrav1alpha1.ProxmoxMachineList{}
+	if err := r.List(ctx, machines, client.InNamespace(template.GetNamespace())); err != nil {
+		return 0, errors.Wrap(err, "unable to list ProxmoxMachines")
+	}
+	for i := range machines.Items {
+		machine := &machines.Items[i]
+		if machine.GetAnnotations()[clusterv1.TemplateClonedFromNameAnnotation] == template.GetName() && machine.GetVirtualMachineID() > 0 {
+			pending--
+		}
+	}
+	if pending < 0 {
+		return 0, nil
+	}
+	return pending, nil
+}
+
+// usesMachineTemplate returns true if the machines of the MachineDeployment are created from the ProxmoxMachineTemplate.
+func usesMachineTemplate(deployment *clusterv1.MachineDeployment, template string) bool {
+	ref := deployment.Spec.Template.Spec.InfrastructureRef
+	return ref.Kind == "ProxmoxMachineTemplate" && ref.Name == template
+}
+
+// machineDeploymentToTemplate enqueues the ProxmoxMachineTemplate of a MachineDeployment.
+func (r *ProxmoxMachineTemplateReconciler) machineDeploymentToTemplate(_ context.Context, o client.Object) []reconcile.Request {
+	deployment, ok := o.(*clusterv1.MachineDeployment)
+	if !ok {
+		return nil
+	}
+	ref := deployment.Spec.Template.Spec.InfrastructureRef
+	if !usesMachineTemplate(deployment, ref.Name) {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: deployment.GetNamespace(), Name: ref.Name}}}
+}
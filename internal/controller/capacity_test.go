@@ -0,0 +1,129 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+// newCapacityTest returns a template of 8GiB machines, whose MachineDeployment has 3 replicas
+// of which one has a VM already.
+func newCapacityTest(t *testing.T) (*infrav1.ProxmoxMachineTemplate, client.Client) {
+	template := &infrav1.ProxmoxMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-worker",
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: "test"},
+		},
+		Spec: infrav1.ProxmoxMachineTemplateSpec{Template: infrav1.ProxmoxMachineTemplateResource{
+			Spec: infrav1.ProxmoxMachineSpec{MemoryMiB: 8192},
+		}},
+	}
+	deployment := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-md-0", Namespace: metav1.NamespaceDefault},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName: "test",
+			Replicas:    ptr.To[int32](3),
+			Template: clusterv1.MachineTemplateSpec{Spec: clusterv1.MachineSpec{
+				ClusterName:       "test",
+				InfrastructureRef: corev1.ObjectReference{Kind: "ProxmoxMachineTemplate", Name: "test-worker"},
+			}},
+		},
+	}
+	existing := &infrav1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-md-0-abcde",
+			Namespace:   metav1.NamespaceDefault,
+			Annotations: map[string]string{clusterv1.TemplateClonedFromNameAnnotation: "test-worker"},
+		},
+		Spec: infrav1.ProxmoxMachineSpec{VirtualMachineID: ptr.To[int64](100)},
+	}
+	kubeClient := newMachineTemplateTestClient(t, template, deployment, existing)
+
+	proxmoxCluster := &infrav1.ProxmoxCluster{}
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: "test"}, proxmoxCluster))
+	proxmoxCluster.Spec.AllowedNodes = []string{"pve1", "pve2"}
+	require.NoError(t, kubeClient.Update(context.Background(), proxmoxCluster))
+	return template, kubeClient
+}
+
+func TestReconcileMachineTemplateCapacity_Available(t *testing.T) {
+	template, kubeClient := newCapacityTest(t)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	proxmoxClient.EXPECT().GetNodeMemory(context.Background(), "pve1", infrav1.MemoryAccountingMaxMemory).Return(proxmox.NodeMemory{TotalBytes: 8 << 30}, nil).Once()
+	proxmoxClient.EXPECT().GetNodeMemory(context.Background(), "pve2", infrav1.MemoryAccountingMaxMemory).Return(proxmox.NodeMemory{TotalBytes: 8 << 30}, nil).Once()
+	reconciler := &ProxmoxMachineTemplateReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	res, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+	require.NoError(t, err)
+	require.Equal(t, capacityRequeueInterval, res.RequeueAfter)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(template), template))
+	require.True(t, conditions.IsTrue(template, infrav1.CapacityAvailableCondition))
+}
+
+func TestReconcileMachineTemplateCapacity_Insufficient(t *testing.T) {
+	template, kubeClient := newCapacityTest(t)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	proxmoxClient.EXPECT().GetNodeMemory(context.Background(), "pve1", infrav1.MemoryAccountingMaxMemory).Return(proxmox.NodeMemory{TotalBytes: 12 << 30}, nil).Once()
+	proxmoxClient.EXPECT().GetNodeMemory(context.Background(), "pve2", infrav1.MemoryAccountingMaxMemory).Return(proxmox.NodeMemory{TotalBytes: 4 << 30}, nil).Once()
+	reconciler := &ProxmoxMachineTemplateReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+	require.NoError(t, err)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(template), template))
+	require.True(t, conditions.IsFalse(template, infrav1.CapacityAvailableCondition))
+	require.Equal(t, infrav1.InsufficientCapacityReason, conditions.GetReason(template, infrav1.CapacityAvailableCondition))
+	require.Equal(t, "only 1 of 2 pending machines fit on the eligible nodes", conditions.GetMessage(template, infrav1.CapacityAvailableCondition))
+}
+
+func TestReconcileMachineTemplateCapacity_NoPendingMachines(t *testing.T) {
+	template, kubeClient := newCapacityTest(t)
+	for _, name := range []string{"test-md-0-fghij", "test-md-0-klmno"} {
+		require.NoError(t, kubeClient.Create(context.Background(), &infrav1.ProxmoxMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   metav1.NamespaceDefault,
+				Annotations: map[string]string{clusterv1.TemplateClonedFromNameAnnotation: "test-worker"},
+			},
+			Spec: infrav1.ProxmoxMachineSpec{VirtualMachineID: ptr.To[int64](101)},
+		}))
+	}
+	reconciler := &ProxmoxMachineTemplateReconciler{Client: kubeClient, ProxmoxClient: proxmoxtest.NewMockClient(t)}
+
+	res, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+	require.NoError(t, err)
+	require.Zero(t, res.RequeueAfter)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(template), template))
+	require.True(t, conditions.IsTrue(template, infrav1.CapacityAvailableCondition))
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// DefaultIPPoolExhaustionThreshold is the default percentage of used addresses from which
+// an InClusterIPPool is considered nearly exhausted.
+const DefaultIPPoolExhaustionThreshold = 90
+
+var ipPoolAddresses = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "capmox_ip_pool_addresses",
+	Help: "Number of addresses of the InClusterIPPools of ProxmoxClusters, by state (total, used or free).",
+}, []string{"namespace", "cluster", "pool", "state"})
+
+func init() {
+	metrics.Registry.MustRegister(ipPoolAddresses)
+}
+
+// reconcileIPPoolUsage exports the address counts of the InClusterIPPools of the cluster, as reported by the
+// in-cluster IPAM provider, and maintains the IPPoolsAvailable condition, so running out of addresses shows
+// before the IP address claims of new machines stay pending.
+func (r *ProxmoxClusterReconciler) reconcileIPPoolUsage(ctx context.Context, clusterScope *scope.ClusterScope) error {
+	pools, err := clusterScope.IPAMHelper.ListInClusterIPPools(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list InClusterIPPools")
+	}
+
+	// pools which were removed from the spec must not keep reporting.
+	deleteIPPoolMetrics(clusterScope)
+
+	cluster := clusterScope.ProxmoxCluster
+	var exhausted, nearlyExhausted []string
+	for i := range pools {
+		pool := &pools[i]
+		usage := pool.Status.Addresses
+		if usage == nil {
+			// the IPAM provider did not count the addresses of the pool yet.
+			continue
+		}
+
+		for state, count := range map[string]int{"total": usage.Total, "used": usage.Used, "free": usage.Free} {
+			ipPoolAddresses.WithLabelValues(cluster.GetNamespace(), cluster.GetName(), pool.GetName(), state).Set(float64(count))
+		}
+
+		switch {
+		case usage.Total == 0:
+		case usage.Free == 0:
+			exhausted = append(exhausted, fmt.Sprintf("%s has no free addresses", pool.GetName()))
+		case r.IPPoolExhaustionThreshold > 0 && float64(usage.Used) >= float64(usage.Total)*float64(r.IPPoolExhaustionThreshold)/100:
+			nearlyExhausted = append(nearlyExhausted, fmt.Sprintf("%s has %d of %d addresses free", pool.GetName(), usage.Free, usage.Total))
+		}
+	}
+
+	switch {
+	case len(exhausted) > 0:
+		sort.Strings(exhausted)
+		conditions.MarkFalse(cluster, infrav1alpha1.IPPoolsAvailableCondition, infrav1alpha1.IPPoolExhaustedReason, clusterv1.ConditionSeverityError,
+			"%s", strings.Join(append(exhausted, nearlyExhausted...), "; "))
+	case len(nearlyExhausted) > 0:
+		sort.Strings(nearlyExhausted)
+		conditions.MarkFalse(cluster, infrav1alpha1.IPPoolsAvailableCondition, infrav1alpha1.IPPoolNearlyExhaustedReason, clusterv1.ConditionSeverityWarning,
+			"%s", strings.Join(nearlyExhausted, "; "))
+	default:
+		conditions.MarkTrue(cluster, infrav1alpha1.IPPoolsAvailableCondition)
+	}
+	return nil
+}
+
+// deleteIPPoolMetrics removes the address counts of the pools of the cluster.
+func deleteIPPoolMetrics(clusterScope *scope.ClusterScope) {
+	cluster := clusterScope.ProxmoxCluster
+	ipPoolAddresses.DeletePartialMatch(prometheus.Labels{"namespace": cluster.GetNamespace(), "cluster": cluster.GetName()})
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ipamicv1 "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// newIPPoolUsageTest returns the scope of a cluster controlling the given pools, whose
+// address counts are set as if they were reported by the IPAM provider.
+func newIPPoolUsageTest(t *testing.T, usage map[string]*ipamicv1.InClusterIPPoolStatusIPAddresses) *scope.ClusterScope {
+	clusterScope, _ := newTestClusterScope(t, infrav1.ProxmoxClusterSpec{})
+	clusterScope.ProxmoxCluster.UID = "cluster-uid"
+	var pools []client.Object
+	for name, addresses := range usage {
+		pools = append(pools, &ipamicv1.InClusterIPPool{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault, OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: infrav1.GroupVersion.String(), Kind: "ProxmoxCluster", Name: "test", UID: "cluster-uid", Controller: ptr.To(true),
+			}}},
+			Status: ipamicv1.InClusterIPPoolStatus{Addresses: addresses},
+		})
+	}
+	pools = append(pools, &ipamicv1.InClusterIPPool{
+		// pools of other clusters are not counted.
+		ObjectMeta: metav1.ObjectMeta{Name: "other-v4-icip", Namespace: metav1.NamespaceDefault},
+		Status:     ipamicv1.InClusterIPPoolStatus{Addresses: &ipamicv1.InClusterIPPoolStatusIPAddresses{Total: 10}},
+	})
+	kubeClient := newTestClient(t, pools...)
+
+	t.Cleanup(func() { ipPoolAddresses.Reset() })
+	clusterScope.IPAMHelper = ipam.NewHelper(kubeClient, clusterScope.ProxmoxCluster)
+	return clusterScope
+}
+
+func TestReconcileIPPoolUsage(t *testing.T) {
+	clusterScope := newIPPoolUsageTest(t, map[string]*ipamicv1.InClusterIPPoolStatusIPAddresses{
+		"test-v4-icip": {Total: 20, Used: 10, Free: 10},
+		"test-v6-icip": {Total: 100, Used: 95, Free: 5},
+		// not counted yet.
+		"test-rack1-v4-1e7cf5f0-icip": nil,
+	})
+	r := &ProxmoxClusterReconciler{IPPoolExhaustionThreshold: DefaultIPPoolExhaustionThreshold}
+
+	require.NoError(t, r.reconcileIPPoolUsage(context.Background(), clusterScope))
+
+	cluster := clusterScope.ProxmoxCluster
+	require.True(t, conditions.IsFalse(cluster, infrav1.IPPoolsAvailableCondition))
+	require.Equal(t, infrav1.IPPoolNearlyExhaustedReason, conditions.GetReason(cluster, infrav1.IPPoolsAvailableCondition))
+	require.Equal(t, "test-v6-icip has 5 of 100 addresses free", conditions.GetMessage(cluster, infrav1.IPPoolsAvailableCondition))
+
+	require.Equal(t, 10.0, testutil.ToFloat64(ipPoolAddresses.WithLabelValues("default", "test", "test-v4-icip", "free")))
+	require.Equal(t, 95.0, testutil.ToFloat64(ipPoolAddresses.WithLabelValues("default", "test", "test-v6-icip", "used")))
+	require.Equal(t, 6, testutil.CollectAndCount(ipPoolAddresses))
+
+	deleteIPPoolMetrics(clusterScope)
+	require.Equal(t, 0, testutil.CollectAndCount(ipPoolAddresses))
+}
+
+func TestReconcileIPPoolUsage_Exhausted(t *testing.T) {
+	clusterScope := newIPPoolUsageTest(t, map[string]*ipamicv1.InClusterIPPoolStatusIPAddresses{
+		"test-v4-icip": {Total: 10, Used: 10},
+		"test-v6-icip": {Total: 100, Used: 95, Free: 5},
+	})
+	// without a threshold, only exhausted pools are reported.
+	r := &ProxmoxClusterReconciler{}
+
+	require.NoError(t, r.reconcileIPPoolUsage(context.Background(), clusterScope))
+
+	cluster := clusterScope.ProxmoxCluster
+	require.Equal(t, infrav1.IPPoolExhaustedReason, conditions.GetReason(cluster, infrav1.IPPoolsAvailableCondition))
+	require.Equal(t, clusterv1.ConditionSeverityError, *conditions.GetSeverity(cluster, infrav1.IPPoolsAvailableCondition))
+	require.Equal(t, "test-v4-icip has no free addresses", conditions.GetMessage(cluster, infrav1.IPPoolsAvailableCondition))
+}
+
+func TestReconcileIPPoolUsage_Available(t *testing.T) {
+	clusterScope := newIPPoolUsageTest(t, map[string]*ipamicv1.InClusterIPPoolStatusIPAddresses{
+		"test-v4-icip": {Total: 20, Used: 17, Free: 3},
+	})
+	r := &ProxmoxClusterReconciler{IPPoolExhaustionThreshold: DefaultIPPoolExhaustionThreshold}
+
+	require.NoError(t, r.reconcileIPPoolUsage(context.Background(), clusterScope))
+	require.True(t, conditions.IsTrue(clusterScope.ProxmoxCluster, infrav1.IPPoolsAvailableCondition))
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/vmservice"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// OrphanedVMPolicy defines what happens to VMs of a cluster which are not referenced by any ProxmoxMachine.
+type OrphanedVMPolicy string
+
+const (
+	// OrphanedVMPolicyReport logs orphaned VMs and records an event on their cluster.
+	OrphanedVMPolicyReport OrphanedVMPolicy = "Report"
+	// OrphanedVMPolicyDelete additionally deletes orphaned VMs.
+	OrphanedVMPolicyDelete OrphanedVMPolicy = "Delete"
+)
+
+// orphanedVM is a VM carrying the tag of a cluster, but lacking a ProxmoxMachine.
+type orphanedVM struct {
+	namespace   string
+	clusterName string
+	node        string
+	vmID        uint64
+	name        string
+}
+
+// OrphanedVMCollector periodically looks for VMs carrying the tag of a cluster which are
+// not referenced by any ProxmoxMachine of that cluster. These are left behind by crashed
+// reconciles or by ProxmoxMachines whose finalizer was removed manually.
+type OrphanedVMCollector struct {
+	client.Client
+	Recorder      record.EventRecorder
+	ProxmoxClient proxmox.Client
+
+	// Policy defines whether orphaned VMs are only reported, or deleted.
+	Policy OrphanedVMPolicy
+	// Interval is the interval in which VMs are checked.
+	Interval time.Duration
+
+	// suspects are the VMs found orphaned by the previous collection. A VM is only
+	// deleted if it is orphaned in two consecutive collections, so a ProxmoxMachine
+	// which was not in the cache yet does not lose its VM.
+	suspects map[uint64]struct{}
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// SetupWithManager adds the collector to the manager.
+func (c *OrphanedVMCollector) SetupWithManager(mgr ctrl.Manager) error {
+	switch c.Policy {
+	case OrphanedVMPolicyReport, OrphanedVMPolicyDelete:
+	default:
+		return fmt.Errorf("unknown orphaned VM policy %q", c.Policy)
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("orphaned VM collection interval must be positive, got %s", c.Interval)
+	}
+
+	return mgr.Add(c)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, as only one manager may delete VMs.
+func (c *OrphanedVMCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Start collects orphaned VMs until the context is done.
+func (c *OrphanedVMCollector) Start(ctx context.Context) error {
+	logger := ctrl.LoggerFrom(ctx).WithName("orphaned-vm-collector")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.collect(logr.NewContext(ctx, logger)); err != nil {
+			logger.Error(err, "unable to collect orphaned VMs")
+		}
+	}, c.Interval)
+	return nil
+}
+
+// collect reports or deletes the VMs which are orphaned.
+func (c *OrphanedVMCollector) collect(ctx context.Context) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	orphans, err := c.findOrphanedVMs(ctx)
+	if err != nil {
+		return err
+	}
+
+	suspects := make(map[uint64]struct{}, len(orphans))
+	for _, vm := range orphans {
+		suspects[vm.vmID] = struct{}{}
+		log := logger.WithValues("vmID", vm.vmID, "node", vm.node, "vmName", vm.name, "namespace", vm.namespace, "cluster", vm.clusterName)
+
+		_, suspected := c.suspects[vm.vmID]
+		if c.Policy != OrphanedVMPolicyDelete || !suspected {
+			log.Info("Found VM without ProxmoxMachine")
+			c.recordEvent(ctx, vm, corev1.EventTypeWarning, "OrphanedVM", "VM %d (%s) on node %s is not referenced by any ProxmoxMachine", vm.vmID, vm.name, vm.node)
+			continue
+		}
+
+		if err := vmservice.DeleteOrphanedVM(ctx, c.ProxmoxClient, vm.node, int64(vm.vmID)); err != nil {
+			log.Error(err, "unable to delete VM without ProxmoxMachine")
+			continue
+		}
+		log.Info("Deleting VM without ProxmoxMachine")
+		c.recordEvent(ctx, vm, corev1.EventTypeNormal, "OrphanedVMDeleted", "Deleting VM %d (%s) on node %s, which is not referenced by any ProxmoxMachine", vm.vmID, vm.name, vm.node)
+		delete(suspects, vm.vmID)
+	}
+	c.suspects = suspects
+
+	return nil
+}
+
+// findOrphanedVMs returns the VMs carrying the tag of a cluster which are not referenced
+// by any ProxmoxMachine of that cluster.
+func (c *OrphanedVMCollector) findOrphanedVMs(ctx context.Context) ([]orphanedVM, error) {
+	resources, err := c.ProxmoxClient.ListVMResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var machines infrav1alpha1.ProxmoxMachineList
+	if err := c.List(ctx, &machines); err != nil {
+		return nil, fmt.Errorf("unable to list ProxmoxMachines: %w", err)
+	}
+
+	// the VM IDs referenced by ProxmoxMachines, per namespace and cluster.
+	referenced := make(map[string]map[uint64]struct{})
+	for _, machine := range machines.Items {
+		if machine.Spec.VirtualMachineID == nil {
+			continue
+		}
+		key := machine.GetNamespace() + "/" + machine.GetLabels()[clusterv1.ClusterNameLabel]
+		if referenced[key] == nil {
+			referenced[key] = make(map[uint64]struct{})
+		}
+		referenced[key][uint64(*machine.Spec.VirtualMachineID)] = struct{}{}
+	}
+
+	var orphans []orphanedVM
+	for _, resource := range resources {
+		if resource.Template != 0 {
+			continue
+		}
+		namespace, clusterName, ok := clusterOfVM(resource.Tags)
+		if !ok {
+			continue
+		}
+		if _, ok := referenced[namespace+"/"+clusterName][resource.VMID]; ok {
+			continue
+		}
+		orphans = append(orphans, orphanedVM{
+			namespace:   namespace,
+			clusterName: clusterName,
+			node:        resource.Node,
+			vmID:        resource.VMID,
+			name:        resource.Name,
+		})
+	}
+
+	return orphans, nil
+}
+
+// clusterOfVM returns the cluster marked by the tags of a VM.
+func clusterOfVM(tags string) (namespace, clusterName string, ok bool) {
+	for _, tag := range strings.FieldsFunc(tags, func(r rune) bool { return r == ';' || r == ',' || r == ' ' }) {
+		if namespace, clusterName, ok = vmservice.ParseClusterTag(tag); ok {
+			return namespace, clusterName, true
+		}
+	}
+	return "", "", false
+}
+
+// recordEvent records an event on the cluster of the VM, if it still exists.
+func (c *OrphanedVMCollector) recordEvent(ctx context.Context, vm orphanedVM, eventType, reason, messageFmt string, args ...interface{}) {
+	var cluster clusterv1.Cluster
+	if err := c.Get(ctx, client.ObjectKey{Namespace: vm.namespace, Name: vm.clusterName}, &cluster); err != nil {
+		if !apierrors.IsNotFound(err) {
+			logr.FromContextOrDiscard(ctx).Error(err, "unable to get cluster of VM", "vmID", vm.vmID)
+		}
+		return
+	}
+	c.Recorder.Eventf(&cluster, eventType, reason, messageFmt, args...)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func TestOrphanedVMCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clusterv1.AddToScheme(scheme))
+	require.NoError(t, infrav1.AddToScheme(scheme))
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault}}
+	machine := &infrav1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-1",
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: "test"},
+		},
+		Spec: infrav1.ProxmoxMachineSpec{VirtualMachineID: ptr.To[int64](100)},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, machine).Build()
+
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	proxmoxClient.EXPECT().ListVMResources(context.Background()).Return([]*proxmox.ClusterResource{
+		{VMID: 100, Node: "pve1", Name: "test-1", Tags: "capmox_default_test"},
+		{VMID: 101, Node: "pve1", Name: "test-2", Tags: "k8s;capmox_default_test"},
+		{VMID: 102, Node: "pve2", Name: "unrelated", Tags: "k8s"},
+		{VMID: 103, Node: "pve2", Name: "template", Tags: "capmox_default_test", Template: 1},
+		{VMID: 104, Node: "pve2", Name: "gone-1", Tags: "capmox_other_gone"},
+	}, nil).Times(2)
+
+	recorder := record.NewFakeRecorder(10)
+	collector := &OrphanedVMCollector{
+		Client:        kubeClient,
+		Recorder:      recorder,
+		ProxmoxClient: proxmoxClient,
+		Policy:        OrphanedVMPolicyDelete,
+	}
+
+	// orphaned VMs are reported first, in case their ProxmoxMachine was not in the cache yet.
+	require.NoError(t, collector.collect(context.Background()))
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "VM 101 (test-2) on node pve1 is not referenced by any ProxmoxMachine")
+
+	proxmoxClient.EXPECT().DeleteVM(context.Background(), "pve1", int64(101), mock.Anything).Return(&proxmox.Task{}, nil).Once()
+	proxmoxClient.EXPECT().DeleteVM(context.Background(), "pve2", int64(104), mock.Anything).Return(&proxmox.Task{}, nil).Once()
+
+	require.NoError(t, collector.collect(context.Background()))
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "Deleting VM 101 (test-2) on node pve1")
+	require.Empty(t, collector.suspects)
+}
+
+func TestOrphanedVMCollector_Report(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clusterv1.AddToScheme(scheme))
+	require.NoError(t, infrav1.AddToScheme(scheme))
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	proxmoxClient.EXPECT().ListVMResources(context.Background()).Return([]*proxmox.ClusterResource{
+		{VMID: 104, Node: "pve2", Name: "gone-1", Tags: "capmox_other_gone"},
+	}, nil).Times(2)
+
+	collector := &OrphanedVMCollector{
+		Client:        kubeClient,
+		Recorder:      record.NewFakeRecorder(10),
+		ProxmoxClient: proxmoxClient,
+		Policy:        OrphanedVMPolicyReport,
+	}
+
+	// reported VMs are never deleted.
+	require.NoError(t, collector.collect(context.Background()))
+	require.NoError(t, collector.collect(context.Background()))
+}
+
+func TestClusterOfVM(t *testing.T) {
+	namespace, clusterName, ok := clusterOfVM("k8s;capmox_default_test;ip_net0_10.0.0.1")
+	require.True(t, ok)
+	require.Equal(t, "default", namespace)
+	require.Equal(t, "test", clusterName)
+
+	_, _, ok = clusterOfVM("k8s;ip_net0_10.0.0.1")
+	require.False(t, ok)
+}
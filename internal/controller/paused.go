@@ -0,0 +1,56 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	v1beta2conditions "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/conditions/v1beta2"
+)
+
+// reconcilePaused sets the v1beta2 Paused condition of an object which is paused itself or through its cluster.
+// The object is only patched if the condition changes, as it is not reconciled otherwise.
+func reconcilePaused(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, obj v1beta2conditions.Setter) error {
+	message := fmt.Sprintf("the %s annotation is set", clusterv1.PausedAnnotation)
+	if cluster.Spec.Paused {
+		message = fmt.Sprintf("cluster %s is paused", cluster.Name)
+	}
+
+	if current := v1beta2conditions.Get(obj, infrav1alpha1.PausedV1Beta2Condition); current != nil &&
+		current.Status == metav1.ConditionTrue && current.Message == message && current.ObservedGeneration == obj.GetGeneration() {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(obj, c)
+	if err != nil {
+		return err
+	}
+	v1beta2conditions.Set(obj, metav1.Condition{
+		Type:    infrav1alpha1.PausedV1Beta2Condition,
+		Status:  metav1.ConditionTrue,
+		Reason:  infrav1alpha1.PausedV1Beta2Reason,
+		Message: message,
+	})
+	return patchHelper.Patch(ctx, obj)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	v1beta2conditions "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/conditions/v1beta2"
+)
+
+func TestReconcilePaused(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrav1.AddToScheme(scheme))
+
+	machine := &infrav1.ProxmoxMachine{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test",
+		Namespace:   metav1.NamespaceDefault,
+		Generation:  2,
+		Annotations: map[string]string{clusterv1.PausedAnnotation: ""},
+	}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(machine).WithStatusSubresource(&infrav1.ProxmoxMachine{}).Build()
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+
+	require.NoError(t, reconcilePaused(ctx, c, cluster, machine))
+
+	var patched infrav1.ProxmoxMachine
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(machine), &patched))
+	condition := v1beta2conditions.Get(&patched, infrav1.PausedV1Beta2Condition)
+	require.NotNil(t, condition)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, infrav1.PausedV1Beta2Reason, condition.Reason)
+	require.Equal(t, "the cluster.x-k8s.io/paused annotation is set", condition.Message)
+	require.Equal(t, int64(2), condition.ObservedGeneration)
+
+	// the object is not patched again while the condition is unchanged.
+	resourceVersion := patched.ResourceVersion
+	require.NoError(t, reconcilePaused(ctx, c, cluster, &patched))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(machine), &patched))
+	require.Equal(t, resourceVersion, patched.ResourceVersion)
+
+	cluster.Spec.Paused = true
+	require.NoError(t, reconcilePaused(ctx, c, cluster, &patched))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(machine), &patched))
+	require.Equal(t, "cluster test is paused", v1beta2conditions.Get(&patched, infrav1.PausedV1Beta2Condition).Message)
+}
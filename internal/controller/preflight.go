@@ -0,0 +1,275 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/vmservice"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// templateRef identifies a template VM by the node it is cloned on.
+type templateRef struct {
+	node string
+	id   int32
+}
+
+// preflightRefs are the template VMs, storages and bridges referenced by the machines of a cluster.
+type preflightRefs struct {
+	templates map[templateRef]struct{}
+	// storages maps the storages to the content types they are used for.
+	storages map[string]map[string]struct{}
+	bridges  map[string]struct{}
+}
+
+func (refs *preflightRefs) addStorage(storage, content string) {
+	if storage == "" {
+		return
+	}
+	if refs.storages[storage] == nil {
+		refs.storages[storage] = make(map[string]struct{})
+	}
+	refs.storages[storage][content] = struct{}{}
+}
+
+// addMachine adds the references of a machine spec, after the machine size and the
+// machine defaults of the cluster were applied.
+func (refs *preflightRefs) addMachine(cluster *infrav1alpha1.ProxmoxCluster, spec *infrav1alpha1.ProxmoxMachineSpec) {
+	machine := &infrav1alpha1.ProxmoxMachine{Spec: *spec.DeepCopy()}
+	cluster.MachineSize(machine.Spec.Size).ApplyTo(machine)
+	cluster.Spec.MachineDefaults.ApplyTo(machine)
+	spec = &machine.Spec
+
+	if spec.TemplateID != nil && spec.SourceNode != "" {
+		refs.templates[templateRef{node: spec.SourceNode, id: *spec.TemplateID}] = struct{}{}
+	}
+	if spec.Storage != nil {
+		refs.addStorage(*spec.Storage, proxmox.StorageContentImages)
+	}
+	if spec.Disks != nil {
+		for _, volume := range spec.Disks.AdditionalVolumes {
+			refs.addStorage(volume.Storage, proxmox.StorageContentImages)
+		}
+	}
+	if spec.Network != nil {
+		if spec.Network.Default != nil && spec.Network.Default.Bridge != "" {
+			refs.bridges[spec.Network.Default.Bridge] = struct{}{}
+		}
+		for _, device := range spec.Network.AdditionalDevices {
+			if device.Bridge != "" {
+				refs.bridges[device.Bridge] = struct{}{}
+			}
+		}
+	}
+}
+
+// collectPreflightRefs returns the references of the machine defaults and of the ProxmoxMachineTemplates
+// of the cluster, as well as the storages of the cluster itself. Templates which are imported from another
+// Proxmox VE cluster are checked by the ProxmoxMachineTemplate controller.
+func (r *ProxmoxClusterReconciler) collectPreflightRefs(ctx context.Context, clusterScope *scope.ClusterScope) (*preflightRefs, error) {
+	cluster := clusterScope.ProxmoxCluster
+	refs := &preflightRefs{
+		templates: make(map[templateRef]struct{}),
+		storages:  make(map[string]map[string]struct{}),
+		bridges:   make(map[string]struct{}),
+	}
+	refs.addMachine(cluster, &infrav1alpha1.ProxmoxMachineSpec{})
+	refs.addStorage(cluster.Spec.CloudInitStorage, proxmox.StorageContentISO)
+	if cluster.Spec.Backup != nil {
+		refs.addStorage(cluster.Spec.Backup.Storage, proxmox.StorageContentBackup)
+	}
+
+	templates := &infrav1alpha1.ProxmoxMachineTemplateList{}
+	if err := r.List(ctx, templates, client.InNamespace(cluster.GetNamespace())); err != nil {
+		return nil, errors.Wrap(err, "unable to list ProxmoxMachineTemplates")
+	}
+	for i := range templates.Items {
+		template := &templates.Items[i]
+		if template.Spec.TemplateSource == nil && belongsToCluster(template, clusterScope.Cluster.GetName()) {
+			refs.addMachine(cluster, &template.Spec.Template.Spec)
+		}
+	}
+
+	// the VNet of the cluster only becomes a bridge of the nodes once it is created.
+	if sdn := cluster.Spec.SDN; sdn != nil {
+		delete(refs.bridges, sdn.VNet)
+	}
+	return refs, nil
+}
+
+// belongsToCluster returns true if the object is labeled with the cluster or owned by it,
+// like the infrastructure templates of MachineDeployments.
+func belongsToCluster(obj metav1.Object, clusterName string) bool {
+	if obj.GetLabels()[clusterv1.ClusterNameLabel] == clusterName {
+		return true
+	}
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind == "Cluster" && ref.Name == clusterName {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcilePreflightChecks checks that the template VMs, storages and bridges, which the machines of the cluster
+// reference, exist, and records the outcome in the TemplatesAvailable, StoragesAvailable and BridgesAvailable
+// conditions. The checks do not fail the reconcile, so that misconfigured machines do not block the cluster.
+func (r *ProxmoxClusterReconciler) reconcilePreflightChecks(ctx context.Context, clusterScope *scope.ClusterScope) error {
+	if conditions.IsFalse(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxAPIReachableCondition) {
+		// the conditions are kept until the API responds again.
+		return nil
+	}
+
+	refs, err := r.collectPreflightRefs(ctx, clusterScope)
+	if err != nil {
+		return err
+	}
+
+	r.checkTemplates(ctx, clusterScope, refs)
+
+	if len(refs.storages) == 0 && len(refs.bridges) == 0 {
+		conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.StoragesAvailableCondition)
+		conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.BridgesAvailableCondition)
+		return nil
+	}
+	nodes, err := scheduler.EligibleNodes(ctx, clusterScope.ProxmoxClient, clusterScope.ProxmoxCluster)
+	if err != nil {
+		for _, condition := range []clusterv1.ConditionType{infrav1alpha1.StoragesAvailableCondition, infrav1alpha1.BridgesAvailableCondition} {
+			conditions.MarkFalse(clusterScope.ProxmoxCluster, condition, infrav1alpha1.PreflightCheckFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
+		}
+		return nil
+	}
+	r.checkStorages(ctx, clusterScope, refs, nodes)
+	r.checkBridges(ctx, clusterScope, refs, nodes)
+	return nil
+}
+
+func (r *ProxmoxClusterReconciler) checkTemplates(ctx context.Context, clusterScope *scope.ClusterScope, refs *preflightRefs) {
+	var missing []string
+	for ref := range refs.templates {
+		vm, err := clusterScope.ProxmoxClient.GetVM(ctx, ref.node, int64(ref.id))
+		switch {
+		case err != nil && vmservice.VMNotFound(err):
+			missing = append(missing, fmt.Sprintf("template VM %d does not exist on node %s", ref.id, ref.node))
+		case err != nil:
+			conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.TemplatesAvailableCondition, infrav1alpha1.PreflightCheckFailedReason, clusterv1.ConditionSeverityWarning,
+				"unable to get template VM %d on node %s: %s", ref.id, ref.node, err)
+			return
+		case !bool(vm.Template) && vm.IsRunning():
+			// regular VMs are cloned as well, but only while they are stopped.
+			missing = append(missing, fmt.Sprintf("VM %d on node %s is running, stop it or convert it to a template", ref.id, ref.node))
+		}
+	}
+
+	markPreflight(clusterScope, infrav1alpha1.TemplatesAvailableCondition, infrav1alpha1.TemplateNotFoundReason, missing)
+}
+
+func (r *ProxmoxClusterReconciler) checkStorages(ctx context.Context, clusterScope *scope.ClusterScope, refs *preflightRefs, nodes []string) {
+	if len(refs.storages) == 0 {
+		conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.StoragesAvailableCondition)
+		return
+	}
+
+	var missing, missingContent []string
+	for _, node := range nodes {
+		storages, err := clusterScope.ProxmoxClient.ListStorages(ctx, node)
+		if err != nil {
+			conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.StoragesAvailableCondition, infrav1alpha1.PreflightCheckFailedReason, clusterv1.ConditionSeverityWarning,
+				"%s", err)
+			return
+		}
+		byName := make(map[string]proxmox.StorageInfo, len(storages))
+		for _, storage := range storages {
+			byName[storage.Name] = storage
+		}
+
+		for name, contents := range refs.storages {
+			storage, ok := byName[name]
+			if !ok || !storage.Enabled {
+				missing = append(missing, fmt.Sprintf("storage %s does not exist on node %s", name, node))
+				continue
+			}
+			for content := range contents {
+				if !storage.HasContent(content) {
+					missingContent = append(missingContent, fmt.Sprintf("storage %s on node %s does not support content %s", name, node, content))
+				}
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		markPreflight(clusterScope, infrav1alpha1.StoragesAvailableCondition, infrav1alpha1.StorageNotFoundReason, append(missing, missingContent...))
+		return
+	}
+	markPreflight(clusterScope, infrav1alpha1.StoragesAvailableCondition, infrav1alpha1.StorageContentMissingReason, missingContent)
+}
+
+func (r *ProxmoxClusterReconciler) checkBridges(ctx context.Context, clusterScope *scope.ClusterScope, refs *preflightRefs, nodes []string) {
+	if len(refs.bridges) == 0 {
+		conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.BridgesAvailableCondition)
+		return
+	}
+
+	var missing []string
+	for _, node := range nodes {
+		bridges, err := clusterScope.ProxmoxClient.ListBridges(ctx, node)
+		if err != nil {
+			conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.BridgesAvailableCondition, infrav1alpha1.PreflightCheckFailedReason, clusterv1.ConditionSeverityWarning,
+				"%s", err)
+			return
+		}
+		existing := make(map[string]struct{}, len(bridges))
+		for _, bridge := range bridges {
+			existing[bridge] = struct{}{}
+		}
+		var missingOnNode []string
+		for bridge := range refs.bridges {
+			if _, ok := existing[bridge]; !ok {
+				missingOnNode = append(missingOnNode, bridge)
+			}
+		}
+		if len(missingOnNode) > 0 {
+			sort.Strings(missingOnNode)
+			missing = append(missing, fmt.Sprintf("node %s is missing bridges %s", node, strings.Join(missingOnNode, ", ")))
+		}
+	}
+
+	markPreflight(clusterScope, infrav1alpha1.BridgesAvailableCondition, infrav1alpha1.BridgeNotFoundReason, missing)
+}
+
+// markPreflight marks the condition true if there are no problems, or else false with the sorted problems.
+func markPreflight(clusterScope *scope.ClusterScope, condition clusterv1.ConditionType, reason string, problems []string) {
+	if len(problems) == 0 {
+		conditions.MarkTrue(clusterScope.ProxmoxCluster, condition)
+		return
+	}
+	sort.Strings(problems)
+	conditions.MarkFalse(clusterScope.ProxmoxCluster, condition, reason, clusterv1.ConditionSeverityWarning, "%s", strings.Join(problems, "; "))
+}
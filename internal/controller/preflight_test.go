@@ -0,0 +1,131 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	go_proxmox "github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// newPreflightTest returns a cluster with the machine defaults template 100 on pve1, storage local-lvm and
+// bridge vmbr0, and a ProxmoxMachineTemplate with an additional device on bridge vmbr1.
+func newPreflightTest(t *testing.T) (*ProxmoxClusterReconciler, *scope.ClusterScope, *proxmoxtest.MockClient) {
+	template := &infrav1.ProxmoxMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-worker", Namespace: metav1.NamespaceDefault, Labels: map[string]string{clusterv1.ClusterNameLabel: "test"}},
+		Spec: infrav1.ProxmoxMachineTemplateSpec{Template: infrav1.ProxmoxMachineTemplateResource{Spec: infrav1.ProxmoxMachineSpec{
+			Network: &infrav1.NetworkSpec{AdditionalDevices: []infrav1.AdditionalNetworkDevice{
+				{Name: "net1", NetworkDevice: infrav1.NetworkDevice{Bridge: "vmbr1"}},
+			}},
+		}}},
+	}
+	other := &infrav1.ProxmoxMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-worker", Namespace: metav1.NamespaceDefault, Labels: map[string]string{clusterv1.ClusterNameLabel: "other"}},
+		Spec: infrav1.ProxmoxMachineTemplateSpec{Template: infrav1.ProxmoxMachineTemplateResource{Spec: infrav1.ProxmoxMachineSpec{
+			VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{Storage: ptr.To("other")},
+		}}},
+	}
+
+	clusterScope, proxmoxClient := newTestClusterScope(t, infrav1.ProxmoxClusterSpec{
+		AllowedNodes:     []string{"pve1", "pve2"},
+		CloudInitStorage: "local",
+		MachineDefaults: &infrav1.MachineDefaults{
+			SourceNode: "pve1",
+			TemplateID: ptr.To[int32](100),
+			Storage:    ptr.To("local-lvm"),
+			Bridge:     "vmbr0",
+		},
+	})
+	kubeClient := newTestClient(t, template, other)
+	return &ProxmoxClusterReconciler{Client: kubeClient}, clusterScope, proxmoxClient
+}
+
+var preflightStorages = []proxmox.StorageInfo{
+	{Name: "local", Content: []string{proxmox.StorageContentISO, proxmox.StorageContentSnippets}, Enabled: true},
+	{Name: "local-lvm", Content: []string{proxmox.StorageContentImages}, Enabled: true},
+}
+
+func TestReconcilePreflightChecks(t *testing.T) {
+	ctx := context.Background()
+	r, clusterScope, proxmoxClient := newPreflightTest(t)
+	proxmoxClient.EXPECT().GetVM(ctx, "pve1", int64(100)).Return(&go_proxmox.VirtualMachine{VMID: 100, Template: true}, nil).Once()
+	proxmoxClient.EXPECT().ListStorages(ctx, "pve1").Return(preflightStorages, nil).Once()
+	proxmoxClient.EXPECT().ListStorages(ctx, "pve2").Return(preflightStorages, nil).Once()
+	proxmoxClient.EXPECT().ListBridges(ctx, "pve1").Return([]string{"vmbr0", "vmbr1"}, nil).Once()
+	proxmoxClient.EXPECT().ListBridges(ctx, "pve2").Return([]string{"vmbr2"}, nil).Once()
+
+	require.NoError(t, r.reconcilePreflightChecks(ctx, clusterScope))
+
+	cluster := clusterScope.ProxmoxCluster
+	require.True(t, conditions.IsTrue(cluster, infrav1.TemplatesAvailableCondition))
+	require.True(t, conditions.IsTrue(cluster, infrav1.StoragesAvailableCondition))
+	require.True(t, conditions.IsFalse(cluster, infrav1.BridgesAvailableCondition))
+	require.Equal(t, infrav1.BridgeNotFoundReason, conditions.GetReason(cluster, infrav1.BridgesAvailableCondition))
+	require.Equal(t, "node pve2 is missing bridges vmbr0, vmbr1", conditions.GetMessage(cluster, infrav1.BridgesAvailableCondition))
+}
+
+func TestReconcilePreflightChecks_Missing(t *testing.T) {
+	ctx := context.Background()
+	r, clusterScope, proxmoxClient := newPreflightTest(t)
+	clusterScope.ProxmoxCluster.Spec.AllowedNodes = []string{"pve1"}
+	clusterScope.ProxmoxCluster.Spec.CloudInitStorage = "local-lvm"
+	proxmoxClient.EXPECT().GetVM(ctx, "pve1", int64(100)).Return(nil, errors.New("vm 100 does not exist")).Once()
+	proxmoxClient.EXPECT().ListStorages(ctx, "pve1").Return(preflightStorages, nil).Once()
+	proxmoxClient.EXPECT().ListBridges(ctx, "pve1").Return(nil, errors.New("503 service unavailable")).Once()
+
+	require.NoError(t, r.reconcilePreflightChecks(ctx, clusterScope))
+
+	cluster := clusterScope.ProxmoxCluster
+	require.Equal(t, infrav1.TemplateNotFoundReason, conditions.GetReason(cluster, infrav1.TemplatesAvailableCondition))
+	require.Equal(t, "template VM 100 does not exist on node pve1", conditions.GetMessage(cluster, infrav1.TemplatesAvailableCondition))
+	require.Equal(t, infrav1.StorageContentMissingReason, conditions.GetReason(cluster, infrav1.StoragesAvailableCondition))
+	require.Equal(t, "storage local-lvm on node pve1 does not support content iso", conditions.GetMessage(cluster, infrav1.StoragesAvailableCondition))
+	require.Equal(t, infrav1.PreflightCheckFailedReason, conditions.GetReason(cluster, infrav1.BridgesAvailableCondition))
+}
+
+func TestCheckTemplates_RegularVMs(t *testing.T) {
+	ctx := context.Background()
+	r, clusterScope, proxmoxClient := newPreflightTest(t)
+	refs := &preflightRefs{templates: map[templateRef]struct{}{{node: "pve1", id: 100}: {}, {node: "pve1", id: 101}: {}}}
+	proxmoxClient.EXPECT().GetVM(ctx, "pve1", int64(100)).Return(&go_proxmox.VirtualMachine{VMID: 100, Status: go_proxmox.StatusVirtualMachineStopped}, nil).Once()
+	proxmoxClient.EXPECT().GetVM(ctx, "pve1", int64(101)).Return(&go_proxmox.VirtualMachine{VMID: 101, Status: go_proxmox.StatusVirtualMachineRunning, QMPStatus: go_proxmox.StatusVirtualMachineRunning}, nil).Once()
+
+	// stopped VMs can be cloned like templates.
+	r.checkTemplates(ctx, clusterScope, refs)
+	require.Equal(t, "VM 101 on node pve1 is running, stop it or convert it to a template", conditions.GetMessage(clusterScope.ProxmoxCluster, infrav1.TemplatesAvailableCondition))
+}
+
+func TestReconcilePreflightChecks_APIUnreachable(t *testing.T) {
+	r, clusterScope, _ := newPreflightTest(t)
+	conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1.ProxmoxAPIReachableCondition, infrav1.ProxmoxAPIUnreachableReason, clusterv1.ConditionSeverityWarning, "")
+
+	// the mock fails on any call to the Proxmox API.
+	require.NoError(t, r.reconcilePreflightChecks(context.Background(), clusterScope))
+	require.False(t, conditions.Has(clusterScope.ProxmoxCluster, infrav1.TemplatesAvailableCondition))
+}
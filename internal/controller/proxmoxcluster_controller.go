@@ -19,13 +19,22 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	ipamicv1 "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -34,11 +43,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
@@ -55,6 +67,21 @@ type ProxmoxClusterReconciler struct {
 	Scheme        *runtime.Scheme
 	Recorder      record.EventRecorder
 	ProxmoxClient proxmox.Client
+
+	// NodeStatusRefreshInterval is the interval in which the summaries of the
+	// eligible Proxmox nodes in the status are refreshed. Zero disables them.
+	NodeStatusRefreshInterval time.Duration
+
+	// APIProbeInterval is the interval in which the Proxmox API is probed to maintain
+	// the ProxmoxAPIReachable condition. Zero disables the probe.
+	APIProbeInterval time.Duration
+
+	// IPPoolExhaustionThreshold is the percentage of used addresses from which an InClusterIPPool
+	// of a cluster is reported as nearly exhausted. Zero only reports pools without free addresses.
+	IPPoolExhaustionThreshold int
+
+	// ControllerOptions configures the concurrency and the rate limiting of the controller.
+	ControllerOptions ControllerOptions
 }
 
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters,verbs=get;list;watch;create;update;patch;delete
@@ -99,8 +126,7 @@ func (r *ProxmoxClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	if annotations.IsPaused(cluster, proxmoxCluster) {
 		logger.Info("ProxmoxCluster or owning Cluster is marked as paused, not reconciling")
-
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, reconcilePaused(ctx, r.Client, cluster, proxmoxCluster)
 	}
 
 	// Create the scope.
@@ -127,11 +153,13 @@ func (r *ProxmoxClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	// Handle deleted clusters
 	if !proxmoxCluster.DeletionTimestamp.IsZero() {
-		return r.reconcileDelete(ctx, clusterScope)
+		res, err := r.reconcileDelete(ctx, clusterScope)
+		return reconcileProxmoxAvailability(proxmoxCluster, res, err)
 	}
 
 	// Handle non-deleted clusters
-	return r.reconcileNormal(ctx, clusterScope)
+	res, err := r.reconcileNormal(ctx, clusterScope)
+	return reconcileProxmoxAvailability(proxmoxCluster, res, err)
 }
 
 func (r *ProxmoxClusterReconciler) reconcileDelete(ctx context.Context, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
@@ -154,20 +182,101 @@ func (r *ProxmoxClusterReconciler) reconcileDelete(ctx context.Context, clusterS
 	// Requeue if there are one or more machines left.
 	if len(machines) > 0 {
 		clusterScope.Info("waiting for machines to be deleted", "remaining", len(machines))
-		return ctrl.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
+		// the deletion of a machine requeues the cluster, see proxmoxMachineToProxmoxCluster.
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	requeue, err := r.reconcileDeleteIPPools(ctx, clusterScope)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if requeue {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if err := r.reconcileDeleteBackup(ctx, clusterScope); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reconcileDeleteSDN(ctx, clusterScope); err != nil {
+		return reconcile.Result{}, err
 	}
 
+	deleteIPPoolMetrics(clusterScope)
 	clusterScope.Info("cluster deleted successfully")
 	ctrlutil.RemoveFinalizer(clusterScope.ProxmoxCluster, infrav1alpha1.ClusterFinalizer)
 	return ctrl.Result{}, nil
 }
 
+// reconcileDeleteIPPools deletes the InClusterIPPools created for the cluster once no IP addresses
+// are allocated from them anymore, and waits for them to be gone.
+func (r *ProxmoxClusterReconciler) reconcileDeleteIPPools(ctx context.Context, clusterScope *scope.ClusterScope) (requeue bool, err error) {
+	// the claims of machines which were deleted uncleanly would block the pools forever.
+	if err := r.reconcileStaleIPAddressClaims(ctx, clusterScope); err != nil {
+		return false, err
+	}
+
+	// pools which were not created by the provider are left alone.
+	pools, err := clusterScope.IPAMHelper.ListInClusterIPPools(ctx)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to list ip pools of cluster %q", clusterScope.InfraClusterName())
+	}
+
+	var inUse, deleting []string
+	for i := range pools {
+		pool := &pools[i]
+		if !pool.GetDeletionTimestamp().IsZero() {
+			deleting = append(deleting, pool.GetName())
+			continue
+		}
+
+		addresses, err := clusterScope.IPAMHelper.ListIPAddressesOfInClusterIPPool(ctx, pool)
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to list ip addresses of pool %s", pool.GetName())
+		}
+		if len(addresses) > 0 {
+			inUse = append(inUse, fmt.Sprintf("%s (%d)", pool.GetName(), len(addresses)))
+			continue
+		}
+
+		clusterScope.Info("deleting InClusterIPPool", "pool", pool.GetName())
+		if err := clusterScope.IPAMHelper.DeleteInClusterIPPool(ctx, pool); err != nil {
+			return false, errors.Wrapf(err, "unable to delete ip pool %s", pool.GetName())
+		}
+		deleting = append(deleting, pool.GetName())
+	}
+
+	switch {
+	case len(inUse) > 0:
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.IPPoolsDeletedCondition, infrav1alpha1.IPAddressesInUseReason, clusterv1.ConditionSeverityWarning,
+			"IP addresses are still allocated from %s", strings.Join(inUse, ", "))
+		return true, nil
+	case len(deleting) > 0:
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.IPPoolsDeletedCondition, infrav1alpha1.DeletingIPPoolsReason, clusterv1.ConditionSeverityInfo,
+			"waiting for %s to be deleted", strings.Join(deleting, ", "))
+		return true, nil
+	}
+
+	conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.IPPoolsDeletedCondition)
+	return false, nil
+}
+
 func (r *ProxmoxClusterReconciler) reconcileNormal(ctx context.Context, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
 	clusterScope.Logger.Info("Reconciling ProxmoxCluster")
 
 	// If the ProxmoxCluster doesn't have our finalizer, add it.
 	ctrlutil.AddFinalizer(clusterScope.ProxmoxCluster, infrav1alpha1.ClusterFinalizer)
 
+	if clusterScope.ProxmoxCluster.MigrateNodeLocations() {
+		clusterScope.Logger.Info("Migrated the node locations recorded by an earlier version")
+	}
+
+	r.reconcileAPIReachability(ctx, clusterScope)
+
+	if err := r.reconcilePreflightChecks(ctx, clusterScope); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	res, err := r.reconcileIPAM(ctx, clusterScope)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -177,11 +286,118 @@ func (r *ProxmoxClusterReconciler) reconcileNormal(ctx context.Context, clusterS
 		return res, nil
 	}
 
+	if err := r.reconcileIPPoolUsage(ctx, clusterScope); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileStaleIPAddressClaims(ctx, clusterScope); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileSDN(ctx, clusterScope); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileBackup(ctx, clusterScope); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxClusterReady)
 
 	clusterScope.ProxmoxCluster.Status.Ready = true
 
-	return ctrl.Result{}, nil
+	res, err = r.reconcileNodeStatus(ctx, clusterScope)
+	if err != nil {
+		return res, err
+	}
+	return requeueWithin(res, r.APIProbeInterval), nil
+}
+
+// reconcileAPIReachability probes the Proxmox API and records the outcome in the ProxmoxAPIReachable condition.
+// A failed probe does not fail the reconcile, the steps which need the API report their own errors.
+func (r *ProxmoxClusterReconciler) reconcileAPIReachability(ctx context.Context, clusterScope *scope.ClusterScope) {
+	if r.APIProbeInterval <= 0 {
+		conditions.Delete(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxAPIReachableCondition)
+		return
+	}
+
+	status, err := clusterScope.ProxmoxClient.GetAPIStatus(ctx)
+	switch {
+	case err != nil:
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxAPIReachableCondition, infrav1alpha1.ProxmoxAPIUnreachableReason, clusterv1.ConditionSeverityWarning,
+			"%s", err)
+	case !status.Quorate:
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxAPIReachableCondition, infrav1alpha1.ProxmoxClusterNotQuorateReason, clusterv1.ConditionSeverityWarning,
+			"Proxmox VE %s responds, but the Proxmox cluster has no quorum", status.Version)
+	default:
+		conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.ProxmoxAPIReachableCondition)
+	}
+}
+
+// requeueWithin returns the result requeued after the given interval at the latest.
+func requeueWithin(res reconcile.Result, interval time.Duration) reconcile.Result {
+	if interval <= 0 {
+		return res
+	}
+	if res.RequeueAfter <= 0 || res.RequeueAfter > interval {
+		res.RequeueAfter = interval
+	}
+	return res
+}
+
+// reconcileNodeStatus refreshes the summaries of the eligible nodes once they are older than the refresh interval.
+func (r *ProxmoxClusterReconciler) reconcileNodeStatus(ctx context.Context, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
+	status := &clusterScope.ProxmoxCluster.Status
+	if r.NodeStatusRefreshInterval <= 0 {
+		status.Nodes, status.NodesRefreshTime = nil, nil
+		return ctrl.Result{}, nil
+	}
+
+	if status.NodesRefreshTime != nil {
+		if age := time.Since(status.NodesRefreshTime.Time); age >= 0 && age < r.NodeStatusRefreshInterval {
+			return ctrl.Result{RequeueAfter: r.NodeStatusRefreshInterval - age}, nil
+		}
+	}
+
+	names, err := scheduler.EligibleNodes(ctx, clusterScope.ProxmoxClient, clusterScope.ProxmoxCluster)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "unable to resolve eligible nodes")
+	}
+
+	summaries, err := clusterScope.ProxmoxClient.GetNodeSummaries(ctx)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "unable to get node summaries")
+	}
+
+	status.Nodes = nodeStatuses(names, summaries)
+	now := metav1.Now()
+	status.NodesRefreshTime = &now
+
+	return ctrl.Result{RequeueAfter: r.NodeStatusRefreshInterval}, nil
+}
+
+// nodeStatuses returns the status of the given nodes. Nodes which are not part of
+// the Proxmox cluster are reported as offline.
+func nodeStatuses(names []string, summaries []proxmox.NodeSummary) []infrav1alpha1.NodeStatus {
+	byName := make(map[string]proxmox.NodeSummary, len(summaries))
+	for _, summary := range summaries {
+		byName[summary.Name] = summary
+	}
+
+	var statuses []infrav1alpha1.NodeStatus
+	for _, name := range names {
+		summary := byName[name]
+		statuses = append(statuses, infrav1alpha1.NodeStatus{
+			Name:               name,
+			Online:             summary.Online,
+			Version:            summary.Version,
+			CPUs:               int32(summary.CPUs),
+			AllocatedCPUs:      int32(summary.AllocatedCPUs),
+			MemoryMiB:          int64(summary.MemoryBytes / (1024 * 1024)),
+			AllocatedMemoryMiB: int64(summary.AllocatedMemoryBytes / (1024 * 1024)),
+		})
+	}
+	return statuses
 }
 
 func (r *ProxmoxClusterReconciler) reconcileIPAM(ctx context.Context, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
@@ -197,7 +413,7 @@ func (r *ProxmoxClusterReconciler) reconcileIPAM(ctx context.Context, clusterSco
 		poolV4, err := clusterScope.IPAMHelper.GetDefaultInClusterIPPool(ctx, infrav1alpha1.IPV4Format)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
-				return ctrl.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
+				return ctrl.Result{Requeue: true}, nil
 			}
 
 			return ctrl.Result{}, err
@@ -208,7 +424,7 @@ func (r *ProxmoxClusterReconciler) reconcileIPAM(ctx context.Context, clusterSco
 		poolV6, err := clusterScope.IPAMHelper.GetDefaultInClusterIPPool(ctx, infrav1alpha1.IPV6Format)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
-				return ctrl.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
+				return ctrl.Result{Requeue: true}, nil
 			}
 
 			return ctrl.Result{}, err
@@ -216,9 +432,235 @@ func (r *ProxmoxClusterReconciler) reconcileIPAM(ctx context.Context, clusterSco
 		clusterScope.ProxmoxCluster.SetInClusterIPPoolRef(poolV6)
 	}
 
+	for _, format := range []string{infrav1alpha1.IPV4Format, infrav1alpha1.IPV6Format} {
+		for _, ref := range clusterScope.IPAMHelper.AdditionalInClusterIPPoolRefs(format) {
+			pool, err := clusterScope.IPAMHelper.GetInClusterIPPool(ctx, &ref)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return ctrl.Result{Requeue: true}, nil
+				}
+
+				return ctrl.Result{}, err
+			}
+			clusterScope.ProxmoxCluster.SetInClusterIPPoolRef(pool)
+		}
+	}
+
+	for _, nodePool := range clusterScope.ProxmoxCluster.Spec.NodeIPPools {
+		for _, format := range []string{infrav1alpha1.IPV4Format, infrav1alpha1.IPV6Format} {
+			if nodePool.Config(format) == nil {
+				continue
+			}
+			pool, err := clusterScope.IPAMHelper.GetNodeInClusterIPPool(ctx, nodePool.Name, format)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return ctrl.Result{Requeue: true}, nil
+				}
+
+				return ctrl.Result{}, err
+			}
+			clusterScope.ProxmoxCluster.SetInClusterIPPoolRef(pool)
+		}
+	}
+
 	return reconcile.Result{}, nil
 }
 
+// sdnVNetAlias returns the alias of the VNets created for a cluster, which marks them as owned by the cluster.
+func sdnVNetAlias(clusterScope *scope.ClusterScope) string {
+	return fmt.Sprintf("capmox-%s-%s", clusterScope.Namespace(), clusterScope.Name())
+}
+
+// sdnSubnets returns the subnets of the VNet of a cluster, which are the networks of its IP pools.
+// Pools without a gateway do not define a network, pools in the same network share its subnet.
+func sdnSubnets(cluster *infrav1alpha1.ProxmoxCluster) []proxmox.SDNSubnet {
+	configs := []*ipamicv1.InClusterIPPoolSpec{cluster.Spec.IPv4Config, cluster.Spec.IPv6Config}
+	for _, additional := range [][]ipamicv1.InClusterIPPoolSpec{cluster.Spec.AdditionalIPv4Configs, cluster.Spec.AdditionalIPv6Configs} {
+		for i := range additional {
+			configs = append(configs, &additional[i])
+		}
+	}
+
+	var subnets []proxmox.SDNSubnet
+	seen := make(map[string]struct{})
+	for _, config := range configs {
+		if config == nil || config.Gateway == "" {
+			continue
+		}
+		gateway, err := netip.ParseAddr(config.Gateway)
+		if err != nil {
+			continue
+		}
+		prefix, err := gateway.Prefix(config.Prefix)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[prefix.String()]; ok {
+			continue
+		}
+		seen[prefix.String()] = struct{}{}
+		subnets = append(subnets, proxmox.SDNSubnet{CIDR: prefix.String(), Gateway: config.Gateway})
+	}
+	return subnets
+}
+
+// reconcileSDN makes sure the VNet of the cluster exists in the configured zone, creating it if necessary.
+func (r *ProxmoxClusterReconciler) reconcileSDN(ctx context.Context, clusterScope *scope.ClusterScope) error {
+	sdn := clusterScope.ProxmoxCluster.Spec.SDN
+	if sdn == nil {
+		return nil
+	}
+	client := clusterScope.ProxmoxClient
+
+	vnets, err := client.ListSDNVNets(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list sdn vnets")
+	}
+	for _, vnet := range vnets {
+		if vnet.Name != sdn.VNet {
+			continue
+		}
+		if vnet.Zone != sdn.Zone {
+			conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.SDNVNetReadyCondition, infrav1alpha1.SDNVNetConflictReason, clusterv1.ConditionSeverityError,
+				"vnet %s exists in zone %s instead of %s", vnet.Name, vnet.Zone, sdn.Zone)
+			return errors.Errorf("sdn vnet %s exists in zone %s instead of %s", vnet.Name, vnet.Zone, sdn.Zone)
+		}
+		conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.SDNVNetReadyCondition)
+		return nil
+	}
+
+	zones, err := client.ListSDNZones(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list sdn zones")
+	}
+	zoneExists := false
+	for _, zone := range zones {
+		zoneExists = zoneExists || zone.Name == sdn.Zone
+	}
+	if !zoneExists {
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.SDNVNetReadyCondition, infrav1alpha1.SDNZoneNotFoundReason, clusterv1.ConditionSeverityError,
+			"zone %s does not exist", sdn.Zone)
+		return errors.Errorf("sdn zone %s does not exist", sdn.Zone)
+	}
+
+	vnet := proxmox.SDNVNet{Name: sdn.VNet, Zone: sdn.Zone, Alias: sdnVNetAlias(clusterScope)}
+	if sdn.Tag != nil {
+		vnet.Tag = int(*sdn.Tag)
+	}
+	clusterScope.Info("creating sdn vnet", "vnet", vnet.Name, "zone", vnet.Zone)
+	if err := client.CreateSDNVNet(ctx, vnet, sdnSubnets(clusterScope.ProxmoxCluster)...); err != nil {
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.SDNVNetReadyCondition, infrav1alpha1.SDNVNetCreationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return err
+	}
+	if err := applySDN(ctx, client); err != nil {
+		conditions.MarkFalse(clusterScope.ProxmoxCluster, infrav1alpha1.SDNVNetReadyCondition, infrav1alpha1.SDNVNetCreationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return err
+	}
+
+	conditions.MarkTrue(clusterScope.ProxmoxCluster, infrav1alpha1.SDNVNetReadyCondition)
+	return nil
+}
+
+// reconcileDeleteSDN deletes the VNet of a cluster if it was created for the cluster.
+func (r *ProxmoxClusterReconciler) reconcileDeleteSDN(ctx context.Context, clusterScope *scope.ClusterScope) error {
+	sdn := clusterScope.ProxmoxCluster.Spec.SDN
+	if sdn == nil {
+		return nil
+	}
+	client := clusterScope.ProxmoxClient
+
+	vnets, err := client.ListSDNVNets(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list sdn vnets")
+	}
+	// vnets which were not created by the provider are left alone.
+	owned := false
+	for _, vnet := range vnets {
+		owned = owned || (vnet.Name == sdn.VNet && vnet.Alias == sdnVNetAlias(clusterScope))
+	}
+	if !owned {
+		return nil
+	}
+
+	clusterScope.Info("deleting sdn vnet", "vnet", sdn.VNet)
+	if err := client.DeleteSDNVNet(ctx, sdn.VNet); err != nil {
+		return err
+	}
+	return applySDN(ctx, client)
+}
+
+// applySDN applies the pending SDN configuration and waits for the nodes to reload their network.
+func applySDN(ctx context.Context, client proxmox.Client) error {
+	task, err := client.ApplySDN(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := client.WaitForTask(ctx, string(task.UPID), proxmox.TaskWaitOptions{}); err != nil {
+		return errors.Wrap(err, "unable to apply sdn configuration")
+	}
+	return nil
+}
+
+// reconcileStaleIPAddressClaims deletes the IP address claims of the cluster whose ProxmoxMachine
+// is gone, e.g. because the machine was deleted without cascading to its claims.
+func (r *ProxmoxClusterReconciler) reconcileStaleIPAddressClaims(ctx context.Context, clusterScope *scope.ClusterScope) error {
+	claims, err := clusterScope.IPAMHelper.ListIPAddressClaims(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list ip address claims")
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+
+	machines, err := r.listProxmoxMachinesForCluster(ctx, clusterScope)
+	if err != nil {
+		return errors.Wrapf(err, "could not retrieve proxmox machines for cluster %q", clusterScope.InfraClusterName())
+	}
+	machineUIDs := make(map[string]types.UID, len(machines))
+	for _, machine := range machines {
+		machineUIDs[machine.GetName()] = machine.GetUID()
+	}
+
+	for i := range claims {
+		claim := &claims[i]
+		if !claim.GetDeletionTimestamp().IsZero() || !isStaleIPAddressClaim(claim, machineUIDs) {
+			continue
+		}
+
+		clusterScope.Info("deleting stale IPAddressClaim", "claim", claim.GetName())
+		if err := clusterScope.IPAMHelper.DeleteIPAddressClaim(ctx, claim); err != nil {
+			return errors.Wrapf(err, "unable to delete stale ip address claim %s", claim.GetName())
+		}
+	}
+
+	return nil
+}
+
+// ipAddressClaimNameRegexp matches the names of the IP address claims of the network devices of a machine.
+var ipAddressClaimNameRegexp = regexp.MustCompile(`^(.+)-net\d+-inet6?$`)
+
+// isStaleIPAddressClaim returns whether the claim belongs to a ProxmoxMachine which does not exist.
+// Claims without owner, which are left over by an orphaning deletion, are attributed by their name.
+func isStaleIPAddressClaim(claim *ipamv1.IPAddressClaim, machineUIDs map[string]types.UID) bool {
+	if owner := metav1.GetControllerOf(claim); owner != nil {
+		if owner.Kind != infrav1alpha1.ProxmoxMachineKind || !strings.HasPrefix(owner.APIVersion, infrav1alpha1.GroupVersion.Group+"/") {
+			return false
+		}
+		uid, ok := machineUIDs[owner.Name]
+		return !ok || uid != owner.UID
+	}
+
+	if len(claim.GetOwnerReferences()) > 0 {
+		return false
+	}
+	match := ipAddressClaimNameRegexp.FindStringSubmatch(claim.GetName())
+	if match == nil {
+		return false
+	}
+	_, ok := machineUIDs[match[1]]
+	return !ok
+}
+
 func (r *ProxmoxClusterReconciler) listProxmoxMachinesForCluster(ctx context.Context, clusterScope *scope.ClusterScope) ([]infrav1alpha1.ProxmoxMachine, error) {
 	var machineList infrav1alpha1.ProxmoxMachineList
 
@@ -237,9 +679,40 @@ func (r *ProxmoxClusterReconciler) listProxmoxMachinesForCluster(ctx context.Con
 func (r *ProxmoxClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1alpha1.ProxmoxCluster{}).
+		WithOptions(r.ControllerOptions.options()).
 		WithEventFilter(predicates.ResourceNotPaused(ctrl.LoggerFrom(ctx))).
+		// the IPAM provider updates the address counts in the status of the pools.
+		Owns(&ipamicv1.InClusterIPPool{}).
 		Watches(&clusterv1.Cluster{},
 			handler.EnqueueRequestsFromMapFunc(util.ClusterToInfrastructureMapFunc(ctx, infrav1alpha1.GroupVersion.WithKind(infrav1alpha1.ProxmoxClusterKind), mgr.GetClient(), &infrav1alpha1.ProxmoxCluster{})),
 			builder.WithPredicates(predicates.ClusterUnpaused(ctrl.LoggerFrom(ctx)))).
+		Watches(&infrav1alpha1.ProxmoxMachine{},
+			handler.EnqueueRequestsFromMapFunc(r.proxmoxMachineToProxmoxCluster),
+			builder.WithPredicates(predicate.Funcs{
+				// the cluster waits for its machines to be gone, and its backup job follows their VMs.
+				CreateFunc:  func(event.CreateEvent) bool { return false },
+				UpdateFunc:  func(e event.UpdateEvent) bool { return backupSelectionChanged(e.ObjectOld, e.ObjectNew) },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			})).
 		Complete(r)
 }
+
+// proxmoxMachineToProxmoxCluster maps a ProxmoxMachine to the ProxmoxCluster of its cluster.
+func (r *ProxmoxClusterReconciler) proxmoxMachineToProxmoxCluster(ctx context.Context, o client.Object) []reconcile.Request {
+	clusterName, ok := o.GetLabels()[clusterv1.ClusterNameLabel]
+	if !ok {
+		return nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: o.GetNamespace(), Name: clusterName}, cluster); err != nil {
+		return nil
+	}
+
+	ref := cluster.Spec.InfrastructureRef
+	if ref == nil || ref.Kind != infrav1alpha1.ProxmoxClusterKind {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: cluster.Namespace, Name: ref.Name}}}
+}
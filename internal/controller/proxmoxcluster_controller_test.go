@@ -18,26 +18,39 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"testing"
 	"time"
 
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	"github.com/go-logr/logr"
+	go_proxmox "github.com/luthermonson/go-proxmox"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/require"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 	ipamicv1 "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 )
 
 var (
@@ -287,3 +300,269 @@ func dummyIPAddress(client client.Client, owner client.Object, poolName string)
 		},
 	}
 }
+
+func TestReconcileNodeStatus(t *testing.T) {
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	clusterScope := &scope.ClusterScope{
+		ProxmoxCluster: &infrav1.ProxmoxCluster{
+			Spec: infrav1.ProxmoxClusterSpec{AllowedNodes: []string{"pve1", "pve2", "pve3"}},
+		},
+		ProxmoxClient: proxmoxClient,
+	}
+	r := &ProxmoxClusterReconciler{NodeStatusRefreshInterval: time.Minute}
+
+	proxmoxClient.EXPECT().GetNodeSummaries(context.Background()).Return([]proxmox.NodeSummary{
+		{Name: "pve1", Online: true, Version: "8.1.3", CPUs: 16, MemoryBytes: 64 << 30, AllocatedCPUs: 6, AllocatedMemoryBytes: 12 << 30},
+		{Name: "pve2"},
+		{Name: "pve4", Online: true},
+	}, nil).Once()
+
+	res, err := r.reconcileNodeStatus(context.Background(), clusterScope)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, res.RequeueAfter)
+	require.Equal(t, []infrav1.NodeStatus{
+		{Name: "pve1", Online: true, Version: "8.1.3", CPUs: 16, AllocatedCPUs: 6, MemoryMiB: 65536, AllocatedMemoryMiB: 12288},
+		{Name: "pve2"},
+		{Name: "pve3"},
+	}, clusterScope.ProxmoxCluster.Status.Nodes)
+	require.NotNil(t, clusterScope.ProxmoxCluster.Status.NodesRefreshTime)
+
+	// the summaries are not refreshed before the interval has passed.
+	res, err = r.reconcileNodeStatus(context.Background(), clusterScope)
+	require.NoError(t, err)
+	require.Greater(t, res.RequeueAfter, time.Duration(0))
+	require.LessOrEqual(t, res.RequeueAfter, time.Minute)
+
+	r.NodeStatusRefreshInterval = 0
+	res, err = r.reconcileNodeStatus(context.Background(), clusterScope)
+	require.NoError(t, err)
+	require.True(t, res.IsZero())
+	require.Nil(t, clusterScope.ProxmoxCluster.Status.Nodes)
+	require.Nil(t, clusterScope.ProxmoxCluster.Status.NodesRefreshTime)
+}
+
+func TestReconcileAPIReachability(t *testing.T) {
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	clusterScope := &scope.ClusterScope{
+		ProxmoxCluster: &infrav1.ProxmoxCluster{},
+		ProxmoxClient:  proxmoxClient,
+	}
+	r := &ProxmoxClusterReconciler{APIProbeInterval: time.Minute}
+
+	proxmoxClient.EXPECT().GetAPIStatus(context.Background()).Return(proxmox.APIStatus{}, errors.New("connection refused")).Once()
+	r.reconcileAPIReachability(context.Background(), clusterScope)
+	require.True(t, conditions.IsFalse(clusterScope.ProxmoxCluster, infrav1.ProxmoxAPIReachableCondition))
+	require.Equal(t, infrav1.ProxmoxAPIUnreachableReason, conditions.GetReason(clusterScope.ProxmoxCluster, infrav1.ProxmoxAPIReachableCondition))
+	require.Equal(t, "connection refused", conditions.GetMessage(clusterScope.ProxmoxCluster, infrav1.ProxmoxAPIReachableCondition))
+
+	proxmoxClient.EXPECT().GetAPIStatus(context.Background()).Return(proxmox.APIStatus{Version: "8.1.3", Clustered: true}, nil).Once()
+	r.reconcileAPIReachability(context.Background(), clusterScope)
+	require.Equal(t, infrav1.ProxmoxClusterNotQuorateReason, conditions.GetReason(clusterScope.ProxmoxCluster, infrav1.ProxmoxAPIReachableCondition))
+
+	proxmoxClient.EXPECT().GetAPIStatus(context.Background()).Return(proxmox.APIStatus{Version: "8.1.3", Quorate: true}, nil).Once()
+	r.reconcileAPIReachability(context.Background(), clusterScope)
+	require.True(t, conditions.IsTrue(clusterScope.ProxmoxCluster, infrav1.ProxmoxAPIReachableCondition))
+
+	r.APIProbeInterval = 0
+	r.reconcileAPIReachability(context.Background(), clusterScope)
+	require.False(t, conditions.Has(clusterScope.ProxmoxCluster, infrav1.ProxmoxAPIReachableCondition))
+}
+
+func TestRequeueWithin(t *testing.T) {
+	require.Equal(t, ctrl.Result{RequeueAfter: time.Minute}, requeueWithin(ctrl.Result{}, time.Minute))
+	require.Equal(t, ctrl.Result{RequeueAfter: time.Second}, requeueWithin(ctrl.Result{RequeueAfter: time.Second}, time.Minute))
+	require.Equal(t, ctrl.Result{RequeueAfter: time.Minute}, requeueWithin(ctrl.Result{RequeueAfter: time.Hour}, time.Minute))
+	require.Equal(t, ctrl.Result{RequeueAfter: time.Hour}, requeueWithin(ctrl.Result{RequeueAfter: time.Hour}, 0))
+}
+
+func TestReconcileStaleIPAddressClaims(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrav1.AddToScheme(scheme))
+	require.NoError(t, ipamv1.AddToScheme(scheme))
+
+	proxmoxCluster := &infrav1.ProxmoxCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault}}
+	machine := &infrav1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-1",
+			Namespace: metav1.NamespaceDefault,
+			UID:       "uid-1",
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: "test"},
+		},
+	}
+
+	newClaim := func(name string, owner *metav1.OwnerReference) *ipamv1.IPAddressClaim {
+		claim := &ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   metav1.NamespaceDefault,
+			Annotations: map[string]string{clusterv1.ClusterNameAnnotation: "test"},
+		}}
+		if owner != nil {
+			claim.SetOwnerReferences([]metav1.OwnerReference{*owner})
+		}
+		return claim
+	}
+	ownerRef := func(kind, name, uid string) *metav1.OwnerReference {
+		return &metav1.OwnerReference{APIVersion: infrav1.GroupVersion.String(), Kind: kind, Name: name, UID: types.UID(uid), Controller: ptr.To(true)}
+	}
+
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		machine,
+		newClaim("test-1-net0-inet", ownerRef(infrav1.ProxmoxMachineKind, "test-1", "uid-1")),
+		// the machine was recreated.
+		newClaim("test-1-net1-inet", ownerRef(infrav1.ProxmoxMachineKind, "test-1", "uid-0")),
+		newClaim("test-2-net0-inet", ownerRef(infrav1.ProxmoxMachineKind, "test-2", "uid-2")),
+		// left over by an orphaning deletion.
+		newClaim("test-3-net0-inet6", nil),
+		newClaim("test-1-net2-inet", nil),
+		newClaim("endpoint", nil),
+		newClaim("other-net0-inet", ownerRef("OtherMachine", "other", "uid-3")),
+	).Build()
+
+	logger := logr.Discard()
+	clusterScope := &scope.ClusterScope{
+		Logger:         &logger,
+		Cluster:        &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault}},
+		ProxmoxCluster: proxmoxCluster,
+		IPAMHelper:     ipam.NewHelper(kubeClient, proxmoxCluster),
+	}
+	r := &ProxmoxClusterReconciler{Client: kubeClient}
+	require.NoError(t, r.reconcileStaleIPAddressClaims(context.Background(), clusterScope))
+
+	var claims ipamv1.IPAddressClaimList
+	require.NoError(t, kubeClient.List(context.Background(), &claims))
+	var names []string
+	for _, claim := range claims.Items {
+		names = append(names, claim.GetName())
+	}
+	require.ElementsMatch(t, []string{"test-1-net0-inet", "test-1-net2-inet", "endpoint", "other-net0-inet"}, names)
+}
+
+func TestReconcileDeleteIPPools(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrav1.AddToScheme(scheme))
+	require.NoError(t, ipamv1.AddToScheme(scheme))
+	require.NoError(t, ipamicv1.AddToScheme(scheme))
+
+	proxmoxCluster := &infrav1.ProxmoxCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+		Spec: infrav1.ProxmoxClusterSpec{
+			IPv4Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"10.10.10.2-10.10.10.10"}, Prefix: 24, Gateway: "10.10.10.1"},
+			IPv6Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"2001:db8::2-2001:db8::10"}, Prefix: 64, Gateway: "2001:db8::1"},
+			NodeIPPools: []infrav1.NodeIPPool{{
+				Name:       "rack1",
+				Nodes:      []string{"pve1"},
+				IPv4Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"10.10.11.2-10.10.11.10"}, Prefix: 24, Gateway: "10.10.11.1"},
+			}},
+		},
+	}
+	address := &ipamv1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-1-net0-inet", Namespace: metav1.NamespaceDefault},
+		Spec: ipamv1.IPAddressSpec{
+			PoolRef: corev1.TypedLocalObjectReference{APIGroup: ptr.To(ipamicv1.GroupVersion.Group), Kind: "InClusterIPPool", Name: "test-v4-icip"},
+			Address: "10.10.10.2",
+			Prefix:  24,
+			Gateway: "10.10.10.1",
+		},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(proxmoxCluster, address).Build()
+
+	logger := logr.Discard()
+	clusterScope := &scope.ClusterScope{
+		Logger:         &logger,
+		Cluster:        &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault}},
+		ProxmoxCluster: proxmoxCluster,
+		IPAMHelper:     ipam.NewHelper(kubeClient, proxmoxCluster),
+	}
+	require.NoError(t, clusterScope.IPAMHelper.CreateOrUpdateInClusterIPPool(context.Background()))
+	r := &ProxmoxClusterReconciler{Client: kubeClient}
+
+	// pools which were not created for the cluster are left alone.
+	foreign := &ipamicv1.InClusterIPPool{ObjectMeta: metav1.ObjectMeta{Name: "foreign", Namespace: metav1.NamespaceDefault}}
+	require.NoError(t, kubeClient.Create(context.Background(), foreign))
+
+	// the pool is kept as long as an IP address is allocated from it.
+	requeue, err := r.reconcileDeleteIPPools(context.Background(), clusterScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	condition := conditions.Get(proxmoxCluster, infrav1.IPPoolsDeletedCondition)
+	require.Equal(t, infrav1.IPAddressesInUseReason, condition.Reason)
+	require.Equal(t, "IP addresses are still allocated from test-v4-icip (1)", condition.Message)
+
+	var pools ipamicv1.InClusterIPPoolList
+	require.NoError(t, kubeClient.List(context.Background(), &pools))
+	require.Len(t, pools.Items, 2)
+	require.Equal(t, "foreign", pools.Items[0].GetName())
+	require.Equal(t, "test-v4-icip", pools.Items[1].GetName())
+
+	require.NoError(t, kubeClient.Delete(context.Background(), address))
+	requeue, err = r.reconcileDeleteIPPools(context.Background(), clusterScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1.DeletingIPPoolsReason, conditions.GetReason(proxmoxCluster, infrav1.IPPoolsDeletedCondition))
+
+	requeue, err = r.reconcileDeleteIPPools(context.Background(), clusterScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, conditions.IsTrue(proxmoxCluster, infrav1.IPPoolsDeletedCondition))
+}
+
+func newSDNClusterScope(t *testing.T) (*scope.ClusterScope, *proxmoxtest.MockClient) {
+	return newTestClusterScope(t, infrav1.ProxmoxClusterSpec{
+		IPv4Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"10.10.10.2-10.10.10.10"}, Prefix: 24, Gateway: "10.10.10.1"},
+		IPv6Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"2001:db8::2-2001:db8::10"}, Prefix: 64},
+		SDN:        &infrav1.SDNSpec{Zone: "capmox", VNet: "test", Tag: ptr.To[int32](100)},
+	})
+}
+
+func TestReconcileSDN(t *testing.T) {
+	ctx := context.Background()
+	clusterScope, proxmoxClient := newSDNClusterScope(t)
+	r := &ProxmoxClusterReconciler{}
+
+	vnet := proxmox.SDNVNet{Name: "test", Zone: "capmox", Tag: 100, Alias: "capmox-default-test"}
+	proxmoxClient.EXPECT().ListSDNVNets(ctx).Return(nil, nil).Once()
+	proxmoxClient.EXPECT().ListSDNZones(ctx).Return([]proxmox.SDNZone{{Name: "capmox", Type: "vlan"}}, nil).Once()
+	// only the IPv4 pool has a gateway and defines a subnet.
+	proxmoxClient.EXPECT().CreateSDNVNet(ctx, vnet, proxmox.SDNSubnet{CIDR: "10.10.10.0/24", Gateway: "10.10.10.1"}).Return(nil).Once()
+	proxmoxClient.EXPECT().ApplySDN(ctx).Return(&go_proxmox.Task{UPID: "sdn"}, nil).Once()
+	proxmoxClient.EXPECT().WaitForTask(ctx, "sdn", proxmox.TaskWaitOptions{}).Return(&go_proxmox.Task{}, nil).Once()
+
+	require.NoError(t, r.reconcileSDN(ctx, clusterScope))
+	require.True(t, conditions.IsTrue(clusterScope.ProxmoxCluster, infrav1.SDNVNetReadyCondition))
+
+	// an existing vnet is used as is.
+	proxmoxClient.EXPECT().ListSDNVNets(ctx).Return([]proxmox.SDNVNet{vnet}, nil).Once()
+	require.NoError(t, r.reconcileSDN(ctx, clusterScope))
+
+	proxmoxClient.EXPECT().ListSDNVNets(ctx).Return([]proxmox.SDNVNet{{Name: "test", Zone: "other"}}, nil).Once()
+	require.ErrorContains(t, r.reconcileSDN(ctx, clusterScope), "exists in zone other")
+	require.Equal(t, infrav1.SDNVNetConflictReason, conditions.GetReason(clusterScope.ProxmoxCluster, infrav1.SDNVNetReadyCondition))
+}
+
+func TestReconcileSDN_ZoneNotFound(t *testing.T) {
+	ctx := context.Background()
+	clusterScope, proxmoxClient := newSDNClusterScope(t)
+	r := &ProxmoxClusterReconciler{}
+
+	proxmoxClient.EXPECT().ListSDNVNets(ctx).Return(nil, nil).Once()
+	proxmoxClient.EXPECT().ListSDNZones(ctx).Return([]proxmox.SDNZone{{Name: "other", Type: "simple"}}, nil).Once()
+
+	require.ErrorContains(t, r.reconcileSDN(ctx, clusterScope), "sdn zone capmox does not exist")
+	require.Equal(t, infrav1.SDNZoneNotFoundReason, conditions.GetReason(clusterScope.ProxmoxCluster, infrav1.SDNVNetReadyCondition))
+}
+
+func TestReconcileDeleteSDN(t *testing.T) {
+	ctx := context.Background()
+	clusterScope, proxmoxClient := newSDNClusterScope(t)
+	r := &ProxmoxClusterReconciler{}
+
+	// vnets which were not created for the cluster are kept.
+	proxmoxClient.EXPECT().ListSDNVNets(ctx).Return([]proxmox.SDNVNet{{Name: "test", Zone: "capmox"}}, nil).Once()
+	require.NoError(t, r.reconcileDeleteSDN(ctx, clusterScope))
+
+	proxmoxClient.EXPECT().ListSDNVNets(ctx).Return([]proxmox.SDNVNet{{Name: "test", Zone: "capmox", Alias: "capmox-default-test"}}, nil).Once()
+	proxmoxClient.EXPECT().DeleteSDNVNet(ctx, "test").Return(nil).Once()
+	proxmoxClient.EXPECT().ApplySDN(ctx).Return(&go_proxmox.Task{UPID: "sdn"}, nil).Once()
+	proxmoxClient.EXPECT().WaitForTask(ctx, "sdn", proxmox.TaskWaitOptions{}).Return(&go_proxmox.Task{}, nil).Once()
+	require.NoError(t, r.reconcileDeleteSDN(ctx, clusterScope))
+}
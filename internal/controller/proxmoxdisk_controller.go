@@ -0,0 +1,201 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/vmservice"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// ProxmoxDiskReconciler allocates the volumes of ProxmoxDisks and destroys them when the ProxmoxDisks are deleted.
+// ProxmoxMachines attach and detach the volumes themselves.
+type ProxmoxDiskReconciler struct {
+	client.Client
+	ProxmoxClient proxmox.Client
+
+	// ControllerOptions configures the concurrency and the rate limiting of the controller.
+	ControllerOptions ControllerOptions
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProxmoxDiskReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1alpha1.ProxmoxDisk{}).
+		WithOptions(r.ControllerOptions.options()).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxdisks,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxdisks/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxdisks/finalizers,verbs=update
+
+// Reconcile allocates or destroys the volume of a ProxmoxDisk.
+func (r *ProxmoxDiskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	disk := &infrav1alpha1.ProxmoxDisk{}
+	if err := r.Get(ctx, req.NamespacedName, disk); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	helper, err := patch.NewHelper(disk, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		// the disk is gone once its finalizer is removed.
+		if err := kerrors.FilterOut(helper.Patch(ctx, disk), apierrors.IsNotFound); err != nil && reterr == nil {
+			reterr = err
+		}
+	}()
+
+	// the machine the volume is attached to was removed without detaching it, e.g. after its finalizer was removed.
+	if disk.Status.AttachedTo != "" {
+		machine := &infrav1alpha1.ProxmoxMachine{}
+		err := r.Get(ctx, client.ObjectKey{Namespace: disk.GetNamespace(), Name: disk.Status.AttachedTo}, machine)
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		if apierrors.IsNotFound(err) {
+			ctrl.LoggerFrom(ctx).Info("ProxmoxMachine of the volume does not exist anymore", "machine", disk.Status.AttachedTo)
+			disk.Status.AttachedTo = ""
+		}
+	}
+
+	if !disk.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.reconcileDelete(ctx, disk)
+	}
+
+	return ctrl.Result{}, r.reconcileNormal(ctx, disk)
+}
+
+func (r *ProxmoxDiskReconciler) reconcileNormal(ctx context.Context, disk *infrav1alpha1.ProxmoxDisk) error {
+	ctrlutil.AddFinalizer(disk, infrav1alpha1.DiskFinalizer)
+	if disk.Status.Volume != "" {
+		disk.Status.Ready = true
+		conditions.MarkTrue(disk, infrav1alpha1.DiskReadyCondition)
+		return nil
+	}
+
+	spec := disk.Spec
+	storage, err := r.ProxmoxClient.GetStorage(ctx, spec.Node, spec.Storage)
+	if err != nil {
+		conditions.MarkFalse(disk, infrav1alpha1.DiskReadyCondition, infrav1alpha1.DiskAllocationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return errors.Wrapf(err, "unable to get storage %s of node %s", spec.Storage, spec.Node)
+	}
+
+	if err := storage.Validate(spec.Node, proxmox.StorageContentImages, ""); err != nil {
+		conditions.MarkFalse(disk, infrav1alpha1.DiskReadyCondition, infrav1alpha1.DiskAllocationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return err
+	}
+
+	format := spec.Format
+	if format == "" {
+		format = infrav1alpha1.TargetStorageFormatRaw
+	}
+	if !vmservice.SupportsFormat(storage, format) {
+		// the spec is immutable, so there is no need to retry.
+		conditions.MarkFalse(disk, infrav1alpha1.DiskReadyCondition, infrav1alpha1.DiskAllocationFailedReason, clusterv1.ConditionSeverityError,
+			"storage %s of type %s only supports raw volumes, use the raw format or a file based storage like dir or nfs", spec.Storage, storage.Type)
+		return nil
+	}
+
+	ownerID, err := r.volumeOwnerID(ctx, disk)
+	if err != nil {
+		return err
+	}
+
+	filename := vmservice.VolumeFilename(ownerID, fmt.Sprintf("%s-%s", disk.GetNamespace(), disk.GetName()), storage, format)
+	volume, err := r.ProxmoxClient.AllocateVolume(ctx, spec.Node, spec.Storage, ownerID, filename, spec.SizeGB)
+	if err != nil {
+		conditions.MarkFalse(disk, infrav1alpha1.DiskReadyCondition, infrav1alpha1.DiskAllocationFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return err
+	}
+
+	ctrl.LoggerFrom(ctx).Info("allocated volume", "volume", volume)
+	disk.Status.Volume = volume
+	disk.Status.Shared = storage.Shared
+	disk.Status.Ready = true
+	conditions.MarkTrue(disk, infrav1alpha1.DiskReadyCondition)
+	return nil
+}
+
+func (r *ProxmoxDiskReconciler) reconcileDelete(ctx context.Context, disk *infrav1alpha1.ProxmoxDisk) error {
+	if disk.Status.AttachedTo != "" {
+		// the volume is destroyed once the machine is deleted, which updates the status of the disk.
+		conditions.MarkFalse(disk, infrav1alpha1.DiskReadyCondition, infrav1alpha1.DiskAttachedReason, clusterv1.ConditionSeverityInfo,
+			"volume is attached to ProxmoxMachine %s", disk.Status.AttachedTo)
+		return nil
+	}
+
+	if disk.Status.Volume != "" {
+		task, err := r.ProxmoxClient.DeleteVolume(ctx, disk.Spec.Node, disk.Status.Volume)
+		if err != nil && !strings.Contains(err.Error(), "does not exist") {
+			return err
+		}
+		if task != nil {
+			if _, err := r.ProxmoxClient.WaitForTask(ctx, string(task.UPID), proxmox.TaskWaitOptions{}); err != nil {
+				return errors.Wrapf(err, "unable to delete volume %s", disk.Status.Volume)
+			}
+		}
+		ctrl.LoggerFrom(ctx).Info("deleted volume", "volume", disk.Status.Volume)
+		disk.Status.Volume = ""
+		disk.Status.Ready = false
+	}
+
+	ctrlutil.RemoveFinalizer(disk, infrav1alpha1.DiskFinalizer)
+	return nil
+}
+
+// volumeOwnerID returns the VMID which owns the volumes of the cluster of the ProxmoxDisk,
+// or the default if the disk does not belong to a cluster.
+func (r *ProxmoxDiskReconciler) volumeOwnerID(ctx context.Context, disk *infrav1alpha1.ProxmoxDisk) (int64, error) {
+	name, ok := disk.GetLabels()[clusterv1.ClusterNameLabel]
+	if !ok {
+		return infrav1alpha1.DefaultVolumeOwnerID, nil
+	}
+
+	cluster, err := util.GetClusterByName(ctx, r.Client, disk.GetNamespace(), name)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to get cluster %s", name)
+	}
+	proxmoxCluster := &infrav1alpha1.ProxmoxCluster{}
+	if cluster.Spec.InfrastructureRef != nil {
+		key := client.ObjectKey{Namespace: disk.GetNamespace(), Name: cluster.Spec.InfrastructureRef.Name}
+		if err := r.Get(ctx, key, proxmoxCluster); client.IgnoreNotFound(err) != nil {
+			return 0, err
+		}
+	}
+	return proxmoxCluster.GetVolumeOwnerID(), nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	go_proxmox "github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func newTestDisk() *infrav1.ProxmoxDisk {
+	return &infrav1.ProxmoxDisk{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: metav1.NamespaceDefault},
+		Spec:       infrav1.ProxmoxDiskSpec{Node: "pve1", Storage: "nfs", SizeGB: 10, Format: infrav1.TargetStorageFormatQcow2},
+	}
+}
+
+func TestReconcileProxmoxDisk_Allocates(t *testing.T) {
+	disk := newTestDisk()
+	kubeClient := newTestClient(t, disk)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "pve1", "nfs").Return(proxmox.StorageInfo{Name: "nfs", Type: "nfs", Shared: true, Content: []string{proxmox.StorageContentImages}, Enabled: true, Active: true}, nil).Once()
+	proxmoxClient.EXPECT().AllocateVolume(context.Background(), "pve1", "nfs", int64(infrav1.DefaultVolumeOwnerID), "vm-9999-default-data.qcow2", int32(10)).
+		Return("nfs:9999/vm-9999-default-data.qcow2", nil).Once()
+	reconciler := &ProxmoxDiskReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(disk)})
+	require.NoError(t, err)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(disk), disk))
+	require.Contains(t, disk.Finalizers, infrav1.DiskFinalizer)
+	require.True(t, disk.Status.Ready)
+	require.True(t, disk.Status.Shared)
+	require.Equal(t, "nfs:9999/vm-9999-default-data.qcow2", disk.Status.Volume)
+	require.True(t, conditions.IsTrue(disk, infrav1.DiskReadyCondition))
+}
+
+func TestReconcileProxmoxDisk_UnsupportedFormat(t *testing.T) {
+	disk := newTestDisk()
+	disk.Spec.Storage = "local-lvm"
+	kubeClient := newTestClient(t, disk)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "pve1", "local-lvm").Return(proxmox.StorageInfo{Name: "local-lvm", Type: "lvmthin", Content: []string{proxmox.StorageContentImages}, Enabled: true, Active: true}, nil).Once()
+	reconciler := &ProxmoxDiskReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(disk)})
+	require.NoError(t, err)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(disk), disk))
+	require.False(t, disk.Status.Ready)
+	require.Equal(t, infrav1.DiskAllocationFailedReason, conditions.GetReason(disk, infrav1.DiskReadyCondition))
+}
+
+func TestReconcileProxmoxDisk_StorageWithoutImages(t *testing.T) {
+	disk := newTestDisk()
+	kubeClient := newTestClient(t, disk)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "pve1", "nfs").
+		Return(proxmox.StorageInfo{Name: "nfs", Type: "nfs", Shared: true, Content: []string{proxmox.StorageContentBackup}, Enabled: true, Active: true}, nil).Once()
+	reconciler := &ProxmoxDiskReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	// the content of the storage may be fixed, so the reconciliation is retried.
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(disk)})
+	require.ErrorContains(t, err, "storage nfs on node pve1 does not support content images")
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(disk), disk))
+	require.False(t, disk.Status.Ready)
+	require.Equal(t, infrav1.DiskAllocationFailedReason, conditions.GetReason(disk, infrav1.DiskReadyCondition))
+}
+
+func TestReconcileProxmoxDisk_DeleteWaitsForDetach(t *testing.T) {
+	disk := newTestDisk()
+	disk.Finalizers = []string{infrav1.DiskFinalizer}
+	disk.Status = infrav1.ProxmoxDiskStatus{Ready: true, Volume: "nfs:9999/vm-9999-default-data.qcow2", AttachedTo: "test"}
+	machine := &infrav1.ProxmoxMachine{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault}}
+	kubeClient := newTestClient(t, disk, machine)
+	require.NoError(t, kubeClient.Delete(context.Background(), disk))
+	reconciler := &ProxmoxDiskReconciler{Client: kubeClient, ProxmoxClient: proxmoxtest.NewMockClient(t)}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(disk)})
+	require.NoError(t, err)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(disk), disk))
+	require.Equal(t, infrav1.DiskAttachedReason, conditions.GetReason(disk, infrav1.DiskReadyCondition))
+}
+
+func TestReconcileProxmoxDisk_Delete(t *testing.T) {
+	disk := newTestDisk()
+	disk.Finalizers = []string{infrav1.DiskFinalizer}
+	// the machine was removed without detaching the volume.
+	disk.Status = infrav1.ProxmoxDiskStatus{Ready: true, Volume: "nfs:9999/vm-9999-default-data.qcow2", AttachedTo: "gone"}
+	kubeClient := newTestClient(t, disk)
+	require.NoError(t, kubeClient.Delete(context.Background(), disk))
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	task := &go_proxmox.Task{UPID: "UPID:pve1:00000001:00000001:00000001:imgdel::root@pam:"}
+	proxmoxClient.EXPECT().DeleteVolume(context.Background(), "pve1", "nfs:9999/vm-9999-default-data.qcow2").Return(task, nil).Once()
+	proxmoxClient.EXPECT().WaitForTask(context.Background(), string(task.UPID), proxmox.TaskWaitOptions{}).Return(task, nil).Once()
+	reconciler := &ProxmoxDiskReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(disk)})
+	require.NoError(t, err)
+
+	err = kubeClient.Get(context.Background(), client.ObjectKeyFromObject(disk), disk)
+	require.True(t, apierrors.IsNotFound(err))
+}
@@ -18,14 +18,17 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -50,16 +53,38 @@ type ProxmoxMachineReconciler struct {
 	Scheme        *runtime.Scheme
 	Recorder      record.EventRecorder
 	ProxmoxClient proxmox.Client
+
+	// TaskWatcher requeues ProxmoxMachines once their in-flight task completed.
+	// A watcher is created when setting up the controller if it is not set.
+	TaskWatcher *taskservice.Watcher
+
+	// DriftCheckInterval is the interval in which ready ProxmoxMachines are checked
+	// for drift of their VM config. Zero disables periodic checks.
+	DriftCheckInterval time.Duration
+
+	// ControllerOptions configures the concurrency and the rate limiting of the controller.
+	ControllerOptions ControllerOptions
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ProxmoxMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.TaskWatcher == nil {
+		r.TaskWatcher = taskservice.NewWatcher(r.ProxmoxClient, taskservice.DefaultWatchInterval, mgr.GetLogger().WithName("taskwatcher"))
+	}
+	if err := mgr.Add(r.TaskWatcher); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1alpha1.ProxmoxMachine{}).
+		WithOptions(r.ControllerOptions.options()).
 		Watches(
 			&clusterv1.Machine{},
 			handler.EnqueueRequestsFromMapFunc(util.MachineToInfrastructureMapFunc(infrav1alpha1.GroupVersion.WithKind(infrav1alpha1.ProxmoxMachineKind))),
 		).
+		// claims are updated once their IPAddress is allocated.
+		Owns(&ipamv1.IPAddressClaim{}).
+		WatchesRawSource(r.TaskWatcher.Source(), &handler.EnqueueRequestForObject{}).
 		Complete(r)
 }
 
@@ -68,6 +93,7 @@ func (r *ProxmoxMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines/finalizers,verbs=update
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets;,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",namespace=system,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
 
 //+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch
@@ -112,7 +138,7 @@ func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	if annotations.IsPaused(cluster, proxmoxMachine) {
 		logger.Info("ProxmoxMachine or linked Cluster is marked as paused, not reconciling")
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, reconcilePaused(ctx, r.Client, cluster, proxmoxMachine)
 	}
 
 	logger = logger.WithValues("cluster", klog.KObj(cluster))
@@ -149,10 +175,24 @@ func (r *ProxmoxMachineReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}()
 
 	if !proxmoxMachine.ObjectMeta.DeletionTimestamp.IsZero() {
-		return r.reconcileDelete(ctx, machineScope)
+		res, err := r.reconcileDelete(ctx, machineScope)
+		return reconcileProxmoxAvailability(proxmoxMachine, res, err)
+	}
+
+	// inherit the machine size and the machine defaults of the cluster, they are persisted when closing the scope.
+	if size := proxmoxMachine.Spec.Size; size != "" {
+		machineSize := infraCluster.ProxmoxCluster.MachineSize(size)
+		if machineSize == nil && proxmoxMachine.Spec.VirtualMachineID == nil {
+			conditions.MarkFalse(proxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.MachineSizeNotFoundReason, clusterv1.ConditionSeverityWarning,
+				"size %s is not defined by the ProxmoxCluster", size)
+			return ctrl.Result{}, errors.Errorf("machine size %q is not defined by ProxmoxCluster %s", size, infraCluster.ProxmoxCluster.GetName())
+		}
+		machineSize.ApplyTo(proxmoxMachine)
 	}
+	infraCluster.ProxmoxCluster.Spec.MachineDefaults.ApplyTo(proxmoxMachine)
 
-	return r.reconcileNormal(ctx, machineScope, infraCluster)
+	res, err := r.reconcileNormal(ctx, machineScope, infraCluster)
+	return reconcileProxmoxAvailability(proxmoxMachine, res, err)
 }
 
 func (r *ProxmoxMachineReconciler) reconcileDelete(ctx context.Context, machineScope *scope.MachineScope) (ctrl.Result, error) {
@@ -164,7 +204,7 @@ func (r *ProxmoxMachineReconciler) reconcileDelete(ctx context.Context, machineS
 		return reconcile.Result{}, err
 	}
 	// VM is being deleted
-	return reconcile.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
+	return reconcile.Result{Requeue: true}, nil
 }
 
 func (r *ProxmoxMachineReconciler) reconcileNormal(ctx context.Context, machineScope *scope.MachineScope, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
@@ -198,14 +238,30 @@ func (r *ProxmoxMachineReconciler) reconcileNormal(ctx context.Context, machineS
 		}
 	}
 
+	// In dry-run mode, the Proxmox operations are only recorded in the status.
+	if machineScope.ProxmoxMachine.IsDryRun() {
+		if err := vmservice.ReconcilePlan(ctx, machineScope); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to plan VM")
+		}
+		return reconcile.Result{RequeueAfter: r.DriftCheckInterval}, nil
+	}
+	machineScope.ProxmoxMachine.Status.Plan = nil
+
+	// Cluster API labels the node of an interruptible machine.
+	machineScope.ProxmoxMachine.Status.Interruptible = machineScope.ProxmoxMachine.Spec.Interruptible
+
 	// find the vm
 	// Get or create the VM.
 	vm, err := vmservice.ReconcileVM(ctx, machineScope)
+	r.watchInFlightTask(machineScope)
 	if err != nil {
 		if requeueErr := new(taskservice.RequeueError); errors.As(err, &requeueErr) {
 			machineScope.Error(err, "Requeue requested")
 			return reconcile.Result{RequeueAfter: requeueErr.RequeueAfter()}, nil
 		}
+		if errors.Is(err, proxmox.ErrCircuitOpen) {
+			return reconcile.Result{}, err
+		}
 		machineScope.Logger.Error(err, "error reconciling VM")
 		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile VM")
 	}
@@ -217,7 +273,8 @@ func (r *ProxmoxMachineReconciler) reconcileNormal(ctx context.Context, machineS
 			"VM state is not reconciled",
 			"expectedVMState", infrav1alpha1.VirtualMachineStateReady,
 			"actualVMState", vm.State)
-		return reconcile.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
+		// requeue with backoff, watches on tasks and ip address claims requeue earlier.
+		return reconcile.Result{Requeue: true}, nil
 	}
 
 	// TODO, check if we need to add some labels to the machine.
@@ -226,7 +283,33 @@ func (r *ProxmoxMachineReconciler) reconcileNormal(ctx context.Context, machineS
 	conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 	machineScope.Logger.Info("ProxmoxMachine is ready")
 
-	return reconcile.Result{}, nil
+	if message := vmservice.ReconcileBootstrapLogs(ctx, machineScope); message != "" {
+		r.Recorder.Event(machineScope.ProxmoxMachine, corev1.EventTypeWarning, infrav1alpha1.BootstrapFailedReason, message)
+	}
+
+	// requeue to detect drift of the VM config, to ping the guest agent, to poll the status of cloud-init
+	// and to collect the bootstrap logs of nodes which did not join.
+	requeueAfter := r.DriftCheckInterval
+	intervals := []time.Duration{
+		vmservice.AgentHealthCheckInterval(machineScope),
+		vmservice.CloudInitCheckInterval(machineScope),
+		vmservice.BootstrapLogsCheckInterval(machineScope),
+	}
+	for _, interval := range intervals {
+		if interval > 0 && (requeueAfter <= 0 || interval < requeueAfter) {
+			requeueAfter = interval
+		}
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// watchInFlightTask registers the in-flight task of the machine with the task watcher,
+// so the machine is requeued as soon as the task completed.
+func (r *ProxmoxMachineReconciler) watchInFlightTask(machineScope *scope.MachineScope) {
+	if r.TaskWatcher == nil || machineScope.ProxmoxMachine.Status.TaskRef == nil {
+		return
+	}
+	r.TaskWatcher.Watch(*machineScope.ProxmoxMachine.Status.TaskRef, machineScope.ProxmoxMachine)
 }
 
 func (r *ProxmoxMachineReconciler) getInfraCluster(ctx context.Context, logger *logr.Logger, cluster *clusterv1.Cluster, proxmosMachine *infrav1alpha1.ProxmoxMachine) (*scope.ClusterScope, error) {
@@ -0,0 +1,354 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/vmservice"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+const (
+	// defaultSockets, defaultCores and defaultMemoryMiB are the values Proxmox VE uses
+	// if a template VM does not configure them.
+	defaultSockets   = 1
+	defaultCores     = 1
+	defaultMemoryMiB = 512
+
+	// templateImportRequeueInterval is the interval in which the import of a template VM is checked.
+	templateImportRequeueInterval = 30 * time.Second
+)
+
+// ProxmoxMachineTemplateReconciler publishes the capacity and the node info of the machines of ProxmoxMachineTemplates,
+// so that the cluster autoscaler can scale MachineDeployments from zero replicas. It also imports their template VM
+// from another Proxmox VE cluster, and checks that the pending machines of their MachineDeployments fit on the nodes.
+type ProxmoxMachineTemplateReconciler struct {
+	client.Client
+	ProxmoxClient proxmox.Client
+
+	// SourceClients connects to the source clusters of template VMs with an API token. Templates importing
+	// from the same source cluster with the same credentials share a client.
+	SourceClients *proxmox.ClientPool
+
+	// ControllerOptions configures the concurrency and the rate limiting of the controller.
+	ControllerOptions ControllerOptions
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProxmoxMachineTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1alpha1.ProxmoxMachineTemplate{}).
+		WithOptions(r.ControllerOptions.options()).
+		// the machine sizes and defaults of the cluster apply to the templates.
+		Watches(
+			&infrav1alpha1.ProxmoxCluster{},
+			handler.EnqueueRequestsFromMapFunc(r.proxmoxClusterToTemplates),
+		).
+		// the replicas of the MachineDeployments are the machines the capacity must suffice for.
+		Watches(
+			&clusterv1.MachineDeployment{},
+			handler.EnqueueRequestsFromMapFunc(r.machineDeploymentToTemplate),
+		).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachinetemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachinetemplates/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile computes the capacity and the node info of the machines of a ProxmoxMachineTemplate.
+func (r *ProxmoxMachineTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	template := &infrav1alpha1.ProxmoxMachineTemplate{}
+	if err := r.Get(ctx, req.NamespacedName, template); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.releaseSourceClient(req.String())
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !template.ObjectMeta.DeletionTimestamp.IsZero() {
+		r.releaseSourceClient(req.String())
+		return ctrl.Result{}, nil
+	}
+
+	// the machines inherit the machine size and the machine defaults of the cluster.
+	machine := &infrav1alpha1.ProxmoxMachine{Spec: *template.Spec.Template.Spec.DeepCopy()}
+	proxmoxCluster, err := r.getProxmoxCluster(ctx, template)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if proxmoxCluster != nil {
+		proxmoxCluster.MachineSize(machine.Spec.Size).ApplyTo(machine)
+		proxmoxCluster.Spec.MachineDefaults.ApplyTo(machine)
+	}
+
+	helper, err := patch.NewHelper(template, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if err := helper.Patch(ctx, template); err != nil && reterr == nil {
+			reterr = err
+		}
+	}()
+
+	if template.Spec.TemplateSource != nil {
+		// the capacity is computed once the template VM exists.
+		if requeueAfter, err := r.reconcileTemplateImport(ctx, template, machine.Spec); err != nil || requeueAfter > 0 {
+			return ctrl.Result{RequeueAfter: requeueAfter}, err
+		}
+	}
+	// the client of the source cluster is only needed while importing.
+	r.releaseSourceClient(req.String())
+
+	status, err := r.status(ctx, machine)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	template.Status.Capacity = status.Capacity
+	template.Status.NodeInfo = status.NodeInfo
+
+	if proxmoxCluster != nil {
+		pending, err := r.reconcileCapacity(ctx, template, proxmoxCluster, machine)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if pending {
+			// the reservable memory changes without the template being notified.
+			return ctrl.Result{RequeueAfter: capacityRequeueInterval}, nil
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileTemplateImport migrates the template VM from the source cluster, if it does not exist on this cluster.
+// It returns the duration after which the import is checked again, while it is in progress or after it failed.
+func (r *ProxmoxMachineTemplateReconciler) reconcileTemplateImport(ctx context.Context, template *infrav1alpha1.ProxmoxMachineTemplate, spec infrav1alpha1.ProxmoxMachineSpec) (time.Duration, error) {
+	source := template.Spec.TemplateSource
+	if spec.TemplateID == nil || spec.SourceNode == "" {
+		conditions.MarkFalse(template, infrav1alpha1.TemplateImportedCondition, infrav1alpha1.TemplateImportFailedReason, clusterv1.ConditionSeverityError,
+			"the templateID and sourceNode of the machines are required to import the template VM")
+		return 0, nil
+	}
+
+	if ref := template.Status.TemplateImportTaskRef; ref != nil {
+		sourceClient, _, err := r.sourceClient(ctx, template)
+		if err != nil {
+			return 0, err
+		}
+		task, err := sourceClient.GetTask(ctx, *ref)
+		if err != nil {
+			return 0, errors.Wrapf(err, "unable to get the import task of source cluster %s", source.URL)
+		}
+		if task.IsRunning {
+			return templateImportRequeueInterval, nil
+		}
+
+		template.Status.TemplateImportTaskRef = nil
+		if task.IsFailed {
+			conditions.MarkFalse(template, infrav1alpha1.TemplateImportedCondition, infrav1alpha1.TemplateImportFailedReason, clusterv1.ConditionSeverityWarning,
+				"import task %s failed: %s", *ref, task.ExitStatus)
+			return templateImportRequeueInterval, nil
+		}
+	}
+
+	vm, err := r.ProxmoxClient.GetVM(ctx, spec.SourceNode, int64(*spec.TemplateID))
+	if err == nil {
+		if vm.Lock != "" {
+			// the migration creates the VM before its disks are copied.
+			return templateImportRequeueInterval, nil
+		}
+		conditions.MarkTrue(template, infrav1alpha1.TemplateImportedCondition)
+		return 0, nil
+	}
+	if !vmservice.VMNotFound(err) {
+		return 0, errors.Wrapf(err, "unable to get template VM %d on node %s", *spec.TemplateID, spec.SourceNode)
+	}
+
+	sourceClient, targetEndpoint, err := r.sourceClient(ctx, template)
+	if err != nil {
+		conditions.MarkFalse(template, infrav1alpha1.TemplateImportedCondition, infrav1alpha1.TemplateImportFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return 0, err
+	}
+	sourceVM, err := sourceClient.GetVM(ctx, source.Node, int64(source.TemplateID))
+	if err != nil {
+		conditions.MarkFalse(template, infrav1alpha1.TemplateImportedCondition, infrav1alpha1.TemplateImportFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return 0, errors.Wrapf(err, "unable to get template VM %d on node %s of source cluster %s", source.TemplateID, source.Node, source.URL)
+	}
+
+	task, err := sourceClient.RemoteMigrateVM(ctx, sourceVM, proxmox.RemoteMigrateOptions{
+		TargetEndpoint: targetEndpoint,
+		TargetVMID:     int64(*spec.TemplateID),
+		TargetStorage:  source.TargetStorage,
+		TargetBridge:   source.TargetBridge,
+	})
+	if err != nil {
+		conditions.MarkFalse(template, infrav1alpha1.TemplateImportedCondition, infrav1alpha1.TemplateImportFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return 0, err
+	}
+
+	ctrl.LoggerFrom(ctx).Info("importing template VM", "source", source.URL, "templateID", source.TemplateID, "task", task.UPID)
+	template.Status.TemplateImportTaskRef = ptr.To(string(task.UPID))
+	conditions.MarkFalse(template, infrav1alpha1.TemplateImportedCondition, infrav1alpha1.ImportingTemplateReason, clusterv1.ConditionSeverityInfo,
+		"importing template VM %d from %s", source.TemplateID, source.URL)
+	return templateImportRequeueInterval, nil
+}
+
+// sourceClient returns a client of the source cluster of the template VM and the endpoint of this cluster,
+// as configured by the credentials secret of the template source.
+func (r *ProxmoxMachineTemplateReconciler) sourceClient(ctx context.Context, template *infrav1alpha1.ProxmoxMachineTemplate) (proxmox.Client, string, error) {
+	source := template.Spec.TemplateSource
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: template.GetNamespace(), Name: source.CredentialsRef.Name}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return nil, "", errors.Wrapf(err, "unable to get credentials %s of the template source", source.CredentialsRef.Name)
+	}
+
+	for _, k := range []string{"token", "secret", "targetEndpoint"} {
+		if len(secret.Data[k]) == 0 {
+			return nil, "", errors.Errorf("credentials %s of the template source are missing the key %s", source.CredentialsRef.Name, k)
+		}
+	}
+
+	owner := client.ObjectKeyFromObject(template).String()
+	sourceClient, err := r.SourceClients.Acquire(ctx, owner, source.URL, string(secret.Data["token"]), string(secret.Data["secret"]))
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "unable to connect to source cluster %s", source.URL)
+	}
+	return sourceClient, string(secret.Data["targetEndpoint"]), nil
+}
+
+// releaseSourceClient drops the reference of the template to the client of its source cluster.
+func (r *ProxmoxMachineTemplateReconciler) releaseSourceClient(owner string) {
+	if r.SourceClients != nil {
+		r.SourceClients.Release(owner)
+	}
+}
+
+// status returns the capacity and the node info of the machine. Resources which the machine does not set
+// are those of the template VM it is cloned from, as well as the architecture.
+func (r *ProxmoxMachineTemplateReconciler) status(ctx context.Context, machine *infrav1alpha1.ProxmoxMachine) (infrav1alpha1.ProxmoxMachineTemplateStatus, error) {
+	spec := machine.Spec
+	sockets, cores, memoryMiB := int64(spec.NumSockets), int64(spec.NumCores), int64(spec.MemoryMiB)
+	nodeInfo := &infrav1alpha1.NodeInfo{Architecture: "amd64", OperatingSystem: "linux"}
+	if spec.GuestOS == infrav1alpha1.GuestOSWindows {
+		nodeInfo.OperatingSystem = "windows"
+	}
+
+	if spec.TemplateID != nil && spec.SourceNode != "" {
+		vm, err := r.ProxmoxClient.GetVM(ctx, spec.SourceNode, int64(*spec.TemplateID))
+		if err != nil {
+			return infrav1alpha1.ProxmoxMachineTemplateStatus{}, errors.Wrapf(err, "unable to get template VM %d on node %s", *spec.TemplateID, spec.SourceNode)
+		}
+		if config := vm.VirtualMachineConfig; config != nil {
+			if sockets == 0 {
+				sockets = int64(config.Sockets)
+			}
+			if cores == 0 {
+				cores = int64(config.Cores)
+			}
+			if memoryMiB == 0 {
+				memoryMiB = int64(config.Memory)
+			}
+		}
+
+		arch, err := r.ProxmoxClient.GetVMArchitecture(ctx, vm)
+		if err != nil {
+			return infrav1alpha1.ProxmoxMachineTemplateStatus{}, errors.Wrapf(err, "unable to get architecture of template VM %d", *spec.TemplateID)
+		}
+		if arch == "aarch64" {
+			nodeInfo.Architecture = "arm64"
+		}
+	}
+
+	if sockets == 0 {
+		sockets = defaultSockets
+	}
+	if cores == 0 {
+		cores = defaultCores
+	}
+	if memoryMiB == 0 {
+		memoryMiB = defaultMemoryMiB
+	}
+
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewQuantity(sockets*cores, resource.DecimalSI),
+		corev1.ResourceMemory: resource.MustParse(fmt.Sprintf("%dMi", memoryMiB)),
+	}
+	if spec.Disks != nil && spec.Disks.BootVolume != nil {
+		capacity[corev1.ResourceEphemeralStorage] = resource.MustParse(fmt.Sprintf("%dGi", spec.Disks.BootVolume.SizeGB))
+	}
+	return infrav1alpha1.ProxmoxMachineTemplateStatus{Capacity: capacity, NodeInfo: nodeInfo}, nil
+}
+
+// getProxmoxCluster returns the ProxmoxCluster of the cluster the template belongs to,
+// or nil if the template does not belong to a cluster yet.
+func (r *ProxmoxMachineTemplateReconciler) getProxmoxCluster(ctx context.Context, template *infrav1alpha1.ProxmoxMachineTemplate) (*infrav1alpha1.ProxmoxCluster, error) {
+	var cluster *clusterv1.Cluster
+	var err error
+	if name, ok := template.GetLabels()[clusterv1.ClusterNameLabel]; ok {
+		cluster, err = util.GetClusterByName(ctx, r.Client, template.GetNamespace(), name)
+	} else {
+		// MachineDeployments set the cluster as owner of their infrastructure templates.
+		cluster, err = util.GetOwnerCluster(ctx, r.Client, template.ObjectMeta)
+	}
+	if err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if cluster == nil || cluster.Spec.InfrastructureRef == nil {
+		return nil, nil
+	}
+
+	proxmoxCluster := &infrav1alpha1.ProxmoxCluster{}
+	key := client.ObjectKey{Namespace: template.GetNamespace(), Name: cluster.Spec.InfrastructureRef.Name}
+	if err := r.Get(ctx, key, proxmoxCluster); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return proxmoxCluster, nil
+}
+
+// proxmoxClusterToTemplates enqueues the ProxmoxMachineTemplates in the namespace of a ProxmoxCluster.
+func (r *ProxmoxMachineTemplateReconciler) proxmoxClusterToTemplates(ctx context.Context, o client.Object) []reconcile.Request {
+	templates := &infrav1alpha1.ProxmoxMachineTemplateList{}
+	if err := r.List(ctx, templates, client.InNamespace(o.GetNamespace())); err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(templates.Items))
+	for i := range templates.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&templates.Items[i])})
+	}
+	return requests
+}
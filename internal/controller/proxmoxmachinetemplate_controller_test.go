@@ -0,0 +1,227 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	go_proxmox "github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func newMachineTemplateTestClient(t *testing.T, objects ...client.Object) client.Client {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+		Spec: clusterv1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{Kind: infrav1.ProxmoxClusterKind, Name: "test"},
+		},
+	}
+	proxmoxCluster := &infrav1.ProxmoxCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+		Spec: infrav1.ProxmoxClusterSpec{
+			MachineSizes: []infrav1.MachineSize{{Name: "large", NumSockets: 2, NumCores: 4, MemoryMiB: 16384}},
+			MachineDefaults: &infrav1.MachineDefaults{
+				BootVolume: &infrav1.DiskSize{Disk: "scsi0", SizeGB: 50},
+			},
+		},
+	}
+
+	return newTestClient(t, append(objects, cluster, proxmoxCluster)...)
+}
+
+func TestReconcileMachineTemplateCapacity(t *testing.T) {
+	template := &infrav1.ProxmoxMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-worker",
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: "test"},
+		},
+		Spec: infrav1.ProxmoxMachineTemplateSpec{Template: infrav1.ProxmoxMachineTemplateResource{
+			Spec: infrav1.ProxmoxMachineSpec{Size: "large", MemoryMiB: 32768},
+		}},
+	}
+	kubeClient := newMachineTemplateTestClient(t, template)
+	reconciler := &ProxmoxMachineTemplateReconciler{Client: kubeClient, ProxmoxClient: proxmoxtest.NewMockClient(t)}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+	require.NoError(t, err)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(template), template))
+	require.Equal(t, corev1.ResourceList{
+		corev1.ResourceCPU:              resource.MustParse("8"),
+		corev1.ResourceMemory:           resource.MustParse("32Gi"),
+		corev1.ResourceEphemeralStorage: resource.MustParse("50Gi"),
+	}, template.Status.Capacity)
+	require.Equal(t, &infrav1.NodeInfo{Architecture: "amd64", OperatingSystem: "linux"}, template.Status.NodeInfo)
+}
+
+func TestReconcileMachineTemplateCapacity_TemplateVM(t *testing.T) {
+	template := &infrav1.ProxmoxMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-worker", Namespace: metav1.NamespaceDefault},
+		Spec: infrav1.ProxmoxMachineTemplateSpec{Template: infrav1.ProxmoxMachineTemplateResource{
+			Spec: infrav1.ProxmoxMachineSpec{
+				VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1", TemplateID: ptr.To[int32](100)},
+				NumCores:                2,
+				GuestOS:                 infrav1.GuestOSWindows,
+			},
+		}},
+	}
+	kubeClient := newMachineTemplateTestClient(t, template)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	vm := &go_proxmox.VirtualMachine{
+		Node:                 "pve1",
+		VMID:                 100,
+		VirtualMachineConfig: &go_proxmox.VirtualMachineConfig{Sockets: 2, Cores: 1, Memory: 4096},
+	}
+	proxmoxClient.EXPECT().GetVM(context.Background(), "pve1", int64(100)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().GetVMArchitecture(context.Background(), vm).Return("aarch64", nil).Once()
+	reconciler := &ProxmoxMachineTemplateReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+	require.NoError(t, err)
+
+	// the template does not belong to a cluster, so the machine defaults do not apply.
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(template), template))
+	require.Equal(t, corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+	}, template.Status.Capacity)
+	require.Equal(t, &infrav1.NodeInfo{Architecture: "arm64", OperatingSystem: "windows"}, template.Status.NodeInfo)
+}
+
+func newTemplateImportTest(t *testing.T) (*infrav1.ProxmoxMachineTemplate, client.Client, *proxmoxtest.MockClient, *proxmoxtest.MockClient, *ProxmoxMachineTemplateReconciler) {
+	template := &infrav1.ProxmoxMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-worker", Namespace: metav1.NamespaceDefault},
+		Spec: infrav1.ProxmoxMachineTemplateSpec{
+			Template: infrav1.ProxmoxMachineTemplateResource{
+				Spec: infrav1.ProxmoxMachineSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1", TemplateID: ptr.To[int32](100)},
+					NumCores:                2,
+				},
+			},
+			TemplateSource: &infrav1.TemplateSource{
+				URL:            "https://source:8006",
+				Node:           "source1",
+				TemplateID:     9000,
+				CredentialsRef: corev1.LocalObjectReference{Name: "source"},
+				TargetStorage:  "local-lvm",
+				TargetBridge:   "vmbr0",
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: metav1.NamespaceDefault},
+		Data: map[string][]byte{
+			"token":          []byte("capi@pve!import"),
+			"secret":         []byte("secret"),
+			"targetEndpoint": []byte("host=target,apitoken=PVEAPIToken=capi@pve!import=secret"),
+		},
+	}
+	kubeClient := newMachineTemplateTestClient(t, template, secret)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	sourceClient := proxmoxtest.NewMockClient(t)
+	reconciler := &ProxmoxMachineTemplateReconciler{
+		Client:        kubeClient,
+		ProxmoxClient: proxmoxClient,
+		SourceClients: proxmox.NewClientPool(func(_ context.Context, url, tokenID, secret string) (proxmox.Client, error) {
+			require.Equal(t, "https://source:8006", url)
+			require.Equal(t, "capi@pve!import", tokenID)
+			require.Equal(t, "secret", secret)
+			return sourceClient, nil
+		}),
+	}
+	return template, kubeClient, proxmoxClient, sourceClient, reconciler
+}
+
+func TestReconcileMachineTemplate_ImportsTemplate(t *testing.T) {
+	template, kubeClient, proxmoxClient, sourceClient, reconciler := newTemplateImportTest(t)
+	ctx := context.Background()
+
+	sourceVM := &go_proxmox.VirtualMachine{Node: "source1", VMID: 9000}
+	proxmoxClient.EXPECT().GetVM(ctx, "pve1", int64(100)).Return(nil, errors.New("vm 100 does not exist")).Once()
+	sourceClient.EXPECT().GetVM(ctx, "source1", int64(9000)).Return(sourceVM, nil).Once()
+	sourceClient.EXPECT().RemoteMigrateVM(ctx, sourceVM, proxmox.RemoteMigrateOptions{
+		TargetEndpoint: "host=target,apitoken=PVEAPIToken=capi@pve!import=secret",
+		TargetVMID:     100,
+		TargetStorage:  "local-lvm",
+		TargetBridge:   "vmbr0",
+	}).Return(&go_proxmox.Task{UPID: "UPID:source1:import"}, nil).Once()
+
+	res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+	require.NoError(t, err)
+	require.Equal(t, templateImportRequeueInterval, res.RequeueAfter)
+
+	require.NoError(t, kubeClient.Get(ctx, client.ObjectKeyFromObject(template), template))
+	require.Equal(t, ptr.To("UPID:source1:import"), template.Status.TemplateImportTaskRef)
+	require.True(t, conditions.IsFalse(template, infrav1.TemplateImportedCondition))
+	require.Equal(t, infrav1.ImportingTemplateReason, conditions.GetReason(template, infrav1.TemplateImportedCondition))
+	require.Nil(t, template.Status.Capacity)
+	require.Equal(t, 1, reconciler.SourceClients.Len())
+}
+
+func TestReconcileMachineTemplate_WaitsForImportTask(t *testing.T) {
+	template, kubeClient, _, sourceClient, reconciler := newTemplateImportTest(t)
+	ctx := context.Background()
+
+	template.Status.TemplateImportTaskRef = ptr.To("UPID:source1:import")
+	require.NoError(t, kubeClient.Status().Update(ctx, template))
+	sourceClient.EXPECT().GetTask(ctx, "UPID:source1:import").Return(&go_proxmox.Task{IsRunning: true}, nil).Once()
+
+	res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+	require.NoError(t, err)
+	require.Equal(t, templateImportRequeueInterval, res.RequeueAfter)
+
+	require.NoError(t, kubeClient.Get(ctx, client.ObjectKeyFromObject(template), template))
+	require.Equal(t, ptr.To("UPID:source1:import"), template.Status.TemplateImportTaskRef)
+}
+
+func TestReconcileMachineTemplate_TemplateImported(t *testing.T) {
+	template, kubeClient, proxmoxClient, _, reconciler := newTemplateImportTest(t)
+	ctx := context.Background()
+
+	vm := &go_proxmox.VirtualMachine{
+		Node:                 "pve1",
+		VMID:                 100,
+		VirtualMachineConfig: &go_proxmox.VirtualMachineConfig{Sockets: 1, Cores: 1, Memory: 2048},
+	}
+	// once for the import and once for the capacity.
+	proxmoxClient.EXPECT().GetVM(ctx, "pve1", int64(100)).Return(vm, nil).Twice()
+	proxmoxClient.EXPECT().GetVMArchitecture(ctx, vm).Return("x86_64", nil).Once()
+
+	res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+	require.NoError(t, err)
+	require.Zero(t, res.RequeueAfter)
+
+	require.NoError(t, kubeClient.Get(ctx, client.ObjectKeyFromObject(template), template))
+	require.True(t, conditions.IsTrue(template, infrav1.TemplateImportedCondition))
+	require.Equal(t, resource.MustParse("2"), template.Status.Capacity[corev1.ResourceCPU])
+	require.Zero(t, reconciler.SourceClients.Len())
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"regexp"
+
+	go_proxmox "github.com/luthermonson/go-proxmox"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/vmservice"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// snapshotNamePattern is the format of snapshot names in Proxmox VE.
+var snapshotNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,39}$`)
+
+// ProxmoxVMSnapshotReconciler creates the snapshots of ProxmoxVMSnapshots, rolls their VMs back to them
+// on request and deletes them when the ProxmoxVMSnapshots are deleted.
+type ProxmoxVMSnapshotReconciler struct {
+	client.Client
+	ProxmoxClient proxmox.Client
+
+	// ControllerOptions configures the concurrency and the rate limiting of the controller.
+	ControllerOptions ControllerOptions
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProxmoxVMSnapshotReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1alpha1.ProxmoxVMSnapshot{}).
+		WithOptions(r.ControllerOptions.options()).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxvmsnapshots,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxvmsnapshots/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=proxmoxvmsnapshots/finalizers,verbs=update
+
+// Reconcile creates, rolls back to or deletes the snapshot of a ProxmoxVMSnapshot.
+func (r *ProxmoxVMSnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
+	snapshot := &infrav1alpha1.ProxmoxVMSnapshot{}
+	if err := r.Get(ctx, req.NamespacedName, snapshot); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	helper, err := patch.NewHelper(snapshot, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		// the snapshot is gone once its finalizer is removed.
+		if err := kerrors.FilterOut(helper.Patch(ctx, snapshot), apierrors.IsNotFound); err != nil && reterr == nil {
+			reterr = err
+		}
+	}()
+
+	if !snapshot.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.reconcileDelete(ctx, snapshot)
+	}
+
+	return r.reconcileNormal(ctx, snapshot)
+}
+
+func (r *ProxmoxVMSnapshotReconciler) reconcileNormal(ctx context.Context, snapshot *infrav1alpha1.ProxmoxVMSnapshot) (ctrl.Result, error) {
+	ctrlutil.AddFinalizer(snapshot, infrav1alpha1.VMSnapshotFinalizer)
+
+	name := snapshot.GetSnapshotName()
+	if !snapshotNamePattern.MatchString(name) {
+		// the name is immutable, so there is no need to retry.
+		conditions.MarkFalse(snapshot, infrav1alpha1.VMSnapshotReadyCondition, infrav1alpha1.InvalidSnapshotNameReason, clusterv1.ConditionSeverityError,
+			"%s is not a valid snapshot name, set spec.snapshotName", name)
+		return ctrl.Result{}, nil
+	}
+
+	vm, err := r.machineVM(ctx, snapshot)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if vm == nil {
+		conditions.MarkFalse(snapshot, infrav1alpha1.VMSnapshotReadyCondition, infrav1alpha1.WaitingForMachineVMReason, clusterv1.ConditionSeverityInfo,
+			"waiting for the VM of ProxmoxMachine %s", snapshot.Spec.MachineRef.Name)
+		return ctrl.Result{RequeueAfter: infrav1alpha1.DefaultReconcilerRequeue}, nil
+	}
+
+	if !snapshot.Status.Ready {
+		if err := r.createSnapshot(ctx, snapshot, vm, name); err != nil {
+			conditions.MarkFalse(snapshot, infrav1alpha1.VMSnapshotReadyCondition, infrav1alpha1.VMSnapshotFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return ctrl.Result{}, err
+		}
+	}
+
+	if int64(vm.VMID) != snapshot.Status.VirtualMachineID {
+		// the snapshot was destroyed along with the VM it was taken of.
+		conditions.MarkFalse(snapshot, infrav1alpha1.VMSnapshotReadyCondition, infrav1alpha1.VMSnapshotFailedReason, clusterv1.ConditionSeverityError,
+			"VM %d of the snapshot was replaced by VM %d", snapshot.Status.VirtualMachineID, vm.VMID)
+		snapshot.Status.Ready = false
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkTrue(snapshot, infrav1alpha1.VMSnapshotReadyCondition)
+
+	if snapshot.RollbackRequested() {
+		if err := r.rollback(ctx, vm, name); err != nil {
+			conditions.MarkFalse(snapshot, infrav1alpha1.VMRolledBackCondition, infrav1alpha1.RollbackFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return ctrl.Result{}, err
+		}
+		ctrl.LoggerFrom(ctx).Info("rolled back vm to snapshot", "vmid", vm.VMID, "snapshot", name)
+		now := metav1.Now()
+		snapshot.Status.LastRollback = snapshot.Spec.Rollback
+		snapshot.Status.RollbackTime = &now
+		conditions.MarkTrue(snapshot, infrav1alpha1.VMRolledBackCondition)
+	}
+	return ctrl.Result{}, nil
+}
+
+// createSnapshot creates the snapshot of the VM, unless it exists already,
+// e.g. because the status could not be updated after creating it.
+func (r *ProxmoxVMSnapshotReconciler) createSnapshot(ctx context.Context, snapshot *infrav1alpha1.ProxmoxVMSnapshot, vm *go_proxmox.VirtualMachine, name string) error {
+	existing, err := r.ProxmoxClient.ListSnapshots(ctx, vm)
+	if err != nil {
+		return err
+	}
+	exists := false
+	for _, s := range existing {
+		exists = exists || s.Name == name
+	}
+
+	if !exists {
+		task, err := r.ProxmoxClient.CreateSnapshot(ctx, vm, name, proxmox.SnapshotOptions{
+			Description:   snapshot.Spec.Description,
+			IncludeMemory: snapshot.Spec.IncludeMemory,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := r.ProxmoxClient.WaitForTask(ctx, string(task.UPID), proxmox.TaskWaitOptions{}); err != nil {
+			return errors.Wrapf(err, "unable to create snapshot %s of vm %d", name, vm.VMID)
+		}
+		ctrl.LoggerFrom(ctx).Info("created snapshot", "vmid", vm.VMID, "snapshot", name)
+	}
+
+	now := metav1.Now()
+	snapshot.Status.Ready = true
+	snapshot.Status.SnapshotName = name
+	snapshot.Status.VirtualMachineID = int64(vm.VMID)
+	snapshot.Status.CreationTime = &now
+	return nil
+}
+
+func (r *ProxmoxVMSnapshotReconciler) rollback(ctx context.Context, vm *go_proxmox.VirtualMachine, name string) error {
+	task, err := r.ProxmoxClient.RollbackSnapshot(ctx, vm, name)
+	if err != nil {
+		return err
+	}
+	if _, err := r.ProxmoxClient.WaitForTask(ctx, string(task.UPID), proxmox.TaskWaitOptions{}); err != nil {
+		return errors.Wrapf(err, "unable to roll back vm %d to snapshot %s", vm.VMID, name)
+	}
+	return nil
+}
+
+func (r *ProxmoxVMSnapshotReconciler) reconcileDelete(ctx context.Context, snapshot *infrav1alpha1.ProxmoxVMSnapshot) error {
+	if snapshot.Status.SnapshotName != "" {
+		vm, err := r.machineVM(ctx, snapshot)
+		if err != nil {
+			return err
+		}
+		// without the VM it was taken of, the snapshot is gone already.
+		if vm != nil && int64(vm.VMID) == snapshot.Status.VirtualMachineID {
+			task, err := r.ProxmoxClient.DeleteSnapshot(ctx, vm, snapshot.Status.SnapshotName)
+			if err != nil && !vmservice.VMNotFound(err) {
+				return err
+			}
+			if task != nil {
+				if _, err := r.ProxmoxClient.WaitForTask(ctx, string(task.UPID), proxmox.TaskWaitOptions{}); err != nil {
+					return errors.Wrapf(err, "unable to delete snapshot %s", snapshot.Status.SnapshotName)
+				}
+			}
+			ctrl.LoggerFrom(ctx).Info("deleted snapshot", "vmid", vm.VMID, "snapshot", snapshot.Status.SnapshotName)
+		}
+		snapshot.Status.Ready = false
+	}
+
+	ctrlutil.RemoveFinalizer(snapshot, infrav1alpha1.VMSnapshotFinalizer)
+	return nil
+}
+
+// machineVM returns the VM of the ProxmoxMachine of a snapshot, or nil if the machine
+// does not exist or has no VM.
+func (r *ProxmoxVMSnapshotReconciler) machineVM(ctx context.Context, snapshot *infrav1alpha1.ProxmoxVMSnapshot) (*go_proxmox.VirtualMachine, error) {
+	machine := &infrav1alpha1.ProxmoxMachine{}
+	key := client.ObjectKey{Namespace: snapshot.GetNamespace(), Name: snapshot.Spec.MachineRef.Name}
+	if err := r.Get(ctx, key, machine); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	vmID := machine.GetVirtualMachineID()
+	if vmID <= 0 || machine.Status.ProxmoxNode == nil {
+		return nil, nil
+	}
+	// the VM is looked up by its ID, as it may have been migrated since the status was updated.
+	vm, err := r.ProxmoxClient.GetVMByID(ctx, vmID)
+	if err != nil {
+		if vmservice.VMNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "unable to get vm %d of ProxmoxMachine %s", vmID, machine.GetName())
+	}
+	return vm, nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	go_proxmox "github.com/luthermonson/go-proxmox"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func newTestVMSnapshot() (*infrav1.ProxmoxVMSnapshot, *infrav1.ProxmoxMachine) {
+	snapshot := &infrav1.ProxmoxVMSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "pre-upgrade", Namespace: metav1.NamespaceDefault},
+		Spec: infrav1.ProxmoxVMSnapshotSpec{
+			MachineRef:  corev1.LocalObjectReference{Name: "test"},
+			Description: "before upgrading",
+		},
+	}
+	machine := &infrav1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+		Spec:       infrav1.ProxmoxMachineSpec{VirtualMachineID: ptr.To[int64](100)},
+		Status:     infrav1.ProxmoxMachineStatus{ProxmoxNode: ptr.To("pve1")},
+	}
+	return snapshot, machine
+}
+
+func TestReconcileProxmoxVMSnapshot_Creates(t *testing.T) {
+	snapshot, machine := newTestVMSnapshot()
+	kubeClient := newTestClient(t, snapshot, machine)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	vm := &go_proxmox.VirtualMachine{VMID: 100, Node: "pve1"}
+	task := &go_proxmox.Task{UPID: "UPID:pve1:00000001:00000001:00000001:qmsnapshot:100:root@pam:"}
+	proxmoxClient.EXPECT().GetVMByID(context.Background(), int64(100)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().ListSnapshots(context.Background(), vm).Return([]*go_proxmox.Snapshot{{Name: "current"}}, nil).Once()
+	proxmoxClient.EXPECT().CreateSnapshot(context.Background(), vm, "pre-upgrade", proxmox.SnapshotOptions{Description: "before upgrading"}).Return(task, nil).Once()
+	proxmoxClient.EXPECT().WaitForTask(context.Background(), string(task.UPID), proxmox.TaskWaitOptions{}).Return(task, nil).Once()
+	reconciler := &ProxmoxVMSnapshotReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+	require.NoError(t, err)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(snapshot), snapshot))
+	require.Contains(t, snapshot.Finalizers, infrav1.VMSnapshotFinalizer)
+	require.True(t, snapshot.Status.Ready)
+	require.Equal(t, "pre-upgrade", snapshot.Status.SnapshotName)
+	require.Equal(t, int64(100), snapshot.Status.VirtualMachineID)
+	require.NotNil(t, snapshot.Status.CreationTime)
+	require.True(t, conditions.IsTrue(snapshot, infrav1.VMSnapshotReadyCondition))
+	require.False(t, conditions.Has(snapshot, infrav1.VMRolledBackCondition))
+}
+
+func TestReconcileProxmoxVMSnapshot_WaitsForMachineVM(t *testing.T) {
+	snapshot, machine := newTestVMSnapshot()
+	machine.Spec.VirtualMachineID = nil
+	kubeClient := newTestClient(t, snapshot, machine)
+	reconciler := &ProxmoxVMSnapshotReconciler{Client: kubeClient, ProxmoxClient: proxmoxtest.NewMockClient(t)}
+
+	res, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+	require.NoError(t, err)
+	require.Equal(t, infrav1.DefaultReconcilerRequeue, res.RequeueAfter)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(snapshot), snapshot))
+	require.False(t, snapshot.Status.Ready)
+	require.Equal(t, infrav1.WaitingForMachineVMReason, conditions.GetReason(snapshot, infrav1.VMSnapshotReadyCondition))
+}
+
+func TestReconcileProxmoxVMSnapshot_InvalidName(t *testing.T) {
+	snapshot, machine := newTestVMSnapshot()
+	snapshot.Name = "1.28"
+	kubeClient := newTestClient(t, snapshot, machine)
+	reconciler := &ProxmoxVMSnapshotReconciler{Client: kubeClient, ProxmoxClient: proxmoxtest.NewMockClient(t)}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+	require.NoError(t, err)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(snapshot), snapshot))
+	require.Equal(t, infrav1.InvalidSnapshotNameReason, conditions.GetReason(snapshot, infrav1.VMSnapshotReadyCondition))
+}
+
+func TestReconcileProxmoxVMSnapshot_Rollback(t *testing.T) {
+	snapshot, machine := newTestVMSnapshot()
+	snapshot.Finalizers = []string{infrav1.VMSnapshotFinalizer}
+	snapshot.Spec.Rollback = "1"
+	snapshot.Status = infrav1.ProxmoxVMSnapshotStatus{Ready: true, SnapshotName: "pre-upgrade", VirtualMachineID: 100}
+	kubeClient := newTestClient(t, snapshot, machine)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	vm := &go_proxmox.VirtualMachine{VMID: 100, Node: "pve1"}
+	task := &go_proxmox.Task{UPID: "UPID:pve1:00000001:00000001:00000001:qmrollback:100:root@pam:"}
+	proxmoxClient.EXPECT().GetVMByID(context.Background(), int64(100)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().RollbackSnapshot(context.Background(), vm, "pre-upgrade").Return(task, nil).Once()
+	proxmoxClient.EXPECT().WaitForTask(context.Background(), string(task.UPID), proxmox.TaskWaitOptions{}).Return(task, nil).Once()
+	reconciler := &ProxmoxVMSnapshotReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+	require.NoError(t, err)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(snapshot), snapshot))
+	require.Equal(t, "1", snapshot.Status.LastRollback)
+	require.NotNil(t, snapshot.Status.RollbackTime)
+	require.True(t, conditions.IsTrue(snapshot, infrav1.VMRolledBackCondition))
+	require.False(t, snapshot.RollbackRequested())
+}
+
+func TestReconcileProxmoxVMSnapshot_VMReplaced(t *testing.T) {
+	snapshot, machine := newTestVMSnapshot()
+	snapshot.Finalizers = []string{infrav1.VMSnapshotFinalizer}
+	snapshot.Spec.Rollback = "1"
+	snapshot.Status = infrav1.ProxmoxVMSnapshotStatus{Ready: true, SnapshotName: "pre-upgrade", VirtualMachineID: 101}
+	kubeClient := newTestClient(t, snapshot, machine)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	proxmoxClient.EXPECT().GetVMByID(context.Background(), int64(100)).Return(&go_proxmox.VirtualMachine{VMID: 100, Node: "pve1"}, nil).Once()
+	reconciler := &ProxmoxVMSnapshotReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	// the new VM is neither snapshotted nor rolled back.
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+	require.NoError(t, err)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(snapshot), snapshot))
+	require.False(t, snapshot.Status.Ready)
+	require.Equal(t, infrav1.VMSnapshotFailedReason, conditions.GetReason(snapshot, infrav1.VMSnapshotReadyCondition))
+}
+
+func TestReconcileProxmoxVMSnapshot_Delete(t *testing.T) {
+	snapshot, machine := newTestVMSnapshot()
+	snapshot.Finalizers = []string{infrav1.VMSnapshotFinalizer}
+	snapshot.Status = infrav1.ProxmoxVMSnapshotStatus{Ready: true, SnapshotName: "pre-upgrade", VirtualMachineID: 100}
+	kubeClient := newTestClient(t, snapshot, machine)
+	require.NoError(t, kubeClient.Delete(context.Background(), snapshot))
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	vm := &go_proxmox.VirtualMachine{VMID: 100, Node: "pve1"}
+	task := &go_proxmox.Task{UPID: "UPID:pve1:00000001:00000001:00000001:qmdelsnapshot:100:root@pam:"}
+	proxmoxClient.EXPECT().GetVMByID(context.Background(), int64(100)).Return(vm, nil).Once()
+	proxmoxClient.EXPECT().DeleteSnapshot(context.Background(), vm, "pre-upgrade").Return(task, nil).Once()
+	proxmoxClient.EXPECT().WaitForTask(context.Background(), string(task.UPID), proxmox.TaskWaitOptions{}).Return(task, nil).Once()
+	reconciler := &ProxmoxVMSnapshotReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+	require.NoError(t, err)
+
+	err = kubeClient.Get(context.Background(), client.ObjectKeyFromObject(snapshot), snapshot)
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func TestReconcileProxmoxVMSnapshot_DeleteListingFails(t *testing.T) {
+	snapshot, machine := newTestVMSnapshot()
+	snapshot.Finalizers = []string{infrav1.VMSnapshotFinalizer}
+	snapshot.Status = infrav1.ProxmoxVMSnapshotStatus{Ready: true, SnapshotName: "pre-upgrade", VirtualMachineID: 100}
+	kubeClient := newTestClient(t, snapshot, machine)
+	require.NoError(t, kubeClient.Delete(context.Background(), snapshot))
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	listingErr := errors.New("cannot list the resources of the cluster to find vm 100: 500 Internal Server Error")
+	proxmoxClient.EXPECT().GetVMByID(context.Background(), int64(100)).Return(nil, listingErr).Once()
+	reconciler := &ProxmoxVMSnapshotReconciler{Client: kubeClient, ProxmoxClient: proxmoxClient}
+
+	// the snapshot is kept until the VM can be looked up again.
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+	require.ErrorIs(t, err, listingErr)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(snapshot), snapshot))
+	require.Contains(t, snapshot.Finalizers, infrav1.VMSnapshotFinalizer)
+}
+
+func TestReconcileProxmoxVMSnapshot_DeleteWithoutMachine(t *testing.T) {
+	snapshot, _ := newTestVMSnapshot()
+	snapshot.Finalizers = []string{infrav1.VMSnapshotFinalizer}
+	snapshot.Status = infrav1.ProxmoxVMSnapshotStatus{Ready: true, SnapshotName: "pre-upgrade", VirtualMachineID: 100}
+	kubeClient := newTestClient(t, snapshot)
+	require.NoError(t, kubeClient.Delete(context.Background(), snapshot))
+	reconciler := &ProxmoxVMSnapshotReconciler{Client: kubeClient, ProxmoxClient: proxmoxtest.NewMockClient(t)}
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+	require.NoError(t, err)
+
+	err = kubeClient.Get(context.Background(), client.ObjectKeyFromObject(snapshot), snapshot)
+	require.True(t, apierrors.IsNotFound(err))
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+)
+
+const (
+	// DefaultReconcileBackoffBase is the delay after the first requeue of an object
+	// which is waiting on Proxmox or on other resources.
+	DefaultReconcileBackoffBase = time.Second
+
+	// DefaultReconcileBackoffMax caps the delay between requeues of the same object.
+	// Objects waiting on tasks or IP addresses are requeued earlier by watches.
+	DefaultReconcileBackoffMax = 2 * time.Minute
+
+	// DefaultReconcileQPS and DefaultReconcileBurst limit the overall rate of requeues
+	// the same way as controller-runtime does by default.
+	DefaultReconcileQPS   = 10
+	DefaultReconcileBurst = 100
+)
+
+// ControllerOptions configures the concurrency and the rate limiting of the requeues of a controller.
+// Fields which are not set use the defaults.
+type ControllerOptions struct {
+	// MaxConcurrentReconciles is the number of objects reconciled in parallel.
+	MaxConcurrentReconciles int
+	// BackoffBase is the delay after the first requeue of an object.
+	BackoffBase time.Duration
+	// BackoffMax caps the delay between requeues of the same object.
+	BackoffMax time.Duration
+	// QPS is the overall rate of requeues per second.
+	QPS float64
+	// Burst is the number of requeues exceeding the rate which are allowed at once.
+	Burst int
+}
+
+// options returns the options of the controller.
+func (o ControllerOptions) options() controller.Options {
+	return controller.Options{
+		MaxConcurrentReconciles: o.MaxConcurrentReconciles,
+		RateLimiter:             newRateLimiter(o),
+	}
+}
+
+// newRateLimiter returns the rate limiter for requeued objects. Each object is requeued with
+// an exponential backoff until it is reconciled successfully, while the overall rate is limited.
+func newRateLimiter(o ControllerOptions) ratelimiter.RateLimiter {
+	if o.BackoffBase <= 0 {
+		o.BackoffBase = DefaultReconcileBackoffBase
+	}
+	if o.BackoffMax <= 0 {
+		o.BackoffMax = DefaultReconcileBackoffMax
+	}
+	if o.QPS <= 0 {
+		o.QPS = DefaultReconcileQPS
+	}
+	if o.Burst <= 0 {
+		o.Burst = DefaultReconcileBurst
+	}
+
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(o.BackoffBase, o.BackoffMax),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(o.QPS), o.Burst)},
+	)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(ControllerOptions{})
+	require.Equal(t, DefaultReconcileBackoffBase, limiter.When("default"))
+	require.Equal(t, 2*DefaultReconcileBackoffBase, limiter.When("default"))
+
+	limiter = newRateLimiter(ControllerOptions{BackoffBase: 10 * time.Second, BackoffMax: 15 * time.Second})
+	require.Equal(t, 10*time.Second, limiter.When("custom"))
+	require.Equal(t, 15*time.Second, limiter.When("custom"))
+	limiter.Forget("custom")
+	require.Equal(t, 10*time.Second, limiter.When("custom"))
+}
+
+func TestControllerOptions(t *testing.T) {
+	opts := ControllerOptions{MaxConcurrentReconciles: 5}.options()
+	require.Equal(t, 5, opts.MaxConcurrentReconciles)
+	require.NotNil(t, opts.RateLimiter)
+}
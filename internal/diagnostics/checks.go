@@ -0,0 +1,187 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// CheckResult is the outcome of a check against the Proxmox API.
+type CheckResult struct {
+	Name string
+	// Detail describes the result of a successful check.
+	Detail string
+	Err    error
+}
+
+// RunChecks checks the connectivity to the Proxmox API, the eligible nodes of the cluster,
+// the templates of its machines and the state of the tasks the machines wait for.
+func RunChecks(ctx context.Context, pc proxmox.Client, r *Report) []CheckResult {
+	results := []CheckResult{checkAPI(ctx, pc)}
+	if results[0].Err != nil {
+		// the other checks would fail for the same reason.
+		return results
+	}
+
+	results = append(results, checkNodes(ctx, pc, r))
+	results = append(results, checkTemplates(ctx, pc, r)...)
+	results = append(results, checkTasks(ctx, pc, r)...)
+	return results
+}
+
+// Failed reports whether any of the checks failed.
+func Failed(results []CheckResult) bool {
+	for _, result := range results {
+		if result.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteChecks prints the results of the checks.
+func WriteChecks(out io.Writer, results []CheckResult) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tRESULT\tDETAIL")
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(w, "%s\tFAIL\t%s\n", result.Name, result.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\tOK\t%s\n", result.Name, result.Detail)
+	}
+	return w.Flush()
+}
+
+func checkAPI(ctx context.Context, pc proxmox.Client) CheckResult {
+	result := CheckResult{Name: "Proxmox API"}
+	status, err := pc.GetAPIStatus(ctx)
+	switch {
+	case err != nil:
+		result.Err = err
+	case !status.Quorate:
+		result.Err = errors.Errorf("Proxmox VE %s responds, but the Proxmox cluster has no quorum", status.Version)
+	default:
+		result.Detail = "Proxmox VE " + status.Version
+	}
+	return result
+}
+
+func checkNodes(ctx context.Context, pc proxmox.Client, r *Report) CheckResult {
+	result := CheckResult{Name: "eligible nodes"}
+	names, err := scheduler.EligibleNodes(ctx, pc, r.Cluster)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if len(names) == 0 {
+		result.Err = scheduler.ErrNoEligibleNodes
+		return result
+	}
+
+	summaries, err := pc.GetNodeSummaries(ctx)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	online := make(map[string]bool, len(summaries))
+	for _, summary := range summaries {
+		online[summary.Name] = summary.Online
+	}
+
+	var offline []string
+	for _, name := range names {
+		if !online[name] {
+			offline = append(offline, name)
+		}
+	}
+	if len(offline) > 0 {
+		result.Err = errors.Errorf("offline or unknown: %s", strings.Join(offline, ", "))
+		return result
+	}
+	result.Detail = strings.Join(names, ", ")
+	return result
+}
+
+// checkTemplates makes sure the template VMs the machines are cloned from exist.
+func checkTemplates(ctx context.Context, pc proxmox.Client, r *Report) []CheckResult {
+	ids := make(map[int32]bool)
+	for i := range r.Machines {
+		if id := r.Machines[i].GetTemplateID(); id > 0 {
+			ids[id] = true
+		}
+	}
+	sorted := make([]int32, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	results := make([]CheckResult, 0, len(sorted))
+	for _, id := range sorted {
+		result := CheckResult{Name: fmt.Sprintf("template %d", id)}
+		resource, err := pc.FindVMResource(ctx, uint64(id))
+		switch {
+		case err != nil:
+			result.Err = err
+		case resource.Template != 1 && resource.Status == "running":
+			result.Err = errors.Errorf("VM %s on node %s is running, stop it or convert it to a template", resource.Name, resource.Node)
+		case resource.Template != 1:
+			result.Detail = fmt.Sprintf("%s on node %s, a stopped VM", resource.Name, resource.Node)
+		default:
+			result.Detail = fmt.Sprintf("%s on node %s", resource.Name, resource.Node)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkTasks reports the state of the Proxmox tasks the machines wait for.
+func checkTasks(ctx context.Context, pc proxmox.Client, r *Report) []CheckResult {
+	var results []CheckResult
+	for i := range r.Machines {
+		m := &r.Machines[i]
+		if m.Status.TaskRef == nil {
+			continue
+		}
+
+		result := CheckResult{Name: "task of " + m.Name}
+		task, err := pc.GetTask(ctx, *m.Status.TaskRef)
+		switch {
+		case err != nil:
+			result.Err = err
+		case task.IsFailed:
+			result.Err = errors.Errorf("%s task failed: %s", task.Type, task.ExitStatus)
+		case task.IsRunning:
+			result.Detail = fmt.Sprintf("%s task running on node %s", task.Type, task.Node)
+		default:
+			result.Detail = fmt.Sprintf("%s task finished", task.Type)
+		}
+		results = append(results, result)
+	}
+	return results
+}
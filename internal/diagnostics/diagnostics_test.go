@@ -0,0 +1,134 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	go_proxmox "github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func newTestReport(t *testing.T) *Report {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrav1alpha1.AddToScheme(scheme))
+	require.NoError(t, ipamv1.AddToScheme(scheme))
+
+	cluster := &infrav1alpha1.ProxmoxCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+		Spec:       infrav1alpha1.ProxmoxClusterSpec{AllowedNodes: []string{"pve1", "pve2"}},
+		Status: infrav1alpha1.ProxmoxClusterStatus{
+			Ready:         true,
+			NodeLocations: &infrav1alpha1.NodeLocations{WorkerMachines: map[string]string{"test-md-0": "pve2"}},
+			Conditions: clusterv1.Conditions{
+				{Type: infrav1alpha1.ProxmoxAPIReachableCondition, Status: corev1.ConditionFalse, Reason: infrav1alpha1.ProxmoxAPIUnreachableReason, Message: "connection refused"},
+			},
+		},
+	}
+	machine := &infrav1alpha1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-md-0", Namespace: metav1.NamespaceDefault, Labels: map[string]string{clusterv1.ClusterNameLabel: "test"}},
+		Spec: infrav1alpha1.ProxmoxMachineSpec{
+			VirtualMachineID:        ptr.To[int64](100),
+			VirtualMachineCloneSpec: infrav1alpha1.VirtualMachineCloneSpec{SourceNode: "pve1", TemplateID: ptr.To[int32](9000)},
+		},
+		Status: infrav1alpha1.ProxmoxMachineStatus{ProxmoxNode: ptr.To("pve2"), TaskRef: ptr.To("UPID:pve2:clone")},
+	}
+	other := &infrav1alpha1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: metav1.NamespaceDefault, Labels: map[string]string{clusterv1.ClusterNameLabel: "other"}},
+	}
+	claim := &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-md-0-net0-inet", Namespace: metav1.NamespaceDefault, Annotations: map[string]string{clusterv1.ClusterNameAnnotation: "test"}},
+		Spec:       ipamv1.IPAddressClaimSpec{PoolRef: corev1.TypedLocalObjectReference{Kind: "InClusterIPPool", Name: "test-v4-icip"}},
+	}
+	address := &ipamv1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-md-0-net0-inet", Namespace: metav1.NamespaceDefault},
+		Spec:       ipamv1.IPAddressSpec{ClaimRef: corev1.LocalObjectReference{Name: "test-md-0-net0-inet"}, Address: "10.0.0.10"},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, machine, other, claim, address).Build()
+	report, err := Collect(context.Background(), c, client.ObjectKeyFromObject(cluster))
+	require.NoError(t, err)
+	return report
+}
+
+func TestCollect(t *testing.T) {
+	report := newTestReport(t)
+
+	require.Len(t, report.Machines, 1)
+	require.Equal(t, "test-md-0", report.Machines[0].Name)
+	require.Len(t, report.Claims, 1)
+	require.Equal(t, "10.0.0.10", report.Addresses["test-md-0-net0-inet"])
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	require.Contains(t, out.String(), "ProxmoxAPIUnreachable")
+	require.Contains(t, out.String(), "UPID:pve2:clone")
+	require.Regexp(t, `pve2\s+worker\s+test-md-0`, out.String())
+	require.Regexp(t, `test-md-0-net0-inet\s+InClusterIPPool/test-v4-icip\s+10.0.0.10`, out.String())
+}
+
+func TestRunChecks(t *testing.T) {
+	report := newTestReport(t)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+	ctx := context.Background()
+
+	proxmoxClient.EXPECT().GetAPIStatus(ctx).Return(proxmox.APIStatus{Version: "8.1.3", Clustered: true, Quorate: true}, nil).Once()
+	proxmoxClient.EXPECT().GetNodeSummaries(ctx).Return([]proxmox.NodeSummary{{Name: "pve1", Online: true}, {Name: "pve2"}}, nil).Once()
+	proxmoxClient.EXPECT().FindVMResource(ctx, uint64(9000)).Return(&go_proxmox.ClusterResource{Name: "ubuntu", Node: "pve1", Template: 1}, nil).Once()
+	proxmoxClient.EXPECT().GetTask(ctx, "UPID:pve2:clone").Return(&go_proxmox.Task{Type: "qmclone", Node: "pve2", IsRunning: true}, nil).Once()
+
+	results := RunChecks(ctx, proxmoxClient, report)
+	require.Equal(t, []CheckResult{
+		{Name: "Proxmox API", Detail: "Proxmox VE 8.1.3"},
+		{Name: "eligible nodes", Err: results[1].Err},
+		{Name: "template 9000", Detail: "ubuntu on node pve1"},
+		{Name: "task of test-md-0", Detail: "qmclone task running on node pve2"},
+	}, results)
+	require.EqualError(t, results[1].Err, "offline or unknown: pve2")
+	require.True(t, Failed(results))
+
+	var out bytes.Buffer
+	require.NoError(t, WriteChecks(&out, results))
+	require.Regexp(t, `eligible nodes\s+FAIL\s+offline or unknown: pve2`, out.String())
+}
+
+func TestRunChecks_Unreachable(t *testing.T) {
+	report := newTestReport(t)
+	proxmoxClient := proxmoxtest.NewMockClient(t)
+
+	proxmoxClient.EXPECT().GetAPIStatus(context.Background()).Return(proxmox.APIStatus{}, errors.New("connection refused")).Once()
+
+	// the other checks are skipped.
+	results := RunChecks(context.Background(), proxmoxClient, report)
+	require.Len(t, results, 1)
+	require.True(t, Failed(results))
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diagnostics summarizes the state of a ProxmoxCluster and checks its Proxmox VE endpoint
+// for the kubectl capmox plugin.
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+// Report is the state of a ProxmoxCluster and the objects belonging to it.
+type Report struct {
+	Cluster  *infrav1alpha1.ProxmoxCluster
+	Machines []infrav1alpha1.ProxmoxMachine
+	Claims   []ipamv1.IPAddressClaim
+	// Addresses are the IP addresses allocated for the claims, by the name of the claim.
+	Addresses map[string]string
+}
+
+// Collect reads the ProxmoxCluster with the given key, its ProxmoxMachines and its IPAddressClaims.
+func Collect(ctx context.Context, c client.Client, key client.ObjectKey) (*Report, error) {
+	cluster := &infrav1alpha1.ProxmoxCluster{}
+	if err := c.Get(ctx, key, cluster); err != nil {
+		return nil, errors.Wrapf(err, "unable to get ProxmoxCluster %s", key)
+	}
+	report := &Report{Cluster: cluster, Addresses: make(map[string]string)}
+
+	// the ProxmoxMachines are labeled with the name of the Cluster, which has the name of the ProxmoxCluster.
+	var machines infrav1alpha1.ProxmoxMachineList
+	if err := c.List(ctx, &machines, client.InNamespace(key.Namespace), client.MatchingLabels{clusterv1.ClusterNameLabel: key.Name}); err != nil {
+		return nil, errors.Wrap(err, "unable to list ProxmoxMachines")
+	}
+	report.Machines = machines.Items
+	sort.Slice(report.Machines, func(i, j int) bool { return report.Machines[i].Name < report.Machines[j].Name })
+
+	var claims ipamv1.IPAddressClaimList
+	if err := c.List(ctx, &claims, client.InNamespace(key.Namespace)); err != nil {
+		return nil, errors.Wrap(err, "unable to list IPAddressClaims")
+	}
+	for _, claim := range claims.Items {
+		if claim.GetAnnotations()[clusterv1.ClusterNameAnnotation] == key.Name {
+			report.Claims = append(report.Claims, claim)
+		}
+	}
+	sort.Slice(report.Claims, func(i, j int) bool { return report.Claims[i].Name < report.Claims[j].Name })
+
+	var addresses ipamv1.IPAddressList
+	if err := c.List(ctx, &addresses, client.InNamespace(key.Namespace)); err != nil {
+		return nil, errors.Wrap(err, "unable to list IPAddresses")
+	}
+	for _, address := range addresses.Items {
+		report.Addresses[address.Spec.ClaimRef.Name] = address.Spec.Address
+	}
+
+	return report, nil
+}
+
+// Write prints the conditions of the cluster and its machines, the node locations, the IP address claims
+// and the Proxmox tasks the machines wait for.
+func (r *Report) Write(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "ProxmoxCluster %s/%s (ready: %t)\n", r.Cluster.Namespace, r.Cluster.Name, r.Cluster.Status.Ready)
+	writeConditions(w, r.Cluster.Status.Conditions)
+
+	fmt.Fprintln(w, "\nMACHINE\tNODE\tVMID\tREADY\tTASK\tCONDITIONS")
+	for i := range r.Machines {
+		m := &r.Machines[i]
+		node, vmid, task := "-", "-", "-"
+		if m.Status.ProxmoxNode != nil {
+			node = *m.Status.ProxmoxNode
+		}
+		if id := m.GetVirtualMachineID(); id > 0 {
+			vmid = fmt.Sprint(id)
+		}
+		if m.Status.TaskRef != nil {
+			task = *m.Status.TaskRef
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\t%s\n", m.Name, node, vmid, m.Status.Ready, task, summarizeConditions(m.Status.Conditions))
+	}
+
+	fmt.Fprintln(w, "\nNODE LOCATION\tROLE\tMACHINE")
+	if locations := r.Cluster.Status.NodeLocations; locations != nil {
+		writeLocations(w, "control plane", locations.ControlPlaneMachines)
+		writeLocations(w, "worker", locations.WorkerMachines)
+	}
+
+	fmt.Fprintln(w, "\nIP ADDRESS CLAIM\tPOOL\tADDRESS")
+	for _, claim := range r.Claims {
+		address := r.Addresses[claim.Name]
+		if address == "" {
+			address = "<pending>"
+		}
+		fmt.Fprintf(w, "%s\t%s/%s\t%s\n", claim.Name, claim.Spec.PoolRef.Kind, claim.Spec.PoolRef.Name, address)
+	}
+
+	return w.Flush()
+}
+
+// writeConditions prints one line per condition with its reason and message.
+func writeConditions(w io.Writer, conds clusterv1.Conditions) {
+	fmt.Fprintln(w, "CONDITION\tSTATUS\tREASON\tMESSAGE")
+	for _, c := range conds {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Type, c.Status, c.Reason, c.Message)
+	}
+}
+
+// summarizeConditions returns the conditions which are not true, or "OK" if there are none.
+func summarizeConditions(conds clusterv1.Conditions) string {
+	var failing []string
+	for _, c := range conds {
+		if c.Status != corev1.ConditionTrue {
+			failing = append(failing, fmt.Sprintf("%s=%s", c.Type, c.Reason))
+		}
+	}
+	if len(failing) == 0 {
+		return "OK"
+	}
+	return strings.Join(failing, ", ")
+}
+
+func writeLocations(w io.Writer, role string, locations map[string]string) {
+	machines := make([]string, 0, len(locations))
+	for machine := range locations {
+		machines = append(machines, machine)
+	}
+	sort.Strings(machines)
+	for _, machine := range machines {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", locations[machine], role, machine)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package extension implements a Cluster API Runtime Extension, which patches the ProxmoxMachineTemplates
+// of clusters with a ClusterClass during the topology reconciliation, so one ClusterClass places the
+// machines of its topologies in different failure domains of the Proxmox VE cluster.
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	runtimecatalog "sigs.k8s.io/cluster-api/exp/runtime/catalog"
+	runtimehooksv1 "sigs.k8s.io/cluster-api/exp/runtime/hooks/api/v1alpha1"
+	"sigs.k8s.io/cluster-api/exp/runtime/server"
+	"sigs.k8s.io/cluster-api/exp/runtime/topologymutation"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+const (
+	// FailureDomainsVariable is the ClusterClass variable which maps the names of failure domains to a FailureDomain.
+	FailureDomainsVariable = "proxmoxFailureDomains"
+
+	// FailureDomainVariable is the ClusterClass variable selecting the failure domain of the machines.
+	// It is usually overridden per MachineDeployment topology.
+	FailureDomainVariable = "proxmoxFailureDomain"
+
+	// GeneratePatchesHandlerName is the name of the GeneratePatches handler, which the external patch
+	// of a ClusterClass references as `generate-patches.<name of the ExtensionConfig>`.
+	GeneratePatchesHandlerName = "generate-patches"
+)
+
+// FailureDomain is where the machines of a failure domain are cloned from and to.
+// Unset fields keep the value of the ProxmoxMachineTemplate.
+type FailureDomain struct {
+	// SourceNode is the node of the template VM.
+	SourceNode string `json:"sourceNode,omitempty"`
+
+	// TemplateID is the vmid of the template VM.
+	TemplateID *int32 `json:"templateID,omitempty"`
+
+	// Storage for full clones.
+	Storage *string `json:"storage,omitempty"`
+
+	// Target node of the clones.
+	Target *string `json:"target,omitempty"`
+}
+
+// Handlers implements the topology mutation hooks of the extension.
+type Handlers struct {
+	decoder runtime.Decoder
+}
+
+// NewHandlers returns the handlers, which decode the templates with the scheme.
+func NewHandlers(scheme *runtime.Scheme) *Handlers {
+	return &Handlers{
+		decoder: serializer.NewCodecFactory(scheme).UniversalDecoder(infrav1alpha1.GroupVersion),
+	}
+}
+
+// NewServer returns the server of the extension, serving the handlers at the port with the certificate
+// of the webhooks of the manager. The server implements manager.Runnable.
+func NewServer(scheme *runtime.Scheme, port int) (*Server, error) {
+	catalog := runtimecatalog.New()
+	if err := runtimehooksv1.AddToCatalog(catalog); err != nil {
+		return nil, err
+	}
+
+	s, err := server.New(server.Options{
+		Catalog: catalog,
+		Port:    port,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	handlers := NewHandlers(scheme)
+	if err := s.AddExtensionHandler(server.ExtensionHandler{
+		Hook:        runtimehooksv1.GeneratePatches,
+		Name:        GeneratePatchesHandlerName,
+		HandlerFunc: handlers.GeneratePatches,
+	}); err != nil {
+		return nil, err
+	}
+	return &Server{Server: s}, nil
+}
+
+// Server serves the extension on every replica of the manager, as the Service of the
+// extension does not select the leader.
+type Server struct {
+	*server.Server
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// GeneratePatches patches the ProxmoxMachineTemplates of a topology with the FailureDomain
+// selected by its variables.
+func (h *Handlers) GeneratePatches(ctx context.Context, req *runtimehooksv1.GeneratePatchesRequest, resp *runtimehooksv1.GeneratePatchesResponse) {
+	topologymutation.WalkTemplates(ctx, h.decoder, req, resp, patchTemplate)
+}
+
+// patchTemplate applies the selected failure domain to a ProxmoxMachineTemplate, other templates are kept.
+func patchTemplate(_ context.Context, obj runtime.Object, variables map[string]apiextensionsv1.JSON, _ runtimehooksv1.HolderReference) error {
+	template, ok := obj.(*infrav1alpha1.ProxmoxMachineTemplate)
+	if !ok {
+		return nil
+	}
+
+	name, found, err := topologymutation.GetStringVariable(variables, FailureDomainVariable)
+	if err != nil || !found || name == "" {
+		return err
+	}
+
+	domains := map[string]FailureDomain{}
+	if value, found, err := topologymutation.GetVariable(variables, FailureDomainsVariable); err != nil {
+		return err
+	} else if found {
+		if err := json.Unmarshal(value.Raw, &domains); err != nil {
+			return fmt.Errorf("cannot read variable %s: %w", FailureDomainsVariable, err)
+		}
+	}
+	domain, ok := domains[name]
+	if !ok {
+		return fmt.Errorf("failure domain %q is not defined in variable %s", name, FailureDomainsVariable)
+	}
+
+	spec := &template.Spec.Template.Spec
+	if domain.SourceNode != "" {
+		spec.SourceNode = domain.SourceNode
+	}
+	if domain.TemplateID != nil {
+		spec.TemplateID = domain.TemplateID
+	}
+	if domain.Storage != nil {
+		spec.Storage = domain.Storage
+	}
+	if domain.Target != nil {
+		spec.Target = domain.Target
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimehooksv1 "sigs.k8s.io/cluster-api/exp/runtime/hooks/api/v1alpha1"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+const template = `{"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha1", "kind": "ProxmoxMachineTemplate",
+	"spec": {"template": {"spec": {"sourceNode": "pve1", "templateID": 100}}}}`
+
+func generatePatches(t *testing.T, variables map[string]string, object string) *runtimehooksv1.GeneratePatchesResponse {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrav1alpha1.AddToScheme(scheme))
+
+	item := runtimehooksv1.GeneratePatchesRequestItem{
+		UID:    "1",
+		Object: runtime.RawExtension{Raw: []byte(object)},
+		HolderReference: runtimehooksv1.HolderReference{
+			APIVersion: "cluster.x-k8s.io/v1beta1",
+			Kind:       "MachineDeployment",
+			Namespace:  "default",
+			Name:       "md-0",
+			FieldPath:  "spec.template.spec.infrastructureRef",
+		},
+	}
+	for name, value := range variables {
+		item.Variables = append(item.Variables, runtimehooksv1.Variable{Name: name, Value: apiextensionsv1.JSON{Raw: []byte(value)}})
+	}
+
+	resp := &runtimehooksv1.GeneratePatchesResponse{}
+	NewHandlers(scheme).GeneratePatches(context.Background(), &runtimehooksv1.GeneratePatchesRequest{Items: []runtimehooksv1.GeneratePatchesRequestItem{item}}, resp)
+	return resp
+}
+
+func TestGeneratePatches(t *testing.T) {
+	resp := generatePatches(t, map[string]string{
+		FailureDomainsVariable: `{"zone-a": {"sourceNode": "pve1"}, "zone-b": {"sourceNode": "pve2", "templateID": 200, "storage": "ceph-b"}}`,
+		FailureDomainVariable:  `"zone-b"`,
+	}, template)
+
+	require.Equal(t, runtimehooksv1.ResponseStatusSuccess, resp.Status, resp.Message)
+	require.Len(t, resp.Items, 1)
+	require.Equal(t, runtimehooksv1.JSONPatchType, resp.Items[0].PatchType)
+	var patch []map[string]any
+	require.NoError(t, json.Unmarshal(resp.Items[0].Patch, &patch))
+	require.ElementsMatch(t, []map[string]any{
+		{"op": "replace", "path": "/spec/template/spec/sourceNode", "value": "pve2"},
+		{"op": "add", "path": "/spec/template/spec/storage", "value": "ceph-b"},
+		{"op": "replace", "path": "/spec/template/spec/templateID", "value": float64(200)},
+	}, patch)
+}
+
+func TestGeneratePatches_WithoutFailureDomain(t *testing.T) {
+	resp := generatePatches(t, map[string]string{
+		FailureDomainsVariable: `{"zone-a": {"sourceNode": "pve2"}}`,
+	}, template)
+
+	require.Equal(t, runtimehooksv1.ResponseStatusSuccess, resp.Status, resp.Message)
+	require.Len(t, resp.Items, 1)
+	require.JSONEq(t, `[]`, string(resp.Items[0].Patch))
+}
+
+func TestGeneratePatches_UnknownFailureDomain(t *testing.T) {
+	resp := generatePatches(t, map[string]string{
+		FailureDomainsVariable: `{"zone-a": {"sourceNode": "pve2"}}`,
+		FailureDomainVariable:  `"zone-c"`,
+	}, template)
+
+	require.Equal(t, runtimehooksv1.ResponseStatusFailure, resp.Status)
+	require.Equal(t, `failure domain "zone-c" is not defined in variable proxmoxFailureDomains`, resp.Message)
+}
+
+func TestGeneratePatches_OtherTemplates(t *testing.T) {
+	resp := generatePatches(t, map[string]string{
+		FailureDomainVariable: `"zone-c"`,
+	}, `{"apiVersion": "bootstrap.cluster.x-k8s.io/v1beta1", "kind": "KubeadmConfigTemplate", "spec": {}}`)
+
+	require.Equal(t, runtimehooksv1.ResponseStatusSuccess, resp.Status, resp.Message)
+	require.Empty(t, resp.Items)
+}
+
+func TestNewServer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrav1alpha1.AddToScheme(scheme))
+
+	s, err := NewServer(scheme, 9444)
+	require.NoError(t, err)
+	require.False(t, s.NeedLeaderElection())
+}
@@ -19,20 +19,48 @@ package inject
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/go-logr/logr"
 	"github.com/luthermonson/go-proxmox"
 	"github.com/pkg/errors"
 
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 )
 
 // CloudInitISODevice default device used to inject cdrom iso.
 const CloudInitISODevice = "ide0"
 
+// WindowsCloudInitISODevice is the device the cdrom iso of Windows VMs is attached to.
+// The last SATA device is used, as the disks of Windows templates are often attached to the first ones.
+const WindowsCloudInitISODevice = "sata5"
+
+// checksumPrefix starts the line of the VirtualMachine description which holds the checksum of the injected data.
+const checksumPrefix = "cloud-init-checksum:"
+
 // ISOInjector used to Inject cloudinit userdata, metadata and network-config into a Proxmox VirtualMachine.
 type ISOInjector struct {
 	VirtualMachine *proxmox.VirtualMachine
 
+	// Client uploads the ISO through the Proxmox API, so no access to the node itself is needed.
+	Client capmox.Client
+	// Transport writes the ISO to the storage on the node instead, if it is set.
+	Transport capmox.NodeTransport
+	// Storage is the storage the ISO is uploaded to. By default, a shared storage for ISO images
+	// is preferred over one which is local to the node of the VirtualMachine.
+	Storage string
+	// Format is the layout of the ISO. The renderers must produce the metadata and network
+	// configuration of this format. Defaults to NoCloud.
+	Format infrav1alpha1.CloudInitFormat
+	// Device is the IDE or SATA device the ISO is attached to. Defaults to CloudInitISODevice.
+	Device string
+
 	BootstrapData []byte
 
 	MetaRenderer    cloudinit.Renderer
@@ -40,6 +68,8 @@ type ISOInjector struct {
 }
 
 // Inject injects cloudinit userdata, metadata and network-config into a Proxmox VirtualMachine.
+// The checksum of the data is kept in the description of the VirtualMachine, so the ISO
+// is not generated and uploaded again if the same data is attached already.
 func (i *ISOInjector) Inject(ctx context.Context) error {
 	// Render metadata.
 	metadata, err := i.MetaRenderer.Render()
@@ -53,11 +83,237 @@ func (i *ISOInjector) Inject(ctx context.Context) error {
 		return errors.Wrap(err, "unable to render network-config")
 	}
 
-	// Inject an ISO with userdata, metadata and network-config into the VirtualMachine.
-	err = i.VirtualMachine.CloudInit(ctx, CloudInitISODevice, string(i.BootstrapData), string(metadata), "", string(network))
+	volumeIdentifier, files, err := i.files(metadata, network)
+	if err != nil {
+		return err
+	}
+
+	isoName := fmt.Sprintf(proxmox.UserDataISOFormat, i.VirtualMachine.VMID)
+	sum := checksum(volumeIdentifier, files)
+	if i.isAttached(isoName) && descriptionChecksum(i.VirtualMachine.VirtualMachineConfig.Description) == sum {
+		logr.FromContextOrDiscard(ctx).V(4).Info("cloud-init data is injected already", "checksum", sum)
+		return nil
+	}
+
+	if err := i.checkDevice(isoName); err != nil {
+		return err
+	}
+
+	iso, err := makeISO(volumeIdentifier, files)
+	if err != nil {
+		return err
+	}
+
+	storage, err := i.isoStorage(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Upload the ISO with userdata, metadata and network-config and attach it to the VirtualMachine.
+	if err := i.upload(ctx, storage, isoName, iso); err != nil {
+		return errors.Wrap(err, "unable to upload CloudInit ISO")
+	}
+
+	if tag := proxmox.MakeTag(proxmox.TagCloudInit); !i.VirtualMachine.HasTag(tag) {
+		task, err := i.Client.TagVM(ctx, i.VirtualMachine, tag)
+		if err != nil {
+			return errors.Wrap(err, "unable to tag VirtualMachine")
+		}
+		if err := i.wait(ctx, task); err != nil {
+			return errors.Wrap(err, "unable to tag VirtualMachine")
+		}
+	}
+
+	device := i.device()
+	config := i.VirtualMachine.VirtualMachineConfig
+	options := []capmox.VirtualMachineOption{{
+		Name:  device,
+		Value: fmt.Sprintf("%s:iso/%s,media=cdrom", storage, isoName),
+	}, {
+		Name:  "description",
+		Value: withChecksum(config.Description, sum),
+	}}
+	if !strings.Contains(config.Boot, device) {
+		options = append(options, capmox.VirtualMachineOption{
+			Name:  "boot",
+			Value: withBootDevice(config.Boot, device),
+		})
+	}
+
+	task, err := i.Client.ConfigureVM(ctx, i.VirtualMachine, options...)
 	if err != nil {
-		return errors.Wrap(err, "unable to inject CloudInit ISO")
+		return errors.Wrap(err, "unable to attach CloudInit ISO")
+	}
+	if err := i.wait(ctx, task); err != nil {
+		return errors.Wrap(err, "unable to attach CloudInit ISO")
 	}
 
 	return nil
 }
+
+// upload uploads the ISO to the storage of the node of the VirtualMachine.
+func (i *ISOInjector) upload(ctx context.Context, storage, isoName string, iso []byte) error {
+	if i.Transport != nil {
+		return i.Transport.WriteVolume(ctx, i.VirtualMachine.Node, fmt.Sprintf("%s:iso/%s", storage, isoName), iso)
+	}
+
+	task, err := i.Client.UploadISO(ctx, i.VirtualMachine.Node, storage, isoName, iso)
+	if err != nil {
+		return err
+	}
+	return i.wait(ctx, task)
+}
+
+// files returns the volume identifier and the files of the ISO in the format of the injector.
+func (i *ISOInjector) files(metadata, network []byte) (string, map[string][]byte, error) {
+	switch i.Format {
+	case "", infrav1alpha1.CloudInitFormatNoCloud:
+		return noCloudVolumeIdentifier, map[string][]byte{
+			"user-data":      i.BootstrapData,
+			"meta-data":      metadata,
+			"network-config": network,
+		}, nil
+	case infrav1alpha1.CloudInitFormatConfigDrive2:
+		return configDriveVolumeIdentifier, map[string][]byte{
+			"openstack/latest/user_data":         i.BootstrapData,
+			"openstack/latest/meta_data.json":    metadata,
+			"openstack/latest/network_data.json": network,
+		}, nil
+	}
+	return "", nil, errors.Errorf("unknown cloud-init format %q", i.Format)
+}
+
+// device returns the device the ISO is attached to.
+func (i *ISOInjector) device() string {
+	if i.Device == "" {
+		return CloudInitISODevice
+	}
+	return i.Device
+}
+
+// attached returns the volume attached to the cloud-init device of the VirtualMachine.
+func (i *ISOInjector) attached() string {
+	config := i.VirtualMachine.VirtualMachineConfig
+	attached := config.MergeIDEs()[i.device()]
+	if attached == "" {
+		attached = config.MergeSATAs()[i.device()]
+	}
+	volume, _, _ := strings.Cut(attached, ",")
+	return volume
+}
+
+// isAttached returns whether the ISO is attached to the cloud-init device of the VirtualMachine.
+func (i *ISOInjector) isAttached(isoName string) bool {
+	return strings.HasSuffix(i.attached(), ":iso/"+isoName)
+}
+
+// checkDevice returns an error if the cloud-init device of the VirtualMachine is in use,
+// so disks and cloud-init drives of the template are not replaced by the ISO.
+// Empty CD-ROM drives and the ISO of a previous injection are replaced.
+func (i *ISOInjector) checkDevice(isoName string) error {
+	volume := i.attached()
+	switch {
+	case volume == "", volume == "none", volume == "cdrom", i.isAttached(isoName):
+		return nil
+	}
+	return errors.Errorf("device %s of VM %d is in use by %s, set cloudInitDevice to a free IDE or SATA device",
+		i.device(), i.VirtualMachine.VMID, volume)
+}
+
+// checksum returns the SHA-256 checksum of the volume identifier and the files of an ISO.
+func checksum(volumeIdentifier string, files map[string][]byte) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", volumeIdentifier)
+	for _, name := range names {
+		// the length separates the content of consecutive files.
+		fmt.Fprintf(h, "%s %d\n", name, len(files[name]))
+		h.Write(files[name])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// descriptionChecksum returns the checksum kept in the description of a VirtualMachine.
+func descriptionChecksum(description string) string {
+	for _, line := range strings.Split(description, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), checksumPrefix); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// withChecksum returns the description with the checksum line replaced or appended.
+func withChecksum(description, sum string) string {
+	var lines []string
+	for _, line := range strings.Split(description, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), checksumPrefix) {
+			lines = append(lines, line)
+		}
+	}
+
+	line := checksumPrefix + " " + sum
+	if kept := strings.TrimRight(strings.Join(lines, "\n"), "\n"); kept != "" {
+		return kept + "\n" + line
+	}
+	return line
+}
+
+// withBootDevice returns the boot order with the device appended.
+func withBootDevice(boot, device string) string {
+	if strings.TrimSpace(boot) == "" {
+		return "order=" + device
+	}
+	return boot + ";" + device
+}
+
+// isoStorage returns the storage to upload the ISO to.
+func (i *ISOInjector) isoStorage(ctx context.Context) (string, error) {
+	node := i.VirtualMachine.Node
+
+	if i.Storage != "" {
+		storage, err := i.Client.GetStorage(ctx, node, i.Storage)
+		if err != nil {
+			return "", err
+		}
+		if err := storage.Validate(node, capmox.StorageContentISO, ""); err != nil {
+			return "", err
+		}
+		return storage.Name, nil
+	}
+
+	storages, err := i.Client.ListStorages(ctx, node)
+	if err != nil {
+		return "", err
+	}
+
+	var local string
+	for _, storage := range storages {
+		if !storage.Enabled || !storage.Active || !storage.HasContent(capmox.StorageContentISO) {
+			continue
+		}
+		if storage.Shared {
+			return storage.Name, nil
+		}
+		if local == "" {
+			local = storage.Name
+		}
+	}
+	if local == "" {
+		return "", errors.Errorf("no active storage with content iso found on node %s, add the content type to a storage or set the storage explicitly", node)
+	}
+	return local, nil
+}
+
+func (i *ISOInjector) wait(ctx context.Context, task *proxmox.Task) error {
+	if task == nil {
+		return nil
+	}
+	_, err := i.Client.WaitForTask(ctx, string(task.UPID), capmox.TaskWaitOptions{})
+	return err
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inject
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/fake"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+type staticRenderer []byte
+
+// fakeTransport records the volumes written to the nodes.
+type fakeTransport map[string][]byte
+
+func (t fakeTransport) WriteVolume(_ context.Context, nodeName, volumeID string, content []byte) error {
+	t[nodeName+"/"+volumeID] = content
+	return nil
+}
+
+func (r staticRenderer) Render() ([]byte, error) {
+	return r, nil
+}
+
+func newTestInjector(t *testing.T, sharedStorage bool) (*proxmoxtest.Simulator, *ISOInjector) {
+	t.Helper()
+	ctx := context.Background()
+	client, err := fake.New(ctx, proxmoxtest.SimulatedNode{Name: "pve1", CPUs: 4, MemoryBytes: 1 << 30})
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	sim := client.Simulator
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Config: map[string]any{"name": "test", "boot": "order=scsi0"}})
+	if sharedStorage {
+		sim.AddSharedISOStorage("nfs")
+	}
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+
+	return sim, &ISOInjector{
+		VirtualMachine:  vm,
+		Client:          client,
+		BootstrapData:   []byte("#cloud-config"),
+		MetaRenderer:    staticRenderer("instance-id: test"),
+		NetworkRenderer: staticRenderer("version: 2"),
+	}
+}
+
+func TestISOInjector_InjectConfigDrive2(t *testing.T) {
+	sim, injector := newTestInjector(t, false)
+	injector.Format = infrav1alpha1.CloudInitFormatConfigDrive2
+	injector.MetaRenderer = staticRenderer(`{"uuid":"test"}`)
+
+	require.NoError(t, injector.Inject(context.Background()))
+
+	content, ok := sim.ISO("pve1", "user-data-100.iso")
+	require.True(t, ok)
+	require.Contains(t, string(content), "config-2")
+	require.Contains(t, string(content), "meta_data.json")
+	require.Contains(t, string(content), `{"uuid":"test"}`)
+
+	injector.Format = "unknown"
+	require.ErrorContains(t, injector.Inject(context.Background()), `unknown cloud-init format "unknown"`)
+}
+
+func TestISOInjector_Inject(t *testing.T) {
+	tests := map[string]struct {
+		sharedStorage   bool
+		storage         string
+		expectedStorage string
+		expectedError   string
+	}{
+		"local storage by default": {
+			expectedStorage: proxmoxtest.SimulatorISOStorage,
+		},
+		"shared storage is preferred": {
+			sharedStorage:   true,
+			expectedStorage: "nfs",
+		},
+		"configured storage": {
+			sharedStorage:   true,
+			storage:         proxmoxtest.SimulatorISOStorage,
+			expectedStorage: proxmoxtest.SimulatorISOStorage,
+		},
+		"configured storage without iso content": {
+			storage:       proxmoxtest.SimulatorImageStorage,
+			expectedError: "storage local-lvm on node pve1 does not support content iso",
+		},
+		"missing storage": {
+			storage:       "missing",
+			expectedError: "does not exist",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sim, injector := newTestInjector(t, test.sharedStorage)
+			injector.Storage = test.storage
+
+			err := injector.Inject(context.Background())
+			if test.expectedError != "" {
+				require.ErrorContains(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			content, ok := sim.StorageISO("pve1", test.expectedStorage, "user-data-100.iso")
+			require.True(t, ok)
+			require.Contains(t, string(content), "cidata")
+
+			state, _ := sim.VM(100)
+			require.Equal(t, test.expectedStorage+":iso/user-data-100.iso,media=cdrom", state.Config["ide0"])
+			require.Equal(t, "order=scsi0;ide0", state.Config["boot"])
+			require.Contains(t, state.Config["tags"], proxmox.MakeTag(proxmox.TagCloudInit))
+		})
+	}
+}
+
+func TestISOInjector_InjectTransport(t *testing.T) {
+	sim, injector := newTestInjector(t, false)
+	transport := fakeTransport{}
+	injector.Transport = transport
+
+	require.NoError(t, injector.Inject(context.Background()))
+
+	// the ISO is written to the storage on the node instead of being uploaded.
+	_, ok := sim.ISO("pve1", "user-data-100.iso")
+	require.False(t, ok)
+	require.Contains(t, string(transport["pve1/"+proxmoxtest.SimulatorISOStorage+":iso/user-data-100.iso"]), "cidata")
+
+	state, _ := sim.VM(100)
+	require.Equal(t, proxmoxtest.SimulatorISOStorage+":iso/user-data-100.iso,media=cdrom", state.Config["ide0"])
+}
+
+func TestISOInjector_InjectUnchanged(t *testing.T) {
+	ctx := context.Background()
+	sim, injector := newTestInjector(t, false)
+	require.NoError(t, injector.Inject(ctx))
+
+	state, _ := sim.VM(100)
+	require.Contains(t, state.Config["description"], "cloud-init-checksum: sha256:")
+
+	reloaded := func() {
+		vm, err := injector.Client.GetVM(ctx, "pve1", 100)
+		require.NoError(t, err)
+		injector.VirtualMachine = vm
+	}
+
+	// no task is started if the data did not change.
+	sim.FailTasks("upload failed")
+	reloaded()
+	require.NoError(t, injector.Inject(ctx))
+
+	injector.BootstrapData = []byte("#cloud-config\nhostname: changed")
+	require.ErrorContains(t, injector.Inject(ctx), "upload failed")
+
+	sim.FailTasks("")
+	require.NoError(t, injector.Inject(ctx))
+	reloaded()
+	require.NoError(t, injector.Inject(ctx))
+
+	state, _ = sim.VM(100)
+	require.Equal(t, "order=scsi0;ide0", state.Config["boot"])
+}
+
+func TestISOInjector_InjectSATADevice(t *testing.T) {
+	ctx := context.Background()
+	sim, injector := newTestInjector(t, false)
+	injector.Device = WindowsCloudInitISODevice
+	require.NoError(t, injector.Inject(ctx))
+
+	state, _ := sim.VM(100)
+	require.Equal(t, proxmoxtest.SimulatorISOStorage+":iso/user-data-100.iso,media=cdrom", state.Config["sata5"])
+	require.Equal(t, "order=scsi0;sata5", state.Config["boot"])
+	require.NotContains(t, state.Config, "ide0")
+
+	// the ISO attached to the SATA device is detected.
+	vm, err := injector.Client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	injector.VirtualMachine = vm
+	sim.FailTasks("upload failed")
+	require.NoError(t, injector.Inject(ctx))
+}
+
+func TestISOInjector_InjectDeviceInUse(t *testing.T) {
+	ctx := context.Background()
+	sim, injector := newTestInjector(t, false)
+	injector.VirtualMachine.VirtualMachineConfig.IDE0 = "local-lvm:vm-100-cloudinit,media=cdrom"
+
+	require.EqualError(t, injector.Inject(ctx), "device ide0 of VM 100 is in use by local-lvm:vm-100-cloudinit, set cloudInitDevice to a free IDE or SATA device")
+	_, ok := sim.ISO("pve1", "user-data-100.iso")
+	require.False(t, ok)
+
+	// empty CD-ROM drives are replaced.
+	injector.VirtualMachine.VirtualMachineConfig.IDE0 = "none,media=cdrom"
+	injector.VirtualMachine.VirtualMachineConfig.IDEs = nil
+	require.NoError(t, injector.Inject(ctx))
+
+	state, _ := sim.VM(100)
+	require.Equal(t, proxmoxtest.SimulatorISOStorage+":iso/user-data-100.iso,media=cdrom", state.Config["ide0"])
+}
+
+func TestWithChecksum(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		expected    string
+	}{
+		"empty description": {
+			expected: "cloud-init-checksum: sha256:new",
+		},
+		"user description": {
+			description: "notes\n\nmore notes\n",
+			expected:    "notes\n\nmore notes\ncloud-init-checksum: sha256:new",
+		},
+		"replaces checksum": {
+			description: "notes\ncloud-init-checksum: sha256:old",
+			expected:    "notes\ncloud-init-checksum: sha256:new",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			description := withChecksum(test.description, "sha256:new")
+			require.Equal(t, test.expected, description)
+			require.Equal(t, "sha256:new", descriptionChecksum(description))
+		})
+	}
+}
+
+func TestWithBootDevice(t *testing.T) {
+	require.Equal(t, "order=ide0", withBootDevice("", CloudInitISODevice))
+	require.Equal(t, "order=scsi0;ide0", withBootDevice("order=scsi0", CloudInitISODevice))
+	require.Equal(t, "order=scsi0;net0;sata5", withBootDevice("order=scsi0;net0", WindowsCloudInitISODevice))
+}
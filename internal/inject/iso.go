@@ -0,0 +1,84 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inject
+
+import (
+	"os"
+	"path"
+	"sort"
+
+	"github.com/diskfs/go-diskfs/filesystem/iso9660"
+	"github.com/pkg/errors"
+)
+
+const (
+	// noCloudVolumeIdentifier is the volume label cloud-init looks for to find a NoCloud datasource.
+	noCloudVolumeIdentifier = "cidata"
+	// configDriveVolumeIdentifier is the volume label of an OpenStack config drive.
+	configDriveVolumeIdentifier = "config-2"
+	isoBlockSize                = 2048
+)
+
+// makeISO creates an ISO 9660 image with the given files and returns its content.
+// The names of the files are relative to the root directory of the image.
+func makeISO(volumeIdentifier string, files map[string][]byte) ([]byte, error) {
+	image, err := os.CreateTemp("", "capmox-*.iso")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create iso image")
+	}
+	defer func() {
+		_ = image.Close()
+		_ = os.Remove(image.Name())
+	}()
+
+	fs, err := iso9660.Create(image, 0, 0, isoBlockSize, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create iso filesystem")
+	}
+	if err := fs.Mkdir("/"); err != nil {
+		return nil, errors.Wrap(err, "unable to create iso filesystem")
+	}
+
+	// write the files in a stable order, so the image only depends on their content.
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if dir := path.Dir("/" + name); dir != "/" {
+			if err := fs.Mkdir(dir); err != nil {
+				return nil, errors.Wrapf(err, "unable to create %s", dir)
+			}
+		}
+
+		file, err := fs.OpenFile("/"+name, os.O_CREATE|os.O_RDWR)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to create %s", name)
+		}
+		if _, err := file.Write(files[name]); err != nil {
+			return nil, errors.Wrapf(err, "unable to write %s", name)
+		}
+	}
+
+	if err := fs.Finalize(iso9660.FinalizeOptions{RockRidge: true, VolumeIdentifier: volumeIdentifier}); err != nil {
+		return nil, errors.Wrap(err, "unable to finalize iso image")
+	}
+
+	return os.ReadFile(image.Name())
+}
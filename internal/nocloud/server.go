@@ -0,0 +1,177 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nocloud implements a metadata server for the cloud-init NoCloud datasource,
+// which machines use instead of an ISO to fetch their cloud-init data over HTTP.
+package nocloud
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TokenLabel is the label of the secret with the cloud-init data of a machine. Its value is
+	// the token in the URL under which the data is served.
+	TokenLabel = "infrastructure.cluster.x-k8s.io/nocloud-token"
+
+	// MachineAnnotation is the annotation of the secret with the cloud-init data of a machine.
+	// Its value is the namespace and name of the ProxmoxMachine.
+	MachineAnnotation = "infrastructure.cluster.x-k8s.io/nocloud-machine"
+
+	// UserDataKey, MetaDataKey and NetworkConfigKey are the keys of the cloud-init data in the secret.
+	UserDataKey      = "user-data"
+	MetaDataKey      = "meta-data"
+	NetworkConfigKey = "network-config"
+
+	secretPrefix      = "capmox-nocloud-"
+	secretHashLength  = 16
+	tokenBytes        = 16
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+var tokenPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// SecretName returns the name of the secret with the cloud-init data of a machine. The secrets of
+// all machines are kept in the namespace of the manager, so the name is derived from the namespace
+// and name of the machine.
+func SecretName(machineNamespace, machineName string) string {
+	hash := sha256.Sum256([]byte(machineNamespace + "/" + machineName))
+	return secretPrefix + hex.EncodeToString(hash[:])[:secretHashLength]
+}
+
+// NewToken returns a random token, which makes the URL of the data of a machine unguessable.
+func NewToken() (string, error) {
+	token := make([]byte, tokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// Seed returns the SMBIOS serial which points cloud-init to the data served under baseURL for the token.
+func Seed(baseURL, token string) string {
+	return "ds=nocloud-net;s=" + strings.TrimSuffix(baseURL, "/") + "/" + token + "/"
+}
+
+// Server serves the cloud-init data kept in the secrets of the machines.
+// It implements manager.Runnable, and runs on every replica of the manager.
+//
+// The data is served over plain HTTP and only protected by the token in the URL,
+// so the server must only be reachable from the network of the VMs.
+type Server struct {
+	// Reader reads the secrets. It should not be cached, so the manager does not watch all secrets.
+	Reader client.Reader
+	// Namespace is the namespace of the secrets, which is the namespace of the manager.
+	Namespace   string
+	BindAddress string
+	Logger      logr.Logger
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the data until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           s,
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		s.Logger.Info("starting NoCloud metadata server", "address", s.BindAddress)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// ServeHTTP serves GET /<token>/<key> with the key of the secret labelled with the token.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, key, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !ok || !tokenPattern.MatchString(token) {
+		http.NotFound(w, r)
+		return
+	}
+	switch key {
+	case UserDataKey, MetaDataKey, NetworkConfigKey:
+	case "vendor-data":
+		// cloud-init asks for vendor data, which is not provided.
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	var secrets corev1.SecretList
+	if err := s.Reader.List(r.Context(), &secrets, client.InNamespace(s.Namespace), client.MatchingLabels{TokenLabel: token}); err != nil {
+		s.Logger.Error(err, "unable to list NoCloud secrets")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if len(secrets.Items) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	secret := secrets.Items[0]
+	data, ok := secret.Data[key]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.Logger.V(4).Info("serving NoCloud data", "secret", client.ObjectKeyFromObject(&secret), "key", key)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(data)
+}
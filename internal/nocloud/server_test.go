@@ -0,0 +1,115 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nocloud
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testToken = "0123456789abcdef0123456789abcdef"
+
+func TestServer_ServeHTTP(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName(metav1.NamespaceDefault, "test"),
+			Namespace: "capmox-system",
+			Labels:    map[string]string{TokenLabel: testToken},
+		},
+		Data: map[string][]byte{
+			UserDataKey: []byte("#cloud-config"),
+			MetaDataKey: []byte("instance-id: test"),
+		},
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build()
+	server := httptest.NewServer(&Server{Reader: reader, Namespace: "capmox-system", Logger: logr.Discard()})
+	t.Cleanup(server.Close)
+
+	tests := map[string]struct {
+		path   string
+		status int
+		body   string
+	}{
+		"user-data":              {path: "/" + testToken + "/user-data", status: http.StatusOK, body: "#cloud-config"},
+		"meta-data":              {path: "/" + testToken + "/meta-data", status: http.StatusOK, body: "instance-id: test"},
+		"vendor-data":            {path: "/" + testToken + "/vendor-data", status: http.StatusOK},
+		"missing network-config": {path: "/" + testToken + "/network-config", status: http.StatusNotFound},
+		"unknown key":            {path: "/" + testToken + "/secret", status: http.StatusNotFound},
+		"unknown token":          {path: "/ffffffffffffffffffffffffffffffff/user-data", status: http.StatusNotFound},
+		"malformed token":        {path: "/test/user-data", status: http.StatusNotFound},
+		"root":                   {path: "/", status: http.StatusNotFound},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := http.Get(server.URL + test.path)
+			require.NoError(t, err)
+			defer func() { _ = res.Body.Close() }()
+
+			require.Equal(t, test.status, res.StatusCode)
+			if test.status == http.StatusOK {
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				require.Equal(t, test.body, string(body))
+			}
+		})
+	}
+}
+
+func TestServer_ServeHTTPOtherNamespace(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName(metav1.NamespaceDefault, "test"),
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{TokenLabel: testToken},
+		},
+		Data: map[string][]byte{UserDataKey: []byte("#cloud-config")},
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build()
+	server := httptest.NewServer(&Server{Reader: reader, Namespace: "capmox-system", Logger: logr.Discard()})
+	t.Cleanup(server.Close)
+
+	res, err := http.Get(server.URL + "/" + testToken + "/user-data")
+	require.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestSecretName(t *testing.T) {
+	name := SecretName(metav1.NamespaceDefault, "test")
+	require.Regexp(t, `^capmox-nocloud-[0-9a-f]{16}$`, name)
+	require.Equal(t, name, SecretName(metav1.NamespaceDefault, "test"))
+	require.NotEqual(t, name, SecretName("other", "test"))
+	require.NotEqual(t, SecretName("a-b", "c"), SecretName("a", "b-c"))
+}
+
+func TestSeed(t *testing.T) {
+	require.Equal(t, "ds=nocloud-net;s=http://10.0.0.1:8082/"+testToken+"/", Seed("http://10.0.0.1:8082/", testToken))
+
+	token, err := NewToken()
+	require.NoError(t, err)
+	require.Regexp(t, tokenPattern, token)
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// DefaultCapacityRefreshInterval is the interval after which a node capacity snapshot
+// is considered stale and fetched again from Proxmox.
+const DefaultCapacityRefreshInterval = 30 * time.Second
+
+// defaultCapacityCache is shared by all scheduling decisions of the controller.
+// Its snapshots are kept per Proxmox client.
+var defaultCapacityCache = NewCapacityCache(DefaultCapacityRefreshInterval)
+
+// SetCapacityRefreshInterval changes the refresh interval of the node capacity snapshots
+// used by ScheduleVM. An interval of zero disables caching.
+func SetCapacityRefreshInterval(interval time.Duration) {
+	defaultCapacityCache.mu.Lock()
+	defer defaultCapacityCache.mu.Unlock()
+
+	defaultCapacityCache.interval = interval
+	defaultCapacityCache.snapshots = make(map[capacityKey]capacitySnapshot)
+	defaultCapacityCache.inventories = make(map[inventoryClient]inventorySnapshot)
+}
+
+// CapacityCache keeps snapshots of the memory and the inventory of Proxmox nodes.
+// Snapshots are refreshed once they are older than the refresh interval,
+// and are updated locally after every placement. This way scheduling
+// multiple machines does not query every node for every machine, and
+// concurrent placements do not race on stale data.
+type CapacityCache struct {
+	mu          sync.Mutex
+	interval    time.Duration
+	now         func() time.Time
+	snapshots   map[capacityKey]capacitySnapshot
+	inventories map[inventoryClient]inventorySnapshot
+}
+
+// capacityKey identifies the snapshot of a node. It includes the client, as the nodes
+// of different Proxmox clusters may have the same names.
+type capacityKey struct {
+	client     resourceClient
+	node       string
+	accounting infrav1.MemoryAccounting
+}
+
+type capacitySnapshot struct {
+	memory    proxmox.NodeMemory
+	refreshed time.Time
+}
+
+// inventoryClient is the subset of the Proxmox client needed to list the inventory of the nodes.
+type inventoryClient interface {
+	GetNodeInventories(ctx context.Context) ([]proxmox.NodeInventory, error)
+}
+
+type inventorySnapshot struct {
+	inventories []proxmox.NodeInventory
+	refreshed   time.Time
+}
+
+// NewCapacityCache returns a CapacityCache refreshing its snapshots after the given interval.
+func NewCapacityCache(interval time.Duration) *CapacityCache {
+	return &CapacityCache{
+		interval:    interval,
+		now:         time.Now,
+		snapshots:   make(map[capacityKey]capacitySnapshot),
+		inventories: make(map[inventoryClient]inventorySnapshot),
+	}
+}
+
+// schedule runs the given scheduling function against the cached capacities of the nodes of client.
+// Stale snapshots are refreshed before, without holding the lock of the cache, so a slow node only
+// delays the placements on it. The scheduling decision holds the lock, and the requested memory is
+// reserved on the selected node afterwards.
+func (c *CapacityCache) schedule(
+	ctx context.Context,
+	client resourceClient,
+	nodes []string,
+	accounting infrav1.MemoryAccounting,
+	requestedMemory uint64,
+	fn func(resourceClient) (string, error),
+) (string, error) {
+	if !reflect.TypeOf(client).Comparable() {
+		// the client cannot be part of the key, so its nodes are not cached.
+		return fn(client)
+	}
+
+	memories, err := c.refresh(ctx, client, nodes, accounting)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	node, err := fn(cachedResourceClient{cache: c, client: client, memories: memories})
+	if err != nil {
+		return "", err
+	}
+
+	key := capacityKey{client: client, node: node, accounting: accounting}
+	if snapshot, ok := c.snapshots[key]; ok {
+		snapshot.memory.ReservedBytes += requestedMemory
+		c.snapshots[key] = snapshot
+	}
+
+	return node, nil
+}
+
+// refresh returns the memory of the nodes, and fetches the nodes without a fresh snapshot from the client.
+// Snapshots which are stale are dropped, so the snapshots of clients which are not used anymore do not pile up.
+func (c *CapacityCache) refresh(ctx context.Context, client resourceClient, nodes []string, accounting infrav1.MemoryAccounting) (map[string]proxmox.NodeMemory, error) {
+	now := c.now()
+	memories := make(map[string]proxmox.NodeMemory, len(nodes))
+
+	c.mu.Lock()
+	for key, snapshot := range c.snapshots {
+		if now.Sub(snapshot.refreshed) >= c.interval {
+			delete(c.snapshots, key)
+		}
+	}
+	var stale []string
+	for _, node := range nodes {
+		if snapshot, ok := c.snapshots[capacityKey{client: client, node: node, accounting: accounting}]; ok {
+			memories[node] = snapshot.memory
+		} else {
+			stale = append(stale, node)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, node := range stale {
+		memory, err := client.GetNodeMemory(ctx, node, accounting)
+		if err != nil {
+			return nil, err
+		}
+		memories[node] = memory
+
+		c.mu.Lock()
+		// another placement may have refreshed the snapshot and reserved memory on it in the meantime.
+		key := capacityKey{client: client, node: node, accounting: accounting}
+		if snapshot, ok := c.snapshots[key]; !ok || snapshot.refreshed.Before(now) {
+			c.snapshots[key] = capacitySnapshot{memory: memory, refreshed: now}
+		}
+		c.mu.Unlock()
+	}
+
+	return memories, nil
+}
+
+// cachedResourceClient serves the memory of the nodes from the cache, which includes the memory
+// reserved by other placements, or from the memory fetched by refresh if the snapshot was dropped
+// in the meantime. Nodes which were not refreshed are queried from the wrapped client.
+// It must only be used while holding the lock of the cache.
+type cachedResourceClient struct {
+	cache    *CapacityCache
+	client   resourceClient
+	memories map[string]proxmox.NodeMemory
+}
+
+func (c cachedResourceClient) GetNodeMemory(ctx context.Context, nodeName string, accounting infrav1.MemoryAccounting) (proxmox.NodeMemory, error) {
+	key := capacityKey{client: c.client, node: nodeName, accounting: accounting}
+	if snapshot, ok := c.cache.snapshots[key]; ok {
+		return snapshot.memory, nil
+	}
+
+	memory, ok := c.memories[nodeName]
+	if !ok {
+		var err error
+		if memory, err = c.client.GetNodeMemory(ctx, nodeName, accounting); err != nil {
+			return proxmox.NodeMemory{}, err
+		}
+	}
+
+	c.cache.snapshots[key] = capacitySnapshot{memory: memory, refreshed: c.cache.now()}
+	return memory, nil
+}
+
+// nodeInventories returns the inventory of the nodes of client, which is fetched again once it is stale.
+// Like the memory of the nodes, the inventory is fetched without holding the lock of the cache.
+func (c *CapacityCache) nodeInventories(ctx context.Context, client inventoryClient) ([]proxmox.NodeInventory, error) {
+	if !reflect.TypeOf(client).Comparable() {
+		return client.GetNodeInventories(ctx)
+	}
+
+	now := c.now()
+
+	c.mu.Lock()
+	for key, snapshot := range c.inventories {
+		if now.Sub(snapshot.refreshed) >= c.interval {
+			delete(c.inventories, key)
+		}
+	}
+	snapshot, ok := c.inventories[client]
+	c.mu.Unlock()
+	if ok {
+		return snapshot.inventories, nil
+	}
+
+	inventories, err := client.GetNodeInventories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if snapshot, ok := c.inventories[client]; !ok || snapshot.refreshed.Before(now) {
+		c.inventories[client] = inventorySnapshot{inventories: inventories, refreshed: now}
+	}
+	c.mu.Unlock()
+
+	return inventories, nil
+}
+
+// cachedInventoryClient serves the inventory of the nodes from the cache.
+type cachedInventoryClient struct {
+	proxmox.Client
+	cache *CapacityCache
+}
+
+func (c cachedInventoryClient) GetNodeInventories(ctx context.Context) ([]proxmox.NodeInventory, error) {
+	return c.cache.nodeInventories(ctx, c.Client)
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// countingResourceClient counts the calls per node.
+type countingResourceClient struct {
+	mem   map[string]uint64
+	calls map[string]int
+}
+
+func (c *countingResourceClient) GetNodeMemory(_ context.Context, nodeName string, _ infrav1.MemoryAccounting) (proxmox.NodeMemory, error) {
+	c.calls[nodeName]++
+	return proxmox.NodeMemory{TotalBytes: c.mem[nodeName]}, nil
+}
+
+func TestCapacityCache(t *testing.T) {
+	allowedNodes := []string{"pve1", "pve2"}
+	client := &countingResourceClient{
+		mem:   map[string]uint64{"pve1": miBytes(20), "pve2": miBytes(10)},
+		calls: map[string]int{},
+	}
+	proxmoxMachine := &infrav1.ProxmoxMachine{
+		Spec: infrav1.ProxmoxMachineSpec{
+			MemoryMiB: 8,
+		},
+	}
+
+	now := time.Now()
+	cache := NewCapacityCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	schedule := func() (string, error) {
+		return cache.schedule(context.Background(), client, allowedNodes, infrav1.MemoryAccountingMaxMemory, miBytes(8), func(c resourceClient) (string, error) {
+			return selectNode(context.Background(), c, proxmoxMachine, nil, allowedNodes, infrav1.MemoryAccountingMaxMemory, 0)
+		})
+	}
+
+	// pve1: 20 -> 12 -> 4; pve2: 10 -> 2
+	for _, expected := range []string{"pve1", "pve1", "pve2"} {
+		node, err := schedule()
+		require.NoError(t, err)
+		require.Equal(t, expected, node)
+	}
+	require.Equal(t, map[string]int{"pve1": 1, "pve2": 1}, client.calls)
+
+	_, err := schedule()
+	require.ErrorAs(t, err, &InsufficientMemoryError{})
+
+	// refresh after the interval expired
+	now = now.Add(time.Minute)
+	node, err := schedule()
+	require.NoError(t, err)
+	require.Equal(t, "pve1", node)
+	require.Equal(t, map[string]int{"pve1": 2, "pve2": 2}, client.calls)
+}
+
+func TestCapacityCachePerClient(t *testing.T) {
+	allowedNodes := []string{"pve1"}
+	proxmoxMachine := &infrav1.ProxmoxMachine{
+		Spec: infrav1.ProxmoxMachineSpec{
+			MemoryMiB: 8,
+		},
+	}
+
+	cache := NewCapacityCache(time.Minute)
+	schedule := func(client resourceClient) (string, error) {
+		return cache.schedule(context.Background(), client, allowedNodes, infrav1.MemoryAccountingMaxMemory, miBytes(8), func(c resourceClient) (string, error) {
+			return selectNode(context.Background(), c, proxmoxMachine, nil, allowedNodes, infrav1.MemoryAccountingMaxMemory, 0)
+		})
+	}
+
+	// both clusters have a node named pve1, which fits a single machine.
+	first := &countingResourceClient{mem: map[string]uint64{"pve1": miBytes(10)}, calls: map[string]int{}}
+	second := &countingResourceClient{mem: map[string]uint64{"pve1": miBytes(10)}, calls: map[string]int{}}
+
+	node, err := schedule(first)
+	require.NoError(t, err)
+	require.Equal(t, "pve1", node)
+
+	node, err = schedule(second)
+	require.NoError(t, err)
+	require.Equal(t, "pve1", node)
+
+	_, err = schedule(first)
+	require.ErrorAs(t, err, &InsufficientMemoryError{})
+	require.Equal(t, map[string]int{"pve1": 1}, first.calls)
+	require.Equal(t, map[string]int{"pve1": 1}, second.calls)
+}
+
+// blockingResourceClient blocks until it is released.
+type blockingResourceClient struct {
+	called  chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingResourceClient) GetNodeMemory(_ context.Context, _ string, _ infrav1.MemoryAccounting) (proxmox.NodeMemory, error) {
+	close(c.called)
+	<-c.release
+	return proxmox.NodeMemory{TotalBytes: miBytes(10)}, nil
+}
+
+func TestCapacityCacheFetchesWithoutLock(t *testing.T) {
+	allowedNodes := []string{"pve1"}
+	proxmoxMachine := &infrav1.ProxmoxMachine{
+		Spec: infrav1.ProxmoxMachineSpec{
+			MemoryMiB: 8,
+		},
+	}
+
+	cache := NewCapacityCache(time.Minute)
+	schedule := func(client resourceClient) (string, error) {
+		return cache.schedule(context.Background(), client, allowedNodes, infrav1.MemoryAccountingMaxMemory, miBytes(8), func(c resourceClient) (string, error) {
+			return selectNode(context.Background(), c, proxmoxMachine, nil, allowedNodes, infrav1.MemoryAccountingMaxMemory, 0)
+		})
+	}
+
+	blocking := &blockingResourceClient{called: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		_, err := schedule(blocking)
+		done <- err
+	}()
+	<-blocking.called
+
+	// a slow node must not block the placements of other clients.
+	node, err := schedule(&countingResourceClient{mem: map[string]uint64{"pve1": miBytes(10)}, calls: map[string]int{}})
+	require.NoError(t, err)
+	require.Equal(t, "pve1", node)
+
+	close(blocking.release)
+	require.NoError(t, <-done)
+}
+
+// countingInventoryClient counts the calls of GetNodeInventories.
+type countingInventoryClient struct {
+	calls int
+}
+
+func (c *countingInventoryClient) GetNodeInventories(_ context.Context) ([]proxmox.NodeInventory, error) {
+	c.calls++
+	return []proxmox.NodeInventory{{Name: "pve1"}}, nil
+}
+
+func TestCapacityCacheNodeInventories(t *testing.T) {
+	now := time.Now()
+	cache := NewCapacityCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	first, second := &countingInventoryClient{}, &countingInventoryClient{}
+	for i := 0; i < 2; i++ {
+		inventories, err := cache.nodeInventories(context.Background(), first)
+		require.NoError(t, err)
+		require.Equal(t, []proxmox.NodeInventory{{Name: "pve1"}}, inventories)
+	}
+	require.Equal(t, 1, first.calls)
+
+	_, err := cache.nodeInventories(context.Background(), second)
+	require.NoError(t, err)
+	require.Equal(t, 1, second.calls)
+
+	// refresh after the interval expired
+	now = now.Add(time.Minute)
+	_, err = cache.nodeInventories(context.Background(), first)
+	require.NoError(t, err)
+	require.Equal(t, 2, first.calls)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	go_proxmox "github.com/luthermonson/go-proxmox"
+)
+
+// templateClient is the subset of the Proxmox client needed to locate the disks of a template.
+type templateClient interface {
+	GetVM(ctx context.Context, nodeName string, vmID int64) (*go_proxmox.VirtualMachine, error)
+	GetNodeInventories(ctx context.Context) ([]proxmox.NodeInventory, error)
+}
+
+// TemplatePlacementError is returned when none of the eligible nodes can clone the template of a machine,
+// e.g. since its disks are on storage which is local to its node.
+type TemplatePlacementError struct {
+	templateID int32
+	skipped    map[string]string
+}
+
+func (err TemplatePlacementError) Error() string {
+	nodesByReason := make(map[string][]string)
+	for node, reason := range err.skipped {
+		nodesByReason[reason] = append(nodesByReason[reason], node)
+	}
+	reasons := make([]string, 0, len(nodesByReason))
+	for reason, nodes := range nodesByReason {
+		sort.Strings(nodes)
+		reasons = append(reasons, strings.Join(nodes, ", ")+": "+reason)
+	}
+	sort.Strings(reasons)
+	return fmt.Sprintf("no eligible node can clone template %d; %s", err.templateID, strings.Join(reasons, "; "))
+}
+
+// templateNodes returns the eligible nodes which can clone the template of the machine, and why the others
+// were skipped. Proxmox VE only clones a template to other nodes if all of its disks are on storage shared with
+// them, which linked clones keep using, so the clones of a template on local storage are placed on its node.
+// The storage of a full clone must be available on the node as well.
+func templateNodes(ctx context.Context, client templateClient, machine *infrav1.ProxmoxMachine, nodes []string) ([]string, map[string]string, error) {
+	templateID := machine.GetTemplateID()
+	if machine.Spec.Image != nil || templateID <= 0 {
+		return nodes, nil, nil
+	}
+
+	source := machine.GetNode()
+	template, err := client.GetVM(ctx, source, int64(templateID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get template %d on node %s: %w", templateID, source, err)
+	}
+	inventories, err := client.GetNodeInventories(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get node inventory: %w", err)
+	}
+	topology := proxmox.NewStorageTopology(inventories)
+	storages := diskStorages(template.VirtualMachineConfig)
+
+	var cloneStorage string
+	if machine.Spec.Storage != nil && (machine.Spec.Full == nil || *machine.Spec.Full) {
+		cloneStorage = *machine.Spec.Storage
+	}
+
+	skipped := make(map[string]string)
+	var eligible []string
+	for _, node := range nodes {
+		if reason := cloneBlocker(topology, source, node, storages, cloneStorage); reason != "" {
+			skipped[node] = reason
+			continue
+		}
+		eligible = append(eligible, node)
+	}
+	if len(eligible) == 0 {
+		return nil, skipped, TemplatePlacementError{templateID: templateID, skipped: skipped}
+	}
+	return eligible, skipped, nil
+}
+
+// cloneBlocker returns why the template on node source with disks on the storages cannot be cloned to the node,
+// or an empty string if it can.
+func cloneBlocker(topology proxmox.StorageTopology, source, node string, storages []string, cloneStorage string) string {
+	for _, storage := range storages {
+		switch {
+		case node != source && !topology.IsShared(storage):
+			return fmt.Sprintf("the template is on the local storage %s of node %s", storage, source)
+		case !topology.Reachable(storage, source, node):
+			return fmt.Sprintf("storage %s of the template is not available on the node", storage)
+		}
+	}
+	if cloneStorage != "" && !topology.Available(cloneStorage, node) {
+		return fmt.Sprintf("storage %s is not available on the node", cloneStorage)
+	}
+	return ""
+}
+
+// diskStorages returns the sorted storages of the disks of a VM, ignoring CD-ROM drives.
+func diskStorages(config *go_proxmox.VirtualMachineConfig) []string {
+	if config == nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	add := func(value string) {
+		volume, options, _ := strings.Cut(value, ",")
+		if volume == "" || volume == "none" || strings.Contains(","+options+",", ",media=cdrom,") {
+			return
+		}
+		if storage, _, ok := strings.Cut(volume, ":"); ok {
+			seen[storage] = struct{}{}
+		}
+	}
+	for _, devices := range []map[string]string{config.MergeIDEs(), config.MergeSATAs(), config.MergeSCSIs(), config.MergeVirtIOs()} {
+		for _, value := range devices {
+			add(value)
+		}
+	}
+	add(config.EFIDisk0)
+	add(config.TPMState0)
+
+	storages := make([]string, 0, len(seen))
+	for storage := range seen {
+		storages = append(storages, storage)
+	}
+	sort.Strings(storages)
+	return storages
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	go_proxmox "github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+type fakeTemplateClient struct {
+	templates   map[int64]*go_proxmox.VirtualMachineConfig
+	inventories []proxmox.NodeInventory
+}
+
+func (c fakeTemplateClient) GetVM(_ context.Context, _ string, vmID int64) (*go_proxmox.VirtualMachine, error) {
+	config, ok := c.templates[vmID]
+	if !ok {
+		return nil, errors.New("vm does not exist")
+	}
+	return &go_proxmox.VirtualMachine{VMID: go_proxmox.StringOrUint64(vmID), Template: true, VirtualMachineConfig: config}, nil
+}
+
+func (c fakeTemplateClient) GetNodeInventories(context.Context) ([]proxmox.NodeInventory, error) {
+	return c.inventories, nil
+}
+
+func TestTemplateNodes(t *testing.T) {
+	ceph := proxmox.StorageInfo{Name: "ceph", Shared: true, Enabled: true, Active: true}
+	localLVM := proxmox.StorageInfo{Name: "local-lvm", Enabled: true, Active: true}
+	nfs := proxmox.StorageInfo{Name: "nfs", Shared: true, Enabled: true, Active: true}
+	client := fakeTemplateClient{
+		templates: map[int64]*go_proxmox.VirtualMachineConfig{
+			100: {SCSI0: "ceph:base-100-disk-0,size=20G", IDE2: "local:iso/ubuntu.iso,media=cdrom", EFIDisk0: "ceph:base-100-disk-1"},
+			101: {SCSI0: "ceph:base-101-disk-0", VirtIO1: "local-lvm:base-101-disk-1", IDE0: "none,media=cdrom"},
+			103: {SCSI0: "nfs:103/base-103-disk-0.qcow2"},
+		},
+		inventories: []proxmox.NodeInventory{
+			{Name: "pve1", Storages: []proxmox.StorageInfo{ceph, localLVM, nfs}},
+			{Name: "pve2", Storages: []proxmox.StorageInfo{ceph, localLVM}},
+			{Name: "pve3", Storages: []proxmox.StorageInfo{ceph, localLVM, nfs}},
+			{Name: "pve4", Storages: []proxmox.StorageInfo{ceph, localLVM}},
+		},
+	}
+	nodes := []string{"pve1", "pve2", "pve3"}
+
+	tests := []struct {
+		name            string
+		spec            infrav1.ProxmoxMachineSpec
+		expectedNodes   []string
+		expectedSkipped []string
+		expectedErr     string
+	}{
+		{
+			name:          "shared storage",
+			spec:          infrav1.ProxmoxMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1", TemplateID: ptr.To[int32](100)}},
+			expectedNodes: nodes,
+		},
+		{
+			name:            "local storage",
+			spec:            infrav1.ProxmoxMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve2", TemplateID: ptr.To[int32](101)}},
+			expectedNodes:   []string{"pve2"},
+			expectedSkipped: []string{"pve1", "pve3"},
+		},
+		{
+			name:            "local storage of an ineligible node",
+			spec:            infrav1.ProxmoxMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve4", TemplateID: ptr.To[int32](101)}},
+			expectedSkipped: nodes,
+			expectedErr:     "no eligible node can clone template 101; pve1, pve2, pve3: the template is on the local storage local-lvm of node pve4",
+		},
+		{
+			name:            "shared storage of some nodes",
+			spec:            infrav1.ProxmoxMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1", TemplateID: ptr.To[int32](103)}},
+			expectedNodes:   []string{"pve1", "pve3"},
+			expectedSkipped: []string{"pve2"},
+		},
+		{
+			name:            "storage of a full clone",
+			spec:            infrav1.ProxmoxMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve2", TemplateID: ptr.To[int32](100), Storage: ptr.To("nfs")}},
+			expectedNodes:   []string{"pve1", "pve3"},
+			expectedSkipped: []string{"pve2"},
+		},
+		{
+			name:          "storage of a linked clone",
+			spec:          infrav1.ProxmoxMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve2", TemplateID: ptr.To[int32](100), Storage: ptr.To("nfs"), Full: ptr.To(false)}},
+			expectedNodes: nodes,
+		},
+		{
+			name:            "no eligible node with the storage",
+			spec:            infrav1.ProxmoxMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1", TemplateID: ptr.To[int32](100), Storage: ptr.To("zfs")}},
+			expectedSkipped: nodes,
+			expectedErr:     "no eligible node can clone template 100; pve1, pve2, pve3: storage zfs is not available on the node",
+		},
+		{
+			name:          "image",
+			spec:          infrav1.ProxmoxMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve2", Image: &infrav1.ImageSource{}}},
+			expectedNodes: nodes,
+		},
+		{
+			name:        "missing template",
+			spec:        infrav1.ProxmoxMachineSpec{VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{SourceNode: "pve1", TemplateID: ptr.To[int32](102)}},
+			expectedErr: "unable to get template 102 on node pve1: vm does not exist",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			machine := &infrav1.ProxmoxMachine{Spec: test.spec}
+
+			eligible, skipped, err := templateNodes(context.Background(), client, machine, nodes)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expectedNodes, eligible)
+
+			skippedNodes := make([]string, 0, len(skipped))
+			for node := range skipped {
+				skippedNodes = append(skippedNodes, node)
+			}
+			require.ElementsMatch(t, test.expectedSkipped, skippedNodes)
+		})
+	}
+}
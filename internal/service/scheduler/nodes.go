@@ -0,0 +1,114 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// nodeClient is the subset of the Proxmox client needed to resolve node selectors.
+type nodeClient interface {
+	GetPoolNodes(ctx context.Context, pool string) ([]string, error)
+	GetNodeInventories(ctx context.Context) ([]proxmox.NodeInventory, error)
+}
+
+// EligibleNodes returns the nodes a VM of the given cluster can be scheduled on.
+// These are the static AllowedNodes followed by the nodes matched by the
+// AllowedNodesSelector, without duplicates. Selecting nodes by their attributes
+// evaluates the live inventory of all online nodes.
+func EligibleNodes(ctx context.Context, client nodeClient, cluster *infrav1.ProxmoxCluster) ([]string, error) {
+	nodes := make([]string, 0, len(cluster.Spec.AllowedNodes))
+	seen := make(map[string]struct{})
+	add := func(names ...string) {
+		for _, name := range names {
+			if _, ok := seen[name]; !ok {
+				seen[name] = struct{}{}
+				nodes = append(nodes, name)
+			}
+		}
+	}
+
+	add(cluster.Spec.AllowedNodes...)
+
+	selector := cluster.Spec.AllowedNodesSelector
+	if selector == nil {
+		return nodes, nil
+	}
+
+	var poolNodes map[string]struct{}
+	if selector.Pool != "" {
+		names, err := client.GetPoolNodes(ctx, selector.Pool)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve nodes of pool %s: %w", selector.Pool, err)
+		}
+		if !selector.HasNodeAttributes() {
+			add(names...)
+			return nodes, nil
+		}
+
+		poolNodes = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			poolNodes[name] = struct{}{}
+		}
+	}
+
+	inventories, err := client.GetNodeInventories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get node inventory: %w", err)
+	}
+
+	for _, inventory := range inventories {
+		if poolNodes != nil {
+			if _, ok := poolNodes[inventory.Name]; !ok {
+				continue
+			}
+		}
+		if matchesNodeAttributes(selector, inventory) {
+			add(inventory.Name)
+		}
+	}
+
+	return nodes, nil
+}
+
+// matchesNodeAttributes returns true if the node matches all attributes of the selector.
+func matchesNodeAttributes(selector *infrav1.NodeSelector, inventory proxmox.NodeInventory) bool {
+	if selector.CPUModel != "" && !strings.Contains(inventory.CPUModel, selector.CPUModel) {
+		return false
+	}
+
+	if selector.MinMemoryMiB > 0 && inventory.MemoryBytes < uint64(selector.MinMemoryMiB)*1024*1024 {
+		return false
+	}
+
+	tags := make(map[string]struct{}, len(inventory.Tags))
+	for _, tag := range inventory.Tags {
+		tags[tag] = struct{}{}
+	}
+	for _, tag := range selector.Tags {
+		if _, ok := tags[tag]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+type fakeNodeClient struct {
+	pools       map[string][]string
+	inventories []proxmox.NodeInventory
+}
+
+func (c fakeNodeClient) GetPoolNodes(_ context.Context, pool string) ([]string, error) {
+	nodes, ok := c.pools[pool]
+	if !ok {
+		return nil, errors.New("pool does not exist")
+	}
+	return nodes, nil
+}
+
+func (c fakeNodeClient) GetNodeInventories(_ context.Context) ([]proxmox.NodeInventory, error) {
+	return c.inventories, nil
+}
+
+func TestEligibleNodes(t *testing.T) {
+	client := fakeNodeClient{
+		pools: map[string][]string{"capi": {"pve2", "pve3"}},
+		inventories: []proxmox.NodeInventory{
+			{Name: "pve1", CPUModel: "AMD EPYC 7543", MemoryBytes: 256 << 30, Tags: []string{"gpu", "ssd"}},
+			{Name: "pve2", CPUModel: "Intel Xeon Gold 6338", MemoryBytes: 512 << 30, Tags: []string{"ssd"}},
+			{Name: "pve3", CPUModel: "AMD EPYC 7543", MemoryBytes: 128 << 30},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		spec     infrav1.ProxmoxClusterSpec
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "static nodes",
+			spec:     infrav1.ProxmoxClusterSpec{AllowedNodes: []string{"pve1", "pve2"}},
+			expected: []string{"pve1", "pve2"},
+		},
+		{
+			name: "pool nodes",
+			spec: infrav1.ProxmoxClusterSpec{
+				AllowedNodesSelector: &infrav1.NodeSelector{Pool: "capi"},
+			},
+			expected: []string{"pve2", "pve3"},
+		},
+		{
+			name: "static and pool nodes",
+			spec: infrav1.ProxmoxClusterSpec{
+				AllowedNodes:         []string{"pve1", "pve2"},
+				AllowedNodesSelector: &infrav1.NodeSelector{Pool: "capi"},
+			},
+			expected: []string{"pve1", "pve2", "pve3"},
+		},
+		{
+			name: "tags",
+			spec: infrav1.ProxmoxClusterSpec{
+				AllowedNodesSelector: &infrav1.NodeSelector{Tags: []string{"ssd"}},
+			},
+			expected: []string{"pve1", "pve2"},
+		},
+		{
+			name: "cpu model and minimum memory",
+			spec: infrav1.ProxmoxClusterSpec{
+				AllowedNodesSelector: &infrav1.NodeSelector{CPUModel: "EPYC", MinMemoryMiB: 200 * 1024},
+			},
+			expected: []string{"pve1"},
+		},
+		{
+			name: "pool and attributes",
+			spec: infrav1.ProxmoxClusterSpec{
+				AllowedNodesSelector: &infrav1.NodeSelector{Pool: "capi", CPUModel: "EPYC"},
+			},
+			expected: []string{"pve3"},
+		},
+		{
+			name: "no matching node",
+			spec: infrav1.ProxmoxClusterSpec{
+				AllowedNodesSelector: &infrav1.NodeSelector{Tags: []string{"gpu", "nvme"}},
+			},
+			expected: []string{},
+		},
+		{
+			name: "unknown pool",
+			spec: infrav1.ProxmoxClusterSpec{
+				AllowedNodesSelector: &infrav1.NodeSelector{Pool: "unknown"},
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := EligibleNodes(context.Background(), client, &infrav1.ProxmoxCluster{Spec: test.spec})
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, nodes)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// placementClient is the subset of the Proxmox client needed to simulate placements.
+type placementClient interface {
+	nodeClient
+	resourceClient
+}
+
+// SimulatePlacement places count machines like the given one after another, the way ScheduleVM would,
+// against the current memory of the eligible nodes of the cluster, without reserving it.
+// It returns the nodes of the machines which fit. Fewer nodes than count means that the remaining
+// machines would fail to schedule with an InsufficientMemoryError.
+func SimulatePlacement(
+	ctx context.Context,
+	client placementClient,
+	cluster *infrav1.ProxmoxCluster,
+	machine *infrav1.ProxmoxMachine,
+	controlPlane bool,
+	count int,
+) ([]string, error) {
+	allowedNodes, err := EligibleNodes(ctx, client, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if len(allowedNodes) == 0 {
+		return nil, ErrNoEligibleNodes
+	}
+	accounting := cluster.Spec.SchedulerHints.GetMemoryAccounting()
+
+	// the simulated machines count towards the distribution of the following ones.
+	locations := make(map[string]string)
+	for name, node := range cluster.GetNodeLocations(controlPlane) {
+		locations[name] = node
+	}
+
+	simulated := &simulatedResourceClient{client: client, memory: make(map[string]proxmox.NodeMemory)}
+	requestedMemory := uint64(machine.Spec.MemoryMiB) * 1024 * 1024 // convert to bytes
+
+	placed := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		node, err := selectNode(ctx, simulated, machine, locations, allowedNodes, accounting, machineOvercommit(cluster, machine))
+		if errors.As(err, &InsufficientMemoryError{}) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		placed = append(placed, node)
+		locations[fmt.Sprintf("simulated-%d", i)] = node
+		memory := simulated.memory[node]
+		memory.ReservedBytes += requestedMemory
+		simulated.memory[node] = memory
+	}
+	return placed, nil
+}
+
+// simulatedResourceClient queries the memory of every node once,
+// so that the memory of simulated placements can be added to it.
+type simulatedResourceClient struct {
+	client resourceClient
+	memory map[string]proxmox.NodeMemory
+}
+
+func (c *simulatedResourceClient) GetNodeMemory(ctx context.Context, nodeName string, accounting infrav1.MemoryAccounting) (proxmox.NodeMemory, error) {
+	if memory, ok := c.memory[nodeName]; ok {
+		return memory, nil
+	}
+
+	memory, err := c.client.GetNodeMemory(ctx, nodeName, accounting)
+	if err != nil {
+		return proxmox.NodeMemory{}, err
+	}
+	c.memory[nodeName] = memory
+	return memory, nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+type fakePlacementClient struct {
+	fakeNodeClient
+	fakeResourceClient
+}
+
+func TestSimulatePlacement(t *testing.T) {
+	client := fakePlacementClient{
+		fakeResourceClient: fakeResourceClient{"pve1": miBytes(20), "pve2": miBytes(30), "pve3": miBytes(15)},
+	}
+	cluster := &infrav1.ProxmoxCluster{
+		Spec: infrav1.ProxmoxClusterSpec{AllowedNodes: []string{"pve1", "pve2", "pve3"}},
+		Status: infrav1.ProxmoxClusterStatus{
+			NodeLocations: &infrav1.NodeLocations{WorkerMachines: map[string]string{"existing": "pve2"}},
+		},
+	}
+	machine := &infrav1.ProxmoxMachine{Spec: infrav1.ProxmoxMachineSpec{MemoryMiB: 8}}
+
+	// pve3 fits one machine, pve1 two and pve2 three.
+	nodes, err := SimulatePlacement(context.Background(), client, cluster, machine, false, 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve1", "pve3", "pve2", "pve1", "pve2", "pve2"}, nodes)
+
+	// the node locations of the cluster are not changed.
+	require.Len(t, cluster.Status.NodeLocations.WorkerMachines, 1)
+
+	nodes, err = SimulatePlacement(context.Background(), client, cluster, machine, false, 2)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+}
+
+func TestSimulatePlacement_NoEligibleNodes(t *testing.T) {
+	_, err := SimulatePlacement(context.Background(), fakePlacementClient{}, &infrav1.ProxmoxCluster{}, &infrav1.ProxmoxMachine{}, false, 1)
+	require.ErrorIs(t, err, ErrNoEligibleNodes)
+}
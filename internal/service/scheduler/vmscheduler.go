@@ -20,11 +20,13 @@ package scheduler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 
 	"github.com/go-logr/logr"
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 	"sigs.k8s.io/cluster-api/util"
 )
@@ -42,33 +44,88 @@ func (err InsufficientMemoryError) Error() string {
 		err.requested, err.node, err.available)
 }
 
+// ErrNoEligibleNodes is returned when the ProxmoxCluster does not select any node.
+var ErrNoEligibleNodes = errors.New("no eligible nodes to schedule the vm on")
+
 // ScheduleVM decides which node to a ProxmoxMachine should be scheduled on.
 // It requires the machine's ProxmoxCluster to have at least 1 allowed node.
 func ScheduleVM(ctx context.Context, machineScope *scope.MachineScope) (string, error) {
 	client := machineScope.InfraCluster.ProxmoxClient
-	allowedNodes := machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes
-	locations := machineScope.InfraCluster.ProxmoxCluster.Status.NodeLocations.Workers
-	if util.IsControlPlaneMachine(machineScope.Machine) {
-		locations = machineScope.InfraCluster.ProxmoxCluster.Status.NodeLocations.ControlPlane
+	allowedNodes, err := machineNodes(ctx, machineScope)
+	if err != nil {
+		return "", err
 	}
+	accounting := machineScope.InfraCluster.ProxmoxCluster.Spec.SchedulerHints.GetMemoryAccounting()
+	locations := machineScope.InfraCluster.ProxmoxCluster.GetNodeLocations(util.IsControlPlaneMachine(machineScope.Machine))
+
+	overcommit := machineOvercommit(machineScope.InfraCluster.ProxmoxCluster, machineScope.ProxmoxMachine)
+
+	requestedMemory := uint64(machineScope.ProxmoxMachine.Spec.MemoryMiB) * 1024 * 1024 // convert to bytes
+
+	return defaultCapacityCache.schedule(ctx, client, allowedNodes, accounting, requestedMemory, func(client resourceClient) (string, error) {
+		return selectNode(ctx, client, machineScope.ProxmoxMachine, locations, allowedNodes, accounting, overcommit)
+	})
+}
 
-	return selectNode(ctx, client, machineScope.ProxmoxMachine, locations, allowedNodes)
+// PreviewVM returns the node ScheduleVM would currently select for a ProxmoxMachine.
+// Unlike ScheduleVM, it does not reserve the memory of the machine on the node.
+func PreviewVM(ctx context.Context, machineScope *scope.MachineScope) (string, error) {
+	client := machineScope.InfraCluster.ProxmoxClient
+	allowedNodes, err := machineNodes(ctx, machineScope)
+	if err != nil {
+		return "", err
+	}
+	accounting := machineScope.InfraCluster.ProxmoxCluster.Spec.SchedulerHints.GetMemoryAccounting()
+	locations := machineScope.InfraCluster.ProxmoxCluster.GetNodeLocations(util.IsControlPlaneMachine(machineScope.Machine))
+	overcommit := machineOvercommit(machineScope.InfraCluster.ProxmoxCluster, machineScope.ProxmoxMachine)
+
+	return selectNode(ctx, client, machineScope.ProxmoxMachine, locations, allowedNodes, accounting, overcommit)
+}
+
+// machineNodes returns the eligible nodes of the cluster which can clone the template of the machine.
+// The inventory of the nodes is read from the cache, since it is consulted for every machine.
+func machineNodes(ctx context.Context, machineScope *scope.MachineScope) ([]string, error) {
+	client := cachedInventoryClient{Client: machineScope.InfraCluster.ProxmoxClient, cache: defaultCapacityCache}
+	allowedNodes, err := EligibleNodes(ctx, client, machineScope.InfraCluster.ProxmoxCluster)
+	if err != nil {
+		return nil, err
+	}
+	if len(allowedNodes) == 0 {
+		return nil, ErrNoEligibleNodes
+	}
+
+	nodes, skipped, err := templateNodes(ctx, client, machineScope.ProxmoxMachine, allowedNodes)
+	if len(skipped) > 0 {
+		machineScope.Info("skipping nodes which cannot clone the template", "skipped", skipped)
+	}
+	return nodes, err
+}
+
+// machineOvercommit returns the percentage by which the memory of a node may be overcommitted by the machine.
+// Only interruptible machines overcommit nodes, which regular machines then avoid.
+func machineOvercommit(cluster *infrav1.ProxmoxCluster, machine *infrav1.ProxmoxMachine) int32 {
+	if !machine.Spec.Interruptible {
+		return 0
+	}
+	return cluster.Spec.SchedulerHints.GetInterruptibleMemoryOvercommit()
 }
 
 func selectNode(
 	ctx context.Context,
 	client resourceClient,
 	machine *infrav1.ProxmoxMachine,
-	locations []infrav1.NodeLocation,
+	locations map[string]string,
 	allowedNodes []string,
+	accounting infrav1.MemoryAccounting,
+	overcommitPercent int32,
 ) (string, error) {
 	byMemory := make(sortByAvailableMemory, len(allowedNodes))
 	for i, nodeName := range allowedNodes {
-		mem, err := client.GetReservableMemoryBytes(ctx, nodeName)
+		memory, err := client.GetNodeMemory(ctx, nodeName, accounting)
 		if err != nil {
 			return "", err
 		}
-		byMemory[i] = nodeInfo{Name: nodeName, AvailableMemory: mem}
+		byMemory[i] = nodeInfo{Name: nodeName, AvailableMemory: memory.Reservable(overcommitPercent)}
 	}
 
 	sort.Sort(byMemory)
@@ -85,8 +142,8 @@ func selectNode(
 
 	// count the existing vms per node
 	nodeCounter := make(map[string]int)
-	for _, nl := range locations {
-		nodeCounter[nl.Node]++
+	for _, node := range locations {
+		nodeCounter[node]++
 	}
 
 	for i, info := range byMemory {
@@ -111,6 +168,8 @@ func selectNode(
 			"byReplicas", byReplicas.String(),
 			"byMemory", byMemory.String(),
 			"requestedMemory", requestedMemory,
+			"memoryAccounting", accounting,
+			"memoryOvercommitPercent", overcommitPercent,
 			"resultNode", decision,
 		)
 	}
@@ -119,7 +178,7 @@ func selectNode(
 }
 
 type resourceClient interface {
-	GetReservableMemoryBytes(context.Context, string) (uint64, error)
+	GetNodeMemory(context.Context, string, infrav1.MemoryAccounting) (proxmox.NodeMemory, error)
 }
 
 type nodeInfo struct {
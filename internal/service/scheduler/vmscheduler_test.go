@@ -22,12 +22,28 @@ import (
 	"testing"
 
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
 )
 
 type fakeResourceClient map[string]uint64
 
-func (c fakeResourceClient) GetReservableMemoryBytes(_ context.Context, nodeName string) (uint64, error) {
+func (c fakeResourceClient) GetNodeMemory(_ context.Context, nodeName string, _ infrav1.MemoryAccounting) (proxmox.NodeMemory, error) {
+	return proxmox.NodeMemory{TotalBytes: c[nodeName]}, nil
+}
+
+// accountingResourceClient reports a different amount of memory per accounting mode.
+type accountingResourceClient map[infrav1.MemoryAccounting]uint64
+
+func (c accountingResourceClient) GetNodeMemory(_ context.Context, _ string, accounting infrav1.MemoryAccounting) (proxmox.NodeMemory, error) {
+	return proxmox.NodeMemory{TotalBytes: miBytes(16), ReservedBytes: miBytes(16) - c[accounting]}, nil
+}
+
+// nodeMemoryClient reports the memory of the nodes.
+type nodeMemoryClient map[string]proxmox.NodeMemory
+
+func (c nodeMemoryClient) GetNodeMemory(_ context.Context, nodeName string, _ infrav1.MemoryAccounting) (proxmox.NodeMemory, error) {
 	return c[nodeName], nil
 }
 
@@ -37,7 +53,7 @@ func miBytes(in uint64) uint64 {
 
 func TestSelectNode(t *testing.T) {
 	allowedNodes := []string{"pve1", "pve2", "pve3"}
-	var locations []infrav1.NodeLocation
+	locations := make(map[string]string)
 	const requestMiB = 8
 	availableMem := map[string]uint64{
 		"pve1": miBytes(20),
@@ -62,14 +78,14 @@ func TestSelectNode(t *testing.T) {
 
 			client := fakeResourceClient(availableMem)
 
-			node, err := selectNode(context.Background(), client, proxmoxMachine, locations, allowedNodes)
+			node, err := selectNode(context.Background(), client, proxmoxMachine, locations, allowedNodes, infrav1.MemoryAccountingMaxMemory, 0)
 			require.NoError(t, err)
 			require.Equal(t, expectedNode, node)
 
 			require.Greater(t, availableMem[node], miBytes(requestMiB))
 			availableMem[node] -= miBytes(requestMiB)
 
-			locations = append(locations, infrav1.NodeLocation{Node: node})
+			locations[fmt.Sprintf("m%d", i)] = node
 		})
 	}
 
@@ -82,7 +98,7 @@ func TestSelectNode(t *testing.T) {
 
 		client := fakeResourceClient(availableMem)
 
-		node, err := selectNode(context.Background(), client, proxmoxMachine, locations, allowedNodes)
+		node, err := selectNode(context.Background(), client, proxmoxMachine, locations, allowedNodes, infrav1.MemoryAccountingMaxMemory, 0)
 		require.ErrorAs(t, err, &InsufficientMemoryError{})
 		require.Empty(t, node)
 
@@ -94,3 +110,57 @@ func TestSelectNode(t *testing.T) {
 		require.Equal(t, expectMem, availableMem)
 	})
 }
+
+func TestSelectNodeMemoryAccounting(t *testing.T) {
+	proxmoxMachine := &infrav1.ProxmoxMachine{
+		Spec: infrav1.ProxmoxMachineSpec{
+			MemoryMiB: 8,
+		},
+	}
+
+	client := accountingResourceClient{
+		infrav1.MemoryAccountingMaxMemory:      miBytes(4),
+		infrav1.MemoryAccountingBalloonMinimum: miBytes(16),
+	}
+
+	_, err := selectNode(context.Background(), client, proxmoxMachine, nil, []string{"pve1"}, infrav1.MemoryAccountingMaxMemory, 0)
+	require.ErrorAs(t, err, &InsufficientMemoryError{})
+
+	node, err := selectNode(context.Background(), client, proxmoxMachine, nil, []string{"pve1"}, infrav1.MemoryAccountingBalloonMinimum, 0)
+	require.NoError(t, err)
+	require.Equal(t, "pve1", node)
+}
+
+func TestSelectNodeInterruptible(t *testing.T) {
+	proxmoxMachine := &infrav1.ProxmoxMachine{
+		Spec: infrav1.ProxmoxMachineSpec{
+			MemoryMiB:     8,
+			Interruptible: true,
+		},
+	}
+	cluster := &infrav1.ProxmoxCluster{}
+	overcommit := machineOvercommit(cluster, proxmoxMachine)
+	require.Equal(t, int32(infrav1.DefaultInterruptibleMemoryOvercommit), overcommit)
+
+	// pve1 is overcommitted by 4MiB already, pve2 has 2MiB left.
+	client := nodeMemoryClient{
+		"pve1": {TotalBytes: miBytes(16), ReservedBytes: miBytes(20)},
+		"pve2": {TotalBytes: miBytes(16), ReservedBytes: miBytes(14)},
+	}
+	allowedNodes := []string{"pve1", "pve2"}
+
+	node, err := selectNode(context.Background(), client, proxmoxMachine, nil, allowedNodes, infrav1.MemoryAccountingMaxMemory, overcommit)
+	require.NoError(t, err)
+	require.Equal(t, "pve2", node)
+
+	// regular machines do not fit on either node.
+	proxmoxMachine.Spec.Interruptible = false
+	_, err = selectNode(context.Background(), client, proxmoxMachine, nil, allowedNodes, infrav1.MemoryAccountingMaxMemory, machineOvercommit(cluster, proxmoxMachine))
+	require.ErrorAs(t, err, &InsufficientMemoryError{})
+
+	// the overcommit of the cluster limits interruptible machines as well.
+	proxmoxMachine.Spec.Interruptible = true
+	cluster.Spec.SchedulerHints = &infrav1.SchedulerHints{InterruptibleMemoryOvercommit: ptr.To[int32](25)}
+	_, err = selectNode(context.Background(), client, proxmoxMachine, nil, allowedNodes, infrav1.MemoryAccountingMaxMemory, machineOvercommit(cluster, proxmoxMachine))
+	require.ErrorAs(t, err, &InsufficientMemoryError{})
+}
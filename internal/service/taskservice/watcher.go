@@ -0,0 +1,120 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskservice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// DefaultWatchInterval is the interval in which the Watcher polls in-flight tasks.
+const DefaultWatchInterval = 2 * time.Second
+
+// Watcher polls in-flight Proxmox tasks and emits a generic event for the object
+// waiting on a task once it has completed. This allows controllers to requeue
+// objects as soon as their task finished, instead of polling on a fixed interval.
+type Watcher struct {
+	client   proxmox.Client
+	interval time.Duration
+	logger   logr.Logger
+
+	events chan event.GenericEvent
+
+	mu      sync.Mutex
+	pending map[string]client.Object
+}
+
+// NewWatcher returns a new Watcher polling tasks with the given client in the given interval.
+func NewWatcher(c proxmox.Client, interval time.Duration, logger logr.Logger) *Watcher {
+	return &Watcher{
+		client:   c,
+		interval: interval,
+		logger:   logger,
+		events:   make(chan event.GenericEvent),
+		pending:  make(map[string]client.Object),
+	}
+}
+
+// Watch registers the task with the given UPID. Once the task is no longer running,
+// an event for obj is emitted. Watching the same task again is a no-op.
+func (w *Watcher) Watch(upid string, obj client.Object) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.pending[upid]; !ok {
+		w.pending[upid] = obj.DeepCopyObject().(client.Object)
+	}
+}
+
+// Source returns the source delivering the events of completed tasks.
+func (w *Watcher) Source() source.Source {
+	return &source.Channel{Source: w.events}
+}
+
+// Start polls the pending tasks until the context is cancelled.
+// It implements the manager.Runnable interface.
+func (w *Watcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.poll(ctx)
+		}
+	}
+}
+
+// poll checks all pending tasks once and emits events for the completed ones.
+func (w *Watcher) poll(ctx context.Context) {
+	w.mu.Lock()
+	pending := make(map[string]client.Object, len(w.pending))
+	for upid, obj := range w.pending {
+		pending[upid] = obj
+	}
+	w.mu.Unlock()
+
+	for upid, obj := range pending {
+		task, err := w.client.GetTask(ctx, upid)
+		if err != nil {
+			// the task may be gone, let the regular reconciliation handle it.
+			w.logger.V(4).Info("unable to get watched task", "upid", upid, "error", err.Error())
+		} else if task.IsRunning {
+			continue
+		}
+
+		w.mu.Lock()
+		delete(w.pending, upid)
+		w.mu.Unlock()
+
+		select {
+		case w.events <- event.GenericEvent{Object: obj}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func TestWatcher_Poll(t *testing.T) {
+	ctx := context.Background()
+	client := proxmoxtest.NewMockClient(t)
+	w := NewWatcher(client, DefaultWatchInterval, logr.Discard())
+
+	machine := &infrav1alpha1.ProxmoxMachine{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+	w.Watch("running", machine)
+	w.Watch("done", machine)
+
+	client.EXPECT().GetTask(ctx, "running").Return(&proxmox.Task{IsRunning: true}, nil).Twice()
+	client.EXPECT().GetTask(ctx, "done").Return(&proxmox.Task{IsCompleted: true, IsSuccessful: true}, nil).Once()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.poll(ctx)
+	}()
+
+	ev := <-w.events
+	require.Equal(t, "test", ev.Object.GetName())
+	<-done
+
+	require.Len(t, w.pending, 1)
+	require.Contains(t, w.pending, "running")
+
+	// the running task is polled again, the completed one is not.
+	w.poll(ctx)
+	require.Len(t, w.pending, 1)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// DefaultAgentHealthCheckInterval is the interval in which the QEMU guest agent is pinged,
+// unless the agent health check of the machine sets one.
+const DefaultAgentHealthCheckInterval = time.Minute
+
+// AgentHealthCheckInterval returns the interval in which the QEMU guest agent of the machine is pinged,
+// or zero if the machine has no agent health check.
+func AgentHealthCheckInterval(machineScope *scope.MachineScope) time.Duration {
+	check := machineScope.ProxmoxMachine.Spec.AgentHealthCheck
+	if check == nil {
+		return 0
+	}
+	if check.Interval != nil && check.Interval.Duration > 0 {
+		return check.Interval.Duration
+	}
+	return DefaultAgentHealthCheckInterval
+}
+
+// reconcileAgentHealth pings the QEMU guest agent of ready machines with an agent health check, and reports
+// the result in the AgentHealthy condition. Once the agent did not respond for longer than the unhealthy timeout,
+// the machine is marked as failed, which a MachineHealthCheck remediates.
+func reconcileAgentHealth(ctx context.Context, machineScope *scope.MachineScope) {
+	check := machineScope.ProxmoxMachine.Spec.AgentHealthCheck
+	powerState := machineScope.ProxmoxMachine.Spec.PowerState
+	if check == nil || !machineScope.ProxmoxMachine.Status.Ready || (powerState != "" && powerState != infrav1alpha1.PowerStateRunning) {
+		// suspended VMs cannot respond.
+		conditions.Delete(machineScope.ProxmoxMachine, infrav1alpha1.AgentHealthyCondition)
+		return
+	}
+
+	err := machineScope.InfraCluster.ProxmoxClient.PingGuestAgent(ctx, machineScope.VirtualMachine)
+	if err == nil {
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.AgentHealthyCondition)
+		return
+	}
+
+	// the condition keeps the first error, as its transition time changes with the message
+	// and marks when the agent stopped responding.
+	if !conditions.IsFalse(machineScope.ProxmoxMachine, infrav1alpha1.AgentHealthyCondition) {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.AgentHealthyCondition, infrav1alpha1.AgentNotRespondingReason, clusterv1.ConditionSeverityWarning, err.Error())
+	}
+	if check.UnhealthyTimeout == nil || check.UnhealthyTimeout.Duration <= 0 {
+		return
+	}
+	if since := conditions.GetLastTransitionTime(machineScope.ProxmoxMachine, infrav1alpha1.AgentHealthyCondition); since != nil && time.Since(since.Time) >= check.UnhealthyTimeout.Duration {
+		machineScope.Error(err, "QEMU guest agent is unhealthy, marking the machine as failed", "timeout", check.UnhealthyTimeout.Duration)
+		machineScope.SetFailureMessage(errors.Errorf("the QEMU guest agent did not respond for %s", check.UnhealthyTimeout.Duration))
+		machineScope.SetFailureReason(capierrors.MachineStatusError("AgentUnhealthy"))
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func TestReconcileAgentHealth_Healthy(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.AgentHealthCheck = &infrav1alpha1.AgentHealthCheck{}
+	machineScope.ProxmoxMachine.Status.Ready = true
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().PingGuestAgent(context.Background(), vm).Return(nil).Once()
+
+	reconcileAgentHealth(context.Background(), machineScope)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.AgentHealthyCondition))
+	require.Equal(t, DefaultAgentHealthCheckInterval, AgentHealthCheckInterval(machineScope))
+}
+
+func TestReconcileAgentHealth_NotResponding(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.AgentHealthCheck = &infrav1alpha1.AgentHealthCheck{UnhealthyTimeout: &metav1.Duration{Duration: 5 * time.Minute}}
+	machineScope.ProxmoxMachine.Status.Ready = true
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().PingGuestAgent(context.Background(), vm).Return(errors.New("QEMU guest agent is not running")).Twice()
+
+	reconcileAgentHealth(context.Background(), machineScope)
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.AgentHealthyCondition)
+	require.False(t, machineScope.HasFailed())
+
+	// the agent did not respond since the timeout.
+	for i := range machineScope.ProxmoxMachine.Status.Conditions {
+		machineScope.ProxmoxMachine.Status.Conditions[i].LastTransitionTime = metav1.NewTime(time.Now().Add(-6 * time.Minute))
+	}
+
+	reconcileAgentHealth(context.Background(), machineScope)
+	require.True(t, machineScope.HasFailed())
+	require.Equal(t, "AgentUnhealthy", string(*machineScope.ProxmoxMachine.Status.FailureReason))
+}
+
+func TestReconcileAgentHealth_Suspended(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.AgentHealthCheck = &infrav1alpha1.AgentHealthCheck{Interval: &metav1.Duration{Duration: 10 * time.Second}}
+	machineScope.ProxmoxMachine.Spec.PowerState = infrav1alpha1.PowerStateSuspended
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.SetVirtualMachine(newPausedVM())
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.AgentHealthyCondition, infrav1alpha1.AgentNotRespondingReason, clusterv1.ConditionSeverityWarning, "")
+
+	// the mock fails the test if the agent is pinged.
+	reconcileAgentHealth(context.Background(), machineScope)
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.AgentHealthyCondition))
+	require.Equal(t, 10*time.Second, AgentHealthCheckInterval(machineScope))
+}
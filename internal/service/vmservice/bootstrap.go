@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/luthermonson/go-proxmox"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
@@ -31,6 +30,7 @@ import (
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/inject"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 )
 
@@ -40,7 +40,7 @@ func reconcileBootstrapData(ctx context.Context, machineScope *scope.MachineScop
 		return false, nil
 	}
 
-	if !machineHasIPAddress(machineScope.ProxmoxMachine) {
+	if !machineHasIPAddress(machineScope) {
 		// skip machine doesn't have an IpAddress yet.
 		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForStaticIPAllocationReason, clusterv1.ConditionSeverityWarning, "no ip address")
 		return true, nil
@@ -51,16 +51,46 @@ func reconcileBootstrapData(ctx context.Context, machineScope *scope.MachineScop
 		return true, nil
 	}
 
+	// hold the configured VM until its bootstrap is approved.
+	if !machineScope.ProxmoxMachine.IsBootstrapApproved() {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForBootstrapApprovalReason, clusterv1.ConditionSeverityInfo,
+			"set the annotation %s to true to approve the bootstrap", infrav1alpha1.BootstrapApprovedAnnotation)
+		return true, nil
+	}
+
 	machineScope.Logger.V(4).Info("reconciling BootstrapData.")
 
 	// Get the bootstrap data.
-	bootstrapData, err := getBootstrapData(ctx, machineScope)
+	bootstrapData, bootstrapVersion, err := getBootstrapData(ctx, machineScope)
 	if err != nil {
 		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.CloningFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
 		return false, err
 	}
 
-	biosUUID := extractUUID(machineScope.VirtualMachine.VirtualMachineConfig.SMBios1)
+	files, err := getFiles(ctx, machineScope)
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, err
+	}
+
+	windows := machineScope.ProxmoxMachine.Spec.GuestOS == infrav1alpha1.GuestOSWindows
+
+	// the proxy settings are written for Linux, Windows VMs have to configure it in their bootstrap data.
+	var commands []string
+	if proxy := machineScope.InfraCluster.ProxmoxCluster.Spec.Proxy; proxy != nil && !windows {
+		p := cloudinit.NewProxy(proxy.HTTPProxy, proxy.HTTPSProxy, proxy.NoProxy)
+		files = append(files, p.Files()...)
+		commands = append(commands, p.Commands()...)
+	}
+
+	// add the files of the machine and the proxy settings to the bootstrap data.
+	userData, err := cloudinit.NewUserData(bootstrapData, files, commands).Render()
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		return false, errors.Wrap(err, "unable to render user-data")
+	}
+
+	instanceID := cloudInitInstanceID(machineScope)
 
 	nicData, err := getNetworkConfigData(ctx, machineScope)
 	if err != nil {
@@ -68,57 +98,135 @@ func reconcileBootstrapData(ctx context.Context, machineScope *scope.MachineScop
 		return false, err
 	}
 
-	// create network renderer
-	network := cloudinit.NewNetworkConfig(nicData)
-
-	// create metadata renderer
-	metadata := cloudinit.NewMetadata(biosUUID, machineScope.Name())
+	// create network and metadata renderers of the format of the machine.
+	var network, metadata cloudinit.Renderer
+	// cloudbase-init of Windows VMs reads config drives.
+	if machineScope.ProxmoxMachine.Spec.CloudInitFormat == infrav1alpha1.CloudInitFormatConfigDrive2 || windows {
+		network = cloudinit.NewConfigDriveNetworkData(nicData)
+		metadata = cloudinit.NewConfigDriveMetadata(instanceID, machineScope.Name())
+	} else {
+		network = cloudinit.NewNetworkConfig(nicData)
+		metadata = cloudinit.NewMetadata(instanceID, machineScope.Name())
+	}
 
-	injector := getISOInjector(machineScope.VirtualMachine, bootstrapData, metadata, network)
-	if err = injector.Inject(ctx); err != nil {
-		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
-		return false, errors.Wrap(err, "cloud-init iso inject failed")
+	if machineScope.ProxmoxMachine.Spec.CloudInitFormat == infrav1alpha1.CloudInitFormatNoCloudNet && !windows {
+		// the VM fetches the data from the metadata server instead of an ISO.
+		if err = seedNoCloudNet(ctx, machineScope, userData, metadata, network); err != nil {
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return false, errors.Wrap(err, "cloud-init nocloud-net seed failed")
+		}
+	} else {
+		transport, err := machineScope.InfraCluster.NodeTransport(ctx)
+		if err != nil {
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return false, err
+		}
+		injector := getISOInjector(machineScope, transport, userData, metadata, network)
+		if err = injector.Inject(ctx); err != nil {
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return false, errors.Wrap(err, "cloud-init iso inject failed")
+		}
 	}
 
 	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
+	machineScope.ProxmoxMachine.Status.ObservedBootstrapSecretVersion = bootstrapVersion
 
 	return false, nil
 }
 
+// cloudInitInstanceID returns the instance-id of the cloud-init meta-data of the machine. It is recorded in the
+// status the first time, so it neither changes when the data is rendered again, nor when the UID of the
+// ProxmoxMachine changes after it was moved to another management cluster.
+func cloudInitInstanceID(machineScope *scope.MachineScope) string {
+	status := &machineScope.ProxmoxMachine.Status
+	if status.CloudInitInstanceID == "" {
+		status.CloudInitInstanceID = string(machineScope.ProxmoxMachine.GetUID())
+	}
+	if status.CloudInitInstanceID == "" {
+		// objects without a UID were never stored by the API server.
+		return extractUUID(machineScope.VirtualMachine.VirtualMachineConfig.SMBios1)
+	}
+	return status.CloudInitInstanceID
+}
+
 type isoInjector interface {
 	Inject(ctx context.Context) error
 }
 
-func defaultISOInjector(vm *proxmox.VirtualMachine, bootStrapData []byte, metadata, network cloudinit.Renderer) isoInjector {
-	return &inject.ISOInjector{
-		VirtualMachine:  vm,
+func defaultISOInjector(machineScope *scope.MachineScope, transport proxmox.NodeTransport, bootStrapData []byte, metadata, network cloudinit.Renderer) isoInjector {
+	injector := &inject.ISOInjector{
+		VirtualMachine:  machineScope.VirtualMachine,
+		Client:          machineScope.InfraCluster.ProxmoxClient,
+		Transport:       transport,
+		Storage:         machineScope.InfraCluster.ProxmoxCluster.Spec.CloudInitStorage,
+		Format:          machineScope.ProxmoxMachine.Spec.CloudInitFormat,
 		BootstrapData:   bootStrapData,
 		MetaRenderer:    metadata,
 		NetworkRenderer: network,
 	}
+	if machineScope.ProxmoxMachine.Spec.GuestOS == infrav1alpha1.GuestOSWindows {
+		injector.Format = infrav1alpha1.CloudInitFormatConfigDrive2
+		injector.Device = inject.WindowsCloudInitISODevice
+	}
+	if device := machineScope.ProxmoxMachine.Spec.CloudInitDevice; device != "" {
+		injector.Device = device
+	}
+	return injector
 }
 
 var getISOInjector = defaultISOInjector
 
-// getBootstrapData obtains a machine's bootstrap data from the relevant K8s secret and returns the data.
+// getBootstrapData obtains a machine's bootstrap data from the relevant K8s secret and returns the data
+// and the resource version of the secret.
 // TODO: Add format return if ignition will be supported.
-func getBootstrapData(ctx context.Context, scope *scope.MachineScope) ([]byte, error) {
+func getBootstrapData(ctx context.Context, scope *scope.MachineScope) ([]byte, string, error) {
 	if scope.Machine.Spec.Bootstrap.DataSecretName == nil {
 		scope.Logger.Info("machine has no bootstrap data.")
-		return nil, errors.New("machine has no bootstrap data")
+		return nil, "", errors.New("machine has no bootstrap data")
 	}
 
 	secret := &corev1.Secret{}
 	if err := scope.GetBootstrapSecret(ctx, secret); err != nil {
-		return nil, errors.Wrapf(err, "failed to retrieve bootstrap data secret")
+		return nil, "", errors.Wrapf(err, "failed to retrieve bootstrap data secret")
 	}
 
 	value, ok := secret.Data["value"]
 	if !ok {
-		return nil, errors.New("error retrieving bootstrap data: secret `value` key is missing")
+		return nil, "", errors.New("error retrieving bootstrap data: secret `value` key is missing")
+	}
+
+	return value, secret.ResourceVersion, nil
+}
+
+// getFiles resolves the files of a machine, including the content referenced in secrets.
+func getFiles(ctx context.Context, machineScope *scope.MachineScope) ([]cloudinit.File, error) {
+	files := make([]cloudinit.File, 0, len(machineScope.ProxmoxMachine.Spec.Files))
+	for _, f := range machineScope.ProxmoxMachine.Spec.Files {
+		content := []byte(f.Content)
+		if f.ContentFrom != nil {
+			ref := f.ContentFrom.Secret
+
+			secret := &corev1.Secret{}
+			if err := machineScope.GetSecret(ctx, ref.Name, secret); err != nil {
+				return nil, errors.Wrapf(err, "failed to retrieve secret %s for file %s", ref.Name, f.Path)
+			}
+
+			value, ok := secret.Data[ref.Key]
+			if !ok {
+				return nil, errors.Errorf("secret %s has no key %s for file %s", ref.Name, ref.Key, f.Path)
+			}
+			content = value
+		}
+
+		files = append(files, cloudinit.File{
+			Path:        f.Path,
+			Content:     content,
+			Permissions: f.Permissions,
+			Owner:       f.Owner,
+		})
 	}
 
-	return value, nil
+	return files, nil
 }
 
 func getNetworkConfigData(ctx context.Context, machineScope *scope.MachineScope) ([]cloudinit.NetworkConfigData, error) {
@@ -137,10 +245,38 @@ func getNetworkConfigData(ctx context.Context, machineScope *scope.MachineScope)
 		return nil, err
 	}
 	networkConfigData = append(networkConfigData, additionalConfig...)
+	setDefaultRouteMetrics(networkConfigData)
 
 	return networkConfigData, nil
 }
 
+// routeMetricStep is the difference between the default route metrics of consecutive network devices.
+const routeMetricStep = 100
+
+// setDefaultRouteMetrics sets the route metrics of network devices without one if multiple devices
+// have a gateway. Otherwise the default routes would be equal, and the primary uplink would be random.
+func setDefaultRouteMetrics(networkConfigData []cloudinit.NetworkConfigData) {
+	gateways := 0
+	for _, config := range networkConfigData {
+		if hasGateway(config) {
+			gateways++
+		}
+	}
+	if gateways < 2 {
+		return
+	}
+
+	for i := range networkConfigData {
+		if hasGateway(networkConfigData[i]) && networkConfigData[i].RouteMetric == nil {
+			networkConfigData[i].RouteMetric = ptr.To(int32((i + 1) * routeMetricStep))
+		}
+	}
+}
+
+func hasGateway(config cloudinit.NetworkConfigData) bool {
+	return config.Gateway != "" || config.Gateway6 != ""
+}
+
 func getNetworkConfigDataForDevice(ctx context.Context, machineScope *scope.MachineScope, device string) (*cloudinit.NetworkConfigData, error) {
 	nets := machineScope.VirtualMachine.VirtualMachineConfig.MergeNets()
 	// For nics supporting multiple IP addresses, we need to cut the '-inet' or '-inet6' part,
@@ -157,7 +293,6 @@ func getNetworkConfigDataForDevice(ctx context.Context, machineScope *scope.Mach
 		return nil, errors.Wrapf(err, "unable to find IPAddress, device=%s", device)
 	}
 
-	dns := machineScope.InfraCluster.ProxmoxCluster.Spec.DNSServers
 	ip := IPAddressWithPrefix(ipAddr.Spec.Address, ipAddr.Spec.Prefix)
 	gw := ipAddr.Spec.Gateway
 
@@ -165,10 +300,34 @@ func getNetworkConfigDataForDevice(ctx context.Context, machineScope *scope.Mach
 		MacAddress: macAddress,
 		IPAddress:  ip,
 		Gateway:    gw,
-		DNSServers: dns,
 	}, nil
 }
 
+// dnsServers returns the nameservers of an address family of a network device. The nameservers of
+// an additional network device take precedence over the ones of the cluster, and within both the
+// IPv6 nameservers take precedence over the general ones for IPv6.
+func dnsServers(machineScope *scope.MachineScope, nic *infrav1alpha1.AdditionalNetworkDevice, format string) []string {
+	spec := machineScope.InfraCluster.ProxmoxCluster.Spec
+	candidates := [][]string{spec.DNSServers}
+	if format == infrav1alpha1.IPV6Format {
+		candidates = [][]string{spec.IPv6DNSServers, spec.DNSServers}
+	}
+	if nic != nil {
+		nicCandidates := [][]string{nic.DNSServers}
+		if format == infrav1alpha1.IPV6Format {
+			nicCandidates = [][]string{nic.IPv6DNSServers, nic.DNSServers}
+		}
+		candidates = append(nicCandidates, candidates...)
+	}
+
+	for _, servers := range candidates {
+		if len(servers) > 0 {
+			return servers
+		}
+	}
+	return nil
+}
+
 func getDefaultNetworkDevice(ctx context.Context, machineScope *scope.MachineScope) ([]cloudinit.NetworkConfigData, error) {
 	var config cloudinit.NetworkConfigData
 
@@ -179,6 +338,7 @@ func getDefaultNetworkDevice(ctx context.Context, machineScope *scope.MachineSco
 			return nil, errors.Wrapf(err, "unable to get network config data for device=%s", DefaultNetworkDeviceIPV4)
 		}
 		config = *conf
+		config.DNSServers = dnsServers(machineScope, nil, infrav1alpha1.IPV4Format)
 	}
 
 	// default network device ipv6.
@@ -188,26 +348,75 @@ func getDefaultNetworkDevice(ctx context.Context, machineScope *scope.MachineSco
 			return nil, errors.Wrapf(err, "unable to get network config data for device=%s", DefaultNetworkDeviceIPV6)
 		}
 
-		switch {
-		case len(config.MacAddress) == 0:
-			config = *conf
-		case config.MacAddress != conf.MacAddress:
-			return nil, errors.New("default network device ipv4 and ipv6 have different mac addresses")
-		default:
-			config.IPV6Address = conf.IPAddress
-			config.Gateway6 = conf.Gateway
+		if err := mergeIPv6NetworkConfigData(&config, conf); err != nil {
+			return nil, errors.Wrap(err, "default network device")
+		}
+		config.DNSServers6 = dnsServers(machineScope, nil, infrav1alpha1.IPV6Format)
+	}
+
+	if cluster := machineScope.InfraCluster.ProxmoxCluster; cluster.Spec.DHCPMode != "" {
+		macAddress, err := deviceMACAddress(machineScope, infrav1alpha1.DefaultNetworkDevice)
+		if err != nil {
+			return nil, err
+		}
+		config.MacAddress = macAddress
+		if cluster.UsesDHCP(infrav1alpha1.IPV4Format) {
+			config.DHCP4 = true
+			config.DNSServers = dnsServers(machineScope, nil, infrav1alpha1.IPV4Format)
+		}
+		if cluster.UsesDHCP(infrav1alpha1.IPV6Format) {
+			// DHCPv6 does not provide default routes.
+			config.DHCP6 = true
+			config.AcceptRA = true
+			config.DNSServers6 = dnsServers(machineScope, nil, infrav1alpha1.IPV6Format)
 		}
 	}
 
+	if network := machineScope.ProxmoxMachine.Spec.Network; network != nil && network.Default != nil {
+		config.RouteMetric = network.Default.RouteMetric
+		config.DHCP6 = config.DHCP6 || network.Default.DHCP6
+		config.MTU = guestMTU(*network.Default)
+	}
+
 	return []cloudinit.NetworkConfigData{config}, nil
 }
 
+// mergeIPv6NetworkConfigData adds the IPv6 configuration of a network device to its IPv4 configuration, if any.
+func mergeIPv6NetworkConfigData(config, conf6 *cloudinit.NetworkConfigData) error {
+	switch {
+	case len(config.MacAddress) == 0:
+		config.MacAddress = conf6.MacAddress
+	case config.MacAddress != conf6.MacAddress:
+		return errors.New("ipv4 and ipv6 have different mac addresses")
+	}
+	config.IPV6Address = conf6.IPAddress
+	config.Gateway6 = conf6.Gateway
+	return nil
+}
+
 func getAdditionalNetworkDevices(ctx context.Context, machineScope *scope.MachineScope, network infrav1alpha1.NetworkSpec) ([]cloudinit.NetworkConfigData, error) {
 	networkConfigData := make([]cloudinit.NetworkConfigData, 0, len(network.AdditionalDevices))
 
 	// additional network devices.
-	for _, nic := range network.AdditionalDevices {
-		var config = ptr.To(cloudinit.NetworkConfigData{})
+	for i := range network.AdditionalDevices {
+		nic := &network.AdditionalDevices[i]
+		var config cloudinit.NetworkConfigData
+
+		if nic.Unmanaged {
+			// the device is configured outside of the network config.
+			continue
+		}
+
+		traffic := nic.Profile == infrav1alpha1.NetworkDeviceProfileTraffic
+		if nic.LinkLocal != "" || (traffic && nic.IPv4PoolRef == nil && nic.IPv6PoolRef == nil && !nic.DHCP6) {
+			conf, err := getLinkLocalNetworkConfigData(machineScope, nic)
+			if err != nil {
+				return nil, err
+			}
+			conf.MTU = guestMTU(nic.NetworkDevice)
+			networkConfigData = append(networkConfigData, *conf)
+			continue
+		}
 
 		if nic.IPv4PoolRef != nil {
 			device := fmt.Sprintf("%s-%s", nic.Name, infrav1alpha1.DefaultSuffix)
@@ -215,10 +424,8 @@ func getAdditionalNetworkDevices(ctx context.Context, machineScope *scope.Machin
 			if err != nil {
 				return nil, errors.Wrapf(err, "unable to get network config data for device=%s", device)
 			}
-			if len(nic.DNSServers) != 0 {
-				config.DNSServers = nic.DNSServers
-			}
-			config = conf
+			config = *conf
+			config.DNSServers = dnsServers(machineScope, nic, infrav1alpha1.IPV4Format)
 		}
 
 		if nic.IPv6PoolRef != nil {
@@ -228,28 +435,71 @@ func getAdditionalNetworkDevices(ctx context.Context, machineScope *scope.Machin
 			if err != nil {
 				return nil, errors.Wrapf(err, "unable to get network config data for device=%s", device)
 			}
-			if len(nic.DNSServers) != 0 {
-				config.DNSServers = nic.DNSServers
+
+			if err := mergeIPv6NetworkConfigData(&config, conf); err != nil {
+				return nil, errors.Wrap(err, "additional network device")
 			}
+			config.DNSServers6 = dnsServers(machineScope, nic, infrav1alpha1.IPV6Format)
+		}
 
-			switch {
-			case len(config.MacAddress) == 0:
-				config = conf
-			case config.MacAddress != conf.MacAddress:
-				return nil, errors.New("additional network device ipv4 and ipv6 have different mac addresses")
-			default:
-				config.IPV6Address = conf.IPAddress
-				config.Gateway6 = conf.Gateway
+		if nic.DHCP6 {
+			if len(config.MacAddress) == 0 {
+				macAddress, err := deviceMACAddress(machineScope, nic.Name)
+				if err != nil {
+					return nil, err
+				}
+				config.MacAddress = macAddress
 			}
+			config.DHCP6 = true
+		}
+
+		if traffic {
+			// the default routes belong to the networks of the other devices.
+			config.Gateway, config.Gateway6 = "", ""
 		}
 
 		if len(config.MacAddress) > 0 {
-			networkConfigData = append(networkConfigData, *config)
+			config.RouteMetric = nic.RouteMetric
+			config.MTU = guestMTU(nic.NetworkDevice)
+			networkConfigData = append(networkConfigData, config)
 		}
 	}
 	return networkConfigData, nil
 }
 
+// guestMTU returns the MTU of a network device to configure in the guest,
+// or 0 if the device inherits the MTU of its bridge.
+func guestMTU(device infrav1alpha1.NetworkDevice) int32 {
+	if device.MTU > 1 {
+		return device.MTU
+	}
+	return 0
+}
+
+// getLinkLocalNetworkConfigData returns the network config of a device without IP addresses.
+func getLinkLocalNetworkConfigData(machineScope *scope.MachineScope, nic *infrav1alpha1.AdditionalNetworkDevice) (*cloudinit.NetworkConfigData, error) {
+	macAddress, err := deviceMACAddress(machineScope, nic.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cloudinit.NetworkConfigData{
+		MacAddress:    macAddress,
+		LinkLocalOnly: true,
+		IPv6LinkLocal: nic.LinkLocal == infrav1alpha1.LinkLocalModeIPv6,
+	}, nil
+}
+
+// deviceMACAddress returns the MAC address of a network device of the VM.
+func deviceMACAddress(machineScope *scope.MachineScope, device string) (string, error) {
+	nets := machineScope.VirtualMachine.VirtualMachineConfig.MergeNets()
+	macAddress := extractMACAddress(nets[device])
+	if len(macAddress) == 0 {
+		return "", errors.Errorf("unable to extract mac address, device=%s", device)
+	}
+	return macAddress, nil
+}
+
 func vmHasMacAddresses(machineScope *scope.MachineScope) bool {
 	nets := machineScope.VirtualMachine.VirtualMachineConfig.MergeNets()
 	if len(nets) == 0 {
@@ -20,9 +20,9 @@ import (
 	"context"
 	"testing"
 
-	"github.com/luthermonson/go-proxmox"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -30,6 +30,7 @@ import (
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/inject"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 )
 
@@ -59,7 +60,7 @@ func TestReconcileBootstrapData_MissingMACAddress(t *testing.T) {
 
 func TestReconcileBootstrapData_NoNetworkConfig_UpdateStatus(t *testing.T) {
 	machineScope, _, kubeClient := setupReconcilerTest(t)
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *scope.MachineScope, _ proxmox.NodeTransport, _ []byte, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -76,6 +77,94 @@ func TestReconcileBootstrapData_NoNetworkConfig_UpdateStatus(t *testing.T) {
 	require.False(t, requeue)
 	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
 	require.True(t, *machineScope.ProxmoxMachine.Status.BootstrapDataProvided)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, machineScope.GetBootstrapSecret(context.Background(), secret))
+	require.Equal(t, secret.ResourceVersion, machineScope.ProxmoxMachine.Status.ObservedBootstrapSecretVersion)
+}
+
+func TestReconcileBootstrapData_ConfigDrive2(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CloudInitFormat = infrav1alpha1.CloudInitFormatConfigDrive2
+	var metadata, network cloudinit.Renderer
+	getISOInjector = func(_ *scope.MachineScope, _ proxmox.NodeTransport, _ []byte, m, n cloudinit.Renderer) isoInjector {
+		metadata, network = m, n
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope)
+
+	_, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.IsType(t, &cloudinit.ConfigDriveMetadata{}, metadata)
+	require.IsType(t, &cloudinit.ConfigDriveNetworkData{}, network)
+}
+
+func TestReconcileBootstrapData_StableInstanceID(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.UID = "2f6b3fd2-6d2a-4d35-a5bc-4c4e2b8a7a53"
+	var metadata cloudinit.Renderer
+	getISOInjector = func(_ *scope.MachineScope, _ proxmox.NodeTransport, _ []byte, m, _ cloudinit.Renderer) isoInjector {
+		metadata = m
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope)
+
+	_, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	rendered, err := metadata.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(rendered), "instance-id: 2f6b3fd2-6d2a-4d35-a5bc-4c4e2b8a7a53")
+	require.Equal(t, "2f6b3fd2-6d2a-4d35-a5bc-4c4e2b8a7a53", machineScope.ProxmoxMachine.Status.CloudInitInstanceID)
+
+	// the data is rendered again after the machine was moved to another management cluster.
+	machineScope.ProxmoxMachine.UID = "9a0e4f0c-0f7e-4d56-8a8e-3b1d3f8f1c11"
+	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = nil
+	_, err = reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	rendered, err = metadata.Render()
+	require.NoError(t, err)
+	require.Contains(t, string(rendered), "instance-id: 2f6b3fd2-6d2a-4d35-a5bc-4c4e2b8a7a53")
+}
+
+func TestReconcileBootstrapData_Windows(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.GuestOS = infrav1alpha1.GuestOSWindows
+	machineScope.ProxmoxMachine.Spec.CloudInitFormat = infrav1alpha1.CloudInitFormatNoCloudNet
+	machineScope.InfraCluster.ProxmoxCluster.Spec.Proxy = &infrav1alpha1.ProxyConfig{HTTPProxy: "http://proxy:3128"}
+	var userData []byte
+	var metadata, network cloudinit.Renderer
+	getISOInjector = func(_ *scope.MachineScope, _ proxmox.NodeTransport, d []byte, m, n cloudinit.Renderer) isoInjector {
+		userData, metadata, network = d, m, n
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	vm := newVMWithNets("e1000=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope)
+
+	_, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.IsType(t, &cloudinit.ConfigDriveMetadata{}, metadata)
+	require.IsType(t, &cloudinit.ConfigDriveNetworkData{}, network)
+	require.NotContains(t, string(userData), "proxy")
 }
 
 func TestReconcileBootstrapData_UpdateStatus(t *testing.T) {
@@ -96,7 +185,7 @@ func TestReconcileBootstrapData_UpdateStatus(t *testing.T) {
 	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
 	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.100.10.10")
 	createBootstrapSecret(t, kubeClient, machineScope)
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *scope.MachineScope, _ proxmox.NodeTransport, _ []byte, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -108,14 +197,78 @@ func TestReconcileBootstrapData_UpdateStatus(t *testing.T) {
 	require.True(t, *machineScope.ProxmoxMachine.Status.BootstrapDataProvided)
 }
 
+func TestReconcileBootstrapData_RequireApproval(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.RequireBootstrapApproval = true
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope)
+	getISOInjector = func(_ *scope.MachineScope, _ proxmox.NodeTransport, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+		return FakeISOInjector{}
+	}
+	t.Cleanup(func() { getISOInjector = defaultISOInjector })
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.WaitingForBootstrapApprovalReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.Nil(t, machineScope.ProxmoxMachine.Status.BootstrapDataProvided)
+
+	machineScope.SetAnnotation(infrav1alpha1.BootstrapApprovedAnnotation, "true")
+
+	requeue, err = reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, *machineScope.ProxmoxMachine.Status.BootstrapDataProvided)
+}
+
 func TestGetBootstrapData_MissingSecretName(t *testing.T) {
 	machineScope, _, _ := setupReconcilerTest(t)
 
-	data, err := getBootstrapData(context.Background(), machineScope)
+	data, _, err := getBootstrapData(context.Background(), machineScope)
 	require.Error(t, err)
 	require.Nil(t, data)
 }
 
+func TestGetFiles(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Files = []infrav1alpha1.File{
+		{Path: "/etc/sysctl.d/99-custom.conf", Content: "vm.swappiness=0", Permissions: "0644"},
+		{Path: "/etc/containers/registries.conf", ContentFrom: &infrav1alpha1.FileSource{
+			Secret: infrav1alpha1.SecretKeySelector{Name: "registries", Key: "registries.conf"},
+		}, Owner: "root:root"},
+	}
+	require.NoError(t, kubeClient.Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registries", Namespace: machineScope.Namespace()},
+		Data:       map[string][]byte{"registries.conf": []byte("unqualified-search-registries = []")},
+	}))
+
+	files, err := getFiles(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Equal(t, []cloudinit.File{
+		{Path: "/etc/sysctl.d/99-custom.conf", Content: []byte("vm.swappiness=0"), Permissions: "0644"},
+		{Path: "/etc/containers/registries.conf", Content: []byte("unqualified-search-registries = []"), Owner: "root:root"},
+	}, files)
+}
+
+func TestGetFiles_MissingSecretKey(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Files = []infrav1alpha1.File{
+		{Path: "/etc/motd", ContentFrom: &infrav1alpha1.FileSource{
+			Secret: infrav1alpha1.SecretKeySelector{Name: "motd", Key: "motd"},
+		}},
+	}
+	require.NoError(t, kubeClient.Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "motd", Namespace: machineScope.Namespace()},
+	}))
+
+	_, err := getFiles(context.Background(), machineScope)
+	require.Error(t, err)
+}
+
 func TestGetNetworkConfigDataForDevice_MissingIPAddress(t *testing.T) {
 	machineScope, _, _ := setupReconcilerTest(t)
 	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
@@ -151,7 +304,7 @@ func TestReconcileBootstrapData_DualStack(t *testing.T) {
 	createIP6AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "2001:db8::2")
 
 	createBootstrapSecret(t, kubeClient, machineScope)
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *scope.MachineScope, _ proxmox.NodeTransport, _ []byte, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -201,7 +354,7 @@ func TestReconcileBootstrapData_DualStack_AdditionalDevices(t *testing.T) {
 	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.0.0.10")
 	createIP6AddressResource(t, kubeClient, machineScope, "net1", "2001:db8::9")
 	createBootstrapSecret(t, kubeClient, machineScope)
-	getISOInjector = func(_ *proxmox.VirtualMachine, _ []byte, _, _ cloudinit.Renderer) isoInjector {
+	getISOInjector = func(_ *scope.MachineScope, _ proxmox.NodeTransport, _ []byte, _, _ cloudinit.Renderer) isoInjector {
 		return FakeISOInjector{}
 	}
 	t.Cleanup(func() { getISOInjector = defaultISOInjector })
@@ -213,6 +366,241 @@ func TestReconcileBootstrapData_DualStack_AdditionalDevices(t *testing.T) {
 	require.True(t, *machineScope.ProxmoxMachine.Status.BootstrapDataProvided)
 }
 
+func TestGetNetworkConfigData_DNSServersPerFamily(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.DNSServers = []string{"10.0.0.53"}
+	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6DNSServers = []string{"2001:db8::53"}
+	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config = &v1alpha2.InClusterIPPoolSpec{
+		Addresses: []string{"2001:db8::/64"},
+		Prefix:    64,
+		Gateway:   "2001:db8::1",
+	}
+
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio")},
+				Name:          "net1",
+				DNSServers:    []string{"1.2.3.4"},
+				IPv6PoolRef: &corev1.TypedLocalObjectReference{
+					APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+					Kind:     "GlobalInClusterIPPool",
+					Name:     "sample",
+				},
+				IPv4PoolRef: &corev1.TypedLocalObjectReference{
+					APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+					Kind:     "InClusterIPPool",
+					Name:     "sample",
+				},
+			},
+		},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+	createIPPools(t, kubeClient, machineScope)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createIP6AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "2001:db8::2")
+	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.0.0.10")
+	createIP6AddressResource(t, kubeClient, machineScope, "net1", "2001:db8::9")
+
+	networkConfigData, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, networkConfigData, 2)
+
+	// the default device uses the nameservers of the cluster.
+	require.Equal(t, []string{"10.0.0.53"}, networkConfigData[0].DNSServers)
+	require.Equal(t, []string{"2001:db8::53"}, networkConfigData[0].DNSServers6)
+	require.Equal(t, "2001:db8::2/64", networkConfigData[0].IPV6Address)
+
+	// the nameservers of the device are used for both address families, unless it has IPv6 nameservers.
+	require.Equal(t, []string{"1.2.3.4"}, networkConfigData[1].DNSServers)
+	require.Equal(t, []string{"1.2.3.4"}, networkConfigData[1].DNSServers6)
+
+	machineScope.ProxmoxMachine.Spec.Network.AdditionalDevices[0].IPv6DNSServers = []string{"2001:db8::1:53"}
+	networkConfigData, err = getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.2.3.4"}, networkConfigData[1].DNSServers)
+	require.Equal(t, []string{"2001:db8::1:53"}, networkConfigData[1].DNSServers6)
+}
+
+func TestGetNetworkConfigData_RouteMetrics(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio")},
+				Name:          "net1",
+				IPv4PoolRef: &corev1.TypedLocalObjectReference{
+					APIGroup: ptr.To("ipam.cluster.x-k8s.io"),
+					Kind:     "InClusterIPPool",
+					Name:     "sample",
+				},
+			},
+		},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+	createIPPools(t, kubeClient, machineScope)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.0.0.10")
+
+	networkConfigData, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, networkConfigData, 2)
+	require.Equal(t, ptr.To(int32(100)), networkConfigData[0].RouteMetric)
+	require.Equal(t, ptr.To(int32(200)), networkConfigData[1].RouteMetric)
+
+	// explicit metrics take precedence, so the additional device becomes the primary uplink.
+	machineScope.ProxmoxMachine.Spec.Network.AdditionalDevices[0].RouteMetric = ptr.To(int32(50))
+	networkConfigData, err = getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Equal(t, ptr.To(int32(100)), networkConfigData[0].RouteMetric)
+	require.Equal(t, ptr.To(int32(50)), networkConfigData[1].RouteMetric)
+}
+
+func TestGetNetworkConfigData_LinkLocal(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio")},
+				Name:          "net1",
+				LinkLocal:     infrav1alpha1.LinkLocalModeIPv6,
+			},
+		},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+
+	networkConfigData, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, networkConfigData, 2)
+	require.Equal(t, cloudinit.NetworkConfigData{MacAddress: "AA:23:64:4D:84:CD", LinkLocalOnly: true, IPv6LinkLocal: true}, networkConfigData[1])
+	require.Nil(t, networkConfigData[0].RouteMetric)
+}
+
+func TestGetNetworkConfigData_Unmanaged(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1"},
+				Name:          "net1",
+				Unmanaged:     true,
+			},
+		},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+
+	networkConfigData, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, networkConfigData, 1)
+	require.Equal(t, "A6:23:64:4D:84:CB", networkConfigData[0].MacAddress)
+	require.Empty(t, ipAddressClaims(machineScope)[1:])
+}
+
+func TestGetNetworkConfigData_TrafficProfile(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", VLAN: 100, MTU: 9000},
+				Name:          "net1",
+				Profile:       infrav1alpha1.NetworkDeviceProfileTraffic,
+				IPv4PoolRef:   &corev1.TypedLocalObjectReference{Kind: "GlobalInClusterIPPool", Name: "ceph"},
+			},
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr2", MTU: 1},
+				Name:          "net2",
+				Profile:       infrav1alpha1.NetworkDeviceProfileTraffic,
+			},
+		},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1,tag=100,mtu=9000", "virtio=AA:23:64:4D:84:CE,bridge=vmbr2,mtu=1")
+	machineScope.SetVirtualMachine(vm)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createIP4AddressResource(t, kubeClient, machineScope, "net1", "10.100.10.10")
+
+	networkConfigData, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, networkConfigData, 3)
+	require.Equal(t, "10.100.10.10/24", networkConfigData[1].IPAddress)
+	require.Empty(t, networkConfigData[1].Gateway)
+	require.Equal(t, int32(9000), networkConfigData[1].MTU)
+	require.Equal(t, cloudinit.NetworkConfigData{MacAddress: "AA:23:64:4D:84:CE", LinkLocalOnly: true}, networkConfigData[2])
+}
+
+func TestGetNetworkConfigData_DHCP6(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", DHCP6: true},
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{
+				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", DHCP6: true},
+				Name:          "net1",
+			},
+		},
+	}
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0", "virtio=AA:23:64:4D:84:CD,bridge=vmbr1")
+	machineScope.SetVirtualMachine(vm)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+
+	networkConfigData, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Len(t, networkConfigData, 2)
+	require.True(t, networkConfigData[0].DHCP6)
+	require.Equal(t, "10.10.10.10/24", networkConfigData[0].IPAddress)
+	require.Equal(t, cloudinit.NetworkConfigData{MacAddress: "AA:23:64:4D:84:CD", DHCP6: true}, networkConfigData[1])
+}
+
+func TestGetNetworkConfigData_DHCP(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv4Config = nil
+	machineScope.InfraCluster.ProxmoxCluster.Spec.DHCPMode = infrav1alpha1.DHCPModeDualStack
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	machineScope.SetVirtualMachine(vm)
+
+	networkConfigData, err := getNetworkConfigData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.Equal(t, []cloudinit.NetworkConfigData{{
+		MacAddress:  "A6:23:64:4D:84:CB",
+		DHCP4:       true,
+		DHCP6:       true,
+		AcceptRA:    true,
+		DNSServers:  machineScope.InfraCluster.ProxmoxCluster.Spec.DNSServers,
+		DNSServers6: machineScope.InfraCluster.ProxmoxCluster.Spec.DNSServers,
+	}}, networkConfigData)
+}
+
+func TestSetDefaultRouteMetrics_SingleGateway(t *testing.T) {
+	networkConfigData := []cloudinit.NetworkConfigData{{MacAddress: "A6:23:64:4D:84:CB", Gateway: "10.0.0.1"}, {MacAddress: "AA:23:64:4D:84:CD"}}
+	setDefaultRouteMetrics(networkConfigData)
+	require.Nil(t, networkConfigData[0].RouteMetric)
+	require.Nil(t, networkConfigData[1].RouteMetric)
+}
+
+func TestSetDefaultRouteMetrics_DeviceWithoutGateway(t *testing.T) {
+	networkConfigData := []cloudinit.NetworkConfigData{
+		{MacAddress: "A6:23:64:4D:84:CB", Gateway: "10.0.0.1"},
+		{MacAddress: "AA:23:64:4D:84:CD"},
+		{MacAddress: "AA:23:64:4D:84:CE", Gateway6: "2001:db8::1"},
+	}
+	setDefaultRouteMetrics(networkConfigData)
+	require.Equal(t, ptr.To(int32(100)), networkConfigData[0].RouteMetric)
+	require.Nil(t, networkConfigData[1].RouteMetric)
+	require.Equal(t, ptr.To(int32(300)), networkConfigData[2].RouteMetric)
+}
+
 func TestVMHasMacAddress(t *testing.T) {
 	machineScope := &scope.MachineScope{VirtualMachine: newRunningVM()}
 	require.False(t, vmHasMacAddresses(machineScope))
@@ -257,8 +645,37 @@ func TestReconcileBootstrapDataMissingNetworkConfig(t *testing.T) {
 }
 
 func TestDefaultISOInjector(t *testing.T) {
-	injector := defaultISOInjector(newRunningVM(), []byte("data"), cloudinit.NewMetadata(biosUUID, "test"), cloudinit.NewNetworkConfig(nil))
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.SetVirtualMachine(newRunningVM())
+	machineScope.InfraCluster.ProxmoxCluster.Spec.CloudInitStorage = "cephfs"
+
+	injector := defaultISOInjector(machineScope, nil, []byte("data"), cloudinit.NewMetadata(biosUUID, "test"), cloudinit.NewNetworkConfig(nil))
 
 	require.NotEmpty(t, injector)
 	require.Equal(t, []byte("data"), injector.(*inject.ISOInjector).BootstrapData)
+	require.Equal(t, proxmoxClient, injector.(*inject.ISOInjector).Client)
+	require.Equal(t, "cephfs", injector.(*inject.ISOInjector).Storage)
+	require.Empty(t, injector.(*inject.ISOInjector).Device)
+}
+
+func TestDefaultISOInjector_Windows(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.SetVirtualMachine(newRunningVM())
+	machineScope.ProxmoxMachine.Spec.GuestOS = infrav1alpha1.GuestOSWindows
+
+	injector := defaultISOInjector(machineScope, nil, []byte("data"), cloudinit.NewConfigDriveMetadata(biosUUID, "test"), cloudinit.NewConfigDriveNetworkData(nil))
+
+	require.Equal(t, infrav1alpha1.CloudInitFormatConfigDrive2, injector.(*inject.ISOInjector).Format)
+	require.Equal(t, inject.WindowsCloudInitISODevice, injector.(*inject.ISOInjector).Device)
+}
+
+func TestDefaultISOInjector_CloudInitDevice(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.SetVirtualMachine(newRunningVM())
+	machineScope.ProxmoxMachine.Spec.GuestOS = infrav1alpha1.GuestOSWindows
+	machineScope.ProxmoxMachine.Spec.CloudInitDevice = "sata1"
+
+	injector := defaultISOInjector(machineScope, nil, []byte("data"), cloudinit.NewConfigDriveMetadata(biosUUID, "test"), cloudinit.NewConfigDriveNetworkData(nil))
+
+	require.Equal(t, "sata1", injector.(*inject.ISOInjector).Device)
 }
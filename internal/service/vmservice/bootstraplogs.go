@@ -0,0 +1,143 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+const (
+	// defaultBootstrapLogLines is the number of last lines collected of each bootstrap log.
+	defaultBootstrapLogLines = 20
+
+	// bootstrapLogExcerptBytes limits the excerpt of the bootstrap logs, which is the message of an event
+	// and a condition as well.
+	bootstrapLogExcerptBytes = 2048
+)
+
+var (
+	// defaultBootstrapLogFiles include the output of kubeadm, which cloud-init runs.
+	defaultBootstrapLogFiles = []string{"/var/log/cloud-init-output.log"}
+
+	defaultWindowsBootstrapLogFiles = []string{`C:\Program Files\Cloudbase Solutions\Cloudbase-Init\log\cloudbase-init.log`}
+)
+
+// BootstrapLogsCheckInterval returns the duration until the bootstrap logs of a ready machine, whose node did not join
+// the cluster yet, are collected, or zero if the logs are not collected.
+func BootstrapLogsCheckInterval(machineScope *scope.MachineScope) time.Duration {
+	collection := machineScope.ProxmoxMachine.Spec.BootstrapLogCollection
+	if collection == nil || conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition) != infrav1alpha1.WaitingForNodeReason {
+		return 0
+	}
+	since := conditions.GetLastTransitionTime(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition)
+	if since == nil {
+		return 0
+	}
+	if remaining := time.Until(since.Add(collection.Deadline.Duration)); remaining > time.Second {
+		return remaining
+	}
+	return time.Second
+}
+
+// ReconcileBootstrapLogs reports in the NodeJoined condition whether the node of a ready machine with bootstrap log
+// collection joined the cluster. Once it did not join within the deadline, or the cloud-init check failed, the last
+// lines of the bootstrap logs are read through the QEMU guest agent and added to the condition. The logs are collected
+// once, and the message of the condition is returned so it can be recorded in an event; otherwise it returns an empty string.
+func ReconcileBootstrapLogs(ctx context.Context, machineScope *scope.MachineScope) string {
+	collection := machineScope.ProxmoxMachine.Spec.BootstrapLogCollection
+	if collection == nil {
+		conditions.Delete(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition)
+		return ""
+	}
+	if machineScope.Machine.Status.NodeRef != nil {
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition)
+		return ""
+	}
+
+	switch conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition) {
+	case infrav1alpha1.BootstrapFailedReason:
+		return ""
+	case infrav1alpha1.WaitingForNodeReason:
+	default:
+		// the transition time marks when the machine became ready.
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition, infrav1alpha1.WaitingForNodeReason, clusterv1.ConditionSeverityInfo, "")
+	}
+
+	var cause string
+	since := conditions.GetLastTransitionTime(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition)
+	switch {
+	case conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition) == infrav1alpha1.CloudInitFailedReason:
+		cause = "cloud-init failed"
+	case since != nil && time.Since(since.Time) >= collection.Deadline.Duration:
+		cause = fmt.Sprintf("node did not join the cluster within %s", collection.Deadline.Duration)
+	default:
+		return ""
+	}
+
+	message := cause + ", bootstrap logs:\n" + collectBootstrapLogs(ctx, machineScope, collection)
+	machineScope.Info("collected bootstrap logs", "cause", cause)
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition, infrav1alpha1.BootstrapFailedReason, clusterv1.ConditionSeverityWarning, "%s", message)
+	return message
+}
+
+// collectBootstrapLogs returns the last lines of the bootstrap log files of the VM, like tail does for multiple files.
+// Each file gets an equal share of the excerpt. Files which cannot be read are reported with the error.
+func collectBootstrapLogs(ctx context.Context, machineScope *scope.MachineScope, collection *infrav1alpha1.BootstrapLogCollection) string {
+	files := collection.Files
+	if len(files) == 0 {
+		files = defaultBootstrapLogFiles
+		if machineScope.ProxmoxMachine.Spec.GuestOS == infrav1alpha1.GuestOSWindows {
+			files = defaultWindowsBootstrapLogFiles
+		}
+	}
+	lines := int(collection.Lines)
+	if lines <= 0 {
+		lines = defaultBootstrapLogLines
+	}
+
+	excerpts := make([]string, 0, len(files))
+	for _, file := range files {
+		content, err := machineScope.InfraCluster.ProxmoxClient.ReadGuestFile(ctx, machineScope.VirtualMachine, file)
+		if err != nil {
+			content = err.Error()
+		}
+		excerpts = append(excerpts, fmt.Sprintf("==> %s <==\n%s", file, tail(content, lines, bootstrapLogExcerptBytes/len(files))))
+	}
+	return strings.Join(excerpts, "\n")
+}
+
+// tail returns the last lines of the content, but at most the given number of bytes.
+func tail(content string, lines, bytes int) string {
+	all := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	result := strings.Join(all, "\n")
+	if len(result) > bytes {
+		result = "..." + result[len(result)-bytes+3:]
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func TestReconcileBootstrapLogs_Deadline(t *testing.T) {
+	ctx := context.Background()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.BootstrapLogCollection = &infrav1alpha1.BootstrapLogCollection{
+		Deadline: metav1.Duration{Duration: 10 * time.Minute},
+		Files:    []string{"/var/log/cloud-init-output.log", "/var/log/missing.log"},
+		Lines:    2,
+	}
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	// the mock fails the test if the logs are collected before the deadline.
+	require.Empty(t, ReconcileBootstrapLogs(ctx, machineScope))
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition)
+	require.InDelta(t, 10*time.Minute, BootstrapLogsCheckInterval(machineScope), float64(time.Minute))
+
+	for i := range machineScope.ProxmoxMachine.Status.Conditions {
+		machineScope.ProxmoxMachine.Status.Conditions[i].LastTransitionTime = metav1.NewTime(time.Now().Add(-11 * time.Minute))
+	}
+	proxmoxClient.EXPECT().ReadGuestFile(ctx, vm, "/var/log/cloud-init-output.log").Return("[init] Using Kubernetes version: v1.27.8\nerror execution phase preflight\nconnection refused\n", nil).Once()
+	proxmoxClient.EXPECT().ReadGuestFile(ctx, vm, "/var/log/missing.log").Return("", errors.New("No such file or directory")).Once()
+
+	message := ReconcileBootstrapLogs(ctx, machineScope)
+	require.Equal(t, "node did not join the cluster within 10m0s, bootstrap logs:\n"+
+		"==> /var/log/cloud-init-output.log <==\nerror execution phase preflight\nconnection refused\n"+
+		"==> /var/log/missing.log <==\nNo such file or directory", message)
+	require.Equal(t, infrav1alpha1.BootstrapFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition))
+	require.Equal(t, message, conditions.GetMessage(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition))
+	require.Zero(t, BootstrapLogsCheckInterval(machineScope))
+
+	// the logs are collected once.
+	require.Empty(t, ReconcileBootstrapLogs(ctx, machineScope))
+
+	// the node joined eventually.
+	machineScope.Machine.Status.NodeRef = &corev1.ObjectReference{Name: "test"}
+	require.Empty(t, ReconcileBootstrapLogs(ctx, machineScope))
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition))
+}
+
+func TestReconcileBootstrapLogs_CloudInitFailed(t *testing.T) {
+	ctx := context.Background()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.BootstrapLogCollection = &infrav1alpha1.BootstrapLogCollection{Deadline: metav1.Duration{Duration: 10 * time.Minute}}
+	machineScope.ProxmoxMachine.Spec.GuestOS = infrav1alpha1.GuestOSWindows
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition, infrav1alpha1.CloudInitFailedReason, clusterv1.ConditionSeverityError, "")
+
+	proxmoxClient.EXPECT().ReadGuestFile(ctx, vm, `C:\Program Files\Cloudbase Solutions\Cloudbase-Init\log\cloudbase-init.log`).Return(strings.Repeat("x", 3000), nil).Once()
+
+	message := ReconcileBootstrapLogs(ctx, machineScope)
+	require.True(t, strings.HasPrefix(message, "cloud-init failed, bootstrap logs:\n"))
+	require.True(t, strings.HasSuffix(message, "<==\n..."+strings.Repeat("x", bootstrapLogExcerptBytes-3)))
+}
+
+func TestReconcileBootstrapLogs_Disabled(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition, infrav1alpha1.WaitingForNodeReason, clusterv1.ConditionSeverityInfo, "")
+
+	require.Empty(t, ReconcileBootstrapLogs(context.Background(), machineScope))
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.NodeJoinedCondition))
+	require.Zero(t, BootstrapLogsCheckInterval(machineScope))
+}
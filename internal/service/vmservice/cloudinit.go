@@ -0,0 +1,128 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// DefaultCloudInitCheckInterval is the interval in which the status of cloud-init is polled,
+// until it finished.
+const DefaultCloudInitCheckInterval = 15 * time.Second
+
+// cloudInitStatusCommand blocks until cloud-init finished. It exits with 0 if cloud-init succeeded,
+// 1 if it failed and 2 if it finished with recoverable errors.
+var cloudInitStatusCommand = []string{"cloud-init", "status", "--wait"}
+
+// CloudInitCheckInterval returns the interval in which the status of cloud-init in the VM of the machine is polled,
+// or zero if the machine has no cloud-init check or the check finished.
+func CloudInitCheckInterval(machineScope *scope.MachineScope) time.Duration {
+	spec := machineScope.ProxmoxMachine.Spec
+	if spec.CloudInitCheck == nil || spec.GuestOS == infrav1alpha1.GuestOSWindows || cloudInitChecked(machineScope) {
+		return 0
+	}
+	return DefaultCloudInitCheckInterval
+}
+
+// cloudInitChecked returns whether cloud-init finished in the VM of the machine.
+func cloudInitChecked(machineScope *scope.MachineScope) bool {
+	return conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition) ||
+		conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition) == infrav1alpha1.CloudInitFailedReason
+}
+
+// reconcileCloudInitCheck runs `cloud-init status --wait` through the QEMU guest agent of machines with a cloud-init check,
+// and reports its result in the CloudInitCompleted condition. The command is started once and its process is polled
+// until it exited; it is started again if the agent lost it, e.g. since the VM rebooted. With a timeout, failed machines
+// and machines whose cloud-init did not finish in time are marked as failed, which a MachineHealthCheck remediates.
+func reconcileCloudInitCheck(ctx context.Context, machineScope *scope.MachineScope) {
+	check := machineScope.ProxmoxMachine.Spec.CloudInitCheck
+	status := &machineScope.ProxmoxMachine.Status
+	if check == nil || machineScope.ProxmoxMachine.Spec.GuestOS == infrav1alpha1.GuestOSWindows {
+		// cloudbase-init has no status command.
+		conditions.Delete(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition)
+		status.CloudInitStatusPID = nil
+		return
+	}
+	if cloudInitChecked(machineScope) || !machineScope.VirtualMachine.IsRunning() {
+		return
+	}
+
+	// the message is kept, as the transition time changes with it and marks when the check started.
+	if !conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition) {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition, infrav1alpha1.WaitingForCloudInitReason, clusterv1.ConditionSeverityInfo, "")
+	}
+
+	proxmoxClient := machineScope.InfraCluster.ProxmoxClient
+	if status.CloudInitStatusPID == nil {
+		pid, err := proxmoxClient.StartGuestCommand(ctx, machineScope.VirtualMachine, cloudInitStatusCommand)
+		if err != nil {
+			machineScope.V(4).Info("unable to check the status of cloud-init", "error", err.Error())
+			checkCloudInitTimeout(machineScope, check)
+			return
+		}
+		status.CloudInitStatusPID = ptr.To(pid)
+	}
+
+	result, err := proxmoxClient.GetGuestCommandStatus(ctx, machineScope.VirtualMachine, *status.CloudInitStatusPID)
+	if err != nil {
+		machineScope.V(4).Info("unable to get the status of cloud-init, starting the check again", "error", err.Error())
+		status.CloudInitStatusPID = nil
+		checkCloudInitTimeout(machineScope, check)
+		return
+	}
+	if !result.Exited {
+		checkCloudInitTimeout(machineScope, check)
+		return
+	}
+	status.CloudInitStatusPID = nil
+
+	if result.ExitCode == 0 || result.ExitCode == 2 {
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition)
+		return
+	}
+
+	output := strings.TrimSpace(strings.TrimLeft(result.Output+result.ErrorOutput, ".\n"))
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition, infrav1alpha1.CloudInitFailedReason, clusterv1.ConditionSeverityError,
+		"cloud-init status exited with %d: %s", result.ExitCode, output)
+	if check.Timeout != nil {
+		machineScope.SetFailureMessage(errors.Errorf("cloud-init failed: %s", output))
+		machineScope.SetFailureReason(capierrors.MachineStatusError("CloudInitFailed"))
+	}
+}
+
+// checkCloudInitTimeout marks the machine as failed if cloud-init did not finish within the timeout of the check.
+func checkCloudInitTimeout(machineScope *scope.MachineScope, check *infrav1alpha1.CloudInitCheck) {
+	if check.Timeout == nil || check.Timeout.Duration <= 0 {
+		return
+	}
+	if since := conditions.GetLastTransitionTime(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition); since != nil && time.Since(since.Time) >= check.Timeout.Duration {
+		machineScope.Error(errors.New("cloud-init did not finish"), "marking the machine as failed", "timeout", check.Timeout.Duration)
+		machineScope.SetFailureMessage(errors.Errorf("cloud-init did not finish within %s", check.Timeout.Duration))
+		machineScope.SetFailureReason(capierrors.MachineStatusError("CloudInitTimeout"))
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestReconcileCloudInitCheck_Completed(t *testing.T) {
+	ctx := context.Background()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CloudInitCheck = &infrav1alpha1.CloudInitCheck{}
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().StartGuestCommand(ctx, vm, []string{"cloud-init", "status", "--wait"}).Return(int64(42), nil).Once()
+	proxmoxClient.EXPECT().GetGuestCommandStatus(ctx, vm, int64(42)).Return(proxmox.GuestCommandStatus{}, nil).Once()
+
+	reconcileCloudInitCheck(ctx, machineScope)
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition)
+	require.Equal(t, int64(42), *machineScope.ProxmoxMachine.Status.CloudInitStatusPID)
+	require.Equal(t, DefaultCloudInitCheckInterval, CloudInitCheckInterval(machineScope))
+
+	// the running command is polled.
+	proxmoxClient.EXPECT().GetGuestCommandStatus(ctx, vm, int64(42)).Return(proxmox.GuestCommandStatus{Exited: true, ExitCode: 2}, nil).Once()
+
+	reconcileCloudInitCheck(ctx, machineScope)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition))
+	require.Nil(t, machineScope.ProxmoxMachine.Status.CloudInitStatusPID)
+	require.Zero(t, CloudInitCheckInterval(machineScope))
+
+	// the mock fails the test if the check runs again.
+	reconcileCloudInitCheck(ctx, machineScope)
+}
+
+func TestReconcileCloudInitCheck_Failed(t *testing.T) {
+	ctx := context.Background()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CloudInitCheck = &infrav1alpha1.CloudInitCheck{Timeout: &metav1.Duration{Duration: 10 * time.Minute}}
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().StartGuestCommand(ctx, vm, []string{"cloud-init", "status", "--wait"}).Return(int64(42), nil).Once()
+	proxmoxClient.EXPECT().GetGuestCommandStatus(ctx, vm, int64(42)).Return(proxmox.GuestCommandStatus{Exited: true, ExitCode: 1, Output: "....\nstatus: error\n"}, nil).Once()
+
+	reconcileCloudInitCheck(ctx, machineScope)
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition)
+	require.Equal(t, infrav1alpha1.CloudInitFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition))
+	require.Equal(t, "cloud-init status exited with 1: status: error", conditions.GetMessage(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition))
+	require.True(t, machineScope.HasFailed())
+	require.Equal(t, "CloudInitFailed", string(*machineScope.ProxmoxMachine.Status.FailureReason))
+	require.Zero(t, CloudInitCheckInterval(machineScope))
+}
+
+func TestReconcileCloudInitCheck_Timeout(t *testing.T) {
+	ctx := context.Background()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CloudInitCheck = &infrav1alpha1.CloudInitCheck{Timeout: &metav1.Duration{Duration: 10 * time.Minute}}
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().StartGuestCommand(ctx, vm, []string{"cloud-init", "status", "--wait"}).Return(int64(0), errors.New("QEMU guest agent is not running")).Once()
+
+	reconcileCloudInitCheck(ctx, machineScope)
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition)
+	require.False(t, machineScope.HasFailed())
+
+	// the agent lost the process, e.g. since the VM rebooted, and the check started before the timeout.
+	machineScope.ProxmoxMachine.Status.CloudInitStatusPID = ptr.To[int64](42)
+	for i := range machineScope.ProxmoxMachine.Status.Conditions {
+		machineScope.ProxmoxMachine.Status.Conditions[i].LastTransitionTime = metav1.NewTime(time.Now().Add(-11 * time.Minute))
+	}
+	proxmoxClient.EXPECT().GetGuestCommandStatus(ctx, vm, int64(42)).Return(proxmox.GuestCommandStatus{}, errors.New("Invalid parameter 'pid'")).Once()
+
+	reconcileCloudInitCheck(ctx, machineScope)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.CloudInitStatusPID)
+	require.True(t, machineScope.HasFailed())
+	require.Equal(t, "CloudInitTimeout", string(*machineScope.ProxmoxMachine.Status.FailureReason))
+}
+
+func TestReconcileCloudInitCheck_Disabled(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.SetVirtualMachine(newRunningVM())
+	machineScope.ProxmoxMachine.Status.CloudInitStatusPID = ptr.To[int64](42)
+	conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition)
+
+	reconcileCloudInitCheck(context.Background(), machineScope)
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.CloudInitCompletedCondition))
+	require.Nil(t, machineScope.ProxmoxMachine.Status.CloudInitStatusPID)
+	require.Zero(t, CloudInitCheckInterval(machineScope))
+}
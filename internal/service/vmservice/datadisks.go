@@ -0,0 +1,215 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// reconcileDataDisks adds the data disks of the spec to the VM before it is started.
+// Proxmox allocates the volumes of disks with the Delete deletion policy, which are owned by the VM.
+func reconcileDataDisks(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	disks := machineScope.ProxmoxMachine.Spec.Disks
+	if disks == nil || len(disks.AdditionalVolumes) == 0 {
+		return false, nil
+	}
+
+	vm := machineScope.VirtualMachine
+	if vm.IsRunning() || machineScope.ProxmoxMachine.Status.Ready {
+		// We only want to do this before the machine was started or is ready
+		return false, nil
+	}
+
+	attached := attachedVolumes(machineScope)
+	validated := make(map[string]bool)
+	var options []proxmox.VirtualMachineOption
+	for _, disk := range disks.AdditionalVolumes {
+		if attached[disk.Disk] != "" {
+			continue
+		}
+
+		var volume string
+		if disk.DeletionPolicy == infrav1alpha1.DiskDeletionPolicyDetach {
+			if volume, err = detachableVolume(ctx, machineScope, disk); err != nil {
+				return false, err
+			}
+		} else {
+			if !validated[disk.Storage] {
+				if _, err := imagesStorage(ctx, machineScope, disk.Storage); err != nil {
+					return false, err
+				}
+				validated[disk.Storage] = true
+			}
+			volume = fmt.Sprintf("%s:%d", disk.Storage, disk.SizeGB)
+		}
+		options = append(options, proxmox.VirtualMachineOption{Name: disk.Disk, Value: volume})
+	}
+	if len(options) == 0 {
+		return false, nil
+	}
+
+	machineScope.V(4).Info("reconciling data disks")
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, vm, options...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to configure data disks of VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// detachableVolume returns the volume of a data disk with the Detach deletion policy. A volume which was
+// detached from a deleted machine of the same group is attached again, otherwise a volume is allocated
+// which is owned by the volume owner of the cluster, so Proxmox does not destroy it along with the VM.
+// The volume is recorded in the status of the machine, so that it is detached when the machine is deleted.
+func detachableVolume(ctx context.Context, machineScope *scope.MachineScope, disk infrav1alpha1.DataDisk) (string, error) {
+	for _, v := range machineScope.ProxmoxMachine.Status.Volumes {
+		if v.Disk == disk.Disk {
+			// the volume was allocated already, but it is not attached yet.
+			return v.Volume, nil
+		}
+	}
+
+	node := machineScope.VirtualMachine.Node
+	client := machineScope.InfraCluster.ProxmoxClient
+	storage, err := imagesStorage(ctx, machineScope, disk.Storage)
+	if err != nil {
+		return "", err
+	}
+
+	cluster := machineScope.InfraCluster.ProxmoxCluster
+	group := machineGroup(machineScope.Machine)
+	var volume string
+	for _, detached := range cluster.Status.DetachedVolumes {
+		if detached.Group == group && detached.Disk == disk.Disk && volumeStorage(detached.Volume) == disk.Storage &&
+			(detached.Node == node || storage.Shared) {
+			volume = detached.Volume
+			break
+		}
+	}
+
+	if volume != "" {
+		// claim the volume, so that it is not attached to another machine as well.
+		machineScope.Info("attaching detached volume", "volume", volume, "disk", disk.Disk)
+		cluster.RemoveDetachedVolume(volume)
+		if err := machineScope.InfraCluster.PatchObject(); err != nil {
+			return "", err
+		}
+	} else {
+		ownerID := cluster.GetVolumeOwnerID()
+		filename := VolumeFilename(ownerID, fmt.Sprintf("%s-%s-%s", machineScope.Namespace(), machineScope.Name(), disk.Disk),
+			storage, infrav1alpha1.TargetStorageFormatRaw)
+		if volume, err = client.AllocateVolume(ctx, node, disk.Storage, ownerID, filename, disk.SizeGB); err != nil {
+			return "", err
+		}
+	}
+
+	machineScope.ProxmoxMachine.Status.Volumes = append(machineScope.ProxmoxMachine.Status.Volumes,
+		infrav1alpha1.MachineVolume{Disk: disk.Disk, Volume: volume})
+	return volume, nil
+}
+
+// imagesStorage returns the storage of the node of the VM, after making sure it can hold the disks of VMs,
+// so a misconfigured storage is reported before Proxmox rejects the volume.
+func imagesStorage(ctx context.Context, machineScope *scope.MachineScope, name string) (proxmox.StorageInfo, error) {
+	node := machineScope.VirtualMachine.Node
+	storage, err := machineScope.InfraCluster.ProxmoxClient.GetStorage(ctx, node, name)
+	if err != nil {
+		return storage, errors.Wrapf(err, "unable to get storage %s of node %s", name, node)
+	}
+	if err := storage.Validate(node, proxmox.StorageContentImages, ""); err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.VMProvisionFailedReason,
+			clusterv1.ConditionSeverityWarning, err.Error())
+		return storage, err
+	}
+	return storage, nil
+}
+
+// VolumeFilename returns the filename of a volume which is allocated owned by the given VMID.
+// On storages which store disk images as files, the extension defines the format of the volume.
+func VolumeFilename(ownerID int64, name string, storage proxmox.StorageInfo, format infrav1alpha1.TargetFileStorageFormat) string {
+	filename := fmt.Sprintf("vm-%d-%s", ownerID, name)
+	if storage.IsFileBased() {
+		filename += "." + string(format)
+	}
+	return filename
+}
+
+// SupportsFormat returns true if volumes of the given format can be allocated on the storage.
+func SupportsFormat(storage proxmox.StorageInfo, format infrav1alpha1.TargetFileStorageFormat) bool {
+	return format == infrav1alpha1.TargetStorageFormatRaw || storage.IsFileBased()
+}
+
+// recordDetachedVolumes records the volumes of the data disks with the Detach deletion policy in the status
+// of the cluster, once the VM of the machine was destroyed.
+func recordDetachedVolumes(machineScope *scope.MachineScope, node string) {
+	group := machineGroup(machineScope.Machine)
+	for _, v := range machineScope.ProxmoxMachine.Status.Volumes {
+		if machineScope.InfraCluster.ProxmoxCluster.AddDetachedVolume(infrav1alpha1.DetachedVolume{
+			Group:  group,
+			Disk:   v.Disk,
+			Volume: v.Volume,
+			Node:   node,
+		}) {
+			machineScope.Info("detached volume", "volume", v.Volume, "disk", v.Disk)
+		}
+	}
+	machineScope.ProxmoxMachine.Status.Volumes = nil
+}
+
+// machineGroup returns the MachineDeployment or the control plane of a machine, whose machines replace each other.
+func machineGroup(machine *clusterv1.Machine) string {
+	if name, ok := machine.GetLabels()[clusterv1.MachineDeploymentNameLabel]; ok {
+		return name
+	}
+	if name, ok := machine.GetLabels()[clusterv1.MachineControlPlaneNameLabel]; ok {
+		return name
+	}
+	return machine.GetName()
+}
+
+// attachedVolumes returns the volumes attached to the SCSI, VirtIO and SATA disks of the VM by their disk.
+func attachedVolumes(machineScope *scope.MachineScope) map[string]string {
+	config := machineScope.VirtualMachine.VirtualMachineConfig
+	attached := make(map[string]string)
+	for _, devices := range []map[string]string{config.MergeSCSIs(), config.MergeVirtIOs(), config.MergeSATAs()} {
+		for device, value := range devices {
+			if volume, _, _ := strings.Cut(value, ","); volume != "" {
+				attached[device] = volume
+			}
+		}
+	}
+	return attached
+}
+
+// volumeStorage returns the storage of a volume ID.
+func volumeStorage(volume string) string {
+	storage, _, _ := strings.Cut(volume, ":")
+	return storage
+}
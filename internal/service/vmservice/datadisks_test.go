@@ -0,0 +1,132 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestReconcileDataDisks_Delete(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DataDisk{
+			{Disk: "scsi1", Storage: "local-lvm", SizeGB: 20, DeletionPolicy: infrav1alpha1.DiskDeletionPolicyDelete},
+			{Disk: "scsi2", Storage: "local-lvm", SizeGB: 10, DeletionPolicy: infrav1alpha1.DiskDeletionPolicyDelete},
+		},
+	}
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SCSI2 = "local-lvm:vm-100-disk-1,size=10G"
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "node1", "local-lvm").Return(proxmox.StorageInfo{Name: "local-lvm", Type: "lvmthin", Content: []string{proxmox.StorageContentImages}, Enabled: true, Active: true}, nil).Once()
+	expectedOptions := []interface{}{proxmox.VirtualMachineOption{Name: "scsi1", Value: "local-lvm:20"}}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileDataDisks(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Empty(t, machineScope.ProxmoxMachine.Status.Volumes)
+}
+
+func TestReconcileDataDisks_DetachAllocates(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DataDisk{
+			{Disk: "scsi1", Storage: "nfs", SizeGB: 20, DeletionPolicy: infrav1alpha1.DiskDeletionPolicyDetach},
+		},
+	}
+	vm := newStoppedVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "node1", "nfs").Return(proxmox.StorageInfo{Name: "nfs", Type: "nfs", Shared: true, Content: []string{proxmox.StorageContentImages}, Enabled: true, Active: true}, nil).Once()
+	proxmoxClient.EXPECT().AllocateVolume(context.Background(), "node1", "nfs", int64(infrav1alpha1.DefaultVolumeOwnerID), "vm-9999-default-test-scsi1.raw", int32(20)).
+		Return("nfs:9999/vm-9999-default-test-scsi1.raw", nil).Once()
+	expectedOptions := []interface{}{proxmox.VirtualMachineOption{Name: "scsi1", Value: "nfs:9999/vm-9999-default-test-scsi1.raw"}}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileDataDisks(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, []infrav1alpha1.MachineVolume{{Disk: "scsi1", Volume: "nfs:9999/vm-9999-default-test-scsi1.raw"}}, machineScope.ProxmoxMachine.Status.Volumes)
+}
+
+func TestReconcileDataDisks_DetachReattaches(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.Machine.Labels = map[string]string{clusterv1.MachineDeploymentNameLabel: "workers"}
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DataDisk{
+			{Disk: "scsi1", Storage: "local-lvm", SizeGB: 20, DeletionPolicy: infrav1alpha1.DiskDeletionPolicyDetach},
+		},
+	}
+	cluster := machineScope.InfraCluster.ProxmoxCluster
+	// the volume on another node cannot be attached, as the storage is not shared.
+	cluster.AddDetachedVolume(infrav1alpha1.DetachedVolume{Group: "workers", Disk: "scsi1", Volume: "local-lvm:vm-9999-default-old-scsi1", Node: "node2"})
+	cluster.AddDetachedVolume(infrav1alpha1.DetachedVolume{Group: "workers", Disk: "scsi1", Volume: "local-lvm:vm-9999-default-older-scsi1", Node: "node1"})
+	vm := newStoppedVM()
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "node1", "local-lvm").Return(proxmox.StorageInfo{Name: "local-lvm", Type: "lvmthin", Content: []string{proxmox.StorageContentImages}, Enabled: true, Active: true}, nil).Once()
+	expectedOptions := []interface{}{proxmox.VirtualMachineOption{Name: "scsi1", Value: "local-lvm:vm-9999-default-older-scsi1"}}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileDataDisks(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, []infrav1alpha1.MachineVolume{{Disk: "scsi1", Volume: "local-lvm:vm-9999-default-older-scsi1"}}, machineScope.ProxmoxMachine.Status.Volumes)
+	require.Equal(t, []infrav1alpha1.DetachedVolume{{Group: "workers", Disk: "scsi1", Volume: "local-lvm:vm-9999-default-old-scsi1", Node: "node2"}}, cluster.Status.DetachedVolumes)
+}
+
+func TestReconcileDataDisks_StorageWithoutImages(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DataDisk{
+			{Disk: "scsi1", Storage: "local", SizeGB: 20, DeletionPolicy: infrav1alpha1.DiskDeletionPolicyDelete},
+		},
+	}
+	machineScope.SetVirtualMachine(newStoppedVM())
+
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "node1", "local").
+		Return(proxmox.StorageInfo{Name: "local", Type: "dir", Content: []string{proxmox.StorageContentISO}, Enabled: true, Active: true}, nil).Once()
+
+	// the VM is not configured, as Proxmox would reject the volume.
+	_, err := reconcileDataDisks(context.Background(), machineScope)
+	require.ErrorContains(t, err, "storage local on node node1 does not support content images")
+	require.Equal(t, infrav1alpha1.VMProvisionFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestReconcileDataDisks_Running(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DataDisk{
+			{Disk: "scsi1", Storage: "local-lvm", SizeGB: 20, DeletionPolicy: infrav1alpha1.DiskDeletionPolicyDelete},
+		},
+	}
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	requeue, err := reconcileDataDisks(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
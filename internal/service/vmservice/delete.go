@@ -26,18 +26,36 @@ import (
 	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
 )
 
+// deleteOptions remove everything belonging to a VM, as it is owned by the machine.
+// Otherwise, backup jobs, HA resources and detached disks would be left behind.
+var deleteOptions = proxmox.VMDeleteOptions{
+	// the machine was drained already, so there is no need to wait for the guest.
+	Stop:                     proxmox.VMStopOptions{Mode: proxmox.StopModeStop},
+	Purge:                    true,
+	DestroyUnreferencedDisks: true,
+}
+
 // DeleteVM implements the logic of destroying a VM.
 func DeleteVM(ctx context.Context, machineScope *scope.MachineScope) error {
 	vmID := machineScope.ProxmoxMachine.GetVirtualMachineID()
 	node := machineScope.LocateProxmoxNode()
 
-	if _, err := machineScope.InfraCluster.ProxmoxClient.DeleteVM(ctx, node, vmID); err != nil {
+	if _, err := machineScope.InfraCluster.ProxmoxClient.DeleteVM(ctx, node, vmID, deleteOptions); err != nil {
 		if VMNotFound(err) {
 			// remove machine from cluster status
 			machineScope.InfraCluster.ProxmoxCluster.RemoveNodeLocation(machineScope.Name(), util.IsControlPlaneMachine(machineScope.Machine))
+			// keep the volumes of data disks with the Detach deletion policy for replacement machines.
+			recordDetachedVolumes(machineScope, node)
+			if err := releasePersistentDisks(ctx, machineScope); err != nil {
+				return err
+			}
+			if err := deleteNoCloudData(ctx, machineScope); err != nil {
+				return err
+			}
 			// The VM is deleted so remove the finalizer.
 			ctrlutil.RemoveFinalizer(machineScope.ProxmoxMachine, infrav1alpha1.MachineFinalizer)
 			return machineScope.InfraCluster.PatchObject()
@@ -49,6 +67,13 @@ func DeleteVM(ctx context.Context, machineScope *scope.MachineScope) error {
 	return nil
 }
 
+// DeleteOrphanedVM destroys a VM which is not referenced by any ProxmoxMachine anymore.
+// The deletion is not awaited, a failed deletion is retried with the next collection.
+func DeleteOrphanedVM(ctx context.Context, client proxmox.Client, node string, vmID int64) error {
+	_, err := client.DeleteVM(ctx, node, vmID, deleteOptions)
+	return err
+}
+
 // VMNotFound checks if the given err is related to that the VM is not found in Proxmox.
 func VMNotFound(err error) bool {
 	return strings.Contains(err.Error(), "does not exist")
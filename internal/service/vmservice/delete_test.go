@@ -21,24 +21,37 @@ import (
 	"errors"
 	"testing"
 
-	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/stretchr/testify/require"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 )
 
 func TestDeleteVM_SuccessNotFound(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	vm := newRunningVM()
 	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
-	machineScope.InfraCluster.ProxmoxCluster.AddNodeLocation(infrav1alpha1.NodeLocation{
-		Machine: corev1.LocalObjectReference{Name: machineScope.Name()},
-		Node:    "node1",
-	}, false)
+	machineScope.InfraCluster.ProxmoxCluster.UpdateNodeLocation(machineScope.Name(), "node1", false)
 
-	proxmoxClient.EXPECT().DeleteVM(context.TODO(), "node1", int64(123)).Return(nil, errors.New("vm does not exist: some reason")).Once()
+	proxmoxClient.EXPECT().DeleteVM(context.TODO(), "node1", int64(123), deleteOptions).Return(nil, errors.New("vm does not exist: some reason")).Once()
 
 	require.NoError(t, DeleteVM(context.TODO(), machineScope))
 	require.Empty(t, machineScope.ProxmoxMachine.Finalizers)
 	require.Empty(t, machineScope.InfraCluster.ProxmoxCluster.GetNode(machineScope.Name(), false))
 }
+
+func TestDeleteVM_RecordsDetachedVolumes(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.Volumes = []infrav1alpha1.MachineVolume{{Disk: "scsi1", Volume: "local-lvm:vm-9999-default-test-scsi1"}}
+	machineScope.InfraCluster.ProxmoxCluster.UpdateNodeLocation(machineScope.Name(), "node1", false)
+
+	proxmoxClient.EXPECT().DeleteVM(context.TODO(), "node1", int64(123), deleteOptions).Return(nil, errors.New("vm does not exist: some reason")).Once()
+
+	require.NoError(t, DeleteVM(context.TODO(), machineScope))
+	require.Empty(t, machineScope.ProxmoxMachine.Status.Volumes)
+	require.Equal(t, []infrav1alpha1.DetachedVolume{
+		{Group: "test", Disk: "scsi1", Volume: "local-lvm:vm-9999-default-test-scsi1", Node: "node1"},
+	}, machineScope.InfraCluster.ProxmoxCluster.Status.DetachedVolumes)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// configDrift describes a difference between the VM config and the spec.
+type configDrift struct {
+	description string
+	option      proxmox.VirtualMachineOption
+}
+
+// reconcileConfigDrift compares the config of the VM of a ready machine with its spec.
+// Depending on the drift policy, drift is either reported or the spec is reapplied.
+func reconcileConfigDrift(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	if !machineScope.ProxmoxMachine.Status.Ready {
+		// drift can only happen after the VM was provisioned.
+		return false, nil
+	}
+
+	drifts := detectConfigDrift(machineScope)
+	if len(drifts) == 0 {
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMConfigInSyncCondition)
+		return false, nil
+	}
+
+	descriptions := make([]string, 0, len(drifts))
+	options := make([]proxmox.VirtualMachineOption, 0, len(drifts))
+	for _, d := range drifts {
+		descriptions = append(descriptions, d.description)
+		options = append(options, d.option)
+	}
+	message := strings.Join(descriptions, ", ")
+	machineScope.Info("VM config drifted", "drift", message)
+
+	if machineScope.ProxmoxMachine.Spec.ConfigDriftPolicy != infrav1alpha1.ConfigDriftPolicyReapply {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMConfigInSyncCondition, infrav1alpha1.VMConfigDriftedReason, clusterv1.ConditionSeverityWarning, message)
+		return false, nil
+	}
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, options...)
+	if err != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMConfigInSyncCondition, infrav1alpha1.VMConfigDriftedReason, clusterv1.ConditionSeverityWarning, message)
+		return false, errors.Wrapf(err, "failed to reapply config of VM %s", machineScope.Name())
+	}
+
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMConfigInSyncCondition, infrav1alpha1.ReapplyingVMConfigReason, clusterv1.ConditionSeverityInfo, message)
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// detectConfigDrift returns the differences between the VM config and the spec.
+// Only fields set in the spec are compared.
+func detectConfigDrift(machineScope *scope.MachineScope) []configDrift {
+	spec := machineScope.ProxmoxMachine.Spec
+	vmConfig := machineScope.VirtualMachine.VirtualMachineConfig
+
+	drifts := detectComputeDrift(machineScope)
+
+	if spec.Network != nil {
+		nets := vmConfig.MergeNets()
+		if d := spec.Network.Default; d != nil {
+			drifts = append(drifts, detectNetworkDeviceDrift(infrav1alpha1.DefaultNetworkDevice, nets[infrav1alpha1.DefaultNetworkDevice], *d, networkModel(machineScope, *d))...)
+		}
+		for _, d := range spec.Network.AdditionalDevices {
+			drifts = append(drifts, detectNetworkDeviceDrift(d.Name, nets[d.Name], d.NetworkDevice, networkModel(machineScope, d.NetworkDevice))...)
+		}
+	}
+
+	// the IP tag of the default network device is used to find the VM.
+	tags := missingTags(machineScope)
+	if ip := machineScope.ProxmoxMachine.Status.IPAddresses[infrav1alpha1.DefaultNetworkDevice].IPV4; ip != "" {
+		ipTag := fmt.Sprintf("ip_%s_%s", infrav1alpha1.DefaultNetworkDevice, ip)
+		if !machineScope.VirtualMachine.HasTag(ipTag) {
+			tags = append(tags, ipTag)
+		}
+	}
+	if len(tags) > 0 {
+		drifts = append(drifts, configDrift{
+			description: fmt.Sprintf("tags %s missing", strings.Join(tags, ", ")),
+			option:      proxmox.VirtualMachineOption{Name: optionTags, Value: joinTags(vmConfig.Tags, tags)},
+		})
+	}
+
+	return drifts
+}
+
+// detectNetworkDeviceDrift compares the model, bridge, VLAN tag and MTU of a network device.
+// The MAC address is preserved when reapplying, as the IP address configuration depends on it,
+// and so is the firewall of the device.
+func detectNetworkDeviceDrift(name, current string, desired infrav1alpha1.NetworkDevice, desiredModel string) []configDrift {
+	desiredBridge := desired.BridgeName()
+	model, bridge := extractNetworkModelAndBridge(current)
+	if model == desiredModel && bridge == desiredBridge && networkDeviceOptionsMatch(current, desired) {
+		return nil
+	}
+
+	value := formatNetworkDevice(desiredModel, desiredBridge)
+	if mac := extractMACAddress(current); mac != "" {
+		value = fmt.Sprintf("%s=%s,bridge=%s", desiredModel, mac, desiredBridge)
+	}
+	value += networkDeviceOptions(desired)
+	if networkFirewallEnabled(current) {
+		value = withNetworkFirewall(value)
+	}
+
+	description := fmt.Sprintf("network device %s is %s on %s instead of %s on %s", name, model, bridge, desiredModel, desiredBridge)
+	if model == desiredModel && bridge == desiredBridge {
+		description = fmt.Sprintf("network device %s has the options %q instead of %q", name,
+			currentNetworkDeviceOptions(current), strings.TrimPrefix(networkDeviceOptions(desired), ","))
+	}
+
+	return []configDrift{{
+		description: description,
+		option:      proxmox.VirtualMachineOption{Name: name, Value: value},
+	}}
+}
+
+// currentNetworkDeviceOptions returns the VLAN tag and MTU options of the config of a network device.
+func currentNetworkDeviceOptions(input string) string {
+	var options []string
+	for _, name := range []string{"tag", "mtu"} {
+		if value := networkDeviceOption(input, name); value != "" {
+			options = append(options, name+"="+value)
+		}
+	}
+	return strings.Join(options, ",")
+}
+
+// detectComputeDrift compares the sockets, cores, vCPUs, CPU limit and weight and memory of the VM with the spec.
+func detectComputeDrift(machineScope *scope.MachineScope) []configDrift {
+	spec := machineScope.ProxmoxMachine.Spec
+	vmConfig := machineScope.VirtualMachine.VirtualMachineConfig
+
+	var drifts []configDrift
+	if value := spec.NumSockets; value > 0 && vmConfig.Sockets != int(value) {
+		drifts = append(drifts, configDrift{
+			description: fmt.Sprintf("sockets %d instead of %d", vmConfig.Sockets, value),
+			option:      proxmox.VirtualMachineOption{Name: optionSockets, Value: value},
+		})
+	}
+	if value := spec.NumCores; value > 0 && vmConfig.Cores != int(value) {
+		drifts = append(drifts, configDrift{
+			description: fmt.Sprintf("cores %d instead of %d", vmConfig.Cores, value),
+			option:      proxmox.VirtualMachineOption{Name: optionCores, Value: value},
+		})
+	}
+	if value := spec.NumVCPUs; value > 0 && vmConfig.Vcpus != int(value) {
+		drifts = append(drifts, configDrift{
+			description: fmt.Sprintf("vcpus %d instead of %d", vmConfig.Vcpus, value),
+			option:      proxmox.VirtualMachineOption{Name: optionVCPUs, Value: value},
+		})
+	}
+	if value := spec.CPULimit; value != "" && !cpuLimitEqual(float64(vmConfig.CPULimit), value) {
+		drifts = append(drifts, configDrift{
+			description: fmt.Sprintf("cpulimit %g instead of %s", float64(vmConfig.CPULimit), value),
+			option:      proxmox.VirtualMachineOption{Name: optionCPULimit, Value: value},
+		})
+	}
+	if value := spec.CPUUnits; value > 0 && vmConfig.CPUUnits != int(value) {
+		drifts = append(drifts, configDrift{
+			description: fmt.Sprintf("cpuunits %d instead of %d", vmConfig.CPUUnits, value),
+			option:      proxmox.VirtualMachineOption{Name: optionCPUUnits, Value: value},
+		})
+	}
+	if value := spec.MemoryMiB; value > 0 && int32(vmConfig.Memory) != value {
+		drifts = append(drifts, configDrift{
+			description: fmt.Sprintf("memory %dMiB instead of %dMiB", vmConfig.Memory, value),
+			option:      proxmox.VirtualMachineOption{Name: optionMemory, Value: value},
+		})
+	}
+
+	return drifts
+}
+
+// cpuLimitEqual reports whether the CPU limit of the VM is the one of the spec, which may be formatted differently.
+func cpuLimitEqual(current float64, desired string) bool {
+	value, err := strconv.ParseFloat(desired, 64)
+	return err == nil && value == current
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestReconcileConfigDrift_InSync(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.NumCores = 2
+	machineScope.ProxmoxMachine.Spec.CPULimit = "2.0"
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", Model: ptr.To("virtio")},
+	}
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.Cores = 2
+	vm.VirtualMachineConfig.CPULimit = 2
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileConfigDrift(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMConfigInSyncCondition))
+}
+
+func TestReconcileConfigDrift_Report(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Memory = 8192
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileConfigDrift(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, conditions.IsFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMConfigInSyncCondition))
+	require.Equal(t, infrav1alpha1.VMConfigDriftedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMConfigInSyncCondition))
+}
+
+func TestReconcileConfigDrift_Reapply(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.ProxmoxMachine.Spec.ConfigDriftPolicy = infrav1alpha1.ConfigDriftPolicyReapply
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", Model: ptr.To("virtio")},
+	}
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr1,firewall=1")
+	vm.VirtualMachineConfig.Tags = "custom"
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: infrav1alpha1.DefaultNetworkDevice, Value: "virtio=A6:23:64:4D:84:CB,bridge=vmbr0,firewall=1"},
+		proxmox.VirtualMachineOption{Name: optionTags, Value: "custom;ip_net0_10.10.10.10"},
+	}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileConfigDrift(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.ReapplyingVMConfigReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMConfigInSyncCondition))
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+}
+
+func TestDetectNetworkDeviceDrift_VLAN(t *testing.T) {
+	desired := infrav1alpha1.NetworkDevice{Bridge: "vmbr1", VLAN: 100, MTU: 9000}
+	require.Empty(t, detectNetworkDeviceDrift("net1", "virtio=A6:23:64:4D:84:CB,bridge=vmbr1,tag=100,mtu=9000", desired, "virtio"))
+
+	drifts := detectNetworkDeviceDrift("net1", "virtio=A6:23:64:4D:84:CB,bridge=vmbr1,firewall=1,tag=200", desired, "virtio")
+	require.Len(t, drifts, 1)
+	require.Equal(t, `network device net1 has the options "tag=200" instead of "tag=100,mtu=9000"`, drifts[0].description)
+	require.Equal(t, "virtio=A6:23:64:4D:84:CB,bridge=vmbr1,tag=100,mtu=9000,firewall=1", drifts[0].option.Value)
+}
+
+func TestReconcileConfigDrift_NotReady(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	machineScope.SetVirtualMachine(newStoppedVM())
+
+	requeue, err := reconcileConfigDrift(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.VMConfigInSyncCondition))
+}
@@ -72,7 +72,7 @@ func updateVMLocation(ctx context.Context, s *scope.MachineScope) error {
 
 	// We are looking for a machine with the ID and check if the name matches.
 	// Then we have to update the node in the machine and cluster status.
-	vm, err := s.InfraCluster.ProxmoxClient.FindVMResource(ctx, uint64(vmID))
+	vm, err := findVMResource(ctx, s, vmID)
 	if err != nil {
 		return err
 	}
@@ -89,9 +89,13 @@ func updateVMLocation(ctx context.Context, s *scope.MachineScope) error {
 
 	// If there is a machine with an ID that doesn't match name of the
 	// Proxmox machine, we need to stop right there.
+	name, err := vmName(s)
+	if err != nil {
+		return err
+	}
 	machineName := s.ProxmoxMachine.GetName()
-	if vm.Name != machineName {
-		err := fmt.Errorf("expected VM name to match %q but it was %q", vm.Name, machineName)
+	if vm.Name != name {
+		err := fmt.Errorf("expected VM name to match %q but it was %q", name, vm.Name)
 		s.SetFailureMessage(err)
 		s.SetFailureReason(capierrors.MachineStatusError("UnkownMachine"))
 		return err
@@ -113,3 +117,50 @@ func updateVMLocation(ctx context.Context, s *scope.MachineScope) error {
 
 	return nil
 }
+
+// findVMResource looks up the VM of the machine by its ID on the allowed nodes and identifies it
+// by its machine tag, which only requests the VM in question from each node. Only if it is not
+// found there, the VM is looked up by its ID in the whole Proxmox cluster.
+func findVMResource(ctx context.Context, s *scope.MachineScope, vmID int64) (*proxmox.ClusterResource, error) {
+	tag := machineTag(s)
+	for _, node := range candidateNodes(s) {
+		vm, err := s.InfraCluster.ProxmoxClient.GetVM(ctx, node, vmID)
+		if err != nil || !vm.HasTag(tag) {
+			continue
+		}
+		return &proxmox.ClusterResource{
+			VMID: uint64(vmID),
+			Node: node,
+			Name: vm.Name,
+			Tags: vm.VirtualMachineConfig.Tags,
+		}, nil
+	}
+
+	vm, err := s.InfraCluster.ProxmoxClient.GetVMByID(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	return &proxmox.ClusterResource{
+		VMID: uint64(vmID),
+		Node: vm.Node,
+		Name: vm.Name,
+		Tags: vm.VirtualMachineConfig.Tags,
+	}, nil
+}
+
+// candidateNodes returns the nodes the VM of the machine may have been moved to,
+// besides the node it was expected on.
+func candidateNodes(s *scope.MachineScope) []string {
+	located := s.LocateProxmoxNode()
+	seen := map[string]struct{}{located: {}}
+
+	var nodes []string
+	for _, node := range append([]string{s.ProxmoxMachine.GetNode(), ptr.Deref(s.ProxmoxMachine.Spec.Target, "")}, s.InfraCluster.ProxmoxCluster.Spec.AllowedNodes...) {
+		if _, ok := seen[node]; ok || node == "" {
+			continue
+		}
+		seen[node] = struct{}{}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
@@ -22,10 +22,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
-
-	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 )
 
 func TestFindVM_FindByNodeAndID(t *testing.T) {
@@ -45,10 +42,7 @@ func TestFindVM_FindByNodeLocationsAndID(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	vm := newRunningVM()
 	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
-	machineScope.InfraCluster.ProxmoxCluster.AddNodeLocation(infrav1alpha1.NodeLocation{
-		Machine: corev1.LocalObjectReference{Name: machineScope.ProxmoxMachine.GetName()},
-		Node:    "node3",
-	}, false)
+	machineScope.InfraCluster.ProxmoxCluster.UpdateNodeLocation(machineScope.ProxmoxMachine.GetName(), "node3", false)
 
 	proxmoxClient.EXPECT().GetVM(ctx, "node3", int64(123)).Return(vm, nil).Once()
 
@@ -80,11 +74,10 @@ func TestUpdateVMLocation_MissingName(t *testing.T) {
 	ctx := context.TODO()
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	vm := newRunningVM()
-	vmr := newVMResource()
-	vmr.Name = ""
+	vm.Name = ""
 	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
 
-	proxmoxClient.EXPECT().FindVMResource(ctx, uint64(123)).Return(vmr, nil).Once()
+	proxmoxClient.EXPECT().GetVMByID(ctx, int64(123)).Return(vm, nil).Once()
 
 	require.Error(t, updateVMLocation(ctx, machineScope))
 }
@@ -93,11 +86,10 @@ func TestUpdateVMLocation_NameMismatch(t *testing.T) {
 	ctx := context.TODO()
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	vm := newRunningVM()
-	vmr := newVMResource()
-	vmr.Name = "foo"
+	vm.Name = "foo"
 	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
 
-	proxmoxClient.EXPECT().FindVMResource(ctx, uint64(123)).Return(vmr, nil).Once()
+	proxmoxClient.EXPECT().GetVMByID(ctx, int64(123)).Return(vm, nil).Once()
 
 	require.Error(t, updateVMLocation(ctx, machineScope))
 	require.True(t, machineScope.HasFailed(), "expected failureReason and failureMessage to be set")
@@ -107,19 +99,53 @@ func TestUpdateVMLocation_UpdateNode(t *testing.T) {
 	ctx := context.TODO()
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	vm := newRunningVM()
-	vmr := newVMResource()
+	vm.Name = "test"
 	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
 	machineScope.ProxmoxMachine.Status.ProxmoxNode = ptr.To("node3")
-	machineScope.InfraCluster.ProxmoxCluster.AddNodeLocation(infrav1alpha1.NodeLocation{
-		Machine: corev1.LocalObjectReference{Name: machineScope.Name()},
-		Node:    "node3",
-	}, false)
+	machineScope.InfraCluster.ProxmoxCluster.UpdateNodeLocation(machineScope.Name(), "node3", false)
+
+	proxmoxClient.EXPECT().GetVM(ctx, "node1", int64(123)).Return(nil, errors.New("not found")).Once()
+	proxmoxClient.EXPECT().GetVMByID(ctx, int64(123)).Return(vm, nil).Once()
+
+	require.NoError(t, updateVMLocation(ctx, machineScope))
+	require.Equal(t, vm.Node, *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.Equal(t, vm.Node, machineScope.InfraCluster.ProxmoxCluster.GetNode(machineScope.Name(), false))
+}
+
+func TestUpdateVMLocation_FindByTag(t *testing.T) {
+	ctx := context.TODO()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(123))
+	machineScope.ProxmoxMachine.Status.ProxmoxNode = ptr.To("node3")
+	machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes = []string{"node1", "node2", "node3"}
+
+	// a VM of another machine reusing the ID is skipped.
+	other := newRunningVM()
+	other.VirtualMachineConfig.Tags = "capmox-machine_other"
+	vm := newRunningVM()
+	vm.Name = "test"
+	vm.VirtualMachineConfig.Tags = "capmox_default_test;capmox-machine_test"
+	proxmoxClient.EXPECT().GetVM(ctx, "node1", int64(123)).Return(other, nil).Once()
+	proxmoxClient.EXPECT().GetVM(ctx, "node2", int64(123)).Return(vm, nil).Once()
+
+	require.NoError(t, updateVMLocation(ctx, machineScope))
+	require.Equal(t, "node2", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.Equal(t, "node2", machineScope.InfraCluster.ProxmoxCluster.GetNode(machineScope.Name(), false))
+}
+
+func TestUpdateVMLocation_NameTemplate(t *testing.T) {
+	ctx := context.TODO()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.Machine.Spec.ClusterName = "capmox"
+	machineScope.ProxmoxMachine.Spec.VMNameTemplate = ptr.To("{{.ClusterName}}-{{.MachineName}}")
+	vm := newRunningVM()
+	vm.Name = "capmox-test"
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(123))
 
-	proxmoxClient.EXPECT().FindVMResource(ctx, uint64(123)).Return(vmr, nil).Once()
+	proxmoxClient.EXPECT().GetVMByID(ctx, int64(123)).Return(vm, nil).Once()
 
 	require.NoError(t, updateVMLocation(ctx, machineScope))
-	require.Equal(t, vmr.Node, *machineScope.ProxmoxMachine.Status.ProxmoxNode)
-	require.Equal(t, vmr.Node, machineScope.InfraCluster.ProxmoxCluster.GetNode(machineScope.Name(), false))
+	require.Equal(t, vm.Node, *machineScope.ProxmoxMachine.Status.ProxmoxNode)
 }
 
 func TestUpdateVMLocation_WithTask(t *testing.T) {
@@ -136,12 +162,11 @@ func TestUpdateVMLocation_WithoutTaskNameMismatch(t *testing.T) {
 	ctx := context.TODO()
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	vm := newRunningVM()
-	vmr := newVMResource()
-	vmr.Name = "foo"
+	vm.Name = "foo"
 	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To(int64(vm.VMID))
 	machineScope.ProxmoxMachine.Status.TaskRef = nil
 
-	proxmoxClient.EXPECT().FindVMResource(ctx, uint64(123)).Return(vmr, nil).Once()
+	proxmoxClient.EXPECT().GetVMByID(ctx, int64(123)).Return(vm, nil).Once()
 
 	require.Error(t, updateVMLocation(ctx, machineScope))
 	require.True(t, machineScope.HasFailed(), "expected failureReason and failureMessage to be set")
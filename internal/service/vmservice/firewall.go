@@ -0,0 +1,135 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+const networkFirewallOption = "firewall=1"
+
+// reconcileFirewall configures the firewall of the VM according to the spec, before the VM is first started.
+func reconcileFirewall(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	spec := machineScope.ProxmoxMachine.Spec.Firewall
+	if spec == nil {
+		return false, nil
+	}
+	if machineScope.VirtualMachine.IsRunning() || machineScope.ProxmoxMachine.Status.Ready {
+		// We only want to do this before the machine was started or is ready
+		return false, nil
+	}
+
+	client := machineScope.InfraCluster.ProxmoxClient
+	vm := machineScope.VirtualMachine
+
+	// the firewall of the VM only filters the traffic of network devices which have it enabled.
+	if spec.Enabled {
+		nets := vm.VirtualMachineConfig.MergeNets()
+		names := make([]string, 0, len(nets))
+		for name := range nets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var options []proxmox.VirtualMachineOption
+		for _, name := range names {
+			if !networkFirewallEnabled(nets[name]) {
+				options = append(options, proxmox.VirtualMachineOption{Name: name, Value: withNetworkFirewall(nets[name])})
+			}
+		}
+		if len(options) > 0 {
+			machineScope.V(4).Info("enabling firewall of network devices")
+			task, err := client.ConfigureVM(ctx, vm, options...)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to enable firewall of network devices of VM %s", machineScope.Name())
+			}
+			machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+			return true, nil
+		}
+	}
+
+	current, err := client.GetVMFirewall(ctx, vm)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to get firewall of VM %s", machineScope.Name())
+	}
+	desired := desiredFirewall(spec)
+	if desired.PolicyIn == "" {
+		// the policy of the VM is kept.
+		current.PolicyIn = ""
+	}
+	if reflect.DeepEqual(current, desired) {
+		return false, nil
+	}
+
+	machineScope.V(4).Info("reconciling firewall")
+	if err := client.SetVMFirewall(ctx, vm, desired); err != nil {
+		return false, errors.Wrapf(err, "unable to configure firewall of VM %s", machineScope.Name())
+	}
+	return false, nil
+}
+
+// desiredFirewall returns the firewall configuration of a VM. The rules of the security groups
+// are evaluated before the inbound rules.
+func desiredFirewall(spec *infrav1alpha1.FirewallSpec) proxmox.VMFirewall {
+	firewall := proxmox.VMFirewall{Enable: spec.Enabled, PolicyIn: spec.InboundPolicy}
+	for _, group := range spec.SecurityGroups {
+		firewall.Rules = append(firewall.Rules, proxmox.FirewallRule{Type: "group", Action: group})
+	}
+	for _, rule := range spec.InboundRules {
+		firewall.Rules = append(firewall.Rules, proxmox.FirewallRule{
+			Type:    "in",
+			Action:  rule.Action,
+			Proto:   rule.Protocol,
+			DPort:   rule.Port,
+			Source:  rule.Source,
+			Comment: rule.Comment,
+		})
+	}
+	return firewall
+}
+
+// networkFirewallEnabled returns whether the firewall is enabled in the config of a network device,
+// e.g. virtio=A6:23:64:4D:84:CB,bridge=vmbr1,firewall=1.
+func networkFirewallEnabled(input string) bool {
+	for _, option := range strings.Split(input, ",") {
+		if option == networkFirewallOption {
+			return true
+		}
+	}
+	return false
+}
+
+// withNetworkFirewall enables the firewall in the config of a network device.
+func withNetworkFirewall(input string) string {
+	options := []string{}
+	for _, option := range strings.Split(input, ",") {
+		if !strings.HasPrefix(option, "firewall=") {
+			options = append(options, option)
+		}
+	}
+	return strings.Join(append(options, networkFirewallOption), ",")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestReconcileFirewall(t *testing.T) {
+	ctx := context.Background()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Firewall = &infrav1alpha1.FirewallSpec{
+		Enabled:        true,
+		InboundPolicy:  "DROP",
+		SecurityGroups: []string{"k8s"},
+		InboundRules:   []infrav1alpha1.FirewallRule{{Action: "ACCEPT", Protocol: "tcp", Port: "6443"}},
+	}
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Nets = map[string]string{
+		"net0": "virtio=A6:23:64:4D:84:CB,bridge=vmbr0",
+		"net1": "virtio=A6:23:64:4D:84:CD,bridge=vmbr1,firewall=1",
+	}
+	machineScope.SetVirtualMachine(vm)
+
+	// the firewall is enabled on the network devices first.
+	proxmoxClient.EXPECT().ConfigureVM(ctx, vm, proxmox.VirtualMachineOption{Name: "net0", Value: "virtio=A6:23:64:4D:84:CB,bridge=vmbr0,firewall=1"}).Return(newTask(), nil).Once()
+	requeue, err := reconcileFirewall(ctx, machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	vm.VirtualMachineConfig.Nets["net0"] += ",firewall=1"
+	desired := proxmox.VMFirewall{
+		Enable:   true,
+		PolicyIn: "DROP",
+		Rules: []proxmox.FirewallRule{
+			{Type: "group", Action: "k8s"},
+			{Type: "in", Action: "ACCEPT", Proto: "tcp", DPort: "6443"},
+		},
+	}
+	proxmoxClient.EXPECT().GetVMFirewall(ctx, vm).Return(proxmox.VMFirewall{PolicyIn: "DROP"}, nil).Once()
+	proxmoxClient.EXPECT().SetVMFirewall(ctx, vm, desired).Return(nil).Once()
+	requeue, err = reconcileFirewall(ctx, machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+
+	// a firewall in sync is left alone.
+	proxmoxClient.EXPECT().GetVMFirewall(ctx, vm).Return(desired, nil).Once()
+	requeue, err = reconcileFirewall(ctx, machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileFirewall_RunningVM(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Firewall = &infrav1alpha1.FirewallSpec{Enabled: true}
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	requeue, err := reconcileFirewall(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestWithNetworkFirewall(t *testing.T) {
+	require.Equal(t, "virtio,bridge=vmbr0,firewall=1", withNetworkFirewall("virtio,bridge=vmbr0"))
+	require.Equal(t, "virtio,bridge=vmbr0,firewall=1", withNetworkFirewall("virtio,firewall=0,bridge=vmbr0"))
+	require.True(t, networkFirewallEnabled("virtio,bridge=vmbr0,firewall=1"))
+	require.False(t, networkFirewallEnabled("virtio,bridge=vmbr0"))
+}
@@ -113,7 +113,7 @@ func setupReconcilerTest(t *testing.T) (*scope.MachineScope, *proxmoxtest.MockCl
 	kubeClient := fake.NewClientBuilder().
 		WithScheme(scheme).
 		WithObjects(cluster, machine, infraCluster, infraMachine).
-		WithStatusSubresource(&infrav1alpha1.ProxmoxCluster{}, &infrav1alpha1.ProxmoxMachine{}).
+		WithStatusSubresource(&infrav1alpha1.ProxmoxCluster{}, &infrav1alpha1.ProxmoxMachine{}, &infrav1alpha1.ProxmoxDisk{}).
 		Build()
 
 	ipamHelper := ipam.NewHelper(kubeClient, infraCluster)
@@ -226,13 +226,6 @@ func newTask() *proxmox.Task {
 	return &proxmox.Task{UPID: "result"}
 }
 
-func newVMResource() *proxmox.ClusterResource {
-	return &proxmox.ClusterResource{
-		Name: "test",
-		Node: "node1",
-	}
-}
-
 func newRunningVM() *proxmox.VirtualMachine {
 	return &proxmox.VirtualMachine{
 		VirtualMachineConfig: &proxmox.VirtualMachineConfig{},
@@ -265,6 +258,14 @@ func newStoppedVM() *proxmox.VirtualMachine {
 	}
 }
 
+func newTemplateVM() *proxmox.VirtualMachine {
+	vm := newStoppedVM()
+	vm.Name = "template"
+	vm.VMID = 123
+	vm.Template = true
+	return vm
+}
+
 func newHibernatedVM() *proxmox.VirtualMachine {
 	return &proxmox.VirtualMachine{
 		VirtualMachineConfig: &proxmox.VirtualMachineConfig{},
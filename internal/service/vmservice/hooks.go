@@ -0,0 +1,53 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"sort"
+	"strings"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// pendingHooks returns the sorted names of the hooks with the annotation prefix which are set on the machine.
+func pendingHooks(machineScope *scope.MachineScope, prefix string) []string {
+	var hooks []string
+	for key := range machineScope.ProxmoxMachine.GetAnnotations() {
+		if name, ok := strings.CutPrefix(key, prefix+"/"); ok {
+			hooks = append(hooks, name)
+		}
+	}
+	sort.Strings(hooks)
+	return hooks
+}
+
+// waitForHooks returns whether the machine is held by hooks with the annotation prefix,
+// and reports them in the VMProvisioned condition.
+func waitForHooks(machineScope *scope.MachineScope, prefix string) bool {
+	hooks := pendingHooks(machineScope, prefix)
+	if len(hooks) == 0 {
+		return false
+	}
+	machineScope.V(4).Info("waiting for hooks", "prefix", prefix, "hooks", hooks)
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForHooksReason, clusterv1.ConditionSeverityInfo,
+		"waiting for the hooks %s of %s", strings.Join(hooks, ", "), prefix)
+	return true
+}
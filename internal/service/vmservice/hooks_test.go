@@ -0,0 +1,91 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func TestPendingHooks(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.SetAnnotation(infrav1alpha1.PreCloneHookAnnotationPrefix+"/ipam", "")
+	machineScope.SetAnnotation(infrav1alpha1.PreCloneHookAnnotationPrefix+"/cmdb", "")
+	machineScope.SetAnnotation(infrav1alpha1.PreStartHookAnnotationPrefix+"/firewall", "")
+	machineScope.SetAnnotation(infrav1alpha1.PreCloneHookAnnotationPrefix, "")
+
+	require.Equal(t, []string{"cmdb", "ipam"}, pendingHooks(machineScope, infrav1alpha1.PreCloneHookAnnotationPrefix))
+	require.Equal(t, []string{"firewall"}, pendingHooks(machineScope, infrav1alpha1.PreStartHookAnnotationPrefix))
+}
+
+func TestEnsureVirtualMachine_PreCloneHook(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.SetAnnotation(infrav1alpha1.PreCloneHookAnnotationPrefix+"/cmdb", "")
+
+	// the mock fails the test if the VM is cloned.
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.WaitingForHooksReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.Equal(t, "waiting for the hooks cmdb of "+infrav1alpha1.PreCloneHookAnnotationPrefix,
+		conditions.GetMessage(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestReconcilePowerState_PreStartHook(t *testing.T) {
+	ctx := context.TODO()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.SetAnnotation(infrav1alpha1.PreStartHookAnnotationPrefix+"/firewall", "")
+	vm := newStoppedVM()
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcilePowerState(ctx, machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.WaitingForHooksReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+
+	delete(machineScope.ProxmoxMachine.Annotations, infrav1alpha1.PreStartHookAnnotationPrefix+"/firewall")
+	proxmoxClient.EXPECT().StartVM(ctx, vm).Return(newTask(), nil).Once()
+
+	requeue, err = reconcilePowerState(ctx, machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.PoweringOnReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestReconcileProvisioningTimeout_WaitingForHooks(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ProvisioningRemediation = &infrav1alpha1.ProvisioningRemediation{
+		Timeout: metav1.Duration{Duration: time.Minute},
+	}
+	machineScope.ProxmoxMachine.Status.ProvisioningStartTime = ptr.To(metav1.NewTime(time.Now().Add(-time.Hour)))
+	machineScope.SetAnnotation(infrav1alpha1.PreCloneHookAnnotationPrefix+"/cmdb", "")
+	require.True(t, waitForHooks(machineScope, infrav1alpha1.PreCloneHookAnnotationPrefix))
+
+	requeue, err := reconcileProvisioningTimeout(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.ProvisioningStartTime)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"strings"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+const (
+	hotplugMemory = "memory"
+
+	// defaultHotplug are the hotplug features of VMs which do not set them, which "1" is an alias for.
+	defaultHotplug = "network,disk,usb"
+)
+
+// memoryHotplugOptions returns the options which enable memory hotplug in a VM with the given hotplug and numa options.
+// Proxmox requires NUMA to hotplug memory.
+func memoryHotplugOptions(hotplug string, numa int) []proxmox.VirtualMachineOption {
+	var options []proxmox.VirtualMachineOption
+	if value := hotplugWithMemory(hotplug); value != hotplug {
+		options = append(options, proxmox.VirtualMachineOption{Name: optionHotplug, Value: value})
+	}
+	if numa != 1 {
+		options = append(options, proxmox.VirtualMachineOption{Name: optionNuma, Value: 1})
+	}
+	return options
+}
+
+// hotplugWithMemory returns the hotplug option with memory added to its features.
+func hotplugWithMemory(hotplug string) string {
+	var features []string
+	switch hotplug {
+	case "", "1":
+		features = strings.Split(defaultHotplug, ",")
+	case "0":
+	default:
+		features = strings.Split(hotplug, ",")
+	}
+
+	for _, feature := range features {
+		if feature == hotplugMemory {
+			return hotplug
+		}
+	}
+	return strings.Join(append(features, hotplugMemory), ",")
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestHotplugWithMemory(t *testing.T) {
+	require.Equal(t, "network,disk,usb,memory", hotplugWithMemory(""))
+	require.Equal(t, "network,disk,usb,memory", hotplugWithMemory("1"))
+	require.Equal(t, "memory", hotplugWithMemory("0"))
+	require.Equal(t, "disk,cpu,memory", hotplugWithMemory("disk,cpu"))
+	require.Equal(t, "memory,disk", hotplugWithMemory("memory,disk"))
+}
+
+func TestReconcileVirtualMachineConfig_MemoryHotplug(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 4096
+	machineScope.ProxmoxMachine.Spec.MemoryHotplug = true
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Tags = "capmox_default_test;capmox-machine_test"
+	vm.VirtualMachineConfig.Memory = 4096
+	vm.VirtualMachineConfig.Hotplug = "disk,network"
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionHotplug, Value: "disk,network,memory"},
+		proxmox.VirtualMachineOption{Name: optionNuma, Value: 1},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.TODO(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.TODO(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	// hotplug is enabled now.
+	vm.VirtualMachineConfig.Hotplug = "disk,network,memory"
+	vm.VirtualMachineConfig.Numa = 1
+	requeue, err = reconcileVirtualMachineConfig(context.TODO(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
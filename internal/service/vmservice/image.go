@@ -0,0 +1,146 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+const (
+	// imageContent is the content type of disk images which VMs import their disks from.
+	imageContent = "import"
+
+	// imageBootDisk is the boot volume of VMs created from an image, if the spec has none.
+	imageBootDisk = "scsi0"
+)
+
+// createVMFromImage creates the VM on the node with its boot volume imported from the image of the spec.
+// If the image is not on the storage of the node yet, the node downloads it first and the response has no VMID,
+// so the VM is created once the download task finished.
+func createVMFromImage(ctx context.Context, machineScope *scope.MachineScope, node string, clone proxmox.VMCloneRequest) (proxmox.VMCloneResponse, error) {
+	image := machineScope.ProxmoxMachine.Spec.Image
+	network := machineScope.ProxmoxMachine.Spec.Network
+	if network == nil || network.Default == nil {
+		return proxmox.VMCloneResponse{}, errors.New("machines created from an image require a default network device")
+	}
+
+	filename, err := imageFilename(image)
+	if err != nil {
+		return proxmox.VMCloneResponse{}, err
+	}
+	volume := fmt.Sprintf("%s:%s/%s", image.Storage, imageContent, filename)
+
+	client := machineScope.InfraCluster.ProxmoxClient
+	volumes, err := client.ListVolumes(ctx, node, image.Storage, imageContent)
+	if err != nil {
+		return proxmox.VMCloneResponse{}, errors.Wrapf(err, "unable to list images on storage %s of node %s", image.Storage, node)
+	}
+	if !containsString(volumes, volume) {
+		task, err := client.DownloadImage(ctx, node, image.Storage, proxmox.ImageDownloadOptions{
+			URL:               image.URL,
+			Filename:          filename,
+			Checksum:          image.Checksum,
+			ChecksumAlgorithm: image.ChecksumAlgorithm,
+		})
+		if err != nil {
+			return proxmox.VMCloneResponse{}, err
+		}
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.DownloadingImageReason, clusterv1.ConditionSeverityInfo,
+			"downloading image %s to node %s", image.URL, node)
+		return proxmox.VMCloneResponse{Task: task}, nil
+	}
+
+	storage := clone.Storage
+	if storage == "" {
+		storage = image.Storage
+	}
+	disk := imageBootDisk
+	if disks := machineScope.ProxmoxMachine.Spec.Disks; disks != nil && disks.BootVolume != nil {
+		disk = disks.BootVolume.Disk
+	}
+	diskOptions := fmt.Sprintf("%s:0,import-from=%s", storage, volume)
+	if clone.Format != "" {
+		diskOptions += ",format=" + clone.Format
+	}
+
+	options := []proxmox.VirtualMachineOption{
+		{Name: "name", Value: clone.Name},
+		{Name: "scsihw", Value: "virtio-scsi-single"},
+		{Name: disk, Value: diskOptions},
+		{Name: "boot", Value: "order=" + disk},
+		{Name: infrav1alpha1.DefaultNetworkDevice, Value: networkDeviceConfig(machineScope, *network.Default)},
+	}
+	if machineScope.ProxmoxMachine.Spec.GuestOS == infrav1alpha1.GuestOSWindows {
+		options = append(options, proxmox.VirtualMachineOption{Name: "ostype", Value: "win11"})
+	} else {
+		options = append(options, proxmox.VirtualMachineOption{Name: "ostype", Value: "l26"})
+	}
+	if clone.Description != "" {
+		options = append(options, proxmox.VirtualMachineOption{Name: "description", Value: clone.Description})
+	}
+	if clone.Pool != "" {
+		options = append(options, proxmox.VirtualMachineOption{Name: "pool", Value: clone.Pool})
+	}
+
+	return client.CreateVM(ctx, node, options...)
+}
+
+// imageFilename returns the filename of the image on the storage.
+func imageFilename(image *infrav1alpha1.ImageSource) (string, error) {
+	if image.Filename != "" {
+		return image.Filename, nil
+	}
+
+	u, err := url.Parse(image.URL)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid image url %s", image.URL)
+	}
+	filename := path.Base(u.Path)
+	if filename == "." || filename == "/" {
+		return "", errors.Errorf("image url %s has no filename, set the filename of the image", image.URL)
+	}
+
+	// the import content only accepts the extensions of the disk formats.
+	for _, ext := range []string{".qcow2", ".raw", ".vmdk"} {
+		if strings.HasSuffix(filename, ext) {
+			return filename, nil
+		}
+	}
+	return filename + ".qcow2", nil
+}
+
+// containsString reports whether the value is one of the values.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
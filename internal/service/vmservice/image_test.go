@@ -0,0 +1,128 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+func setupImageReconcilerTest(t *testing.T) (*scope.MachineScope, *proxmoxtest.MockClient) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.TemplateID = nil
+	machineScope.ProxmoxMachine.Spec.Image = &infrav1alpha1.ImageSource{
+		URL:               "https://cloud-images.ubuntu.com/noble/current/noble-server-cloudimg-amd64.img",
+		Storage:           "local",
+		Checksum:          "abc",
+		ChecksumAlgorithm: "sha256",
+	}
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0"}}
+	return machineScope, proxmoxClient
+}
+
+func TestEnsureVirtualMachine_CreateVMFromImage_DownloadsImage(t *testing.T) {
+	machineScope, proxmoxClient := setupImageReconcilerTest(t)
+
+	proxmoxClient.EXPECT().ListBridges(context.Background(), "node1").Return([]string{"vmbr0"}, nil).Once()
+	proxmoxClient.EXPECT().ListVolumes(context.Background(), "node1", "local", "import").Return([]string{"local:import/jammy.qcow2"}, nil).Once()
+	proxmoxClient.EXPECT().DownloadImage(context.Background(), "node1", "local", proxmox.ImageDownloadOptions{
+		URL:               "https://cloud-images.ubuntu.com/noble/current/noble-server-cloudimg-amd64.img",
+		Filename:          "noble-server-cloudimg-amd64.img.qcow2",
+		Checksum:          "abc",
+		ChecksumAlgorithm: "sha256",
+	}).Return(newTask(), nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	// the VM is created once the download finished, on the node which downloaded the image.
+	require.Nil(t, machineScope.ProxmoxMachine.Spec.VirtualMachineID)
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, "node1", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.Equal(t, infrav1alpha1.DownloadingImageReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestEnsureVirtualMachine_CreateVMFromImage(t *testing.T) {
+	machineScope, proxmoxClient := setupImageReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Storage = ptr.To("local-lvm")
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{BootVolume: &infrav1alpha1.DiskSize{Disk: "virtio0", SizeGB: 50}}
+	machineScope.ProxmoxMachine.Status.ProxmoxNode = ptr.To("node2")
+
+	proxmoxClient.EXPECT().ListBridges(context.Background(), "node2").Return([]string{"vmbr0", "vmbr1"}, nil).Once()
+	proxmoxClient.EXPECT().ListVolumes(context.Background(), "node2", "local", "import").
+		Return([]string{"local:import/noble-server-cloudimg-amd64.img.qcow2"}, nil).Once()
+	proxmoxClient.EXPECT().CreateVM(context.Background(), "node2",
+		proxmox.VirtualMachineOption{Name: "name", Value: "test"},
+		proxmox.VirtualMachineOption{Name: "scsihw", Value: "virtio-scsi-single"},
+		proxmox.VirtualMachineOption{Name: "virtio0", Value: "local-lvm:0,import-from=local:import/noble-server-cloudimg-amd64.img.qcow2"},
+		proxmox.VirtualMachineOption{Name: "boot", Value: "order=virtio0"},
+		proxmox.VirtualMachineOption{Name: "net0", Value: "virtio,bridge=vmbr0"},
+		proxmox.VirtualMachineOption{Name: "ostype", Value: "l26"},
+	).Return(proxmox.VMCloneResponse{NewID: 124, Task: newTask()}, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	require.Equal(t, int64(124), *machineScope.ProxmoxMachine.Spec.VirtualMachineID)
+	require.Equal(t, "node2", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.ClonedFrom)
+	require.True(t, machineScope.InfraCluster.ProxmoxCluster.HasMachine(machineScope.Name(), false))
+}
+
+func TestEnsureVirtualMachine_CreateVMFromImage_NoNetwork(t *testing.T) {
+	machineScope, _ := setupImageReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = nil
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorContains(t, err, "require a default network device")
+}
+
+func TestImageFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		image    infrav1alpha1.ImageSource
+		expected string
+		err      string
+	}{
+		{name: "filename", image: infrav1alpha1.ImageSource{URL: "https://example.com/image", Filename: "noble.raw"}, expected: "noble.raw"},
+		{name: "qcow2", image: infrav1alpha1.ImageSource{URL: "https://example.com/images/noble.qcow2?version=1"}, expected: "noble.qcow2"},
+		{name: "img", image: infrav1alpha1.ImageSource{URL: "https://example.com/noble.img"}, expected: "noble.img.qcow2"},
+		{name: "no filename", image: infrav1alpha1.ImageSource{URL: "https://example.com/"}, err: "has no filename"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename, err := imageFilename(&test.image)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, filename)
+		})
+	}
+}
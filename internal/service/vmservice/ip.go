@@ -20,10 +20,12 @@ import (
 	"context"
 	"fmt"
 	"net/netip"
+	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
@@ -35,24 +37,53 @@ import (
 )
 
 func reconcileIPAddresses(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	if err := reconcileStaleIPAddressClaims(ctx, machineScope); err != nil {
+		return false, err
+	}
+
 	if machineScope.ProxmoxMachine.Status.IPAddresses != nil {
 		// skip machine has IpAddress already.
 		return false, nil
 	}
 	machineScope.Logger.V(4).Info("reconciling IPAddresses.")
-	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForStaticIPAllocationReason, clusterv1.ConditionSeverityInfo, "")
 
+	// all claims are handled, so the status shows every device which is still waiting.
 	addresses := make(map[string]infrav1alpha1.IPAddress)
+	allocations := make([]infrav1alpha1.IPAllocation, 0, 1)
+	for _, claim := range ipAddressClaims(machineScope) {
+		allocation, err := handleIPAddressForDevice(ctx, machineScope, claim)
+		if err != nil {
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForStaticIPAllocationReason, clusterv1.ConditionSeverityWarning, err.Error())
+			return true, errors.Wrapf(err, "unable to handle IPAddress for device %s", claim.device)
+		}
+		allocations = append(allocations, allocation)
+
+		if allocation.State == infrav1alpha1.IPAllocationStateBound {
+			addr := addresses[claim.device]
+			if claim.format == infrav1alpha1.IPV6Format {
+				addr.IPV6 = allocation.Address
+			} else {
+				addr.IPV4 = allocation.Address
+			}
+			addresses[claim.device] = addr
+		}
+	}
+	machineScope.ProxmoxMachine.Status.IPAllocations = allocations
 
-	// default device.
-	if requeue, err = handleDefaultDevice(ctx, machineScope, addresses); err != nil || requeue {
-		return true, errors.Wrap(err, "unable to handle default device")
+	if severity, message := ipAllocationMessage(allocations); message != "" {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForStaticIPAllocationReason, severity, message)
+		return true, nil
 	}
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForStaticIPAllocationReason, clusterv1.ConditionSeverityInfo, "")
 
-	if machineScope.ProxmoxMachine.Spec.Network != nil {
-		if requeue, err = handleAdditionalDevices(ctx, machineScope, addresses); err != nil || requeue {
-			return true, errors.Wrap(err, "unable to handle additional devices")
-		}
+	if machineScope.ProxmoxMachine.Status.TaskRef != nil {
+		// wait for the IP tag of the VM.
+		return true, nil
+	}
+
+	if machineScope.InfraCluster.ProxmoxCluster.Spec.DHCPMode != "" {
+		// the default network device gets its addresses by DHCP, the empty entry marks it as handled.
+		addresses[infrav1alpha1.DefaultNetworkDevice] = infrav1alpha1.IPAddress{}
 	}
 
 	// update the status.IpAddr.
@@ -62,6 +93,129 @@ func reconcileIPAddresses(ctx context.Context, machineScope *scope.MachineScope)
 	return true, nil
 }
 
+// ipAddressClaim is an IP address claim of a network device.
+type ipAddressClaim struct {
+	device  string
+	format  string
+	poolRef *corev1.TypedLocalObjectReference
+	// fallbacks are the pools the claim moves to in order once its pool is exhausted.
+	fallbacks []corev1.TypedLocalObjectReference
+}
+
+// ipAddressClaims returns the IP address claims of the network devices of the machine.
+func ipAddressClaims(machineScope *scope.MachineScope) []ipAddressClaim {
+	var claims []ipAddressClaim
+
+	// the default network device uses the pools of the cluster, or of the node IP pool of its node.
+	// node IP pools replace the pools of the cluster, including its additional pools.
+	node := machineScope.LocateProxmoxNode()
+	for _, format := range []string{infrav1alpha1.IPV4Format, infrav1alpha1.IPV6Format} {
+		config := machineScope.InfraCluster.ProxmoxCluster.Spec.IPv4Config
+		if format == infrav1alpha1.IPV6Format {
+			config = machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config
+		}
+		if config == nil {
+			continue
+		}
+		claim := ipAddressClaim{device: infrav1alpha1.DefaultNetworkDevice, format: format,
+			poolRef: machineScope.IPAMHelper.NodeInClusterIPPoolRef(node, format)}
+		if claim.poolRef == nil {
+			claim.fallbacks = machineScope.IPAMHelper.AdditionalInClusterIPPoolRefs(format)
+		}
+		claims = append(claims, claim)
+	}
+
+	if machineScope.ProxmoxMachine.Spec.Network != nil {
+		for _, net := range machineScope.ProxmoxMachine.Spec.Network.AdditionalDevices {
+			if net.IPv4PoolRef != nil {
+				claims = append(claims, ipAddressClaim{device: net.Name, format: infrav1alpha1.IPV4Format, poolRef: net.IPv4PoolRef, fallbacks: net.AdditionalIPv4PoolRefs})
+			}
+			if net.IPv6PoolRef != nil {
+				claims = append(claims, ipAddressClaim{device: net.Name, format: infrav1alpha1.IPV6Format, poolRef: net.IPv6PoolRef, fallbacks: net.AdditionalIPv6PoolRefs})
+			}
+		}
+	}
+
+	return claims
+}
+
+// reconcileStaleIPAddressClaims deletes the IP address claims of the machine which do not belong
+// to a network device of the spec anymore, so they do not hold IP addresses of the pools.
+func reconcileStaleIPAddressClaims(ctx context.Context, machineScope *scope.MachineScope) error {
+	desired := make(map[string]struct{})
+	for _, claim := range ipAddressClaims(machineScope) {
+		desired[ipAddressClaimName(machineScope.Name(), claim.device, claim.format)] = struct{}{}
+	}
+
+	claims, err := machineScope.IPAMHelper.ListIPAddressClaims(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list Ip address claims")
+	}
+
+	stale := make(map[string]struct{})
+	for i := range claims {
+		claim := &claims[i]
+		if _, ok := desired[claim.GetName()]; ok || !metav1.IsControlledBy(claim, machineScope.ProxmoxMachine) {
+			continue
+		}
+		stale[claim.GetName()] = struct{}{}
+		if !claim.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		machineScope.Logger.Info("deleting stale IPAddressClaim", "claim", claim.GetName())
+		if err := machineScope.IPAMHelper.DeleteIPAddressClaim(ctx, claim); err != nil {
+			return errors.Wrapf(err, "unable to delete stale Ip address claim %s", claim.GetName())
+		}
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+	var allocations []infrav1alpha1.IPAllocation
+	for _, allocation := range machineScope.ProxmoxMachine.Status.IPAllocations {
+		if _, ok := stale[allocation.ClaimName]; !ok {
+			allocations = append(allocations, allocation)
+		}
+	}
+	machineScope.ProxmoxMachine.Status.IPAllocations = allocations
+
+	return nil
+}
+
+// ipAddressClaimName returns the name of the IP address claim of a network device.
+func ipAddressClaimName(machineName, device, format string) string {
+	suffix := infrav1alpha1.DefaultSuffix
+	if format == infrav1alpha1.IPV6Format {
+		suffix += "6"
+	}
+	return formatIPAddressName(machineName, fmt.Sprintf("%s-%s", device, suffix))
+}
+
+// ipAllocationMessage describes the allocations which are not bound yet.
+func ipAllocationMessage(allocations []infrav1alpha1.IPAllocation) (clusterv1.ConditionSeverity, string) {
+	severity := clusterv1.ConditionSeverityInfo
+	var pending, failed []string
+	for _, a := range allocations {
+		switch a.State {
+		case infrav1alpha1.IPAllocationStatePending:
+			pending = append(pending, fmt.Sprintf("%s (%s)", a.Device, a.Format))
+		case infrav1alpha1.IPAllocationStateFailed:
+			severity = clusterv1.ConditionSeverityWarning
+			failed = append(failed, fmt.Sprintf("%s (%s): %s", a.Device, a.Format, a.Message))
+		}
+	}
+
+	var messages []string
+	if len(failed) > 0 {
+		messages = append(messages, "IP address allocation failed for "+strings.Join(failed, ", "))
+	}
+	if len(pending) > 0 {
+		messages = append(messages, "waiting for IP addresses of "+strings.Join(pending, ", "))
+	}
+	return severity, strings.Join(messages, "; ")
+}
+
 func findIPAddress(ctx context.Context, machineScope *scope.MachineScope, device string) (*ipamv1.IPAddress, error) {
 	key := client.ObjectKey{
 		Namespace: machineScope.Namespace(),
@@ -74,31 +228,87 @@ func formatIPAddressName(name, device string) string {
 	return fmt.Sprintf("%s-%s", name, device)
 }
 
-func machineHasIPAddress(machine *infrav1alpha1.ProxmoxMachine) bool {
-	return machine.Status.IPAddresses[infrav1alpha1.DefaultNetworkDevice] != (infrav1alpha1.IPAddress{})
+// machineHasIPAddress returns true if the default network device of the machine has its IP addresses,
+// or gets them by DHCP.
+func machineHasIPAddress(machineScope *scope.MachineScope) bool {
+	addr, ok := machineScope.ProxmoxMachine.Status.IPAddresses[infrav1alpha1.DefaultNetworkDevice]
+	if machineScope.InfraCluster.ProxmoxCluster.Spec.DHCPMode != "" {
+		return ok
+	}
+	return addr != (infrav1alpha1.IPAddress{})
 }
 
-func handleIPAddressForDevice(ctx context.Context, machineScope *scope.MachineScope, device, format string, ipamRef *corev1.TypedLocalObjectReference) (string, error) {
-	suffix := infrav1alpha1.DefaultSuffix
-	if format == infrav1alpha1.IPV6Format {
-		suffix += "6"
+// handleIPAddressForDevice creates the IP address claim of a network device if it does not exist,
+// and returns the state of its allocation. Claims of exhausted pools are replaced by claims of the
+// next fallback pool, the allocation remembers the pool until the new claim is created.
+func handleIPAddressForDevice(ctx context.Context, machineScope *scope.MachineScope, claim ipAddressClaim) (infrav1alpha1.IPAllocation, error) {
+	device := claim.device
+	allocation := infrav1alpha1.IPAllocation{
+		Device:    device,
+		Format:    claim.format,
+		ClaimName: ipAddressClaimName(machineScope.Name(), device, claim.format),
+		State:     infrav1alpha1.IPAllocationStatePending,
 	}
-	formattedDevice := fmt.Sprintf("%s-%s", device, suffix)
-	ipAddr, err := findIPAddress(ctx, machineScope, formattedDevice)
+	key := client.ObjectKey{Namespace: machineScope.Namespace(), Name: allocation.ClaimName}
+
+	ipAddr, err := machineScope.IPAMHelper.GetIPAddress(ctx, key)
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
-			return "", err
+			return allocation, err
 		}
-		machineScope.Logger.V(4).Info("IPAddress not found, creating it.", "device", device)
-		// IpAddress not yet created.
-		err = machineScope.IPAMHelper.CreateIPAddressClaim(ctx, machineScope.ProxmoxMachine, device, format, ipamRef)
-		if err != nil {
-			return "", errors.Wrapf(err, "unable to create Ip address claim for machine %s", machineScope.Name())
+
+		ipClaim, err := machineScope.IPAMHelper.GetIPAddressClaim(ctx, key)
+		if client.IgnoreNotFound(err) != nil {
+			return allocation, errors.Wrapf(err, "unable to get Ip address claim for machine %s", machineScope.Name())
 		}
-		return "", nil
+		if ipClaim == nil {
+			machineScope.Logger.V(4).Info("IPAddress not found, creating it.", "device", device)
+			// IpAddress not yet created.
+			poolRef := claim.poolRef
+			if fallback := fallbackPoolRef(claim, previousIPAllocationPool(machineScope, allocation.ClaimName)); fallback != nil {
+				poolRef = fallback
+			}
+			err = machineScope.IPAMHelper.CreateIPAddressClaim(ctx, machineScope.ProxmoxMachine, device, claim.format, poolRef)
+			if err != nil {
+				return allocation, errors.Wrapf(err, "unable to create Ip address claim for machine %s", machineScope.Name())
+			}
+
+			ipClaim, err = machineScope.IPAMHelper.GetIPAddressClaim(ctx, key)
+			if err != nil {
+				return allocation, errors.Wrapf(err, "unable to get Ip address claim for machine %s", machineScope.Name())
+			}
+		}
+		allocation.Pool = ipClaim.Spec.PoolRef.Name
+		if !ipClaim.GetDeletionTimestamp().IsZero() {
+			// the claim is replaced by a claim of the next pool.
+			allocation.Pool = previousIPAllocationPool(machineScope, allocation.ClaimName)
+			return allocation, nil
+		}
+
+		// the IPAM provider reports errors like exhausted pools in the ready condition of the claim.
+		if ready := conditions.Get(ipClaim, clusterv1.ReadyCondition); ready != nil && ready.Status == corev1.ConditionFalse && ready.Severity != clusterv1.ConditionSeverityInfo {
+			if next := nextPoolRef(claim, ipClaim.Spec.PoolRef); next != nil {
+				machineScope.Logger.Info("IP pool exhausted, falling back to the next pool", "device", device, "pool", ipClaim.Spec.PoolRef.Name, "next", next.Name)
+				if err := machineScope.IPAMHelper.DeleteIPAddressClaim(ctx, ipClaim); err != nil {
+					return allocation, errors.Wrapf(err, "unable to delete Ip address claim %s", ipClaim.GetName())
+				}
+				allocation.Pool = next.Name
+				return allocation, nil
+			}
+
+			allocation.State = infrav1alpha1.IPAllocationStateFailed
+			allocation.Message = ready.Message
+			if allocation.Message == "" {
+				allocation.Message = ready.Reason
+			}
+		}
+		return allocation, nil
 	}
 
+	allocation.Pool = ipAddr.Spec.PoolRef.Name
 	ip := ipAddr.Spec.Address
+	allocation.State = infrav1alpha1.IPAllocationStateBound
+	allocation.Address = ip
 
 	machineScope.Logger.V(4).Info("IPAddress found, ", "ip", ip, "device", device)
 
@@ -111,68 +321,46 @@ func handleIPAddressForDevice(ctx context.Context, machineScope *scope.MachineSc
 		machineScope.Logger.V(4).Info("adding virtual machine ip tag.")
 		t, err := machineScope.InfraCluster.ProxmoxClient.TagVM(ctx, vm, ipTag)
 		if err != nil {
-			return "", errors.Wrapf(err, "unable to add Ip tag to VirtualMachine %s", machineScope.Name())
+			return allocation, errors.Wrapf(err, "unable to add Ip tag to VirtualMachine %s", machineScope.Name())
 		}
 		machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(t.UPID))
-		return "", nil
 	}
 
-	return ip, nil
+	return allocation, nil
 }
 
-func handleDefaultDevice(ctx context.Context, machineScope *scope.MachineScope, addresses map[string]infrav1alpha1.IPAddress) (bool, error) {
-	// default network device ipv4.
-	if machineScope.InfraCluster.ProxmoxCluster.Spec.IPv4Config != nil {
-		ip, err := handleIPAddressForDevice(ctx, machineScope, infrav1alpha1.DefaultNetworkDevice, infrav1alpha1.IPV4Format, nil)
-		if err != nil || ip == "" {
-			return true, err
-		}
-		addresses[infrav1alpha1.DefaultNetworkDevice] = infrav1alpha1.IPAddress{
-			IPV4: ip,
+// previousIPAllocationPool returns the pool of the last reconciled allocation of a claim.
+func previousIPAllocationPool(machineScope *scope.MachineScope, claimName string) string {
+	for _, allocation := range machineScope.ProxmoxMachine.Status.IPAllocations {
+		if allocation.ClaimName == claimName {
+			return allocation.Pool
 		}
 	}
+	return ""
+}
 
-	// default network device ipv6.
-	if machineScope.InfraCluster.ProxmoxCluster.Spec.IPv6Config != nil {
-		ip, err := handleIPAddressForDevice(ctx, machineScope, infrav1alpha1.DefaultNetworkDevice, infrav1alpha1.IPV6Format, nil)
-		if err != nil || ip == "" {
-			return true, err
+// fallbackPoolRef returns the fallback pool of the claim with the given name, or nil if it is none of them.
+func fallbackPoolRef(claim ipAddressClaim, name string) *corev1.TypedLocalObjectReference {
+	for i := range claim.fallbacks {
+		if claim.fallbacks[i].Name == name {
+			return &claim.fallbacks[i]
 		}
-
-		addr := addresses[infrav1alpha1.DefaultNetworkDevice]
-		addr.IPV6 = ip
-		addresses[infrav1alpha1.DefaultNetworkDevice] = addr
 	}
-	return false, nil
+	return nil
 }
 
-func handleAdditionalDevices(ctx context.Context, machineScope *scope.MachineScope, addresses map[string]infrav1alpha1.IPAddress) (bool, error) {
-	// additional network devices.
-	for _, net := range machineScope.ProxmoxMachine.Spec.Network.AdditionalDevices {
-		if net.IPv4PoolRef != nil {
-			ip, err := handleIPAddressForDevice(ctx, machineScope, net.Name, infrav1alpha1.IPV4Format, net.IPv4PoolRef)
-			if err != nil || ip == "" {
-				return true, errors.Wrapf(err, "unable to handle IPAddress for device %s", net.Name)
-			}
-
-			addresses[net.Name] = infrav1alpha1.IPAddress{
-				IPV4: ip,
-			}
-		}
-
-		if net.IPv6PoolRef != nil {
-			ip, err := handleIPAddressForDevice(ctx, machineScope, net.Name, infrav1alpha1.IPV6Format, net.IPv6PoolRef)
-			if err != nil || ip == "" {
-				return true, errors.Wrapf(err, "unable to handle IPAddress for device %s", net.Name)
-			}
-
-			addresses[net.Name] = infrav1alpha1.IPAddress{
-				IPV6: ip,
-			}
+// nextPoolRef returns the fallback pool which follows the given pool, or nil if it is the last pool of the claim.
+func nextPoolRef(claim ipAddressClaim, current corev1.TypedLocalObjectReference) *corev1.TypedLocalObjectReference {
+	next := 0
+	for i, ref := range claim.fallbacks {
+		if ref.Kind == current.Kind && ref.Name == current.Name {
+			next = i + 1
 		}
 	}
-
-	return false, nil
+	if next >= len(claim.fallbacks) {
+		return nil
+	}
+	return &claim.fallbacks[next]
 }
 
 func isIPV4(ip string) bool {
@@ -22,8 +22,16 @@ import (
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
 	ipamicv1 "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
 )
 
@@ -38,6 +46,113 @@ func TestReconcileIPAddresses_CreateDefaultClaim(t *testing.T) {
 	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 }
 
+func TestReconcileIPAddresses_CreateNodeIPPoolClaim(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	cluster := machineScope.InfraCluster.ProxmoxCluster
+	cluster.Spec.NodeIPPools = []infrav1alpha1.NodeIPPool{{
+		Name:       "rack1",
+		Nodes:      []string{"pve-rack1"},
+		IPv4Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"10.10.11.2-10.10.11.100"}, Prefix: 24, Gateway: "10.10.11.1"},
+	}}
+	machineScope.ProxmoxMachine.Status.ProxmoxNode = ptr.To("pve-rack1")
+	require.NoError(t, machineScope.IPAMHelper.CreateOrUpdateInClusterIPPool(context.Background()))
+
+	requeue, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	claim, err := machineScope.IPAMHelper.GetIPAddressClaim(context.Background(), client.ObjectKey{Namespace: machineScope.Namespace(), Name: "test-net0-inet"})
+	require.NoError(t, err)
+	require.Equal(t, ipam.NodeInClusterPoolFormat(cluster, "rack1", infrav1alpha1.IPV4Format), claim.Spec.PoolRef.Name)
+	require.Equal(t, "InClusterIPPool", claim.Spec.PoolRef.Kind)
+}
+
+func TestReconcileIPAddresses_DHCP(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.IPv4Config = nil
+	machineScope.InfraCluster.ProxmoxCluster.Spec.DHCPMode = infrav1alpha1.DHCPModeIPv4
+
+	requeue, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {}}, machineScope.ProxmoxMachine.Status.IPAddresses)
+	require.True(t, machineHasIPAddress(machineScope))
+
+	claims, err := machineScope.IPAMHelper.ListIPAddressClaims(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, claims)
+}
+
+func TestReconcileIPAddresses_AllocationStates(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", IPv4PoolRef: &corev1.TypedLocalObjectReference{Kind: "GlobalInClusterIPPool", Name: "ipv4pool"}},
+			{Name: "net2", IPv6PoolRef: &corev1.TypedLocalObjectReference{Kind: "GlobalInClusterIPPool", Name: "ipv6pool"}},
+		},
+	}
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Tags = ipTag
+	machineScope.SetVirtualMachine(vm)
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createIPPools(t, kubeClient, machineScope)
+
+	// the pool of net2 is exhausted.
+	claim := &ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-net2-inet6", Namespace: machineScope.Namespace()}}
+	conditions.MarkFalse(claim, clusterv1.ReadyCondition, "PoolExhausted", clusterv1.ConditionSeverityError, "pool ipv6pool has no free addresses")
+	require.NoError(t, kubeClient.Create(context.Background(), claim))
+
+	requeue, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.IPAddresses)
+
+	expected := []infrav1alpha1.IPAllocation{
+		{Device: "net0", Format: "v4", ClaimName: "test-net0-inet", State: infrav1alpha1.IPAllocationStateBound, Address: "10.10.10.10"},
+		{Device: "net1", Format: "v4", ClaimName: "test-net1-inet", Pool: "ipv4pool", State: infrav1alpha1.IPAllocationStatePending},
+		{Device: "net2", Format: "v6", ClaimName: "test-net2-inet6", State: infrav1alpha1.IPAllocationStateFailed, Message: "pool ipv6pool has no free addresses"},
+	}
+	require.Equal(t, expected, machineScope.ProxmoxMachine.Status.IPAllocations)
+
+	condition := conditions.Get(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
+	require.Equal(t, infrav1alpha1.WaitingForStaticIPAllocationReason, condition.Reason)
+	require.Equal(t, clusterv1.ConditionSeverityWarning, condition.Severity)
+	require.Equal(t, "IP address allocation failed for net2 (v6): pool ipv6pool has no free addresses; waiting for IP addresses of net1 (v4)", condition.Message)
+}
+
+func TestReconcileIPAddresses_FallbackPool(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	cluster := machineScope.InfraCluster.ProxmoxCluster
+	cluster.Spec.AdditionalIPv4Configs = []ipamicv1.InClusterIPPoolSpec{{Addresses: []string{"10.10.20.2-10.10.20.100"}, Prefix: 24, Gateway: "10.10.20.1"}}
+	require.NoError(t, machineScope.IPAMHelper.CreateOrUpdateInClusterIPPool(context.Background()))
+
+	// the pool of the cluster is exhausted.
+	claim := &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-net0-inet", Namespace: machineScope.Namespace()},
+		Spec:       ipamv1.IPAddressClaimSpec{PoolRef: corev1.TypedLocalObjectReference{Kind: "InClusterIPPool", Name: ipam.InClusterPoolFormat(cluster, infrav1alpha1.IPV4Format)}},
+	}
+	conditions.MarkFalse(claim, clusterv1.ReadyCondition, "PoolExhausted", clusterv1.ConditionSeverityError, "pool has no free addresses")
+	require.NoError(t, kubeClient.Create(context.Background(), claim))
+
+	fallback := ipam.AdditionalInClusterPoolFormat(cluster, infrav1alpha1.IPV4Format, 1)
+	requeue, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, []infrav1alpha1.IPAllocation{
+		{Device: "net0", Format: "v4", ClaimName: "test-net0-inet", Pool: fallback, State: infrav1alpha1.IPAllocationStatePending},
+	}, machineScope.ProxmoxMachine.Status.IPAllocations)
+
+	// the claim of the exhausted pool is replaced by a claim of the additional pool.
+	requeue, err = reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	claim, err = machineScope.IPAMHelper.GetIPAddressClaim(context.Background(), client.ObjectKey{Namespace: machineScope.Namespace(), Name: "test-net0-inet"})
+	require.NoError(t, err)
+	require.Equal(t, fallback, claim.Spec.PoolRef.Name)
+	require.Equal(t, fallback, machineScope.ProxmoxMachine.Status.IPAllocations[0].Pool)
+}
+
 func TestReconcileIPAddresses_CreateAdditionalClaim(t *testing.T) {
 	machineScope, _, kubeClient := setupReconcilerTest(t)
 	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
@@ -150,3 +265,44 @@ func TestReconcileIPAddresses_IPV6(t *testing.T) {
 	require.True(t, requeue)
 	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 }
+
+func TestReconcileIPAddresses_DeleteStaleClaims(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.ProxmoxMachine.Status.IPAllocations = []infrav1alpha1.IPAllocation{
+		{Device: "net0", Format: "v4", ClaimName: "test-net0-inet", State: infrav1alpha1.IPAllocationStateBound, Address: "10.10.10.10"},
+		{Device: "net1", Format: "v4", ClaimName: "test-net1-inet", State: infrav1alpha1.IPAllocationStatePending},
+	}
+
+	newClaim := func(name string, controlled bool) *ipamv1.IPAddressClaim {
+		claim := &ipamv1.IPAddressClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   machineScope.Namespace(),
+			Annotations: map[string]string{clusterv1.ClusterNameAnnotation: machineScope.InfraCluster.ProxmoxCluster.GetName()},
+		}}
+		if controlled {
+			require.NoError(t, controllerutil.SetControllerReference(machineScope.ProxmoxMachine, claim, kubeClient.Scheme()))
+		}
+		require.NoError(t, kubeClient.Create(context.Background(), claim))
+		return claim
+	}
+	newClaim("test-net0-inet", true)
+	// net1 was removed from the spec.
+	newClaim("test-net1-inet", true)
+	newClaim("other-net1-inet", false)
+
+	requeue, err := reconcileIPAddresses(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+
+	var claims ipamv1.IPAddressClaimList
+	require.NoError(t, kubeClient.List(context.Background(), &claims))
+	var names []string
+	for _, claim := range claims.Items {
+		names = append(names, claim.GetName())
+	}
+	require.ElementsMatch(t, []string{"test-net0-inet", "other-net1-inet"}, names)
+	require.Equal(t, []infrav1alpha1.IPAllocation{
+		{Device: "net0", Format: "v4", ClaimName: "test-net0-inet", State: infrav1alpha1.IPAllocationStateBound, Address: "10.10.10.10"},
+	}, machineScope.ProxmoxMachine.Status.IPAllocations)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// emptyCDROM is the volume of a CD-ROM device without ISO image.
+const emptyCDROM = "none"
+
+// reconcileISODevices attaches the additional ISO images of the spec to the CD-ROM devices of the VM,
+// and ejects the ones which are detached. Proxmox changes the media of running VMs immediately.
+func reconcileISODevices(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	options := isoDeviceOptions(machineScope)
+	if len(options) == 0 {
+		return false, nil
+	}
+
+	machineScope.V(4).Info("reconciling iso devices")
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, options...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to configure iso devices of VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// isoDeviceOptions returns the options of the CD-ROM devices whose ISO image differs from the spec.
+func isoDeviceOptions(machineScope *scope.MachineScope) []proxmox.VirtualMachineOption {
+	vmConfig := machineScope.VirtualMachine.VirtualMachineConfig
+
+	var options []proxmox.VirtualMachineOption
+	for _, iso := range machineScope.ProxmoxMachine.Spec.ISOs {
+		image := iso.Image
+		if iso.Detach {
+			image = emptyCDROM
+		}
+
+		current := vmConfig.MergeIDEs()[iso.Device]
+		if current == "" {
+			current = vmConfig.MergeSATAs()[iso.Device]
+		}
+		if volume, _, _ := strings.Cut(current, ","); volume == image || (iso.Detach && current == "") {
+			continue
+		}
+
+		options = append(options, proxmox.VirtualMachineOption{
+			Name:  iso.Device,
+			Value: fmt.Sprintf("%s,media=cdrom", image),
+		})
+	}
+	return options
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestReconcileISODevices_Attach(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ISOs = []infrav1alpha1.ISODevice{
+		{Device: "ide2", Image: "local:iso/virtio-win.iso"},
+		{Device: "sata1", Image: "nfs:iso/packages.iso"},
+	}
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SATA1 = "nfs:iso/packages.iso,media=cdrom,size=1G"
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "ide2", Value: "local:iso/virtio-win.iso,media=cdrom"},
+	}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileISODevices(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+}
+
+func TestReconcileISODevices_Detach(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.ISOs = []infrav1alpha1.ISODevice{
+		{Device: "ide2", Image: "local:iso/virtio-win.iso", Detach: true},
+		{Device: "ide3", Image: "local:iso/packages.iso", Detach: true},
+	}
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.IDE2 = "local:iso/virtio-win.iso,media=cdrom"
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: "ide2", Value: "none,media=cdrom"},
+	}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileISODevices(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileISODevices_InSync(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ISOs = []infrav1alpha1.ISODevice{
+		{Device: "ide2", Image: "local:iso/virtio-win.iso", Detach: true},
+	}
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.IDE2 = "none,media=cdrom"
+	machineScope.SetVirtualMachine(vm)
+
+	requeue, err := reconcileISODevices(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
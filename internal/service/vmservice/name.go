@@ -0,0 +1,46 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"sigs.k8s.io/cluster-api/util"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/vmname"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// vmName returns the name of the VM of the machine, which is rendered from the VM name template
+// or defaults to the name of the ProxmoxMachine.
+func vmName(machineScope *scope.MachineScope) (string, error) {
+	nameTemplate := machineScope.ProxmoxMachine.Spec.VMNameTemplate
+	if nameTemplate == nil || *nameTemplate == "" {
+		return machineScope.ProxmoxMachine.GetName(), nil
+	}
+
+	role := vmname.RoleWorker
+	if util.IsControlPlaneMachine(machineScope.Machine) {
+		role = vmname.RoleControlPlane
+	}
+
+	return vmname.Render(*nameTemplate, vmname.Data{
+		ClusterName: machineScope.Machine.Spec.ClusterName,
+		MachineName: machineScope.ProxmoxMachine.GetName(),
+		Namespace:   machineScope.Namespace(),
+		Role:        role,
+		Random:      vmname.Random(machineScope.ProxmoxMachine.GetUID()),
+	})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func TestVMName(t *testing.T) {
+	tests := map[string]struct {
+		template      *string
+		controlPlane  bool
+		expected      string
+		expectedError string
+	}{
+		"defaults to the machine name": {
+			expected: "test",
+		},
+		"empty template": {
+			template: ptr.To(""),
+			expected: "test",
+		},
+		"worker": {
+			template: ptr.To("dc1-{{.ClusterName}}-{{.Role}}-{{.Random}}"),
+			expected: "dc1-capmox-worker-2161d",
+		},
+		"control plane": {
+			template:     ptr.To("{{.Namespace}}-{{.MachineName}}-{{.Role}}"),
+			controlPlane: true,
+			expected:     "default-test-control-plane",
+		},
+		"invalid template": {
+			template:      ptr.To("{{.ClusterName"),
+			expectedError: "invalid VM name template",
+		},
+		"unknown field": {
+			template:      ptr.To("{{.Unknown}}"),
+			expectedError: "unable to render VM name template",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			machineScope, _, _ := setupReconcilerTest(t)
+			machineScope.ProxmoxMachine.UID = types.UID("7c58dbd4-5e5e-4c8e-a9ad-0b5e0d1d2d34")
+			machineScope.Machine.Spec.ClusterName = "capmox"
+			if test.controlPlane {
+				machineScope.Machine.Labels = map[string]string{clusterv1.MachineControlPlaneLabel: ""}
+			}
+			machineScope.ProxmoxMachine.Spec.VMNameTemplate = test.template
+
+			name, err := vmName(machineScope)
+			if test.expectedError != "" {
+				require.ErrorContains(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, name)
+		})
+	}
+}
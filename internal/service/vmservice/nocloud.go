@@ -0,0 +1,129 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/nocloud"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/cloudinit"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+var (
+	// metadataServerURL is the URL of the NoCloud metadata server as seen from the VMs.
+	metadataServerURL string
+	// metadataNamespace is the namespace of the secrets served by the NoCloud metadata server.
+	metadataNamespace string
+)
+
+// SetMetadataServerURL sets the URL of the NoCloud metadata server, which machines with the
+// NoCloudNet cloud-init format fetch their data from.
+func SetMetadataServerURL(url string) {
+	metadataServerURL = url
+}
+
+// SetMetadataNamespace sets the namespace of the secrets served by the NoCloud metadata server.
+// It is the namespace of the manager, so write access to secrets is limited to it.
+func SetMetadataNamespace(namespace string) {
+	metadataNamespace = namespace
+}
+
+// seedNoCloudNet stores the cloud-init data in the secret served by the metadata server,
+// and points the SMBIOS serial of the VM to it.
+func seedNoCloudNet(ctx context.Context, machineScope *scope.MachineScope, userData []byte, metadata, network cloudinit.Renderer) error {
+	if metadataServerURL == "" || metadataNamespace == "" {
+		return errors.New("the NoCloud metadata server is not configured")
+	}
+
+	metaData, err := metadata.Render()
+	if err != nil {
+		return errors.Wrap(err, "unable to render metadata")
+	}
+	networkConfig, err := network.Render()
+	if err != nil {
+		return errors.Wrap(err, "unable to render network-config")
+	}
+
+	secret := &corev1.Secret{}
+	secret.Namespace = metadataNamespace
+	secret.Name = nocloud.SecretName(machineScope.Namespace(), machineScope.Name())
+	err = machineScope.CreateOrUpdateSecret(ctx, secret, func() error {
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[nocloud.MachineAnnotation] = machineScope.Namespace() + "/" + machineScope.Name()
+		if secret.Labels[nocloud.TokenLabel] == "" {
+			token, err := nocloud.NewToken()
+			if err != nil {
+				return err
+			}
+			if secret.Labels == nil {
+				secret.Labels = map[string]string{}
+			}
+			secret.Labels[nocloud.TokenLabel] = token
+		}
+		secret.Data = map[string][]byte{
+			nocloud.UserDataKey:      userData,
+			nocloud.MetaDataKey:      metaData,
+			nocloud.NetworkConfigKey: networkConfig,
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to store NoCloud data")
+	}
+
+	vm := machineScope.VirtualMachine
+	seed := nocloud.Seed(metadataServerURL, secret.Labels[nocloud.TokenLabel])
+	smbios := smbiosWithSerial(vm.VirtualMachineConfig.SMBios1, seed)
+	if smbios == vm.VirtualMachineConfig.SMBios1 {
+		return nil
+	}
+
+	client := machineScope.InfraCluster.ProxmoxClient
+	task, err := client.ConfigureVM(ctx, vm, proxmox.VirtualMachineOption{Name: optionSMBios1, Value: smbios})
+	if err != nil {
+		return errors.Wrap(err, "unable to set SMBIOS serial")
+	}
+	if _, err := client.WaitForTask(ctx, string(task.UPID), proxmox.TaskWaitOptions{}); err != nil {
+		return errors.Wrap(err, "unable to set SMBIOS serial")
+	}
+	vm.VirtualMachineConfig.SMBios1 = smbios
+
+	return nil
+}
+
+// deleteNoCloudData deletes the secret served by the metadata server. It is not in the namespace
+// of the machine, so it is not garbage collected with the ProxmoxMachine.
+func deleteNoCloudData(ctx context.Context, machineScope *scope.MachineScope) error {
+	if metadataNamespace == "" {
+		return nil
+	}
+	name := nocloud.SecretName(machineScope.Namespace(), machineScope.Name())
+	return errors.Wrap(machineScope.DeleteSecret(ctx, metadataNamespace, name), "unable to delete NoCloud data")
+}
+
+// smbiosWithSerial returns the smbios1 option with the serial replaced. The values are base64
+// encoded, as the serial contains characters which Proxmox does not accept otherwise.
+func smbiosWithSerial(smbios1, serial string) string {
+	return smbiosWithFields(smbios1, map[string]string{"serial": serial})
+}
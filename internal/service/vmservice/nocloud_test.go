@@ -0,0 +1,132 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/nocloud"
+)
+
+func TestReconcileBootstrapData_NoCloudNet(t *testing.T) {
+	machineScope, proxmoxClient, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CloudInitFormat = infrav1alpha1.CloudInitFormatNoCloudNet
+	SetMetadataServerURL("http://10.0.0.1:8082/")
+	SetMetadataNamespace("capmox-system")
+	t.Cleanup(func() {
+		SetMetadataServerURL("")
+		SetMetadataNamespace("")
+	})
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope)
+
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, mock.Anything).Return(newTask(), nil).Once()
+	proxmoxClient.EXPECT().WaitForTask(context.Background(), "result", mock.Anything).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, *machineScope.ProxmoxMachine.Status.BootstrapDataProvided)
+
+	var secret corev1.Secret
+	require.NoError(t, kubeClient.Get(context.Background(), types.NamespacedName{Namespace: "capmox-system", Name: nocloud.SecretName(machineScope.Namespace(), machineScope.Name())}, &secret))
+	token := secret.Labels[nocloud.TokenLabel]
+	require.Len(t, token, 32)
+	require.Contains(t, string(secret.Data[nocloud.MetaDataKey]), "local-hostname: test")
+	require.Contains(t, string(secret.Data[nocloud.NetworkConfigKey]), "10.10.10.10")
+	require.NotEmpty(t, secret.Data[nocloud.UserDataKey])
+	require.Equal(t, "default/test", secret.Annotations[nocloud.MachineAnnotation])
+	require.Empty(t, secret.OwnerReferences)
+
+	serial := base64.StdEncoding.EncodeToString([]byte("ds=nocloud-net;s=http://10.0.0.1:8082/" + token + "/"))
+	require.Equal(t, biosUUID+",serial="+serial+",base64=1", vm.VirtualMachineConfig.SMBios1)
+}
+
+func TestReconcileBootstrapData_NoCloudNetWithoutServer(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.CloudInitFormat = infrav1alpha1.CloudInitFormatNoCloudNet
+
+	vm := newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0")
+	vm.VirtualMachineConfig.SMBios1 = biosUUID
+	machineScope.SetVirtualMachine(vm)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	createIP4AddressResource(t, kubeClient, machineScope, infrav1alpha1.DefaultNetworkDevice, "10.10.10.10")
+	createBootstrapSecret(t, kubeClient, machineScope)
+
+	_, err := reconcileBootstrapData(context.Background(), machineScope)
+	require.ErrorContains(t, err, "the NoCloud metadata server is not configured")
+	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
+}
+
+func TestDeleteNoCloudData(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	SetMetadataNamespace("capmox-system")
+	t.Cleanup(func() { SetMetadataNamespace("") })
+
+	secret := &corev1.Secret{}
+	secret.Namespace = "capmox-system"
+	secret.Name = nocloud.SecretName(machineScope.Namespace(), machineScope.Name())
+	require.NoError(t, kubeClient.Create(context.Background(), secret))
+
+	require.NoError(t, deleteNoCloudData(context.Background(), machineScope))
+	require.True(t, apierrors.IsNotFound(kubeClient.Get(context.Background(), client.ObjectKeyFromObject(secret), secret)))
+
+	// the secret is gone already
+	require.NoError(t, deleteNoCloudData(context.Background(), machineScope))
+}
+
+func TestSmbiosWithSerial(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	tests := map[string]struct {
+		smbios1  string
+		expected string
+	}{
+		"empty": {
+			expected: "serial=" + encode("ds=nocloud-net") + ",base64=1",
+		},
+		"encodes plain values": {
+			smbios1:  "uuid=41ec1197-580f-460b-b41b-1dfefabe6e32,manufacturer=IONOS,serial=old",
+			expected: "uuid=41ec1197-580f-460b-b41b-1dfefabe6e32,manufacturer=" + encode("IONOS") + ",serial=" + encode("ds=nocloud-net") + ",base64=1",
+		},
+		"keeps encoded values": {
+			smbios1:  "uuid=41ec1197-580f-460b-b41b-1dfefabe6e32,manufacturer=" + encode("IONOS") + ",base64=1",
+			expected: "uuid=41ec1197-580f-460b-b41b-1dfefabe6e32,manufacturer=" + encode("IONOS") + ",serial=" + encode("ds=nocloud-net") + ",base64=1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, smbiosWithSerial(test.smbios1, "ds=nocloud-net"))
+		})
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// reconcilePersistentDisks attaches the ProxmoxDisks of the spec to the VM before it is started.
+// A ProxmoxDisk is claimed by recording the machine in its status, before its volume is attached.
+func reconcilePersistentDisks(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	storage := machineScope.ProxmoxMachine.Spec.Disks
+	if storage == nil || len(storage.PersistentDisks) == 0 {
+		return false, nil
+	}
+
+	vm := machineScope.VirtualMachine
+	if vm.IsRunning() || machineScope.ProxmoxMachine.Status.Ready {
+		// We only want to do this before the machine was started or is ready
+		return false, nil
+	}
+
+	attached := attachedVolumes(machineScope)
+	var pending []infrav1alpha1.PersistentDisk
+	for _, ref := range storage.PersistentDisks {
+		for _, volume := range storage.AdditionalVolumes {
+			if volume.Disk == ref.Disk {
+				return false, errors.Errorf("persistent disk %s may not use the disk of an additional volume", ref.Disk)
+			}
+		}
+		if attached[ref.Disk] == "" {
+			pending = append(pending, ref)
+		}
+	}
+	if len(pending) == 0 {
+		return false, nil
+	}
+
+	disks, err := machineScope.ListProxmoxDisks(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to list ProxmoxDisks")
+	}
+
+	// the disks which are attached already cannot be selected again.
+	used := make(map[string]bool)
+	for _, volume := range attached {
+		used[volume] = true
+	}
+
+	var options []proxmox.VirtualMachineOption
+	for _, ref := range pending {
+		disk, err := selectProxmoxDisk(disks, ref, machineScope.Name(), vm.Node, used)
+		if err != nil {
+			return false, err
+		}
+		if disk == nil {
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForPersistentDiskReason, clusterv1.ConditionSeverityInfo,
+				"no ProxmoxDisk can be attached as disk %s of node %s", ref.Disk, vm.Node)
+			return true, nil
+		}
+
+		if disk.Status.AttachedTo != machineScope.Name() {
+			machineScope.Info("attaching ProxmoxDisk", "disk", ref.Disk, "proxmoxDisk", disk.GetName())
+			disk.Status.AttachedTo = machineScope.Name()
+			if err := machineScope.UpdateProxmoxDiskStatus(ctx, disk); err != nil {
+				if apierrors.IsConflict(err) {
+					// the disk was changed, possibly claimed by another machine.
+					return true, nil
+				}
+				return false, errors.Wrapf(err, "unable to claim ProxmoxDisk %s", disk.GetName())
+			}
+		}
+		used[disk.Status.Volume] = true
+		options = append(options, proxmox.VirtualMachineOption{Name: ref.Disk, Value: disk.Status.Volume})
+	}
+
+	machineScope.V(4).Info("reconciling persistent disks")
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, vm, options...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to configure persistent disks of VM %s", machineScope.Name())
+	}
+
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+// selectProxmoxDisk returns the ProxmoxDisk to attach to a disk of the VM on the given node, or nil if none can be
+// attached yet. A disk which was claimed by the machine before is preferred over one which is not attached.
+func selectProxmoxDisk(disks []infrav1alpha1.ProxmoxDisk, ref infrav1alpha1.PersistentDisk, machine, node string, used map[string]bool) (*infrav1alpha1.ProxmoxDisk, error) {
+	selector := labels.Nothing()
+	if ref.Selector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(ref.Selector); err != nil {
+			return nil, errors.Wrapf(err, "invalid selector of persistent disk %s", ref.Disk)
+		}
+	}
+
+	var selected *infrav1alpha1.ProxmoxDisk
+	for i := range disks {
+		disk := &disks[i]
+		if disk.GetName() != ref.Name && !selector.Matches(labels.Set(disk.GetLabels())) {
+			continue
+		}
+		if used[disk.Status.Volume] || !disk.CanAttachTo(machine, node) {
+			continue
+		}
+		if disk.Status.AttachedTo == machine {
+			return disk, nil
+		}
+		if selected == nil {
+			selected = disk
+		}
+	}
+	return selected, nil
+}
+
+// releasePersistentDisks removes the machine from the status of the ProxmoxDisks it claimed,
+// once its VM was destroyed. Their volumes are kept, as they do not belong to the VM.
+func releasePersistentDisks(ctx context.Context, machineScope *scope.MachineScope) error {
+	storage := machineScope.ProxmoxMachine.Spec.Disks
+	if storage == nil || len(storage.PersistentDisks) == 0 {
+		return nil
+	}
+
+	disks, err := machineScope.ListProxmoxDisks(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list ProxmoxDisks")
+	}
+
+	for i := range disks {
+		disk := &disks[i]
+		if disk.Status.AttachedTo != machineScope.Name() {
+			continue
+		}
+		machineScope.Info("detaching ProxmoxDisk", "proxmoxDisk", disk.GetName())
+		disk.Status.AttachedTo = ""
+		if err := machineScope.UpdateProxmoxDiskStatus(ctx, disk); err != nil {
+			return errors.Wrapf(err, "unable to release ProxmoxDisk %s", disk.GetName())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func createProxmoxDisk(t *testing.T, c client.Client, name, node string, shared bool, attachedTo string) *infrav1alpha1.ProxmoxDisk {
+	disk := &infrav1alpha1.ProxmoxDisk{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{"pool": "data"},
+		},
+		Spec: infrav1alpha1.ProxmoxDiskSpec{Node: node, Storage: "local-lvm", SizeGB: 10},
+	}
+	require.NoError(t, c.Create(context.Background(), disk))
+	disk.Status = infrav1alpha1.ProxmoxDiskStatus{
+		Ready:      true,
+		Volume:     "local-lvm:vm-9999-default-" + name,
+		Shared:     shared,
+		AttachedTo: attachedTo,
+	}
+	require.NoError(t, c.Status().Update(context.Background(), disk))
+	return disk
+}
+
+func TestReconcilePersistentDisks_Name(t *testing.T) {
+	machineScope, proxmoxClient, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		PersistentDisks: []infrav1alpha1.PersistentDisk{{Disk: "scsi1", Name: "data"}},
+	}
+	disk := createProxmoxDisk(t, kubeClient, "data", "node1", false, "")
+	vm := newStoppedVM()
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{proxmox.VirtualMachineOption{Name: "scsi1", Value: "local-lvm:vm-9999-default-data"}}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcilePersistentDisks(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(disk), disk))
+	require.Equal(t, "test", disk.Status.AttachedTo)
+}
+
+func TestReconcilePersistentDisks_Selector(t *testing.T) {
+	machineScope, proxmoxClient, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		PersistentDisks: []infrav1alpha1.PersistentDisk{
+			{Disk: "scsi1", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "data"}}},
+			{Disk: "scsi2", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "data"}}},
+		},
+	}
+	// disks which are attached to other machines or on other nodes are not selected.
+	createProxmoxDisk(t, kubeClient, "attached", "node1", false, "other")
+	createProxmoxDisk(t, kubeClient, "other-node", "node2", false, "")
+	createProxmoxDisk(t, kubeClient, "shared", "node2", true, "")
+	createProxmoxDisk(t, kubeClient, "claimed", "node1", false, "test")
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.SCSI1 = "local-lvm:vm-9999-default-claimed,size=10G"
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{proxmox.VirtualMachineOption{Name: "scsi2", Value: "local-lvm:vm-9999-default-shared"}}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcilePersistentDisks(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcilePersistentDisks_Waiting(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		PersistentDisks: []infrav1alpha1.PersistentDisk{{Disk: "scsi1", Name: "data"}},
+	}
+	createProxmoxDisk(t, kubeClient, "data", "node1", false, "other")
+	machineScope.SetVirtualMachine(newStoppedVM())
+
+	requeue, err := reconcilePersistentDisks(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.TaskRef)
+}
+
+func TestReleasePersistentDisks(t *testing.T) {
+	machineScope, _, kubeClient := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		PersistentDisks: []infrav1alpha1.PersistentDisk{{Disk: "scsi1", Name: "data"}},
+	}
+	disk := createProxmoxDisk(t, kubeClient, "data", "node1", false, "test")
+	other := createProxmoxDisk(t, kubeClient, "other", "node1", false, "other")
+
+	require.NoError(t, releasePersistentDisks(context.Background(), machineScope))
+
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(disk), disk))
+	require.Empty(t, disk.Status.AttachedTo)
+	require.NoError(t, kubeClient.Get(context.Background(), client.ObjectKeyFromObject(other), other))
+	require.Equal(t, "other", other.Status.AttachedTo)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// ReconcilePlan records the Proxmox operations ReconcileVM would perform for the machine in its status,
+// without performing any of them. The time of the plan only changes with its operations.
+func ReconcilePlan(ctx context.Context, machineScope *scope.MachineScope) error {
+	plan, err := planOperations(ctx, machineScope)
+	if err != nil {
+		return err
+	}
+
+	status := &machineScope.ProxmoxMachine.Status
+	if status.Plan != nil {
+		plan.PlannedTime = status.Plan.PlannedTime
+		if reflect.DeepEqual(*status.Plan, plan) {
+			return nil
+		}
+	}
+
+	plan.PlannedTime = metav1.Now()
+	status.Plan = &plan
+	return nil
+}
+
+// planOperations returns the clone of a machine without a VM, or the config changes and disk operations of an existing VM.
+func planOperations(ctx context.Context, machineScope *scope.MachineScope) (infrav1alpha1.MachinePlan, error) {
+	vmRef, err := FindVM(ctx, machineScope)
+	switch {
+	case errors.Is(err, ErrVMNotCreated):
+		clone, err := planClone(ctx, machineScope)
+		if err != nil {
+			return infrav1alpha1.MachinePlan{}, err
+		}
+		return infrav1alpha1.MachinePlan{Clone: clone, DiskOperations: planDiskOperations(machineScope, nil)}, nil
+	case err != nil:
+		return infrav1alpha1.MachinePlan{}, err
+	}
+
+	machineScope.SetVirtualMachine(vmRef)
+
+	var plan infrav1alpha1.MachinePlan
+	for _, d := range detectConfigDrift(machineScope) {
+		plan.ConfigChanges = append(plan.ConfigChanges, d.description)
+	}
+
+	// disks are only changed before the VM is started.
+	if !vmRef.IsRunning() && !machineScope.ProxmoxMachine.Status.Ready {
+		plan.DiskOperations = planDiskOperations(machineScope, attachedVolumes(machineScope))
+	}
+	return plan, nil
+}
+
+// planClone returns the VM which would be created for the machine. The target node is selected
+// like for the actual clone, but its memory is not reserved.
+func planClone(ctx context.Context, machineScope *scope.MachineScope) (*infrav1alpha1.ClonePlan, error) {
+	options, err := cloneOptions(ctx, machineScope, previewNextNode)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan the clone of the VM")
+	}
+
+	clone := &infrav1alpha1.ClonePlan{
+		Name:       options.Name,
+		SourceNode: options.Node,
+		TargetNode: options.Target,
+		Storage:    options.Storage,
+		Full:       options.Full == 1,
+	}
+	if image := machineScope.ProxmoxMachine.Spec.Image; image != nil {
+		clone.ImageURL = image.URL
+	} else {
+		clone.TemplateID = ptr.To(machineScope.ProxmoxMachine.GetTemplateID())
+	}
+	return clone, nil
+}
+
+// planDiskOperations returns the boot volume resize and the data and persistent disks which are not attached yet.
+func planDiskOperations(machineScope *scope.MachineScope, attached map[string]string) []string {
+	disks := machineScope.ProxmoxMachine.Spec.Disks
+	if disks == nil {
+		return nil
+	}
+
+	var operations []string
+	if bv := disks.BootVolume; bv != nil {
+		operations = append(operations, fmt.Sprintf("resize %s to %s", bv.Disk, bv.FormatSize()))
+	}
+	for _, disk := range disks.AdditionalVolumes {
+		if attached[disk.Disk] == "" {
+			operations = append(operations, fmt.Sprintf("add %s with %dG on %s", disk.Disk, disk.SizeGB, disk.Storage))
+		}
+	}
+	for _, ref := range disks.PersistentDisks {
+		if attached[ref.Disk] != "" {
+			continue
+		}
+		if ref.Name != "" {
+			operations = append(operations, fmt.Sprintf("attach ProxmoxDisk %s as %s", ref.Name, ref.Disk))
+		} else {
+			operations = append(operations, fmt.Sprintf("attach a selected ProxmoxDisk as %s", ref.Disk))
+		}
+	}
+	return operations
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+func TestReconcilePlan_Clone(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes = []string{"node1", "node2", "node3"}
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		BootVolume:        &infrav1alpha1.DiskSize{Disk: "scsi0", SizeGB: 50},
+		AdditionalVolumes: []infrav1alpha1.DataDisk{{Disk: "scsi1", Storage: "local-lvm", SizeGB: 10}},
+	}
+
+	previewNextNode = func(context.Context, *scope.MachineScope) (string, error) {
+		return "node3", nil
+	}
+	t.Cleanup(func() { previewNextNode = scheduler.PreviewVM })
+
+	// the mock fails the test if the VM is cloned.
+	require.NoError(t, ReconcilePlan(context.Background(), machineScope))
+
+	plan := machineScope.ProxmoxMachine.Status.Plan
+	require.NotNil(t, plan)
+	require.Equal(t, &infrav1alpha1.ClonePlan{Name: "test", SourceNode: "node1", TemplateID: ptr.To[int32](123), TargetNode: "node3"}, plan.Clone)
+	require.Equal(t, []string{"resize scsi0 to 50G", "add scsi1 with 10G on local-lvm"}, plan.DiskOperations)
+	require.False(t, plan.PlannedTime.IsZero())
+	require.Nil(t, machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.False(t, machineScope.InfraCluster.ProxmoxCluster.HasMachine(machineScope.Name(), false))
+}
+
+func TestReconcilePlan_ConfigChanges(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.NumSockets = 2
+	machineScope.ProxmoxMachine.Spec.Disks = &infrav1alpha1.Storage{
+		AdditionalVolumes: []infrav1alpha1.DataDisk{{Disk: "scsi1", Storage: "local-lvm", SizeGB: 10}},
+	}
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Sockets = 1
+	vm.VirtualMachineConfig.SCSI1 = "local-lvm:vm-123-disk-1,size=10G"
+	vm.VirtualMachineConfig.Tags = "capmox_default_test;capmox-machine_test"
+	machineScope.SetVirtualMachineID(123)
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(vm, nil).Twice()
+
+	require.NoError(t, ReconcilePlan(context.Background(), machineScope))
+
+	plan := machineScope.ProxmoxMachine.Status.Plan
+	require.NotNil(t, plan)
+	require.Nil(t, plan.Clone)
+	require.Equal(t, []string{"sockets 1 instead of 2"}, plan.ConfigChanges)
+	require.Empty(t, plan.DiskOperations)
+
+	// an unchanged plan keeps its time.
+	planned := plan.PlannedTime
+	require.NoError(t, ReconcilePlan(context.Background(), machineScope))
+	require.Equal(t, planned, machineScope.ProxmoxMachine.Status.Plan.PlannedTime)
+}
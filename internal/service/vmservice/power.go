@@ -31,13 +31,26 @@ import (
 )
 
 func reconcilePowerState(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
-	if !machineHasIPAddress(machineScope.ProxmoxMachine) {
+	if !machineHasIPAddress(machineScope) {
 		machineScope.V(4).Info("ip address not set for machine")
 		// machine doesn't have an ip address yet
 		// needs to reconcile again
 		return true, nil
 	}
 
+	if machineScope.ProxmoxMachine.Status.Ready {
+		switch machineScope.ProxmoxMachine.Spec.PowerState {
+		case infrav1alpha1.PowerStateSuspended, infrav1alpha1.PowerStateHibernated:
+			return reconcileSuspension(ctx, machineScope)
+		}
+	}
+
+	// the hooks hold the VM only before it is started for the first time.
+	if !machineScope.ProxmoxMachine.Status.Ready && machineScope.VirtualMachine.IsStopped() &&
+		waitForHooks(machineScope, infrav1alpha1.PreStartHookAnnotationPrefix) {
+		return true, nil
+	}
+
 	machineScope.V(4).Info("ensuring machine is started")
 	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.PoweringOnReason, clusterv1.ConditionSeverityInfo, "")
 
@@ -77,3 +90,50 @@ func startVirtualMachine(ctx context.Context, client capmox.Client, vm *proxmox.
 	// nothing to do.
 	return nil, nil
 }
+
+// reconcileSuspension suspends or hibernates the VM of a ready machine according to its desired power state.
+func reconcileSuspension(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	powerState := machineScope.ProxmoxMachine.Spec.PowerState
+	machineScope.V(4).Info("ensuring machine is suspended", "powerState", powerState)
+
+	t, err := suspendVirtualMachine(ctx, machineScope.InfraCluster.ProxmoxClient, machineScope.VirtualMachine, powerState)
+	if err != nil {
+		return false, err
+	}
+
+	if t != nil {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.SuspendingReason, clusterv1.ConditionSeverityInfo, "")
+		machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(t.UPID))
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func suspendVirtualMachine(ctx context.Context, client capmox.Client, vm *proxmox.VirtualMachine, powerState infrav1alpha1.PowerState) (*proxmox.Task, error) {
+	// a stopped VM, which includes a hibernated one, has no state which could be suspended.
+	if vm.IsStopped() {
+		return nil, nil
+	}
+
+	if powerState == infrav1alpha1.PowerStateHibernated {
+		t, err := client.HibernateVM(ctx, vm)
+		if err != nil {
+			return nil, fmt.Errorf("unable to hibernate the virtual machine %d: %w", vm.VMID, err)
+		}
+
+		return t, nil
+	}
+
+	if !vm.IsPaused() {
+		t, err := client.SuspendVM(ctx, vm)
+		if err != nil {
+			return nil, fmt.Errorf("unable to suspend the virtual machine %d: %w", vm.VMID, err)
+		}
+
+		return t, nil
+	}
+
+	// nothing to do.
+	return nil, nil
+}
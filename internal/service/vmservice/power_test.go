@@ -20,8 +20,12 @@ import (
 	"context"
 	"testing"
 
-	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/luthermonson/go-proxmox"
 	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
 )
 
 func TestReconcilePowerState_MissingIPAddress(t *testing.T) {
@@ -90,3 +94,87 @@ func TestStartVirtualMachine_Started(t *testing.T) {
 	require.NoError(t, err)
 	require.Nil(t, task)
 }
+
+func TestReconcilePowerState_IgnoresPowerStateOfUnreadyMachine(t *testing.T) {
+	ctx := context.TODO()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.ProxmoxMachine.Spec.PowerState = infrav1alpha1.PowerStateHibernated
+
+	vm := newStoppedVM()
+	machineScope.SetVirtualMachine(vm)
+	proxmoxClient.EXPECT().StartVM(ctx, vm).Return(newTask(), nil).Once()
+
+	requeue, err := reconcilePowerState(ctx, machineScope)
+	require.True(t, requeue)
+	require.NoError(t, err)
+}
+
+func TestReconcilePowerState_Suspend(t *testing.T) {
+	ctx := context.TODO()
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.PowerState = infrav1alpha1.PowerStateSuspended
+
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	proxmoxClient.EXPECT().SuspendVM(ctx, vm).Return(newTask(), nil).Once()
+
+	requeue, err := reconcilePowerState(ctx, machineScope)
+	require.True(t, requeue)
+	require.NoError(t, err)
+	require.NotEmpty(t, *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.True(t, conditions.IsFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+	require.Equal(t, infrav1alpha1.SuspendingReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestSuspendVirtualMachine(t *testing.T) {
+	tests := []struct {
+		name       string
+		vm         *proxmox.VirtualMachine
+		powerState infrav1alpha1.PowerState
+		expect     func(*proxmoxtest.MockClient_Expecter, *proxmox.VirtualMachine)
+	}{
+		{
+			name:       "suspend running",
+			vm:         newRunningVM(),
+			powerState: infrav1alpha1.PowerStateSuspended,
+			expect: func(e *proxmoxtest.MockClient_Expecter, vm *proxmox.VirtualMachine) {
+				e.SuspendVM(context.TODO(), vm).Return(newTask(), nil).Once()
+			},
+		},
+		{name: "suspend paused", vm: newPausedVM(), powerState: infrav1alpha1.PowerStateSuspended},
+		{name: "suspend stopped", vm: newStoppedVM(), powerState: infrav1alpha1.PowerStateSuspended},
+		{
+			name:       "hibernate running",
+			vm:         newRunningVM(),
+			powerState: infrav1alpha1.PowerStateHibernated,
+			expect: func(e *proxmoxtest.MockClient_Expecter, vm *proxmox.VirtualMachine) {
+				e.HibernateVM(context.TODO(), vm).Return(newTask(), nil).Once()
+			},
+		},
+		{
+			name:       "hibernate paused",
+			vm:         newPausedVM(),
+			powerState: infrav1alpha1.PowerStateHibernated,
+			expect: func(e *proxmoxtest.MockClient_Expecter, vm *proxmox.VirtualMachine) {
+				e.HibernateVM(context.TODO(), vm).Return(newTask(), nil).Once()
+			},
+		},
+		{name: "hibernate hibernated", vm: newHibernatedVM(), powerState: infrav1alpha1.PowerStateHibernated},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, proxmoxClient, _ := setupReconcilerTest(t)
+			if test.expect != nil {
+				test.expect(proxmoxClient.EXPECT(), test.vm)
+			}
+
+			task, err := suspendVirtualMachine(context.TODO(), proxmoxClient, test.vm, test.powerState)
+			require.NoError(t, err)
+			require.Equal(t, test.expect != nil, task != nil)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/taskservice"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// provisioningHeld returns whether the machine waits for the approval of its bootstrap or for hooks.
+func provisioningHeld(machineScope *scope.MachineScope) bool {
+	switch conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition) {
+	case infrav1alpha1.WaitingForBootstrapApprovalReason:
+		return !machineScope.ProxmoxMachine.IsBootstrapApproved()
+	case infrav1alpha1.WaitingForHooksReason:
+		return len(pendingHooks(machineScope, infrav1alpha1.PreCloneHookAnnotationPrefix)) > 0 ||
+			len(pendingHooks(machineScope, infrav1alpha1.PreStartHookAnnotationPrefix)) > 0
+	default:
+		return false
+	}
+}
+
+// reconcileProvisioningTimeout remediates machines which did not become ready within the
+// provisioning timeout. The partially provisioned VM is deleted, and recreated by the
+// following reconciliations once the deletion finished, until the retries are exhausted and the machine is marked failed.
+func reconcileProvisioningTimeout(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	remediation := machineScope.ProxmoxMachine.Spec.ProvisioningRemediation
+	status := &machineScope.ProxmoxMachine.Status
+	if remediation == nil || status.Ready {
+		return false, nil
+	}
+
+	// the VM is only forgotten once its deletion finished, otherwise it would leak.
+	if conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition) == infrav1alpha1.DeletingTimedOutVMReason {
+		if inFlight, err := taskservice.ReconcileInFlightTask(ctx, machineScope); err != nil || inFlight {
+			return true, err
+		}
+		return true, recreateTimedOutVM(machineScope)
+	}
+
+	// the timeout starts once the machine is no longer held.
+	if provisioningHeld(machineScope) {
+		status.ProvisioningStartTime = nil
+		return false, nil
+	}
+
+	if status.ProvisioningStartTime == nil {
+		status.ProvisioningStartTime = ptr.To(metav1.Now())
+		return false, nil
+	}
+
+	if time.Since(status.ProvisioningStartTime.Time) < remediation.Timeout.Duration {
+		return false, nil
+	}
+
+	if status.ProvisioningRetries >= remediation.MaxRetries {
+		err := errors.Errorf("provisioning did not finish within %s", remediation.Timeout.Duration)
+		machineScope.Error(err, "giving up on provisioning", "retries", status.ProvisioningRetries)
+		machineScope.SetFailureMessage(err)
+		machineScope.SetFailureReason(capierrors.CreateMachineError)
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.ProvisioningTimeoutReason, clusterv1.ConditionSeverityError, err.Error())
+		return true, nil
+	}
+
+	machineScope.Info("provisioning timed out, recreating VM", "timeout", remediation.Timeout.Duration, "retries", status.ProvisioningRetries)
+
+	if vmID := machineScope.ProxmoxMachine.GetVirtualMachineID(); vmID > 0 {
+		task, err := machineScope.InfraCluster.ProxmoxClient.DeleteVM(ctx, machineScope.LocateProxmoxNode(), vmID, deleteOptions)
+		if err != nil && !VMNotFound(err) {
+			return false, errors.Wrapf(err, "unable to delete timed out VM %d", vmID)
+		}
+
+		if err == nil {
+			machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.DeletingTimedOutVMReason, clusterv1.ConditionSeverityInfo,
+				fmt.Sprintf("provisioning did not finish within %s, deleting VM %d", remediation.Timeout.Duration, vmID))
+			return true, nil
+		}
+	}
+
+	return true, recreateTimedOutVM(machineScope)
+}
+
+// recreateTimedOutVM resets everything describing the deleted VM of a timed out provisioning,
+// so provisioning starts over.
+func recreateTimedOutVM(machineScope *scope.MachineScope) error {
+	remediation := machineScope.ProxmoxMachine.Spec.ProvisioningRemediation
+	status := &machineScope.ProxmoxMachine.Status
+
+	machineScope.InfraCluster.ProxmoxCluster.RemoveNodeLocation(machineScope.Name(), util.IsControlPlaneMachine(machineScope.Machine))
+	if err := machineScope.InfraCluster.PatchObject(); err != nil {
+		return err
+	}
+
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = nil
+	machineScope.ProxmoxMachine.Spec.ProviderID = nil
+	status.ProxmoxNode = nil
+	status.TaskRef = nil
+	status.RetryAfter = metav1.Time{}
+	status.BootstrapDataProvided = nil
+	status.ObservedBootstrapSecretVersion = ""
+	status.ClonedFrom = nil
+	status.Network = nil
+
+	status.ProvisioningRetries++
+	status.ProvisioningStartTime = ptr.To(metav1.Now())
+
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.ProvisioningTimeoutReason, clusterv1.ConditionSeverityWarning,
+		fmt.Sprintf("provisioning did not finish within %s, recreating VM", remediation.Timeout.Duration))
+
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func TestReconcileProvisioningTimeout_Disabled(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+
+	requeue, err := reconcileProvisioningTimeout(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.ProvisioningStartTime)
+}
+
+func TestReconcileProvisioningTimeout_SetsStartTime(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ProvisioningRemediation = &infrav1alpha1.ProvisioningRemediation{
+		Timeout: metav1.Duration{Duration: time.Hour},
+	}
+
+	requeue, err := reconcileProvisioningTimeout(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.NotNil(t, machineScope.ProxmoxMachine.Status.ProvisioningStartTime)
+
+	requeue, err = reconcileProvisioningTimeout(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
+func TestReconcileProvisioningTimeout_WaitingForBootstrapApproval(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ProvisioningRemediation = &infrav1alpha1.ProvisioningRemediation{
+		Timeout: metav1.Duration{Duration: time.Minute},
+	}
+	machineScope.ProxmoxMachine.Spec.RequireBootstrapApproval = true
+	machineScope.ProxmoxMachine.Status.ProvisioningStartTime = ptr.To(metav1.NewTime(time.Now().Add(-time.Hour)))
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition, infrav1alpha1.WaitingForBootstrapApprovalReason, clusterv1.ConditionSeverityInfo, "")
+
+	// the mock fails the test if the held VM is deleted.
+	requeue, err := reconcileProvisioningTimeout(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.ProvisioningStartTime)
+}
+
+func TestReconcileProvisioningTimeout_RecreatesVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ProvisioningRemediation = &infrav1alpha1.ProvisioningRemediation{
+		Timeout:    metav1.Duration{Duration: time.Minute},
+		MaxRetries: 1,
+	}
+	machineScope.ProxmoxMachine.Spec.VirtualMachineID = ptr.To[int64](123)
+	machineScope.ProxmoxMachine.Status.ProxmoxNode = ptr.To("node1")
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To("UPID:node1:stuck")
+	machineScope.ProxmoxMachine.Status.ProvisioningStartTime = ptr.To(metav1.NewTime(time.Now().Add(-time.Hour)))
+	machineScope.InfraCluster.ProxmoxCluster.UpdateNodeLocation(machineScope.Name(), "node1", false)
+
+	proxmoxClient.EXPECT().DeleteVM(context.Background(), "node1", int64(123), deleteOptions).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileProvisioningTimeout(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, infrav1alpha1.DeletingTimedOutVMReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+
+	// the VM is kept until its deletion finished.
+	proxmoxClient.EXPECT().GetTask(context.Background(), "result").Return(&proxmox.Task{IsRunning: true}, nil).Once()
+
+	requeue, err = reconcileProvisioningTimeout(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.EqualValues(t, 123, machineScope.ProxmoxMachine.GetVirtualMachineID())
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Zero(t, machineScope.ProxmoxMachine.Status.ProvisioningRetries)
+
+	proxmoxClient.EXPECT().GetTask(context.Background(), "result").Return(&proxmox.Task{IsSuccessful: true}, nil).Once()
+
+	requeue, err = reconcileProvisioningTimeout(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Nil(t, machineScope.ProxmoxMachine.Spec.VirtualMachineID)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.Nil(t, machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, int32(1), machineScope.ProxmoxMachine.Status.ProvisioningRetries)
+	require.Empty(t, machineScope.InfraCluster.ProxmoxCluster.GetNode(machineScope.Name(), false))
+	require.False(t, machineScope.HasFailed())
+	require.Equal(t, infrav1alpha1.ProvisioningTimeoutReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestReconcileProvisioningTimeout_RetriesExhausted(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.ProvisioningRemediation = &infrav1alpha1.ProvisioningRemediation{
+		Timeout:    metav1.Duration{Duration: time.Minute},
+		MaxRetries: 1,
+	}
+	machineScope.ProxmoxMachine.Status.ProvisioningRetries = 1
+	machineScope.ProxmoxMachine.Status.ProvisioningStartTime = ptr.To(metav1.NewTime(time.Now().Add(-time.Hour)))
+
+	requeue, err := reconcileProvisioningTimeout(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.True(t, machineScope.HasFailed())
+}
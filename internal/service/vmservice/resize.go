@@ -0,0 +1,103 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/scope"
+)
+
+// reconcileResize applies changes of the compute resources in the spec to the VM of a ready machine,
+// once per generation of the ProxmoxMachine.
+// Changes which Proxmox cannot hotplug stay pending, and are applied by a reboot if the resize policy allows it.
+func reconcileResize(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	policy := machineScope.ProxmoxMachine.Spec.ResizePolicy
+	if !machineScope.ProxmoxMachine.Status.Ready || policy == "" || policy == infrav1alpha1.ResizePolicyDisabled {
+		return false, nil
+	}
+
+	// only changes of the spec are resized, changes of the VM itself are handled by the drift policy.
+	generation := machineScope.ProxmoxMachine.GetGeneration()
+	if drifts := detectComputeDrift(machineScope); len(drifts) > 0 && machineScope.ProxmoxMachine.Status.ObservedResizeGeneration != generation {
+		descriptions := make([]string, 0, len(drifts))
+		options := make([]proxmox.VirtualMachineOption, 0, len(drifts))
+		for _, d := range drifts {
+			descriptions = append(descriptions, d.description)
+			options = append(options, d.option)
+		}
+		machineScope.Info("resizing VM", "resize", strings.Join(descriptions, ", "))
+
+		task, err := machineScope.InfraCluster.ProxmoxClient.ConfigureVM(ctx, machineScope.VirtualMachine, options...)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to resize VM %s", machineScope.Name())
+		}
+
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition, infrav1alpha1.ResizingReason, clusterv1.ConditionSeverityInfo, "")
+		machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+		machineScope.ProxmoxMachine.Status.ObservedResizeGeneration = generation
+		return true, nil
+	}
+	machineScope.ProxmoxMachine.Status.ObservedResizeGeneration = generation
+
+	if !conditions.IsFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition) {
+		// no resize in progress.
+		return false, nil
+	}
+
+	pending, err := machineScope.InfraCluster.ProxmoxClient.GetPendingChanges(ctx, machineScope.VirtualMachine)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get pending changes of VM %s", machineScope.Name())
+	}
+
+	var pendingResize []string
+	for _, key := range pending {
+		if key == optionSockets || key == optionCores || key == optionVCPUs || key == optionMemory {
+			pendingResize = append(pendingResize, key)
+		}
+	}
+
+	if len(pendingResize) == 0 {
+		conditions.MarkTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition)
+		return false, nil
+	}
+
+	message := fmt.Sprintf("changes of %s require a reboot", strings.Join(pendingResize, ", "))
+	if policy != infrav1alpha1.ResizePolicyReboot {
+		conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition, infrav1alpha1.ResizePendingReason, clusterv1.ConditionSeverityWarning, message)
+		return false, nil
+	}
+
+	machineScope.Info("rebooting VM to apply resize", "pending", pendingResize)
+	task, err := machineScope.InfraCluster.ProxmoxClient.RebootVM(ctx, machineScope.VirtualMachine)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to reboot VM %s", machineScope.Name())
+	}
+
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition, infrav1alpha1.RebootingReason, clusterv1.ConditionSeverityInfo, message)
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
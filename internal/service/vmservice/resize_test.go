@@ -0,0 +1,136 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestReconcileResize_Disabled(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.NumCores = 4
+	machineScope.SetVirtualMachine(newRunningVM())
+
+	requeue, err := reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+}
+
+func TestReconcileResize_ConfiguresVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Generation = 2
+	machineScope.ProxmoxMachine.Status.ObservedResizeGeneration = 1
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.ResizePolicy = infrav1alpha1.ResizePolicyHotplug
+	machineScope.ProxmoxMachine.Spec.NumCores = 4
+	machineScope.ProxmoxMachine.Spec.MemoryMiB = 8192
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Cores = 2
+	vm.VirtualMachineConfig.Memory = 4096
+	machineScope.SetVirtualMachine(vm)
+
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionCores, Value: machineScope.ProxmoxMachine.Spec.NumCores},
+		proxmox.VirtualMachineOption{Name: optionMemory, Value: machineScope.ProxmoxMachine.Spec.MemoryMiB},
+	}
+	proxmoxClient.EXPECT().ConfigureVM(context.Background(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, infrav1alpha1.ResizingReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+	require.Equal(t, int64(2), machineScope.ProxmoxMachine.Status.ObservedResizeGeneration)
+}
+
+func TestReconcileResize_IgnoresChangesOfVM(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Generation = 2
+	machineScope.ProxmoxMachine.Status.ObservedResizeGeneration = 2
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.ResizePolicy = infrav1alpha1.ResizePolicyHotplug
+	machineScope.ProxmoxMachine.Spec.NumCores = 4
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Cores = 2
+	machineScope.SetVirtualMachine(vm)
+
+	// the mock fails the test if the VM is resized, the change is left to the drift policy.
+	requeue, err := reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.False(t, conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+}
+
+func TestReconcileResize_Pending(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy infrav1alpha1.ResizePolicy
+		reason string
+	}{
+		{name: "hotplug", policy: infrav1alpha1.ResizePolicyHotplug, reason: infrav1alpha1.ResizePendingReason},
+		{name: "reboot", policy: infrav1alpha1.ResizePolicyReboot, reason: infrav1alpha1.RebootingReason},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+			machineScope.ProxmoxMachine.Status.Ready = true
+			machineScope.ProxmoxMachine.Spec.ResizePolicy = test.policy
+			machineScope.ProxmoxMachine.Spec.NumCores = 4
+			vm := newRunningVM()
+			vm.VirtualMachineConfig.Cores = 4
+			machineScope.SetVirtualMachine(vm)
+			conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition, infrav1alpha1.ResizingReason, clusterv1.ConditionSeverityInfo, "")
+
+			proxmoxClient.EXPECT().GetPendingChanges(context.Background(), vm).Return([]string{optionCores}, nil).Once()
+			if test.policy == infrav1alpha1.ResizePolicyReboot {
+				proxmoxClient.EXPECT().RebootVM(context.Background(), vm).Return(newTask(), nil).Once()
+			}
+
+			requeue, err := reconcileResize(context.Background(), machineScope)
+			require.NoError(t, err)
+			require.Equal(t, test.policy == infrav1alpha1.ResizePolicyReboot, requeue)
+			require.Equal(t, test.reason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+		})
+	}
+}
+
+func TestReconcileResize_Completed(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Status.Ready = true
+	machineScope.ProxmoxMachine.Spec.ResizePolicy = infrav1alpha1.ResizePolicyReboot
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	conditions.MarkFalse(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition, infrav1alpha1.RebootingReason, clusterv1.ConditionSeverityInfo, "")
+
+	proxmoxClient.EXPECT().GetPendingChanges(context.Background(), vm).Return(nil, nil).Once()
+
+	requeue, err := reconcileResize(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.True(t, conditions.IsTrue(machineScope.ProxmoxMachine, infrav1alpha1.VMResizedCondition))
+}
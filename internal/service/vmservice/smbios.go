@@ -0,0 +1,119 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"encoding/base64"
+	"strings"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+// smbiosBase64Keys are the smbios1 values which are base64 encoded if the base64 flag is set.
+var smbiosBase64Keys = map[string]bool{
+	"family":       true,
+	"manufacturer": true,
+	"product":      true,
+	"serial":       true,
+	"sku":          true,
+	"version":      true,
+}
+
+// smbiosFields returns the decoded values of the smbios1 option by their keys.
+// Values which are not valid base64 despite the base64 flag are returned as they are.
+func smbiosFields(smbios1 string) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range strings.Split(smbios1, ",") {
+		if key, value, ok := strings.Cut(field, "="); ok {
+			fields[key] = value
+		}
+	}
+	if fields["base64"] != "1" {
+		return fields
+	}
+	for key, value := range fields {
+		if decoded, err := base64.StdEncoding.DecodeString(value); smbiosBase64Keys[key] && err == nil {
+			fields[key] = string(decoded)
+		}
+	}
+	return fields
+}
+
+// smbiosWithFields returns the smbios1 option with the given values replaced, or added after the present ones.
+// The values are base64 encoded, as Proxmox does not accept all characters otherwise.
+func smbiosWithFields(smbios1 string, values map[string]string) string {
+	encoded := false
+	var fields [][2]string
+	for _, field := range strings.Split(smbios1, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "":
+			continue
+		case "base64":
+			encoded = value == "1"
+			continue
+		}
+		fields = append(fields, [2]string{key, value})
+	}
+
+	options := make([]string, 0, len(fields)+len(values)+1)
+	replaced := make(map[string]bool, len(values))
+	for _, field := range fields {
+		if value, ok := values[field[0]]; ok {
+			options = append(options, field[0]+"="+base64.StdEncoding.EncodeToString([]byte(value)))
+			replaced[field[0]] = true
+			continue
+		}
+		if !encoded && smbiosBase64Keys[field[0]] {
+			field[1] = base64.StdEncoding.EncodeToString([]byte(field[1]))
+		}
+		options = append(options, field[0]+"="+field[1])
+	}
+	for _, key := range []string{"family", "manufacturer", "product", "serial", "sku", "version"} {
+		if value, ok := values[key]; ok && !replaced[key] {
+			options = append(options, key+"="+base64.StdEncoding.EncodeToString([]byte(value)))
+		}
+	}
+	options = append(options, "base64=1")
+
+	return strings.Join(options, ",")
+}
+
+// desiredSMBIOS returns the smbios1 option with the SMBIOS fields of the spec, or an empty string
+// if the VM has them already.
+func desiredSMBIOS(smbios1 string, spec *infrav1alpha1.SMBIOS) string {
+	if spec == nil {
+		return ""
+	}
+
+	current := smbiosFields(smbios1)
+	values := make(map[string]string)
+	for key, value := range map[string]string{
+		"serial":       spec.Serial,
+		"manufacturer": spec.Manufacturer,
+		"product":      spec.Product,
+		"sku":          spec.SKU,
+	} {
+		if value != "" && current[key] != value {
+			values[key] = value
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return smbiosWithFields(smbios1, values)
+}
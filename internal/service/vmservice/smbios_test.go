@@ -0,0 +1,70 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmservice
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func TestSmbiosFields(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	require.Equal(t, map[string]string{"uuid": "41ec1197-580f-460b-b41b-1dfefabe6e32", "product": "Node"},
+		smbiosFields("uuid=41ec1197-580f-460b-b41b-1dfefabe6e32,product=Node"))
+	require.Equal(t, map[string]string{"uuid": "41ec1197-580f-460b-b41b-1dfefabe6e32", "serial": "ds=nocloud;s=http://10.0.0.1/", "base64": "1"},
+		smbiosFields("serial="+encode("ds=nocloud;s=http://10.0.0.1/")+",base64=1,uuid=41ec1197-580f-460b-b41b-1dfefabe6e32"))
+}
+
+func TestDesiredSMBIOS(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	spec := &infrav1alpha1.SMBIOS{Product: "Worker Node", SKU: "XL"}
+
+	tests := map[string]struct {
+		smbios1  string
+		spec     *infrav1alpha1.SMBIOS
+		expected string
+	}{
+		"no spec": {
+			smbios1: "uuid=41ec1197-580f-460b-b41b-1dfefabe6e32",
+		},
+		"adds fields": {
+			smbios1:  "uuid=41ec1197-580f-460b-b41b-1dfefabe6e32",
+			spec:     spec,
+			expected: "uuid=41ec1197-580f-460b-b41b-1dfefabe6e32,product=" + encode("Worker Node") + ",sku=" + encode("XL") + ",base64=1",
+		},
+		"replaces fields in place": {
+			smbios1:  "uuid=41ec1197-580f-460b-b41b-1dfefabe6e32,product=Standard,serial=" + encode("ds=nocloud-net") + ",base64=1",
+			spec:     spec,
+			expected: "uuid=41ec1197-580f-460b-b41b-1dfefabe6e32,product=" + encode("Worker Node") + ",serial=" + encode("ds=nocloud-net") + ",sku=" + encode("XL") + ",base64=1",
+		},
+		"unchanged": {
+			smbios1: "sku=" + encode("XL") + ",product=" + encode("Worker Node") + ",uuid=41ec1197-580f-460b-b41b-1dfefabe6e32,base64=1",
+			spec:    spec,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, desiredSMBIOS(test.smbios1, test.spec))
+		})
+	}
+}
@@ -19,6 +19,8 @@ package vmservice
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
@@ -34,15 +36,9 @@ const (
 )
 
 func extractUUID(input string) string {
-	pattern := `(^|,)uuid=([0-9a-fA-F-]+)`
-
-	re := regexp.MustCompile(pattern)
-	match := re.FindStringSubmatch(input)
-
-	if len(match) > 1 {
-		if parsed, err := uuid.Parse(match[len(match)-1]); err == nil {
-			return parsed.String()
-		}
+	// the other fields of the smbios1 option may have any values, so they are parsed instead of matched.
+	if parsed, err := uuid.Parse(smbiosFields(input)["uuid"]); err == nil {
+		return parsed.String()
 	}
 	return ""
 }
@@ -79,7 +75,8 @@ func shouldUpdateNetworkDevices(machineScope *scope.MachineScope) bool {
 			return true
 		}
 		model, bridge := extractNetworkModelAndBridge(net0)
-		if model != *machineScope.ProxmoxMachine.Spec.Network.Default.Model || bridge != machineScope.ProxmoxMachine.Spec.Network.Default.Bridge {
+		if model != networkModel(machineScope, *machineScope.ProxmoxMachine.Spec.Network.Default) || bridge != machineScope.ProxmoxMachine.Spec.Network.Default.BridgeName() ||
+			!networkDeviceOptionsMatch(net0, *machineScope.ProxmoxMachine.Spec.Network.Default) {
 			return true
 		}
 	}
@@ -93,7 +90,7 @@ func shouldUpdateNetworkDevices(machineScope *scope.MachineScope) bool {
 		}
 		model, bridge := extractNetworkModelAndBridge(net)
 		// current is different from the desired spec.
-		if model != *v.Model || bridge != v.Bridge {
+		if model != networkModel(machineScope, v.NetworkDevice) || bridge != v.BridgeName() || !networkDeviceOptionsMatch(net, v.NetworkDevice) {
 			return true
 		}
 	}
@@ -101,12 +98,111 @@ func shouldUpdateNetworkDevices(machineScope *scope.MachineScope) bool {
 	return false
 }
 
+// networkModel returns the model of a network device, which defaults to virtio,
+// or to e1000 for Windows VMs as it needs no additional drivers.
+func networkModel(machineScope *scope.MachineScope, device infrav1alpha1.NetworkDevice) string {
+	if device.Model != nil {
+		return *device.Model
+	}
+	if machineScope.ProxmoxMachine.Spec.GuestOS == infrav1alpha1.GuestOSWindows {
+		return "e1000"
+	}
+	return "virtio"
+}
+
+// referencedVNets returns the SDN VNets the network devices of the machine are attached to.
+func referencedVNets(machineScope *scope.MachineScope) []string {
+	network := machineScope.ProxmoxMachine.Spec.Network
+	if network == nil {
+		return nil
+	}
+
+	var vnets []string
+	if network.Default != nil && network.Default.VNet != "" {
+		vnets = append(vnets, network.Default.VNet)
+	}
+	for _, device := range network.AdditionalDevices {
+		if device.VNet != "" {
+			vnets = append(vnets, device.VNet)
+		}
+	}
+	return vnets
+}
+
+// referencedBridges returns the bridges the network devices of the machine are attached to, except for
+// SDN VNets and the VNet of the cluster, which only becomes a bridge of the nodes once it is created.
+func referencedBridges(machineScope *scope.MachineScope) []string {
+	network := machineScope.ProxmoxMachine.Spec.Network
+	if network == nil {
+		return nil
+	}
+
+	var clusterVNet string
+	if sdn := machineScope.InfraCluster.ProxmoxCluster.Spec.SDN; sdn != nil {
+		clusterVNet = sdn.VNet
+	}
+
+	devices := make([]infrav1alpha1.NetworkDevice, 0, len(network.AdditionalDevices)+1)
+	if network.Default != nil {
+		devices = append(devices, *network.Default)
+	}
+	for _, device := range network.AdditionalDevices {
+		devices = append(devices, device.NetworkDevice)
+	}
+
+	var bridges []string
+	seen := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		if device.VNet != "" || device.Bridge == "" || device.Bridge == clusterVNet || seen[device.Bridge] {
+			continue
+		}
+		seen[device.Bridge] = true
+		bridges = append(bridges, device.Bridge)
+	}
+	return bridges
+}
+
 // formatNetworkDevice formats a network device config
 // example 'virtio,bridge=vmbr0'.
 func formatNetworkDevice(model, bridge string) string {
 	return fmt.Sprintf("%s,bridge=%s", model, bridge)
 }
 
+// networkDeviceConfig formats the config of a network device with its VLAN tag and MTU,
+// example 'virtio,bridge=vmbr1,tag=100,mtu=9000'.
+func networkDeviceConfig(machineScope *scope.MachineScope, device infrav1alpha1.NetworkDevice) string {
+	return formatNetworkDevice(networkModel(machineScope, device), device.BridgeName()) + networkDeviceOptions(device)
+}
+
+// networkDeviceOptions formats the VLAN tag and MTU of a network device, example ',tag=100,mtu=9000'.
+func networkDeviceOptions(device infrav1alpha1.NetworkDevice) string {
+	var options string
+	if device.VLAN > 0 {
+		options += fmt.Sprintf(",tag=%d", device.VLAN)
+	}
+	if device.MTU > 0 {
+		options += fmt.Sprintf(",mtu=%d", device.MTU)
+	}
+	return options
+}
+
+// networkDeviceOptionsMatch returns whether the config of a network device has the VLAN tag and MTU
+// of the spec. Options which the spec does not set are not compared.
+func networkDeviceOptionsMatch(input string, device infrav1alpha1.NetworkDevice) bool {
+	return (device.VLAN == 0 || networkDeviceOption(input, "tag") == strconv.Itoa(int(device.VLAN))) &&
+		(device.MTU == 0 || networkDeviceOption(input, "mtu") == strconv.Itoa(int(device.MTU)))
+}
+
+// networkDeviceOption returns the value of an option of the config of a network device.
+func networkDeviceOption(input, name string) string {
+	for _, option := range strings.Split(input, ",") {
+		if value, ok := strings.CutPrefix(option, name+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
 // extractMACAddress returns the macaddress out of net device input e.g. virtio=A6:23:64:4D:84:CB,bridge=vmbr1.
 func extractMACAddress(input string) string {
 	re := regexp.MustCompile(`=([^,]+),bridge`)
@@ -116,3 +212,67 @@ func extractMACAddress(input string) string {
 	}
 	return ""
 }
+
+// clusterTagPrefix is the prefix of the tag marking the VMs of a cluster.
+const clusterTagPrefix = "capmox_"
+
+// ClusterTag returns the tag marking the VMs of the given cluster, which allows
+// to find VMs whose ProxmoxMachine is gone.
+func ClusterTag(namespace, clusterName string) string {
+	return clusterTagPrefix + namespace + "_" + clusterName
+}
+
+// ParseClusterTag returns the namespace and the name of the cluster marked by the tag.
+// As namespaces can not contain underscores, the tag is unambiguous.
+func ParseClusterTag(tag string) (namespace, clusterName string, ok bool) {
+	rest, ok := strings.CutPrefix(tag, clusterTagPrefix)
+	if !ok {
+		return "", "", false
+	}
+	namespace, clusterName, ok = strings.Cut(rest, "_")
+	return namespace, clusterName, ok && namespace != "" && clusterName != ""
+}
+
+// clusterTag returns the tag marking the VM as part of the machine's cluster.
+func clusterTag(machineScope *scope.MachineScope) string {
+	return ClusterTag(machineScope.Namespace(), machineScope.Cluster.Name)
+}
+
+// machineTagPrefix is the prefix of the tag marking the VM of a machine.
+const machineTagPrefix = "capmox-machine_"
+
+// machineTag returns the tag marking the VM as the one of the machine, which allows
+// to identify it without listing all VMs of the Proxmox cluster.
+func machineTag(machineScope *scope.MachineScope) string {
+	return machineTagPrefix + machineScope.Name()
+}
+
+// missingIdentityTags returns the cluster and machine tags which are not set on the VM.
+func missingIdentityTags(machineScope *scope.MachineScope) []string {
+	var missing []string
+	for _, tag := range []string{clusterTag(machineScope), machineTag(machineScope)} {
+		if !machineScope.VirtualMachine.HasTag(tag) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// missingTags returns the tags of the spec which are not set on the VM.
+func missingTags(machineScope *scope.MachineScope) []string {
+	var missing []string
+	for _, tag := range machineScope.ProxmoxMachine.Spec.Tags {
+		if !machineScope.VirtualMachine.HasTag(tag) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// joinTags appends the tags to the semicolon separated tags of a VM config.
+func joinTags(current string, tags []string) string {
+	if current == "" {
+		return strings.Join(tags, ";")
+	}
+	return current + ";" + strings.Join(tags, ";")
+}
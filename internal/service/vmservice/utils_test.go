@@ -34,6 +34,7 @@ func TestExtractUUID(t *testing.T) {
 		{"uuid=7dd9b137-6a3c-4661-a4fa-375075e1776b", "7dd9b137-6a3c-4661-a4fa-375075e1776b"},
 		{"foo=bar,uuid=71A5f8b4-5d30-43a3-b902-242393ad80b5,baz=quux", "71a5f8b4-5d30-43a3-b902-242393ad80b5"},
 		{",uuid=e80432e2-2b5c-4539-af97-852aaa7e84d7", "e80432e2-2b5c-4539-af97-852aaa7e84d7"},
+		{"manufacturer=SU9OT1M=,serial=dXVpZD0xMjM=,uuid=e80432e2-2b5c-4539-af97-852aaa7e84d7,base64=1", "e80432e2-2b5c-4539-af97-852aaa7e84d7"},
 	}
 
 	badstrings := []string{
@@ -116,6 +117,42 @@ func TestShouldUpdateNetworkDevices_DefaultDeviceNeedsUpdate(t *testing.T) {
 	require.True(t, shouldUpdateNetworkDevices(machineScope))
 }
 
+func TestShouldUpdateNetworkDevices_VLAN(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", VLAN: 100},
+	}
+	machineScope.SetVirtualMachine(newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0"))
+	require.True(t, shouldUpdateNetworkDevices(machineScope))
+	require.Equal(t, "virtio,bridge=vmbr0,tag=100", networkDeviceConfig(machineScope, *machineScope.ProxmoxMachine.Spec.Network.Default))
+
+	machineScope.SetVirtualMachine(newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0,tag=100"))
+	require.False(t, shouldUpdateNetworkDevices(machineScope))
+}
+
+func TestShouldUpdateNetworkDevices_WindowsDefaultModel(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.GuestOS = infrav1alpha1.GuestOSWindows
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0"},
+	}
+	machineScope.SetVirtualMachine(newVMWithNets("virtio=A6:23:64:4D:84:CB,bridge=vmbr0"))
+	require.True(t, shouldUpdateNetworkDevices(machineScope))
+
+	machineScope.SetVirtualMachine(newVMWithNets("e1000=A6:23:64:4D:84:CB,bridge=vmbr0"))
+	require.False(t, shouldUpdateNetworkDevices(machineScope))
+}
+
+func TestNetworkModel(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	require.Equal(t, "virtio", networkModel(machineScope, infrav1alpha1.NetworkDevice{}))
+	require.Equal(t, "vmxnet3", networkModel(machineScope, infrav1alpha1.NetworkDevice{Model: ptr.To("vmxnet3")}))
+
+	machineScope.ProxmoxMachine.Spec.GuestOS = infrav1alpha1.GuestOSWindows
+	require.Equal(t, "e1000", networkModel(machineScope, infrav1alpha1.NetworkDevice{}))
+	require.Equal(t, "virtio", networkModel(machineScope, infrav1alpha1.NetworkDevice{Model: ptr.To("virtio")}))
+}
+
 func TestShouldUpdateNetworkDevices_MissingAdditionalDeviceOnVM(t *testing.T) {
 	machineScope, _, _ := setupReconcilerTest(t)
 	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
@@ -152,3 +189,15 @@ func TestShouldUpdateNetworkDevices_NoUpdate(t *testing.T) {
 
 	require.False(t, shouldUpdateNetworkDevices(machineScope))
 }
+
+func TestParseClusterTag(t *testing.T) {
+	namespace, clusterName, ok := ParseClusterTag(ClusterTag("default", "my-cluster"))
+	require.True(t, ok)
+	require.Equal(t, "default", namespace)
+	require.Equal(t, "my-cluster", clusterName)
+
+	for _, tag := range []string{"k8s", "capmox_", "capmox_default", "capmox_default_", "ip_net0_10.0.0.1"} {
+		_, _, ok = ParseClusterTag(tag)
+		require.False(t, ok, tag)
+	}
+}
@@ -19,9 +19,11 @@ package vmservice
 
 import (
 	"context"
+	"net/netip"
+	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capierrors "sigs.k8s.io/cluster-api/errors"
@@ -39,9 +41,16 @@ const (
 	// See following link for a list of available config options:
 	// https://pve.proxmox.com/pve-docs/api-viewer/index.html#/nodes/{node}/qemu/{vmid}/config
 
-	optionSockets = "sockets"
-	optionCores   = "cores"
-	optionMemory  = "memory"
+	optionSockets  = "sockets"
+	optionCores    = "cores"
+	optionVCPUs    = "vcpus"
+	optionCPULimit = "cpulimit"
+	optionCPUUnits = "cpuunits"
+	optionMemory   = "memory"
+	optionHotplug  = "hotplug"
+	optionNuma     = "numa"
+	optionTags     = "tags"
+	optionSMBios1  = "smbios1"
 )
 
 // ReconcileVM makes sure that the VM is in the desired state by:
@@ -56,6 +65,11 @@ func ReconcileVM(ctx context.Context, scope *scope.MachineScope) (infrav1alpha1.
 		State: infrav1alpha1.VirtualMachineStatePending,
 	}
 
+	// Remediate machines stuck in provisioning, even if their task never finishes.
+	if requeue, err := reconcileProvisioningTimeout(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
 	// If there is an in-flight task associated with this VM then do not
 	// reconcile the VM until the task is completed.
 	if inFlight, err := taskservice.ReconcileInFlightTask(ctx, scope); err != nil || inFlight {
@@ -66,10 +80,38 @@ func ReconcileVM(ctx context.Context, scope *scope.MachineScope) (infrav1alpha1.
 		return vm, err
 	}
 
+	if requeue, err := reconcileResize(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileConfigDrift(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
 	if requeue, err := reconcileVirtualMachineConfig(ctx, scope); err != nil || requeue {
 		return vm, err
 	}
 
+	if requeue, err := reconcileIdentityTags(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileFirewall(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileISODevices(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcileDataDisks(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
+	if requeue, err := reconcilePersistentDisks(ctx, scope); err != nil || requeue {
+		return vm, err
+	}
+
 	if err := reconcileDisks(ctx, scope); err != nil {
 		return vm, err
 	}
@@ -86,10 +128,13 @@ func ReconcileVM(ctx context.Context, scope *scope.MachineScope) (infrav1alpha1.
 		return vm, err
 	}
 
-	if err := reconcileMachineAddresses(scope); err != nil {
+	if err := reconcileMachineAddresses(ctx, scope); err != nil {
 		return vm, err
 	}
 
+	reconcileAgentHealth(ctx, scope)
+	reconcileCloudInitCheck(ctx, scope)
+
 	vm.State = infrav1alpha1.VirtualMachineStateReady
 	return vm, nil
 }
@@ -112,6 +157,10 @@ func ensureVirtualMachine(ctx context.Context, machineScope *scope.MachineScope)
 		}
 
 		// Otherwise, this is a new machine and the VM should be created.
+		if waitForHooks(machineScope, infrav1alpha1.PreCloneHookAnnotationPrefix) {
+			return true, nil
+		}
+
 		// NOTE: We are setting this condition only in case it does not exist, so we avoid to get flickering LastConditionTime
 		// in case of cloning errors or powering on errors.
 		if !conditions.Has(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition) {
@@ -126,9 +175,11 @@ func ensureVirtualMachine(ctx context.Context, machineScope *scope.MachineScope)
 		}
 		machineScope.Logger.V(4).Info("Task created", "taskID", resp.Task.ID)
 
-		// make sure spec.VirtualMachineID is always set.
+		// make sure spec.VirtualMachineID is always set, unless the image of the VM is still being downloaded.
 		machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(resp.Task.UPID))
-		machineScope.SetVirtualMachineID(resp.NewID)
+		if resp.NewID > 0 {
+			machineScope.SetVirtualMachineID(resp.NewID)
+		}
 
 		return true, nil
 	}
@@ -183,16 +234,39 @@ func reconcileVirtualMachineConfig(ctx context.Context, machineScope *scope.Mach
 	if value := machineScope.ProxmoxMachine.Spec.NumCores; value > 0 && vmConfig.Cores != int(value) {
 		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionCores, Value: value})
 	}
+	if value := machineScope.ProxmoxMachine.Spec.NumVCPUs; value > 0 && vmConfig.Vcpus != int(value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionVCPUs, Value: value})
+	}
+	if value := machineScope.ProxmoxMachine.Spec.CPULimit; value != "" && !cpuLimitEqual(float64(vmConfig.CPULimit), value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionCPULimit, Value: value})
+	}
+	if value := machineScope.ProxmoxMachine.Spec.CPUUnits; value > 0 && vmConfig.CPUUnits != int(value) {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionCPUUnits, Value: value})
+	}
 	if value := machineScope.ProxmoxMachine.Spec.MemoryMiB; value > 0 && int32(vmConfig.Memory) != value {
 		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionMemory, Value: value})
 	}
+	if machineScope.ProxmoxMachine.Spec.MemoryHotplug {
+		vmOptions = append(vmOptions, memoryHotplugOptions(vmConfig.Hotplug, vmConfig.Numa)...)
+	}
+
+	// SMBIOS.
+	if value := desiredSMBIOS(vmConfig.SMBios1, machineScope.ProxmoxMachine.Spec.SMBIOS); value != "" {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionSMBios1, Value: value})
+	}
+
+	// Tags.
+	tags := append(missingIdentityTags(machineScope), missingTags(machineScope)...)
+	if len(tags) > 0 {
+		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{Name: optionTags, Value: joinTags(vmConfig.Tags, tags)})
+	}
 
 	// Network vmbrs.
 	if machineScope.ProxmoxMachine.Spec.Network != nil && shouldUpdateNetworkDevices(machineScope) {
 		// adding the default network device.
 		vmOptions = append(vmOptions, proxmox.VirtualMachineOption{
 			Name:  infrav1alpha1.DefaultNetworkDevice,
-			Value: formatNetworkDevice(*machineScope.ProxmoxMachine.Spec.Network.Default.Model, machineScope.ProxmoxMachine.Spec.Network.Default.Bridge),
+			Value: networkDeviceConfig(machineScope, *machineScope.ProxmoxMachine.Spec.Network.Default),
 		})
 
 		// handing additional network devices.
@@ -200,7 +274,7 @@ func reconcileVirtualMachineConfig(ctx context.Context, machineScope *scope.Mach
 		for _, v := range devices {
 			vmOptions = append(vmOptions, proxmox.VirtualMachineOption{
 				Name:  v.Name,
-				Value: formatNetworkDevice(*v.Model, v.Bridge),
+				Value: networkDeviceConfig(machineScope, v.NetworkDevice),
 			})
 		}
 	}
@@ -220,8 +294,23 @@ func reconcileVirtualMachineConfig(ctx context.Context, machineScope *scope.Mach
 	return true, nil
 }
 
-func reconcileMachineAddresses(scope *scope.MachineScope) error {
-	addr, err := getMachineAddresses(scope)
+// reconcileIdentityTags adds the cluster and machine tags to VMs which were started before they were introduced.
+func reconcileIdentityTags(ctx context.Context, machineScope *scope.MachineScope) (requeue bool, err error) {
+	missing := missingIdentityTags(machineScope)
+	if len(missing) == 0 {
+		return false, nil
+	}
+
+	task, err := machineScope.InfraCluster.ProxmoxClient.TagVM(ctx, machineScope.VirtualMachine, missing[0])
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to add tag %s to VirtualMachine %s", missing[0], machineScope.Name())
+	}
+	machineScope.ProxmoxMachine.Status.TaskRef = ptr.To(string(task.UPID))
+	return true, nil
+}
+
+func reconcileMachineAddresses(ctx context.Context, scope *scope.MachineScope) error {
+	addr, err := getMachineAddresses(ctx, scope)
 	if err != nil {
 		scope.Error(err, "failed to retrieve machine addresses")
 		return err
@@ -231,8 +320,11 @@ func reconcileMachineAddresses(scope *scope.MachineScope) error {
 	return nil
 }
 
-func getMachineAddresses(scope *scope.MachineScope) ([]clusterv1.MachineAddress, error) {
-	if !machineHasIPAddress(scope.ProxmoxMachine) {
+// getMachineAddresses returns the hostname and the addresses of all network devices of the machine.
+// The static addresses come first, with the default network device before the additional ones,
+// followed by the addresses the QEMU guest agent reports for the network devices of the VM.
+func getMachineAddresses(ctx context.Context, scope *scope.MachineScope) ([]clusterv1.MachineAddress, error) {
+	if !machineHasIPAddress(scope) {
 		return nil, errors.New("machine does not yet have an ip address")
 	}
 
@@ -247,28 +339,263 @@ func getMachineAddresses(scope *scope.MachineScope) ([]clusterv1.MachineAddress,
 		},
 	}
 
-	if scope.InfraCluster.ProxmoxCluster.Spec.IPv4Config != nil {
+	seen := make(map[string]bool)
+	add := func(device, address string) {
+		ip, err := netip.ParseAddr(address)
+		if err != nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || seen[ip.String()] {
+			return
+		}
+		seen[ip.String()] = true
 		addresses = append(addresses, clusterv1.MachineAddress{
-			Type:    clusterv1.MachineInternalIP,
-			Address: scope.ProxmoxMachine.Status.IPAddresses[infrav1alpha1.DefaultNetworkDevice].IPV4,
+			Type:    machineAddressType(device, ip),
+			Address: ip.String(),
 		})
 	}
 
-	if scope.InfraCluster.ProxmoxCluster.Spec.IPv6Config != nil {
-		addresses = append(addresses, clusterv1.MachineAddress{
-			Type:    clusterv1.MachineInternalIP,
-			Address: scope.ProxmoxMachine.Status.IPAddresses[infrav1alpha1.DefaultNetworkDevice].IPV6,
-		})
+	// the default network device sorts first.
+	devices := make([]string, 0, len(scope.ProxmoxMachine.Status.IPAddresses))
+	for device := range scope.ProxmoxMachine.Status.IPAddresses {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	for _, device := range devices {
+		add(device, scope.ProxmoxMachine.Status.IPAddresses[device].IPV4)
+		add(device, scope.ProxmoxMachine.Status.IPAddresses[device].IPV6)
+	}
+
+	guest := guestAddresses(ctx, scope)
+	devices = devices[:0]
+	for device := range guest {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	for _, device := range devices {
+		for _, ip := range guest[device] {
+			add(device, ip)
+		}
 	}
 
 	return addresses, nil
 }
 
+// guestAddresses returns the addresses the QEMU guest agent reports, by the network device of the VM
+// with the MAC address of the interface. Interfaces of the guest without a network device, like bridges
+// of the container runtime, are ignored. The addresses are optional, so failures are only logged.
+func guestAddresses(ctx context.Context, scope *scope.MachineScope) map[string][]string {
+	if !agentEnabled(scope.VirtualMachine.VirtualMachineConfig.Agent) {
+		return nil
+	}
+
+	ifaces, err := scope.InfraCluster.ProxmoxClient.GetGuestNetworkInterfaces(ctx, scope.VirtualMachine)
+	if err != nil {
+		scope.V(4).Info("unable to get the guest network interfaces", "error", err.Error())
+		return nil
+	}
+
+	devices := make(map[string]string)
+	for device, config := range scope.VirtualMachine.VirtualMachineConfig.MergeNets() {
+		if mac := extractMACAddress(config); mac != "" {
+			devices[strings.ToLower(mac)] = device
+		}
+	}
+
+	addresses := make(map[string][]string)
+	for _, iface := range ifaces {
+		if device, ok := devices[strings.ToLower(iface.MACAddress)]; ok {
+			addresses[device] = append(addresses[device], iface.IPAddresses...)
+		}
+	}
+	return addresses
+}
+
+// machineAddressType returns InternalIP for the addresses of the default network device and for private
+// addresses of the additional ones, and ExternalIP for the public addresses of the additional ones.
+func machineAddressType(device string, ip netip.Addr) clusterv1.MachineAddressType {
+	if device == infrav1alpha1.DefaultNetworkDevice || ip.IsPrivate() {
+		return clusterv1.MachineInternalIP
+	}
+	return clusterv1.MachineExternalIP
+}
+
+// agentEnabled reports whether the agent option of a VM, [enabled=]<0|1>[,...], enables the QEMU guest agent.
+func agentEnabled(agent string) bool {
+	enabled, _, _ := strings.Cut(agent, ",")
+	return strings.TrimPrefix(enabled, "enabled=") == "1"
+}
+
+// validateVNets makes sure the SDN VNets referenced by the network devices exist,
+// since Proxmox VE would only fail to start the VM.
+func validateVNets(ctx context.Context, scope *scope.MachineScope) error {
+	referenced := referencedVNets(scope)
+	if len(referenced) == 0 {
+		return nil
+	}
+
+	vnets, err := scope.InfraCluster.ProxmoxClient.ListSDNVNets(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list sdn vnets")
+	}
+	existing := make(map[string]bool, len(vnets))
+	for _, vnet := range vnets {
+		existing[vnet.Name] = true
+	}
+
+	for _, name := range referenced {
+		if !existing[name] {
+			return errors.Errorf("sdn vnet %q does not exist", name)
+		}
+	}
+	return nil
+}
+
+// validateBridges makes sure the bridges referenced by the network devices exist on the node of the VM,
+// since Proxmox VE would only fail to start the VM. SDN VNets are validated by validateVNets.
+func validateBridges(ctx context.Context, scope *scope.MachineScope, node string) error {
+	referenced := referencedBridges(scope)
+	if len(referenced) == 0 {
+		return nil
+	}
+
+	bridges, err := scope.InfraCluster.ProxmoxClient.ListBridges(ctx, node)
+	if err != nil {
+		return errors.Wrapf(err, "unable to list bridges of node %s", node)
+	}
+	existing := make(map[string]bool, len(bridges))
+	for _, bridge := range bridges {
+		existing[bridge] = true
+	}
+
+	var missing []string
+	for _, bridge := range referenced {
+		if !existing[bridge] {
+			missing = append(missing, bridge)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("node %s is missing bridges %s", node, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// validateCloneSource makes sure a regular VM the machine is cloned from is stopped, since only templates
+// are guaranteed to be unchanged while they are cloned. Proxmox VE always copies all disks of regular VMs,
+// so their clones are full clones.
+func validateCloneSource(ctx context.Context, scope *scope.MachineScope, options *proxmox.VMCloneRequest) error {
+	templateID := scope.ProxmoxMachine.GetTemplateID()
+	source, err := scope.InfraCluster.ProxmoxClient.GetVM(ctx, options.Node, int64(templateID))
+	if err != nil {
+		return errors.Wrapf(err, "unable to get source VM %d on node %s", templateID, options.Node)
+	}
+	if source.Template {
+		return nil
+	}
+
+	if source.IsRunning() {
+		return errors.Errorf("source VM %d on node %s is running, stop it or convert it to a template to clone machines from it", templateID, options.Node)
+	}
+	if full := scope.ProxmoxMachine.Spec.Full; full != nil && !*full {
+		return errors.Errorf("source VM %d on node %s is not a template, linked clones require a template; set full to true", templateID, options.Node)
+	}
+	options.Full = 1
+	return nil
+}
+
+// validateCloneStorage makes sure the target storage of a clone can hold the disks of VMs in the requested format,
+// since Proxmox VE reports a misconfigured storage only once the clone task fails.
+func validateCloneStorage(ctx context.Context, scope *scope.MachineScope, node string, options proxmox.VMCloneRequest) error {
+	if options.Storage == "" {
+		return nil
+	}
+
+	storage, err := scope.InfraCluster.ProxmoxClient.GetStorage(ctx, node, options.Storage)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get storage %s of node %s", options.Storage, node)
+	}
+
+	// the format only applies to full clones.
+	var format string
+	if options.Full == 1 {
+		format = options.Format
+	}
+	return storage.Validate(node, proxmox.StorageContentImages, format)
+}
+
 func createVM(ctx context.Context, scope *scope.MachineScope) (proxmox.VMCloneResponse, error) {
+	if err := validateVNets(ctx, scope); err != nil {
+		return proxmox.VMCloneResponse{}, err
+	}
+
+	options, err := cloneOptions(ctx, scope, selectNextNode)
+	if err != nil {
+		if errors.As(err, &scheduler.InsufficientMemoryError{}) {
+			scope.SetFailureMessage(err)
+			scope.SetFailureReason(capierrors.InsufficientResourcesMachineError)
+		}
+		return proxmox.VMCloneResponse{}, err
+	}
+
+	node := options.Target
+	if node == "" {
+		node = options.Node
+	}
+
+	if err := validateBridges(ctx, scope, node); err != nil {
+		return proxmox.VMCloneResponse{}, err
+	}
+
+	var res proxmox.VMCloneResponse
+	if scope.ProxmoxMachine.Spec.Image != nil {
+		if res, err = createVMFromImage(ctx, scope, node, options); err != nil {
+			return res, err
+		}
+		if res.NewID == 0 {
+			scope.ProxmoxMachine.Status.ProxmoxNode = ptr.To(node)
+			return res, nil
+		}
+	} else {
+		if err := validateCloneSource(ctx, scope, &options); err != nil {
+			return res, err
+		}
+		if err := validateCloneStorage(ctx, scope, node, options); err != nil {
+			return res, err
+		}
+		templateID := scope.ProxmoxMachine.GetTemplateID()
+		if res, err = scope.InfraCluster.ProxmoxClient.CloneVM(ctx, int(templateID), options); err != nil {
+			return res, err
+		}
+		scope.ProxmoxMachine.Status.ClonedFrom = &infrav1alpha1.TemplateReference{
+			SourceNode: options.Node,
+			TemplateID: templateID,
+			SnapName:   options.SnapName,
+		}
+	}
+
+	scope.ProxmoxMachine.Status.ProxmoxNode = ptr.To(node)
+
+	// if the creation was successful, we store the information about the node in the
+	// cluster status
+	scope.InfraCluster.ProxmoxCluster.UpdateNodeLocation(scope.ProxmoxMachine.GetName(), node, util.IsControlPlaneMachine(scope.Machine))
+
+	return res, scope.InfraCluster.PatchObject()
+}
+
+// cloneOptions returns the request to create the VM of the machine. The target node is selected with
+// the given scheduler, unless the spec sets it.
+func cloneOptions(ctx context.Context, scope *scope.MachineScope, schedule func(context.Context, *scope.MachineScope) (string, error)) (proxmox.VMCloneRequest, error) {
+	if scope.ProxmoxMachine.GetNode() == "" {
+		return proxmox.VMCloneRequest{}, errors.New("no source node set, neither on the machine nor in the machine defaults of the cluster")
+	}
+
+	name, err := vmName(scope)
+	if err != nil {
+		return proxmox.VMCloneRequest{}, err
+	}
+
 	options := proxmox.VMCloneRequest{
+
 		Node: scope.ProxmoxMachine.GetNode(),
 		// NewID:       0, no need to provide newID
-		Name: scope.ProxmoxMachine.GetName(),
+		Name: name,
 	}
 
 	if scope.ProxmoxMachine.Spec.Description != nil {
@@ -297,46 +624,25 @@ func createVM(ctx context.Context, scope *scope.MachineScope) (proxmox.VMCloneRe
 		options.Target = *scope.ProxmoxMachine.Spec.Target
 	}
 
-	if scope.InfraCluster.ProxmoxCluster.Status.NodeLocations == nil {
-		scope.InfraCluster.ProxmoxCluster.Status.NodeLocations = new(infrav1alpha1.NodeLocations)
+	// a VM created from an image stays on the node which downloaded the image.
+	if scope.ProxmoxMachine.Spec.Image != nil && scope.ProxmoxMachine.Status.ProxmoxNode != nil {
+		options.Target = *scope.ProxmoxMachine.Status.ProxmoxNode
 	}
 
 	// if no target was specified but we have a set of nodes defined in the cluster spec, we want to evenly distribute
 	// the nodes across the cluster.
-	if scope.ProxmoxMachine.Spec.Target == nil && len(scope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes) > 0 {
+	if options.Target == "" && scope.InfraCluster.ProxmoxCluster.HasNodeSelection() {
 		// select next node as a target
-		var err error
-		options.Target, err = selectNextNode(ctx, scope)
+		options.Target, err = schedule(ctx, scope)
 		if err != nil {
-			if errors.As(err, &scheduler.InsufficientMemoryError{}) {
-				scope.SetFailureMessage(err)
-				scope.SetFailureReason(capierrors.InsufficientResourcesMachineError)
-			}
-			return proxmox.VMCloneResponse{}, err
+			return proxmox.VMCloneRequest{}, err
 		}
 	}
 
-	templateID := scope.ProxmoxMachine.GetTemplateID()
-	res, err := scope.InfraCluster.ProxmoxClient.CloneVM(ctx, int(templateID), options)
-	if err != nil {
-		return res, err
-	}
-
-	node := options.Target
-	if node == "" {
-		node = options.Node
-	}
-
-	scope.ProxmoxMachine.Status.ProxmoxNode = ptr.To(node)
-
-	// if the creation was successful, we store the information about the node in the
-	// cluster status
-	scope.InfraCluster.ProxmoxCluster.AddNodeLocation(infrav1alpha1.NodeLocation{
-		Machine: corev1.LocalObjectReference{Name: options.Name},
-		Node:    node,
-	}, util.IsControlPlaneMachine(scope.Machine))
-
-	return res, scope.InfraCluster.PatchObject()
+	return options, nil
 }
 
-var selectNextNode = scheduler.ScheduleVM
+var (
+	selectNextNode  = scheduler.ScheduleVM
+	previewNextNode = scheduler.PreviewVM
+)
@@ -18,11 +18,14 @@ package vmservice
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
@@ -34,6 +37,7 @@ import (
 func TestReconcileVM_EverythingReady(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	vm := newRunningVM()
+	vm.VirtualMachineConfig.Tags = "capmox_default_test;capmox-machine_test"
 	machineScope.SetVirtualMachineID(int64(vm.VMID))
 	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
 	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
@@ -67,6 +71,9 @@ func TestEnsureVirtualMachine_CreateVM_FullOptions(t *testing.T) {
 		Target:      "node2",
 	}
 	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "node2", "storage").
+		Return(proxmox.StorageInfo{Name: "storage", Type: "lvmthin", Content: []string{proxmox.StorageContentImages}, Enabled: true, Active: true}, nil).Once()
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newTemplateVM(), nil).Once()
 	proxmoxClient.EXPECT().CloneVM(context.TODO(), 123, expectedOptions).Return(response, nil).Once()
 
 	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
@@ -74,10 +81,89 @@ func TestEnsureVirtualMachine_CreateVM_FullOptions(t *testing.T) {
 	require.True(t, requeue)
 
 	require.Equal(t, "node2", *machineScope.ProxmoxMachine.Status.ProxmoxNode)
+	require.Equal(t, &infrav1alpha1.TemplateReference{SourceNode: "node1", TemplateID: 123, SnapName: "snap"}, machineScope.ProxmoxMachine.Status.ClonedFrom)
 	require.True(t, machineScope.InfraCluster.ProxmoxCluster.HasMachine(machineScope.Name(), false))
 	requireConditionIsFalse(t, machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 }
 
+func TestEnsureVirtualMachine_CreateVM_UnsupportedFormat(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Format = ptr.To(infrav1alpha1.TargetStorageFormatQcow2)
+	machineScope.ProxmoxMachine.Spec.Full = ptr.To(true)
+	machineScope.ProxmoxMachine.Spec.Storage = ptr.To("local-lvm")
+
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newTemplateVM(), nil).Once()
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "node1", "local-lvm").
+		Return(proxmox.StorageInfo{Name: "local-lvm", Type: "lvmthin", Content: []string{proxmox.StorageContentImages}, Enabled: true, Active: true}, nil).Once()
+
+	// the template is not cloned, as the clone task would fail.
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorContains(t, err, "storage local-lvm of type lvmthin only supports raw volumes")
+	require.Equal(t, infrav1alpha1.CloningFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestEnsureVirtualMachine_CreateVM_FromRegularVM(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Format = ptr.To(infrav1alpha1.TargetStorageFormatQcow2)
+	machineScope.ProxmoxMachine.Spec.Storage = ptr.To("local-lvm")
+
+	// regular VMs are always cloned as full clones, so the format applies.
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newStoppedVM(), nil).Once()
+	proxmoxClient.EXPECT().GetStorage(context.Background(), "node1", "local-lvm").
+		Return(proxmox.StorageInfo{Name: "local-lvm", Type: "lvmthin", Content: []string{proxmox.StorageContentImages}, Enabled: true, Active: true}, nil).Once()
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorContains(t, err, "storage local-lvm of type lvmthin only supports raw volumes")
+
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newRunningVM(), nil).Once()
+	_, err = ensureVirtualMachine(context.Background(), machineScope)
+	require.EqualError(t, err, "source VM 123 on node node1 is running, stop it or convert it to a template to clone machines from it")
+
+	machineScope.ProxmoxMachine.Spec.Full = ptr.To(false)
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newStoppedVM(), nil).Once()
+	_, err = ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorContains(t, err, "linked clones require a template")
+	require.Equal(t, infrav1alpha1.CloningFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestEnsureVirtualMachine_CreateVM_MissingBridge(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.InfraCluster.ProxmoxCluster.Spec.SDN = &infrav1alpha1.SDNSpec{Zone: "zone", VNet: "capmox"}
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0"},
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1"}},
+			{Name: "net2", NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr2"}},
+			{Name: "net3", NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "capmox"}},
+		},
+	}
+
+	// the VNet of the cluster is not a bridge of the node until it is created.
+	proxmoxClient.EXPECT().ListBridges(context.Background(), "node1").Return([]string{"vmbr0"}, nil).Once()
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.EqualError(t, err, "node node1 is missing bridges vmbr1, vmbr2")
+	require.Equal(t, infrav1alpha1.CloningFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+}
+
+func TestEnsureVirtualMachine_CreateVM_NameTemplate(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.Machine.Spec.ClusterName = "capmox"
+	machineScope.ProxmoxMachine.Spec.VMNameTemplate = ptr.To("{{.ClusterName}}-{{.Role}}")
+
+	expectedOptions := proxmox.VMCloneRequest{Node: "node1", Name: "capmox-worker"}
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newTemplateVM(), nil).Once()
+	proxmoxClient.EXPECT().CloneVM(context.TODO(), 123, expectedOptions).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	// the cluster status refers to the ProxmoxMachine.
+	require.True(t, machineScope.InfraCluster.ProxmoxCluster.HasMachine(machineScope.Name(), false))
+}
+
 func TestEnsureVirtualMachine_CreateVM_SelectNode(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	machineScope.InfraCluster.ProxmoxCluster.Spec.AllowedNodes = []string{"node1", "node2", "node3"}
@@ -89,6 +175,7 @@ func TestEnsureVirtualMachine_CreateVM_SelectNode(t *testing.T) {
 
 	expectedOptions := proxmox.VMCloneRequest{Node: "node1", Name: "test", Target: "node3"}
 	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newTemplateVM(), nil).Once()
 	proxmoxClient.EXPECT().CloneVM(context.TODO(), 123, expectedOptions).Return(response, nil).Once()
 
 	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
@@ -117,6 +204,31 @@ func TestEnsureVirtualMachine_CreateVM_SelectNode_InsufficientMemory(t *testing.
 	require.True(t, machineScope.HasFailed())
 }
 
+func TestEnsureVirtualMachine_CreateVM_VNet(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
+		Default: &infrav1alpha1.NetworkDevice{VNet: "test"},
+		AdditionalDevices: []infrav1alpha1.AdditionalNetworkDevice{
+			{Name: "net1", NetworkDevice: infrav1alpha1.NetworkDevice{VNet: "missing"}},
+		},
+	}
+	proxmoxClient.EXPECT().ListSDNVNets(context.Background()).Return([]proxmox.SDNVNet{{Name: "test", Zone: "capmox"}}, nil).Once()
+
+	_, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.ErrorContains(t, err, `sdn vnet "missing" does not exist`)
+	require.Equal(t, infrav1alpha1.CloningFailedReason, conditions.GetReason(machineScope.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition))
+
+	machineScope.ProxmoxMachine.Spec.Network.AdditionalDevices = nil
+	proxmoxClient.EXPECT().ListSDNVNets(context.Background()).Return([]proxmox.SDNVNet{{Name: "test", Zone: "capmox"}}, nil).Once()
+	response := proxmox.VMCloneResponse{NewID: 123, Task: newTask()}
+	proxmoxClient.EXPECT().GetVM(context.Background(), "node1", int64(123)).Return(newTemplateVM(), nil).Once()
+	proxmoxClient.EXPECT().CloneVM(context.TODO(), 123, proxmox.VMCloneRequest{Node: "node1", Name: "test"}).Return(response, nil).Once()
+
+	requeue, err := ensureVirtualMachine(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
 func TestEnsureVirtualMachine_FindVM(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	machineScope.SetVirtualMachineID(123)
@@ -138,7 +250,7 @@ func TestEnsureVirtualMachine_UpdateVMLocation_Error(t *testing.T) {
 	machineScope.SetVirtualMachineID(123)
 
 	proxmoxClient.EXPECT().GetVM(context.TODO(), "node1", int64(123)).Return(nil, fmt.Errorf("not found")).Once()
-	proxmoxClient.EXPECT().FindVMResource(context.TODO(), uint64(123)).Return(nil, fmt.Errorf("unavailalbe")).Once()
+	proxmoxClient.EXPECT().GetVMByID(context.TODO(), int64(123)).Return(nil, fmt.Errorf("unavailalbe")).Once()
 
 	_, err := ensureVirtualMachine(context.Background(), machineScope)
 	require.Error(t, err)
@@ -147,6 +259,7 @@ func TestEnsureVirtualMachine_UpdateVMLocation_Error(t *testing.T) {
 func TestReconcileVirtualMachineConfig_NoConfig(t *testing.T) {
 	machineScope, _, _ := setupReconcilerTest(t)
 	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Tags = "capmox_default_test;capmox-machine_test"
 	machineScope.SetVirtualMachine(vm)
 
 	requeue, err := reconcileVirtualMachineConfig(context.TODO(), machineScope)
@@ -154,10 +267,88 @@ func TestReconcileVirtualMachineConfig_NoConfig(t *testing.T) {
 	require.False(t, requeue)
 }
 
+func TestReconcileVirtualMachineConfig_Tags(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.Tags = []string{"k8s", "worker"}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Tags = "k8s"
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionTags, Value: "k8s;capmox_default_test;capmox-machine_test;worker"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.TODO(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.TODO(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+}
+
+func TestReconcileVirtualMachineConfig_SMBIOS(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	machineScope.ProxmoxMachine.Spec.SMBIOS = &infrav1alpha1.SMBIOS{Serial: "CZ-4711", Manufacturer: "IONOS"}
+
+	vm := newStoppedVM()
+	vm.VirtualMachineConfig.Tags = "capmox_default_test;capmox-machine_test"
+	vm.VirtualMachineConfig.SMBios1 = "uuid=56603c36-46b9-4608-90ae-c731c15eae64,manufacturer=QEMU"
+	machineScope.SetVirtualMachine(vm)
+	expectedOptions := []interface{}{
+		proxmox.VirtualMachineOption{Name: optionSMBios1, Value: "uuid=56603c36-46b9-4608-90ae-c731c15eae64,manufacturer=SU9OT1M=,serial=Q1otNDcxMQ==,base64=1"},
+	}
+
+	proxmoxClient.EXPECT().ConfigureVM(context.TODO(), vm, expectedOptions...).Return(newTask(), nil).Once()
+
+	requeue, err := reconcileVirtualMachineConfig(context.TODO(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	// the VM has the fields now.
+	vm.VirtualMachineConfig.SMBios1 = expectedOptions[0].(proxmox.VirtualMachineOption).Value.(string)
+	requeue, err = reconcileVirtualMachineConfig(context.TODO(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+	require.Equal(t, "56603c36-46b9-4608-90ae-c731c15eae64", extractUUID(vm.VirtualMachineConfig.SMBios1))
+}
+
+func TestReconcileIdentityTags(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Tags = "k8s"
+	machineScope.SetVirtualMachine(vm)
+
+	proxmoxClient.EXPECT().TagVM(context.Background(), vm, "capmox_default_test").Return(newTask(), nil).Once()
+
+	requeue, err := reconcileIdentityTags(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+	require.Equal(t, "result", *machineScope.ProxmoxMachine.Status.TaskRef)
+
+	// the VM is fetched again after the task.
+	vm = newRunningVM()
+	vm.VirtualMachineConfig.Tags = "k8s;capmox_default_test"
+	machineScope.SetVirtualMachine(vm)
+	proxmoxClient.EXPECT().TagVM(context.Background(), vm, "capmox-machine_test").Return(newTask(), nil).Once()
+
+	requeue, err = reconcileIdentityTags(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.True(t, requeue)
+
+	vm = newRunningVM()
+	vm.VirtualMachineConfig.Tags = "k8s;capmox_default_test;capmox-machine_test"
+	machineScope.SetVirtualMachine(vm)
+	requeue, err = reconcileIdentityTags(context.Background(), machineScope)
+	require.NoError(t, err)
+	require.False(t, requeue)
+}
+
 func TestReconcileVirtualMachineConfig_ApplyConfig(t *testing.T) {
 	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
 	machineScope.ProxmoxMachine.Spec.NumSockets = 4
 	machineScope.ProxmoxMachine.Spec.NumCores = 4
+	machineScope.ProxmoxMachine.Spec.NumVCPUs = 8
+	machineScope.ProxmoxMachine.Spec.CPULimit = "1.5"
+	machineScope.ProxmoxMachine.Spec.CPUUnits = 50
 	machineScope.ProxmoxMachine.Spec.MemoryMiB = 16 * 1024
 	machineScope.ProxmoxMachine.Spec.Network = &infrav1alpha1.NetworkSpec{
 		Default: &infrav1alpha1.NetworkDevice{Bridge: "vmbr0", Model: ptr.To("virtio")},
@@ -166,6 +357,10 @@ func TestReconcileVirtualMachineConfig_ApplyConfig(t *testing.T) {
 				Name:          "net1",
 				NetworkDevice: infrav1alpha1.NetworkDevice{Bridge: "vmbr1", Model: ptr.To("virtio")},
 			},
+			{
+				Name:          "net2",
+				NetworkDevice: infrav1alpha1.NetworkDevice{VNet: "test", Model: ptr.To("virtio")},
+			},
 		},
 	}
 
@@ -175,9 +370,14 @@ func TestReconcileVirtualMachineConfig_ApplyConfig(t *testing.T) {
 	expectedOptions := []interface{}{
 		proxmox.VirtualMachineOption{Name: optionSockets, Value: machineScope.ProxmoxMachine.Spec.NumSockets},
 		proxmox.VirtualMachineOption{Name: optionCores, Value: machineScope.ProxmoxMachine.Spec.NumCores},
+		proxmox.VirtualMachineOption{Name: optionVCPUs, Value: machineScope.ProxmoxMachine.Spec.NumVCPUs},
+		proxmox.VirtualMachineOption{Name: optionCPULimit, Value: machineScope.ProxmoxMachine.Spec.CPULimit},
+		proxmox.VirtualMachineOption{Name: optionCPUUnits, Value: machineScope.ProxmoxMachine.Spec.CPUUnits},
 		proxmox.VirtualMachineOption{Name: optionMemory, Value: machineScope.ProxmoxMachine.Spec.MemoryMiB},
+		proxmox.VirtualMachineOption{Name: optionTags, Value: "capmox_default_test;capmox-machine_test"},
 		proxmox.VirtualMachineOption{Name: "net0", Value: formatNetworkDevice("virtio", "vmbr0")},
 		proxmox.VirtualMachineOption{Name: "net1", Value: formatNetworkDevice("virtio", "vmbr1")},
+		proxmox.VirtualMachineOption{Name: "net2", Value: formatNetworkDevice("virtio", "test")},
 	}
 
 	proxmoxClient.EXPECT().ConfigureVM(context.TODO(), vm, expectedOptions...).Return(task, nil).Once()
@@ -219,7 +419,7 @@ func TestReconcileMachineAddresses_IPV4(t *testing.T) {
 	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
 	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
 
-	require.NoError(t, reconcileMachineAddresses(machineScope))
+	require.NoError(t, reconcileMachineAddresses(context.Background(), machineScope))
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[0].Address, machineScope.ProxmoxMachine.GetName())
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[1].Address, "10.10.10.10")
 }
@@ -239,7 +439,7 @@ func TestReconcileMachineAddresses_IPV6(t *testing.T) {
 	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV6: "2001:db8::2"}}
 	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
 
-	require.NoError(t, reconcileMachineAddresses(machineScope))
+	require.NoError(t, reconcileMachineAddresses(context.Background(), machineScope))
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[0].Address, machineScope.ProxmoxMachine.GetName())
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[1].Address, "2001:db8::2")
 }
@@ -258,8 +458,70 @@ func TestReconcileMachineAddresses_DualStack(t *testing.T) {
 	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10", IPV6: "2001:db8::2"}}
 	machineScope.ProxmoxMachine.Status.BootstrapDataProvided = ptr.To(true)
 
-	require.NoError(t, reconcileMachineAddresses(machineScope))
+	require.NoError(t, reconcileMachineAddresses(context.Background(), machineScope))
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[0].Address, machineScope.ProxmoxMachine.GetName())
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[1].Address, "10.10.10.10")
 	require.Equal(t, machineScope.ProxmoxMachine.Status.Addresses[2].Address, "2001:db8::2")
 }
+
+func TestReconcileMachineAddresses_AdditionalDevices(t *testing.T) {
+	machineScope, _, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{
+		infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"},
+		"net1":                             {IPV4: "203.0.113.10", IPV6: "2001:db8::10"},
+		"net2":                             {IPV4: "192.168.1.5"},
+	}
+
+	require.NoError(t, reconcileMachineAddresses(context.Background(), machineScope))
+	require.Equal(t, []clusterv1.MachineAddress{
+		{Type: clusterv1.MachineHostName, Address: machineScope.ProxmoxMachine.GetName()},
+		{Type: clusterv1.MachineInternalIP, Address: "10.10.10.10"},
+		{Type: clusterv1.MachineExternalIP, Address: "203.0.113.10"},
+		{Type: clusterv1.MachineExternalIP, Address: "2001:db8::10"},
+		{Type: clusterv1.MachineInternalIP, Address: "192.168.1.5"},
+	}, machineScope.ProxmoxMachine.Status.Addresses)
+}
+
+func TestReconcileMachineAddresses_GuestAgent(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Agent = "enabled=1,fstrim_cloned_disks=1"
+	vm.VirtualMachineConfig.Net0 = "virtio=BC:24:11:00:00:01,bridge=vmbr0"
+	vm.VirtualMachineConfig.Net1 = "virtio=BC:24:11:00:00:02,bridge=vmbr1"
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+
+	proxmoxClient.EXPECT().GetGuestNetworkInterfaces(context.Background(), vm).Return([]proxmox.GuestNetworkInterface{
+		{Name: "eth0", MACAddress: "bc:24:11:00:00:01", IPAddresses: []string{"10.10.10.10", "10.10.10.11", "fe80::be24:11ff:fe00:1"}},
+		{Name: "eth1", MACAddress: "bc:24:11:00:00:02", IPAddresses: []string{"198.51.100.7"}},
+		{Name: "cni0", MACAddress: "6e:8a:00:00:00:01", IPAddresses: []string{"10.244.0.1"}},
+	}, nil).Once()
+
+	require.NoError(t, reconcileMachineAddresses(context.Background(), machineScope))
+	require.Equal(t, []clusterv1.MachineAddress{
+		{Type: clusterv1.MachineHostName, Address: machineScope.ProxmoxMachine.GetName()},
+		{Type: clusterv1.MachineInternalIP, Address: "10.10.10.10"},
+		{Type: clusterv1.MachineInternalIP, Address: "10.10.10.11"},
+		{Type: clusterv1.MachineExternalIP, Address: "198.51.100.7"},
+	}, machineScope.ProxmoxMachine.Status.Addresses)
+}
+
+func TestReconcileMachineAddresses_GuestAgentNotRunning(t *testing.T) {
+	machineScope, proxmoxClient, _ := setupReconcilerTest(t)
+	vm := newRunningVM()
+	vm.VirtualMachineConfig.Agent = "1"
+	machineScope.SetVirtualMachine(vm)
+	machineScope.SetVirtualMachineID(int64(vm.VMID))
+	machineScope.ProxmoxMachine.Status.IPAddresses = map[string]infrav1alpha1.IPAddress{infrav1alpha1.DefaultNetworkDevice: {IPV4: "10.10.10.10"}}
+
+	proxmoxClient.EXPECT().GetGuestNetworkInterfaces(context.Background(), vm).Return(nil, errors.New("QEMU guest agent is not running")).Once()
+
+	// the static addresses are reported anyway.
+	require.NoError(t, reconcileMachineAddresses(context.Background(), machineScope))
+	require.Len(t, machineScope.ProxmoxMachine.Status.Addresses, 2)
+	require.Equal(t, "10.10.10.10", machineScope.ProxmoxMachine.Status.Addresses[1].Address)
+}
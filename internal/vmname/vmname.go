@@ -0,0 +1,97 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vmname renders and validates the names of Proxmox VMs.
+package vmname
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// RoleControlPlane is the role of control plane machines.
+	RoleControlPlane = "control-plane"
+	// RoleWorker is the role of worker machines.
+	RoleWorker = "worker"
+
+	// randomLength is the length of the random part of VM names.
+	randomLength = 5
+
+	// maxLength and maxLabelLength are the limits of DNS names, which Proxmox requires VM names to be.
+	maxLength      = 253
+	maxLabelLength = 63
+)
+
+// labelRegex matches a label of a DNS name, which Proxmox validates VM names with.
+var labelRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+
+// Data are the fields available in a VM name template.
+type Data struct {
+	ClusterName string
+	MachineName string
+	Namespace   string
+	Role        string
+	Random      string
+}
+
+// Random returns the random part of the VM name of a machine. It is derived from the UID
+// of the machine, so the name is the same in every reconciliation.
+func Random(uid types.UID) string {
+	sum := sha256.Sum256([]byte(uid))
+	return hex.EncodeToString(sum[:])[:randomLength]
+}
+
+// Render renders the VM name template with the data.
+func Render(nameTemplate string, data Data) (string, error) {
+	tmpl, err := template.New("vmName").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid VM name template")
+	}
+
+	var name strings.Builder
+	if err := tmpl.Execute(&name, data); err != nil {
+		return "", errors.Wrap(err, "unable to render VM name template")
+	}
+	return name.String(), nil
+}
+
+// Validate checks that the name is a DNS name, as Proxmox requires for VM names.
+func Validate(name string) error {
+	if name == "" {
+		return errors.New("VM name must not be empty")
+	}
+	if len(name) > maxLength {
+		return fmt.Errorf("VM name %q must be no more than %d characters", name, maxLength)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) > maxLabelLength {
+			return fmt.Errorf("VM name %q must consist of parts of no more than %d characters, separated by '.'", name, maxLabelLength)
+		}
+		if !labelRegex.MatchString(label) {
+			return fmt.Errorf("VM name %q must be a DNS name: parts may only contain alphanumeric characters and '-', "+
+				"must start and end with an alphanumeric character and are separated by '.'", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vmname
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	data := Data{ClusterName: "capmox", MachineName: "test", Namespace: "default", Role: RoleWorker, Random: Random("uid")}
+
+	name, err := Render("dc1-{{.ClusterName}}-{{.Role}}-{{.Random}}", data)
+	require.NoError(t, err)
+	require.Equal(t, "dc1-capmox-worker-"+Random("uid"), name)
+	require.Len(t, Random("uid"), randomLength)
+
+	_, err = Render("{{.ClusterName", data)
+	require.ErrorContains(t, err, "invalid VM name template")
+
+	_, err = Render("{{.Unknown}}", data)
+	require.ErrorContains(t, err, "unable to render VM name template")
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]string{
+		"test":                          "",
+		"Capmox-Worker-1":               "",
+		"worker.dc1.example":            "",
+		"":                              "must not be empty",
+		"worker_1":                      "must be a DNS name",
+		"-worker":                       "must be a DNS name",
+		"worker-":                       "must be a DNS name",
+		"worker..dc1":                   "must be a DNS name",
+		strings.Repeat("a", 64):         "parts of no more than 63 characters",
+		strings.Repeat("a.", 127) + "a": "no more than 253 characters",
+	}
+
+	for name, expectedError := range tests {
+		err := Validate(name)
+		if expectedError == "" {
+			require.NoError(t, err, name)
+			continue
+		}
+		require.ErrorContains(t, err, expectedError, name)
+	}
+}
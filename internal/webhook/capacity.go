@@ -0,0 +1,111 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/service/scheduler"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// capacityCheckTimeout limits the Proxmox API calls of the capacity check, so that the admission request
+// does not time out.
+const capacityCheckTimeout = 5 * time.Second
+
+// capacityChecker warns about new machines which do not fit on the eligible nodes of their cluster.
+type capacityChecker struct {
+	reader        client.Reader
+	proxmoxClient proxmox.Client
+}
+
+// warnings simulates the placement of count machines with the given spec on the eligible nodes of the cluster
+// the object belongs to. The check is skipped without a Proxmox client, for objects which do not belong to a cluster,
+// and if the Proxmox API fails, as the machines are scheduled again when they are created anyway.
+func (c capacityChecker) warnings(ctx context.Context, obj metav1.Object, spec *infrav1.ProxmoxMachineSpec, controlPlane bool, count int) admission.Warnings {
+	if c.proxmoxClient == nil || c.reader == nil || count < 1 {
+		return nil
+	}
+	clusterName, ok := obj.GetLabels()[clusterv1.ClusterNameLabel]
+	if !ok {
+		return nil
+	}
+
+	logger := ctrl.LoggerFrom(ctx)
+	cluster := &clusterv1.Cluster{}
+	if err := c.reader.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: clusterName}, cluster); err != nil || cluster.Spec.InfrastructureRef == nil {
+		return nil
+	}
+	proxmoxCluster := &infrav1.ProxmoxCluster{}
+	if err := c.reader.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: cluster.Spec.InfrastructureRef.Name}, proxmoxCluster); err != nil {
+		return nil
+	}
+
+	// the machines inherit the machine size and the machine defaults of the cluster.
+	machine := &infrav1.ProxmoxMachine{Spec: *spec.DeepCopy()}
+	proxmoxCluster.MachineSize(machine.Spec.Size).ApplyTo(machine)
+	proxmoxCluster.Spec.MachineDefaults.ApplyTo(machine)
+
+	ctx, cancel := context.WithTimeout(ctx, capacityCheckTimeout)
+	defer cancel()
+	nodes, err := scheduler.SimulatePlacement(ctx, c.proxmoxClient, proxmoxCluster, machine, controlPlane, count)
+	if errors.Is(err, scheduler.ErrNoEligibleNodes) {
+		return admission.Warnings{fmt.Sprintf("ProxmoxCluster %s has no eligible nodes to schedule the machines on", proxmoxCluster.GetName())}
+	}
+	if err != nil {
+		logger.V(4).Info("skipping capacity check", "reason", err.Error())
+		return nil
+	}
+
+	if len(nodes) < count {
+		return admission.Warnings{fmt.Sprintf("only %d of %d machines with %d MiB of memory fit on the eligible nodes of ProxmoxCluster %s",
+			len(nodes), count, machine.Spec.MemoryMiB, proxmoxCluster.GetName())}
+	}
+	return nil
+}
+
+// machineDeploymentReplicas returns the number of replicas of the MachineDeployments which create their machines
+// from the ProxmoxMachineTemplate.
+func (c capacityChecker) machineDeploymentReplicas(ctx context.Context, template *infrav1.ProxmoxMachineTemplate) int {
+	if c.reader == nil {
+		return 0
+	}
+	deployments := &clusterv1.MachineDeploymentList{}
+	if err := c.reader.List(ctx, deployments, client.InNamespace(template.GetNamespace())); err != nil {
+		return 0
+	}
+
+	replicas := 0
+	for _, deployment := range deployments.Items {
+		ref := deployment.Spec.Template.Spec.InfrastructureRef
+		if ref.Kind == "ProxmoxMachineTemplate" && ref.Name == template.GetName() {
+			replicas += int(ptr.Deref(deployment.Spec.Replicas, 1))
+		}
+	}
+	return replicas
+}
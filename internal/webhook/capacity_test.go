@@ -0,0 +1,105 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+var _ = Describe("Capacity check", func() {
+	g := NewWithT(GinkgoT())
+
+	newChecker := func(reservable uint64) (capacityChecker, *infrav1.ProxmoxMachineTemplate) {
+		scheme := runtime.NewScheme()
+		g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+		g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+
+		cluster := &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+			Spec:       clusterv1.ClusterSpec{InfrastructureRef: &corev1.ObjectReference{Kind: infrav1.ProxmoxClusterKind, Name: "test"}},
+		}
+		proxmoxCluster := &infrav1.ProxmoxCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+			Spec: infrav1.ProxmoxClusterSpec{
+				AllowedNodes: []string{"pve1"},
+				MachineSizes: []infrav1.MachineSize{{Name: "large", NumSockets: 2, NumCores: 4, MemoryMiB: 16384}},
+			},
+		}
+		deployment := &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-md-0", Namespace: metav1.NamespaceDefault},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ClusterName: "test",
+				Replicas:    ptr.To[int32](3),
+				Template: clusterv1.MachineTemplateSpec{Spec: clusterv1.MachineSpec{
+					ClusterName:       "test",
+					InfrastructureRef: corev1.ObjectReference{Kind: "ProxmoxMachineTemplate", Name: "test-worker"},
+				}},
+			},
+		}
+		template := &infrav1.ProxmoxMachineTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-worker",
+				Namespace: metav1.NamespaceDefault,
+				Labels:    map[string]string{clusterv1.ClusterNameLabel: "test"},
+			},
+			Spec: infrav1.ProxmoxMachineTemplateSpec{Template: infrav1.ProxmoxMachineTemplateResource{
+				Spec: infrav1.ProxmoxMachineSpec{Size: "large"},
+			}},
+		}
+
+		proxmoxClient := proxmoxtest.NewMockClient(GinkgoT())
+		// the check waits for the Proxmox API with a timeout.
+		proxmoxClient.On("GetNodeMemory", mock.Anything, "pve1", infrav1.MemoryAccountingMaxMemory).Return(proxmox.NodeMemory{TotalBytes: reservable}, nil).Maybe()
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, proxmoxCluster, deployment).Build()
+		return capacityChecker{reader: reader, proxmoxClient: proxmoxClient}, template
+	}
+
+	It("should warn about the replicas of the MachineDeployments which do not fit", func() {
+		checker, template := newChecker(40 << 30)
+		replicas := checker.machineDeploymentReplicas(context.Background(), template)
+		g.Expect(replicas).To(Equal(3))
+
+		// the machine size of the cluster applies.
+		warnings := checker.warnings(context.Background(), template, &template.Spec.Template.Spec, false, replicas)
+		g.Expect(warnings).To(ConsistOf("only 2 of 3 machines with 16384 MiB of memory fit on the eligible nodes of ProxmoxCluster test"))
+	})
+
+	It("should not warn if the machines fit", func() {
+		checker, template := newChecker(48 << 30)
+		g.Expect(checker.warnings(context.Background(), template, &template.Spec.Template.Spec, false, 3)).To(BeEmpty())
+	})
+
+	It("should skip objects without a cluster", func() {
+		checker, template := newChecker(0)
+		template.Labels = nil
+		g.Expect(checker.warnings(context.Background(), template, &template.Spec.Template.Spec, false, 3)).To(BeEmpty())
+	})
+})
@@ -24,12 +24,18 @@ import (
 	"strings"
 
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
 	"github.com/pkg/errors"
 	"go4.org/netipx"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	ipamicv1 "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -37,11 +43,19 @@ var _ admission.CustomValidator = &ProxmoxCluster{}
 
 // ProxmoxCluster is a type that implements
 // the interfaces from the admission package.
-type ProxmoxCluster struct{}
+type ProxmoxCluster struct {
+	// Reader is used to look up the IP pools of other clusters.
+	// It defaults to the API reader of the manager.
+	Reader client.Reader
+}
 
 // SetupWebhookWithManager sets up the webhook with the
 // custom interfaces.
 func (p *ProxmoxCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if p.Reader == nil {
+		p.Reader = mgr.GetAPIReader()
+	}
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(&infrav1.ProxmoxCluster{}).
 		WithValidator(p).
@@ -51,7 +65,7 @@ func (p *ProxmoxCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
 //+kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha1-proxmoxcluster,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxclusters,versions=v1alpha1,name=validation.proxmoxcluster.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
 
 // ValidateCreate implements the creation validation function.
-func (*ProxmoxCluster) ValidateCreate(_ context.Context, obj runtime.Object) (warnings admission.Warnings, err error) {
+func (p *ProxmoxCluster) ValidateCreate(ctx context.Context, obj runtime.Object) (warnings admission.Warnings, err error) {
 	cluster, ok := obj.(*infrav1.ProxmoxCluster)
 	if !ok {
 		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxCluster but got %T", obj))
@@ -68,7 +82,35 @@ func (*ProxmoxCluster) ValidateCreate(_ context.Context, obj runtime.Object) (wa
 		return warnings, err
 	}
 
-	return warnings, nil
+	if err := validateMachineDefaults(cluster); err != nil {
+		return warnings, err
+	}
+
+	if err := validateAdditionalIPPools(cluster); err != nil {
+		return warnings, err
+	}
+
+	if err := validateNodeIPPools(cluster); err != nil {
+		return warnings, err
+	}
+
+	poolWarnings, err := validateIPPoolConfigs(nil, cluster)
+	warnings = append(warnings, poolWarnings...)
+	if err != nil {
+		return warnings, err
+	}
+
+	if err := validateDNSServers(cluster); err != nil {
+		return warnings, err
+	}
+
+	if err := validateSchedulerHints(cluster); err != nil {
+		return warnings, err
+	}
+
+	overlapWarnings, err := p.validateIPPoolOverlap(ctx, nil, cluster)
+	warnings = append(warnings, overlapWarnings...)
+	return warnings, err
 }
 
 // ValidateDelete implements the deletion validation function.
@@ -77,18 +119,67 @@ func (*ProxmoxCluster) ValidateDelete(_ context.Context, _ runtime.Object) (admi
 }
 
 // ValidateUpdate implements the update validation function.
-func (*ProxmoxCluster) ValidateUpdate(_ context.Context, _ runtime.Object, newObj runtime.Object) (warnings admission.Warnings, err error) {
+func (p *ProxmoxCluster) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (warnings admission.Warnings, err error) {
 	newCluster, ok := newObj.(*infrav1.ProxmoxCluster)
 	if !ok {
 		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxCluster but got %T", newCluster))
 	}
+	oldCluster, ok := oldObj.(*infrav1.ProxmoxCluster)
+	if !ok {
+		return warnings, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxCluster but got %T", oldObj))
+	}
 
 	if err := validateIPs(newCluster); err != nil {
 		warnings = append(warnings, fmt.Sprintf("cannot update proxmox cluster %s", newCluster.GetName()))
 		return warnings, err
 	}
 
-	return warnings, nil
+	if err := validateMachineDefaults(newCluster); err != nil {
+		return warnings, err
+	}
+
+	if err := validateAdditionalIPPools(newCluster); err != nil {
+		return warnings, err
+	}
+
+	if err := validateNodeIPPools(newCluster); err != nil {
+		return warnings, err
+	}
+
+	// objects being deleted must be able to drop their finalizers, even if they were valid
+	// before stricter checks were introduced.
+	deleting := !newCluster.DeletionTimestamp.IsZero()
+
+	if !deleting {
+		poolWarnings, err := validateIPPoolConfigs(oldCluster, newCluster)
+		warnings = append(warnings, poolWarnings...)
+		if err != nil {
+			return warnings, err
+		}
+	}
+
+	if !deleting && dnsServersChanged(oldCluster, newCluster) {
+		if err := validateDNSServers(newCluster); err != nil {
+			return warnings, err
+		}
+	}
+
+	if !deleting && schedulerHintsChanged(oldCluster, newCluster) {
+		if err := validateSchedulerHints(newCluster); err != nil {
+			return warnings, err
+		}
+	}
+
+	if err := p.validateIPPoolChanges(ctx, oldCluster, newCluster); err != nil {
+		return warnings, err
+	}
+
+	if deleting {
+		return warnings, nil
+	}
+	overlapWarnings, err := p.validateIPPoolOverlap(ctx, oldCluster, newCluster)
+	warnings = append(warnings, overlapWarnings...)
+	return warnings, err
 }
 
 func validateIPs(cluster *infrav1.ProxmoxCluster) error {
@@ -193,6 +284,564 @@ func buildSetFromAddresses(addresses []string) (*netipx.IPSet, error) {
 	return set, nil
 }
 
+// validateMachineDefaults checks that the VM name template of the machine defaults renders names which Proxmox accepts.
+func validateMachineDefaults(cluster *infrav1.ProxmoxCluster) error {
+	defaults := cluster.Spec.MachineDefaults
+	if defaults == nil || defaults.VMNameTemplate == nil || *defaults.VMNameTemplate == "" {
+		return nil
+	}
+
+	path := field.NewPath("spec", "machineDefaults", "vmNameTemplate")
+	if errs := validateVMName(path, "", defaults.VMNameTemplate, exampleVMNameData); len(errs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), errs)
+	}
+	return nil
+}
+
+// validateAdditionalIPPools checks that the additional pools only define valid addresses of the address
+// families the cluster defines as well.
+func validateAdditionalIPPools(cluster *infrav1.ProxmoxCluster) error {
+	ep := cluster.Spec.ControlPlaneEndpoint
+	endpoint, _ := netip.ParseAddr(ep.Host)
+
+	var errs field.ErrorList
+	for _, family := range []struct {
+		name          string
+		configs       []ipamicv1.InClusterIPPoolSpec
+		clusterName   string
+		clusterConfig *ipamicv1.InClusterIPPoolSpec
+	}{
+		{"additionalIPv4Configs", cluster.Spec.AdditionalIPv4Configs, "ipv4Config", cluster.Spec.IPv4Config},
+		{"additionalIPv6Configs", cluster.Spec.AdditionalIPv6Configs, "ipv6Config", cluster.Spec.IPv6Config},
+	} {
+		path := field.NewPath("spec", family.name)
+		if len(family.configs) > 0 && family.clusterConfig == nil {
+			errs = append(errs, field.Forbidden(path, fmt.Sprintf("requires the %s of the cluster", family.clusterName)))
+			continue
+		}
+		for i, config := range family.configs {
+			set, err := buildSetFromAddresses(config.Addresses)
+			if err != nil {
+				errs = append(errs, field.Invalid(path.Index(i).Child("addresses"), config.Addresses, "provided addresses are not valid IP addresses, ranges or CIDRs"))
+				continue
+			}
+			if endpoint.IsValid() && set.Contains(endpoint) {
+				errs = append(errs, field.Invalid(path.Index(i).Child("addresses"), config.Addresses, "addresses may not contain the endpoint IP"))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), errs)
+	}
+	return nil
+}
+
+// validateNodeIPPools checks that every node is in one node IP pool at most, and that the pools only
+// define valid addresses of the address families the cluster defines as well.
+func validateNodeIPPools(cluster *infrav1.ProxmoxCluster) error {
+	ep := cluster.Spec.ControlPlaneEndpoint
+	endpoint, _ := netip.ParseAddr(ep.Host)
+
+	var errs field.ErrorList
+	poolOfNode := make(map[string]string)
+	for i, pool := range cluster.Spec.NodeIPPools {
+		path := field.NewPath("spec", "nodeIPPools").Index(i)
+		for j, node := range pool.Nodes {
+			if other, ok := poolOfNode[node]; ok {
+				errs = append(errs, field.Invalid(path.Child("nodes").Index(j), node, fmt.Sprintf("node is already in node IP pool %s", other)))
+				continue
+			}
+			poolOfNode[node] = pool.Name
+		}
+
+		for _, family := range []struct {
+			name          string
+			config        *ipamicv1.InClusterIPPoolSpec
+			clusterConfig *ipamicv1.InClusterIPPoolSpec
+		}{
+			{"ipv4Config", pool.IPv4Config, cluster.Spec.IPv4Config},
+			{"ipv6Config", pool.IPv6Config, cluster.Spec.IPv6Config},
+		} {
+			if family.config == nil {
+				continue
+			}
+			if family.clusterConfig == nil {
+				errs = append(errs, field.Forbidden(path.Child(family.name), fmt.Sprintf("requires the %s of the cluster", family.name)))
+				continue
+			}
+
+			set, err := buildSetFromAddresses(family.config.Addresses)
+			if err != nil {
+				errs = append(errs, field.Invalid(path.Child(family.name, "addresses"), family.config.Addresses, "provided addresses are not valid IP addresses, ranges or CIDRs"))
+				continue
+			}
+			if endpoint.IsValid() && set.Contains(endpoint) {
+				errs = append(errs, field.Invalid(path.Child(family.name, "addresses"), family.config.Addresses, "addresses may not contain the endpoint IP"))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), errs)
+	}
+	return nil
+}
+
+// validateIPPoolConfigs checks that the addresses and gateways of the cluster, additional and node IP pools belong to the
+// address family of their config, and that the pools share no addresses with each other.
+// Invalid addresses are reported by validateIPs, validateAdditionalIPPools and validateNodeIPPools.
+// On updates, only the pool configs which differ from the old cluster are checked, so clusters created before
+// a check was introduced can still be updated.
+func validateIPPoolConfigs(oldCluster, cluster *infrav1.ProxmoxCluster) (admission.Warnings, error) {
+	var oldConfigs map[string]*ipamicv1.InClusterIPPoolSpec
+	if oldCluster != nil {
+		oldConfigs = make(map[string]*ipamicv1.InClusterIPPoolSpec)
+		for _, p := range ipPoolConfigs(oldCluster) {
+			oldConfigs[p.path.String()] = p.config
+		}
+	}
+
+	var errs field.ErrorList
+	var warnings admission.Warnings
+	var sets []*netipx.IPSet
+	var paths []*field.Path
+	var changed []bool
+	for _, p := range ipPoolConfigs(cluster) {
+		if p.config == nil {
+			continue
+		}
+		oldConfig, ok := oldConfigs[p.path.String()]
+		poolChanged := oldCluster == nil || !ok || !equality.Semantic.DeepEqual(oldConfig, p.config)
+		if poolChanged {
+			poolErrs, poolWarnings := validatePoolConfig(p.path, p.config, p.ipv6)
+			errs = append(errs, poolErrs...)
+			warnings = append(warnings, poolWarnings...)
+		}
+
+		set, err := buildSetFromAddresses(p.config.Addresses)
+		if err != nil {
+			continue
+		}
+		for j, other := range sets {
+			// node IP pools replace the cluster pool for their nodes, they must not hand out its addresses.
+			if (poolChanged || changed[j]) && set.Overlaps(other) {
+				errs = append(errs, field.Invalid(p.path.Child("addresses"), p.config.Addresses, fmt.Sprintf("addresses overlap with %s", paths[j])))
+			}
+		}
+		sets = append(sets, set)
+		paths = append(paths, p.path)
+		changed = append(changed, poolChanged)
+	}
+
+	if len(errs) > 0 {
+		return warnings, apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), errs)
+	}
+	return warnings, nil
+}
+
+// ipPoolConfig is an IP pool config of a cluster and its path.
+type ipPoolConfig struct {
+	path   *field.Path
+	config *ipamicv1.InClusterIPPoolSpec
+	ipv6   bool
+}
+
+// ipPoolConfigs returns the configs of the cluster, additional and node IP pools of the cluster.
+func ipPoolConfigs(cluster *infrav1.ProxmoxCluster) []ipPoolConfig {
+	pools := []ipPoolConfig{
+		{field.NewPath("spec", "ipv4Config"), cluster.Spec.IPv4Config, false},
+		{field.NewPath("spec", "ipv6Config"), cluster.Spec.IPv6Config, true},
+	}
+	for i := range cluster.Spec.AdditionalIPv4Configs {
+		pools = append(pools, ipPoolConfig{field.NewPath("spec", "additionalIPv4Configs").Index(i), &cluster.Spec.AdditionalIPv4Configs[i], false})
+	}
+	for i := range cluster.Spec.AdditionalIPv6Configs {
+		pools = append(pools, ipPoolConfig{field.NewPath("spec", "additionalIPv6Configs").Index(i), &cluster.Spec.AdditionalIPv6Configs[i], true})
+	}
+	for i, nodePool := range cluster.Spec.NodeIPPools {
+		path := field.NewPath("spec", "nodeIPPools").Index(i)
+		pools = append(pools,
+			ipPoolConfig{path.Child("ipv4Config"), nodePool.IPv4Config, false},
+			ipPoolConfig{path.Child("ipv6Config"), nodePool.IPv6Config, true})
+	}
+	return pools
+}
+
+// validatePoolConfig checks the address family of the addresses, the gateway and the prefix of an IP pool config,
+// and that the addresses are in the network of the gateway. Host prefixes like /32 are only warned about, as they
+// are valid with a gateway which is routed on-link.
+func validatePoolConfig(path *field.Path, config *ipamicv1.InClusterIPPoolSpec, ipv6 bool) (field.ErrorList, admission.Warnings) {
+	family, bits := "IPv4", 32
+	if ipv6 {
+		family, bits = "IPv6", 128
+	}
+
+	var errs field.ErrorList
+	var warnings admission.Warnings
+	for i, address := range config.Addresses {
+		from, to, err := addressBounds(address)
+		switch {
+		case err != nil:
+			// reported by validateIPs and validateNodeIPPools.
+		case from.Is6() != ipv6:
+			errs = append(errs, field.Invalid(path.Child("addresses").Index(i), address, fmt.Sprintf("must be an %s address, range or CIDR", family)))
+		case from.Is4In6() || to.Is4In6():
+			errs = append(errs, field.Invalid(path.Child("addresses").Index(i), address, "must not be an IPv4-mapped IPv6 address, use the ipv4Config instead"))
+		case from.Zone() != "" || to.Zone() != "":
+			errs = append(errs, field.Invalid(path.Child("addresses").Index(i), address, "must not have a zone"))
+		}
+	}
+
+	if config.Prefix < 0 || config.Prefix > bits {
+		errs = append(errs, field.Invalid(path.Child("prefix"), config.Prefix, fmt.Sprintf("must be between 0 and %d", bits)))
+		return errs, warnings
+	}
+	if config.Prefix == bits {
+		warnings = append(warnings, fmt.Sprintf("%s: prefix /%d makes every address a network of its own, "+
+			"the gateway must be routed on-link by the guests", path, config.Prefix))
+	}
+
+	if config.Gateway == "" {
+		return errs, warnings
+	}
+	gateway, err := netip.ParseAddr(config.Gateway)
+	switch {
+	case err != nil:
+		errs = append(errs, field.Invalid(path.Child("gateway"), config.Gateway, "must be a valid IP address"))
+	case gateway.Is6() != ipv6:
+		errs = append(errs, field.Invalid(path.Child("gateway"), config.Gateway, fmt.Sprintf("must be an %s address", family)))
+	case gateway.Is4In6():
+		errs = append(errs, field.Invalid(path.Child("gateway"), config.Gateway, "must not be an IPv4-mapped IPv6 address"))
+	case gateway.Zone() != "":
+		errs = append(errs, field.Invalid(path.Child("gateway"), config.Gateway, "must not have a zone"))
+	case config.Prefix == bits || gateway.IsLinkLocalUnicast():
+		// the gateway is not in the network of the addresses. IPv6 gateways are often the link-local address of the router.
+	default:
+		network := netip.PrefixFrom(gateway, config.Prefix).Masked()
+		if address := outsideNetwork(network, config.Addresses); address != "" {
+			errs = append(errs, field.Invalid(path.Child("gateway"), config.Gateway,
+				fmt.Sprintf("must be in the network of the addresses, but %s is not in %s; check the gateway and the prefix", address, network)))
+		}
+	}
+	return errs, warnings
+}
+
+// addressBounds returns the first and the last address of an IP address, range or CIDR.
+func addressBounds(address string) (from, to netip.Addr, err error) {
+	switch {
+	case strings.Contains(address, "-"):
+		ipRange, err := netipx.ParseIPRange(address)
+		return ipRange.From(), ipRange.To(), err
+	case strings.Contains(address, "/"):
+		prefix, err := netip.ParsePrefix(address)
+		if err != nil {
+			return from, to, err
+		}
+		ipRange := netipx.RangeOfPrefix(prefix)
+		return ipRange.From(), ipRange.To(), nil
+	default:
+		addr, err := netip.ParseAddr(address)
+		return addr, addr, err
+	}
+}
+
+// outsideNetwork returns the first of the addresses which is not entirely in the network, or an empty string.
+// Invalid addresses are ignored.
+func outsideNetwork(network netip.Prefix, addresses []string) string {
+	for _, address := range addresses {
+		from, to, err := addressBounds(address)
+		if err == nil && (!network.Contains(from) || !network.Contains(to)) {
+			return address
+		}
+	}
+	return ""
+}
+
+// validateDNSServers checks that the nameservers are IP addresses, and that the IPv6 nameservers are IPv6 addresses.
+func validateDNSServers(cluster *infrav1.ProxmoxCluster) error {
+	var errs field.ErrorList
+	for i, server := range cluster.Spec.DNSServers {
+		if _, err := netip.ParseAddr(server); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "dnsServers").Index(i), server, "must be a valid IP address"))
+		}
+	}
+	for i, server := range cluster.Spec.IPv6DNSServers {
+		if addr, err := netip.ParseAddr(server); err != nil || !addr.Is6() {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "ipv6DNSServers").Index(i), server, "must be a valid IPv6 address"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), errs)
+	}
+	return nil
+}
+
+// dnsServersChanged returns true if the nameservers of the cluster changed.
+func dnsServersChanged(oldCluster, newCluster *infrav1.ProxmoxCluster) bool {
+	return !equality.Semantic.DeepEqual(oldCluster.Spec.DNSServers, newCluster.Spec.DNSServers) ||
+		!equality.Semantic.DeepEqual(oldCluster.Spec.IPv6DNSServers, newCluster.Spec.IPv6DNSServers)
+}
+
+// schedulerHintsChanged returns true if the scheduler hints or the node selection of the cluster changed.
+func schedulerHintsChanged(oldCluster, newCluster *infrav1.ProxmoxCluster) bool {
+	return !equality.Semantic.DeepEqual(oldCluster.Spec.SchedulerHints, newCluster.Spec.SchedulerHints) ||
+		!equality.Semantic.DeepEqual(oldCluster.Spec.AllowedNodes, newCluster.Spec.AllowedNodes) ||
+		!equality.Semantic.DeepEqual(oldCluster.Spec.AllowedNodesSelector, newCluster.Spec.AllowedNodesSelector)
+}
+
+// validateSchedulerHints rejects scheduler hints without allowed nodes, since the scheduler only
+// distributes VMs across the allowed nodes and would silently ignore them.
+func validateSchedulerHints(cluster *infrav1.ProxmoxCluster) error {
+	if cluster.Spec.SchedulerHints == nil || cluster.HasNodeSelection() {
+		return nil
+	}
+
+	return apierrors.NewInvalid(cluster.GroupVersionKind().GroupKind(), cluster.GetName(), field.ErrorList{
+		field.Forbidden(field.NewPath("spec", "schedulerHints"), "requires allowedNodes or allowedNodesSelector"),
+	})
+}
+
+// validateIPPoolOverlap rejects a cluster whose IP pools overlap with the IP pools of another ProxmoxCluster
+// in the management cluster, so that no address is assigned twice. Overlaps with InClusterIPPools of the namespace
+// and GlobalInClusterIPPools which are not managed by a ProxmoxCluster are reported as warnings.
+// On updates, only the addresses which were not in the pools of the old cluster are checked, so clusters which
+// overlap already, like clusters created concurrently, can still be updated.
+func (p *ProxmoxCluster) validateIPPoolOverlap(ctx context.Context, oldCluster, cluster *infrav1.ProxmoxCluster) (admission.Warnings, error) {
+	if p.Reader == nil {
+		return nil, nil
+	}
+
+	set, err := buildSetFromAddresses(clusterPoolAddresses(cluster))
+	if err != nil {
+		// invalid addresses are reported by validateIPs and validateNodeIPPools.
+		return nil, nil //nolint:nilerr
+	}
+	if oldCluster != nil {
+		if set, err = addedAddresses(set, clusterPoolAddresses(oldCluster)); err != nil {
+			return nil, apierrors.NewInternalError(errors.Wrap(err, "unable to compare the ip pools"))
+		}
+		if len(set.Ranges()) == 0 {
+			return nil, nil
+		}
+	}
+
+	clusters := &infrav1.ProxmoxClusterList{}
+	if err := p.Reader.List(ctx, clusters); err != nil {
+		return nil, apierrors.NewInternalError(errors.Wrap(err, "unable to list proxmox clusters"))
+	}
+
+	var overlapping []string
+	for i := range clusters.Items {
+		other := &clusters.Items[i]
+		if other.GetNamespace() == cluster.GetNamespace() && other.GetName() == cluster.GetName() {
+			continue
+		}
+		if overlaps(set, clusterPoolAddresses(other)) {
+			overlapping = append(overlapping, client.ObjectKeyFromObject(other).String())
+		}
+	}
+	if len(overlapping) > 0 {
+		return nil, apierrors.NewInvalid(
+			cluster.GroupVersionKind().GroupKind(),
+			cluster.GetName(),
+			field.ErrorList{
+				field.Forbidden(field.NewPath("spec"), fmt.Sprintf("ip addresses overlap with the ip pools of proxmox clusters %s", strings.Join(overlapping, ", "))),
+			})
+	}
+
+	var warnings admission.Warnings
+	pools := &ipamicv1.InClusterIPPoolList{}
+	if err := p.Reader.List(ctx, pools, client.InNamespace(cluster.GetNamespace())); err != nil {
+		return nil, apierrors.NewInternalError(errors.Wrap(err, "unable to list InClusterIPPools"))
+	}
+	for i := range pools.Items {
+		pool := &pools.Items[i]
+		if !isControlledByProxmoxCluster(pool) && overlaps(set, pool.Spec.Addresses) {
+			warnings = append(warnings, fmt.Sprintf("ip addresses of proxmox cluster %s overlap with InClusterIPPool %s", cluster.GetName(), pool.GetName()))
+		}
+	}
+
+	globalPools := &ipamicv1.GlobalInClusterIPPoolList{}
+	if err := p.Reader.List(ctx, globalPools); err != nil {
+		return nil, apierrors.NewInternalError(errors.Wrap(err, "unable to list GlobalInClusterIPPools"))
+	}
+	for i := range globalPools.Items {
+		pool := &globalPools.Items[i]
+		if !isControlledByProxmoxCluster(pool) && overlaps(set, pool.Spec.Addresses) {
+			warnings = append(warnings, fmt.Sprintf("ip addresses of proxmox cluster %s overlap with GlobalInClusterIPPool %s", cluster.GetName(), pool.GetName()))
+		}
+	}
+
+	return warnings, nil
+}
+
+// addedAddresses returns the addresses of the set which are not in the old addresses.
+// Invalid old addresses are ignored, as they were not assigned.
+func addedAddresses(set *netipx.IPSet, oldAddresses []string) (*netipx.IPSet, error) {
+	oldSet, err := buildSetFromAddresses(oldAddresses)
+	if err != nil {
+		return set, nil //nolint:nilerr
+	}
+
+	builder := netipx.IPSetBuilder{}
+	builder.AddSet(set)
+	builder.RemoveSet(oldSet)
+	return builder.IPSet()
+}
+
+// validateIPPoolChanges checks that changed IP pool configs keep the addresses which are allocated from their
+// InClusterIPPools, so pools can be grown in place, but not shrunk below their allocations. The gateway of a pool
+// with allocations cannot be changed, as the machines keep the gateway they were configured with.
+func (p *ProxmoxCluster) validateIPPoolChanges(ctx context.Context, oldCluster, newCluster *infrav1.ProxmoxCluster) error {
+	if p.Reader == nil {
+		return nil
+	}
+
+	type change struct {
+		path     *field.Path
+		pool     string
+		old, new *ipamicv1.InClusterIPPoolSpec
+	}
+	changes := []change{
+		{field.NewPath("spec", "ipv4Config"), ipam.InClusterPoolFormat(newCluster, infrav1.IPV4Format), oldCluster.Spec.IPv4Config, newCluster.Spec.IPv4Config},
+		{field.NewPath("spec", "ipv6Config"), ipam.InClusterPoolFormat(newCluster, infrav1.IPV6Format), oldCluster.Spec.IPv6Config, newCluster.Spec.IPv6Config},
+	}
+	for _, format := range []string{infrav1.IPV4Format, infrav1.IPV6Format} {
+		// the pools of additional configs are named by their index.
+		path := field.NewPath("spec", "additionalIPv4Configs")
+		if format == infrav1.IPV6Format {
+			path = field.NewPath("spec", "additionalIPv6Configs")
+		}
+		oldConfigs, newConfigs := oldCluster.AdditionalIPPoolConfigs(format), newCluster.AdditionalIPPoolConfigs(format)
+		for i := range oldConfigs {
+			var newConfig *ipamicv1.InClusterIPPoolSpec
+			if i < len(newConfigs) {
+				newConfig = &newConfigs[i]
+			}
+			changes = append(changes, change{path.Index(i), ipam.AdditionalInClusterPoolFormat(newCluster, format, i+1), &oldConfigs[i], newConfig})
+		}
+	}
+	for _, oldPool := range oldCluster.Spec.NodeIPPools {
+		path := field.NewPath("spec", "nodeIPPools").Key(oldPool.Name)
+		var newPool infrav1.NodeIPPool
+		for _, pool := range newCluster.Spec.NodeIPPools {
+			if pool.Name == oldPool.Name {
+				newPool = pool
+			}
+		}
+		for _, format := range []string{infrav1.IPV4Format, infrav1.IPV6Format} {
+			name := "ipv4Config"
+			if format == infrav1.IPV6Format {
+				name = "ipv6Config"
+			}
+			changes = append(changes, change{path.Child(name), ipam.NodeInClusterPoolFormat(newCluster, oldPool.Name, format), oldPool.Config(format), newPool.Config(format)})
+		}
+	}
+
+	var allocated map[string][]ipamv1.IPAddress
+	var errs field.ErrorList
+	for _, c := range changes {
+		if c.old == nil || equality.Semantic.DeepEqual(c.old, c.new) {
+			continue
+		}
+
+		if allocated == nil {
+			var err error
+			if allocated, err = p.allocatedAddresses(ctx, newCluster.GetNamespace()); err != nil {
+				return err
+			}
+		}
+		addresses := allocated[c.pool]
+		if len(addresses) == 0 {
+			continue
+		}
+
+		if c.new == nil {
+			errs = append(errs, field.Forbidden(c.path, fmt.Sprintf("cannot be removed while %d addresses are allocated from InClusterIPPool %s", len(addresses), c.pool)))
+			continue
+		}
+		if c.new.Gateway != c.old.Gateway {
+			errs = append(errs, field.Forbidden(c.path.Child("gateway"), fmt.Sprintf("cannot be changed while %d addresses are allocated from InClusterIPPool %s", len(addresses), c.pool)))
+		}
+		set, err := buildSetFromAddresses(c.new.Addresses)
+		if err != nil {
+			// reported by validateIPs and validateNodeIPPools.
+			continue
+		}
+		for _, address := range addresses {
+			if addr, err := netip.ParseAddr(address.Spec.Address); err == nil && !set.Contains(addr) {
+				errs = append(errs, field.Invalid(c.path.Child("addresses"), c.new.Addresses,
+					fmt.Sprintf("must contain the allocated address %s of IPAddressClaim %s", address.Spec.Address, address.Spec.ClaimRef.Name)))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(newCluster.GroupVersionKind().GroupKind(), newCluster.GetName(), errs)
+	}
+	return nil
+}
+
+// allocatedAddresses returns the IPAddresses of the namespace which are allocated from InClusterIPPools,
+// by the name of their pool.
+func (p *ProxmoxCluster) allocatedAddresses(ctx context.Context, namespace string) (map[string][]ipamv1.IPAddress, error) {
+	addresses := &ipamv1.IPAddressList{}
+	if err := p.Reader.List(ctx, addresses, client.InNamespace(namespace)); err != nil {
+		return nil, apierrors.NewInternalError(errors.Wrap(err, "unable to list IPAddresses"))
+	}
+
+	byPool := make(map[string][]ipamv1.IPAddress)
+	for _, address := range addresses.Items {
+		if ref := address.Spec.PoolRef; ref.Kind == "InClusterIPPool" {
+			byPool[ref.Name] = append(byPool[ref.Name], address)
+		}
+	}
+	return byPool, nil
+}
+
+// clusterPoolAddresses returns the addresses of the cluster, additional and node IP pools of a cluster.
+func clusterPoolAddresses(cluster *infrav1.ProxmoxCluster) []string {
+	var addresses []string
+	for _, config := range []*ipamicv1.InClusterIPPoolSpec{cluster.Spec.IPv4Config, cluster.Spec.IPv6Config} {
+		if config != nil {
+			addresses = append(addresses, config.Addresses...)
+		}
+	}
+	for _, additional := range [][]ipamicv1.InClusterIPPoolSpec{cluster.Spec.AdditionalIPv4Configs, cluster.Spec.AdditionalIPv6Configs} {
+		for _, config := range additional {
+			addresses = append(addresses, config.Addresses...)
+		}
+	}
+	for _, pool := range cluster.Spec.NodeIPPools {
+		for _, config := range []*ipamicv1.InClusterIPPoolSpec{pool.IPv4Config, pool.IPv6Config} {
+			if config != nil {
+				addresses = append(addresses, config.Addresses...)
+			}
+		}
+	}
+	return addresses
+}
+
+// overlaps returns whether the set shares addresses with the given addresses.
+// Invalid addresses are ignored, they are rejected when the object defining them is validated.
+func overlaps(set *netipx.IPSet, addresses []string) bool {
+	other, err := buildSetFromAddresses(addresses)
+	if err != nil {
+		return false
+	}
+	return set.Overlaps(other)
+}
+
+// isControlledByProxmoxCluster returns whether the pool was created for a ProxmoxCluster,
+// its addresses are validated as part of the cluster.
+func isControlledByProxmoxCluster(pool metav1.Object) bool {
+	owner := metav1.GetControllerOf(pool)
+	return owner != nil && owner.Kind == infrav1.ProxmoxClusterKind
+}
+
+// hasNoIPPoolConfig returns true if the machines get neither addresses of IP pools nor of DHCP.
 func hasNoIPPoolConfig(cluster *infrav1.ProxmoxCluster) bool {
-	return cluster.Spec.IPv4Config == nil && cluster.Spec.IPv6Config == nil
+	return cluster.Spec.IPv4Config == nil && cluster.Spec.IPv6Config == nil && cluster.Spec.DHCPMode == ""
 }
@@ -22,9 +22,12 @@ import (
 	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	ipamicv1 "sigs.k8s.io/cluster-api-ipam-provider-in-cluster/api/v1alpha2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -44,18 +47,143 @@ var _ = Describe("Controller Test", func() {
 			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("at least one ip config must be set")))
 		})
 
+		It("should allow cluster using DHCP without any IP pool config", func() {
+			cluster := validProxmoxCluster("test-cluster-dhcp")
+			cluster.Spec.IPv4Config = nil
+			cluster.Spec.DHCPMode = infrav1.DHCPModeIPv4
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(Succeed())
+			g.Expect(k8sClient.Delete(testEnv.GetContext(), &cluster)).To(Succeed())
+		})
+
 		It("should disallow invalid endpoint IP", func() {
 			cluster := invalidProxmoxCluster("test-cluster")
 			cluster.Spec.ControlPlaneEndpoint.Host = "invalid"
 			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("provided endpoint is not in a valid IP and port format")))
 		})
 
+		It("should disallow an invalid VM name template in the machine defaults", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.MachineDefaults = &infrav1.MachineDefaults{VMNameTemplate: ptr.To("{{.ClusterName}}_{{.Role}}")}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("must be a DNS name")))
+		})
+
 		It("should disallow invalid IPV4 IPs", func() {
 			cluster := invalidProxmoxCluster("test-cluster")
 			cluster.Spec.IPv4Config.Addresses = []string{"invalid"}
 			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("provided addresses are not valid IP addresses, ranges or CIDRs")))
 		})
 
+		It("should disallow a node in multiple node IP pools", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.NodeIPPools = []infrav1.NodeIPPool{
+				{Name: "rack1", Nodes: []string{"pve1"}, IPv4Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"10.10.11.2-10.10.11.10"}, Prefix: 24, Gateway: "10.10.11.1"}},
+				{Name: "rack2", Nodes: []string{"pve2", "pve1"}, IPv4Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"10.10.12.2-10.10.12.10"}, Prefix: 24, Gateway: "10.10.12.1"}},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("node is already in node IP pool rack1")))
+		})
+
+		It("should disallow node IP pools with an address family the cluster does not define", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.NodeIPPools = []infrav1.NodeIPPool{
+				{Name: "rack1", Nodes: []string{"pve1"}, IPv6Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"2001:db8::/64"}, Prefix: 64, Gateway: "2001:db8::1"}},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("requires the ipv6Config of the cluster")))
+		})
+
+		It("should disallow node IP pools overlapping with the IP pool of the cluster", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.NodeIPPools = []infrav1.NodeIPPool{
+				{Name: "rack1", Nodes: []string{"pve1"}, IPv4Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"10.10.10.8-10.10.10.20"}, Prefix: 24, Gateway: "10.10.10.1"}},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("addresses overlap with spec.ipv4Config")))
+		})
+
+		It("should disallow additional IP pools without the config of their address family", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.AdditionalIPv6Configs = []ipamicv1.InClusterIPPoolSpec{{Addresses: []string{"2001:db8::/64"}, Prefix: 64, Gateway: "2001:db8::1"}}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("requires the ipv6Config of the cluster")))
+		})
+
+		It("should disallow additional IP pools overlapping with the IP pool of the cluster", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.AdditionalIPv4Configs = []ipamicv1.InClusterIPPoolSpec{
+				{Addresses: []string{"10.10.20.2-10.10.20.100"}, Prefix: 24, Gateway: "10.10.20.1"},
+				{Addresses: []string{"10.10.10.8-10.10.10.20"}, Prefix: 24, Gateway: "10.10.10.1"},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("spec.additionalIPv4Configs[1].addresses: Invalid value: []string{\"10.10.10.8-10.10.10.20\"}: addresses overlap with spec.ipv4Config")))
+		})
+
+		It("should disallow IPv6 addresses in the IPv4 config", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.IPv4Config.Addresses = []string{"10.10.10.2-10.10.10.10", "2001:db8::/64"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("must be an IPv4 address, range or CIDR")))
+		})
+
+		It("should disallow a gateway of another address family", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.IPv6Config = &ipamicv1.InClusterIPPoolSpec{
+				Addresses: []string{"2001:db8::/64"},
+				Prefix:    64,
+				Gateway:   "10.10.10.1",
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("must be an IPv6 address")))
+		})
+
+		It("should disallow a gateway outside of the network of the addresses", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.IPv4Config.Gateway = "10.10.1.1"
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("10.10.10.2-10.10.10.10 is not in 10.10.1.0/24")))
+
+			cluster.Spec.IPv4Config.Gateway = "10.10.10.1"
+			cluster.Spec.IPv4Config.Addresses = []string{"10.10.0.0/16"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("10.10.0.0/16 is not in 10.10.10.0/24")))
+		})
+
+		It("should allow a link-local IPv6 gateway", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.IPv6Config = &ipamicv1.InClusterIPPoolSpec{
+				Addresses: []string{"2001:db8::10-2001:db8::20"},
+				Prefix:    64,
+				Gateway:   "fe80::1",
+			}
+			warnings, err := (&ProxmoxCluster{Reader: k8sClient}).ValidateCreate(testEnv.GetContext(), &cluster)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(warnings).To(BeEmpty())
+		})
+
+		It("should disallow IPv4-mapped and zoned IPv6 addresses", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.IPv6Config = &ipamicv1.InClusterIPPoolSpec{
+				Addresses: []string{"::ffff:10.10.20.1", "fe80::10%eth0"},
+				Prefix:    64,
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(And(
+				MatchError(ContainSubstring("must not be an IPv4-mapped IPv6 address")),
+				MatchError(ContainSubstring("must not have a zone"))))
+		})
+
+		It("should warn about host prefixes", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.IPv4Config.Prefix = 32
+			cluster.Spec.IPv4Config.Gateway = "172.16.0.1"
+			warnings, err := (&ProxmoxCluster{Reader: k8sClient}).ValidateCreate(testEnv.GetContext(), &cluster)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(warnings).To(ContainElement(ContainSubstring("spec.ipv4Config: prefix /32 makes every address a network of its own")))
+		})
+
+		It("should disallow DNS servers which are not IP addresses", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.DNSServers = []string{"8.8.8.8", "dns.example.com"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("spec.dnsServers[1]")))
+		})
+
+		It("should disallow scheduler hints without allowed nodes", func() {
+			cluster := validProxmoxCluster("test-cluster")
+			cluster.Spec.AllowedNodes = nil
+			cluster.Spec.SchedulerHints = &infrav1.SchedulerHints{}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("requires allowedNodes or allowedNodesSelector")))
+		})
+
 		It("should disallow invalid IPV6 IPs", func() {
 			cluster := validProxmoxCluster("test-cluster")
 			cluster.Spec.IPv6Config = &ipamicv1.InClusterIPPoolSpec{
@@ -78,7 +206,171 @@ var _ = Describe("Controller Test", func() {
 		})
 	})
 
+	Context("ip pool overlap", func() {
+		It("should disallow IP pools overlapping with the IP pools of another cluster", func() {
+			existing := validProxmoxCluster("test-cluster-existing")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &existing)).To(Succeed())
+			DeferCleanup(func() {
+				g.Expect(client.IgnoreNotFound(k8sClient.Delete(testEnv.GetContext(), &existing))).To(Succeed())
+			})
+
+			cluster := validProxmoxCluster("test-cluster-overlap")
+			cluster.Spec.ControlPlaneEndpoint.Host = "10.10.11.1"
+			cluster.Spec.IPv4Config.Addresses = []string{"10.10.10.8-10.10.10.20"}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("ip addresses overlap with the ip pools of proxmox clusters default/test-cluster-existing")))
+
+			cluster.Spec.IPv4Config.Addresses = []string{"10.10.11.2-10.10.11.10"}
+			cluster.Spec.IPv4Config.Gateway = "10.10.11.1"
+			cluster.Spec.NodeIPPools = []infrav1.NodeIPPool{
+				{Name: "rack1", Nodes: []string{"pve1"}, IPv4Config: &ipamicv1.InClusterIPPoolSpec{Addresses: []string{"10.10.10.0/28"}, Prefix: 24, Gateway: "10.10.10.1"}},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &cluster)).To(MatchError(ContainSubstring("ip addresses overlap with the ip pools of proxmox clusters default/test-cluster-existing")))
+		})
+
+		It("should only disallow updates adding addresses which overlap with another cluster", func() {
+			existing := validProxmoxCluster("test-cluster-existing")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &existing)).To(Succeed())
+			DeferCleanup(func() {
+				g.Expect(client.IgnoreNotFound(k8sClient.Delete(testEnv.GetContext(), &existing))).To(Succeed())
+			})
+			webhook := &ProxmoxCluster{Reader: k8sClient}
+
+			// the cluster overlaps already, e.g. since both were created concurrently.
+			cluster := validProxmoxCluster("test-cluster-overlap")
+			cluster.Spec.ControlPlaneEndpoint.Host = "10.10.10.30"
+			cluster.Spec.IPv4Config.Addresses = []string{"10.10.10.8-10.10.10.20"}
+			cluster.Finalizers = []string{infrav1.ClusterFinalizer}
+
+			unfinalized := cluster.DeepCopy()
+			unfinalized.Finalizers = nil
+			_, err := webhook.ValidateUpdate(testEnv.GetContext(), &cluster, unfinalized)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			grown := cluster.DeepCopy()
+			grown.Spec.IPv4Config.Addresses = []string{"10.10.10.2-10.10.10.20"}
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, grown)
+			g.Expect(err).To(MatchError(ContainSubstring("ip addresses overlap with the ip pools of proxmox clusters default/test-cluster-existing")))
+
+			grown.DeletionTimestamp = ptr.To(metav1.Now())
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, grown)
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should warn about IP pools overlapping with an InClusterIPPool", func() {
+			pool := ipamicv1.InClusterIPPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pool",
+					Namespace: metav1.NamespaceDefault,
+				},
+				Spec: ipamicv1.InClusterIPPoolSpec{
+					Addresses: []string{"10.10.10.10-10.10.10.20"},
+					Prefix:    24,
+					Gateway:   "10.10.10.1",
+				},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &pool)).To(Succeed())
+			DeferCleanup(func() {
+				g.Expect(client.IgnoreNotFound(k8sClient.Delete(testEnv.GetContext(), &pool))).To(Succeed())
+			})
+
+			cluster := validProxmoxCluster("test-cluster-pool-overlap")
+			webhook := &ProxmoxCluster{Reader: k8sClient}
+			warnings, err := webhook.ValidateCreate(testEnv.GetContext(), &cluster)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(warnings).To(ContainElement(ContainSubstring("overlap with InClusterIPPool test-pool")))
+		})
+	})
+
 	Context("update proxmox cluster", func() {
+		It("should allow growing IP pools with allocated addresses, but not shrinking them", func() {
+			cluster := validProxmoxCluster("test-cluster-grow")
+			address := ipamv1.IPAddress{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cluster-grow-0", Namespace: metav1.NamespaceDefault},
+				Spec: ipamv1.IPAddressSpec{
+					ClaimRef: corev1.LocalObjectReference{Name: "test-cluster-grow-0-net0-inet"},
+					PoolRef:  corev1.TypedLocalObjectReference{APIGroup: ptr.To(ipamicv1.GroupVersion.Group), Kind: "InClusterIPPool", Name: "test-cluster-grow-v4-icip"},
+					Address:  "10.10.10.5",
+					Prefix:   24,
+					Gateway:  "10.10.10.1",
+				},
+			}
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &address)).To(Succeed())
+			DeferCleanup(func() {
+				g.Expect(client.IgnoreNotFound(k8sClient.Delete(testEnv.GetContext(), &address))).To(Succeed())
+			})
+			webhook := &ProxmoxCluster{Reader: k8sClient}
+
+			grown := cluster.DeepCopy()
+			grown.Spec.IPv4Config.Addresses = append(grown.Spec.IPv4Config.Addresses, "10.10.10.20-10.10.10.30")
+			_, err := webhook.ValidateUpdate(testEnv.GetContext(), &cluster, grown)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			shrunk := cluster.DeepCopy()
+			shrunk.Spec.IPv4Config.Addresses = []string{"10.10.10.6-10.10.10.10"}
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, shrunk)
+			g.Expect(err).To(MatchError(ContainSubstring("must contain the allocated address 10.10.10.5 of IPAddressClaim test-cluster-grow-0-net0-inet")))
+
+			regateway := cluster.DeepCopy()
+			regateway.Spec.IPv4Config.Gateway = "10.10.10.254"
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, regateway)
+			g.Expect(err).To(MatchError(ContainSubstring("cannot be changed while 1 addresses are allocated from InClusterIPPool test-cluster-grow-v4-icip")))
+		})
+
+		It("should only check the IP pool configs which changed", func() {
+			// the gateway is outside of the network, which was accepted before the check was introduced.
+			cluster := validProxmoxCluster("test-cluster-legacy")
+			cluster.Spec.IPv4Config.Gateway = "10.10.20.1"
+			cluster.Finalizers = []string{infrav1.ClusterFinalizer}
+			webhook := &ProxmoxCluster{}
+
+			unfinalized := cluster.DeepCopy()
+			unfinalized.Finalizers = nil
+			_, err := webhook.ValidateUpdate(testEnv.GetContext(), &cluster, unfinalized)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			added := cluster.DeepCopy()
+			added.Spec.AdditionalIPv4Configs = []ipamicv1.InClusterIPPoolSpec{{Addresses: []string{"10.10.30.2-10.10.30.10"}, Prefix: 24, Gateway: "10.10.31.1"}}
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, added)
+			g.Expect(err).To(MatchError(ContainSubstring("spec.additionalIPv4Configs[0].gateway")))
+			g.Expect(err).ToNot(MatchError(ContainSubstring("spec.ipv4Config.gateway")))
+
+			changed := cluster.DeepCopy()
+			changed.Spec.IPv4Config.Addresses = []string{"10.10.10.2-10.10.10.20"}
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, changed)
+			g.Expect(err).To(MatchError(ContainSubstring("spec.ipv4Config.gateway")))
+
+			changed.DeletionTimestamp = ptr.To(metav1.Now())
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, changed)
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should only check the DNS servers and scheduler hints if they changed", func() {
+			cluster := validProxmoxCluster("test-cluster-legacy")
+			cluster.Spec.DNSServers = []string{"dns.example.com"}
+			cluster.Spec.SchedulerHints = &infrav1.SchedulerHints{}
+			cluster.Finalizers = []string{infrav1.ClusterFinalizer}
+			webhook := &ProxmoxCluster{}
+
+			unfinalized := cluster.DeepCopy()
+			unfinalized.Finalizers = nil
+			_, err := webhook.ValidateUpdate(testEnv.GetContext(), &cluster, unfinalized)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			dns := cluster.DeepCopy()
+			dns.Spec.DNSServers = append(dns.Spec.DNSServers, "8.8.8.8")
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, dns)
+			g.Expect(err).To(MatchError(ContainSubstring("spec.dnsServers[0]")))
+
+			hints := cluster.DeepCopy()
+			hints.Spec.SchedulerHints.MemoryAccounting = infrav1.MemoryAccountingUsage
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, hints)
+			g.Expect(err).To(MatchError(ContainSubstring("requires allowedNodes or allowedNodesSelector")))
+
+			hints.DeletionTimestamp = ptr.To(metav1.Now())
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &cluster, hints)
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+
 		It("should disallow new endpoint IP to intersect with node IPs", func() {
 			clusterName := "test-cluster"
 			cluster := validProxmoxCluster(clusterName)
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/vmname"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+var _ admission.CustomValidator = &ProxmoxMachine{}
+
+// ProxmoxMachine is a type that implements
+// the interfaces from the admission package.
+type ProxmoxMachine struct {
+	// Reader is used to look up the cluster of new machines.
+	// It defaults to the API reader of the manager.
+	Reader client.Reader
+	// ProxmoxClient is used to warn about new machines which do not fit on the eligible nodes.
+	// The check is skipped if it is not set.
+	ProxmoxClient proxmox.Client
+}
+
+// SetupWebhookWithManager sets up the webhook with the
+// custom interfaces.
+func (p *ProxmoxMachine) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if p.Reader == nil {
+		p.Reader = mgr.GetAPIReader()
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&infrav1.ProxmoxMachine{}).
+		WithValidator(p).
+		Complete()
+}
+
+//+kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha1-proxmoxmachine,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachines,versions=v1alpha1,name=validation.proxmoxmachine.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+// ValidateCreate implements the creation validation function.
+// It warns if the machine does not fit on the eligible nodes of its cluster.
+func (p *ProxmoxMachine) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	machine, ok := obj.(*infrav1.ProxmoxMachine)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got %T", obj))
+	}
+
+	errs := validateMachineVMName(machine)
+	errs = append(errs, validateMemoryHotplug(field.NewPath("spec"), &machine.Spec)...)
+	if len(errs) > 0 {
+		return nil, apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), errs)
+	}
+
+	_, controlPlane := machine.GetLabels()[clusterv1.MachineControlPlaneLabel]
+	checker := capacityChecker{reader: p.Reader, proxmoxClient: p.ProxmoxClient}
+	return checker.warnings(ctx, machine, &machine.Spec, controlPlane, 1), nil
+}
+
+// ValidateDelete implements the deletion validation function.
+func (*ProxmoxMachine) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateUpdate implements the update validation function.
+// Only the VM name and memory of machines whose name, template or memory changed are checked, and machines
+// being deleted are not checked at all, so machines created before a check was introduced can drop their finalizers.
+func (*ProxmoxMachine) ValidateUpdate(_ context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	machine, ok := newObj.(*infrav1.ProxmoxMachine)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got %T", newObj))
+	}
+	oldMachine, ok := oldObj.(*infrav1.ProxmoxMachine)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachine but got %T", oldObj))
+	}
+	if !machine.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	var errs field.ErrorList
+	if machine.GetName() != oldMachine.GetName() || !equality.Semantic.DeepEqual(machine.Spec.VMNameTemplate, oldMachine.Spec.VMNameTemplate) {
+		errs = append(errs, validateMachineVMName(machine)...)
+	}
+	if machine.Spec.MemoryHotplug != oldMachine.Spec.MemoryHotplug || machine.Spec.MemoryMiB != oldMachine.Spec.MemoryMiB {
+		errs = append(errs, validateMemoryHotplug(field.NewPath("spec"), &machine.Spec)...)
+	}
+	if len(errs) > 0 {
+		return nil, apierrors.NewInvalid(machine.GroupVersionKind().GroupKind(), machine.GetName(), errs)
+	}
+
+	return nil, nil
+}
+
+// validateMachineVMName checks the name of the VM of the machine, so invalid names do not only fail when cloning.
+// The cluster name and role are taken from the labels, which Cluster API sets on the machines it creates.
+func validateMachineVMName(machine *infrav1.ProxmoxMachine) field.ErrorList {
+	role := vmname.RoleWorker
+	if _, ok := machine.GetLabels()[clusterv1.MachineControlPlaneLabel]; ok {
+		role = vmname.RoleControlPlane
+	}
+
+	path := field.NewPath("metadata", "name")
+	if machine.Spec.VMNameTemplate != nil && *machine.Spec.VMNameTemplate != "" {
+		path = field.NewPath("spec", "vmNameTemplate")
+	}
+
+	return validateVMName(path, machine.GetName(), machine.Spec.VMNameTemplate, vmname.Data{
+		ClusterName: machine.GetLabels()[clusterv1.ClusterNameLabel],
+		MachineName: machine.GetName(),
+		Namespace:   machine.GetNamespace(),
+		Role:        role,
+		Random:      vmname.Random(machine.GetUID()),
+	})
+}
+
+// validateMemoryHotplug checks that the memory of a machine with memory hotplug can be plugged in DIMMs,
+// which Proxmox only reports when the VM is configured.
+func validateMemoryHotplug(path *field.Path, spec *infrav1.ProxmoxMachineSpec) field.ErrorList {
+	if !spec.MemoryHotplug || spec.MemoryMiB == 0 {
+		return nil
+	}
+
+	if err := proxmox.ValidateHotplugMemory(int64(spec.MemoryMiB)); err != nil {
+		return field.ErrorList{field.Invalid(path.Child("memoryMiB"), spec.MemoryMiB, err.Error())}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+var _ = Describe("ProxmoxMachine Test", func() {
+	g := NewWithT(GinkgoT())
+
+	Context("create proxmox machine", func() {
+		It("should allow a valid VM name template", func() {
+			machine := validProxmoxMachine("test-machine-vm-name")
+			machine.Spec.VMNameTemplate = ptr.To("{{.ClusterName}}-{{.Role}}-{{.Random}}")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(Succeed())
+			g.Expect(k8sClient.Delete(testEnv.GetContext(), &machine)).To(Succeed())
+		})
+
+		It("should disallow a VM name template rendering an invalid name", func() {
+			machine := validProxmoxMachine("test-machine-invalid-vm-name")
+			machine.Spec.VMNameTemplate = ptr.To("{{.ClusterName}}_{{.Role}}")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("must be a DNS name")))
+		})
+
+		It("should disallow a name which is too long for a VM", func() {
+			machine := validProxmoxMachine(strings.Repeat("a", 64))
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("no more than 63 characters")))
+		})
+
+		It("should disallow memory which cannot be hotplugged", func() {
+			machine := validProxmoxMachine("test-machine-memory-hotplug")
+			machine.Spec.MemoryHotplug = true
+			machine.Spec.MemoryMiB = 1800
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(MatchError(ContainSubstring("use 1536MiB or 2048MiB")))
+
+			machine.Spec.MemoryMiB = 2048
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &machine)).To(Succeed())
+			g.Expect(k8sClient.Delete(testEnv.GetContext(), &machine)).To(Succeed())
+		})
+	})
+
+	Context("update proxmox machine", func() {
+		It("should only check the VM name if the template changed", func() {
+			// the name was accepted before the check was introduced.
+			machine := validProxmoxMachine(strings.Repeat("a", 64))
+			machine.Finalizers = []string{infrav1.MachineFinalizer}
+			webhook := &ProxmoxMachine{}
+
+			unfinalized := machine.DeepCopy()
+			unfinalized.Finalizers = nil
+			_, err := webhook.ValidateUpdate(testEnv.GetContext(), &machine, unfinalized)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			templated := machine.DeepCopy()
+			templated.Spec.VMNameTemplate = ptr.To("{{.ClusterName}}_{{.Role}}")
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &machine, templated)
+			g.Expect(err).To(MatchError(ContainSubstring("must be a DNS name")))
+
+			templated.DeletionTimestamp = ptr.To(metav1.Now())
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &machine, templated)
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should only check the memory if it changed", func() {
+			machine := validProxmoxMachine("test-machine-memory-legacy")
+			machine.Spec.MemoryHotplug = true
+			machine.Spec.MemoryMiB = 1800
+			machine.Finalizers = []string{infrav1.MachineFinalizer}
+			webhook := &ProxmoxMachine{}
+
+			unfinalized := machine.DeepCopy()
+			unfinalized.Finalizers = nil
+			_, err := webhook.ValidateUpdate(testEnv.GetContext(), &machine, unfinalized)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			resized := machine.DeepCopy()
+			resized.Spec.MemoryMiB = 1900
+			_, err = webhook.ValidateUpdate(testEnv.GetContext(), &machine, resized)
+			g.Expect(err).To(MatchError(ContainSubstring("spec.memoryMiB")))
+		})
+	})
+})
+
+func validProxmoxMachine(name string) infrav1.ProxmoxMachine {
+	return infrav1.ProxmoxMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: "test-cluster"},
+		},
+		Spec: infrav1.ProxmoxMachineSpec{
+			VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+				SourceNode: "pve1",
+				TemplateID: ptr.To[int32](100),
+			},
+		},
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/cluster-api/util/topology"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+var _ admission.CustomValidator = &ProxmoxMachineTemplate{}
+
+// ProxmoxMachineTemplate is a type that implements
+// the interfaces from the admission package.
+type ProxmoxMachineTemplate struct {
+	// Reader is used to look up the cluster and the MachineDeployments of new templates.
+	// It defaults to the API reader of the manager.
+	Reader client.Reader
+	// ProxmoxClient is used to warn about the machines of new templates which do not fit on the eligible nodes.
+	// The check is skipped if it is not set.
+	ProxmoxClient proxmox.Client
+}
+
+// SetupWebhookWithManager sets up the webhook with the
+// custom interfaces.
+func (p *ProxmoxMachineTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if p.Reader == nil {
+		p.Reader = mgr.GetAPIReader()
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&infrav1.ProxmoxMachineTemplate{}).
+		WithValidator(p).
+		Complete()
+}
+
+//+kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha1-proxmoxmachinetemplate,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=proxmoxmachinetemplates,versions=v1alpha1,name=validation.proxmoxmachinetemplate.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+// ValidateCreate implements the creation validation function.
+// It warns if the replicas of the MachineDeployments using the template, or at least one machine,
+// do not fit on the eligible nodes of its cluster.
+func (p *ProxmoxMachineTemplate) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	template, ok := obj.(*infrav1.ProxmoxMachineTemplate)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got %T", obj))
+	}
+
+	if errs := validateMachineTemplateSpec(template); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(template.GroupVersionKind().GroupKind(), template.GetName(), errs)
+	}
+
+	checker := capacityChecker{reader: p.Reader, proxmoxClient: p.ProxmoxClient}
+	replicas := checker.machineDeploymentReplicas(ctx, template)
+	if replicas < 1 {
+		replicas = 1
+	}
+	return checker.warnings(ctx, template, &template.Spec.Template.Spec, false, replicas), nil
+}
+
+// ValidateDelete implements the deletion validation function.
+func (*ProxmoxMachineTemplate) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateUpdate implements the update validation function.
+// The spec of a template is immutable, as changing it would silently diverge from the machines
+// created from it. Changes require a new template and rotating the references to it.
+func (*ProxmoxMachineTemplate) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	oldTemplate, ok := oldObj.(*infrav1.ProxmoxMachineTemplate)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got %T", oldObj))
+	}
+	newTemplate, ok := newObj.(*infrav1.ProxmoxMachineTemplate)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a ProxmoxMachineTemplate but got %T", newObj))
+	}
+
+	errs := validateMachineTemplateSpec(newTemplate)
+
+	// the topology controller dry-runs changes to templates to detect whether they need rotating.
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected an admission.Request inside context: %v", err))
+	}
+	if !topology.ShouldSkipImmutabilityChecks(req, newTemplate) && !reflect.DeepEqual(oldTemplate.Spec.Template.Spec, newTemplate.Spec.Template.Spec) {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "template", "spec"),
+			"ProxmoxMachineTemplate spec is immutable, create a new template and update the references to it instead"))
+	}
+
+	if len(errs) > 0 {
+		return nil, apierrors.NewInvalid(newTemplate.GroupVersionKind().GroupKind(), newTemplate.GetName(), errs)
+	}
+
+	return nil, nil
+}
+
+// validateMachineTemplateSpec checks that the template does not contain fields which identify a single machine,
+// and that the VM name template renders names which Proxmox accepts.
+func validateMachineTemplateSpec(template *infrav1.ProxmoxMachineTemplate) field.ErrorList {
+	var errs field.ErrorList
+	spec := template.Spec.Template.Spec
+	path := field.NewPath("spec", "template", "spec")
+
+	if spec.ProviderID != nil {
+		errs = append(errs, field.Forbidden(path.Child("providerID"), "cannot be set in a template"))
+	}
+	if spec.VirtualMachineID != nil {
+		errs = append(errs, field.Forbidden(path.Child("virtualMachineID"), "cannot be set in a template"))
+	}
+	if spec.VMNameTemplate != nil && *spec.VMNameTemplate != "" {
+		errs = append(errs, validateVMName(path.Child("vmNameTemplate"), "", spec.VMNameTemplate, exampleVMNameData)...)
+	}
+	errs = append(errs, validateMemoryHotplug(path, &spec)...)
+
+	return errs
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+var _ = Describe("ProxmoxMachineTemplate Test", func() {
+	g := NewWithT(GinkgoT())
+
+	Context("create proxmox machine template", func() {
+		It("should disallow a provider ID", func() {
+			template := validProxmoxMachineTemplate("test-template-provider-id")
+			template.Spec.Template.Spec.ProviderID = ptr.To("proxmox://abc")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &template)).To(MatchError(ContainSubstring("cannot be set in a template")))
+		})
+
+		It("should disallow a virtual machine ID", func() {
+			template := validProxmoxMachineTemplate("test-template-vmid")
+			template.Spec.Template.Spec.VirtualMachineID = ptr.To[int64](100)
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &template)).To(MatchError(ContainSubstring("cannot be set in a template")))
+		})
+
+		It("should disallow an invalid VM name template", func() {
+			template := validProxmoxMachineTemplate("test-template-vm-name")
+			template.Spec.Template.Spec.VMNameTemplate = ptr.To("{{.ClusterName")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &template)).To(MatchError(ContainSubstring("invalid VM name template")))
+		})
+	})
+
+	Context("update proxmox machine template", func() {
+		It("should disallow changes of the spec", func() {
+			template := validProxmoxMachineTemplate("test-template")
+			g.Expect(k8sClient.Create(testEnv.GetContext(), &template)).To(Succeed())
+
+			g.Expect(k8sClient.Get(testEnv.GetContext(), client.ObjectKeyFromObject(&template), &template)).To(Succeed())
+			template.Spec.Template.Spec.NumCores = 4
+
+			g.Expect(k8sClient.Update(testEnv.GetContext(), &template)).To(MatchError(ContainSubstring("ProxmoxMachineTemplate spec is immutable")))
+
+			g.Eventually(func(g Gomega) {
+				g.Expect(client.IgnoreNotFound(k8sClient.Delete(testEnv.GetContext(), &template))).To(Succeed())
+			}).WithTimeout(time.Second * 10).
+				WithPolling(time.Second).
+				Should(Succeed())
+		})
+	})
+})
+
+func validProxmoxMachineTemplate(name string) infrav1.ProxmoxMachineTemplate {
+	return infrav1.ProxmoxMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: infrav1.ProxmoxMachineTemplateSpec{
+			Template: infrav1.ProxmoxMachineTemplateResource{
+				Spec: infrav1.ProxmoxMachineSpec{
+					VirtualMachineCloneSpec: infrav1.VirtualMachineCloneSpec{
+						SourceNode: "pve1",
+						TemplateID: ptr.To[int32](100),
+					},
+					NumCores: 2,
+				},
+			},
+		},
+	}
+}
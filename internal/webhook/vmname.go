@@ -0,0 +1,52 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/internal/vmname"
+)
+
+// exampleVMNameData is used to check VM name templates before the machines they name exist.
+var exampleVMNameData = vmname.Data{
+	ClusterName: "cluster",
+	MachineName: "machine",
+	Namespace:   "default",
+	Role:        vmname.RoleControlPlane,
+	Random:      vmname.Random(""),
+}
+
+// validateVMName checks that the VM name rendered from the template, or the name itself
+// if there is no template, is accepted by Proxmox.
+func validateVMName(path *field.Path, name string, nameTemplate *string, data vmname.Data) field.ErrorList {
+	if nameTemplate != nil && *nameTemplate != "" {
+		rendered, err := vmname.Render(*nameTemplate, data)
+		if err != nil {
+			return field.ErrorList{field.Invalid(path, *nameTemplate, err.Error())}
+		}
+		if err := vmname.Validate(rendered); err != nil {
+			return field.ErrorList{field.Invalid(path, *nameTemplate, err.Error())}
+		}
+		return nil
+	}
+
+	if err := vmname.Validate(name); err != nil {
+		return field.ErrorList{field.Invalid(path, name, err.Error())}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// ConfigDriveMetadata provides functionality to render the meta_data.json of an OpenStack config drive.
+type ConfigDriveMetadata struct {
+	data BaseCloudInitData
+}
+
+// NewConfigDriveMetadata returns a new ConfigDriveMetadata object.
+func NewConfigDriveMetadata(instanceID, hostname string) *ConfigDriveMetadata {
+	return &ConfigDriveMetadata{data: BaseCloudInitData{
+		Hostname:   hostname,
+		InstanceID: instanceID,
+	}}
+}
+
+// Render returns rendered meta_data.json.
+func (r *ConfigDriveMetadata) Render() ([]byte, error) {
+	if err := (&Metadata{data: r.data}).validate(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]string{
+		"uuid":     r.data.InstanceID,
+		"hostname": r.data.Hostname,
+		"name":     r.data.Hostname,
+	})
+}
+
+// ConfigDriveNetworkData provides functionality to render the network_data.json of an OpenStack config drive.
+type ConfigDriveNetworkData struct {
+	data BaseCloudInitData
+}
+
+// NewConfigDriveNetworkData returns a new ConfigDriveNetworkData object.
+func NewConfigDriveNetworkData(configs []NetworkConfigData) *ConfigDriveNetworkData {
+	return &ConfigDriveNetworkData{data: BaseCloudInitData{
+		NetworkConfigData: configs,
+	}}
+}
+
+type networkDataLink struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	MacAddress string `json:"ethernet_mac_address"`
+	MTU        int32  `json:"mtu,omitempty"`
+}
+
+type networkDataRoute struct {
+	Network string `json:"network"`
+	Netmask string `json:"netmask"`
+	Gateway string `json:"gateway"`
+}
+
+type networkDataNetwork struct {
+	ID        string             `json:"id"`
+	Type      string             `json:"type"`
+	Link      string             `json:"link"`
+	IPAddress string             `json:"ip_address,omitempty"`
+	Netmask   string             `json:"netmask,omitempty"`
+	Routes    []networkDataRoute `json:"routes,omitempty"`
+}
+
+type networkDataService struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+}
+
+type networkData struct {
+	Links    []networkDataLink    `json:"links"`
+	Networks []networkDataNetwork `json:"networks"`
+	Services []networkDataService `json:"services,omitempty"`
+}
+
+// Render returns rendered network_data.json.
+func (r *ConfigDriveNetworkData) Render() ([]byte, error) {
+	if err := (&NetworkConfig{data: r.data}).validate(); err != nil {
+		return nil, err
+	}
+
+	var data networkData
+	dnsServers := make(map[string]struct{})
+	for i, config := range r.data.NetworkConfigData {
+		link := fmt.Sprintf("eth%d", i)
+		data.Links = append(data.Links, networkDataLink{ID: link, Type: "phy", MacAddress: config.MacAddress, MTU: config.MTU})
+
+		for _, address := range []struct{ prefix, gateway, networkType, defaultNetwork string }{
+			{config.IPAddress, config.Gateway, "ipv4", "0.0.0.0"},
+			{config.IPV6Address, config.Gateway6, "ipv6", "::"},
+		} {
+			if address.prefix == "" {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(address.prefix)
+			if err != nil {
+				return nil, ErrMalformedIPAddress
+			}
+
+			network := networkDataNetwork{
+				ID:        fmt.Sprintf("network%d", len(data.Networks)),
+				Type:      address.networkType,
+				Link:      link,
+				IPAddress: prefix.Addr().String(),
+				Netmask:   net.IP(net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen())).String(),
+			}
+			if address.gateway != "" {
+				network.Routes = []networkDataRoute{{Network: address.defaultNetwork, Netmask: address.defaultNetwork, Gateway: address.gateway}}
+			}
+			data.Networks = append(data.Networks, network)
+		}
+
+		if config.DHCP4 {
+			data.Networks = append(data.Networks, networkDataNetwork{ID: fmt.Sprintf("network%d", len(data.Networks)), Type: "ipv4_dhcp", Link: link})
+		}
+		if config.DHCP6 {
+			data.Networks = append(data.Networks, networkDataNetwork{ID: fmt.Sprintf("network%d", len(data.Networks)), Type: "ipv6_dhcp", Link: link})
+		}
+
+		for _, server := range config.Nameservers() {
+			if _, ok := dnsServers[server]; !ok {
+				dnsServers[server] = struct{}{}
+				data.Services = append(data.Services, networkDataService{Type: "dns", Address: server})
+			}
+		}
+	}
+
+	return json.Marshal(data)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigDriveMetadata_Render(t *testing.T) {
+	metadata, err := NewConfigDriveMetadata("9a82e2ca-4294-11ee-be56-0242ac120002", "proxmox-control-plane").Render()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"uuid":"9a82e2ca-4294-11ee-be56-0242ac120002","hostname":"proxmox-control-plane","name":"proxmox-control-plane"}`, string(metadata))
+
+	_, err = NewConfigDriveMetadata("", "proxmox-control-plane").Render()
+	require.ErrorIs(t, err, ErrMissingInstanceID)
+}
+
+func TestConfigDriveNetworkData_Render(t *testing.T) {
+	type want struct {
+		network string
+		err     error
+	}
+
+	cases := map[string]struct {
+		configs []NetworkConfigData
+		want    want
+	}{
+		"dual stack with two devices": {
+			configs: []NetworkConfigData{
+				{
+					MacAddress:  "92:60:a0:5b:22:c2",
+					IPAddress:   "10.10.10.12/24",
+					IPV6Address: "2001:db8::1/64",
+					Gateway:     "10.10.10.1",
+					Gateway6:    "2001:db8::ffff",
+					DNSServers:  []string{"8.8.8.8", "8.8.4.4"},
+				},
+				{
+					MacAddress: "b4:87:18:bf:a3:60",
+					IPAddress:  "196.168.100.124/16",
+					Gateway:    "196.168.1.1",
+					DNSServers: []string{"8.8.8.8"},
+				},
+			},
+			want: want{
+				network: `{
+  "links": [
+    {"id": "eth0", "type": "phy", "ethernet_mac_address": "92:60:a0:5b:22:c2"},
+    {"id": "eth1", "type": "phy", "ethernet_mac_address": "b4:87:18:bf:a3:60"}
+  ],
+  "networks": [
+    {"id": "network0", "type": "ipv4", "link": "eth0", "ip_address": "10.10.10.12", "netmask": "255.255.255.0",
+     "routes": [{"network": "0.0.0.0", "netmask": "0.0.0.0", "gateway": "10.10.10.1"}]},
+    {"id": "network1", "type": "ipv6", "link": "eth0", "ip_address": "2001:db8::1", "netmask": "ffff:ffff:ffff:ffff::",
+     "routes": [{"network": "::", "netmask": "::", "gateway": "2001:db8::ffff"}]},
+    {"id": "network2", "type": "ipv4", "link": "eth1", "ip_address": "196.168.100.124", "netmask": "255.255.0.0",
+     "routes": [{"network": "0.0.0.0", "netmask": "0.0.0.0", "gateway": "196.168.1.1"}]}
+  ],
+  "services": [
+    {"type": "dns", "address": "8.8.8.8"},
+    {"type": "dns", "address": "8.8.4.4"}
+  ]
+}`,
+			},
+		},
+		"additional device without gateway": {
+			configs: []NetworkConfigData{
+				{MacAddress: "92:60:a0:5b:22:c2", IPAddress: "10.10.10.12/24", Gateway: "10.10.10.1"},
+				{MacAddress: "b4:87:18:bf:a3:60", IPAddress: "172.16.0.12/24"},
+			},
+			want: want{
+				network: `{
+  "links": [
+    {"id": "eth0", "type": "phy", "ethernet_mac_address": "92:60:a0:5b:22:c2"},
+    {"id": "eth1", "type": "phy", "ethernet_mac_address": "b4:87:18:bf:a3:60"}
+  ],
+  "networks": [
+    {"id": "network0", "type": "ipv4", "link": "eth0", "ip_address": "10.10.10.12", "netmask": "255.255.255.0",
+     "routes": [{"network": "0.0.0.0", "netmask": "0.0.0.0", "gateway": "10.10.10.1"}]},
+    {"id": "network1", "type": "ipv4", "link": "eth1", "ip_address": "172.16.0.12", "netmask": "255.255.255.0"}
+  ]
+}`,
+			},
+		},
+		"additional device with dhcpv6": {
+			configs: []NetworkConfigData{
+				{MacAddress: "92:60:a0:5b:22:c2", IPAddress: "10.10.10.12/24", Gateway: "10.10.10.1"},
+				{MacAddress: "b4:87:18:bf:a3:60", DHCP6: true},
+			},
+			want: want{
+				network: `{
+  "links": [
+    {"id": "eth0", "type": "phy", "ethernet_mac_address": "92:60:a0:5b:22:c2"},
+    {"id": "eth1", "type": "phy", "ethernet_mac_address": "b4:87:18:bf:a3:60"}
+  ],
+  "networks": [
+    {"id": "network0", "type": "ipv4", "link": "eth0", "ip_address": "10.10.10.12", "netmask": "255.255.255.0",
+     "routes": [{"network": "0.0.0.0", "netmask": "0.0.0.0", "gateway": "10.10.10.1"}]},
+    {"id": "network1", "type": "ipv6_dhcp", "link": "eth1"}
+  ]
+}`,
+			},
+		},
+		"default device with dhcp": {
+			configs: []NetworkConfigData{
+				{MacAddress: "92:60:a0:5b:22:c2", DHCP4: true},
+			},
+			want: want{
+				network: `{
+  "links": [
+    {"id": "eth0", "type": "phy", "ethernet_mac_address": "92:60:a0:5b:22:c2"}
+  ],
+  "networks": [
+    {"id": "network0", "type": "ipv4_dhcp", "link": "eth0"}
+  ]
+}`,
+			},
+		},
+		"missing gateway of the default device": {
+			configs: []NetworkConfigData{{MacAddress: "92:60:a0:5b:22:c2", IPAddress: "10.10.10.12/24"}},
+			want:    want{err: ErrMissingGateway},
+		},
+		"missing mac address": {
+			configs: []NetworkConfigData{{IPAddress: "10.10.10.12/24", Gateway: "10.10.10.1"}},
+			want:    want{err: ErrMissingMacAddress},
+		},
+		"missing network config data": {
+			want: want{err: ErrMissingNetworkConfigData},
+		},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			network, err := NewConfigDriveNetworkData(test.configs).Render()
+			require.ErrorIs(t, err, test.want.err)
+			if test.want.err == nil {
+				require.JSONEq(t, test.want.network, string(network))
+			}
+		})
+	}
+}
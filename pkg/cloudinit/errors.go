@@ -40,6 +40,9 @@ var (
 	// ErrMissingNetworkConfigData returns an error if required network config data is empty.
 	ErrMissingNetworkConfigData = errors.New("network config data is not set")
 
+	// ErrUnsupportedUserData returns an error if the bootstrap data cannot be extended with files.
+	ErrUnsupportedUserData = errors.New("bootstrap data is neither a cloud-config nor a script")
+
 	// ErrMissingIPAddresses returns an error if required ip addresses is empty.
 	ErrMissingIPAddresses = errors.New("ip addresses is not set")
 )
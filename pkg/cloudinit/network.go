@@ -30,27 +30,50 @@ const (
     eth{{ $index }}:
       match:
         macaddress: {{ $element.MacAddress }}
-      dhcp4: 'no'
+      {{- if $element.MTU }}
+      mtu: {{ $element.MTU }}
+      {{- end }}
+      dhcp4: '{{ if $element.DHCP4 }}yes{{ else }}no{{ end }}'
+      {{- if $element.DHCP6 }}
+      dhcp6: 'yes'
+      {{- end }}
+      {{- if $element.AcceptRA }}
+      accept-ra: true
+      {{- end }}
+      {{- if $element.LinkLocalOnly }}
+      link-local: [{{ if $element.IPv6LinkLocal }} ipv6 {{ end }}]
+      {{- else }}
+      {{- if or $element.IPAddress $element.IPV6Address }}
       addresses:
+      {{- end }}
       {{- if $element.IPAddress }}
         - {{ $element.IPAddress }}
       {{- end }}
       {{- if $element.IPV6Address }}
         - {{ $element.IPV6Address }}
 	  {{- end }}
+      {{- if or $element.Gateway $element.Gateway6 }}
       routes:
+      {{- end }}
       {{- if $element.Gateway }}
         - to: default
           via: {{ $element.Gateway }}
+          {{- if $element.RouteMetric }}
+          metric: {{ $element.RouteMetric }}
+          {{- end }}
 	  {{- end }}
       {{- if $element.Gateway6 }}
         - to: default
           via: {{ $element.Gateway6 }}
+          {{- if $element.RouteMetric }}
+          metric: {{ $element.RouteMetric }}
+          {{- end }}
 	  {{- end }}
-      {{- if $element.DNSServers }}
+      {{- end }}
+      {{- if $element.Nameservers }}
       nameservers:
         addresses:
-        {{- range $element.DNSServers }}
+        {{- range $element.Nameservers }}
           - {{ . }}
         {{- end -}}
       {{- end -}}
@@ -85,19 +108,34 @@ func (r *NetworkConfig) validate() error {
 	if len(r.data.NetworkConfigData) == 0 {
 		return ErrMissingNetworkConfigData
 	}
-	for _, d := range r.data.NetworkConfigData {
+	for i, d := range r.data.NetworkConfigData {
+		if d.MacAddress == "" {
+			return ErrMissingMacAddress
+		}
+		if d.LinkLocalOnly {
+			if i == 0 {
+				return ErrMissingIPAddress
+			}
+			continue
+		}
+
+		if d.IPAddress == "" && d.IPV6Address == "" && (d.DHCP4 || (d.DHCP6 && (i > 0 || d.AcceptRA))) {
+			// devices may only be configured by DHCP, the default device needs a default route
+			// of DHCP or router advertisements then.
+			continue
+		}
+
 		err := validIPAddress(d.IPAddress)
 		err6 := validIPAddress(d.IPV6Address)
 		if err != nil && err6 != nil {
 			return err
 		}
 
-		if d.Gateway == "" && d.Gateway6 == "" {
+		// only the primary network device requires a default route, additional
+		// devices of storage or cluster-internal networks may have no gateway.
+		if i == 0 && d.Gateway == "" && d.Gateway6 == "" {
 			return ErrMissingGateway
 		}
-		if d.MacAddress == "" {
-			return ErrMissingMacAddress
-		}
 	}
 	return nil
 }
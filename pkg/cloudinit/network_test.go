@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
 )
 
 const (
@@ -108,6 +109,175 @@ const (
           - 8.8.8.8
           - 8.8.4.4`
 
+	expectedValidNetworkConfigDualStackNameservers = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: 'no'
+      addresses:
+        - 10.10.10.12/24
+        - 2001:db8::1/64
+      routes:
+        - to: default
+          via: 10.10.10.1
+        - to: default
+          via: 2001:db8::1
+      nameservers:
+        addresses:
+          - 8.8.8.8
+          - 2001:4860:4860::8888
+          - 2001:4860:4860::8844`
+
+	expectedValidNetworkConfigRouteMetric = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: 'no'
+      addresses:
+        - 10.10.10.12/24
+        - 2001:db8::1/64
+      routes:
+        - to: default
+          via: 10.10.10.1
+          metric: 100
+        - to: default
+          via: 2001:db8::1
+          metric: 100
+      nameservers:
+        addresses:
+          - 8.8.8.8
+    eth1:
+      match:
+        macaddress: b4:87:18:bf:a3:60
+      dhcp4: 'no'
+      addresses:
+        - 196.168.100.124/24
+      routes:
+        - to: default
+          via: 196.168.100.254
+          metric: 200
+      nameservers:
+        addresses:
+          - 8.8.8.8`
+
+	expectedValidNetworkConfigWithoutGateway = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: 'no'
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: default
+          via: 10.10.10.1
+      nameservers:
+        addresses:
+          - 8.8.8.8
+    eth1:
+      match:
+        macaddress: b4:87:18:bf:a3:60
+      dhcp4: 'no'
+      addresses:
+        - 172.16.0.12/24`
+
+	expectedValidNetworkConfigLinkLocal = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: 'no'
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: default
+          via: 10.10.10.1
+    eth1:
+      match:
+        macaddress: b4:87:18:bf:a3:60
+      dhcp4: 'no'
+      link-local: [ ipv6 ]
+    eth2:
+      match:
+        macaddress: b4:87:18:bf:a3:61
+      dhcp4: 'no'
+      link-local: []`
+
+	expectedValidNetworkConfigDHCP6 = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: 'no'
+      dhcp6: 'yes'
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: default
+          via: 10.10.10.1
+    eth1:
+      match:
+        macaddress: b4:87:18:bf:a3:60
+      dhcp4: 'no'
+      dhcp6: 'yes'`
+
+	expectedValidNetworkConfigMTU = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: 'no'
+      addresses:
+        - 10.10.10.12/24
+      routes:
+        - to: default
+          via: 10.10.10.1
+    eth1:
+      match:
+        macaddress: b4:87:18:bf:a3:60
+      mtu: 9000
+      dhcp4: 'no'
+      addresses:
+        - 172.16.0.12/24`
+
+	expectedValidNetworkConfigDHCP = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: 'yes'
+      dhcp6: 'yes'
+      nameservers:
+        addresses:
+          - 8.8.8.8`
+
+	expectedValidNetworkConfigDHCP6AcceptRA = `network:
+  version: 2
+  renderer: networkd
+  ethernets:
+    eth0:
+      match:
+        macaddress: 92:60:a0:5b:22:c2
+      dhcp4: 'no'
+      dhcp6: 'yes'
+      accept-ra: true`
+
 	expectedValidNetworkConfigIPV6 = `network:
   version: 2
   renderer: networkd
@@ -309,6 +479,204 @@ func TestNetworkConfig_Render(t *testing.T) {
 				err:     nil,
 			},
 		},
+		"ValidNetworkConfigDualStackNameservers": {
+			reason: "render the nameservers of both address families",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress:  "92:60:a0:5b:22:c2",
+						IPAddress:   "10.10.10.12/24",
+						IPV6Address: "2001:db8::1/64",
+						Gateway6:    "2001:db8::1",
+						Gateway:     "10.10.10.1",
+						DNSServers:  []string{"8.8.8.8", "2001:4860:4860::8888"},
+						DNSServers6: []string{"2001:4860:4860::8888", "2001:4860:4860::8844"},
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigDualStackNameservers,
+				err:     nil,
+			},
+		},
+		"ValidNetworkConfigRouteMetric": {
+			reason: "render the metrics of the default routes",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress:  "92:60:a0:5b:22:c2",
+						IPAddress:   "10.10.10.12/24",
+						IPV6Address: "2001:db8::1/64",
+						Gateway6:    "2001:db8::1",
+						Gateway:     "10.10.10.1",
+						DNSServers:  []string{"8.8.8.8"},
+						RouteMetric: ptr.To(int32(100)),
+					},
+					{
+						MacAddress:  "b4:87:18:bf:a3:60",
+						IPAddress:   "196.168.100.124/24",
+						Gateway:     "196.168.100.254",
+						DNSServers:  []string{"8.8.8.8"},
+						RouteMetric: ptr.To(int32(200)),
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigRouteMetric,
+				err:     nil,
+			},
+		},
+		"ValidNetworkConfigWithoutGateway": {
+			reason: "render an additional device without a default route",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress: "92:60:a0:5b:22:c2",
+						IPAddress:  "10.10.10.12/24",
+						Gateway:    "10.10.10.1",
+						DNSServers: []string{"8.8.8.8"},
+					},
+					{
+						MacAddress: "b4:87:18:bf:a3:60",
+						IPAddress:  "172.16.0.12/24",
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigWithoutGateway,
+				err:     nil,
+			},
+		},
+		"ValidNetworkConfigLinkLocal": {
+			reason: "render additional devices without addresses",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress: "92:60:a0:5b:22:c2",
+						IPAddress:  "10.10.10.12/24",
+						Gateway:    "10.10.10.1",
+					},
+					{
+						MacAddress:    "b4:87:18:bf:a3:60",
+						LinkLocalOnly: true,
+						IPv6LinkLocal: true,
+					},
+					{
+						MacAddress:    "b4:87:18:bf:a3:61",
+						LinkLocalOnly: true,
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigLinkLocal,
+				err:     nil,
+			},
+		},
+		"InvalidNetworkConfigLinkLocalDefault": {
+			reason: "the default device requires an ip address",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress:    "92:60:a0:5b:22:c2",
+						LinkLocalOnly: true,
+					},
+				},
+			},
+			want: want{
+				network: "",
+				err:     ErrMissingIPAddress,
+			},
+		},
+		"ValidNetworkConfigDHCP6": {
+			reason: "render devices with dhcpv6",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress: "92:60:a0:5b:22:c2",
+						IPAddress:  "10.10.10.12/24",
+						Gateway:    "10.10.10.1",
+						DHCP6:      true,
+					},
+					{
+						MacAddress: "b4:87:18:bf:a3:60",
+						DHCP6:      true,
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigDHCP6,
+				err:     nil,
+			},
+		},
+		"InvalidNetworkConfigDHCP6Default": {
+			reason: "the default device requires an ip address with dhcpv6",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress: "92:60:a0:5b:22:c2",
+						DHCP6:      true,
+					},
+				},
+			},
+			want: want{
+				network: "",
+				err:     ErrMissingIPAddress,
+			},
+		},
+		"ValidNetworkConfigMTU": {
+			reason: "render a device without default route and with an mtu",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress: "92:60:a0:5b:22:c2",
+						IPAddress:  "10.10.10.12/24",
+						Gateway:    "10.10.10.1",
+					},
+					{
+						MacAddress: "b4:87:18:bf:a3:60",
+						IPAddress:  "172.16.0.12/24",
+						MTU:        9000,
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigMTU,
+				err:     nil,
+			},
+		},
+		"ValidNetworkConfigDHCP": {
+			reason: "render a default device configured by dhcp",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress: "92:60:a0:5b:22:c2",
+						DHCP4:      true,
+						DHCP6:      true,
+						DNSServers: []string{"8.8.8.8"},
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigDHCP,
+				err:     nil,
+			},
+		},
+		"ValidNetworkConfigDHCP6AcceptRA": {
+			reason: "render a default device configured by dhcpv6 and router advertisements",
+			args: args{
+				nics: []NetworkConfigData{
+					{
+						MacAddress: "92:60:a0:5b:22:c2",
+						DHCP6:      true,
+						AcceptRA:   true,
+					},
+				},
+			},
+			want: want{
+				network: expectedValidNetworkConfigDHCP6AcceptRA,
+				err:     nil,
+			},
+		},
 		"ValidNetworkConfigIPV6": {
 			reason: "render valid ipv6 network-config",
 			args: args{
@@ -337,3 +705,12 @@ func TestNetworkConfig_Render(t *testing.T) {
 		})
 	}
 }
+
+func TestNetworkConfigData_Nameservers(t *testing.T) {
+	data := NetworkConfigData{
+		DNSServers:  []string{"10.0.0.1", "10.0.0.2"},
+		DNSServers6: []string{"2001:db8::53", "10.0.0.2"},
+	}
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2", "2001:db8::53"}, data.Nameservers())
+	require.Empty(t, NetworkConfigData{}.Nameservers())
+}
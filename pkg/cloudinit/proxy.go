@@ -0,0 +1,92 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// proxyEnvironmentPath is the file providing the proxy to login shells and PAM sessions.
+	proxyEnvironmentPath = "/etc/environment"
+
+	// proxyContainerdDropInPath is the systemd drop-in providing the proxy to containerd.
+	proxyContainerdDropInPath = "/etc/systemd/system/containerd.service.d/http-proxy.conf"
+)
+
+// Proxy provides the files and commands configuring the HTTP proxy of a machine.
+type Proxy struct {
+	httpProxy  string
+	httpsProxy string
+	noProxy    []string
+}
+
+// NewProxy returns a new Proxy object.
+func NewProxy(httpProxy, httpsProxy string, noProxy []string) *Proxy {
+	return &Proxy{
+		httpProxy:  httpProxy,
+		httpsProxy: httpsProxy,
+		noProxy:    noProxy,
+	}
+}
+
+// environment returns the proxy environment variables.
+func (p *Proxy) environment() []string {
+	var env []string
+	for _, v := range []struct {
+		name  string
+		value string
+	}{
+		{name: "HTTP_PROXY", value: p.httpProxy},
+		{name: "HTTPS_PROXY", value: p.httpsProxy},
+		{name: "NO_PROXY", value: strings.Join(p.noProxy, ",")},
+	} {
+		if v.value != "" {
+			env = append(env, fmt.Sprintf("%s=%s", v.name, v.value))
+		}
+	}
+	return env
+}
+
+// Files returns the files setting the proxy for the environment and containerd.
+func (p *Proxy) Files() []File {
+	env := p.environment()
+
+	environment := &strings.Builder{}
+	dropIn := &strings.Builder{}
+	dropIn.WriteString("[Service]\n")
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		// many tools only respect the lowercase variables.
+		fmt.Fprintf(environment, "%s\n%s=%s\n", e, strings.ToLower(name), value)
+		fmt.Fprintf(dropIn, "Environment=%q\n", e)
+	}
+
+	return []File{
+		{Path: proxyEnvironmentPath, Content: []byte(environment.String()), Append: true},
+		{Path: proxyContainerdDropInPath, Content: []byte(dropIn.String()), Permissions: "0644", Owner: "root:root"},
+	}
+}
+
+// Commands returns the commands to apply the proxy to a running containerd.
+func (p *Proxy) Commands() []string {
+	return []string{
+		"systemctl daemon-reload",
+		"systemctl try-restart containerd.service",
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	expectedProxyEnvironment = `HTTP_PROXY=http://proxy.example.com:3128
+http_proxy=http://proxy.example.com:3128
+NO_PROXY=10.0.0.0/8,.svc,.cluster.local
+no_proxy=10.0.0.0/8,.svc,.cluster.local
+`
+
+	expectedProxyDropIn = `[Service]
+Environment="HTTP_PROXY=http://proxy.example.com:3128"
+Environment="NO_PROXY=10.0.0.0/8,.svc,.cluster.local"
+`
+)
+
+func TestProxy_Files(t *testing.T) {
+	p := NewProxy("http://proxy.example.com:3128", "", []string{"10.0.0.0/8", ".svc", ".cluster.local"})
+
+	files := p.Files()
+	require.Len(t, files, 2)
+
+	require.Equal(t, proxyEnvironmentPath, files[0].Path)
+	require.True(t, files[0].Append)
+	require.Equal(t, expectedProxyEnvironment, string(files[0].Content))
+
+	require.Equal(t, proxyContainerdDropInPath, files[1].Path)
+	require.False(t, files[1].Append)
+	require.Equal(t, expectedProxyDropIn, string(files[1].Content))
+
+	require.NotEmpty(t, p.Commands())
+}
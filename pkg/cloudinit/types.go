@@ -32,5 +32,44 @@ type NetworkConfigData struct {
 	IPV6Address string
 	Gateway     string
 	Gateway6    string
-	DNSServers  []string
+	// DNSServers are the nameservers of the IPv4 configuration.
+	DNSServers []string
+	// DNSServers6 are the nameservers of the IPv6 configuration.
+	DNSServers6 []string
+	// RouteMetric is the metric of the default routes, which is left to the OS if not set.
+	RouteMetric *int32
+	// MTU is the MTU of the device, which is left to the OS if not set.
+	MTU int32
+	// DHCP4 configures the IPv4 address, the default route and the nameservers of the device by DHCP.
+	DHCP4 bool
+	// DHCP6 enables DHCPv6 on the device.
+	DHCP6 bool
+	// AcceptRA accepts the default route of IPv6 router advertisements, for devices configured by DHCPv6 only.
+	AcceptRA bool
+	// LinkLocalOnly configures the device without IP addresses and routes.
+	LinkLocalOnly bool
+	// IPv6LinkLocal enables the IPv6 link-local address of a device configured with LinkLocalOnly.
+	IPv6LinkLocal bool
+}
+
+// Nameservers returns the nameservers of the IPv4 and IPv6 configurations, without duplicates.
+func (d NetworkConfigData) Nameservers() []string {
+	var nameservers []string
+	seen := make(map[string]struct{})
+	for _, server := range append(append([]string(nil), d.DNSServers...), d.DNSServers6...) {
+		if _, ok := seen[server]; !ok {
+			seen[server] = struct{}{}
+			nameservers = append(nameservers, server)
+		}
+	}
+	return nameservers
+}
+
+// File is a file written to the machine by cloud-init.
+type File struct {
+	Path        string
+	Content     []byte
+	Permissions string
+	Owner       string
+	Append      bool
 }
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"mime/multipart"
+	"net/textproto"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	userDataBoundary = "==CAPMOX-USERDATA-BOUNDARY=="
+
+	// extraMergeHow prepends the files and commands to the write_files and runcmd of
+	// the bootstrap data, instead of replacing them. This way the commands run before
+	// the bootstrap commands, e.g. kubeadm.
+	extraMergeHow = "list(prepend)+dict(no_replace,recurse_list)+str()"
+)
+
+// UserData provides functionality to render the user-data of a machine.
+type UserData struct {
+	bootstrapData []byte
+	files         []File
+	commands      []string
+}
+
+// NewUserData returns a new UserData object.
+func NewUserData(bootstrapData []byte, files []File, commands []string) *UserData {
+	return &UserData{
+		bootstrapData: bootstrapData,
+		files:         files,
+		commands:      commands,
+	}
+}
+
+type writeFile struct {
+	Path        string `json:"path"`
+	Encoding    string `json:"encoding"`
+	Content     string `json:"content"`
+	Permissions string `json:"permissions,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Append      bool   `json:"append,omitempty"`
+}
+
+type extraConfig struct {
+	MergeHow   string      `json:"merge_how"`
+	WriteFiles []writeFile `json:"write_files,omitempty"`
+	RunCmd     []string    `json:"runcmd,omitempty"`
+}
+
+// Render returns the rendered user-data.
+// Without files and commands, the bootstrap data is returned as is. Otherwise, they
+// are added as an additional cloud-config part of a multipart user-data.
+func (r *UserData) Render() ([]byte, error) {
+	if len(r.files) == 0 && len(r.commands) == 0 {
+		return r.bootstrapData, nil
+	}
+
+	bootstrapType, err := userDataContentType(r.bootstrapData)
+	if err != nil {
+		return nil, err
+	}
+
+	config := extraConfig{MergeHow: extraMergeHow, RunCmd: r.commands}
+	for _, f := range r.files {
+		config.WriteFiles = append(config.WriteFiles, writeFile{
+			Path:        f.Path,
+			Encoding:    "b64",
+			Content:     base64.StdEncoding.EncodeToString(f.Content),
+			Permissions: f.Permissions,
+			Owner:       f.Owner,
+			Append:      f.Append,
+		})
+	}
+	extra, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render files")
+	}
+
+	buffer := &bytes.Buffer{}
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: multipart/mixed; boundary=\"" + userDataBoundary + "\"\r\n\r\n")
+
+	mw := multipart.NewWriter(buffer)
+	if err := mw.SetBoundary(userDataBoundary); err != nil {
+		return nil, errors.Wrap(err, "failed to render user-data")
+	}
+
+	parts := []struct {
+		contentType string
+		content     []byte
+	}{
+		{contentType: bootstrapType, content: r.bootstrapData},
+		{contentType: "text/cloud-config", content: append([]byte("#cloud-config\n"), extra...)},
+	}
+	for _, part := range parts {
+		w, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType + "; charset=\"utf-8\""}})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render user-data")
+		}
+		if _, err := w.Write(part.content); err != nil {
+			return nil, errors.Wrap(err, "failed to render user-data")
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to render user-data")
+	}
+	return buffer.Bytes(), nil
+}
+
+// userDataContentType returns the MIME type of the given bootstrap data.
+func userDataContentType(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("## template: jinja")):
+		return "text/jinja2", nil
+	case bytes.HasPrefix(data, []byte("#cloud-config")):
+		return "text/cloud-config", nil
+	case bytes.HasPrefix(data, []byte("#!")), bytes.HasPrefix(data, []byte("#ps1")):
+		// cloudbase-init runs PowerShell scripts like #ps1_sysnative as shell scripts, too.
+		return "text/x-shellscript", nil
+	default:
+		return "", ErrUnsupportedUserData
+	}
+}
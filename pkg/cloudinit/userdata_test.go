@@ -0,0 +1,102 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestUserData_RenderWithoutFiles(t *testing.T) {
+	bootstrapData := []byte("#cloud-config\nruncmd: []\n")
+
+	userData, err := NewUserData(bootstrapData, nil, nil).Render()
+	require.NoError(t, err)
+	require.Equal(t, bootstrapData, userData)
+}
+
+func TestUserData_RenderWithFiles(t *testing.T) {
+	bootstrapData := []byte("## template: jinja\n#cloud-config\nruncmd: []\n")
+	files := []File{
+		{Path: "/etc/sysctl.d/99-custom.conf", Content: []byte("vm.swappiness=0\n"), Permissions: "0644", Owner: "root:root"},
+	}
+
+	userData, err := NewUserData(bootstrapData, files, []string{"systemctl daemon-reload"}).Render()
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(userData))
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/mixed", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+
+	part, err := mr.NextPart()
+	require.NoError(t, err)
+	require.Equal(t, "text/jinja2; charset=\"utf-8\"", part.Header.Get("Content-Type"))
+	content, err := io.ReadAll(part)
+	require.NoError(t, err)
+	require.Equal(t, bootstrapData, content)
+
+	part, err = mr.NextPart()
+	require.NoError(t, err)
+	require.Equal(t, "text/cloud-config; charset=\"utf-8\"", part.Header.Get("Content-Type"))
+	content, err = io.ReadAll(part)
+	require.NoError(t, err)
+
+	var config extraConfig
+	require.NoError(t, yaml.Unmarshal(content, &config))
+	require.Equal(t, extraMergeHow, config.MergeHow)
+	require.Equal(t, []string{"systemctl daemon-reload"}, config.RunCmd)
+	require.Equal(t, []writeFile{{
+		Path:        "/etc/sysctl.d/99-custom.conf",
+		Encoding:    "b64",
+		Content:     "dm0uc3dhcHBpbmVzcz0wCg==",
+		Permissions: "0644",
+		Owner:       "root:root",
+	}}, config.WriteFiles)
+
+	_, err = mr.NextPart()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestUserDataContentType(t *testing.T) {
+	tests := map[string]string{
+		"## template: jinja\n": "text/jinja2",
+		"#cloud-config\n":      "text/cloud-config",
+		"#!/bin/sh\n":          "text/x-shellscript",
+		"#ps1_sysnative\n":     "text/x-shellscript",
+	}
+	for data, expected := range tests {
+		contentType, err := userDataContentType([]byte(data))
+		require.NoError(t, err)
+		require.Equal(t, expected, contentType)
+	}
+}
+
+func TestUserData_RenderUnsupportedFormat(t *testing.T) {
+	_, err := NewUserData([]byte(`{"ignition":{}}`), []File{{Path: "/etc/motd"}}, nil).Render()
+	require.ErrorIs(t, err, ErrUnsupportedUserData)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1beta2 sets the conditions of the v1beta2 contract of Cluster API, which are metav1.Conditions
+// in status.v1beta2.conditions. During the deprecation of the v1beta1 conditions, which the reconcilers
+// still set, they are mirrored to the v1beta2 conditions.
+package v1beta2
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Getter is an object with v1beta2 conditions.
+type Getter interface {
+	client.Object
+	GetV1Beta2Conditions() []metav1.Condition
+}
+
+// Setter is an object whose v1beta2 conditions can be set.
+type Setter interface {
+	Getter
+	SetV1Beta2Conditions([]metav1.Condition)
+}
+
+// V1Beta1Setter is an object with v1beta1 conditions, whose v1beta2 conditions can be set.
+type V1Beta1Setter interface {
+	Setter
+	GetConditions() clusterv1.Conditions
+}
+
+// Get returns the condition of the type, or nil if the object has none.
+func Get(obj Getter, conditionType string) *metav1.Condition {
+	return meta.FindStatusCondition(obj.GetV1Beta2Conditions(), conditionType)
+}
+
+// Set sets the condition, which is observed at the current generation of the object.
+// The transition time is kept unless the status changes.
+func Set(obj Setter, condition metav1.Condition) {
+	conditions := obj.GetV1Beta2Conditions()
+	condition.ObservedGeneration = obj.GetGeneration()
+	meta.SetStatusCondition(&conditions, condition)
+	obj.SetV1Beta2Conditions(conditions)
+}
+
+// MirrorV1Beta1 sets the v1beta2 conditions to the v1beta1 conditions of the object. The v1beta2 conditions of the
+// excepted types are neither mirrored nor removed, all others without a v1beta1 condition are removed.
+// As v1beta2 conditions require a reason, conditions without one get a reason derived from their type and status.
+func MirrorV1Beta1(obj V1Beta1Setter, except ...string) {
+	excepted := make(map[string]bool, len(except))
+	for _, conditionType := range except {
+		excepted[conditionType] = true
+	}
+
+	mirrored := make(map[string]bool)
+	for _, c := range obj.GetConditions() {
+		conditionType := string(c.Type)
+		if excepted[conditionType] {
+			continue
+		}
+		mirrored[conditionType] = true
+
+		status := metav1.ConditionStatus(c.Status)
+		reason := c.Reason
+		if reason == "" {
+			reason = derivedReason(conditionType, status)
+		}
+		Set(obj, metav1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime,
+		})
+	}
+
+	var conditions []metav1.Condition
+	for _, c := range obj.GetV1Beta2Conditions() {
+		if excepted[c.Type] || mirrored[c.Type] {
+			conditions = append(conditions, c)
+		}
+	}
+	obj.SetV1Beta2Conditions(conditions)
+}
+
+// SetSummary sets the condition of the target type to the summary of the conditions of the given types. Like the
+// v1beta1 summary, it skips conditions which are not reported. The summary is false if any condition is false,
+// unknown if any is unknown or none is reported, and true otherwise. Its message lists the conditions which are not true.
+func SetSummary(obj Setter, targetType string, conditionTypes ...string) {
+	status := metav1.ConditionTrue
+	var messages []string
+	reported := false
+	for _, conditionType := range conditionTypes {
+		c := Get(obj, conditionType)
+		if c == nil {
+			continue
+		}
+		reported = true
+
+		switch {
+		case c.Status == metav1.ConditionTrue:
+			continue
+		case c.Status == metav1.ConditionFalse:
+			status = metav1.ConditionFalse
+		case status == metav1.ConditionTrue:
+			status = metav1.ConditionUnknown
+		}
+		message := "* " + c.Type
+		if c.Message != "" {
+			message += ": " + c.Message
+		}
+		messages = append(messages, message)
+	}
+
+	if !reported {
+		status = metav1.ConditionUnknown
+		messages = []string{"waiting for " + strings.Join(conditionTypes, ", ")}
+	}
+
+	Set(obj, metav1.Condition{
+		Type:    targetType,
+		Status:  status,
+		Reason:  derivedReason(targetType, status),
+		Message: strings.Join(messages, "\n"),
+	})
+}
+
+// derivedReason returns the reason of a condition of the type and status, like Ready, NotReady and ReadyUnknown.
+func derivedReason(conditionType string, status metav1.ConditionStatus) string {
+	switch status {
+	case metav1.ConditionTrue:
+		return conditionType
+	case metav1.ConditionFalse:
+		return "Not" + conditionType
+	default:
+		return conditionType + "Unknown"
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+)
+
+func TestSet(t *testing.T) {
+	machine := &infrav1alpha1.ProxmoxMachine{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+	since := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+	Set(machine, metav1.Condition{Type: "Paused", Status: metav1.ConditionFalse, Reason: "NotPaused", LastTransitionTime: since})
+	require.Equal(t, []metav1.Condition{
+		{Type: "Paused", Status: metav1.ConditionFalse, Reason: "NotPaused", ObservedGeneration: 3, LastTransitionTime: since},
+	}, machine.Status.V1Beta2.Conditions)
+
+	// the transition time is kept while the status does not change.
+	machine.Generation = 4
+	Set(machine, metav1.Condition{Type: "Paused", Status: metav1.ConditionFalse, Reason: "NotPaused"})
+	require.Equal(t, since, Get(machine, "Paused").LastTransitionTime)
+	require.Equal(t, int64(4), Get(machine, "Paused").ObservedGeneration)
+
+	require.Nil(t, Get(machine, "Ready"))
+}
+
+func TestMirrorV1Beta1(t *testing.T) {
+	machine := &infrav1alpha1.ProxmoxMachine{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+	conditions.MarkTrue(machine, infrav1alpha1.VMProvisionedCondition)
+	conditions.MarkFalse(machine, infrav1alpha1.AgentHealthyCondition, infrav1alpha1.AgentNotRespondingReason, clusterv1.ConditionSeverityWarning, "no response")
+	conditions.MarkUnknown(machine, infrav1alpha1.CloudInitCompletedCondition, "", "")
+	conditions.MarkTrue(machine, clusterv1.ReadyCondition)
+	machine.SetV1Beta2Conditions([]metav1.Condition{
+		{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Ready"},
+		{Type: "NodeJoined", Status: metav1.ConditionTrue, Reason: "NodeJoined"},
+	})
+
+	MirrorV1Beta1(machine, "Ready")
+
+	types := make([]string, 0, len(machine.GetV1Beta2Conditions()))
+	for _, c := range machine.GetV1Beta2Conditions() {
+		types = append(types, c.Type)
+	}
+	require.ElementsMatch(t, []string{"Ready", "VMProvisioned", "AgentHealthy", "CloudInitCompleted"}, types)
+
+	require.Equal(t, "VMProvisioned", Get(machine, "VMProvisioned").Reason)
+	agent := Get(machine, "AgentHealthy")
+	require.Equal(t, metav1.ConditionFalse, agent.Status)
+	require.Equal(t, infrav1alpha1.AgentNotRespondingReason, agent.Reason)
+	require.Equal(t, "no response", agent.Message)
+	require.Equal(t, int64(1), agent.ObservedGeneration)
+	require.Equal(t, conditions.Get(machine, infrav1alpha1.AgentHealthyCondition).LastTransitionTime, agent.LastTransitionTime)
+	require.Equal(t, "CloudInitCompletedUnknown", Get(machine, "CloudInitCompleted").Reason)
+}
+
+func TestSetSummary(t *testing.T) {
+	machine := &infrav1alpha1.ProxmoxMachine{}
+
+	SetSummary(machine, "Ready", "VMProvisioned", "AgentHealthy")
+	ready := Get(machine, "Ready")
+	require.Equal(t, metav1.ConditionUnknown, ready.Status)
+	require.Equal(t, infrav1alpha1.ReadyUnknownV1Beta2Reason, ready.Reason)
+	require.Equal(t, "waiting for VMProvisioned, AgentHealthy", ready.Message)
+
+	// conditions which are not reported are skipped.
+	Set(machine, metav1.Condition{Type: "VMProvisioned", Status: metav1.ConditionTrue, Reason: "VMProvisioned"})
+	SetSummary(machine, "Ready", "VMProvisioned", "AgentHealthy")
+	ready = Get(machine, "Ready")
+	require.Equal(t, metav1.ConditionTrue, ready.Status)
+	require.Equal(t, infrav1alpha1.ReadyV1Beta2Reason, ready.Reason)
+	require.Empty(t, ready.Message)
+
+	Set(machine, metav1.Condition{Type: "AgentHealthy", Status: metav1.ConditionUnknown, Reason: "AgentHealthyUnknown"})
+	SetSummary(machine, "Ready", "VMProvisioned", "AgentHealthy")
+	require.Equal(t, metav1.ConditionUnknown, Get(machine, "Ready").Status)
+
+	Set(machine, metav1.Condition{Type: "VMProvisioned", Status: metav1.ConditionFalse, Reason: "Cloning", Message: "cloning template 100"})
+	SetSummary(machine, "Ready", "VMProvisioned", "AgentHealthy")
+	ready = Get(machine, "Ready")
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, infrav1alpha1.NotReadyV1Beta2Reason, ready.Reason)
+	require.Equal(t, "* VMProvisioned: cloning template 100\n* AgentHealthy", ready.Message)
+}
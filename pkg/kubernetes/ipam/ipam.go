@@ -20,7 +20,11 @@ package ipam
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -58,67 +62,138 @@ func InClusterPoolFormat(cluster *infrav1.ProxmoxCluster, format string) string
 	return fmt.Sprintf("%s-%s-icip", cluster.GetName(), format)
 }
 
+// NodeInClusterPoolFormat returns the name of the `InClusterIPPool` of a node IP pool of a given cluster.
+func NodeInClusterPoolFormat(cluster *infrav1.ProxmoxCluster, pool, format string) string {
+	return fmt.Sprintf("%s-%s-%s-%s-icip", cluster.GetName(), pool, format, poolHash(cluster.GetName(), "node", pool, format))
+}
+
+// AdditionalInClusterPoolFormat returns the name of the `InClusterIPPool` of an additional pool config
+// of a given cluster, the index of the first additional pool is 1.
+func AdditionalInClusterPoolFormat(cluster *infrav1.ProxmoxCluster, format string, index int) string {
+	return fmt.Sprintf("%s-%s-%d-%s-icip", cluster.GetName(), format, index, poolHash(cluster.GetName(), "additional", format, strconv.Itoa(index)))
+}
+
+// poolHashLength is the length of the hash in the names of the `InClusterIPPools` of node IP pools
+// and additional pool configs.
+const poolHashLength = 8
+
+// poolHash returns a short hash of the parts of the name of a pool. The names of clusters and node IP pools
+// contain dashes themselves, so the hash keeps the names of the pools of different clusters apart.
+func poolHash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "/")))
+	return hex.EncodeToString(sum[:])[:poolHashLength]
+}
+
 // ErrMissingAddresses is returned when the cluster IPAM config does not contain any addresses.
 var ErrMissingAddresses = errors.New("no valid ip addresses defined for the ip pool")
 
 // CreateOrUpdateInClusterIPPool creates or updates an `InClusterIPPool` which will be
 // used by the `cluster-api-ipam-provider-in-cluster` to provide IP addresses for new nodes.
 // We also need to create this resource to pre-allocate IP addresses which are already in use
-// by Proxmox in order to avoid conflicts.
+// by Proxmox in order to avoid conflicts. The additional pool configs and the node IP pools of the cluster
+// get pools of their own.
 func (h *Helper) CreateOrUpdateInClusterIPPool(ctx context.Context) error {
-	// ipv4
-	if h.cluster.Spec.IPv4Config != nil {
-		ipv4Config := h.cluster.Spec.IPv4Config
-
-		v4Pool := &ipamicv1.InClusterIPPool{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      InClusterPoolFormat(h.cluster, infrav1.IPV4Format),
-				Namespace: h.cluster.GetNamespace(),
-			},
-			Spec: ipamicv1.InClusterIPPoolSpec{
-				Addresses: ipv4Config.Addresses,
-				Prefix:    ipv4Config.Prefix,
-				Gateway:   ipv4Config.Gateway,
-			},
+	for _, format := range []string{infrav1.IPV4Format, infrav1.IPV6Format} {
+		config := h.cluster.Spec.IPv4Config
+		if format == infrav1.IPV6Format {
+			config = h.cluster.Spec.IPv6Config
+		}
+		if config != nil {
+			if err := h.createOrUpdatePool(ctx, InClusterPoolFormat(h.cluster, format), config); err != nil {
+				return err
+			}
 		}
 
-		desired := v4Pool.DeepCopy()
-		_, err := controllerutil.CreateOrUpdate(ctx, h.ctrlClient, v4Pool, func() error {
-			v4Pool.Spec = desired.Spec
-			// set the owner reference to the cluster
-			return controllerutil.SetControllerReference(h.cluster, v4Pool, h.ctrlClient.Scheme())
-		})
-		if err != nil {
-			return err
+		additional := h.cluster.AdditionalIPPoolConfigs(format)
+		for i := range additional {
+			if err := h.createOrUpdatePool(ctx, AdditionalInClusterPoolFormat(h.cluster, format, i+1), &additional[i]); err != nil {
+				return err
+			}
+		}
+
+		for i := range h.cluster.Spec.NodeIPPools {
+			nodePool := &h.cluster.Spec.NodeIPPools[i]
+			if config := nodePool.Config(format); config != nil {
+				if err := h.createOrUpdatePool(ctx, NodeInClusterPoolFormat(h.cluster, nodePool.Name, format), config); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
-	// ipv6
-	if h.cluster.Spec.IPv6Config != nil {
-		v6Pool := &ipamicv1.InClusterIPPool{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      InClusterPoolFormat(h.cluster, infrav1.IPV6Format),
-				Namespace: h.cluster.GetNamespace(),
-			},
-			Spec: ipamicv1.InClusterIPPoolSpec{
-				Addresses: h.cluster.Spec.IPv6Config.Addresses,
-				Prefix:    h.cluster.Spec.IPv6Config.Prefix,
-				Gateway:   h.cluster.Spec.IPv6Config.Gateway,
-			},
+	return nil
+}
+
+func (h *Helper) createOrUpdatePool(ctx context.Context, name string, config *ipamicv1.InClusterIPPoolSpec) error {
+	pool := &ipamicv1.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: h.cluster.GetNamespace(),
+		},
+		Spec: ipamicv1.InClusterIPPoolSpec{
+			Addresses: config.Addresses,
+			Prefix:    config.Prefix,
+			Gateway:   config.Gateway,
+		},
+	}
+
+	desired := pool.DeepCopy()
+	_, err := controllerutil.CreateOrUpdate(ctx, h.ctrlClient, pool, func() error {
+		// an existing pool which is not managed by the cluster is not taken over.
+		if pool.GetResourceVersion() != "" && !metav1.IsControlledBy(pool, h.cluster) {
+			return errors.Errorf("InClusterIPPool %s already exists and is not managed by ProxmoxCluster %s", pool.GetName(), h.cluster.GetName())
 		}
+		pool.Spec = desired.Spec
+		// set the owner reference to the cluster
+		return controllerutil.SetControllerReference(h.cluster, pool, h.ctrlClient.Scheme())
+	})
+	return err
+}
+
+// NodeInClusterIPPoolRef returns the reference to the `InClusterIPPool` of the node IP pool which
+// provides the addresses of an address family on a node, or nil if the node uses the pool of the cluster.
+func (h *Helper) NodeInClusterIPPoolRef(node, format string) *corev1.TypedLocalObjectReference {
+	nodePool := h.cluster.NodeIPPoolOf(node, format)
+	if nodePool == nil {
+		return nil
+	}
+	return &corev1.TypedLocalObjectReference{
+		APIGroup: ptr.To(ipamicv1.GroupVersion.Group),
+		Kind:     "InClusterIPPool",
+		Name:     NodeInClusterPoolFormat(h.cluster, nodePool.Name, format),
+	}
+}
 
-		desired := v6Pool.DeepCopy()
-		_, err := controllerutil.CreateOrUpdate(ctx, h.ctrlClient, v6Pool, func() error {
-			v6Pool.Spec = desired.Spec
-			// set the owner reference to the cluster
-			return controllerutil.SetControllerReference(h.cluster, v6Pool, h.ctrlClient.Scheme())
+// AdditionalInClusterIPPoolRefs returns the references to the `InClusterIPPools` of the additional pool configs
+// of an address family, in the order in which the default network devices fall back to them.
+func (h *Helper) AdditionalInClusterIPPoolRefs(format string) []corev1.TypedLocalObjectReference {
+	additional := h.cluster.AdditionalIPPoolConfigs(format)
+	refs := make([]corev1.TypedLocalObjectReference, 0, len(additional))
+	for i := range additional {
+		refs = append(refs, corev1.TypedLocalObjectReference{
+			APIGroup: ptr.To(ipamicv1.GroupVersion.Group),
+			Kind:     "InClusterIPPool",
+			Name:     AdditionalInClusterPoolFormat(h.cluster, format, i+1),
 		})
-		if err != nil {
-			return err
+	}
+	return refs
+}
+
+// ListInClusterIPPools lists the `InClusterIPPools` which were created for the cluster.
+func (h *Helper) ListInClusterIPPools(ctx context.Context) ([]ipamicv1.InClusterIPPool, error) {
+	var list ipamicv1.InClusterIPPoolList
+	if err := h.ctrlClient.List(ctx, &list, client.InNamespace(h.cluster.GetNamespace())); err != nil {
+		return nil, err
+	}
+
+	var pools []ipamicv1.InClusterIPPool
+	for _, pool := range list.Items {
+		if metav1.IsControlledBy(&pool, h.cluster) {
+			pools = append(pools, pool)
 		}
 	}
 
-	return nil
+	return pools, nil
 }
 
 // GetDefaultInClusterIPPool attempts to retrieve the `InClusterIPPool`
@@ -129,6 +204,13 @@ func (h *Helper) GetDefaultInClusterIPPool(ctx context.Context, format string) (
 	})
 }
 
+// GetNodeInClusterIPPool attempts to retrieve the `InClusterIPPool` of a node IP pool of the cluster.
+func (h *Helper) GetNodeInClusterIPPool(ctx context.Context, pool, format string) (*ipamicv1.InClusterIPPool, error) {
+	return h.GetInClusterIPPool(ctx, &corev1.TypedLocalObjectReference{
+		Name: NodeInClusterPoolFormat(h.cluster, pool, format),
+	})
+}
+
 // GetInClusterIPPool attempts to retrieve the referenced `InClusterIPPool`.
 func (h *Helper) GetInClusterIPPool(ctx context.Context, ref *corev1.TypedLocalObjectReference) (*ipamicv1.InClusterIPPool, error) {
 	out := &ipamicv1.InClusterIPPool{}
@@ -140,6 +222,28 @@ func (h *Helper) GetInClusterIPPool(ctx context.Context, ref *corev1.TypedLocalO
 	return out, nil
 }
 
+// ListIPAddressesOfInClusterIPPool lists the IPAddresses which are allocated from the `InClusterIPPool`.
+func (h *Helper) ListIPAddressesOfInClusterIPPool(ctx context.Context, pool *ipamicv1.InClusterIPPool) ([]ipamv1.IPAddress, error) {
+	var list ipamv1.IPAddressList
+	if err := h.ctrlClient.List(ctx, &list, client.InNamespace(pool.GetNamespace())); err != nil {
+		return nil, err
+	}
+
+	var addresses []ipamv1.IPAddress
+	for _, addr := range list.Items {
+		if ref := addr.Spec.PoolRef; ref.Kind == "InClusterIPPool" && ref.Name == pool.GetName() {
+			addresses = append(addresses, addr)
+		}
+	}
+
+	return addresses, nil
+}
+
+// DeleteInClusterIPPool deletes the `InClusterIPPool`.
+func (h *Helper) DeleteInClusterIPPool(ctx context.Context, pool *ipamicv1.InClusterIPPool) error {
+	return client.IgnoreNotFound(h.ctrlClient.Delete(ctx, pool))
+}
+
 // GetGlobalInClusterIPPool attempts to retrieve the referenced `GlobalInClusterIPPool`.
 func (h *Helper) GetGlobalInClusterIPPool(ctx context.Context, ref *corev1.TypedLocalObjectReference) (*ipamicv1.GlobalInClusterIPPool, error) {
 	out := &ipamicv1.GlobalInClusterIPPool{}
@@ -164,7 +268,8 @@ func (h *Helper) CreateIPAddressClaim(ctx context.Context, owner client.Object,
 	}
 
 	switch {
-	case device == infrav1.DefaultNetworkDevice:
+	case device == infrav1.DefaultNetworkDevice && (ref == nil || ref.Kind == ""):
+		// the default device uses the pool of the cluster, unless it references the pool of its node.
 		pool, err := h.GetDefaultInClusterIPPool(ctx, format)
 		if err != nil {
 			return errors.Wrapf(err, "unable to find inclusterpool for cluster %s", h.cluster.Name)
@@ -226,6 +331,39 @@ func (h *Helper) CreateIPAddressClaim(ctx context.Context, owner client.Object,
 	return err
 }
 
+// GetIPAddressClaim attempts to retrieve the IPAddressClaim.
+func (h *Helper) GetIPAddressClaim(ctx context.Context, key client.ObjectKey) (*ipamv1.IPAddressClaim, error) {
+	out := &ipamv1.IPAddressClaim{}
+	err := h.ctrlClient.Get(ctx, key, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// ListIPAddressClaims lists the IPAddressClaims which belong to the cluster.
+func (h *Helper) ListIPAddressClaims(ctx context.Context) ([]ipamv1.IPAddressClaim, error) {
+	var list ipamv1.IPAddressClaimList
+	if err := h.ctrlClient.List(ctx, &list, client.InNamespace(h.cluster.GetNamespace())); err != nil {
+		return nil, err
+	}
+
+	var claims []ipamv1.IPAddressClaim
+	for _, claim := range list.Items {
+		if claim.GetAnnotations()[clusterv1.ClusterNameAnnotation] == h.cluster.GetName() {
+			claims = append(claims, claim)
+		}
+	}
+
+	return claims, nil
+}
+
+// DeleteIPAddressClaim deletes the IPAddressClaim, which releases its IP address.
+func (h *Helper) DeleteIPAddressClaim(ctx context.Context, claim *ipamv1.IPAddressClaim) error {
+	return client.IgnoreNotFound(h.ctrlClient.Delete(ctx, claim))
+}
+
 // GetIPAddress attempts to retrieve the IPAddress.
 func (h *Helper) GetIPAddress(ctx context.Context, key client.ObjectKey) (*ipamv1.IPAddress, error) {
 	out := &ipamv1.IPAddress{}
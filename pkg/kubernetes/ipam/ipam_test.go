@@ -309,6 +309,95 @@ func (s *IPAMTestSuite) Test_CreateIPAddressClaim() {
 	s.NoError(err)
 }
 
+func (s *IPAMTestSuite) Test_NodeIPPools() {
+	s.cluster.Spec.NodeIPPools = []infrav1.NodeIPPool{{
+		Name:  "rack1",
+		Nodes: []string{"pve1", "pve2"},
+		IPv4Config: &ipamicv1.InClusterIPPoolSpec{
+			Addresses: []string{"10.10.11.2-10.10.11.100"},
+			Prefix:    24,
+			Gateway:   "10.10.11.1",
+		},
+	}}
+	s.NoError(s.helper.CreateOrUpdateInClusterIPPool(s.ctx))
+
+	pool, err := s.helper.GetNodeInClusterIPPool(s.ctx, "rack1", infrav1.IPV4Format)
+	s.NoError(err)
+	s.Equal("test-cluster-rack1-v4-34dbfafe-icip", pool.GetName())
+	s.Equal("10.10.11.1", pool.Spec.Gateway)
+	s.True(metav1.IsControlledBy(pool, s.cluster))
+
+	pools, err := s.helper.ListInClusterIPPools(s.ctx)
+	s.NoError(err)
+	s.Len(pools, 2)
+
+	// machines on other nodes and of other address families use the pools of the cluster.
+	s.Nil(s.helper.NodeInClusterIPPoolRef("pve3", infrav1.IPV4Format))
+	s.Nil(s.helper.NodeInClusterIPPoolRef("pve1", infrav1.IPV6Format))
+
+	ref := s.helper.NodeInClusterIPPoolRef("pve2", infrav1.IPV4Format)
+	s.Equal(&corev1.TypedLocalObjectReference{APIGroup: ptr.To("ipam.cluster.x-k8s.io"), Kind: "InClusterIPPool", Name: "test-cluster-rack1-v4-34dbfafe-icip"}, ref)
+
+	machine := getCluster()
+	s.NoError(s.helper.CreateIPAddressClaim(s.ctx, machine, infrav1.DefaultNetworkDevice, infrav1.IPV4Format, ref))
+
+	claim, err := s.helper.GetIPAddressClaim(s.ctx, client.ObjectKey{Namespace: "test", Name: "test-cluster-net0-inet"})
+	s.NoError(err)
+	s.Equal("test-cluster-rack1-v4-34dbfafe-icip", claim.Spec.PoolRef.Name)
+
+	// the names of the cluster and of the pool contain dashes, which must not make the names of the pools ambiguous.
+	other := getCluster()
+	other.SetName("test")
+	s.NotEqual(NodeInClusterPoolFormat(s.cluster, "rack1", infrav1.IPV4Format), NodeInClusterPoolFormat(other, "cluster-rack1", infrav1.IPV4Format))
+}
+
+func (s *IPAMTestSuite) Test_CreateOrUpdateInClusterIPPoolNotManaged() {
+	// a pool of the same name which is not managed by the cluster is not taken over.
+	s.NoError(s.cl.Create(s.ctx, &ipamicv1.InClusterIPPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      InClusterPoolFormat(s.cluster, infrav1.IPV4Format),
+			Namespace: s.cluster.GetNamespace(),
+		},
+		Spec: ipamicv1.InClusterIPPoolSpec{
+			Addresses: []string{"10.11.0.2-10.11.0.10"},
+			Prefix:    24,
+			Gateway:   "10.11.0.1",
+		},
+	}))
+
+	s.ErrorContains(s.helper.CreateOrUpdateInClusterIPPool(s.ctx), "is not managed by ProxmoxCluster test-cluster")
+
+	pool, err := s.helper.GetDefaultInClusterIPPool(s.ctx, infrav1.IPV4Format)
+	s.NoError(err)
+	s.Equal("10.11.0.1", pool.Spec.Gateway)
+	s.Empty(pool.GetOwnerReferences())
+}
+
+func (s *IPAMTestSuite) Test_AdditionalIPPools() {
+	s.cluster.Spec.AdditionalIPv4Configs = []ipamicv1.InClusterIPPoolSpec{
+		{Addresses: []string{"10.20.0.2-10.20.0.100"}, Prefix: 24, Gateway: "10.20.0.1"},
+		{Addresses: []string{"10.30.0.0/25"}, Prefix: 25, Gateway: "10.30.0.129"},
+	}
+	s.NoError(s.helper.CreateOrUpdateInClusterIPPool(s.ctx))
+
+	refs := s.helper.AdditionalInClusterIPPoolRefs(infrav1.IPV4Format)
+	s.Equal([]corev1.TypedLocalObjectReference{
+		{APIGroup: ptr.To("ipam.cluster.x-k8s.io"), Kind: "InClusterIPPool", Name: "test-cluster-v4-1-3d8b14ea-icip"},
+		{APIGroup: ptr.To("ipam.cluster.x-k8s.io"), Kind: "InClusterIPPool", Name: "test-cluster-v4-2-632e4ef5-icip"},
+	}, refs)
+	s.Empty(s.helper.AdditionalInClusterIPPoolRefs(infrav1.IPV6Format))
+
+	pool, err := s.helper.GetInClusterIPPool(s.ctx, &refs[1])
+	s.NoError(err)
+	s.Equal([]string{"10.30.0.0/25"}, pool.Spec.Addresses)
+	s.Equal("10.30.0.129", pool.Spec.Gateway)
+	s.True(metav1.IsControlledBy(pool, s.cluster))
+
+	pools, err := s.helper.ListInClusterIPPools(s.ctx)
+	s.NoError(err)
+	s.Len(pools, 3)
+}
+
 func (s *IPAMTestSuite) Test_GetIPAddress() {
 	s.NoError(s.helper.CreateOrUpdateInClusterIPPool(s.ctx))
 
@@ -334,6 +423,42 @@ func (s *IPAMTestSuite) Test_GetIPAddress() {
 	s.Equal(ip.Spec.Address, "10.10.10.11")
 }
 
+func (s *IPAMTestSuite) Test_GetIPAddressClaim() {
+	s.NoError(s.helper.CreateOrUpdateInClusterIPPool(s.ctx))
+
+	err := s.helper.CreateIPAddressClaim(s.ctx, getCluster(), "net0", infrav1.IPV4Format, nil)
+	s.NoError(err)
+
+	claim, err := s.helper.GetIPAddressClaim(s.ctx, client.ObjectKey{Namespace: "test", Name: "test-cluster-net0-inet"})
+	s.NoError(err)
+	s.Equal("test-cluster-v4-icip", claim.Spec.PoolRef.Name)
+}
+
+func (s *IPAMTestSuite) Test_ListAndDeleteIPAddressClaims() {
+	s.NoError(s.helper.CreateOrUpdateInClusterIPPool(s.ctx))
+
+	s.NoError(s.helper.CreateIPAddressClaim(s.ctx, getCluster(), "net0", infrav1.IPV4Format, nil))
+	s.NoError(s.cl.Create(s.ctx, &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "other-net0-inet",
+			Namespace:   "test",
+			Annotations: map[string]string{clusterv1.ClusterNameAnnotation: "other-cluster"},
+		},
+	}))
+
+	claims, err := s.helper.ListIPAddressClaims(s.ctx)
+	s.NoError(err)
+	s.Len(claims, 1)
+	s.Equal("test-cluster-net0-inet", claims[0].GetName())
+
+	s.NoError(s.helper.DeleteIPAddressClaim(s.ctx, &claims[0]))
+	s.NoError(s.helper.DeleteIPAddressClaim(s.ctx, &claims[0]))
+
+	claims, err = s.helper.ListIPAddressClaims(s.ctx)
+	s.NoError(err)
+	s.Empty(claims)
+}
+
 func getCluster() *infrav1.ProxmoxCluster {
 	return &infrav1.ProxmoxCluster{
 		TypeMeta: metav1.TypeMeta{
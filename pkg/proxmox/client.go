@@ -21,29 +21,128 @@ import (
 	"context"
 
 	"github.com/luthermonson/go-proxmox"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 )
 
 // Client Global Proxmox client interface.
 type Client interface {
+	ApplySDN(ctx context.Context) (*proxmox.Task, error)
+
+	AllocateVolume(ctx context.Context, nodeName, storage string, ownerID int64, filename string, sizeGB int32) (string, error)
+
+	BackupVM(ctx context.Context, vm *proxmox.VirtualMachine, opts BackupOptions) (*proxmox.Task, error)
+
 	CloneVM(ctx context.Context, templateID int, clone VMCloneRequest) (VMCloneResponse, error)
 
 	ConfigureVM(ctx context.Context, vm *proxmox.VirtualMachine, options ...VirtualMachineOption) (*proxmox.Task, error)
 
+	CreateVM(ctx context.Context, nodeName string, options ...VirtualMachineOption) (VMCloneResponse, error)
+
 	FindVMResource(ctx context.Context, vmID uint64) (*proxmox.ClusterResource, error)
 
 	GetVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.VirtualMachine, error)
 
-	DeleteVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.Task, error)
+	GetVMByID(ctx context.Context, vmID int64) (*proxmox.VirtualMachine, error)
+
+	CreateBackupJob(ctx context.Context, job BackupJob) error
+
+	CreateSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string, opts SnapshotOptions) (*proxmox.Task, error)
+
+	CreateSDNVNet(ctx context.Context, vnet SDNVNet, subnets ...SDNSubnet) error
+
+	DeleteBackupJob(ctx context.Context, id string) error
+
+	DeleteSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string) (*proxmox.Task, error)
+
+	DeleteSDNVNet(ctx context.Context, name string) error
+
+	DeleteVM(ctx context.Context, nodeName string, vmID int64, opts VMDeleteOptions) (*proxmox.Task, error)
+
+	DeleteVolume(ctx context.Context, nodeName, volume string) (*proxmox.Task, error)
+
+	DownloadImage(ctx context.Context, nodeName, storage string, opts ImageDownloadOptions) (*proxmox.Task, error)
+
+	GetStorage(ctx context.Context, nodeName, storage string) (StorageInfo, error)
+
+	GetVMArchitecture(ctx context.Context, vm *proxmox.VirtualMachine) (string, error)
+
+	GetVMFirewall(ctx context.Context, vm *proxmox.VirtualMachine) (VMFirewall, error)
+
+	GetGuestNetworkInterfaces(ctx context.Context, vm *proxmox.VirtualMachine) ([]GuestNetworkInterface, error)
+
+	GetGuestCommandStatus(ctx context.Context, vm *proxmox.VirtualMachine, pid int64) (GuestCommandStatus, error)
 
 	GetTask(ctx context.Context, upID string) (*proxmox.Task, error)
 
-	GetReservableMemoryBytes(ctx context.Context, nodeName string) (uint64, error)
+	GetPoolNodes(ctx context.Context, pool string) ([]string, error)
+
+	GetNodeInventories(ctx context.Context) ([]NodeInventory, error)
+
+	GetNodeSummaries(ctx context.Context) ([]NodeSummary, error)
+
+	GetAPIStatus(ctx context.Context) (APIStatus, error)
+
+	GetNodeMemory(ctx context.Context, nodeName string, accounting infrav1alpha1.MemoryAccounting) (NodeMemory, error)
+
+	GetPendingChanges(ctx context.Context, vm *proxmox.VirtualMachine) ([]string, error)
+
+	HibernateVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error)
+
+	ListBackupJobs(ctx context.Context) ([]BackupJob, error)
+
+	ListBridges(ctx context.Context, nodeName string) ([]string, error)
+
+	ListSDNVNets(ctx context.Context) ([]SDNVNet, error)
+
+	ListSDNZones(ctx context.Context) ([]SDNZone, error)
+
+	ListSnapshots(ctx context.Context, vm *proxmox.VirtualMachine) ([]*proxmox.Snapshot, error)
+
+	ListStorages(ctx context.Context, nodeName string) ([]StorageInfo, error)
+
+	ListVMResources(ctx context.Context) ([]*proxmox.ClusterResource, error)
+
+	ListVolumes(ctx context.Context, nodeName, storage, content string) ([]string, error)
+
+	PingGuestAgent(ctx context.Context, vm *proxmox.VirtualMachine) error
+
+	MigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, targetNode string, online bool) (*proxmox.Task, error)
+	RemoteMigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, opts RemoteMigrateOptions) (*proxmox.Task, error)
+
+	ReadGuestFile(ctx context.Context, vm *proxmox.VirtualMachine, path string) (string, error)
+
+	RebootVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error)
 
 	ResizeDisk(ctx context.Context, vm *proxmox.VirtualMachine, disk, size string) error
 
 	ResumeVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error)
 
+	RollbackSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string) (*proxmox.Task, error)
+
+	SetVMFirewall(ctx context.Context, vm *proxmox.VirtualMachine, firewall VMFirewall) error
+
+	ShutdownVM(ctx context.Context, vm *proxmox.VirtualMachine, opts VMStopOptions) (*proxmox.Task, error)
+
+	StartGuestCommand(ctx context.Context, vm *proxmox.VirtualMachine, command []string) (int64, error)
+
 	StartVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error)
 
+	SuspendVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error)
+
 	TagVM(ctx context.Context, vm *proxmox.VirtualMachine, tag string) (*proxmox.Task, error)
+
+	UpdateBackupJob(ctx context.Context, job BackupJob) error
+
+	UploadISO(ctx context.Context, nodeName, storage, filename string, iso []byte) (*proxmox.Task, error)
+
+	WaitForTask(ctx context.Context, upID string, opts TaskWaitOptions) (*proxmox.Task, error)
+}
+
+// NodeTransport accesses the Proxmox nodes directly, for operations which the Proxmox API
+// does not support for all storages.
+type NodeTransport interface {
+	// WriteVolume writes the content to the file of a volume on the node, like local:iso/user-data.iso,
+	// replacing an existing file.
+	WriteVolume(ctx context.Context, nodeName, volumeID string, content []byte) error
 }
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides an in-memory capmox.Client for tests. It runs the Proxmox API client against a
+// simulated cluster, so VMs, storages and tasks behave like on Proxmox VE without registering HTTP responses,
+// and errors can be injected into single methods.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/luthermonson/go-proxmox"
+
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+var _ capmox.Client = &Client{}
+
+// Client is a capmox.Client of a simulated Proxmox VE cluster.
+type Client struct {
+	// Simulator holds the state of the cluster. Tests add VMs to it, inspect them,
+	// and make the tasks of the cluster run longer or fail.
+	Simulator *proxmoxtest.Simulator
+
+	client *goproxmox.APIClient
+
+	mu    sync.Mutex
+	calls map[string]int
+	// next holds the errors of the next calls of a method, fail those of all later calls.
+	next map[string][]error
+	fail map[string]error
+}
+
+// New starts a simulated cluster with the nodes and returns its client. The client must be closed after use.
+func New(ctx context.Context, nodes ...proxmoxtest.SimulatedNode) (*Client, error) {
+	sim := proxmoxtest.NewSimulator()
+	for _, node := range nodes {
+		sim.AddNode(node)
+	}
+
+	// a client of its own is not intercepted by httpmock in the same test.
+	client, err := goproxmox.NewAPIClient(ctx, logr.Discard(), sim.URL(),
+		proxmox.WithHTTPClient(&http.Client{Transport: &http.Transport{}}))
+	if err != nil {
+		sim.Close()
+		return nil, err
+	}
+	// VMs added to the simulator are visible right away.
+	client.ResourceCacheTTL = 0
+
+	return &Client{
+		Simulator: sim,
+		client:    client,
+		calls:     make(map[string]int),
+		next:      make(map[string][]error),
+		fail:      make(map[string]error),
+	}, nil
+}
+
+// Close shuts down the simulated cluster.
+func (c *Client) Close() {
+	c.Simulator.Close()
+}
+
+// FailNext makes the next calls of the method return the errors, one per call, without reaching the cluster.
+func (c *Client) FailNext(method string, errs ...error) {
+	mustBeMethod(method)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.next[method] = append(c.next[method], errs...)
+}
+
+// Fail makes all calls of the method return the error, without reaching the cluster, once the errors
+// of FailNext are returned. A nil error lets the calls succeed again.
+func (c *Client) Fail(method string, err error) {
+	mustBeMethod(method)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		delete(c.fail, method)
+		return
+	}
+	c.fail[method] = err
+}
+
+// Calls returns the number of calls of the method, including those which failed.
+func (c *Client) Calls(method string) int {
+	mustBeMethod(method)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls[method]
+}
+
+// mustBeMethod panics if the name is not a method of capmox.Client, so typos do not pass tests silently.
+func mustBeMethod(method string) {
+	if _, ok := reflect.TypeOf((*capmox.Client)(nil)).Elem().MethodByName(method); !ok {
+		panic(fmt.Sprintf("fake: %s is not a method of capmox.Client", method))
+	}
+}
+
+// injected counts the call of the method and returns its injected error, if any.
+func (c *Client) injected(method string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls[method]++
+	if errs := c.next[method]; len(errs) > 0 {
+		c.next[method] = errs[1:]
+		return errs[0]
+	}
+	return c.fail[method]
+}
+
+// call runs fn unless an error is injected into the method.
+func call[T any](c *Client, method string, fn func() (T, error)) (T, error) {
+	if err := c.injected(method); err != nil {
+		var zero T
+		return zero, err
+	}
+	return fn()
+}
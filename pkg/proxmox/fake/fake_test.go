@@ -0,0 +1,121 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func newTestClient(t *testing.T) *Client {
+	client, err := New(context.Background(), proxmoxtest.SimulatedNode{Name: "pve1", CPUs: 4, MemoryBytes: 1 << 30})
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	client.Simulator.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Template: true, Config: map[string]any{"name": "template"}})
+
+	// the VMs of the simulator are found without waiting for the resource cache.
+	vm, err := client.GetVMByID(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, "pve1", vm.Node)
+
+	response, err := client.CloneVM(ctx, 100, capmox.VMCloneRequest{Node: "pve1", NewID: 101, Name: "test"})
+	require.NoError(t, err)
+	task, err := client.WaitForTask(ctx, string(response.Task.UPID), capmox.TaskWaitOptions{})
+	require.NoError(t, err)
+	require.True(t, task.IsSuccessful)
+
+	clone, ok := client.Simulator.VM(101)
+	require.True(t, ok)
+	require.Equal(t, "test", clone.Config["name"])
+	require.Equal(t, 1, client.Calls("CloneVM"))
+}
+
+func TestClient_FailedTask(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	client.Simulator.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1"})
+	client.Simulator.FailTasks("start failed: QEMU exited with code 1")
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	task, err := client.StartVM(ctx, vm)
+	require.NoError(t, err)
+	_, err = client.WaitForTask(ctx, string(task.UPID), capmox.TaskWaitOptions{})
+	require.ErrorIs(t, err, capmox.ErrTaskFailed)
+}
+
+func TestClient_FailNext(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	client.Simulator.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1"})
+
+	first, second := errors.New("first"), errors.New("second")
+	client.FailNext("GetVM", first, second)
+
+	_, err := client.GetVM(ctx, "pve1", 100)
+	require.ErrorIs(t, err, first)
+	_, err = client.GetVM(ctx, "pve1", 100)
+	require.ErrorIs(t, err, second)
+	_, err = client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	require.Equal(t, 3, client.Calls("GetVM"))
+	require.Zero(t, client.Calls("StartVM"))
+}
+
+func TestClient_Fail(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	unavailable := errors.New("503 Service Unavailable")
+	client.FailNext("ListStorages", errors.New("first"))
+	client.Fail("ListStorages", unavailable)
+
+	_, err := client.ListStorages(ctx, "pve1")
+	require.EqualError(t, err, "first")
+	for i := 0; i < 2; i++ {
+		_, err = client.ListStorages(ctx, "pve1")
+		require.ErrorIs(t, err, unavailable)
+	}
+
+	client.Fail("ListStorages", nil)
+	_, err = client.ListStorages(ctx, "pve1")
+	require.NoError(t, err)
+
+	// methods returning only an error fail as well.
+	client.Fail("DeleteSDNVNet", unavailable)
+	require.ErrorIs(t, client.DeleteSDNVNet(ctx, "vnet0"), unavailable)
+}
+
+func TestClient_UnknownMethod(t *testing.T) {
+	client := newTestClient(t)
+
+	require.PanicsWithValue(t, "fake: GetVm is not a method of capmox.Client", func() {
+		client.Fail("GetVm", errors.New("error"))
+	})
+}
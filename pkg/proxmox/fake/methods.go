@@ -0,0 +1,426 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/luthermonson/go-proxmox"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+// AllocateVolume implements capmox.Client.
+func (c *Client) AllocateVolume(ctx context.Context, nodeName, storage string, ownerID int64, filename string, sizeGB int32) (string, error) {
+	return call(c, "AllocateVolume", func() (string, error) {
+		return c.client.AllocateVolume(ctx, nodeName, storage, ownerID, filename, sizeGB)
+	})
+}
+
+// ApplySDN implements capmox.Client.
+func (c *Client) ApplySDN(ctx context.Context) (*proxmox.Task, error) {
+	return call(c, "ApplySDN", func() (*proxmox.Task, error) {
+		return c.client.ApplySDN(ctx)
+	})
+}
+
+// BackupVM implements capmox.Client.
+func (c *Client) BackupVM(ctx context.Context, vm *proxmox.VirtualMachine, opts capmox.BackupOptions) (*proxmox.Task, error) {
+	return call(c, "BackupVM", func() (*proxmox.Task, error) {
+		return c.client.BackupVM(ctx, vm, opts)
+	})
+}
+
+// CloneVM implements capmox.Client.
+func (c *Client) CloneVM(ctx context.Context, templateID int, clone capmox.VMCloneRequest) (capmox.VMCloneResponse, error) {
+	return call(c, "CloneVM", func() (capmox.VMCloneResponse, error) {
+		return c.client.CloneVM(ctx, templateID, clone)
+	})
+}
+
+// ConfigureVM implements capmox.Client.
+func (c *Client) ConfigureVM(ctx context.Context, vm *proxmox.VirtualMachine, options ...capmox.VirtualMachineOption) (*proxmox.Task, error) {
+	return call(c, "ConfigureVM", func() (*proxmox.Task, error) {
+		return c.client.ConfigureVM(ctx, vm, options...)
+	})
+}
+
+// CreateBackupJob implements capmox.Client.
+func (c *Client) CreateBackupJob(ctx context.Context, job capmox.BackupJob) error {
+	_, err := call(c, "CreateBackupJob", func() (struct{}, error) {
+		return struct{}{}, c.client.CreateBackupJob(ctx, job)
+	})
+	return err
+}
+
+// CreateSDNVNet implements capmox.Client.
+func (c *Client) CreateSDNVNet(ctx context.Context, vnet capmox.SDNVNet, subnets ...capmox.SDNSubnet) error {
+	_, err := call(c, "CreateSDNVNet", func() (struct{}, error) {
+		return struct{}{}, c.client.CreateSDNVNet(ctx, vnet, subnets...)
+	})
+	return err
+}
+
+// CreateSnapshot implements capmox.Client.
+func (c *Client) CreateSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string, opts capmox.SnapshotOptions) (*proxmox.Task, error) {
+	return call(c, "CreateSnapshot", func() (*proxmox.Task, error) {
+		return c.client.CreateSnapshot(ctx, vm, name, opts)
+	})
+}
+
+// CreateVM implements capmox.Client.
+func (c *Client) CreateVM(ctx context.Context, nodeName string, options ...capmox.VirtualMachineOption) (capmox.VMCloneResponse, error) {
+	return call(c, "CreateVM", func() (capmox.VMCloneResponse, error) {
+		return c.client.CreateVM(ctx, nodeName, options...)
+	})
+}
+
+// DeleteBackupJob implements capmox.Client.
+func (c *Client) DeleteBackupJob(ctx context.Context, id string) error {
+	_, err := call(c, "DeleteBackupJob", func() (struct{}, error) {
+		return struct{}{}, c.client.DeleteBackupJob(ctx, id)
+	})
+	return err
+}
+
+// DeleteSDNVNet implements capmox.Client.
+func (c *Client) DeleteSDNVNet(ctx context.Context, name string) error {
+	_, err := call(c, "DeleteSDNVNet", func() (struct{}, error) {
+		return struct{}{}, c.client.DeleteSDNVNet(ctx, name)
+	})
+	return err
+}
+
+// DeleteSnapshot implements capmox.Client.
+func (c *Client) DeleteSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string) (*proxmox.Task, error) {
+	return call(c, "DeleteSnapshot", func() (*proxmox.Task, error) {
+		return c.client.DeleteSnapshot(ctx, vm, name)
+	})
+}
+
+// DeleteVM implements capmox.Client.
+func (c *Client) DeleteVM(ctx context.Context, nodeName string, vmID int64, opts capmox.VMDeleteOptions) (*proxmox.Task, error) {
+	return call(c, "DeleteVM", func() (*proxmox.Task, error) {
+		return c.client.DeleteVM(ctx, nodeName, vmID, opts)
+	})
+}
+
+// DeleteVolume implements capmox.Client.
+func (c *Client) DeleteVolume(ctx context.Context, nodeName, volume string) (*proxmox.Task, error) {
+	return call(c, "DeleteVolume", func() (*proxmox.Task, error) {
+		return c.client.DeleteVolume(ctx, nodeName, volume)
+	})
+}
+
+// DownloadImage implements capmox.Client.
+func (c *Client) DownloadImage(ctx context.Context, nodeName, storage string, opts capmox.ImageDownloadOptions) (*proxmox.Task, error) {
+	return call(c, "DownloadImage", func() (*proxmox.Task, error) {
+		return c.client.DownloadImage(ctx, nodeName, storage, opts)
+	})
+}
+
+// FindVMResource implements capmox.Client.
+func (c *Client) FindVMResource(ctx context.Context, vmID uint64) (*proxmox.ClusterResource, error) {
+	return call(c, "FindVMResource", func() (*proxmox.ClusterResource, error) {
+		return c.client.FindVMResource(ctx, vmID)
+	})
+}
+
+// GetAPIStatus implements capmox.Client.
+func (c *Client) GetAPIStatus(ctx context.Context) (capmox.APIStatus, error) {
+	return call(c, "GetAPIStatus", func() (capmox.APIStatus, error) {
+		return c.client.GetAPIStatus(ctx)
+	})
+}
+
+// GetGuestCommandStatus implements capmox.Client.
+func (c *Client) GetGuestCommandStatus(ctx context.Context, vm *proxmox.VirtualMachine, pid int64) (capmox.GuestCommandStatus, error) {
+	return call(c, "GetGuestCommandStatus", func() (capmox.GuestCommandStatus, error) {
+		return c.client.GetGuestCommandStatus(ctx, vm, pid)
+	})
+}
+
+// GetGuestNetworkInterfaces implements capmox.Client.
+func (c *Client) GetGuestNetworkInterfaces(ctx context.Context, vm *proxmox.VirtualMachine) ([]capmox.GuestNetworkInterface, error) {
+	return call(c, "GetGuestNetworkInterfaces", func() ([]capmox.GuestNetworkInterface, error) {
+		return c.client.GetGuestNetworkInterfaces(ctx, vm)
+	})
+}
+
+// GetNodeInventories implements capmox.Client.
+func (c *Client) GetNodeInventories(ctx context.Context) ([]capmox.NodeInventory, error) {
+	return call(c, "GetNodeInventories", func() ([]capmox.NodeInventory, error) {
+		return c.client.GetNodeInventories(ctx)
+	})
+}
+
+// GetNodeMemory implements capmox.Client.
+func (c *Client) GetNodeMemory(ctx context.Context, nodeName string, accounting infrav1alpha1.MemoryAccounting) (capmox.NodeMemory, error) {
+	return call(c, "GetNodeMemory", func() (capmox.NodeMemory, error) {
+		return c.client.GetNodeMemory(ctx, nodeName, accounting)
+	})
+}
+
+// GetNodeSummaries implements capmox.Client.
+func (c *Client) GetNodeSummaries(ctx context.Context) ([]capmox.NodeSummary, error) {
+	return call(c, "GetNodeSummaries", func() ([]capmox.NodeSummary, error) {
+		return c.client.GetNodeSummaries(ctx)
+	})
+}
+
+// GetPendingChanges implements capmox.Client.
+func (c *Client) GetPendingChanges(ctx context.Context, vm *proxmox.VirtualMachine) ([]string, error) {
+	return call(c, "GetPendingChanges", func() ([]string, error) {
+		return c.client.GetPendingChanges(ctx, vm)
+	})
+}
+
+// GetPoolNodes implements capmox.Client.
+func (c *Client) GetPoolNodes(ctx context.Context, pool string) ([]string, error) {
+	return call(c, "GetPoolNodes", func() ([]string, error) {
+		return c.client.GetPoolNodes(ctx, pool)
+	})
+}
+
+// GetStorage implements capmox.Client.
+func (c *Client) GetStorage(ctx context.Context, nodeName, storage string) (capmox.StorageInfo, error) {
+	return call(c, "GetStorage", func() (capmox.StorageInfo, error) {
+		return c.client.GetStorage(ctx, nodeName, storage)
+	})
+}
+
+// GetTask implements capmox.Client.
+func (c *Client) GetTask(ctx context.Context, upID string) (*proxmox.Task, error) {
+	return call(c, "GetTask", func() (*proxmox.Task, error) {
+		return c.client.GetTask(ctx, upID)
+	})
+}
+
+// GetVM implements capmox.Client.
+func (c *Client) GetVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.VirtualMachine, error) {
+	return call(c, "GetVM", func() (*proxmox.VirtualMachine, error) {
+		return c.client.GetVM(ctx, nodeName, vmID)
+	})
+}
+
+// GetVMArchitecture implements capmox.Client.
+func (c *Client) GetVMArchitecture(ctx context.Context, vm *proxmox.VirtualMachine) (string, error) {
+	return call(c, "GetVMArchitecture", func() (string, error) {
+		return c.client.GetVMArchitecture(ctx, vm)
+	})
+}
+
+// GetVMByID implements capmox.Client.
+func (c *Client) GetVMByID(ctx context.Context, vmID int64) (*proxmox.VirtualMachine, error) {
+	return call(c, "GetVMByID", func() (*proxmox.VirtualMachine, error) {
+		return c.client.GetVMByID(ctx, vmID)
+	})
+}
+
+// GetVMFirewall implements capmox.Client.
+func (c *Client) GetVMFirewall(ctx context.Context, vm *proxmox.VirtualMachine) (capmox.VMFirewall, error) {
+	return call(c, "GetVMFirewall", func() (capmox.VMFirewall, error) {
+		return c.client.GetVMFirewall(ctx, vm)
+	})
+}
+
+// HibernateVM implements capmox.Client.
+func (c *Client) HibernateVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return call(c, "HibernateVM", func() (*proxmox.Task, error) {
+		return c.client.HibernateVM(ctx, vm)
+	})
+}
+
+// ListBackupJobs implements capmox.Client.
+func (c *Client) ListBackupJobs(ctx context.Context) ([]capmox.BackupJob, error) {
+	return call(c, "ListBackupJobs", func() ([]capmox.BackupJob, error) {
+		return c.client.ListBackupJobs(ctx)
+	})
+}
+
+// ListBridges implements capmox.Client.
+func (c *Client) ListBridges(ctx context.Context, nodeName string) ([]string, error) {
+	return call(c, "ListBridges", func() ([]string, error) {
+		return c.client.ListBridges(ctx, nodeName)
+	})
+}
+
+// ListSDNVNets implements capmox.Client.
+func (c *Client) ListSDNVNets(ctx context.Context) ([]capmox.SDNVNet, error) {
+	return call(c, "ListSDNVNets", func() ([]capmox.SDNVNet, error) {
+		return c.client.ListSDNVNets(ctx)
+	})
+}
+
+// ListSDNZones implements capmox.Client.
+func (c *Client) ListSDNZones(ctx context.Context) ([]capmox.SDNZone, error) {
+	return call(c, "ListSDNZones", func() ([]capmox.SDNZone, error) {
+		return c.client.ListSDNZones(ctx)
+	})
+}
+
+// ListSnapshots implements capmox.Client.
+func (c *Client) ListSnapshots(ctx context.Context, vm *proxmox.VirtualMachine) ([]*proxmox.Snapshot, error) {
+	return call(c, "ListSnapshots", func() ([]*proxmox.Snapshot, error) {
+		return c.client.ListSnapshots(ctx, vm)
+	})
+}
+
+// ListStorages implements capmox.Client.
+func (c *Client) ListStorages(ctx context.Context, nodeName string) ([]capmox.StorageInfo, error) {
+	return call(c, "ListStorages", func() ([]capmox.StorageInfo, error) {
+		return c.client.ListStorages(ctx, nodeName)
+	})
+}
+
+// ListVMResources implements capmox.Client.
+func (c *Client) ListVMResources(ctx context.Context) ([]*proxmox.ClusterResource, error) {
+	return call(c, "ListVMResources", func() ([]*proxmox.ClusterResource, error) {
+		return c.client.ListVMResources(ctx)
+	})
+}
+
+// ListVolumes implements capmox.Client.
+func (c *Client) ListVolumes(ctx context.Context, nodeName, storage, content string) ([]string, error) {
+	return call(c, "ListVolumes", func() ([]string, error) {
+		return c.client.ListVolumes(ctx, nodeName, storage, content)
+	})
+}
+
+// MigrateVM implements capmox.Client.
+func (c *Client) MigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, targetNode string, online bool) (*proxmox.Task, error) {
+	return call(c, "MigrateVM", func() (*proxmox.Task, error) {
+		return c.client.MigrateVM(ctx, vm, targetNode, online)
+	})
+}
+
+// PingGuestAgent implements capmox.Client.
+func (c *Client) PingGuestAgent(ctx context.Context, vm *proxmox.VirtualMachine) error {
+	_, err := call(c, "PingGuestAgent", func() (struct{}, error) {
+		return struct{}{}, c.client.PingGuestAgent(ctx, vm)
+	})
+	return err
+}
+
+// ReadGuestFile implements capmox.Client.
+func (c *Client) ReadGuestFile(ctx context.Context, vm *proxmox.VirtualMachine, path string) (string, error) {
+	return call(c, "ReadGuestFile", func() (string, error) {
+		return c.client.ReadGuestFile(ctx, vm, path)
+	})
+}
+
+// RebootVM implements capmox.Client.
+func (c *Client) RebootVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return call(c, "RebootVM", func() (*proxmox.Task, error) {
+		return c.client.RebootVM(ctx, vm)
+	})
+}
+
+// RemoteMigrateVM implements capmox.Client.
+func (c *Client) RemoteMigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, opts capmox.RemoteMigrateOptions) (*proxmox.Task, error) {
+	return call(c, "RemoteMigrateVM", func() (*proxmox.Task, error) {
+		return c.client.RemoteMigrateVM(ctx, vm, opts)
+	})
+}
+
+// ResizeDisk implements capmox.Client.
+func (c *Client) ResizeDisk(ctx context.Context, vm *proxmox.VirtualMachine, disk, size string) error {
+	_, err := call(c, "ResizeDisk", func() (struct{}, error) {
+		return struct{}{}, c.client.ResizeDisk(ctx, vm, disk, size)
+	})
+	return err
+}
+
+// ResumeVM implements capmox.Client.
+func (c *Client) ResumeVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return call(c, "ResumeVM", func() (*proxmox.Task, error) {
+		return c.client.ResumeVM(ctx, vm)
+	})
+}
+
+// RollbackSnapshot implements capmox.Client.
+func (c *Client) RollbackSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string) (*proxmox.Task, error) {
+	return call(c, "RollbackSnapshot", func() (*proxmox.Task, error) {
+		return c.client.RollbackSnapshot(ctx, vm, name)
+	})
+}
+
+// SetVMFirewall implements capmox.Client.
+func (c *Client) SetVMFirewall(ctx context.Context, vm *proxmox.VirtualMachine, firewall capmox.VMFirewall) error {
+	_, err := call(c, "SetVMFirewall", func() (struct{}, error) {
+		return struct{}{}, c.client.SetVMFirewall(ctx, vm, firewall)
+	})
+	return err
+}
+
+// ShutdownVM implements capmox.Client.
+func (c *Client) ShutdownVM(ctx context.Context, vm *proxmox.VirtualMachine, opts capmox.VMStopOptions) (*proxmox.Task, error) {
+	return call(c, "ShutdownVM", func() (*proxmox.Task, error) {
+		return c.client.ShutdownVM(ctx, vm, opts)
+	})
+}
+
+// StartGuestCommand implements capmox.Client.
+func (c *Client) StartGuestCommand(ctx context.Context, vm *proxmox.VirtualMachine, command []string) (int64, error) {
+	return call(c, "StartGuestCommand", func() (int64, error) {
+		return c.client.StartGuestCommand(ctx, vm, command)
+	})
+}
+
+// StartVM implements capmox.Client.
+func (c *Client) StartVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return call(c, "StartVM", func() (*proxmox.Task, error) {
+		return c.client.StartVM(ctx, vm)
+	})
+}
+
+// SuspendVM implements capmox.Client.
+func (c *Client) SuspendVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return call(c, "SuspendVM", func() (*proxmox.Task, error) {
+		return c.client.SuspendVM(ctx, vm)
+	})
+}
+
+// TagVM implements capmox.Client.
+func (c *Client) TagVM(ctx context.Context, vm *proxmox.VirtualMachine, tag string) (*proxmox.Task, error) {
+	return call(c, "TagVM", func() (*proxmox.Task, error) {
+		return c.client.TagVM(ctx, vm, tag)
+	})
+}
+
+// UpdateBackupJob implements capmox.Client.
+func (c *Client) UpdateBackupJob(ctx context.Context, job capmox.BackupJob) error {
+	_, err := call(c, "UpdateBackupJob", func() (struct{}, error) {
+		return struct{}{}, c.client.UpdateBackupJob(ctx, job)
+	})
+	return err
+}
+
+// UploadISO implements capmox.Client.
+func (c *Client) UploadISO(ctx context.Context, nodeName, storage, filename string, iso []byte) (*proxmox.Task, error) {
+	return call(c, "UploadISO", func() (*proxmox.Task, error) {
+		return c.client.UploadISO(ctx, nodeName, storage, filename, iso)
+	})
+}
+
+// WaitForTask implements capmox.Client.
+func (c *Client) WaitForTask(ctx context.Context, upID string, opts capmox.TaskWaitOptions) (*proxmox.Task, error) {
+	return call(c, "WaitForTask", func() (*proxmox.Task, error) {
+		return c.client.WaitForTask(ctx, upID, opts)
+	})
+}
@@ -19,21 +19,44 @@ package goproxmox
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/luthermonson/go-proxmox"
 
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
 )
 
 var _ capmox.Client = &APIClient{}
 
+// DefaultResourceCacheTTL is the duration for which the VM resources of the cluster are reused.
+const DefaultResourceCacheTTL = 10 * time.Second
+
 // APIClient Proxmox API client object.
 type APIClient struct {
 	*proxmox.Client
 	logger logr.Logger
+
+	// ResourceCacheTTL is the duration for which the listed VM resources of the cluster are
+	// reused by later calls, as the Proxmox API neither filters nor pages them. Zero disables caching.
+	ResourceCacheTTL time.Duration
+
+	resourcesMu      sync.Mutex
+	resources        []*proxmox.ClusterResource
+	resourceIndex    map[uint64]*proxmox.ClusterResource
+	resourcesFetched time.Time
+	now              func() time.Time
 }
 
 // NewAPIClient initializes a Proxmox API client. If the client is misconfigured, an error is returned.
@@ -53,8 +76,10 @@ func NewAPIClient(ctx context.Context, logger logr.Logger, baseURL string, optio
 	logger.Info("Proxmox server", "version", version.Release)
 
 	return &APIClient{
-		Client: upstreamClient,
-		logger: logger,
+		Client:           upstreamClient,
+		logger:           logger,
+		ResourceCacheTTL: DefaultResourceCacheTTL,
+		now:              time.Now,
 	}, nil
 }
 
@@ -87,6 +112,7 @@ func (c *APIClient) CloneVM(ctx context.Context, templateID int, clone capmox.VM
 	if err != nil {
 		return capmox.VMCloneResponse{}, fmt.Errorf("unable to create new vm: %w", err)
 	}
+	c.invalidateResources()
 
 	return capmox.VMCloneResponse{NewID: int64(newID), Task: task}, nil
 }
@@ -100,6 +126,31 @@ func (c *APIClient) ConfigureVM(ctx context.Context, vm *proxmox.VirtualMachine,
 	return task, nil
 }
 
+// CreateVM creates a VM with the next free VMID on the node and the given options, without cloning a template.
+func (c *APIClient) CreateVM(ctx context.Context, nodeName string, options ...capmox.VirtualMachineOption) (capmox.VMCloneResponse, error) {
+	var nextID string
+	if err := c.Client.Get(ctx, "/cluster/nextid", &nextID); err != nil {
+		return capmox.VMCloneResponse{}, fmt.Errorf("cannot get next free vmid: %w", err)
+	}
+	vmID, err := strconv.ParseInt(nextID, 10, 64)
+	if err != nil {
+		return capmox.VMCloneResponse{}, fmt.Errorf("invalid vmid %q: %w", nextID, err)
+	}
+
+	params := map[string]any{"vmid": vmID}
+	for _, option := range options {
+		params[option.Name] = option.Value
+	}
+
+	var upid proxmox.UPID
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/qemu", nodeName), params, &upid); err != nil {
+		return capmox.VMCloneResponse{}, fmt.Errorf("unable to create new vm: %w", err)
+	}
+	c.invalidateResources()
+
+	return capmox.VMCloneResponse{NewID: vmID, Task: proxmox.NewTask(upid, c.Client)}, nil
+}
+
 // GetVM returns a VM based on nodeName and vmID.
 func (c *APIClient) GetVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.VirtualMachine, error) {
 	node, err := c.Node(ctx, nodeName)
@@ -117,27 +168,101 @@ func (c *APIClient) GetVM(ctx context.Context, nodeName string, vmID int64) (*pr
 
 // FindVMResource tries to find a VM by its ID on the whole cluster.
 func (c *APIClient) FindVMResource(ctx context.Context, vmID uint64) (*proxmox.ClusterResource, error) {
+	_, index, err := c.indexedResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, ok := index[vmID]
+	if !ok {
+		return nil, fmt.Errorf("unable to find VM with ID %d on any of the nodes", vmID)
+	}
+	r := *resource
+	return &r, nil
+}
+
+// GetVMByID returns the VM with the ID on any node of the cluster. Its node is looked up in the index of the
+// cluster resources, which is refreshed once if the VM is not on the indexed node, e.g. since it was migrated.
+func (c *APIClient) GetVMByID(ctx context.Context, vmID int64) (*proxmox.VirtualMachine, error) {
+	if resource, err := c.FindVMResource(ctx, uint64(vmID)); err == nil {
+		if vm, err := c.GetVM(ctx, resource.Node, vmID); err == nil {
+			return vm, nil
+		}
+	}
+
+	c.invalidateResources()
+	_, index, err := c.indexedResources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the resources of the cluster to find vm %d: %w", vmID, err)
+	}
+	resource, ok := index[uint64(vmID)]
+	if !ok {
+		// the error reads like the one Proxmox returns for a VM missing on a node.
+		return nil, fmt.Errorf("vm %d does not exist on any node of the cluster", vmID)
+	}
+	return c.GetVM(ctx, resource.Node, vmID)
+}
+
+// ListVMResources lists the VMs and templates on all nodes of the cluster.
+// The list is reused for the ResourceCacheTTL, and concurrent calls share a single request.
+func (c *APIClient) ListVMResources(ctx context.Context) ([]*proxmox.ClusterResource, error) {
+	resources, _, err := c.indexedResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return copyResources(resources), nil
+}
+
+// indexedResources returns the VM resources of the cluster with their index by VMID, which callers must not modify.
+func (c *APIClient) indexedResources(ctx context.Context) ([]*proxmox.ClusterResource, map[uint64]*proxmox.ClusterResource, error) {
+	c.resourcesMu.Lock()
+	defer c.resourcesMu.Unlock()
+
+	if c.resources != nil && c.now().Sub(c.resourcesFetched) < c.ResourceCacheTTL {
+		return c.resources, c.resourceIndex, nil
+	}
+
 	cluster, err := c.Cluster(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get cluster status: %w", err)
+		return nil, nil, fmt.Errorf("cannot get cluster status: %w", err)
 	}
 
 	vmResources, err := cluster.Resources(ctx, "vm")
 	if err != nil {
-		return nil, fmt.Errorf("could not list vm resources: %w", err)
+		return nil, nil, fmt.Errorf("could not list vm resources: %w", err)
 	}
 
-	for _, vm := range vmResources {
-		if vm.VMID == vmID {
-			return vm, nil
-		}
+	index := make(map[uint64]*proxmox.ClusterResource, len(vmResources))
+	for _, resource := range vmResources {
+		index[resource.VMID] = resource
+	}
+
+	if c.ResourceCacheTTL > 0 {
+		c.resources, c.resourceIndex, c.resourcesFetched = vmResources, index, c.now()
 	}
+	return vmResources, index, nil
+}
 
-	return nil, fmt.Errorf("unable to find VM with ID %d on any of the nodes", vmID)
+// invalidateResources drops the cached VM resources after VMs were created or deleted.
+func (c *APIClient) invalidateResources() {
+	c.resourcesMu.Lock()
+	defer c.resourcesMu.Unlock()
+
+	c.resources, c.resourceIndex = nil, nil
+}
+
+// copyResources copies the resources, so callers can not modify the cache.
+func copyResources(resources []*proxmox.ClusterResource) []*proxmox.ClusterResource {
+	copied := make([]*proxmox.ClusterResource, 0, len(resources))
+	for _, resource := range resources {
+		r := *resource
+		copied = append(copied, &r)
+	}
+	return copied
 }
 
 // DeleteVM deletes a VM based on the nodeName and vmID.
-func (c *APIClient) DeleteVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.Task, error) {
+func (c *APIClient) DeleteVM(ctx context.Context, nodeName string, vmID int64, opts capmox.VMDeleteOptions) (*proxmox.Task, error) {
 	node, err := c.Node(ctx, nodeName)
 	if err != nil {
 		return nil, fmt.Errorf("cannot find node with name %s: %w", nodeName, err)
@@ -149,17 +274,94 @@ func (c *APIClient) DeleteVM(ctx context.Context, nodeName string, vmID int64) (
 	}
 
 	if vm.IsRunning() {
-		if _, err = vm.Stop(ctx); err != nil {
+		stopTask, err := c.ShutdownVM(ctx, vm, opts.Stop)
+		if err != nil {
+			return nil, fmt.Errorf("cannot stop vm id %d: %w", vmID, err)
+		}
+		// proxmox refuses to delete a VM which is still running.
+		if _, err := c.WaitForTask(ctx, string(stopTask.UPID), capmox.TaskWaitOptions{}); err != nil {
 			return nil, fmt.Errorf("cannot stop vm id %d: %w", vmID, err)
 		}
 	}
 
-	task, err := vm.Delete(ctx)
-	if err != nil {
+	// vm.Delete does not support any options, and only finds the cloud-init ISO on the first ISO storage.
+	if err := c.deleteCloudInitISO(ctx, vm); err != nil {
+		return nil, fmt.Errorf("cannot delete cloud-init iso of vm with id %d: %w", vmID, err)
+	}
+
+	params := url.Values{}
+	if opts.Purge {
+		params.Set("purge", "1")
+	}
+	if opts.DestroyUnreferencedDisks {
+		params.Set("destroy-unreferenced-disks", "1")
+	}
+
+	path := fmt.Sprintf("/nodes/%s/qemu/%d", nodeName, vmID)
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var upid proxmox.UPID
+	if err := c.Client.Delete(ctx, path, &upid); err != nil {
 		return nil, fmt.Errorf("cannot delete vm with id %d: %w", vmID, err)
 	}
+	c.invalidateResources()
 
-	return task, nil
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// deleteCloudInitISO deletes the ISO uploaded by vm.CloudInit, if there is one.
+func (c *APIClient) deleteCloudInitISO(ctx context.Context, vm *proxmox.VirtualMachine) error {
+	if !vm.HasTag(proxmox.MakeTag(proxmox.TagCloudInit)) {
+		return nil
+	}
+
+	node, err := c.Node(ctx, vm.Node)
+	if err != nil {
+		return err
+	}
+
+	isoName := fmt.Sprintf(proxmox.UserDataISOFormat, vm.VMID)
+	var storage *proxmox.Storage
+	if name := cloudInitISOStorage(vm, isoName); name != "" {
+		storage, err = node.Storage(ctx, name)
+	} else {
+		// the iso is not attached anymore, it was uploaded to the first iso storage by default.
+		storage, err = node.StorageISO(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	iso, err := storage.ISO(ctx, isoName)
+	if err != nil {
+		// the iso is gone already.
+		return nil
+	}
+
+	task, err := iso.Delete(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = c.WaitForTask(ctx, string(task.UPID), capmox.TaskWaitOptions{})
+	return err
+}
+
+// cloudInitISOStorage returns the storage of the cloud-init ISO attached to one of the IDE or SATA devices of the VM.
+func cloudInitISOStorage(vm *proxmox.VirtualMachine, isoName string) string {
+	if vm.VirtualMachineConfig == nil {
+		return ""
+	}
+	config := vm.VirtualMachineConfig
+	devices := []string{config.IDE0, config.IDE1, config.IDE2, config.IDE3,
+		config.SATA0, config.SATA1, config.SATA2, config.SATA3, config.SATA4, config.SATA5}
+	for _, device := range devices {
+		volume, _, _ := strings.Cut(device, ",")
+		if storage, ok := strings.CutSuffix(volume, ":iso/"+isoName); ok {
+			return storage
+		}
+	}
+	return ""
 }
 
 // GetTask returns a task associated with upID.
@@ -174,29 +376,581 @@ func (c *APIClient) GetTask(ctx context.Context, upID string) (*proxmox.Task, er
 	return task, nil
 }
 
-// GetReservableMemoryBytes returns the memory that can be reserved by a new VM, in bytes.
-func (c *APIClient) GetReservableMemoryBytes(ctx context.Context, nodeName string) (uint64, error) {
-	node, err := c.Client.Node(ctx, nodeName)
+// WaitForTask polls the task with the given UPID until it has completed or the context is cancelled.
+// The lines of the task log are logged at debug level while waiting. If the task failed,
+// it is returned along with an error wrapping capmox.ErrTaskFailed.
+func (c *APIClient) WaitForTask(ctx context.Context, upID string, opts capmox.TaskWaitOptions) (*proxmox.Task, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = capmox.DefaultTaskWaitInterval
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// prefer the logger of the reconciliation, which identifies the object waiting for the task.
+	logger, err := logr.FromContext(ctx)
+	if err != nil {
+		logger = c.logger
+	}
+	logger = logger.WithValues("upid", upID)
+
+	task := proxmox.NewTask(proxmox.UPID(upID), c.Client)
+	if task == nil {
+		return nil, fmt.Errorf("cannot wait for task: empty UPID")
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	logLine := 0
+	for {
+		if err := task.Ping(ctx); err != nil {
+			return nil, fmt.Errorf("cannot get task with UPID %s: %w", upID, err)
+		}
+
+		// the log is only of interest for debugging, failing to get it does not fail the wait.
+		if lines, err := task.Log(ctx, logLine, 50); err == nil {
+			for ; ; logLine++ {
+				line, ok := lines[logLine]
+				if !ok {
+					break
+				}
+				logger.V(4).Info("task log", "line", line)
+			}
+		}
+
+		if task.IsCompleted {
+			if task.IsFailed {
+				return task, fmt.Errorf("%w: task %s exited with %q", capmox.ErrTaskFailed, upID, task.ExitStatus)
+			}
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for task with UPID %s: %w", upID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetPoolNodes returns the sorted names of all nodes hosting a member of the given pool.
+func (c *APIClient) GetPoolNodes(ctx context.Context, pool string) ([]string, error) {
+	p, err := c.Client.Pool(ctx, pool)
 	if err != nil {
-		return 0, fmt.Errorf("cannot find node with name %s: %w", nodeName, err)
+		return nil, fmt.Errorf("cannot get pool %s: %w", pool, err)
 	}
 
-	reservableMemory := node.Memory.Total
+	seen := make(map[string]struct{})
+	var nodes []string
+	for _, member := range p.Members {
+		if _, ok := seen[member.Node]; ok || member.Node == "" {
+			continue
+		}
+		seen[member.Node] = struct{}{}
+		nodes = append(nodes, member.Node)
+	}
+	sort.Strings(nodes)
+
+	return nodes, nil
+}
+
+// nodeStatus contains the parts of a node's status which are not exposed by go-proxmox.
+type nodeStatus struct {
+	CPUInfo struct {
+		Model string `json:"model"`
+		CPUs  int    `json:"cpus"`
+	} `json:"cpuinfo"`
+	Memory     proxmox.Memory `json:"memory"`
+	PVEVersion string         `json:"pveversion"`
+}
+
+// nodeConfig contains the parts of a node's config relevant for the inventory.
+type nodeConfig struct {
+	Description string `json:"description,omitempty"`
+}
+
+// GetNodeInventories returns the inventory of all online nodes, sorted by name.
+func (c *APIClient) GetNodeInventories(ctx context.Context) ([]capmox.NodeInventory, error) {
+	nodes, err := c.Client.Nodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list nodes: %w", err)
+	}
+
+	inventories := make([]capmox.NodeInventory, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Status != "online" {
+			continue
+		}
+
+		var status nodeStatus
+		if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/status", node.Node), &status); err != nil {
+			return nil, fmt.Errorf("cannot get status of node %s: %w", node.Node, err)
+		}
+
+		var config nodeConfig
+		if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/config", node.Node), &config); err != nil {
+			return nil, fmt.Errorf("cannot get config of node %s: %w", node.Node, err)
+		}
+
+		storages, err := c.ListStorages(ctx, node.Node)
+		if err != nil {
+			return nil, err
+		}
+
+		inventories = append(inventories, capmox.NodeInventory{
+			Name:        node.Node,
+			CPUModel:    status.CPUInfo.Model,
+			CPUs:        status.CPUInfo.CPUs,
+			MemoryBytes: status.Memory.Total,
+			Tags:        parseNodeTags(config.Description),
+			Storages:    storages,
+		})
+	}
+
+	sort.Slice(inventories, func(i, j int) bool { return inventories[i].Name < inventories[j].Name })
+
+	return inventories, nil
+}
+
+// GetNodeSummaries returns the state and the allocated resources of all nodes, sorted by name.
+func (c *APIClient) GetNodeSummaries(ctx context.Context) ([]capmox.NodeSummary, error) {
+	nodes, err := c.Client.Nodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list nodes: %w", err)
+	}
+
+	summaries := make([]capmox.NodeSummary, 0, len(nodes))
+	for _, node := range nodes {
+		summary := capmox.NodeSummary{Name: node.Node}
+		if node.Status != "online" {
+			summaries = append(summaries, summary)
+			continue
+		}
+
+		var status nodeStatus
+		if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/status", node.Node), &status); err != nil {
+			return nil, fmt.Errorf("cannot get status of node %s: %w", node.Node, err)
+		}
+
+		var vms proxmox.VirtualMachines
+		if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/qemu", node.Node), &vms); err != nil {
+			return nil, fmt.Errorf("cannot list vms for node %s: %w", node.Node, err)
+		}
+
+		summary.Online = true
+		summary.Version = parsePVEVersion(status.PVEVersion)
+		summary.CPUs = status.CPUInfo.CPUs
+		summary.MemoryBytes = status.Memory.Total
+		for _, vm := range vms {
+			// templates can not be started, so they do not occupy any resources.
+			if vm.Template {
+				continue
+			}
+			summary.AllocatedCPUs += vm.CPUs
+			summary.AllocatedMemoryBytes += vm.MaxMem
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+
+	return summaries, nil
+}
+
+// GetAPIStatus probes the version and the cluster status of the Proxmox API.
+func (c *APIClient) GetAPIStatus(ctx context.Context) (capmox.APIStatus, error) {
+	// the version is not fetched with Version(), which caches it in the shared client.
+	var version proxmox.Version
+	if err := c.Client.Get(ctx, "/version", &version); err != nil {
+		return capmox.APIStatus{}, fmt.Errorf("cannot get version: %w", err)
+	}
+
+	cluster, err := c.Client.Cluster(ctx)
+	if err != nil {
+		return capmox.APIStatus{}, fmt.Errorf("cannot get cluster status: %w", err)
+	}
+
+	// standalone nodes do not report a cluster entry, and so do tokens without Sys.Audit.
+	status := capmox.APIStatus{Version: version.Version, Quorate: true}
+	if cluster.Name != "" {
+		status.Clustered = true
+		status.Quorate = cluster.Quorate == 1
+	}
+	return status, nil
+}
+
+// parsePVEVersion extracts the version from the manager version of a node, like `pve-manager/8.1.3/b46aac3b42da5d15`.
+func parsePVEVersion(managerVersion string) string {
+	parts := strings.Split(managerVersion, "/")
+	if len(parts) < 2 {
+		return managerVersion
+	}
+	return parts[1]
+}
+
+// parseNodeTags extracts the tags from the `tags:` line of a node's notes.
+// Tags can be separated by semicolons, commas or spaces, like VM tags.
+func parseNodeTags(description string) []string {
+	for _, line := range strings.Split(description, "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "tags:")
+		if !ok {
+			continue
+		}
+		return strings.FieldsFunc(value, func(r rune) bool {
+			return r == ';' || r == ',' || r == ' '
+		})
+	}
+	return nil
+}
+
+// ListStorages returns the storages available on the node, sorted by name.
+func (c *APIClient) ListStorages(ctx context.Context, nodeName string) ([]capmox.StorageInfo, error) {
+	var storages proxmox.Storages
+	if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/storage", nodeName), &storages); err != nil {
+		return nil, fmt.Errorf("cannot list storages of node %s: %w", nodeName, err)
+	}
+
+	infos := make([]capmox.StorageInfo, 0, len(storages))
+	for _, storage := range storages {
+		infos = append(infos, storageInfo(storage.Name, storage))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	return infos, nil
+}
+
+// ListBridges returns the names of the Linux and Open vSwitch bridges of a node.
+func (c *APIClient) ListBridges(ctx context.Context, nodeName string) ([]string, error) {
+	var interfaces []struct {
+		Iface string `json:"iface"`
+	}
+	if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/network?type=any_bridge", nodeName), &interfaces); err != nil {
+		return nil, fmt.Errorf("cannot list bridges of node %s: %w", nodeName, err)
+	}
+
+	bridges := make([]string, 0, len(interfaces))
+	for _, iface := range interfaces {
+		bridges = append(bridges, iface.Iface)
+	}
+	sort.Strings(bridges)
+
+	return bridges, nil
+}
+
+// GetStorage returns the storage with the given name as seen from the node.
+func (c *APIClient) GetStorage(ctx context.Context, nodeName, storage string) (capmox.StorageInfo, error) {
+	var status proxmox.Storage
+	if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/storage/%s/status", nodeName, storage), &status); err != nil {
+		return capmox.StorageInfo{}, fmt.Errorf("cannot get storage %s of node %s: %w", storage, nodeName, err)
+	}
+	return storageInfo(storage, &status), nil
+}
+
+func storageInfo(name string, storage *proxmox.Storage) capmox.StorageInfo {
+	var content []string
+	for _, c := range strings.Split(storage.Content, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			content = append(content, c)
+		}
+	}
+
+	return capmox.StorageInfo{
+		Name:           name,
+		Type:           storage.Type,
+		Content:        content,
+		Shared:         storage.Shared == 1,
+		Enabled:        storage.Enabled == 1,
+		Active:         storage.Active == 1,
+		TotalBytes:     storage.Total,
+		AvailableBytes: storage.Avail,
+	}
+}
+
+// GetNodeMemory returns the memory of the node and the memory its VMs reserve, in bytes.
+func (c *APIClient) GetNodeMemory(ctx context.Context, nodeName string, accounting infrav1alpha1.MemoryAccounting) (capmox.NodeMemory, error) {
+	node, err := c.Client.Node(ctx, nodeName)
+	if err != nil {
+		return capmox.NodeMemory{}, fmt.Errorf("cannot find node with name %s: %w", nodeName, err)
+	}
 
 	vms, err := node.VirtualMachines(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("cannot list vms for node %s: %w", nodeName, err)
+		return capmox.NodeMemory{}, fmt.Errorf("cannot list vms for node %s: %w", nodeName, err)
 	}
 
+	memory := capmox.NodeMemory{TotalBytes: node.Memory.Total}
 	for _, vm := range vms {
-		if reservableMemory < vm.MaxMem {
-			reservableMemory = 0
-		} else {
-			reservableMemory -= vm.MaxMem
+		reserved, err := c.reservedMemoryBytes(ctx, nodeName, vm, accounting)
+		if err != nil {
+			return capmox.NodeMemory{}, err
+		}
+		memory.ReservedBytes += reserved
+	}
+
+	return memory, nil
+}
+
+// reservedMemoryBytes returns the amount of memory a VM occupies on its node
+// according to the given accounting mode.
+func (c *APIClient) reservedMemoryBytes(ctx context.Context, nodeName string, vm *proxmox.VirtualMachine, accounting infrav1alpha1.MemoryAccounting) (uint64, error) {
+	switch accounting {
+	case infrav1alpha1.MemoryAccountingUsage:
+		// the qmp status is not part of the vm listing, only the plain status is.
+		if vm.Status == proxmox.StatusVirtualMachineRunning {
+			return vm.Mem, nil
+		}
+	case infrav1alpha1.MemoryAccountingBalloonMinimum:
+		var config proxmox.VirtualMachineConfig
+		if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/config", nodeName, uint64(vm.VMID)), &config); err != nil {
+			return 0, fmt.Errorf("cannot get config of vm %d on node %s: %w", uint64(vm.VMID), nodeName, err)
+		}
+
+		// a balloon of 0 disables ballooning, in which case the VM always uses its maximum memory.
+		if balloon := uint64(config.Balloon) * 1024 * 1024; balloon > 0 && balloon < vm.MaxMem {
+			return balloon, nil
+		}
+	}
+
+	return vm.MaxMem, nil
+}
+
+// pendingChange is an entry of the pending config of a VM.
+type pendingChange struct {
+	Key     string `json:"key"`
+	Pending any    `json:"pending,omitempty"`
+	Delete  int    `json:"delete,omitempty"`
+}
+
+// GetPendingChanges returns the config options of the VM which are only applied on the next reboot.
+func (c *APIClient) GetPendingChanges(ctx context.Context, vm *proxmox.VirtualMachine) ([]string, error) {
+	var changes []pendingChange
+	if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/pending", vm.Node, vm.VMID), &changes); err != nil {
+		return nil, fmt.Errorf("cannot get pending changes of vm %d: %w", vm.VMID, err)
+	}
+
+	var keys []string
+	for _, change := range changes {
+		if change.Pending != nil || change.Delete > 0 {
+			keys = append(keys, change.Key)
+		}
+	}
+	return keys, nil
+}
+
+// GetGuestNetworkInterfaces returns the network interfaces the QEMU guest agent reports for the VM,
+// except the loopback interface. It fails if the agent is not running.
+func (c *APIClient) GetGuestNetworkInterfaces(ctx context.Context, vm *proxmox.VirtualMachine) ([]capmox.GuestNetworkInterface, error) {
+	var result map[string][]*proxmox.AgentNetworkIface
+	if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/agent/network-get-interfaces", vm.Node, vm.VMID), &result); err != nil {
+		return nil, fmt.Errorf("cannot get guest network interfaces of vm %d: %w", vm.VMID, err)
+	}
+
+	var ifaces []capmox.GuestNetworkInterface
+	for _, agentIface := range result["result"] {
+		if agentIface.Name == "lo" {
+			continue
+		}
+		iface := capmox.GuestNetworkInterface{Name: agentIface.Name, MACAddress: agentIface.HardwareAddress}
+		for _, address := range agentIface.IPAddresses {
+			iface.IPAddresses = append(iface.IPAddresses, address.IPAddress)
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// GetVMArchitecture returns the CPU architecture of the VM, x86_64 or aarch64.
+// VMs which do not configure it run with the architecture of the node, which is x86_64 for Proxmox VE.
+func (c *APIClient) GetVMArchitecture(ctx context.Context, vm *proxmox.VirtualMachine) (string, error) {
+	var config struct {
+		Arch string `json:"arch,omitempty"`
+	}
+	if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/config", vm.Node, vm.VMID), &config); err != nil {
+		return "", fmt.Errorf("cannot get config of vm %d: %w", vm.VMID, err)
+	}
+
+	if config.Arch == "" {
+		return "x86_64", nil
+	}
+	return config.Arch, nil
+}
+
+// MigrateVM migrates the VM to the target node. A running VM can only be migrated online,
+// in which case local disks are migrated along with it.
+func (c *APIClient) MigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, targetNode string, online bool) (*proxmox.Task, error) {
+	params := map[string]any{
+		"target": targetNode,
+	}
+	if online {
+		params["online"] = 1
+		params["with-local-disks"] = 1
+	}
+
+	var upid proxmox.UPID
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/migrate", vm.Node, vm.VMID), params, &upid); err != nil {
+		return nil, fmt.Errorf("cannot migrate vm %d to node %s: %w", vm.VMID, targetNode, err)
+	}
+	// the cached resources locate the VM on its previous node.
+	c.invalidateResources()
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// RemoteMigrateVM migrates a stopped VM to another Proxmox VE cluster. The task runs on the source cluster.
+func (c *APIClient) RemoteMigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, opts capmox.RemoteMigrateOptions) (*proxmox.Task, error) {
+	params := map[string]any{
+		"target-endpoint": opts.TargetEndpoint,
+		"target-storage":  opts.TargetStorage,
+		"target-bridge":   opts.TargetBridge,
+	}
+	if opts.TargetVMID != 0 {
+		params["target-vmid"] = opts.TargetVMID
+	}
+	if opts.Delete {
+		params["delete"] = 1
+	}
+
+	var upid proxmox.UPID
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/remote_migrate", vm.Node, vm.VMID), params, &upid); err != nil {
+		return nil, fmt.Errorf("cannot migrate vm %d to remote cluster: %w", vm.VMID, err)
+	}
+	c.invalidateResources()
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// currentSnapshot is the name of the entry describing the current state in the list of snapshots.
+const currentSnapshot = "current"
+
+// BackupVM starts a vzdump backup of the VM.
+func (c *APIClient) BackupVM(ctx context.Context, vm *proxmox.VirtualMachine, opts capmox.BackupOptions) (*proxmox.Task, error) {
+	if opts.Storage == "" {
+		return nil, fmt.Errorf("cannot back up vm %d: no storage given", vm.VMID)
+	}
+
+	params := map[string]any{
+		"vmid":    vm.VMID,
+		"storage": opts.Storage,
+		"mode":    string(capmox.BackupModeSnapshot),
+	}
+	if opts.Mode != "" {
+		params["mode"] = string(opts.Mode)
+	}
+	if opts.Compression != "" {
+		params["compress"] = opts.Compression
+	}
+	if opts.Notes != "" {
+		params["notes-template"] = opts.Notes
+	}
+
+	var upid proxmox.UPID
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/vzdump", vm.Node), params, &upid); err != nil {
+		return nil, fmt.Errorf("cannot back up vm %d to storage %s: %w", vm.VMID, opts.Storage, err)
+	}
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// ListSnapshots returns the snapshots of the VM, without the entry of the current state.
+func (c *APIClient) ListSnapshots(ctx context.Context, vm *proxmox.VirtualMachine) ([]*proxmox.Snapshot, error) {
+	all, err := vm.Snapshots(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list snapshots of vm %d: %w", vm.VMID, err)
+	}
+
+	snapshots := make([]*proxmox.Snapshot, 0, len(all))
+	for _, snapshot := range all {
+		if snapshot.Name != currentSnapshot {
+			snapshots = append(snapshots, snapshot)
 		}
 	}
+	return snapshots, nil
+}
+
+// CreateSnapshot creates a snapshot of the VM with the given name.
+func (c *APIClient) CreateSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string, opts capmox.SnapshotOptions) (*proxmox.Task, error) {
+	params := map[string]any{
+		"snapname": name,
+	}
+	if opts.Description != "" {
+		params["description"] = opts.Description
+	}
+	if opts.IncludeMemory {
+		params["vmstate"] = 1
+	}
+
+	var upid proxmox.UPID
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/snapshot", vm.Node, vm.VMID), params, &upid); err != nil {
+		return nil, fmt.Errorf("cannot create snapshot %s of vm %d: %w", name, vm.VMID, err)
+	}
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// DeleteSnapshot deletes the snapshot of the VM with the given name.
+func (c *APIClient) DeleteSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string) (*proxmox.Task, error) {
+	var upid proxmox.UPID
+	if err := c.Client.Delete(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/snapshot/%s", vm.Node, vm.VMID, url.PathEscape(name)), &upid); err != nil {
+		return nil, fmt.Errorf("cannot delete snapshot %s of vm %d: %w", name, vm.VMID, err)
+	}
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// RollbackSnapshot resets the VM to the snapshot with the given name. Unless the snapshot includes
+// the memory of the VM, the VM is stopped afterwards.
+func (c *APIClient) RollbackSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string) (*proxmox.Task, error) {
+	task, err := vm.SnapshotRollback(ctx, url.PathEscape(name))
+	if err != nil {
+		return nil, fmt.Errorf("cannot roll back vm %d to snapshot %s: %w", vm.VMID, name, err)
+	}
+	return task, nil
+}
+
+// StartGuestCommand runs a command inside the VM through the QEMU guest agent and returns its process ID,
+// without waiting for it to exit. It fails if the agent is not running.
+func (c *APIClient) StartGuestCommand(ctx context.Context, vm *proxmox.VirtualMachine, command []string) (int64, error) {
+	var result struct {
+		PID int64 `json:"pid"`
+	}
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/agent/exec", vm.Node, vm.VMID), map[string]any{"command": command}, &result); err != nil {
+		return 0, fmt.Errorf("cannot run %q in vm %d: %w", strings.Join(command, " "), vm.VMID, err)
+	}
+	return result.PID, nil
+}
+
+// GetGuestCommandStatus returns the status of a command started with StartGuestCommand.
+// It fails if the agent is not running, or no longer knows the process, e.g. since the VM rebooted.
+func (c *APIClient) GetGuestCommandStatus(ctx context.Context, vm *proxmox.VirtualMachine, pid int64) (capmox.GuestCommandStatus, error) {
+	// proxmox.AgentExecStatus cannot be used, Proxmox VE reports exited as integer and the exit code as exitcode.
+	var result struct {
+		Exited   proxmox.IntOrBool `json:"exited"`
+		ExitCode int               `json:"exitcode"`
+		OutData  string            `json:"out-data"`
+		ErrData  string            `json:"err-data"`
+	}
+	if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/agent/exec-status?pid=%d", vm.Node, vm.VMID, pid), &result); err != nil {
+		return capmox.GuestCommandStatus{}, fmt.Errorf("cannot get status of process %d in vm %d: %w", pid, vm.VMID, err)
+	}
+	return capmox.GuestCommandStatus{Exited: bool(result.Exited), ExitCode: result.ExitCode, Output: result.OutData, ErrorOutput: result.ErrData}, nil
+}
 
-	return reservableMemory, nil
+// ReadGuestFile reads a file inside the VM through the QEMU guest agent. Proxmox VE returns at most
+// the first 16 MiB of the file. It fails if the agent is not running or the file cannot be read.
+func (c *APIClient) ReadGuestFile(ctx context.Context, vm *proxmox.VirtualMachine, path string) (string, error) {
+	var result struct {
+		Content string `json:"content"`
+	}
+	if err := c.Client.Get(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/agent/file-read?file=%s", vm.Node, vm.VMID, url.QueryEscape(path)), &result); err != nil {
+		return "", fmt.Errorf("cannot read %s in vm %d: %w", path, vm.VMID, err)
+	}
+	return result.Content, nil
+}
+
+// PingGuestAgent pings the QEMU guest agent of the VM. It fails if the agent does not respond.
+func (c *APIClient) PingGuestAgent(ctx context.Context, vm *proxmox.VirtualMachine) error {
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/agent/ping", vm.Node, vm.VMID), nil, nil); err != nil {
+		return fmt.Errorf("cannot ping guest agent of vm %d: %w", vm.VMID, err)
+	}
+	return nil
 }
 
 // ResizeDisk resizes a VM disk to the specified size.
@@ -209,12 +963,498 @@ func (c *APIClient) ResumeVM(ctx context.Context, vm *proxmox.VirtualMachine) (*
 	return vm.Resume(ctx)
 }
 
+// RebootVM reboots the VM by shutting it down and starting it again.
+func (c *APIClient) RebootVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return vm.Reboot(ctx)
+}
+
+// ShutdownVM stops the VM according to the stop mode. In StopModeShutdown, Proxmox VE stops the VM
+// itself once the guest did not shut down within the timeout, so the returned task always ends with
+// the VM being stopped.
+func (c *APIClient) ShutdownVM(ctx context.Context, vm *proxmox.VirtualMachine, opts capmox.VMStopOptions) (*proxmox.Task, error) {
+	switch opts.Mode {
+	case "", capmox.StopModeStop:
+		return vm.Stop(ctx)
+	case capmox.StopModeShutdown:
+	default:
+		return nil, fmt.Errorf("unknown stop mode %q", opts.Mode)
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = capmox.DefaultShutdownTimeout
+	}
+
+	params := map[string]any{
+		"timeout":   int(timeout.Seconds()),
+		"forceStop": 1,
+	}
+	var upid proxmox.UPID
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/qemu/%d/status/shutdown", vm.Node, vm.VMID), params, &upid); err != nil {
+		return nil, fmt.Errorf("cannot shut down vm %d: %w", vm.VMID, err)
+	}
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
 // StartVM starts the VM.
 func (c *APIClient) StartVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
 	return vm.Start(ctx)
 }
 
+// SuspendVM pauses the VM, keeping its state in memory. It is continued with ResumeVM.
+func (c *APIClient) SuspendVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return vm.Pause(ctx)
+}
+
+// HibernateVM suspends the VM to disk and stops it, which frees its memory on the node.
+// It is continued with StartVM.
+func (c *APIClient) HibernateVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return vm.Hibernate(ctx)
+}
+
 // TagVM tags the VM.
 func (c *APIClient) TagVM(ctx context.Context, vm *proxmox.VirtualMachine, tag string) (*proxmox.Task, error) {
 	return vm.AddTag(ctx, tag)
 }
+
+// AllocateVolume allocates a disk image on the storage, which is owned by the given VMID.
+// The VM does not need to exist, which keeps the volume if the VMs it is attached to are destroyed.
+func (c *APIClient) AllocateVolume(ctx context.Context, nodeName, storage string, ownerID int64, filename string, sizeGB int32) (string, error) {
+	params := map[string]any{
+		"vmid":     ownerID,
+		"filename": filename,
+		"size":     fmt.Sprintf("%dG", sizeGB),
+	}
+
+	var volume string
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/storage/%s/content", nodeName, storage), params, &volume); err != nil {
+		return "", fmt.Errorf("cannot allocate volume %s on storage %s of node %s: %w", filename, storage, nodeName, err)
+	}
+	return volume, nil
+}
+
+// DeleteVolume destroys a volume on the storage of a node, regardless of the VMID which owns it.
+func (c *APIClient) DeleteVolume(ctx context.Context, nodeName, volume string) (*proxmox.Task, error) {
+	storage, _, ok := strings.Cut(volume, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid volume %q", volume)
+	}
+
+	var upid proxmox.UPID
+	if err := c.Client.Delete(ctx, fmt.Sprintf("/nodes/%s/storage/%s/content/%s", nodeName, storage, volume), &upid); err != nil {
+		return nil, fmt.Errorf("cannot delete volume %s of node %s: %w", volume, nodeName, err)
+	}
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// ListVolumes returns the volumes of a content type on the storage of a node, for example import or iso.
+func (c *APIClient) ListVolumes(ctx context.Context, nodeName, storage, content string) ([]string, error) {
+	var entries []struct {
+		VolID string `json:"volid"`
+	}
+	path := fmt.Sprintf("/nodes/%s/storage/%s/content?content=%s", nodeName, storage, url.QueryEscape(content))
+	if err := c.Client.Get(ctx, path, &entries); err != nil {
+		return nil, fmt.Errorf("cannot list %s volumes on storage %s of node %s: %w", content, storage, nodeName, err)
+	}
+
+	volumes := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		volumes = append(volumes, entry.VolID)
+	}
+	return volumes, nil
+}
+
+// DownloadImage lets the node download a disk image from a URL to the import content of the storage.
+// Importing disk images requires Proxmox VE 8.2 or newer.
+func (c *APIClient) DownloadImage(ctx context.Context, nodeName, storage string, opts capmox.ImageDownloadOptions) (*proxmox.Task, error) {
+	params := map[string]any{
+		"content":  "import",
+		"url":      opts.URL,
+		"filename": opts.Filename,
+	}
+	if opts.Checksum != "" {
+		params["checksum"] = opts.Checksum
+		params["checksum-algorithm"] = opts.ChecksumAlgorithm
+	}
+
+	var upid proxmox.UPID
+	if err := c.Client.Post(ctx, fmt.Sprintf("/nodes/%s/storage/%s/download-url", nodeName, storage), params, &upid); err != nil {
+		return nil, fmt.Errorf("cannot download image %s to storage %s of node %s: %w", opts.URL, storage, nodeName, err)
+	}
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// UploadISO uploads an ISO image to the storage through the Proxmox API, replacing an image with the same filename.
+// Unlike copying the image to the node, this does not need any access to the node besides the API.
+func (c *APIClient) UploadISO(ctx context.Context, nodeName, storage, filename string, iso []byte) (*proxmox.Task, error) {
+	// the upload is sent as multipart form, which go-proxmox reads from a file named like the image.
+	dir, err := os.MkdirTemp("", "capmox-upload-")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	file, err := os.Create(filepath.Join(dir, filepath.Base(filename)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temporary file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	if _, err := file.Write(iso); err != nil {
+		return nil, fmt.Errorf("cannot write temporary file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// go-proxmox sends the upload without a context, so the upload is abandoned once ctx is done,
+	// and closing the file it streams from aborts sending the request.
+	type result struct {
+		upid proxmox.UPID
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		fields := map[string]string{"content": capmox.StorageContentISO}
+		res.err = c.Client.Upload(fmt.Sprintf("/nodes/%s/storage/%s/upload", nodeName, storage), fields, file, &res.upid)
+		done <- res
+	}()
+
+	select {
+	case <-ctx.Done():
+		_ = file.Close()
+		return nil, fmt.Errorf("cannot upload %s to storage %s of node %s: %w", filename, storage, nodeName, ctx.Err())
+	case res := <-done:
+		if res.err != nil {
+			return nil, fmt.Errorf("cannot upload %s to storage %s of node %s: %w", filename, storage, nodeName, res.err)
+		}
+		return proxmox.NewTask(res.upid, c.Client), nil
+	}
+}
+
+// ListSDNZones lists the zones of the software-defined network.
+func (c *APIClient) ListSDNZones(ctx context.Context) ([]capmox.SDNZone, error) {
+	var zones []struct {
+		Zone string `json:"zone"`
+		Type string `json:"type"`
+	}
+	if err := c.Client.Get(ctx, "/cluster/sdn/zones", &zones); err != nil {
+		return nil, fmt.Errorf("cannot list sdn zones: %w", err)
+	}
+
+	result := make([]capmox.SDNZone, 0, len(zones))
+	for _, zone := range zones {
+		result = append(result, capmox.SDNZone{Name: zone.Zone, Type: zone.Type})
+	}
+	return result, nil
+}
+
+// sdnVNet is a VNet as returned by the Proxmox API.
+type sdnVNet struct {
+	VNet  string `json:"vnet"`
+	Zone  string `json:"zone"`
+	Tag   int    `json:"tag,omitempty"`
+	Alias string `json:"alias,omitempty"`
+}
+
+// ListSDNVNets lists the VNets of the software-defined network.
+func (c *APIClient) ListSDNVNets(ctx context.Context) ([]capmox.SDNVNet, error) {
+	var vnets []sdnVNet
+	if err := c.Client.Get(ctx, "/cluster/sdn/vnets", &vnets); err != nil {
+		return nil, fmt.Errorf("cannot list sdn vnets: %w", err)
+	}
+
+	result := make([]capmox.SDNVNet, 0, len(vnets))
+	for _, vnet := range vnets {
+		result = append(result, capmox.SDNVNet{Name: vnet.VNet, Zone: vnet.Zone, Tag: vnet.Tag, Alias: vnet.Alias})
+	}
+	return result, nil
+}
+
+// CreateSDNVNet creates a VNet with the given subnets. The VNet is only available
+// on the nodes once the pending SDN configuration is applied with ApplySDN.
+func (c *APIClient) CreateSDNVNet(ctx context.Context, vnet capmox.SDNVNet, subnets ...capmox.SDNSubnet) error {
+	params := map[string]any{
+		"vnet": vnet.Name,
+		"zone": vnet.Zone,
+	}
+	if vnet.Tag != 0 {
+		params["tag"] = vnet.Tag
+	}
+	if vnet.Alias != "" {
+		params["alias"] = vnet.Alias
+	}
+	if err := c.Client.Post(ctx, "/cluster/sdn/vnets", params, nil); err != nil {
+		return fmt.Errorf("cannot create sdn vnet %s: %w", vnet.Name, err)
+	}
+
+	for _, subnet := range subnets {
+		params := map[string]any{
+			"subnet": subnet.CIDR,
+			"type":   "subnet",
+		}
+		if subnet.Gateway != "" {
+			params["gateway"] = subnet.Gateway
+		}
+		if err := c.Client.Post(ctx, fmt.Sprintf("/cluster/sdn/vnets/%s/subnets", vnet.Name), params, nil); err != nil {
+			return fmt.Errorf("cannot create subnet %s of sdn vnet %s: %w", subnet.CIDR, vnet.Name, err)
+		}
+	}
+	return nil
+}
+
+// DeleteSDNVNet deletes a VNet and its subnets. The VNet is only removed
+// from the nodes once the pending SDN configuration is applied with ApplySDN.
+func (c *APIClient) DeleteSDNVNet(ctx context.Context, name string) error {
+	// proxmox refuses to delete a VNet which still has subnets.
+	var subnets []struct {
+		Subnet string `json:"subnet"`
+	}
+	if err := c.Client.Get(ctx, fmt.Sprintf("/cluster/sdn/vnets/%s/subnets", name), &subnets); err != nil {
+		return fmt.Errorf("cannot list subnets of sdn vnet %s: %w", name, err)
+	}
+	for _, subnet := range subnets {
+		if err := c.Client.Delete(ctx, fmt.Sprintf("/cluster/sdn/vnets/%s/subnets/%s", name, subnet.Subnet), nil); err != nil {
+			return fmt.Errorf("cannot delete subnet %s of sdn vnet %s: %w", subnet.Subnet, name, err)
+		}
+	}
+
+	if err := c.Client.Delete(ctx, fmt.Sprintf("/cluster/sdn/vnets/%s", name), nil); err != nil {
+		return fmt.Errorf("cannot delete sdn vnet %s: %w", name, err)
+	}
+	return nil
+}
+
+// ApplySDN applies the pending configuration of the software-defined network to all nodes.
+func (c *APIClient) ApplySDN(ctx context.Context) (*proxmox.Task, error) {
+	var upid proxmox.UPID
+	if err := c.Client.Put(ctx, "/cluster/sdn", nil, &upid); err != nil {
+		return nil, fmt.Errorf("cannot apply sdn configuration: %w", err)
+	}
+	return proxmox.NewTask(upid, c.Client), nil
+}
+
+// backupJob is a backup job as returned by the Proxmox API.
+type backupJob struct {
+	ID       string `json:"id"`
+	Schedule string `json:"schedule"`
+	Storage  string `json:"storage"`
+	Mode     string `json:"mode"`
+	VMID     string `json:"vmid"`
+	// PruneBackups is a property string, which some versions of Proxmox VE return decoded.
+	PruneBackups json.RawMessage    `json:"prune-backups,omitempty"`
+	Comment      string             `json:"comment,omitempty"`
+	Enabled      *proxmox.IntOrBool `json:"enabled,omitempty"`
+}
+
+// ListBackupJobs lists the scheduled backup jobs of the cluster.
+func (c *APIClient) ListBackupJobs(ctx context.Context) ([]capmox.BackupJob, error) {
+	var jobs []backupJob
+	if err := c.Client.Get(ctx, "/cluster/backup", &jobs); err != nil {
+		return nil, fmt.Errorf("cannot list backup jobs: %w", err)
+	}
+
+	result := make([]capmox.BackupJob, 0, len(jobs))
+	for _, job := range jobs {
+		vmids := []int64{}
+		for _, id := range strings.Split(job.VMID, ",") {
+			if vmid, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64); err == nil {
+				vmids = append(vmids, vmid)
+			}
+		}
+		result = append(result, capmox.BackupJob{
+			ID:           job.ID,
+			Schedule:     job.Schedule,
+			Storage:      job.Storage,
+			Mode:         capmox.BackupMode(job.Mode),
+			VMIDs:        vmids,
+			PruneBackups: propertyString(job.PruneBackups),
+			Comment:      job.Comment,
+			// jobs are enabled unless disabled explicitly.
+			Enabled: job.Enabled == nil || bool(*job.Enabled),
+		})
+	}
+	return result, nil
+}
+
+// propertyString returns a property string like "keep-daily=7,keep-last=3",
+// which is either returned as is or as an object with the properties.
+func propertyString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var properties map[string]any
+	if err := json.Unmarshal(raw, &properties); err != nil {
+		return ""
+	}
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, properties[key]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// backupJobParams returns the parameters of creating or updating a backup job.
+func backupJobParams(job capmox.BackupJob) map[string]any {
+	vmids := make([]string, 0, len(job.VMIDs))
+	for _, vmid := range job.VMIDs {
+		vmids = append(vmids, strconv.FormatInt(vmid, 10))
+	}
+	params := map[string]any{
+		"schedule": job.Schedule,
+		"storage":  job.Storage,
+		"mode":     string(capmox.BackupModeSnapshot),
+		"vmid":     strings.Join(vmids, ","),
+		"enabled":  0,
+	}
+	if job.Enabled {
+		params["enabled"] = 1
+	}
+	if job.Mode != "" {
+		params["mode"] = string(job.Mode)
+	}
+	if job.PruneBackups != "" {
+		params["prune-backups"] = job.PruneBackups
+	}
+	if job.Comment != "" {
+		params["comment"] = job.Comment
+	}
+	return params
+}
+
+// CreateBackupJob creates a scheduled backup job with the ID of the job.
+func (c *APIClient) CreateBackupJob(ctx context.Context, job capmox.BackupJob) error {
+	params := backupJobParams(job)
+	params["id"] = job.ID
+	if err := c.Client.Post(ctx, "/cluster/backup", params, nil); err != nil {
+		return fmt.Errorf("cannot create backup job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// UpdateBackupJob replaces the settings of a backup job.
+func (c *APIClient) UpdateBackupJob(ctx context.Context, job capmox.BackupJob) error {
+	params := backupJobParams(job)
+	// the retention and comment are only removed if they are deleted explicitly.
+	var deleted []string
+	if job.PruneBackups == "" {
+		deleted = append(deleted, "prune-backups")
+	}
+	if job.Comment == "" {
+		deleted = append(deleted, "comment")
+	}
+	if len(deleted) > 0 {
+		params["delete"] = strings.Join(deleted, ",")
+	}
+	if err := c.Client.Put(ctx, fmt.Sprintf("/cluster/backup/%s", job.ID), params, nil); err != nil {
+		return fmt.Errorf("cannot update backup job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// DeleteBackupJob deletes a scheduled backup job. The existing backups are kept.
+func (c *APIClient) DeleteBackupJob(ctx context.Context, id string) error {
+	if err := c.Client.Delete(ctx, fmt.Sprintf("/cluster/backup/%s", id), nil); err != nil {
+		return fmt.Errorf("cannot delete backup job %s: %w", id, err)
+	}
+	return nil
+}
+
+// vmFirewallPath returns the path of the firewall API of a VM.
+func vmFirewallPath(vm *proxmox.VirtualMachine, elem ...string) string {
+	return strings.Join(append([]string{fmt.Sprintf("/nodes/%s/qemu/%d/firewall", vm.Node, vm.VMID)}, elem...), "/")
+}
+
+// firewallRule is a firewall rule as returned by the Proxmox API.
+type firewallRule struct {
+	Pos     int    `json:"pos"`
+	Type    string `json:"type"`
+	Action  string `json:"action"`
+	Proto   string `json:"proto,omitempty"`
+	DPort   string `json:"dport,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// GetVMFirewall returns the firewall options and rules of a VM.
+func (c *APIClient) GetVMFirewall(ctx context.Context, vm *proxmox.VirtualMachine) (capmox.VMFirewall, error) {
+	var options struct {
+		Enable   int    `json:"enable"`
+		PolicyIn string `json:"policy_in"`
+	}
+	if err := c.Client.Get(ctx, vmFirewallPath(vm, "options"), &options); err != nil {
+		return capmox.VMFirewall{}, fmt.Errorf("cannot get firewall options of vm %d: %w", vm.VMID, err)
+	}
+
+	var rules []firewallRule
+	if err := c.Client.Get(ctx, vmFirewallPath(vm, "rules"), &rules); err != nil {
+		return capmox.VMFirewall{}, fmt.Errorf("cannot list firewall rules of vm %d: %w", vm.VMID, err)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Pos < rules[j].Pos })
+
+	firewall := capmox.VMFirewall{Enable: options.Enable == 1, PolicyIn: options.PolicyIn}
+	for _, rule := range rules {
+		firewall.Rules = append(firewall.Rules, capmox.FirewallRule{
+			Type:    rule.Type,
+			Action:  rule.Action,
+			Proto:   rule.Proto,
+			DPort:   rule.DPort,
+			Source:  rule.Source,
+			Comment: rule.Comment,
+		})
+	}
+	return firewall, nil
+}
+
+// SetVMFirewall sets the firewall options of a VM and replaces its rules.
+func (c *APIClient) SetVMFirewall(ctx context.Context, vm *proxmox.VirtualMachine, firewall capmox.VMFirewall) error {
+	options := map[string]any{"enable": 0}
+	if firewall.Enable {
+		options["enable"] = 1
+	}
+	if firewall.PolicyIn != "" {
+		options["policy_in"] = firewall.PolicyIn
+	}
+	if err := c.Client.Put(ctx, vmFirewallPath(vm, "options"), options, nil); err != nil {
+		return fmt.Errorf("cannot set firewall options of vm %d: %w", vm.VMID, err)
+	}
+
+	var current []firewallRule
+	if err := c.Client.Get(ctx, vmFirewallPath(vm, "rules"), &current); err != nil {
+		return fmt.Errorf("cannot list firewall rules of vm %d: %w", vm.VMID, err)
+	}
+	// the positions of the following rules shift on every deletion.
+	for range current {
+		if err := c.Client.Delete(ctx, vmFirewallPath(vm, "rules", "0"), nil); err != nil {
+			return fmt.Errorf("cannot delete firewall rule of vm %d: %w", vm.VMID, err)
+		}
+	}
+
+	for i, rule := range firewall.Rules {
+		params := map[string]any{
+			"pos":    i,
+			"type":   rule.Type,
+			"action": rule.Action,
+			"enable": 1,
+		}
+		for key, value := range map[string]string{"proto": rule.Proto, "dport": rule.DPort, "source": rule.Source, "comment": rule.Comment} {
+			if value != "" {
+				params[key] = value
+			}
+		}
+		if err := c.Client.Post(ctx, vmFirewallPath(vm, "rules"), params, nil); err != nil {
+			return fmt.Errorf("cannot create firewall rule %d of vm %d: %w", i, vm.VMID, err)
+		}
+	}
+	return nil
+}
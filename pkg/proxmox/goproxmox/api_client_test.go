@@ -18,13 +18,22 @@ package goproxmox
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
 	"github.com/jarcoal/httpmock"
 	"github.com/luthermonson/go-proxmox"
 	"github.com/stretchr/testify/require"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
 )
 
 const testBaseURL = "http://pve.local.test/" // regression test against trailing /
@@ -42,33 +51,880 @@ func newTestClient(t *testing.T) *APIClient {
 	return client
 }
 
+func newSimulatorClient(t *testing.T) (*proxmoxtest.Simulator, *APIClient) {
+	sim := proxmoxtest.NewSimulator()
+	t.Cleanup(sim.Close)
+	sim.AddNode(proxmoxtest.SimulatedNode{Name: "pve1", CPUs: 4, MemoryBytes: 1 << 30})
+
+	client, err := NewAPIClient(context.Background(), logr.Discard(), sim.URL(),
+		proxmox.WithHTTPClient(&http.Client{Transport: &http.Transport{}}))
+	require.NoError(t, err)
+
+	return sim, client
+}
+
+// registerTestVM registers the responses to get the stopped VM 100 on node pve1.
+func registerTestVM() {
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve1/status`,
+		newJSONResponder(200, proxmox.Node{Name: "pve1"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve1/qemu/100/status/current`,
+		newJSONResponder(200, proxmox.VirtualMachine{Node: "pve1", VMID: 100, Status: proxmox.StatusVirtualMachineStopped}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve1/qemu/100/config`,
+		newJSONResponder(200, proxmox.VirtualMachineConfig{Name: "test"}))
+}
+
 func newJSONResponder(status int, data any) httpmock.Responder {
 	return httpmock.NewJsonResponderOrPanic(status, map[string]any{"data": data}).Once()
 }
 
-func TestProxmoxAPIClient_GetReservableMemoryBytes(t *testing.T) {
+func TestProxmoxAPIClient_GetNodeMemory(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
+		newJSONResponder(200, proxmox.Node{Memory: proxmox.Memory{Total: 30}}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu`,
+		newJSONResponder(200, proxmox.VirtualMachines{{MaxMem: 20}, {MaxMem: 25}}))
+
+	// the reserved memory exceeds the memory of an overcommitted node.
+	memory, err := client.GetNodeMemory(context.Background(), "test", infrav1alpha1.MemoryAccountingMaxMemory)
+	require.NoError(t, err)
+	require.Equal(t, capmox.NodeMemory{TotalBytes: 30, ReservedBytes: 45}, memory)
+}
+
+func TestProxmoxAPIClient_GetNodeMemoryAccounting(t *testing.T) {
+	const mib = 1024 * 1024
 	tests := []struct {
-		name   string
-		maxMem uint64
-		expect uint64
+		name       string
+		accounting infrav1alpha1.MemoryAccounting
+		vm         proxmox.VirtualMachine
+		balloon    int
+		expect     uint64
 	}{
-		{name: "under zero", maxMem: 29, expect: 1},
-		{name: "exact zero", maxMem: 30, expect: 0},
-		{name: "over zero", maxMem: 31, expect: 0},
+		{name: "max memory", accounting: infrav1alpha1.MemoryAccountingMaxMemory, vm: proxmox.VirtualMachine{VMID: 100, MaxMem: 8 * mib, Mem: 2 * mib, Status: "running"}, expect: 8 * mib},
+		{name: "balloon minimum", accounting: infrav1alpha1.MemoryAccountingBalloonMinimum, vm: proxmox.VirtualMachine{VMID: 100, MaxMem: 8 * mib}, balloon: 4, expect: 4 * mib},
+		{name: "balloon disabled", accounting: infrav1alpha1.MemoryAccountingBalloonMinimum, vm: proxmox.VirtualMachine{VMID: 100, MaxMem: 8 * mib}, balloon: 0, expect: 8 * mib},
+		{name: "usage running", accounting: infrav1alpha1.MemoryAccountingUsage, vm: proxmox.VirtualMachine{VMID: 100, MaxMem: 8 * mib, Mem: 2 * mib, Status: "running"}, expect: 2 * mib},
+		{name: "usage stopped", accounting: infrav1alpha1.MemoryAccountingUsage, vm: proxmox.VirtualMachine{VMID: 100, MaxMem: 8 * mib, Status: "stopped"}, expect: 8 * mib},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			client := newTestClient(t)
 			httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/status`,
-				newJSONResponder(200, proxmox.Node{Memory: proxmox.Memory{Total: 30}}))
+				newJSONResponder(200, proxmox.Node{Memory: proxmox.Memory{Total: 16 * mib}}))
+
+			httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu$`,
+				newJSONResponder(200, proxmox.VirtualMachines{&test.vm}))
 
-			httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu`,
-				newJSONResponder(200, proxmox.VirtualMachines{{MaxMem: test.maxMem}}))
+			httpmock.RegisterResponder(http.MethodGet, `=~/nodes/test/qemu/100/config`,
+				newJSONResponder(200, proxmox.VirtualMachineConfig{Balloon: test.balloon}))
 
-			reservable, err := client.GetReservableMemoryBytes(context.Background(), "test")
+			memory, err := client.GetNodeMemory(context.Background(), "test", test.accounting)
 			require.NoError(t, err)
-			require.Equal(t, test.expect, reservable)
+			require.Equal(t, test.expect, memory.ReservedBytes)
 		})
 	}
 }
+
+func TestProxmoxAPIClient_GetPoolNodes(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/pools/capi`,
+		newJSONResponder(200, proxmox.Pool{Members: []proxmox.ClusterResource{
+			{Type: "storage", Node: "pve2", Storage: "local"},
+			{Type: "qemu", Node: "pve1", VMID: 100},
+			{Type: "storage", Node: "pve1", Storage: "local"},
+		}}))
+
+	nodes, err := client.GetPoolNodes(context.Background(), "capi")
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve1", "pve2"}, nodes)
+}
+
+func TestProxmoxAPIClient_GetNodeInventories(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes$`,
+		newJSONResponder(200, proxmox.NodeStatuses{
+			{Node: "pve2", Status: "online"},
+			{Node: "pve1", Status: "online"},
+			{Node: "pve3", Status: "offline"},
+		}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve1/status`,
+		newJSONResponder(200, map[string]any{
+			"cpuinfo": map[string]any{"model": "AMD EPYC 7543", "cpus": 64},
+			"memory":  map[string]any{"total": 1 << 30},
+		}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve2/status`,
+		newJSONResponder(200, map[string]any{
+			"cpuinfo": map[string]any{"model": "Intel Xeon Gold 6338", "cpus": 32},
+			"memory":  map[string]any{"total": 2 << 30},
+		}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve1/config`,
+		newJSONResponder(200, map[string]any{"description": "rack 3\ntags: gpu;ssd\n"}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve2/config`,
+		newJSONResponder(200, map[string]any{}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve1/storage`,
+		newJSONResponder(200, []map[string]any{
+			{"storage": "local-lvm", "type": "lvmthin", "content": "images,rootdir", "enabled": 1, "active": 1},
+			{"storage": "ceph", "type": "rbd", "content": "images", "shared": 1, "enabled": 1, "active": 1},
+		}))
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve2/storage`,
+		newJSONResponder(200, []map[string]any{
+			{"storage": "ceph", "type": "rbd", "content": "images", "shared": 1, "enabled": 1, "active": 1},
+		}))
+
+	inventories, err := client.GetNodeInventories(context.Background())
+	require.NoError(t, err)
+	ceph := capmox.StorageInfo{Name: "ceph", Type: "rbd", Content: []string{"images"}, Shared: true, Enabled: true, Active: true}
+	require.Equal(t, []capmox.NodeInventory{
+		{Name: "pve1", CPUModel: "AMD EPYC 7543", CPUs: 64, MemoryBytes: 1 << 30, Tags: []string{"gpu", "ssd"}, Storages: []capmox.StorageInfo{
+			ceph,
+			{Name: "local-lvm", Type: "lvmthin", Content: []string{"images", "rootdir"}, Enabled: true, Active: true},
+		}},
+		{Name: "pve2", CPUModel: "Intel Xeon Gold 6338", CPUs: 32, MemoryBytes: 2 << 30, Storages: []capmox.StorageInfo{ceph}},
+	}, inventories)
+
+	topology := capmox.NewStorageTopology(inventories)
+	require.True(t, topology.Reachable("ceph", "pve1", "pve2"))
+	require.False(t, topology.Reachable("local-lvm", "pve1", "pve2"))
+	require.True(t, topology.Reachable("local-lvm", "pve1", "pve1"))
+}
+
+func TestProxmoxAPIClient_GetNodeSummaries(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	sim.AddNode(proxmoxtest.SimulatedNode{Name: "pve2", CPUs: 8, MemoryBytes: 2 << 30, Offline: true})
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Config: map[string]any{"cores": 2, "memory": 2048}})
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 101, Node: "pve1", Status: "running", Config: map[string]any{"cores": 1, "sockets": 2}})
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 9000, Node: "pve1", Template: true, Config: map[string]any{"cores": 4}})
+
+	summaries, err := client.GetNodeSummaries(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []capmox.NodeSummary{
+		{Name: "pve1", Online: true, Version: proxmoxtest.SimulatorRelease, CPUs: 4, MemoryBytes: 1 << 30, AllocatedCPUs: 4, AllocatedMemoryBytes: 2560 << 20},
+		{Name: "pve2"},
+	}, summaries)
+}
+
+func TestProxmoxAPIClient_GetAPIStatus(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+
+	status, err := client.GetAPIStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, capmox.APIStatus{Version: proxmoxtest.SimulatorRelease + ".0", Clustered: true, Quorate: true}, status)
+
+	sim.LoseQuorum(true)
+	status, err = client.GetAPIStatus(context.Background())
+	require.NoError(t, err)
+	require.True(t, status.Clustered)
+	require.False(t, status.Quorate)
+}
+
+func TestProxmoxAPIClient_ListVMResources(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Config: map[string]any{"name": "test", "tags": "capmox_default_test"}})
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 9000, Node: "pve1", Template: true})
+
+	resources, err := client.ListVMResources(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	require.Equal(t, uint64(100), resources[0].VMID)
+	require.Equal(t, "capmox_default_test", resources[0].Tags)
+	require.Equal(t, uint64(1), resources[1].Template)
+}
+
+func TestProxmoxAPIClient_ListVMResourcesCache(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	now := time.Now()
+	client.now = func() time.Time { return now }
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1"})
+
+	resources, err := client.ListVMResources(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	resources[0].Name = "modified"
+
+	// the cached list is served until it expires, and callers can not modify it.
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 101, Node: "pve1"})
+	resources, err = client.ListVMResources(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.NotEqual(t, "modified", resources[0].Name)
+
+	now = now.Add(DefaultResourceCacheTTL)
+	resources, err = client.ListVMResources(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+
+	// deleting a VM drops the cached list.
+	_, err = client.DeleteVM(context.Background(), "pve1", 101, capmox.VMDeleteOptions{})
+	require.NoError(t, err)
+	resources, err = client.ListVMResources(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+
+	client.ResourceCacheTTL = 0
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 102, Node: "pve1"})
+	resources, err = client.ListVMResources(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+}
+
+func TestProxmoxAPIClient_GetVMByID(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	now := time.Now()
+	client.now = func() time.Time { return now }
+	sim.AddNode(proxmoxtest.SimulatedNode{Name: "pve2", CPUs: 4, MemoryBytes: 1 << 30})
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Config: map[string]any{"name": "test"}})
+
+	vm, err := client.GetVMByID(context.Background(), 100)
+	require.NoError(t, err)
+	require.Equal(t, "pve1", vm.Node)
+	require.Equal(t, "test", vm.Name)
+
+	// the cached index is refreshed once the VM was migrated.
+	_, err = client.MigrateVM(context.Background(), vm, "pve2", false)
+	require.NoError(t, err)
+	vm, err = client.GetVMByID(context.Background(), 100)
+	require.NoError(t, err)
+	require.Equal(t, "pve2", vm.Node)
+
+	_, err = client.GetVMByID(context.Background(), 101)
+	require.ErrorContains(t, err, "vm 101 does not exist on any node of the cluster")
+}
+
+func TestProxmoxAPIClient_GetVMByID_ListingFails(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/cluster/resources`, httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+
+	// the VM is only reported missing if the resources of the cluster could be listed.
+	_, err := client.GetVMByID(context.Background(), 100)
+	require.ErrorContains(t, err, "cannot list the resources of the cluster to find vm 100")
+	require.NotContains(t, err.Error(), "does not exist")
+}
+
+func TestParsePVEVersion(t *testing.T) {
+	require.Equal(t, "8.1.3", parsePVEVersion("pve-manager/8.1.3/b46aac3b42da5d15"))
+	require.Equal(t, "unknown", parsePVEVersion("unknown"))
+}
+
+func TestProxmoxAPIClient_GetPendingChanges(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, `=~/nodes/pve1/qemu/100/pending`,
+		newJSONResponder(200, []map[string]any{
+			{"key": "cores", "value": 2, "pending": 4},
+			{"key": "memory", "value": 4096},
+			{"key": "net1", "value": "virtio,bridge=vmbr1", "delete": 1},
+		}))
+
+	vm := &proxmox.VirtualMachine{Node: "pve1", VMID: 100}
+	keys, err := client.GetPendingChanges(context.Background(), vm)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cores", "net1"}, keys)
+}
+
+func TestProxmoxAPIClient_GetVMArchitecture(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1"})
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 101, Node: "pve1", Config: map[string]any{"arch": "aarch64"}})
+
+	arch, err := client.GetVMArchitecture(context.Background(), &proxmox.VirtualMachine{Node: "pve1", VMID: 100})
+	require.NoError(t, err)
+	require.Equal(t, "x86_64", arch)
+
+	arch, err = client.GetVMArchitecture(context.Background(), &proxmox.VirtualMachine{Node: "pve1", VMID: 101})
+	require.NoError(t, err)
+	require.Equal(t, "aarch64", arch)
+}
+
+func TestProxmoxAPIClient_WaitForTask(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1"})
+	sim.DelayTasks(2)
+
+	vm, err := client.GetVM(context.Background(), "pve1", 100)
+	require.NoError(t, err)
+	task, err := client.StartVM(context.Background(), vm)
+	require.NoError(t, err)
+
+	var lines []string
+	logger := funcr.New(func(_, args string) { lines = append(lines, args) }, funcr.Options{Verbosity: 4})
+	ctx := logr.NewContext(context.Background(), logger)
+
+	task, err = client.WaitForTask(ctx, string(task.UPID), capmox.TaskWaitOptions{Interval: time.Millisecond})
+	require.NoError(t, err)
+	require.True(t, task.IsSuccessful)
+	require.Len(t, lines, 4)
+	require.Contains(t, lines[0], "qmstart 100 started")
+	require.Contains(t, lines[3], "TASK OK")
+}
+
+func TestProxmoxAPIClient_WaitForTaskFailed(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1"})
+	sim.FailTasks("start failed: QEMU exited with code 1")
+
+	vm, err := client.GetVM(context.Background(), "pve1", 100)
+	require.NoError(t, err)
+	task, err := client.StartVM(context.Background(), vm)
+	require.NoError(t, err)
+
+	task, err = client.WaitForTask(context.Background(), string(task.UPID), capmox.TaskWaitOptions{})
+	require.ErrorIs(t, err, capmox.ErrTaskFailed)
+	require.ErrorContains(t, err, "QEMU exited with code 1")
+	require.True(t, task.IsFailed)
+}
+
+func TestProxmoxAPIClient_WaitForTaskTimeout(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1"})
+	sim.DelayTasks(1000)
+
+	vm, err := client.GetVM(context.Background(), "pve1", 100)
+	require.NoError(t, err)
+	task, err := client.StartVM(context.Background(), vm)
+	require.NoError(t, err)
+
+	_, err = client.WaitForTask(context.Background(), string(task.UPID), capmox.TaskWaitOptions{
+		Interval: time.Millisecond,
+		Timeout:  20 * time.Millisecond,
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestProxmoxAPIClient_DeleteVMWaitsForStop(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Status: "running"})
+	sim.DelayTasks(1)
+
+	_, err := client.DeleteVM(context.Background(), "pve1", 100, capmox.VMDeleteOptions{})
+	require.NoError(t, err)
+
+	_, ok := sim.VM(100)
+	require.False(t, ok)
+}
+
+func TestProxmoxAPIClient_DeleteVMOptions(t *testing.T) {
+	client := newTestClient(t)
+	registerTestVM()
+	httpmock.RegisterResponderWithQuery(http.MethodDelete, testBaseURL+"api2/json/nodes/pve1/qemu/100",
+		"destroy-unreferenced-disks=1&purge=1",
+		newJSONResponder(200, "UPID:pve1:00000001:00000001:00000001:qmdestroy:100:root@pam:"))
+
+	task, err := client.DeleteVM(context.Background(), "pve1", 100, capmox.VMDeleteOptions{Purge: true, DestroyUnreferencedDisks: true})
+	require.NoError(t, err)
+	require.Equal(t, "qmdestroy", task.Type)
+}
+
+func TestProxmoxAPIClient_AllocateVolume(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1"})
+
+	volume, err := client.AllocateVolume(context.Background(), "pve1", proxmoxtest.SimulatorImageStorage, 9999, "vm-9999-test-scsi1", 20)
+	require.NoError(t, err)
+	require.Equal(t, proxmoxtest.SimulatorImageStorage+":vm-9999-test-scsi1", volume)
+
+	// the volume is not owned by the VM it is attached to, so it is kept when the VM is destroyed.
+	_, err = client.DeleteVM(context.Background(), "pve1", 100, capmox.VMDeleteOptions{DestroyUnreferencedDisks: true})
+	require.NoError(t, err)
+	allocated, ok := sim.Volume("pve1", volume)
+	require.True(t, ok)
+	require.Equal(t, uint64(9999), allocated.Owner)
+	require.Equal(t, 20, allocated.SizeGB)
+
+	_, err = client.AllocateVolume(context.Background(), "pve1", proxmoxtest.SimulatorImageStorage, 9999, "vm-9999-test-scsi1", 20)
+	require.ErrorContains(t, err, "already exists")
+}
+
+func TestProxmoxAPIClient_DeleteVolume(t *testing.T) {
+	sim, client := newSimulatorClient(t)
+	volume, err := client.AllocateVolume(context.Background(), "pve1", proxmoxtest.SimulatorImageStorage, 9999, "vm-9999-test-scsi1", 20)
+	require.NoError(t, err)
+
+	task, err := client.DeleteVolume(context.Background(), "pve1", volume)
+	require.NoError(t, err)
+	_, err = client.WaitForTask(context.Background(), string(task.UPID), capmox.TaskWaitOptions{})
+	require.NoError(t, err)
+	_, ok := sim.Volume("pve1", volume)
+	require.False(t, ok)
+
+	_, err = client.DeleteVolume(context.Background(), "pve1", "vm-9999-test-scsi1")
+	require.ErrorContains(t, err, "invalid volume")
+}
+
+func TestProxmoxAPIClient_RemoteMigrateVM(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodPost, testBaseURL+"api2/json/nodes/pve1/qemu/100/remote_migrate",
+		func(req *http.Request) (*http.Response, error) {
+			var params map[string]any
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&params))
+			require.Equal(t, map[string]any{
+				"target-endpoint": "apitoken=PVEAPIToken=capi@pve!import=secret,host=pve.example.com",
+				"target-storage":  "local-lvm",
+				"target-bridge":   "vmbr0",
+				"target-vmid":     9000.0,
+			}, params)
+			return newJSONResponder(200, "UPID:pve1:00000001:00000001:00000001:qmigrate:100:root@pam:")(req)
+		})
+
+	task, err := client.RemoteMigrateVM(context.Background(), &proxmox.VirtualMachine{Node: "pve1", VMID: 100}, capmox.RemoteMigrateOptions{
+		TargetEndpoint: "apitoken=PVEAPIToken=capi@pve!import=secret,host=pve.example.com",
+		TargetVMID:     9000,
+		TargetStorage:  "local-lvm",
+		TargetBridge:   "vmbr0",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "qmigrate", task.Type)
+}
+
+func TestProxmoxAPIClient_CreateVM(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, testBaseURL+"api2/json/cluster/nextid", newJSONResponder(200, "104"))
+	httpmock.RegisterResponder(http.MethodPost, testBaseURL+"api2/json/nodes/pve1/qemu",
+		func(req *http.Request) (*http.Response, error) {
+			var params map[string]any
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&params))
+			require.Equal(t, map[string]any{
+				"vmid":  104.0,
+				"name":  "test",
+				"scsi0": "local-lvm:0,import-from=local:import/noble.qcow2",
+			}, params)
+			return newJSONResponder(200, "UPID:pve1:00000001:00000001:00000001:qmcreate:104:root@pam:")(req)
+		})
+
+	res, err := client.CreateVM(context.Background(), "pve1",
+		capmox.VirtualMachineOption{Name: "name", Value: "test"},
+		capmox.VirtualMachineOption{Name: "scsi0", Value: "local-lvm:0,import-from=local:import/noble.qcow2"})
+	require.NoError(t, err)
+	require.Equal(t, int64(104), res.NewID)
+	require.Equal(t, "qmcreate", res.Task.Type)
+}
+
+func TestProxmoxAPIClient_ListVolumes(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodGet, testBaseURL+"api2/json/nodes/pve1/storage/local/content?content=import",
+		newJSONResponder(200, []map[string]any{
+			{"volid": "local:import/noble.qcow2", "format": "qcow2", "content": "import"},
+			{"volid": "local:import/jammy.raw", "format": "raw", "content": "import"},
+		}))
+
+	volumes, err := client.ListVolumes(context.Background(), "pve1", "local", "import")
+	require.NoError(t, err)
+	require.Equal(t, []string{"local:import/noble.qcow2", "local:import/jammy.raw"}, volumes)
+}
+
+func TestProxmoxAPIClient_DownloadImage(t *testing.T) {
+	client := newTestClient(t)
+	httpmock.RegisterResponder(http.MethodPost, testBaseURL+"api2/json/nodes/pve1/storage/local/download-url",
+		func(req *http.Request) (*http.Response, error) {
+			var params map[string]any
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&params))
+			require.Equal(t, map[string]any{
+				"content":            "import",
+				"url":                "https://cloud-images.ubuntu.com/noble/current/noble-server-cloudimg-amd64.img",
+				"filename":           "noble.qcow2",
+				"checksum":           "abc",
+				"checksum-algorithm": "sha256",
+			}, params)
+			return newJSONResponder(200, "UPID:pve1:00000001:00000001:00000001:download:noble.qcow2:root@pam:")(req)
+		})
+
+	task, err := client.DownloadImage(context.Background(), "pve1", "local", capmox.ImageDownloadOptions{
+		URL:               "https://cloud-images.ubuntu.com/noble/current/noble-server-cloudimg-amd64.img",
+		Filename:          "noble.qcow2",
+		Checksum:          "abc",
+		ChecksumAlgorithm: "sha256",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "download", task.Type)
+}
+
+func TestProxmoxAPIClient_ShutdownVM(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   capmox.VMStopOptions
+		path   string
+		params map[string]any
+		err    string
+	}{
+		{name: "default", path: "stop"},
+		{name: "stop", opts: capmox.VMStopOptions{Mode: capmox.StopModeStop}, path: "stop"},
+		{name: "shutdown", opts: capmox.VMStopOptions{Mode: capmox.StopModeShutdown}, path: "shutdown", params: map[string]any{"timeout": 60.0, "forceStop": 1.0}},
+		{name: "shutdown with timeout", opts: capmox.VMStopOptions{Mode: capmox.StopModeShutdown, Timeout: 5 * time.Minute}, path: "shutdown", params: map[string]any{"timeout": 300.0, "forceStop": 1.0}},
+		{name: "unknown", opts: capmox.VMStopOptions{Mode: "Unplug"}, err: `unknown stop mode "Unplug"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t)
+			var params map[string]any
+			httpmock.RegisterResponder(http.MethodPost, `=~/nodes/pve1/qemu/100/status/`+test.path,
+				func(req *http.Request) (*http.Response, error) {
+					if req.Body != nil {
+						_ = json.NewDecoder(req.Body).Decode(&params)
+					}
+					return httpmock.NewJsonResponse(200, map[string]any{"data": "UPID:pve1:00000001:00000001:00000001:qm" + test.path + ":100:root@pam:"})
+				})
+
+			registerTestVM()
+			vm, err := client.GetVM(context.Background(), "pve1", 100)
+			require.NoError(t, err)
+
+			task, err := client.ShutdownVM(context.Background(), vm, test.opts)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "qm"+test.path, task.Type)
+			require.Equal(t, test.params, params)
+		})
+	}
+}
+
+func TestProxmoxAPIClient_HibernateVM(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Status: "running"})
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	_, err = client.HibernateVM(ctx, vm)
+	require.NoError(t, err)
+
+	vm, err = client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	require.True(t, vm.IsHibernated())
+
+	_, err = client.StartVM(ctx, vm)
+	require.NoError(t, err)
+	vm, err = client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	require.True(t, vm.IsRunning())
+	require.False(t, vm.IsHibernated())
+}
+
+func TestProxmoxAPIClient_SuspendVM(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Status: "running"})
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	_, err = client.SuspendVM(ctx, vm)
+	require.NoError(t, err)
+
+	vm, err = client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	require.True(t, vm.IsPaused())
+}
+
+func TestProxmoxAPIClient_MigrateVM(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddNode(proxmoxtest.SimulatedNode{Name: "pve2", CPUs: 4, MemoryBytes: 1 << 30})
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Status: "running"})
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	resource, err := client.FindVMResource(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, "pve1", resource.Node)
+
+	_, err = client.MigrateVM(ctx, vm, "pve2", false)
+	require.ErrorContains(t, err, "can't migrate running VM without --online")
+
+	task, err := client.MigrateVM(ctx, vm, "pve2", true)
+	require.NoError(t, err)
+	require.Equal(t, "qmigrate", task.Type)
+
+	state, _ := sim.VM(100)
+	require.Equal(t, "pve2", state.Node)
+	_, err = client.GetVM(ctx, "pve2", 100)
+	require.NoError(t, err)
+
+	// the cached resources are refreshed after the migration.
+	resource, err = client.FindVMResource(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, "pve2", resource.Node)
+}
+
+func TestProxmoxAPIClient_Snapshots(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Status: "running", Config: map[string]any{"cores": 2}})
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+
+	_, err = client.CreateSnapshot(ctx, vm, "before-upgrade", capmox.SnapshotOptions{Description: "kubernetes v1.27"})
+	require.NoError(t, err)
+	_, err = client.CreateSnapshot(ctx, vm, "before-upgrade", capmox.SnapshotOptions{})
+	require.ErrorContains(t, err, "already used")
+
+	_, err = client.ConfigureVM(ctx, vm, capmox.VirtualMachineOption{Name: "cores", Value: 4})
+	require.NoError(t, err)
+	_, err = client.CreateSnapshot(ctx, vm, "after-upgrade", capmox.SnapshotOptions{IncludeMemory: true})
+	require.NoError(t, err)
+
+	snapshots, err := client.ListSnapshots(ctx, vm)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	require.Equal(t, "before-upgrade", snapshots[0].Name)
+	require.Equal(t, "kubernetes v1.27", snapshots[0].Description)
+	require.Equal(t, "after-upgrade", snapshots[1].Name)
+	require.Equal(t, 1, snapshots[1].Vmstate)
+
+	_, err = client.RollbackSnapshot(ctx, vm, "before-upgrade")
+	require.NoError(t, err)
+	state, _ := sim.VM(100)
+	require.Equal(t, 2, state.Config["cores"])
+	require.Equal(t, "stopped", state.Status)
+
+	_, err = client.DeleteSnapshot(ctx, vm, "after-upgrade")
+	require.NoError(t, err)
+	_, err = client.DeleteSnapshot(ctx, vm, "after-upgrade")
+	require.ErrorContains(t, err, "does not exist")
+
+	snapshots, err = client.ListSnapshots(ctx, vm)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+}
+
+func TestProxmoxAPIClient_BackupVM(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Status: "running"})
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+
+	_, err = client.BackupVM(ctx, vm, capmox.BackupOptions{})
+	require.ErrorContains(t, err, "no storage given")
+	_, err = client.BackupVM(ctx, vm, capmox.BackupOptions{Storage: proxmoxtest.SimulatorImageStorage})
+	require.ErrorContains(t, err, "does not support backups")
+
+	task, err := client.BackupVM(ctx, vm, capmox.BackupOptions{
+		Storage:     proxmoxtest.SimulatorISOStorage,
+		Mode:        capmox.BackupModeSuspend,
+		Compression: "zstd",
+		Notes:       "{{guestname}} before upgrade",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "vzdump", task.Type)
+
+	backups := sim.Backups("pve1")
+	require.Len(t, backups, 1)
+	require.Regexp(t, `^local:backup/vzdump-qemu-100-\d+\.vma\.zst$`, backups[0])
+}
+
+func TestProxmoxAPIClient_Storages(t *testing.T) {
+	ctx := context.Background()
+	_, client := newSimulatorClient(t)
+
+	storages, err := client.ListStorages(ctx, "pve1")
+	require.NoError(t, err)
+	require.Len(t, storages, 2)
+	require.Equal(t, proxmoxtest.SimulatorISOStorage, storages[0].Name)
+	require.True(t, storages[0].HasContent(capmox.StorageContentISO))
+	require.False(t, storages[0].HasContent(capmox.StorageContentImages))
+	require.Equal(t, proxmoxtest.SimulatorImageStorage, storages[1].Name)
+	require.True(t, storages[1].HasContent(capmox.StorageContentImages))
+
+	storage, err := client.GetStorage(ctx, "pve1", proxmoxtest.SimulatorImageStorage)
+	require.NoError(t, err)
+	require.Equal(t, "lvmthin", storage.Type)
+	require.True(t, storage.Active)
+	require.NotZero(t, storage.AvailableBytes)
+
+	_, err = client.GetStorage(ctx, "pve1", "missing")
+	require.ErrorContains(t, err, "does not exist")
+}
+
+func TestProxmoxAPIClient_ListBridges(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddNode(proxmoxtest.SimulatedNode{Name: "pve2", Bridges: []string{"vmbr1", "vmbr0"}})
+
+	bridges, err := client.ListBridges(ctx, "pve1")
+	require.NoError(t, err)
+	require.Equal(t, []string{proxmoxtest.SimulatorBridge}, bridges)
+
+	bridges, err = client.ListBridges(ctx, "pve2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"vmbr0", "vmbr1"}, bridges)
+
+	_, err = client.ListBridges(ctx, "missing")
+	require.ErrorContains(t, err, "cannot list bridges of node missing")
+}
+
+func TestProxmoxAPIClient_UploadISO(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddSharedISOStorage("nfs")
+
+	task, err := client.UploadISO(ctx, "pve1", "nfs", "test.iso", []byte("image"))
+	require.NoError(t, err)
+	_, err = client.WaitForTask(ctx, string(task.UPID), capmox.TaskWaitOptions{})
+	require.NoError(t, err)
+
+	content, ok := sim.StorageISO("pve1", "nfs", "test.iso")
+	require.True(t, ok)
+	require.Equal(t, []byte("image"), content)
+
+	_, err = client.UploadISO(ctx, "pve1", proxmoxtest.SimulatorImageStorage, "test.iso", []byte("image"))
+	require.ErrorContains(t, err, "does not support content type 'iso'")
+}
+
+func TestProxmoxAPIClient_UploadISOCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/version") {
+			_, _ = w.Write([]byte(`{"data":{"release":"8.1"}}`))
+			return
+		}
+		// the upload never finishes.
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client, err := NewAPIClient(context.Background(), logr.Discard(), server.URL,
+		proxmox.WithHTTPClient(&http.Client{Transport: &http.Transport{}}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = client.UploadISO(ctx, "pve1", "nfs", "test.iso", []byte("image"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestProxmoxAPIClient_DeleteVMCloudInitISOOnSharedStorage(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddSharedISOStorage("nfs")
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Config: map[string]any{
+		"name": "test",
+		"tags": proxmox.MakeTag(proxmox.TagCloudInit),
+		"ide0": "nfs:iso/user-data-100.iso,media=cdrom",
+	}})
+
+	_, err := client.UploadISO(ctx, "pve1", "nfs", "user-data-100.iso", []byte("image"))
+	require.NoError(t, err)
+
+	_, err = client.DeleteVM(ctx, "pve1", 100, capmox.VMDeleteOptions{})
+	require.NoError(t, err)
+
+	_, ok := sim.StorageISO("pve1", "nfs", "user-data-100.iso")
+	require.False(t, ok)
+}
+
+func TestProxmoxAPIClient_SDN(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddSDNZone("capmox", "vlan")
+
+	zones, err := client.ListSDNZones(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []capmox.SDNZone{{Name: "capmox", Type: "vlan"}}, zones)
+
+	vnet := capmox.SDNVNet{Name: "test", Zone: "capmox", Tag: 100, Alias: "capmox-default-test"}
+	err = client.CreateSDNVNet(ctx, vnet, capmox.SDNSubnet{CIDR: "10.0.0.0/24", Gateway: "10.0.0.1"})
+	require.NoError(t, err)
+	require.ErrorContains(t, client.CreateSDNVNet(ctx, vnet), "already defined")
+	require.ErrorContains(t, client.CreateSDNVNet(ctx, capmox.SDNVNet{Name: "other", Zone: "missing"}), "does not exist")
+
+	vnets, err := client.ListSDNVNets(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []capmox.SDNVNet{vnet}, vnets)
+
+	task, err := client.ApplySDN(ctx)
+	require.NoError(t, err)
+	_, err = client.WaitForTask(ctx, string(task.UPID), capmox.TaskWaitOptions{})
+	require.NoError(t, err)
+
+	state, ok := sim.SDNVNet("test")
+	require.True(t, ok)
+	require.True(t, state.Applied)
+	require.Equal(t, map[string]string{"10.0.0.0/24": "10.0.0.1"}, state.Subnets)
+
+	require.NoError(t, client.DeleteSDNVNet(ctx, "test"))
+	_, ok = sim.SDNVNet("test")
+	require.False(t, ok)
+	require.ErrorContains(t, client.DeleteSDNVNet(ctx, "test"), "does not exist")
+}
+
+func TestProxmoxAPIClient_BackupJobs(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+
+	job := capmox.BackupJob{
+		ID:           "capmox-default-test",
+		Schedule:     "daily",
+		Storage:      proxmoxtest.SimulatorISOStorage,
+		Mode:         capmox.BackupModeSnapshot,
+		VMIDs:        []int64{100, 101},
+		PruneBackups: "keep-daily=7,keep-last=3",
+		Comment:      "capmox-default-test",
+		Enabled:      true,
+	}
+	require.NoError(t, client.CreateBackupJob(ctx, job))
+	require.ErrorContains(t, client.CreateBackupJob(ctx, job), "already exists")
+	require.ErrorContains(t, client.CreateBackupJob(ctx, capmox.BackupJob{ID: "other", Schedule: "daily", Storage: proxmoxtest.SimulatorImageStorage, VMIDs: []int64{100}}), "does not support backups")
+
+	jobs, err := client.ListBackupJobs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []capmox.BackupJob{job}, jobs)
+
+	// the retention and comment are removed along with the fields.
+	job.VMIDs, job.PruneBackups, job.Comment, job.Mode = []int64{100}, "", "", capmox.BackupModeStop
+	require.NoError(t, client.UpdateBackupJob(ctx, job))
+	state, ok := sim.BackupJob("capmox-default-test")
+	require.True(t, ok)
+	require.Equal(t, proxmoxtest.SimulatedBackupJob{ID: "capmox-default-test", Schedule: "daily", Storage: proxmoxtest.SimulatorISOStorage, Mode: "stop", VMIDs: []uint64{100}, Enabled: true}, state)
+
+	require.NoError(t, client.DeleteBackupJob(ctx, "capmox-default-test"))
+	_, ok = sim.BackupJob("capmox-default-test")
+	require.False(t, ok)
+	require.ErrorContains(t, client.DeleteBackupJob(ctx, "capmox-default-test"), "does not exist")
+}
+
+func TestPropertyString(t *testing.T) {
+	require.Equal(t, "keep-last=3", propertyString(json.RawMessage(`"keep-last=3"`)))
+	require.Equal(t, "keep-daily=7,keep-last=3", propertyString(json.RawMessage(`{"keep-last":"3","keep-daily":7}`)))
+	require.Empty(t, propertyString(nil))
+}
+
+func TestProxmoxAPIClient_VMFirewall(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddSecurityGroup("k8s")
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Config: map[string]any{"name": "test"}, FirewallRules: []map[string]any{
+		{"type": "in", "action": "ACCEPT", "comment": "manual"},
+	}})
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+
+	firewall, err := client.GetVMFirewall(ctx, vm)
+	require.NoError(t, err)
+	require.Equal(t, capmox.VMFirewall{Rules: []capmox.FirewallRule{{Type: "in", Action: "ACCEPT", Comment: "manual"}}}, firewall)
+
+	desired := capmox.VMFirewall{
+		Enable:   true,
+		PolicyIn: "DROP",
+		Rules: []capmox.FirewallRule{
+			{Type: "group", Action: "k8s"},
+			{Type: "in", Action: "ACCEPT", Proto: "tcp", DPort: "6443", Source: "10.0.0.0/8"},
+		},
+	}
+	require.NoError(t, client.SetVMFirewall(ctx, vm, desired))
+
+	firewall, err = client.GetVMFirewall(ctx, vm)
+	require.NoError(t, err)
+	require.Equal(t, desired, firewall)
+
+	err = client.SetVMFirewall(ctx, vm, capmox.VMFirewall{Rules: []capmox.FirewallRule{{Type: "group", Action: "missing"}}})
+	require.ErrorContains(t, err, "security group 'missing' does not exist")
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // TOTP is based on HMAC-SHA1.
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+)
+
+// DefaultTicketRefreshInterval is the age after which a ticket is renewed.
+// Proxmox VE tickets expire two hours after they were issued.
+const DefaultTicketRefreshInterval = 90 * time.Minute
+
+// totpPeriod is the validity of a TOTP code in seconds.
+const totpPeriod = 30
+
+var (
+	// ErrAuthenticationFailed is returned if Proxmox VE rejects the credentials.
+	ErrAuthenticationFailed = errors.New("proxmox authentication failed")
+	// ErrSecondFactorRequired is returned if the user has two-factor authentication enabled,
+	// but no TOTP secret is configured.
+	ErrSecondFactorRequired = errors.New("proxmox user requires a second factor, but no TOTP secret is set")
+)
+
+// TicketTransport is an http.RoundTripper which authenticates requests with a ticket obtained
+// by logging in with username and password. The ticket is renewed before it expires, and once
+// if a request is rejected as unauthorized, so long-running managers keep working.
+type TicketTransport struct {
+	ticketURL   string
+	credentials proxmox.Credentials
+	transport   http.RoundTripper
+
+	// RefreshInterval is the age after which the ticket is renewed.
+	RefreshInterval time.Duration
+	// TOTPSecret is the base32 encoded secret used to answer the TOTP challenge
+	// of users with two-factor authentication.
+	TOTPSecret string
+
+	mu        sync.Mutex
+	ticket    string
+	csrfToken string
+	issued    time.Time
+	now       func() time.Time
+}
+
+// NewTicketTransport creates a TicketTransport for the Proxmox VE host at baseURL.
+// Requests are sent using the given transport, which defaults to http.DefaultTransport.
+func NewTicketTransport(baseURL string, credentials proxmox.Credentials, transport http.RoundTripper) (*TicketTransport, error) {
+	ticketURL, err := url.JoinPath(baseURL, "api2", "json", "access", "ticket")
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxmox base URL %q: %w", baseURL, err)
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &TicketTransport{
+		ticketURL:       ticketURL,
+		credentials:     credentials,
+		transport:       transport,
+		RefreshInterval: DefaultTicketRefreshInterval,
+		now:             time.Now,
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *TicketTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ticket, csrfToken, err := t.currentTicket(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.transport.RoundTrip(authenticatedRequest(req, ticket, csrfToken))
+	if err != nil || res.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return res, err
+	}
+
+	// the ticket was revoked or expired early, log in again and retry once.
+	_ = res.Body.Close()
+	retry := req.Clone(req.Context())
+	if req.Body != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	if ticket, csrfToken, err = t.currentTicket(req, true); err != nil {
+		return nil, err
+	}
+	return t.transport.RoundTrip(authenticatedRequest(retry, ticket, csrfToken))
+}
+
+// currentTicket returns a valid ticket, logging in if there is none, it is due for renewal or renewal is forced.
+func (t *TicketTransport) currentTicket(req *http.Request, force bool) (ticket, csrfToken string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !force && t.ticket != "" && t.now().Sub(t.issued) < t.RefreshInterval {
+		return t.ticket, t.csrfToken, nil
+	}
+
+	session, err := t.login(req)
+	if err != nil {
+		t.ticket, t.csrfToken = "", ""
+		return "", "", err
+	}
+
+	t.ticket, t.csrfToken, t.issued = session.Ticket, session.CSRFPreventionToken, t.now()
+	return t.ticket, t.csrfToken, nil
+}
+
+func (t *TicketTransport) login(req *http.Request) (*proxmox.Session, error) {
+	form := url.Values{}
+	form.Set("username", t.credentials.Username)
+	form.Set("password", t.credentials.Password)
+	if t.credentials.Realm != "" {
+		form.Set("realm", t.credentials.Realm)
+	}
+
+	session, err := t.requestTicket(req, form)
+	if err != nil {
+		return nil, err
+	}
+	if session.NeedTFA == 0 {
+		return &session.Session, nil
+	}
+
+	// the user has two-factor authentication enabled, the ticket is only a challenge.
+	if t.TOTPSecret == "" {
+		return nil, fmt.Errorf("%w for user %s", ErrSecondFactorRequired, t.credentials.Username)
+	}
+	code, err := totpCode(t.TOTPSecret, t.now())
+	if err != nil {
+		return nil, err
+	}
+
+	form = url.Values{}
+	form.Set("username", t.credentials.Username)
+	form.Set("tfa-challenge", session.Ticket)
+	form.Set("password", "totp:"+code)
+	if session, err = t.requestTicket(req, form); err != nil {
+		return nil, err
+	}
+	if session.NeedTFA != 0 {
+		return nil, fmt.Errorf("%w for user %s: second factor was not accepted", ErrAuthenticationFailed, t.credentials.Username)
+	}
+	return &session.Session, nil
+}
+
+// ticketSession is the response of a login, which may require a second factor.
+type ticketSession struct {
+	proxmox.Session
+	NeedTFA int `json:"NeedTFA,omitempty"`
+}
+
+func (t *TicketTransport) requestTicket(req *http.Request, form url.Values) (*ticketSession, error) {
+	loginReq, err := http.NewRequestWithContext(req.Context(), http.MethodPost, t.ticketURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	loginReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	loginReq.Header.Set("Accept", "application/json")
+
+	res, err := t.transport.RoundTrip(loginReq)
+	if err != nil {
+		return nil, fmt.Errorf("cannot request proxmox ticket: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("%w for user %s: %s", ErrAuthenticationFailed, t.credentials.Username, res.Status)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot request proxmox ticket: %s", res.Status)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read proxmox ticket: %w", err)
+	}
+
+	var response struct {
+		Data ticketSession `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("cannot parse proxmox ticket: %w", err)
+	}
+	if response.Data.Ticket == "" {
+		return nil, fmt.Errorf("%w for user %s: no ticket returned", ErrAuthenticationFailed, t.credentials.Username)
+	}
+
+	return &response.Data, nil
+}
+
+// totpCode returns the time-based one-time password of a base32 encoded secret, as defined by RFC 6238.
+func totpCode(secret string, now time.Time) (string, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(now.Unix()/totpPeriod))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000), nil
+}
+
+// authenticatedRequest returns a copy of the request with the ticket cookie and, for writes, the CSRF token.
+func authenticatedRequest(req *http.Request, ticket, csrfToken string) *http.Request {
+	authenticated := req.Clone(req.Context())
+	authenticated.Body = req.Body
+	authenticated.Header.Set("Cookie", "PVEAuthCookie="+ticket)
+	if req.Method != http.MethodGet {
+		authenticated.Header.Set("CSRFPreventionToken", csrfToken)
+	}
+	return authenticated
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func newTicketTestClient(t *testing.T, password string) (*proxmoxtest.Simulator, *TicketTransport, *APIClient, error) {
+	sim := proxmoxtest.NewSimulator()
+	t.Cleanup(sim.Close)
+	sim.SetCredentials("capi@pve", "secret")
+	sim.AddNode(proxmoxtest.SimulatedNode{Name: "pve1", CPUs: 4, MemoryBytes: 1 << 30})
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Config: map[string]any{"name": "test"}})
+
+	transport, err := NewTicketTransport(sim.URL(), proxmox.Credentials{Username: "capi@pve", Password: password}, &http.Transport{})
+	require.NoError(t, err)
+
+	client, err := NewAPIClient(context.Background(), logr.Discard(), sim.URL(), proxmox.WithHTTPClient(&http.Client{Transport: transport}))
+	return sim, transport, client, err
+}
+
+func TestTicketTransport_ReauthenticatesExpiredTicket(t *testing.T) {
+	ctx := context.Background()
+	sim, _, client, err := newTicketTestClient(t, "secret")
+	require.NoError(t, err)
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+
+	sim.ExpireTickets()
+
+	// writes need the CSRF token of the new ticket.
+	_, err = client.ConfigureVM(ctx, vm, capmox.VirtualMachineOption{Name: "cores", Value: 2})
+	require.NoError(t, err)
+
+	state, _ := sim.VM(100)
+	require.Equal(t, json.Number("2"), state.Config["cores"])
+}
+
+func TestTicketTransport_RefreshesOldTicket(t *testing.T) {
+	ctx := context.Background()
+	_, transport, client, err := newTicketTestClient(t, "secret")
+	require.NoError(t, err)
+
+	now := time.Now()
+	transport.now = func() time.Time { return now }
+	_, err = client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	ticket := transport.ticket
+
+	now = now.Add(DefaultTicketRefreshInterval - time.Minute)
+	_, err = client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	require.Equal(t, ticket, transport.ticket)
+
+	now = now.Add(2 * time.Minute)
+	_, err = client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	require.NotEqual(t, ticket, transport.ticket)
+}
+
+func TestTicketTransport_InvalidCredentials(t *testing.T) {
+	_, _, _, err := newTicketTestClient(t, "wrong")
+	require.ErrorIs(t, err, ErrAuthenticationFailed)
+}
+
+func TestTicketTransport_TOTP(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	ctx := context.Background()
+	sim := proxmoxtest.NewSimulator()
+	t.Cleanup(sim.Close)
+	sim.SetCredentials("capi@pve", "secret")
+	sim.RequireTOTP(func(code string) bool {
+		expected, err := totpCode(secret, time.Now())
+		return err == nil && code == expected
+	})
+	sim.AddNode(proxmoxtest.SimulatedNode{Name: "pve1", CPUs: 4, MemoryBytes: 1 << 30})
+
+	transport, err := NewTicketTransport(sim.URL(), proxmox.Credentials{Username: "capi@pve", Password: "secret"}, &http.Transport{})
+	require.NoError(t, err)
+	_, err = NewAPIClient(ctx, logr.Discard(), sim.URL(), proxmox.WithHTTPClient(&http.Client{Transport: transport}))
+	require.ErrorIs(t, err, ErrSecondFactorRequired)
+
+	transport.TOTPSecret = secret
+	_, err = NewAPIClient(ctx, logr.Discard(), sim.URL(), proxmox.WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+}
+
+func TestTOTPCode(t *testing.T) {
+	// test vector of RFC 6238 for SHA1, truncated to 6 digits.
+	code, err := totpCode("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", time.Unix(59, 0))
+	require.NoError(t, err)
+	require.Equal(t, "287082", code)
+
+	_, err = totpCode("not base32!", time.Now())
+	require.ErrorContains(t, err, "invalid TOTP secret")
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+const (
+	// DefaultCircuitBreakerThreshold is the default number of consecutive failed requests which open the circuit.
+	DefaultCircuitBreakerThreshold = 5
+	// DefaultCircuitBreakerCooldown is the default duration requests are rejected once the circuit opened.
+	DefaultCircuitBreakerCooldown = 30 * time.Second
+)
+
+var circuitOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "capmox_proxmox_circuit_breaker_open",
+	Help: "Whether the circuit breaker of the Proxmox API rejects requests.",
+}, []string{"host"})
+
+func init() {
+	metrics.Registry.MustRegister(circuitOpen)
+}
+
+// CircuitBreakerTransport is an http.RoundTripper which stops sending requests to the Proxmox API
+// after consecutive failures. While the circuit is open, requests fail with a capmox.CircuitOpenError
+// right away, so reconciles do not pile up waiting for timeouts while Proxmox VE is down.
+// Once the cooldown passed, a single request probes the endpoint and closes the circuit if it succeeds.
+type CircuitBreakerTransport struct {
+	transport http.RoundTripper
+
+	// Threshold is the number of consecutive failed requests which open the circuit. A threshold of 0 disables the breaker.
+	Threshold int
+	// Cooldown is the duration requests are rejected once the circuit opened.
+	Cooldown time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+// NewCircuitBreakerTransport creates a CircuitBreakerTransport sending requests using the given transport,
+// which defaults to http.DefaultTransport.
+func NewCircuitBreakerTransport(transport http.RoundTripper, threshold int, cooldown time.Duration) *CircuitBreakerTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &CircuitBreakerTransport{
+		transport: transport,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CircuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Threshold <= 0 {
+		return t.transport.RoundTrip(req)
+	}
+
+	if err := t.allow(); err != nil {
+		return nil, err
+	}
+
+	res, err := t.transport.RoundTrip(req)
+	t.record(req, isEndpointFailure(req, res, err))
+	return res, err
+}
+
+// Open reports whether the circuit rejects requests.
+func (t *CircuitBreakerTransport) Open() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.openedAt.IsZero()
+}
+
+func (t *CircuitBreakerTransport) allow() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.openedAt.IsZero() {
+		return nil
+	}
+	if remaining := t.openedAt.Add(t.Cooldown).Sub(t.now()); remaining > 0 {
+		return &capmox.CircuitOpenError{RetryAfter: remaining}
+	}
+	if t.probing {
+		return &capmox.CircuitOpenError{RetryAfter: t.Cooldown}
+	}
+	t.probing = true
+	return nil
+}
+
+func (t *CircuitBreakerTransport) record(req *http.Request, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.probing = false
+	if !failed {
+		t.failures = 0
+		if !t.openedAt.IsZero() {
+			t.openedAt = time.Time{}
+			circuitOpen.WithLabelValues(req.URL.Host).Set(0)
+		}
+		return
+	}
+
+	t.failures++
+	// a failed probe opens the circuit again right away.
+	if !t.openedAt.IsZero() || t.failures >= t.Threshold {
+		t.openedAt = t.now()
+		circuitOpen.WithLabelValues(req.URL.Host).Set(1)
+	}
+}
+
+// isEndpointFailure reports whether a request failed because the endpoint is unavailable.
+// Requests canceled by the caller and errors returned by the API, like missing VMs, do not count.
+func isEndpointFailure(req *http.Request, res *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	var status atomic.Int32
+	var requests atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(server.Close)
+
+	now := time.Now()
+	transport := NewCircuitBreakerTransport(&http.Transport{}, 2, time.Minute)
+	transport.now = func() time.Time { return now }
+	client := &http.Client{Transport: transport}
+
+	get := func() error {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/api2/json/version", nil)
+		require.NoError(t, err)
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return res.Body.Close()
+	}
+
+	// the circuit opens after two consecutive failures.
+	require.NoError(t, get())
+	require.False(t, transport.Open())
+	require.NoError(t, get())
+	require.True(t, transport.Open())
+
+	err := get()
+	require.ErrorIs(t, err, capmox.ErrCircuitOpen)
+	circuitErr := new(capmox.CircuitOpenError)
+	require.True(t, errors.As(err, &circuitErr))
+	require.Equal(t, time.Minute, circuitErr.RetryAfter)
+	require.EqualValues(t, 2, requests.Load())
+
+	// a failed probe after the cooldown opens the circuit again.
+	now = now.Add(time.Minute)
+	require.NoError(t, get())
+	require.EqualValues(t, 3, requests.Load())
+	require.ErrorIs(t, get(), capmox.ErrCircuitOpen)
+
+	// a successful probe closes the circuit.
+	status.Store(http.StatusOK)
+	now = now.Add(time.Minute)
+	require.NoError(t, get())
+	require.False(t, transport.Open())
+	require.NoError(t, get())
+	require.EqualValues(t, 5, requests.Load())
+}
+
+func TestCircuitBreakerTransport_APIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	transport := NewCircuitBreakerTransport(&http.Transport{}, 1, time.Minute)
+	client := &http.Client{Transport: transport}
+	for i := 0; i < 3; i++ {
+		res, err := client.Get(server.URL + "/api2/json/nodes/pve1/qemu/100/status/current")
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+	}
+	require.False(t, transport.Open())
+}
+
+func TestCircuitBreakerTransport_Disabled(t *testing.T) {
+	transport := NewCircuitBreakerTransport(&http.Transport{}, 0, time.Minute)
+	client := &http.Client{Transport: transport}
+	for i := 0; i < 3; i++ {
+		_, err := client.Get("http://127.0.0.1:1/api2/json/version")
+		require.Error(t, err)
+		require.NotErrorIs(t, err, capmox.ErrCircuitOpen)
+	}
+	require.False(t, transport.Open())
+}
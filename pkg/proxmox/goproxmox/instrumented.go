@@ -0,0 +1,523 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/luthermonson/go-proxmox"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+const (
+	// DefaultCallTimeout is the default deadline of a client call, which may consist of several requests.
+	DefaultCallTimeout = 2 * time.Minute
+	// DefaultSlowCallThreshold is the default duration after which a client call is logged as slow.
+	DefaultSlowCallThreshold = 10 * time.Second
+)
+
+var (
+	callDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capmox_proxmox_call_duration_seconds",
+		Help:    "Duration of calls to the Proxmox API client.",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"method", "result"})
+	slowCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capmox_proxmox_slow_calls_total",
+		Help: "Number of calls to the Proxmox API client exceeding the slow call threshold.",
+	}, []string{"method"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(callDuration, slowCalls)
+}
+
+var _ capmox.Client = &InstrumentedClient{}
+
+// InstrumentedClient wraps a capmox.Client. Every call gets a deadline derived from its context,
+// and calls exceeding the slow call threshold are logged and counted, so hung Proxmox nodes
+// become visible instead of silently stalling the workqueue.
+type InstrumentedClient struct {
+	client capmox.Client
+	logger logr.Logger
+
+	// CallTimeout is the deadline of calls without an override. A timeout of 0 disables the deadline.
+	CallTimeout time.Duration
+	// CloneTimeout is the deadline of calls cloning a VM.
+	CloneTimeout time.Duration
+	// SlowCallThreshold is the duration after which a call is logged as slow. A threshold of 0 disables logging.
+	SlowCallThreshold time.Duration
+}
+
+// NewInstrumentedClient creates an InstrumentedClient with the default deadlines and threshold.
+func NewInstrumentedClient(client capmox.Client, logger logr.Logger) *InstrumentedClient {
+	return &InstrumentedClient{
+		client:            client,
+		logger:            logger,
+		CallTimeout:       DefaultCallTimeout,
+		CloneTimeout:      DefaultCloneTimeout + DefaultCallTimeout,
+		SlowCallThreshold: DefaultSlowCallThreshold,
+	}
+}
+
+// instrument runs fn with a deadline and records its duration.
+func instrument[T any](ctx context.Context, c *InstrumentedClient, method string, timeout time.Duration, fn func(context.Context) (T, error)) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result, err := fn(ctx)
+	duration := time.Since(start)
+
+	outcome := "success"
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		outcome = "timeout"
+	case err != nil:
+		outcome = "error"
+	}
+	callDuration.WithLabelValues(method, outcome).Observe(duration.Seconds())
+
+	if c.SlowCallThreshold > 0 && duration >= c.SlowCallThreshold {
+		slowCalls.WithLabelValues(method).Inc()
+		c.logger.Info("slow Proxmox API call", "method", method, "duration", duration.String(), "result", outcome)
+	}
+
+	return result, err
+}
+
+// stopTimeout returns the deadline of calls stopping a VM, which are extended by the duration
+// the guest may take to shut down, so a graceful shutdown is not cut off.
+func (c *InstrumentedClient) stopTimeout(opts capmox.VMStopOptions) time.Duration {
+	if c.CallTimeout <= 0 || opts.Mode != capmox.StopModeShutdown {
+		return c.CallTimeout
+	}
+	if opts.Timeout <= 0 {
+		return c.CallTimeout + capmox.DefaultShutdownTimeout
+	}
+	return c.CallTimeout + opts.Timeout
+}
+
+// AllocateVolume implements capmox.Client.
+func (c *InstrumentedClient) AllocateVolume(ctx context.Context, nodeName, storage string, ownerID int64, filename string, sizeGB int32) (string, error) {
+	return instrument(ctx, c, "AllocateVolume", c.CallTimeout, func(ctx context.Context) (string, error) {
+		return c.client.AllocateVolume(ctx, nodeName, storage, ownerID, filename, sizeGB)
+	})
+}
+
+// BackupVM implements capmox.Client.
+func (c *InstrumentedClient) BackupVM(ctx context.Context, vm *proxmox.VirtualMachine, opts capmox.BackupOptions) (*proxmox.Task, error) {
+	return instrument(ctx, c, "BackupVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.BackupVM(ctx, vm, opts)
+	})
+}
+
+// CloneVM implements capmox.Client.
+func (c *InstrumentedClient) CloneVM(ctx context.Context, templateID int, clone capmox.VMCloneRequest) (capmox.VMCloneResponse, error) {
+	return instrument(ctx, c, "CloneVM", c.CloneTimeout, func(ctx context.Context) (capmox.VMCloneResponse, error) {
+		return c.client.CloneVM(ctx, templateID, clone)
+	})
+}
+
+// ConfigureVM implements capmox.Client.
+func (c *InstrumentedClient) ConfigureVM(ctx context.Context, vm *proxmox.VirtualMachine, options ...capmox.VirtualMachineOption) (*proxmox.Task, error) {
+	return instrument(ctx, c, "ConfigureVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.ConfigureVM(ctx, vm, options...)
+	})
+}
+
+// CreateVM implements capmox.Client.
+func (c *InstrumentedClient) CreateVM(ctx context.Context, nodeName string, options ...capmox.VirtualMachineOption) (capmox.VMCloneResponse, error) {
+	return instrument(ctx, c, "CreateVM", c.CallTimeout, func(ctx context.Context) (capmox.VMCloneResponse, error) {
+		return c.client.CreateVM(ctx, nodeName, options...)
+	})
+}
+
+// FindVMResource implements capmox.Client.
+func (c *InstrumentedClient) FindVMResource(ctx context.Context, vmID uint64) (*proxmox.ClusterResource, error) {
+	return instrument(ctx, c, "FindVMResource", c.CallTimeout, func(ctx context.Context) (*proxmox.ClusterResource, error) {
+		return c.client.FindVMResource(ctx, vmID)
+	})
+}
+
+// GetVM implements capmox.Client.
+func (c *InstrumentedClient) GetVM(ctx context.Context, nodeName string, vmID int64) (*proxmox.VirtualMachine, error) {
+	return instrument(ctx, c, "GetVM", c.CallTimeout, func(ctx context.Context) (*proxmox.VirtualMachine, error) {
+		return c.client.GetVM(ctx, nodeName, vmID)
+	})
+}
+
+// GetVMByID implements capmox.Client.
+func (c *InstrumentedClient) GetVMByID(ctx context.Context, vmID int64) (*proxmox.VirtualMachine, error) {
+	return instrument(ctx, c, "GetVMByID", c.CallTimeout, func(ctx context.Context) (*proxmox.VirtualMachine, error) {
+		return c.client.GetVMByID(ctx, vmID)
+	})
+}
+
+// CreateSnapshot implements capmox.Client.
+func (c *InstrumentedClient) CreateSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string, opts capmox.SnapshotOptions) (*proxmox.Task, error) {
+	return instrument(ctx, c, "CreateSnapshot", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.CreateSnapshot(ctx, vm, name, opts)
+	})
+}
+
+// DeleteSnapshot implements capmox.Client.
+func (c *InstrumentedClient) DeleteSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string) (*proxmox.Task, error) {
+	return instrument(ctx, c, "DeleteSnapshot", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.DeleteSnapshot(ctx, vm, name)
+	})
+}
+
+// DeleteVM implements capmox.Client.
+func (c *InstrumentedClient) DeleteVM(ctx context.Context, nodeName string, vmID int64, opts capmox.VMDeleteOptions) (*proxmox.Task, error) {
+	return instrument(ctx, c, "DeleteVM", c.stopTimeout(opts.Stop), func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.DeleteVM(ctx, nodeName, vmID, opts)
+	})
+}
+
+// DeleteVolume implements capmox.Client.
+func (c *InstrumentedClient) DeleteVolume(ctx context.Context, nodeName, volume string) (*proxmox.Task, error) {
+	return instrument(ctx, c, "DeleteVolume", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.DeleteVolume(ctx, nodeName, volume)
+	})
+}
+
+// DownloadImage implements capmox.Client.
+func (c *InstrumentedClient) DownloadImage(ctx context.Context, nodeName, storage string, opts capmox.ImageDownloadOptions) (*proxmox.Task, error) {
+	return instrument(ctx, c, "DownloadImage", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.DownloadImage(ctx, nodeName, storage, opts)
+	})
+}
+
+// GetStorage implements capmox.Client.
+func (c *InstrumentedClient) GetStorage(ctx context.Context, nodeName, storage string) (capmox.StorageInfo, error) {
+	return instrument(ctx, c, "GetStorage", c.CallTimeout, func(ctx context.Context) (capmox.StorageInfo, error) {
+		return c.client.GetStorage(ctx, nodeName, storage)
+	})
+}
+
+// GetTask implements capmox.Client.
+func (c *InstrumentedClient) GetTask(ctx context.Context, upID string) (*proxmox.Task, error) {
+	return instrument(ctx, c, "GetTask", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.GetTask(ctx, upID)
+	})
+}
+
+// GetPoolNodes implements capmox.Client.
+func (c *InstrumentedClient) GetPoolNodes(ctx context.Context, pool string) ([]string, error) {
+	return instrument(ctx, c, "GetPoolNodes", c.CallTimeout, func(ctx context.Context) ([]string, error) {
+		return c.client.GetPoolNodes(ctx, pool)
+	})
+}
+
+// GetNodeInventories implements capmox.Client.
+func (c *InstrumentedClient) GetNodeInventories(ctx context.Context) ([]capmox.NodeInventory, error) {
+	return instrument(ctx, c, "GetNodeInventories", c.CallTimeout, func(ctx context.Context) ([]capmox.NodeInventory, error) {
+		return c.client.GetNodeInventories(ctx)
+	})
+}
+
+// GetNodeSummaries implements capmox.Client.
+func (c *InstrumentedClient) GetNodeSummaries(ctx context.Context) ([]capmox.NodeSummary, error) {
+	return instrument(ctx, c, "GetNodeSummaries", c.CallTimeout, func(ctx context.Context) ([]capmox.NodeSummary, error) {
+		return c.client.GetNodeSummaries(ctx)
+	})
+}
+
+// GetAPIStatus implements capmox.Client.
+func (c *InstrumentedClient) GetAPIStatus(ctx context.Context) (capmox.APIStatus, error) {
+	return instrument(ctx, c, "GetAPIStatus", c.CallTimeout, func(ctx context.Context) (capmox.APIStatus, error) {
+		return c.client.GetAPIStatus(ctx)
+	})
+}
+
+// GetNodeMemory implements capmox.Client.
+func (c *InstrumentedClient) GetNodeMemory(ctx context.Context, nodeName string, accounting infrav1alpha1.MemoryAccounting) (capmox.NodeMemory, error) {
+	return instrument(ctx, c, "GetNodeMemory", c.CallTimeout, func(ctx context.Context) (capmox.NodeMemory, error) {
+		return c.client.GetNodeMemory(ctx, nodeName, accounting)
+	})
+}
+
+// GetPendingChanges implements capmox.Client.
+func (c *InstrumentedClient) GetPendingChanges(ctx context.Context, vm *proxmox.VirtualMachine) ([]string, error) {
+	return instrument(ctx, c, "GetPendingChanges", c.CallTimeout, func(ctx context.Context) ([]string, error) {
+		return c.client.GetPendingChanges(ctx, vm)
+	})
+}
+
+// GetGuestNetworkInterfaces implements capmox.Client.
+func (c *InstrumentedClient) GetGuestNetworkInterfaces(ctx context.Context, vm *proxmox.VirtualMachine) ([]capmox.GuestNetworkInterface, error) {
+	return instrument(ctx, c, "GetGuestNetworkInterfaces", c.CallTimeout, func(ctx context.Context) ([]capmox.GuestNetworkInterface, error) {
+		return c.client.GetGuestNetworkInterfaces(ctx, vm)
+	})
+}
+
+// GetGuestCommandStatus implements capmox.Client.
+func (c *InstrumentedClient) GetGuestCommandStatus(ctx context.Context, vm *proxmox.VirtualMachine, pid int64) (capmox.GuestCommandStatus, error) {
+	return instrument(ctx, c, "GetGuestCommandStatus", c.CallTimeout, func(ctx context.Context) (capmox.GuestCommandStatus, error) {
+		return c.client.GetGuestCommandStatus(ctx, vm, pid)
+	})
+}
+
+// GetVMArchitecture implements capmox.Client.
+func (c *InstrumentedClient) GetVMArchitecture(ctx context.Context, vm *proxmox.VirtualMachine) (string, error) {
+	return instrument(ctx, c, "GetVMArchitecture", c.CallTimeout, func(ctx context.Context) (string, error) {
+		return c.client.GetVMArchitecture(ctx, vm)
+	})
+}
+
+// HibernateVM implements capmox.Client.
+func (c *InstrumentedClient) HibernateVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return instrument(ctx, c, "HibernateVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.HibernateVM(ctx, vm)
+	})
+}
+
+// ListBridges implements capmox.Client.
+func (c *InstrumentedClient) ListBridges(ctx context.Context, nodeName string) ([]string, error) {
+	return instrument(ctx, c, "ListBridges", c.CallTimeout, func(ctx context.Context) ([]string, error) {
+		return c.client.ListBridges(ctx, nodeName)
+	})
+}
+
+// ListStorages implements capmox.Client.
+func (c *InstrumentedClient) ListStorages(ctx context.Context, nodeName string) ([]capmox.StorageInfo, error) {
+	return instrument(ctx, c, "ListStorages", c.CallTimeout, func(ctx context.Context) ([]capmox.StorageInfo, error) {
+		return c.client.ListStorages(ctx, nodeName)
+	})
+}
+
+// ListSnapshots implements capmox.Client.
+func (c *InstrumentedClient) ListSnapshots(ctx context.Context, vm *proxmox.VirtualMachine) ([]*proxmox.Snapshot, error) {
+	return instrument(ctx, c, "ListSnapshots", c.CallTimeout, func(ctx context.Context) ([]*proxmox.Snapshot, error) {
+		return c.client.ListSnapshots(ctx, vm)
+	})
+}
+
+// ListVMResources implements capmox.Client.
+func (c *InstrumentedClient) ListVMResources(ctx context.Context) ([]*proxmox.ClusterResource, error) {
+	return instrument(ctx, c, "ListVMResources", c.CallTimeout, func(ctx context.Context) ([]*proxmox.ClusterResource, error) {
+		return c.client.ListVMResources(ctx)
+	})
+}
+
+// ListVolumes implements capmox.Client.
+func (c *InstrumentedClient) ListVolumes(ctx context.Context, nodeName, storage, content string) ([]string, error) {
+	return instrument(ctx, c, "ListVolumes", c.CallTimeout, func(ctx context.Context) ([]string, error) {
+		return c.client.ListVolumes(ctx, nodeName, storage, content)
+	})
+}
+
+// MigrateVM implements capmox.Client.
+func (c *InstrumentedClient) MigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, targetNode string, online bool) (*proxmox.Task, error) {
+	return instrument(ctx, c, "MigrateVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.MigrateVM(ctx, vm, targetNode, online)
+	})
+}
+
+// RebootVM implements capmox.Client.
+func (c *InstrumentedClient) RebootVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return instrument(ctx, c, "RebootVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.RebootVM(ctx, vm)
+	})
+}
+
+// RemoteMigrateVM implements capmox.Client.
+func (c *InstrumentedClient) RemoteMigrateVM(ctx context.Context, vm *proxmox.VirtualMachine, opts capmox.RemoteMigrateOptions) (*proxmox.Task, error) {
+	return instrument(ctx, c, "RemoteMigrateVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.RemoteMigrateVM(ctx, vm, opts)
+	})
+}
+
+// ReadGuestFile implements capmox.Client.
+func (c *InstrumentedClient) ReadGuestFile(ctx context.Context, vm *proxmox.VirtualMachine, path string) (string, error) {
+	return instrument(ctx, c, "ReadGuestFile", c.CallTimeout, func(ctx context.Context) (string, error) {
+		return c.client.ReadGuestFile(ctx, vm, path)
+	})
+}
+
+// PingGuestAgent implements capmox.Client.
+func (c *InstrumentedClient) PingGuestAgent(ctx context.Context, vm *proxmox.VirtualMachine) error {
+	_, err := instrument(ctx, c, "PingGuestAgent", c.CallTimeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.client.PingGuestAgent(ctx, vm)
+	})
+	return err
+}
+
+// ResizeDisk implements capmox.Client.
+func (c *InstrumentedClient) ResizeDisk(ctx context.Context, vm *proxmox.VirtualMachine, disk, size string) error {
+	_, err := instrument(ctx, c, "ResizeDisk", c.CallTimeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.client.ResizeDisk(ctx, vm, disk, size)
+	})
+	return err
+}
+
+// ResumeVM implements capmox.Client.
+func (c *InstrumentedClient) ResumeVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return instrument(ctx, c, "ResumeVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.ResumeVM(ctx, vm)
+	})
+}
+
+// RollbackSnapshot implements capmox.Client.
+func (c *InstrumentedClient) RollbackSnapshot(ctx context.Context, vm *proxmox.VirtualMachine, name string) (*proxmox.Task, error) {
+	return instrument(ctx, c, "RollbackSnapshot", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.RollbackSnapshot(ctx, vm, name)
+	})
+}
+
+// ShutdownVM implements capmox.Client. A shutdown is limited by its own timeout in addition to the call timeout.
+func (c *InstrumentedClient) ShutdownVM(ctx context.Context, vm *proxmox.VirtualMachine, opts capmox.VMStopOptions) (*proxmox.Task, error) {
+	return instrument(ctx, c, "ShutdownVM", c.stopTimeout(opts), func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.ShutdownVM(ctx, vm, opts)
+	})
+}
+
+// StartGuestCommand implements capmox.Client.
+func (c *InstrumentedClient) StartGuestCommand(ctx context.Context, vm *proxmox.VirtualMachine, command []string) (int64, error) {
+	return instrument(ctx, c, "StartGuestCommand", c.CallTimeout, func(ctx context.Context) (int64, error) {
+		return c.client.StartGuestCommand(ctx, vm, command)
+	})
+}
+
+// StartVM implements capmox.Client.
+func (c *InstrumentedClient) StartVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return instrument(ctx, c, "StartVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.StartVM(ctx, vm)
+	})
+}
+
+// SuspendVM implements capmox.Client.
+func (c *InstrumentedClient) SuspendVM(ctx context.Context, vm *proxmox.VirtualMachine) (*proxmox.Task, error) {
+	return instrument(ctx, c, "SuspendVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.SuspendVM(ctx, vm)
+	})
+}
+
+// TagVM implements capmox.Client.
+func (c *InstrumentedClient) TagVM(ctx context.Context, vm *proxmox.VirtualMachine, tag string) (*proxmox.Task, error) {
+	return instrument(ctx, c, "TagVM", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.TagVM(ctx, vm, tag)
+	})
+}
+
+// UploadISO implements capmox.Client.
+func (c *InstrumentedClient) UploadISO(ctx context.Context, nodeName, storage, filename string, iso []byte) (*proxmox.Task, error) {
+	return instrument(ctx, c, "UploadISO", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.UploadISO(ctx, nodeName, storage, filename, iso)
+	})
+}
+
+// WaitForTask implements capmox.Client. The wait is limited by its options instead of the call timeout.
+func (c *InstrumentedClient) WaitForTask(ctx context.Context, upID string, opts capmox.TaskWaitOptions) (*proxmox.Task, error) {
+	return instrument(ctx, c, "WaitForTask", 0, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.WaitForTask(ctx, upID, opts)
+	})
+}
+
+// ListSDNZones implements capmox.Client.
+func (c *InstrumentedClient) ListSDNZones(ctx context.Context) ([]capmox.SDNZone, error) {
+	return instrument(ctx, c, "ListSDNZones", c.CallTimeout, func(ctx context.Context) ([]capmox.SDNZone, error) {
+		return c.client.ListSDNZones(ctx)
+	})
+}
+
+// ListSDNVNets implements capmox.Client.
+func (c *InstrumentedClient) ListSDNVNets(ctx context.Context) ([]capmox.SDNVNet, error) {
+	return instrument(ctx, c, "ListSDNVNets", c.CallTimeout, func(ctx context.Context) ([]capmox.SDNVNet, error) {
+		return c.client.ListSDNVNets(ctx)
+	})
+}
+
+// CreateSDNVNet implements capmox.Client.
+func (c *InstrumentedClient) CreateSDNVNet(ctx context.Context, vnet capmox.SDNVNet, subnets ...capmox.SDNSubnet) error {
+	_, err := instrument(ctx, c, "CreateSDNVNet", c.CallTimeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.client.CreateSDNVNet(ctx, vnet, subnets...)
+	})
+	return err
+}
+
+// DeleteSDNVNet implements capmox.Client.
+func (c *InstrumentedClient) DeleteSDNVNet(ctx context.Context, name string) error {
+	_, err := instrument(ctx, c, "DeleteSDNVNet", c.CallTimeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.client.DeleteSDNVNet(ctx, name)
+	})
+	return err
+}
+
+// ListBackupJobs implements capmox.Client.
+func (c *InstrumentedClient) ListBackupJobs(ctx context.Context) ([]capmox.BackupJob, error) {
+	return instrument(ctx, c, "ListBackupJobs", c.CallTimeout, func(ctx context.Context) ([]capmox.BackupJob, error) {
+		return c.client.ListBackupJobs(ctx)
+	})
+}
+
+// CreateBackupJob implements capmox.Client.
+func (c *InstrumentedClient) CreateBackupJob(ctx context.Context, job capmox.BackupJob) error {
+	_, err := instrument(ctx, c, "CreateBackupJob", c.CallTimeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.client.CreateBackupJob(ctx, job)
+	})
+	return err
+}
+
+// UpdateBackupJob implements capmox.Client.
+func (c *InstrumentedClient) UpdateBackupJob(ctx context.Context, job capmox.BackupJob) error {
+	_, err := instrument(ctx, c, "UpdateBackupJob", c.CallTimeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.client.UpdateBackupJob(ctx, job)
+	})
+	return err
+}
+
+// DeleteBackupJob implements capmox.Client.
+func (c *InstrumentedClient) DeleteBackupJob(ctx context.Context, id string) error {
+	_, err := instrument(ctx, c, "DeleteBackupJob", c.CallTimeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.client.DeleteBackupJob(ctx, id)
+	})
+	return err
+}
+
+// ApplySDN implements capmox.Client.
+func (c *InstrumentedClient) ApplySDN(ctx context.Context) (*proxmox.Task, error) {
+	return instrument(ctx, c, "ApplySDN", c.CallTimeout, func(ctx context.Context) (*proxmox.Task, error) {
+		return c.client.ApplySDN(ctx)
+	})
+}
+
+// GetVMFirewall implements capmox.Client.
+func (c *InstrumentedClient) GetVMFirewall(ctx context.Context, vm *proxmox.VirtualMachine) (capmox.VMFirewall, error) {
+	return instrument(ctx, c, "GetVMFirewall", c.CallTimeout, func(ctx context.Context) (capmox.VMFirewall, error) {
+		return c.client.GetVMFirewall(ctx, vm)
+	})
+}
+
+// SetVMFirewall implements capmox.Client.
+func (c *InstrumentedClient) SetVMFirewall(ctx context.Context, vm *proxmox.VirtualMachine, firewall capmox.VMFirewall) error {
+	_, err := instrument(ctx, c, "SetVMFirewall", c.CallTimeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, c.client.SetVMFirewall(ctx, vm, firewall)
+	})
+	return err
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func TestInstrumentedClient_Deadline(t *testing.T) {
+	mockClient := proxmoxtest.NewMockClient(t)
+	// the call receives a derived context, which the typed expecter cannot match.
+	mockClient.On("GetVM", mock.Anything, "pve1", int64(100)).Return(
+		func(ctx context.Context, _ string, _ int64) (*proxmox.VirtualMachine, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	var logs []string
+	logger := funcr.New(func(_, args string) { logs = append(logs, args) }, funcr.Options{})
+
+	client := NewInstrumentedClient(mockClient, logger)
+	client.CallTimeout = 10 * time.Millisecond
+	client.SlowCallThreshold = 5 * time.Millisecond
+
+	_, err := client.GetVM(context.Background(), "pve1", 100)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Len(t, logs, 1)
+	require.Contains(t, logs[0], `"method"="GetVM"`)
+	require.Contains(t, logs[0], `"result"="timeout"`)
+}
+
+func TestInstrumentedClient_FastCall(t *testing.T) {
+	mockClient := proxmoxtest.NewMockClient(t)
+	mockClient.On("GetPoolNodes", mock.Anything, "pool").Return(
+		func(ctx context.Context, _ string) ([]string, error) {
+			_, ok := ctx.Deadline()
+			require.True(t, ok)
+			return []string{"pve1"}, nil
+		})
+
+	var logs []string
+	logger := funcr.New(func(_, args string) { logs = append(logs, args) }, funcr.Options{})
+
+	nodes, err := NewInstrumentedClient(mockClient, logger).GetPoolNodes(context.Background(), "pool")
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve1"}, nodes)
+	require.Empty(t, logs)
+}
+
+func TestInstrumentedClient_ShutdownDeadline(t *testing.T) {
+	opts := capmox.VMStopOptions{Mode: capmox.StopModeShutdown, Timeout: 10 * time.Minute}
+
+	mockClient := proxmoxtest.NewMockClient(t)
+	mockClient.On("DeleteVM", mock.Anything, "pve1", int64(100), capmox.VMDeleteOptions{Stop: opts}).Return(
+		func(ctx context.Context, _ string, _ int64, _ capmox.VMDeleteOptions) (*proxmox.Task, error) {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			require.Greater(t, time.Until(deadline), 10*time.Minute)
+			return nil, nil
+		})
+
+	client := NewInstrumentedClient(mockClient, logr.Discard())
+	_, err := client.DeleteVM(context.Background(), "pve1", 100, capmox.VMDeleteOptions{Stop: opts})
+	require.NoError(t, err)
+
+	require.Equal(t, client.CallTimeout, client.stopTimeout(capmox.VMStopOptions{Mode: capmox.StopModeStop}))
+	require.Equal(t, client.CallTimeout+capmox.DefaultShutdownTimeout, client.stopTimeout(capmox.VMStopOptions{Mode: capmox.StopModeShutdown}))
+
+	client.CallTimeout = 0
+	require.Zero(t, client.stopTimeout(opts))
+}
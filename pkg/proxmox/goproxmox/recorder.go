@@ -0,0 +1,246 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// RecorderMode defines whether a Recorder records or replays API interactions.
+type RecorderMode string
+
+const (
+	// RecorderModeRecord forwards requests to the Proxmox API and records them.
+	RecorderModeRecord RecorderMode = "Record"
+	// RecorderModeReplay answers requests from a fixture without contacting the Proxmox API.
+	RecorderModeReplay RecorderMode = "Replay"
+)
+
+// redacted replaces credentials in recorded interactions.
+const redacted = "redacted"
+
+// Interaction is a recorded request to the Proxmox API and its response.
+type Interaction struct {
+	Method string `json:"method"`
+	// URI is the request path including the query, relative to the host.
+	URI          string `json:"uri"`
+	RequestBody  string `json:"requestBody,omitempty"`
+	Status       string `json:"status"`
+	StatusCode   int    `json:"statusCode"`
+	ResponseBody string `json:"responseBody,omitempty"`
+}
+
+// Fixture is a list of recorded interactions.
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper which records the interactions with the Proxmox API to a fixture file,
+// or replays them from it. It is used with proxmox.WithHTTPClient to write regression tests
+// against the behaviour of specific Proxmox VE versions.
+type Recorder struct {
+	mode        RecorderMode
+	fixturePath string
+	transport   http.RoundTripper
+
+	mu       sync.Mutex
+	fixture  Fixture
+	replayed []bool
+}
+
+// NewRecorder creates a Recorder for the given fixture file. When recording, requests are sent
+// using the given transport, which defaults to http.DefaultTransport. When replaying, the fixture must exist.
+func NewRecorder(mode RecorderMode, fixturePath string, transport http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{
+		mode:        mode,
+		fixturePath: fixturePath,
+		transport:   transport,
+	}
+
+	switch mode {
+	case RecorderModeRecord:
+		if r.transport == nil {
+			r.transport = http.DefaultTransport
+		}
+	case RecorderModeReplay:
+		data, err := os.ReadFile(fixturePath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read fixture: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &r.fixture); err != nil {
+			return nil, fmt.Errorf("cannot parse fixture %s: %w", fixturePath, err)
+		}
+		r.replayed = make([]bool, len(r.fixture.Interactions))
+	default:
+		return nil, fmt.Errorf("unknown recorder mode %q", mode)
+	}
+
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	interaction := Interaction{
+		Method:      req.Method,
+		URI:         req.URL.RequestURI(),
+		RequestBody: redactRequestBody(req.URL.Path, body),
+	}
+
+	if r.mode == RecorderModeReplay {
+		return r.replay(req, interaction)
+	}
+	return r.record(req, interaction)
+}
+
+func (r *Recorder) record(req *http.Request, interaction Interaction) (*http.Response, error) {
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction.Status = res.Status
+	interaction.StatusCode = res.StatusCode
+	interaction.ResponseBody = redactResponseBody(req.URL.Path, body)
+
+	r.mu.Lock()
+	r.fixture.Interactions = append(r.fixture.Interactions, interaction)
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+// replay returns the response of the first interaction matching the request which was not replayed yet.
+func (r *Recorder) replay(req *http.Request, interaction Interaction) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, recorded := range r.fixture.Interactions {
+		if r.replayed[i] || recorded.Method != interaction.Method || recorded.URI != interaction.URI || recorded.RequestBody != interaction.RequestBody {
+			continue
+		}
+		r.replayed[i] = true
+
+		return &http.Response{
+			Status:        recorded.Status,
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json;charset=UTF-8"}},
+			Body:          io.NopCloser(strings.NewReader(recorded.ResponseBody)),
+			ContentLength: int64(len(recorded.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded interaction for %s %s in fixture %s", interaction.Method, interaction.URI, r.fixturePath)
+}
+
+// Save writes the recorded interactions to the fixture file.
+func (r *Recorder) Save() error {
+	if r.mode != RecorderModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := yaml.Marshal(r.fixture)
+	if err != nil {
+		return fmt.Errorf("cannot marshal fixture: %w", err)
+	}
+	if err := os.WriteFile(r.fixturePath, data, 0o600); err != nil {
+		return fmt.Errorf("cannot write fixture: %w", err)
+	}
+	return nil
+}
+
+// Unreplayed returns the recorded interactions which were not replayed, to verify that a test
+// performed all expected requests.
+func (r *Recorder) Unreplayed() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var interactions []Interaction
+	for i, replayed := range r.replayed {
+		if !replayed {
+			interactions = append(interactions, r.fixture.Interactions[i])
+		}
+	}
+	return interactions
+}
+
+func isTicketRequest(path string) bool {
+	return strings.HasSuffix(path, "/access/ticket")
+}
+
+// redactRequestBody removes the password from login requests.
+func redactRequestBody(path string, body []byte) string {
+	if isTicketRequest(path) && len(body) > 0 {
+		return redacted
+	}
+	return string(body)
+}
+
+// redactResponseBody removes the ticket and CSRF token from login responses.
+func redactResponseBody(path string, body []byte) string {
+	if !isTicketRequest(path) {
+		return string(body)
+	}
+
+	var response map[string]map[string]any
+	if err := json.Unmarshal(body, &response); err != nil || response["data"] == nil {
+		return string(body)
+	}
+	for _, key := range []string{"ticket", "CSRFPreventionToken"} {
+		if _, ok := response["data"][key]; ok {
+			response["data"][key] = redacted
+		}
+	}
+
+	redactedBody, err := json.Marshal(response)
+	if err != nil {
+		return string(body)
+	}
+	return string(redactedBody)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/luthermonson/go-proxmox"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/proxmoxtest"
+)
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	fixture := filepath.Join(t.TempDir(), "fixture.yaml")
+
+	sim := proxmoxtest.NewSimulator()
+	sim.AddNode(proxmoxtest.SimulatedNode{Name: "pve1", CPUs: 4, MemoryBytes: 1 << 30})
+	sim.AddVM(proxmoxtest.SimulatedVM{VMID: 100, Node: "pve1", Config: map[string]any{"name": "test", "cores": 2}})
+
+	recorder, err := NewRecorder(RecorderModeRecord, fixture, &http.Transport{})
+	require.NoError(t, err)
+	client, err := NewAPIClient(ctx, logr.Discard(), sim.URL(), proxmox.WithHTTPClient(&http.Client{Transport: recorder}))
+	require.NoError(t, err)
+
+	vm, err := client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	require.Equal(t, 2, vm.VirtualMachineConfig.Cores)
+	_, err = client.GetVM(ctx, "pve1", 101)
+	require.ErrorContains(t, err, "does not exist")
+	require.NoError(t, recorder.Save())
+	sim.Close()
+
+	replayer, err := NewRecorder(RecorderModeReplay, fixture, nil)
+	require.NoError(t, err)
+	client, err = NewAPIClient(ctx, logr.Discard(), sim.URL(), proxmox.WithHTTPClient(&http.Client{Transport: replayer}))
+	require.NoError(t, err)
+
+	vm, err = client.GetVM(ctx, "pve1", 100)
+	require.NoError(t, err)
+	require.Equal(t, "test", vm.Name)
+	require.Equal(t, 2, vm.VirtualMachineConfig.Cores)
+	_, err = client.GetVM(ctx, "pve1", 101)
+	require.ErrorContains(t, err, "does not exist")
+	require.Empty(t, replayer.Unreplayed())
+
+	_, err = client.GetVM(ctx, "pve1", 100)
+	require.ErrorContains(t, err, "no recorded interaction for GET /api2/json/nodes/pve1/status")
+}
+
+func TestRecorder_RedactsCredentials(t *testing.T) {
+	require.Equal(t, redacted, redactRequestBody("/api2/json/access/ticket", []byte(`{"password":"secret"}`)))
+	require.Equal(t, `{"vmid":1}`, redactRequestBody("/api2/json/nodes/pve1/qemu", []byte(`{"vmid":1}`)))
+
+	body := redactResponseBody("/api2/json/access/ticket", []byte(`{"data":{"ticket":"PVE:root@pam:abc","CSRFPreventionToken":"xyz","username":"root@pam"}}`))
+	require.JSONEq(t, `{"data":{"ticket":"redacted","CSRFPreventionToken":"redacted","username":"root@pam"}}`, body)
+}
+
+func TestNewRecorder_MissingFixture(t *testing.T) {
+	_, err := NewRecorder(RecorderModeReplay, filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	require.ErrorContains(t, err, "cannot read fixture")
+}
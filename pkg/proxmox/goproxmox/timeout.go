@@ -0,0 +1,98 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const (
+	// DefaultConnectTimeout is the default timeout for establishing a connection to the Proxmox API.
+	DefaultConnectTimeout = 10 * time.Second
+	// DefaultRequestTimeout is the default timeout of a request to the Proxmox API.
+	DefaultRequestTimeout = time.Minute
+	// DefaultCloneTimeout is the default timeout of a request to clone a VM.
+	// Proxmox VE holds the request while it locks and prepares the clone.
+	DefaultCloneTimeout = 5 * time.Minute
+)
+
+var clonePathPattern = regexp.MustCompile(`/qemu/\d+/clone$`)
+
+// TimeoutTransport is an http.RoundTripper which limits the duration of every request,
+// including reading the response body, so reconciles do not hang on unreachable hosts.
+// A timeout of 0 disables the limit.
+type TimeoutTransport struct {
+	transport http.RoundTripper
+
+	// RequestTimeout is the timeout of requests without an override.
+	RequestTimeout time.Duration
+	// CloneTimeout is the timeout of requests to clone a VM.
+	CloneTimeout time.Duration
+}
+
+// NewTimeoutTransport creates a TimeoutTransport sending requests using the given transport,
+// which defaults to http.DefaultTransport.
+func NewTimeoutTransport(transport http.RoundTripper, requestTimeout, cloneTimeout time.Duration) *TimeoutTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &TimeoutTransport{
+		transport:      transport,
+		RequestTimeout: requestTimeout,
+		CloneTimeout:   cloneTimeout,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *TimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timeout := t.timeout(req)
+	if timeout <= 0 {
+		return t.transport.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	res, err := t.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+func (t *TimeoutTransport) timeout(req *http.Request) time.Duration {
+	if req.Method == http.MethodPost && clonePathPattern.MatchString(req.URL.Path) {
+		return t.CloneTimeout
+	}
+	return t.RequestTimeout
+}
+
+// cancelOnClose releases the context of a request once its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"data":null}`))
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		timeout bool
+	}{
+		{name: "request", method: http.MethodGet, path: "/api2/json/nodes/pve1/qemu/100/status/current", timeout: true},
+		{name: "clone", method: http.MethodPost, path: "/api2/json/nodes/pve1/qemu/100/clone", timeout: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &http.Client{Transport: NewTimeoutTransport(&http.Transport{}, 20*time.Millisecond, time.Second)}
+			req, err := http.NewRequestWithContext(context.Background(), test.method, server.URL+test.path, nil)
+			require.NoError(t, err)
+
+			res, err := client.Do(req)
+			if test.timeout {
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+				return
+			}
+			require.NoError(t, err)
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.Equal(t, `{"data":null}`, string(body))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultMaxIdleConnsPerHost is the default number of idle connections kept to the Proxmox API.
+	// It is larger than the net/http default of 2, as all clusters usually share one endpoint.
+	DefaultMaxIdleConnsPerHost = 16
+	// DefaultIdleConnTimeout is the default duration an idle connection is kept open.
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// TransportOptions configures the HTTP transport used to connect to the Proxmox API.
+type TransportOptions struct {
+	// ConnectTimeout limits establishing a connection, including the TLS handshake.
+	ConnectTimeout time.Duration
+	// MaxIdleConnsPerHost is the number of idle connections kept to the Proxmox API.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is the duration an idle connection is kept open.
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+	// EnableHTTP2 attempts to use HTTP/2, which multiplexes all requests over one connection.
+	EnableHTTP2 bool
+	// ProxyFromEnvironment connects through the proxy of the HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables.
+	// Connections are direct by default, so proxy variables set for other purposes are not picked up.
+	ProxyFromEnvironment bool
+	// TLSConfig is the TLS configuration of connections.
+	TLSConfig *tls.Config
+}
+
+// DefaultTransportOptions returns the default options of the transport.
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		ConnectTimeout:      DefaultConnectTimeout,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+	}
+}
+
+// NewTransport creates an HTTP transport for the Proxmox API. Connections are reused across
+// requests, so many clusters sharing one endpoint do not cause a TLS handshake per request.
+func NewTransport(opts TransportOptions) *http.Transport {
+	var proxy func(*http.Request) (*url.URL, error)
+	if opts.ProxyFromEnvironment {
+		proxy = http.ProxyFromEnvironment
+	}
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   opts.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:     opts.TLSConfig,
+		TLSHandshakeTimeout: opts.ConnectTimeout,
+		MaxIdleConns:        opts.MaxIdleConnsPerHost,
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:     opts.IdleConnTimeout,
+		DisableKeepAlives:   opts.DisableKeepAlives,
+		// a custom TLS config or dialer disables HTTP/2 unless it is forced.
+		ForceAttemptHTTP2: opts.EnableHTTP2,
+	}
+}
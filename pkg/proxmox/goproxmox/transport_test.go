@@ -0,0 +1,51 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package goproxmox
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransport(t *testing.T) {
+	opts := DefaultTransportOptions()
+	opts.MaxIdleConnsPerHost = 32
+	opts.EnableHTTP2 = true
+	opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+
+	transport := NewTransport(opts)
+	require.Equal(t, 32, transport.MaxIdleConnsPerHost)
+	require.Equal(t, 32, transport.MaxIdleConns)
+	require.Equal(t, DefaultIdleConnTimeout, transport.IdleConnTimeout)
+	require.Equal(t, DefaultConnectTimeout, transport.TLSHandshakeTimeout)
+	require.True(t, transport.ForceAttemptHTTP2)
+	require.False(t, transport.DisableKeepAlives)
+	require.Same(t, opts.TLSConfig, transport.TLSClientConfig)
+	require.Nil(t, transport.Proxy)
+
+	opts.DisableKeepAlives = true
+	opts.ConnectTimeout = time.Second
+	transport = NewTransport(opts)
+	require.True(t, transport.DisableKeepAlives)
+	require.Equal(t, time.Second, transport.TLSHandshakeTimeout)
+
+	opts.ProxyFromEnvironment = true
+	require.NotNil(t, NewTransport(opts).Proxy)
+}
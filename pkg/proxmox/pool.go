@@ -0,0 +1,115 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"context"
+	"sync"
+)
+
+// NewClientFunc connects to the Proxmox API at the URL with an API token.
+type NewClientFunc func(ctx context.Context, url, tokenID, secret string) (Client, error)
+
+// ClientPool shares the clients of a Proxmox API endpoint between the objects using the same API token,
+// so that reconciling many objects does not log in and allocate a client for each of them.
+// Clients are reference counted by their owners and dropped once no owner uses them anymore.
+// A client is replaced when an owner acquires it with a different secret, as the token was rotated.
+type ClientPool struct {
+	newClient NewClientFunc
+
+	mu      sync.Mutex
+	entries map[clientKey]*clientEntry
+	owners  map[string]clientKey
+}
+
+type clientKey struct {
+	url     string
+	tokenID string
+}
+
+type clientEntry struct {
+	client Client
+	secret string
+	refs   int
+}
+
+// NewClientPool creates a ClientPool creating clients with the given function.
+func NewClientPool(newClient NewClientFunc) *ClientPool {
+	return &ClientPool{
+		newClient: newClient,
+		entries:   map[clientKey]*clientEntry{},
+		owners:    map[string]clientKey{},
+	}
+}
+
+// Acquire returns the client of the endpoint and token for the owner, creating it if no other owner uses it yet.
+// An owner holds a single client, acquiring a client of another endpoint or token releases the previous one.
+func (p *ClientPool) Acquire(ctx context.Context, owner, url, tokenID, secret string) (Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := clientKey{url: url, tokenID: tokenID}
+	entry, ok := p.entries[key]
+	if !ok || entry.secret != secret {
+		client, err := p.newClient(ctx, url, tokenID, secret)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			entry = &clientEntry{}
+			p.entries[key] = entry
+		}
+		// the other owners get the client with the new secret once they acquire it again.
+		entry.client, entry.secret = client, secret
+	}
+
+	if current, ok := p.owners[owner]; !ok || current != key {
+		p.release(owner)
+		entry.refs++
+		p.owners[owner] = key
+	}
+	return entry.client, nil
+}
+
+// Release drops the reference of the owner, and the client once it has no owners left.
+func (p *ClientPool) Release(owner string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.release(owner)
+}
+
+// Len returns the number of clients in the pool.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+func (p *ClientPool) release(owner string) {
+	key, ok := p.owners[owner]
+	if !ok {
+		return
+	}
+	delete(p.owners, owner)
+
+	if entry := p.entries[key]; entry != nil {
+		entry.refs--
+		if entry.refs <= 0 {
+			delete(p.entries, key)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// poolTestClient is a Client which is only compared by identity.
+type poolTestClient struct {
+	Client
+	secret string
+}
+
+func TestClientPool(t *testing.T) {
+	created := 0
+	pool := NewClientPool(func(_ context.Context, url, tokenID, secret string) (Client, error) {
+		if secret == "invalid" {
+			return nil, errors.New("authentication failed")
+		}
+		created++
+		return &poolTestClient{secret: secret}, nil
+	})
+	ctx := context.Background()
+
+	// owners with the same endpoint and token share a client.
+	first, err := pool.Acquire(ctx, "default/a", "https://source:8006", "capi@pve!import", "secret")
+	require.NoError(t, err)
+	second, err := pool.Acquire(ctx, "default/b", "https://source:8006", "capi@pve!import", "secret")
+	require.NoError(t, err)
+	require.Same(t, first, second)
+	other, err := pool.Acquire(ctx, "default/c", "https://other:8006", "capi@pve!import", "secret")
+	require.NoError(t, err)
+	require.NotSame(t, first, other)
+	require.Equal(t, 2, created)
+	require.Equal(t, 2, pool.Len())
+
+	// a rotated secret replaces the client.
+	rotated, err := pool.Acquire(ctx, "default/a", "https://source:8006", "capi@pve!import", "rotated")
+	require.NoError(t, err)
+	require.NotSame(t, first, rotated)
+	require.Equal(t, "rotated", rotated.(*poolTestClient).secret)
+	_, err = pool.Acquire(ctx, "default/b", "https://source:8006", "capi@pve!import", "invalid")
+	require.Error(t, err)
+
+	// an owner switching the endpoint releases the previous client.
+	_, err = pool.Acquire(ctx, "default/c", "https://source:8006", "capi@pve!import", "rotated")
+	require.NoError(t, err)
+	require.Equal(t, 1, pool.Len())
+
+	pool.Release("default/a")
+	pool.Release("default/b")
+	require.Equal(t, 1, pool.Len())
+	pool.Release("default/c")
+	pool.Release("default/c")
+	require.Zero(t, pool.Len())
+}
@@ -23,6 +23,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	proxmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+
+	v1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 )
 
 // MockClient is an autogenerated mock type for the Client type
@@ -38,6 +40,170 @@ func (_m *MockClient) EXPECT() *MockClient_Expecter {
 	return &MockClient_Expecter{mock: &_m.Mock}
 }
 
+// AllocateVolume provides a mock function with given fields: nodeName, storage, ownerID, filename, sizeGB
+func (_m *MockClient) AllocateVolume(ctx context.Context, nodeName string, storage string, ownerID int64, filename string, sizeGB int32) (string, error) {
+	ret := _m.Called(ctx, nodeName, storage, ownerID, filename, sizeGB)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, string, int32) (string, error)); ok {
+		return rf(ctx, nodeName, storage, ownerID, filename, sizeGB)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, string, int32) string); ok {
+		r0 = rf(ctx, nodeName, storage, ownerID, filename, sizeGB)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64, string, int32) error); ok {
+		r1 = rf(ctx, nodeName, storage, ownerID, filename, sizeGB)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_AllocateVolume_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AllocateVolume'
+type MockClient_AllocateVolume_Call struct {
+	*mock.Call
+}
+
+// AllocateVolume is a helper method to define mock.On call
+//   - nodeName string
+//   - storage string
+//   - ownerID int64
+//   - filename string
+//   - sizeGB int32
+func (_e *MockClient_Expecter) AllocateVolume(ctx context.Context, nodeName interface{}, storage interface{}, ownerID interface{}, filename interface{}, sizeGB interface{}) *MockClient_AllocateVolume_Call {
+	return &MockClient_AllocateVolume_Call{Call: _e.mock.On("AllocateVolume", ctx, nodeName, storage, ownerID, filename, sizeGB)}
+}
+
+func (_c *MockClient_AllocateVolume_Call) Run(run func(ctx context.Context, nodeName string, storage string, ownerID int64, filename string, sizeGB int32)) *MockClient_AllocateVolume_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(int64), args[4].(string), args[5].(int32))
+	})
+	return _c
+}
+
+func (_c *MockClient_AllocateVolume_Call) Return(_a0 string, _a1 error) *MockClient_AllocateVolume_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_AllocateVolume_Call) RunAndReturn(run func(context.Context, string, string, int64, string, int32) (string, error)) *MockClient_AllocateVolume_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ApplySDN provides a mock function with no fields
+func (_m *MockClient) ApplySDN(ctx context.Context) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*go_proxmox.Task, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *go_proxmox.Task); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ApplySDN_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApplySDN'
+type MockClient_ApplySDN_Call struct {
+	*mock.Call
+}
+
+// ApplySDN is a helper method to define mock.On call
+func (_e *MockClient_Expecter) ApplySDN(ctx context.Context) *MockClient_ApplySDN_Call {
+	return &MockClient_ApplySDN_Call{Call: _e.mock.On("ApplySDN", ctx)}
+}
+
+func (_c *MockClient_ApplySDN_Call) Run(run func(ctx context.Context)) *MockClient_ApplySDN_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_ApplySDN_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_ApplySDN_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ApplySDN_Call) RunAndReturn(run func(context.Context) (*go_proxmox.Task, error)) *MockClient_ApplySDN_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// BackupVM provides a mock function with given fields: vm, opts
+func (_m *MockClient) BackupVM(ctx context.Context, vm *go_proxmox.VirtualMachine, opts proxmox.BackupOptions) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, opts)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.BackupOptions) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.BackupOptions) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.BackupOptions) error); ok {
+		r1 = rf(ctx, vm, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_BackupVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BackupVM'
+type MockClient_BackupVM_Call struct {
+	*mock.Call
+}
+
+// BackupVM is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - opts proxmox.BackupOptions
+func (_e *MockClient_Expecter) BackupVM(ctx context.Context, vm interface{}, opts interface{}) *MockClient_BackupVM_Call {
+	return &MockClient_BackupVM_Call{Call: _e.mock.On("BackupVM", ctx, vm, opts)}
+}
+
+func (_c *MockClient_BackupVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, opts proxmox.BackupOptions)) *MockClient_BackupVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(proxmox.BackupOptions))
+	})
+	return _c
+}
+
+func (_c *MockClient_BackupVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_BackupVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_BackupVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, proxmox.BackupOptions) (*go_proxmox.Task, error)) *MockClient_BackupVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CloneVM provides a mock function with given fields: templateID, clone
 func (_m *MockClient) CloneVM(ctx context.Context, templateID int, clone proxmox.VMCloneRequest) (proxmox.VMCloneResponse, error) {
 	ret := _m.Called(ctx, templateID, clone)
@@ -53,8 +219,8 @@ func (_m *MockClient) CloneVM(ctx context.Context, templateID int, clone proxmox
 		r0 = ret.Get(0).(proxmox.VMCloneResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(int, proxmox.VMCloneRequest) error); ok {
-		r1 = rf(templateID, clone)
+	if rf, ok := ret.Get(1).(func(context.Context, int, proxmox.VMCloneRequest) error); ok {
+		r1 = rf(ctx, templateID, clone)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -132,108 +298,2320 @@ type MockClient_ConfigureVM_Call struct {
 
 // ConfigureVM is a helper method to define mock.On call
 //   - vm *go_proxmox.VirtualMachine
-//   - options ...go_proxmox.VirtualMachineOption
-func (_e *MockClient_Expecter) ConfigureVM(ctx context.Context, vm interface{}, options ...interface{}) *MockClient_ConfigureVM_Call {
-	return &MockClient_ConfigureVM_Call{Call: _e.mock.On("ConfigureVM", append([]interface{}{ctx, vm}, options...)...)}
+//   - options ...go_proxmox.VirtualMachineOption
+func (_e *MockClient_Expecter) ConfigureVM(ctx context.Context, vm interface{}, options ...interface{}) *MockClient_ConfigureVM_Call {
+	return &MockClient_ConfigureVM_Call{Call: _e.mock.On("ConfigureVM", append([]interface{}{ctx, vm}, options...)...)}
+}
+
+func (_c *MockClient_ConfigureVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, options ...go_proxmox.VirtualMachineOption)) *MockClient_ConfigureVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]go_proxmox.VirtualMachineOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(go_proxmox.VirtualMachineOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockClient_ConfigureVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_ConfigureVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ConfigureVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, ...go_proxmox.VirtualMachineOption) (*go_proxmox.Task, error)) *MockClient_ConfigureVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateBackupJob provides a mock function with given fields: job
+func (_m *MockClient) CreateBackupJob(ctx context.Context, job proxmox.BackupJob) error {
+	ret := _m.Called(ctx, job)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, proxmox.BackupJob) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_CreateBackupJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateBackupJob'
+type MockClient_CreateBackupJob_Call struct {
+	*mock.Call
+}
+
+// CreateBackupJob is a helper method to define mock.On call
+//   - job proxmox.BackupJob
+func (_e *MockClient_Expecter) CreateBackupJob(ctx context.Context, job interface{}) *MockClient_CreateBackupJob_Call {
+	return &MockClient_CreateBackupJob_Call{Call: _e.mock.On("CreateBackupJob", ctx, job)}
+}
+
+func (_c *MockClient_CreateBackupJob_Call) Run(run func(ctx context.Context, job proxmox.BackupJob)) *MockClient_CreateBackupJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(proxmox.BackupJob))
+	})
+	return _c
+}
+
+func (_c *MockClient_CreateBackupJob_Call) Return(_a0 error) *MockClient_CreateBackupJob_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_CreateBackupJob_Call) RunAndReturn(run func(context.Context, proxmox.BackupJob) error) *MockClient_CreateBackupJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateSDNVNet provides a mock function with given fields: vnet, subnets
+func (_m *MockClient) CreateSDNVNet(ctx context.Context, vnet proxmox.SDNVNet, subnets ...proxmox.SDNSubnet) error {
+	_va := make([]interface{}, len(subnets))
+	for _i := range subnets {
+		_va[_i] = subnets[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, vnet)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, proxmox.SDNVNet, ...proxmox.SDNSubnet) error); ok {
+		r0 = rf(ctx, vnet, subnets...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_CreateSDNVNet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateSDNVNet'
+type MockClient_CreateSDNVNet_Call struct {
+	*mock.Call
+}
+
+// CreateSDNVNet is a helper method to define mock.On call
+//   - vnet proxmox.SDNVNet
+//   - subnets ...proxmox.SDNSubnet
+func (_e *MockClient_Expecter) CreateSDNVNet(ctx context.Context, vnet interface{}, subnets ...interface{}) *MockClient_CreateSDNVNet_Call {
+	return &MockClient_CreateSDNVNet_Call{Call: _e.mock.On("CreateSDNVNet", append([]interface{}{ctx, vnet}, subnets...)...)}
+}
+
+func (_c *MockClient_CreateSDNVNet_Call) Run(run func(ctx context.Context, vnet proxmox.SDNVNet, subnets ...proxmox.SDNSubnet)) *MockClient_CreateSDNVNet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]proxmox.SDNSubnet, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(proxmox.SDNSubnet)
+			}
+		}
+		run(args[0].(context.Context), args[1].(proxmox.SDNVNet), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockClient_CreateSDNVNet_Call) Return(_a0 error) *MockClient_CreateSDNVNet_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_CreateSDNVNet_Call) RunAndReturn(run func(context.Context, proxmox.SDNVNet, ...proxmox.SDNSubnet) error) *MockClient_CreateSDNVNet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateSnapshot provides a mock function with given fields: vm, name, opts
+func (_m *MockClient) CreateSnapshot(ctx context.Context, vm *go_proxmox.VirtualMachine, name string, opts proxmox.SnapshotOptions) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, name, opts)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string, proxmox.SnapshotOptions) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, name, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string, proxmox.SnapshotOptions) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, name, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, string, proxmox.SnapshotOptions) error); ok {
+		r1 = rf(ctx, vm, name, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_CreateSnapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateSnapshot'
+type MockClient_CreateSnapshot_Call struct {
+	*mock.Call
+}
+
+// CreateSnapshot is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - name string
+//   - opts proxmox.SnapshotOptions
+func (_e *MockClient_Expecter) CreateSnapshot(ctx context.Context, vm interface{}, name interface{}, opts interface{}) *MockClient_CreateSnapshot_Call {
+	return &MockClient_CreateSnapshot_Call{Call: _e.mock.On("CreateSnapshot", ctx, vm, name, opts)}
+}
+
+func (_c *MockClient_CreateSnapshot_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, name string, opts proxmox.SnapshotOptions)) *MockClient_CreateSnapshot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string), args[3].(proxmox.SnapshotOptions))
+	})
+	return _c
+}
+
+func (_c *MockClient_CreateSnapshot_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_CreateSnapshot_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_CreateSnapshot_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string, proxmox.SnapshotOptions) (*go_proxmox.Task, error)) *MockClient_CreateSnapshot_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateVM provides a mock function with given fields: nodeName, options
+func (_m *MockClient) CreateVM(ctx context.Context, nodeName string, options ...go_proxmox.VirtualMachineOption) (proxmox.VMCloneResponse, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, nodeName)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 proxmox.VMCloneResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...go_proxmox.VirtualMachineOption) (proxmox.VMCloneResponse, error)); ok {
+		return rf(ctx, nodeName, options...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...go_proxmox.VirtualMachineOption) proxmox.VMCloneResponse); ok {
+		r0 = rf(ctx, nodeName, options...)
+	} else {
+		r0 = ret.Get(0).(proxmox.VMCloneResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...go_proxmox.VirtualMachineOption) error); ok {
+		r1 = rf(ctx, nodeName, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_CreateVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateVM'
+type MockClient_CreateVM_Call struct {
+	*mock.Call
+}
+
+// CreateVM is a helper method to define mock.On call
+//   - nodeName string
+//   - options ...go_proxmox.VirtualMachineOption
+func (_e *MockClient_Expecter) CreateVM(ctx context.Context, nodeName interface{}, options ...interface{}) *MockClient_CreateVM_Call {
+	return &MockClient_CreateVM_Call{Call: _e.mock.On("CreateVM", append([]interface{}{ctx, nodeName}, options...)...)}
+}
+
+func (_c *MockClient_CreateVM_Call) Run(run func(ctx context.Context, nodeName string, options ...go_proxmox.VirtualMachineOption)) *MockClient_CreateVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]go_proxmox.VirtualMachineOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(go_proxmox.VirtualMachineOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockClient_CreateVM_Call) Return(_a0 proxmox.VMCloneResponse, _a1 error) *MockClient_CreateVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_CreateVM_Call) RunAndReturn(run func(context.Context, string, ...go_proxmox.VirtualMachineOption) (proxmox.VMCloneResponse, error)) *MockClient_CreateVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteBackupJob provides a mock function with given fields: id
+func (_m *MockClient) DeleteBackupJob(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_DeleteBackupJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteBackupJob'
+type MockClient_DeleteBackupJob_Call struct {
+	*mock.Call
+}
+
+// DeleteBackupJob is a helper method to define mock.On call
+//   - id string
+func (_e *MockClient_Expecter) DeleteBackupJob(ctx context.Context, id interface{}) *MockClient_DeleteBackupJob_Call {
+	return &MockClient_DeleteBackupJob_Call{Call: _e.mock.On("DeleteBackupJob", ctx, id)}
+}
+
+func (_c *MockClient_DeleteBackupJob_Call) Run(run func(ctx context.Context, id string)) *MockClient_DeleteBackupJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_DeleteBackupJob_Call) Return(_a0 error) *MockClient_DeleteBackupJob_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_DeleteBackupJob_Call) RunAndReturn(run func(context.Context, string) error) *MockClient_DeleteBackupJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteSDNVNet provides a mock function with given fields: name
+func (_m *MockClient) DeleteSDNVNet(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_DeleteSDNVNet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteSDNVNet'
+type MockClient_DeleteSDNVNet_Call struct {
+	*mock.Call
+}
+
+// DeleteSDNVNet is a helper method to define mock.On call
+//   - name string
+func (_e *MockClient_Expecter) DeleteSDNVNet(ctx context.Context, name interface{}) *MockClient_DeleteSDNVNet_Call {
+	return &MockClient_DeleteSDNVNet_Call{Call: _e.mock.On("DeleteSDNVNet", ctx, name)}
+}
+
+func (_c *MockClient_DeleteSDNVNet_Call) Run(run func(ctx context.Context, name string)) *MockClient_DeleteSDNVNet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_DeleteSDNVNet_Call) Return(_a0 error) *MockClient_DeleteSDNVNet_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_DeleteSDNVNet_Call) RunAndReturn(run func(context.Context, string) error) *MockClient_DeleteSDNVNet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteSnapshot provides a mock function with given fields: vm, name
+func (_m *MockClient) DeleteSnapshot(ctx context.Context, vm *go_proxmox.VirtualMachine, name string) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, name)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, string) error); ok {
+		r1 = rf(ctx, vm, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_DeleteSnapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteSnapshot'
+type MockClient_DeleteSnapshot_Call struct {
+	*mock.Call
+}
+
+// DeleteSnapshot is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - name string
+func (_e *MockClient_Expecter) DeleteSnapshot(ctx context.Context, vm interface{}, name interface{}) *MockClient_DeleteSnapshot_Call {
+	return &MockClient_DeleteSnapshot_Call{Call: _e.mock.On("DeleteSnapshot", ctx, vm, name)}
+}
+
+func (_c *MockClient_DeleteSnapshot_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, name string)) *MockClient_DeleteSnapshot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_DeleteSnapshot_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_DeleteSnapshot_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_DeleteSnapshot_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)) *MockClient_DeleteSnapshot_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteVM provides a mock function with given fields: nodeName, vmID, opts
+func (_m *MockClient) DeleteVM(ctx context.Context, nodeName string, vmID int64, opts proxmox.VMDeleteOptions) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, nodeName, vmID, opts)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, proxmox.VMDeleteOptions) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, nodeName, vmID, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, proxmox.VMDeleteOptions) *go_proxmox.Task); ok {
+		r0 = rf(ctx, nodeName, vmID, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, proxmox.VMDeleteOptions) error); ok {
+		r1 = rf(ctx, nodeName, vmID, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_DeleteVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteVM'
+type MockClient_DeleteVM_Call struct {
+	*mock.Call
+}
+
+// DeleteVM is a helper method to define mock.On call
+//   - nodeName string
+//   - vmID int64
+//   - opts proxmox.VMDeleteOptions
+func (_e *MockClient_Expecter) DeleteVM(ctx context.Context, nodeName interface{}, vmID interface{}, opts interface{}) *MockClient_DeleteVM_Call {
+	return &MockClient_DeleteVM_Call{Call: _e.mock.On("DeleteVM", ctx, nodeName, vmID, opts)}
+}
+
+func (_c *MockClient_DeleteVM_Call) Run(run func(ctx context.Context, nodeName string, vmID int64, opts proxmox.VMDeleteOptions)) *MockClient_DeleteVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int64), args[3].(proxmox.VMDeleteOptions))
+	})
+	return _c
+}
+
+func (_c *MockClient_DeleteVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_DeleteVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_DeleteVM_Call) RunAndReturn(run func(context.Context, string, int64, proxmox.VMDeleteOptions) (*go_proxmox.Task, error)) *MockClient_DeleteVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteVolume provides a mock function with given fields: nodeName, volume
+func (_m *MockClient) DeleteVolume(ctx context.Context, nodeName string, volume string) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, nodeName, volume)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, nodeName, volume)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *go_proxmox.Task); ok {
+		r0 = rf(ctx, nodeName, volume)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, nodeName, volume)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_DeleteVolume_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteVolume'
+type MockClient_DeleteVolume_Call struct {
+	*mock.Call
+}
+
+// DeleteVolume is a helper method to define mock.On call
+//   - nodeName string
+//   - volume string
+func (_e *MockClient_Expecter) DeleteVolume(ctx context.Context, nodeName interface{}, volume interface{}) *MockClient_DeleteVolume_Call {
+	return &MockClient_DeleteVolume_Call{Call: _e.mock.On("DeleteVolume", ctx, nodeName, volume)}
+}
+
+func (_c *MockClient_DeleteVolume_Call) Run(run func(ctx context.Context, nodeName string, volume string)) *MockClient_DeleteVolume_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_DeleteVolume_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_DeleteVolume_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_DeleteVolume_Call) RunAndReturn(run func(context.Context, string, string) (*go_proxmox.Task, error)) *MockClient_DeleteVolume_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DownloadImage provides a mock function with given fields: nodeName, storage, opts
+func (_m *MockClient) DownloadImage(ctx context.Context, nodeName string, storage string, opts proxmox.ImageDownloadOptions) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, nodeName, storage, opts)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, proxmox.ImageDownloadOptions) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, nodeName, storage, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, proxmox.ImageDownloadOptions) *go_proxmox.Task); ok {
+		r0 = rf(ctx, nodeName, storage, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, proxmox.ImageDownloadOptions) error); ok {
+		r1 = rf(ctx, nodeName, storage, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_DownloadImage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DownloadImage'
+type MockClient_DownloadImage_Call struct {
+	*mock.Call
+}
+
+// DownloadImage is a helper method to define mock.On call
+//   - nodeName string
+//   - storage string
+//   - opts proxmox.ImageDownloadOptions
+func (_e *MockClient_Expecter) DownloadImage(ctx context.Context, nodeName interface{}, storage interface{}, opts interface{}) *MockClient_DownloadImage_Call {
+	return &MockClient_DownloadImage_Call{Call: _e.mock.On("DownloadImage", ctx, nodeName, storage, opts)}
+}
+
+func (_c *MockClient_DownloadImage_Call) Run(run func(ctx context.Context, nodeName string, storage string, opts proxmox.ImageDownloadOptions)) *MockClient_DownloadImage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(proxmox.ImageDownloadOptions))
+	})
+	return _c
+}
+
+func (_c *MockClient_DownloadImage_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_DownloadImage_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_DownloadImage_Call) RunAndReturn(run func(context.Context, string, string, proxmox.ImageDownloadOptions) (*go_proxmox.Task, error)) *MockClient_DownloadImage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindVMResource provides a mock function with given fields: vmID
+func (_m *MockClient) FindVMResource(ctx context.Context, vmID uint64) (*go_proxmox.ClusterResource, error) {
+	ret := _m.Called(ctx, vmID)
+
+	var r0 *go_proxmox.ClusterResource
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*go_proxmox.ClusterResource, error)); ok {
+		return rf(ctx, vmID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *go_proxmox.ClusterResource); ok {
+		r0 = rf(ctx, vmID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.ClusterResource)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, vmID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_FindVMResource_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindVMResource'
+type MockClient_FindVMResource_Call struct {
+	*mock.Call
+}
+
+// FindVMResource is a helper method to define mock.On call
+//   - vmID uint64
+func (_e *MockClient_Expecter) FindVMResource(ctx context.Context, vmID interface{}) *MockClient_FindVMResource_Call {
+	return &MockClient_FindVMResource_Call{Call: _e.mock.On("FindVMResource", ctx, vmID)}
+}
+
+func (_c *MockClient_FindVMResource_Call) Run(run func(ctx context.Context, vmID uint64)) *MockClient_FindVMResource_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uint64))
+	})
+	return _c
+}
+
+func (_c *MockClient_FindVMResource_Call) Return(_a0 *go_proxmox.ClusterResource, _a1 error) *MockClient_FindVMResource_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_FindVMResource_Call) RunAndReturn(run func(context.Context, uint64) (*go_proxmox.ClusterResource, error)) *MockClient_FindVMResource_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAPIStatus provides a mock function with no fields
+func (_m *MockClient) GetAPIStatus(ctx context.Context) (proxmox.APIStatus, error) {
+	ret := _m.Called(ctx)
+
+	var r0 proxmox.APIStatus
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (proxmox.APIStatus, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) proxmox.APIStatus); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(proxmox.APIStatus)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetAPIStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAPIStatus'
+type MockClient_GetAPIStatus_Call struct {
+	*mock.Call
+}
+
+// GetAPIStatus is a helper method to define mock.On call
+func (_e *MockClient_Expecter) GetAPIStatus(ctx context.Context) *MockClient_GetAPIStatus_Call {
+	return &MockClient_GetAPIStatus_Call{Call: _e.mock.On("GetAPIStatus", ctx)}
+}
+
+func (_c *MockClient_GetAPIStatus_Call) Run(run func(ctx context.Context)) *MockClient_GetAPIStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetAPIStatus_Call) Return(_a0 proxmox.APIStatus, _a1 error) *MockClient_GetAPIStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetAPIStatus_Call) RunAndReturn(run func(context.Context) (proxmox.APIStatus, error)) *MockClient_GetAPIStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGuestCommandStatus provides a mock function with given fields: vm, pid
+func (_m *MockClient) GetGuestCommandStatus(ctx context.Context, vm *go_proxmox.VirtualMachine, pid int64) (proxmox.GuestCommandStatus, error) {
+	ret := _m.Called(ctx, vm, pid)
+
+	var r0 proxmox.GuestCommandStatus
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, int64) (proxmox.GuestCommandStatus, error)); ok {
+		return rf(ctx, vm, pid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, int64) proxmox.GuestCommandStatus); ok {
+		r0 = rf(ctx, vm, pid)
+	} else {
+		r0 = ret.Get(0).(proxmox.GuestCommandStatus)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, int64) error); ok {
+		r1 = rf(ctx, vm, pid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetGuestCommandStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGuestCommandStatus'
+type MockClient_GetGuestCommandStatus_Call struct {
+	*mock.Call
+}
+
+// GetGuestCommandStatus is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - pid int64
+func (_e *MockClient_Expecter) GetGuestCommandStatus(ctx context.Context, vm interface{}, pid interface{}) *MockClient_GetGuestCommandStatus_Call {
+	return &MockClient_GetGuestCommandStatus_Call{Call: _e.mock.On("GetGuestCommandStatus", ctx, vm, pid)}
+}
+
+func (_c *MockClient_GetGuestCommandStatus_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, pid int64)) *MockClient_GetGuestCommandStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetGuestCommandStatus_Call) Return(_a0 proxmox.GuestCommandStatus, _a1 error) *MockClient_GetGuestCommandStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetGuestCommandStatus_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, int64) (proxmox.GuestCommandStatus, error)) *MockClient_GetGuestCommandStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGuestNetworkInterfaces provides a mock function with given fields: vm
+func (_m *MockClient) GetGuestNetworkInterfaces(ctx context.Context, vm *go_proxmox.VirtualMachine) ([]proxmox.GuestNetworkInterface, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 []proxmox.GuestNetworkInterface
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) ([]proxmox.GuestNetworkInterface, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) []proxmox.GuestNetworkInterface); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]proxmox.GuestNetworkInterface)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetGuestNetworkInterfaces_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGuestNetworkInterfaces'
+type MockClient_GetGuestNetworkInterfaces_Call struct {
+	*mock.Call
+}
+
+// GetGuestNetworkInterfaces is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) GetGuestNetworkInterfaces(ctx context.Context, vm interface{}) *MockClient_GetGuestNetworkInterfaces_Call {
+	return &MockClient_GetGuestNetworkInterfaces_Call{Call: _e.mock.On("GetGuestNetworkInterfaces", ctx, vm)}
+}
+
+func (_c *MockClient_GetGuestNetworkInterfaces_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_GetGuestNetworkInterfaces_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetGuestNetworkInterfaces_Call) Return(_a0 []proxmox.GuestNetworkInterface, _a1 error) *MockClient_GetGuestNetworkInterfaces_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetGuestNetworkInterfaces_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) ([]proxmox.GuestNetworkInterface, error)) *MockClient_GetGuestNetworkInterfaces_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetNodeInventories provides a mock function with no fields
+func (_m *MockClient) GetNodeInventories(ctx context.Context) ([]proxmox.NodeInventory, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []proxmox.NodeInventory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]proxmox.NodeInventory, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []proxmox.NodeInventory); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]proxmox.NodeInventory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetNodeInventories_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNodeInventories'
+type MockClient_GetNodeInventories_Call struct {
+	*mock.Call
+}
+
+// GetNodeInventories is a helper method to define mock.On call
+func (_e *MockClient_Expecter) GetNodeInventories(ctx context.Context) *MockClient_GetNodeInventories_Call {
+	return &MockClient_GetNodeInventories_Call{Call: _e.mock.On("GetNodeInventories", ctx)}
+}
+
+func (_c *MockClient_GetNodeInventories_Call) Run(run func(ctx context.Context)) *MockClient_GetNodeInventories_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetNodeInventories_Call) Return(_a0 []proxmox.NodeInventory, _a1 error) *MockClient_GetNodeInventories_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetNodeInventories_Call) RunAndReturn(run func(context.Context) ([]proxmox.NodeInventory, error)) *MockClient_GetNodeInventories_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetNodeMemory provides a mock function with given fields: nodeName, accounting
+func (_m *MockClient) GetNodeMemory(ctx context.Context, nodeName string, accounting v1alpha1.MemoryAccounting) (proxmox.NodeMemory, error) {
+	ret := _m.Called(ctx, nodeName, accounting)
+
+	var r0 proxmox.NodeMemory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, v1alpha1.MemoryAccounting) (proxmox.NodeMemory, error)); ok {
+		return rf(ctx, nodeName, accounting)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, v1alpha1.MemoryAccounting) proxmox.NodeMemory); ok {
+		r0 = rf(ctx, nodeName, accounting)
+	} else {
+		r0 = ret.Get(0).(proxmox.NodeMemory)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, v1alpha1.MemoryAccounting) error); ok {
+		r1 = rf(ctx, nodeName, accounting)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetNodeMemory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNodeMemory'
+type MockClient_GetNodeMemory_Call struct {
+	*mock.Call
+}
+
+// GetNodeMemory is a helper method to define mock.On call
+//   - nodeName string
+//   - accounting v1alpha1.MemoryAccounting
+func (_e *MockClient_Expecter) GetNodeMemory(ctx context.Context, nodeName interface{}, accounting interface{}) *MockClient_GetNodeMemory_Call {
+	return &MockClient_GetNodeMemory_Call{Call: _e.mock.On("GetNodeMemory", ctx, nodeName, accounting)}
+}
+
+func (_c *MockClient_GetNodeMemory_Call) Run(run func(ctx context.Context, nodeName string, accounting v1alpha1.MemoryAccounting)) *MockClient_GetNodeMemory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(v1alpha1.MemoryAccounting))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetNodeMemory_Call) Return(_a0 proxmox.NodeMemory, _a1 error) *MockClient_GetNodeMemory_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetNodeMemory_Call) RunAndReturn(run func(context.Context, string, v1alpha1.MemoryAccounting) (proxmox.NodeMemory, error)) *MockClient_GetNodeMemory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetNodeSummaries provides a mock function with no fields
+func (_m *MockClient) GetNodeSummaries(ctx context.Context) ([]proxmox.NodeSummary, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []proxmox.NodeSummary
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]proxmox.NodeSummary, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []proxmox.NodeSummary); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]proxmox.NodeSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetNodeSummaries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNodeSummaries'
+type MockClient_GetNodeSummaries_Call struct {
+	*mock.Call
+}
+
+// GetNodeSummaries is a helper method to define mock.On call
+func (_e *MockClient_Expecter) GetNodeSummaries(ctx context.Context) *MockClient_GetNodeSummaries_Call {
+	return &MockClient_GetNodeSummaries_Call{Call: _e.mock.On("GetNodeSummaries", ctx)}
+}
+
+func (_c *MockClient_GetNodeSummaries_Call) Run(run func(ctx context.Context)) *MockClient_GetNodeSummaries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetNodeSummaries_Call) Return(_a0 []proxmox.NodeSummary, _a1 error) *MockClient_GetNodeSummaries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetNodeSummaries_Call) RunAndReturn(run func(context.Context) ([]proxmox.NodeSummary, error)) *MockClient_GetNodeSummaries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPendingChanges provides a mock function with given fields: vm
+func (_m *MockClient) GetPendingChanges(ctx context.Context, vm *go_proxmox.VirtualMachine) ([]string, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) ([]string, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) []string); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetPendingChanges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPendingChanges'
+type MockClient_GetPendingChanges_Call struct {
+	*mock.Call
+}
+
+// GetPendingChanges is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) GetPendingChanges(ctx context.Context, vm interface{}) *MockClient_GetPendingChanges_Call {
+	return &MockClient_GetPendingChanges_Call{Call: _e.mock.On("GetPendingChanges", ctx, vm)}
+}
+
+func (_c *MockClient_GetPendingChanges_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_GetPendingChanges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetPendingChanges_Call) Return(_a0 []string, _a1 error) *MockClient_GetPendingChanges_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetPendingChanges_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) ([]string, error)) *MockClient_GetPendingChanges_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPoolNodes provides a mock function with given fields: pool
+func (_m *MockClient) GetPoolNodes(ctx context.Context, pool string) ([]string, error) {
+	ret := _m.Called(ctx, pool)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return rf(ctx, pool)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, pool)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, pool)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetPoolNodes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPoolNodes'
+type MockClient_GetPoolNodes_Call struct {
+	*mock.Call
+}
+
+// GetPoolNodes is a helper method to define mock.On call
+//   - pool string
+func (_e *MockClient_Expecter) GetPoolNodes(ctx context.Context, pool interface{}) *MockClient_GetPoolNodes_Call {
+	return &MockClient_GetPoolNodes_Call{Call: _e.mock.On("GetPoolNodes", ctx, pool)}
+}
+
+func (_c *MockClient_GetPoolNodes_Call) Run(run func(ctx context.Context, pool string)) *MockClient_GetPoolNodes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetPoolNodes_Call) Return(_a0 []string, _a1 error) *MockClient_GetPoolNodes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetPoolNodes_Call) RunAndReturn(run func(context.Context, string) ([]string, error)) *MockClient_GetPoolNodes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetStorage provides a mock function with given fields: nodeName, storage
+func (_m *MockClient) GetStorage(ctx context.Context, nodeName string, storage string) (proxmox.StorageInfo, error) {
+	ret := _m.Called(ctx, nodeName, storage)
+
+	var r0 proxmox.StorageInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (proxmox.StorageInfo, error)); ok {
+		return rf(ctx, nodeName, storage)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) proxmox.StorageInfo); ok {
+		r0 = rf(ctx, nodeName, storage)
+	} else {
+		r0 = ret.Get(0).(proxmox.StorageInfo)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, nodeName, storage)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetStorage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStorage'
+type MockClient_GetStorage_Call struct {
+	*mock.Call
+}
+
+// GetStorage is a helper method to define mock.On call
+//   - nodeName string
+//   - storage string
+func (_e *MockClient_Expecter) GetStorage(ctx context.Context, nodeName interface{}, storage interface{}) *MockClient_GetStorage_Call {
+	return &MockClient_GetStorage_Call{Call: _e.mock.On("GetStorage", ctx, nodeName, storage)}
+}
+
+func (_c *MockClient_GetStorage_Call) Run(run func(ctx context.Context, nodeName string, storage string)) *MockClient_GetStorage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetStorage_Call) Return(_a0 proxmox.StorageInfo, _a1 error) *MockClient_GetStorage_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetStorage_Call) RunAndReturn(run func(context.Context, string, string) (proxmox.StorageInfo, error)) *MockClient_GetStorage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTask provides a mock function with given fields: upID
+func (_m *MockClient) GetTask(ctx context.Context, upID string) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, upID)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, upID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *go_proxmox.Task); ok {
+		r0 = rf(ctx, upID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, upID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetTask_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTask'
+type MockClient_GetTask_Call struct {
+	*mock.Call
+}
+
+// GetTask is a helper method to define mock.On call
+//   - upID string
+func (_e *MockClient_Expecter) GetTask(ctx context.Context, upID interface{}) *MockClient_GetTask_Call {
+	return &MockClient_GetTask_Call{Call: _e.mock.On("GetTask", ctx, upID)}
+}
+
+func (_c *MockClient_GetTask_Call) Run(run func(ctx context.Context, upID string)) *MockClient_GetTask_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetTask_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_GetTask_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetTask_Call) RunAndReturn(run func(context.Context, string) (*go_proxmox.Task, error)) *MockClient_GetTask_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVM provides a mock function with given fields: nodeName, vmID
+func (_m *MockClient) GetVM(ctx context.Context, nodeName string, vmID int64) (*go_proxmox.VirtualMachine, error) {
+	ret := _m.Called(ctx, nodeName, vmID)
+
+	var r0 *go_proxmox.VirtualMachine
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) (*go_proxmox.VirtualMachine, error)); ok {
+		return rf(ctx, nodeName, vmID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *go_proxmox.VirtualMachine); ok {
+		r0 = rf(ctx, nodeName, vmID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.VirtualMachine)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, nodeName, vmID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVM'
+type MockClient_GetVM_Call struct {
+	*mock.Call
+}
+
+// GetVM is a helper method to define mock.On call
+//   - nodeName string
+//   - vmID int64
+func (_e *MockClient_Expecter) GetVM(ctx context.Context, nodeName interface{}, vmID interface{}) *MockClient_GetVM_Call {
+	return &MockClient_GetVM_Call{Call: _e.mock.On("GetVM", ctx, nodeName, vmID)}
+}
+
+func (_c *MockClient_GetVM_Call) Run(run func(ctx context.Context, nodeName string, vmID int64)) *MockClient_GetVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetVM_Call) Return(_a0 *go_proxmox.VirtualMachine, _a1 error) *MockClient_GetVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetVM_Call) RunAndReturn(run func(context.Context, string, int64) (*go_proxmox.VirtualMachine, error)) *MockClient_GetVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVMArchitecture provides a mock function with given fields: vm
+func (_m *MockClient) GetVMArchitecture(ctx context.Context, vm *go_proxmox.VirtualMachine) (string, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (string, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) string); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetVMArchitecture_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVMArchitecture'
+type MockClient_GetVMArchitecture_Call struct {
+	*mock.Call
+}
+
+// GetVMArchitecture is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) GetVMArchitecture(ctx context.Context, vm interface{}) *MockClient_GetVMArchitecture_Call {
+	return &MockClient_GetVMArchitecture_Call{Call: _e.mock.On("GetVMArchitecture", ctx, vm)}
+}
+
+func (_c *MockClient_GetVMArchitecture_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_GetVMArchitecture_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetVMArchitecture_Call) Return(_a0 string, _a1 error) *MockClient_GetVMArchitecture_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetVMArchitecture_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (string, error)) *MockClient_GetVMArchitecture_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVMByID provides a mock function with given fields: vmID
+func (_m *MockClient) GetVMByID(ctx context.Context, vmID int64) (*go_proxmox.VirtualMachine, error) {
+	ret := _m.Called(ctx, vmID)
+
+	var r0 *go_proxmox.VirtualMachine
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*go_proxmox.VirtualMachine, error)); ok {
+		return rf(ctx, vmID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *go_proxmox.VirtualMachine); ok {
+		r0 = rf(ctx, vmID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.VirtualMachine)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, vmID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetVMByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVMByID'
+type MockClient_GetVMByID_Call struct {
+	*mock.Call
+}
+
+// GetVMByID is a helper method to define mock.On call
+//   - vmID int64
+func (_e *MockClient_Expecter) GetVMByID(ctx context.Context, vmID interface{}) *MockClient_GetVMByID_Call {
+	return &MockClient_GetVMByID_Call{Call: _e.mock.On("GetVMByID", ctx, vmID)}
+}
+
+func (_c *MockClient_GetVMByID_Call) Run(run func(ctx context.Context, vmID int64)) *MockClient_GetVMByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetVMByID_Call) Return(_a0 *go_proxmox.VirtualMachine, _a1 error) *MockClient_GetVMByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetVMByID_Call) RunAndReturn(run func(context.Context, int64) (*go_proxmox.VirtualMachine, error)) *MockClient_GetVMByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVMFirewall provides a mock function with given fields: vm
+func (_m *MockClient) GetVMFirewall(ctx context.Context, vm *go_proxmox.VirtualMachine) (proxmox.VMFirewall, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 proxmox.VMFirewall
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (proxmox.VMFirewall, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) proxmox.VMFirewall); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		r0 = ret.Get(0).(proxmox.VMFirewall)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_GetVMFirewall_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVMFirewall'
+type MockClient_GetVMFirewall_Call struct {
+	*mock.Call
+}
+
+// GetVMFirewall is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) GetVMFirewall(ctx context.Context, vm interface{}) *MockClient_GetVMFirewall_Call {
+	return &MockClient_GetVMFirewall_Call{Call: _e.mock.On("GetVMFirewall", ctx, vm)}
+}
+
+func (_c *MockClient_GetVMFirewall_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_GetVMFirewall_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_GetVMFirewall_Call) Return(_a0 proxmox.VMFirewall, _a1 error) *MockClient_GetVMFirewall_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_GetVMFirewall_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (proxmox.VMFirewall, error)) *MockClient_GetVMFirewall_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HibernateVM provides a mock function with given fields: vm
+func (_m *MockClient) HibernateVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_HibernateVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HibernateVM'
+type MockClient_HibernateVM_Call struct {
+	*mock.Call
+}
+
+// HibernateVM is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) HibernateVM(ctx context.Context, vm interface{}) *MockClient_HibernateVM_Call {
+	return &MockClient_HibernateVM_Call{Call: _e.mock.On("HibernateVM", ctx, vm)}
+}
+
+func (_c *MockClient_HibernateVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_HibernateVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_HibernateVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_HibernateVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_HibernateVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)) *MockClient_HibernateVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListBackupJobs provides a mock function with no fields
+func (_m *MockClient) ListBackupJobs(ctx context.Context) ([]proxmox.BackupJob, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []proxmox.BackupJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]proxmox.BackupJob, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []proxmox.BackupJob); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]proxmox.BackupJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListBackupJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListBackupJobs'
+type MockClient_ListBackupJobs_Call struct {
+	*mock.Call
+}
+
+// ListBackupJobs is a helper method to define mock.On call
+func (_e *MockClient_Expecter) ListBackupJobs(ctx context.Context) *MockClient_ListBackupJobs_Call {
+	return &MockClient_ListBackupJobs_Call{Call: _e.mock.On("ListBackupJobs", ctx)}
+}
+
+func (_c *MockClient_ListBackupJobs_Call) Run(run func(ctx context.Context)) *MockClient_ListBackupJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListBackupJobs_Call) Return(_a0 []proxmox.BackupJob, _a1 error) *MockClient_ListBackupJobs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListBackupJobs_Call) RunAndReturn(run func(context.Context) ([]proxmox.BackupJob, error)) *MockClient_ListBackupJobs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListBridges provides a mock function with given fields: nodeName
+func (_m *MockClient) ListBridges(ctx context.Context, nodeName string) ([]string, error) {
+	ret := _m.Called(ctx, nodeName)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return rf(ctx, nodeName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, nodeName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, nodeName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListBridges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListBridges'
+type MockClient_ListBridges_Call struct {
+	*mock.Call
+}
+
+// ListBridges is a helper method to define mock.On call
+//   - nodeName string
+func (_e *MockClient_Expecter) ListBridges(ctx context.Context, nodeName interface{}) *MockClient_ListBridges_Call {
+	return &MockClient_ListBridges_Call{Call: _e.mock.On("ListBridges", ctx, nodeName)}
+}
+
+func (_c *MockClient_ListBridges_Call) Run(run func(ctx context.Context, nodeName string)) *MockClient_ListBridges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListBridges_Call) Return(_a0 []string, _a1 error) *MockClient_ListBridges_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListBridges_Call) RunAndReturn(run func(context.Context, string) ([]string, error)) *MockClient_ListBridges_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListSDNVNets provides a mock function with no fields
+func (_m *MockClient) ListSDNVNets(ctx context.Context) ([]proxmox.SDNVNet, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []proxmox.SDNVNet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]proxmox.SDNVNet, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []proxmox.SDNVNet); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]proxmox.SDNVNet)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListSDNVNets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSDNVNets'
+type MockClient_ListSDNVNets_Call struct {
+	*mock.Call
+}
+
+// ListSDNVNets is a helper method to define mock.On call
+func (_e *MockClient_Expecter) ListSDNVNets(ctx context.Context) *MockClient_ListSDNVNets_Call {
+	return &MockClient_ListSDNVNets_Call{Call: _e.mock.On("ListSDNVNets", ctx)}
+}
+
+func (_c *MockClient_ListSDNVNets_Call) Run(run func(ctx context.Context)) *MockClient_ListSDNVNets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListSDNVNets_Call) Return(_a0 []proxmox.SDNVNet, _a1 error) *MockClient_ListSDNVNets_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListSDNVNets_Call) RunAndReturn(run func(context.Context) ([]proxmox.SDNVNet, error)) *MockClient_ListSDNVNets_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListSDNZones provides a mock function with no fields
+func (_m *MockClient) ListSDNZones(ctx context.Context) ([]proxmox.SDNZone, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []proxmox.SDNZone
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]proxmox.SDNZone, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []proxmox.SDNZone); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]proxmox.SDNZone)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListSDNZones_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSDNZones'
+type MockClient_ListSDNZones_Call struct {
+	*mock.Call
+}
+
+// ListSDNZones is a helper method to define mock.On call
+func (_e *MockClient_Expecter) ListSDNZones(ctx context.Context) *MockClient_ListSDNZones_Call {
+	return &MockClient_ListSDNZones_Call{Call: _e.mock.On("ListSDNZones", ctx)}
+}
+
+func (_c *MockClient_ListSDNZones_Call) Run(run func(ctx context.Context)) *MockClient_ListSDNZones_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListSDNZones_Call) Return(_a0 []proxmox.SDNZone, _a1 error) *MockClient_ListSDNZones_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListSDNZones_Call) RunAndReturn(run func(context.Context) ([]proxmox.SDNZone, error)) *MockClient_ListSDNZones_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListSnapshots provides a mock function with given fields: vm
+func (_m *MockClient) ListSnapshots(ctx context.Context, vm *go_proxmox.VirtualMachine) ([]*go_proxmox.Snapshot, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 []*go_proxmox.Snapshot
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) ([]*go_proxmox.Snapshot, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) []*go_proxmox.Snapshot); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*go_proxmox.Snapshot)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListSnapshots_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSnapshots'
+type MockClient_ListSnapshots_Call struct {
+	*mock.Call
+}
+
+// ListSnapshots is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) ListSnapshots(ctx context.Context, vm interface{}) *MockClient_ListSnapshots_Call {
+	return &MockClient_ListSnapshots_Call{Call: _e.mock.On("ListSnapshots", ctx, vm)}
+}
+
+func (_c *MockClient_ListSnapshots_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_ListSnapshots_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListSnapshots_Call) Return(_a0 []*go_proxmox.Snapshot, _a1 error) *MockClient_ListSnapshots_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListSnapshots_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) ([]*go_proxmox.Snapshot, error)) *MockClient_ListSnapshots_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListStorages provides a mock function with given fields: nodeName
+func (_m *MockClient) ListStorages(ctx context.Context, nodeName string) ([]proxmox.StorageInfo, error) {
+	ret := _m.Called(ctx, nodeName)
+
+	var r0 []proxmox.StorageInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]proxmox.StorageInfo, error)); ok {
+		return rf(ctx, nodeName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []proxmox.StorageInfo); ok {
+		r0 = rf(ctx, nodeName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]proxmox.StorageInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, nodeName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListStorages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListStorages'
+type MockClient_ListStorages_Call struct {
+	*mock.Call
+}
+
+// ListStorages is a helper method to define mock.On call
+//   - nodeName string
+func (_e *MockClient_Expecter) ListStorages(ctx context.Context, nodeName interface{}) *MockClient_ListStorages_Call {
+	return &MockClient_ListStorages_Call{Call: _e.mock.On("ListStorages", ctx, nodeName)}
+}
+
+func (_c *MockClient_ListStorages_Call) Run(run func(ctx context.Context, nodeName string)) *MockClient_ListStorages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListStorages_Call) Return(_a0 []proxmox.StorageInfo, _a1 error) *MockClient_ListStorages_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListStorages_Call) RunAndReturn(run func(context.Context, string) ([]proxmox.StorageInfo, error)) *MockClient_ListStorages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListVMResources provides a mock function with no fields
+func (_m *MockClient) ListVMResources(ctx context.Context) ([]*go_proxmox.ClusterResource, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*go_proxmox.ClusterResource
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*go_proxmox.ClusterResource, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*go_proxmox.ClusterResource); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*go_proxmox.ClusterResource)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListVMResources_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListVMResources'
+type MockClient_ListVMResources_Call struct {
+	*mock.Call
+}
+
+// ListVMResources is a helper method to define mock.On call
+func (_e *MockClient_Expecter) ListVMResources(ctx context.Context) *MockClient_ListVMResources_Call {
+	return &MockClient_ListVMResources_Call{Call: _e.mock.On("ListVMResources", ctx)}
+}
+
+func (_c *MockClient_ListVMResources_Call) Run(run func(ctx context.Context)) *MockClient_ListVMResources_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListVMResources_Call) Return(_a0 []*go_proxmox.ClusterResource, _a1 error) *MockClient_ListVMResources_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListVMResources_Call) RunAndReturn(run func(context.Context) ([]*go_proxmox.ClusterResource, error)) *MockClient_ListVMResources_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListVolumes provides a mock function with given fields: nodeName, storage, content
+func (_m *MockClient) ListVolumes(ctx context.Context, nodeName string, storage string, content string) ([]string, error) {
+	ret := _m.Called(ctx, nodeName, storage, content)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) ([]string, error)); ok {
+		return rf(ctx, nodeName, storage, content)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) []string); ok {
+		r0 = rf(ctx, nodeName, storage, content)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, nodeName, storage, content)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListVolumes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListVolumes'
+type MockClient_ListVolumes_Call struct {
+	*mock.Call
+}
+
+// ListVolumes is a helper method to define mock.On call
+//   - nodeName string
+//   - storage string
+//   - content string
+func (_e *MockClient_Expecter) ListVolumes(ctx context.Context, nodeName interface{}, storage interface{}, content interface{}) *MockClient_ListVolumes_Call {
+	return &MockClient_ListVolumes_Call{Call: _e.mock.On("ListVolumes", ctx, nodeName, storage, content)}
+}
+
+func (_c *MockClient_ListVolumes_Call) Run(run func(ctx context.Context, nodeName string, storage string, content string)) *MockClient_ListVolumes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListVolumes_Call) Return(_a0 []string, _a1 error) *MockClient_ListVolumes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListVolumes_Call) RunAndReturn(run func(context.Context, string, string, string) ([]string, error)) *MockClient_ListVolumes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MigrateVM provides a mock function with given fields: vm, targetNode, online
+func (_m *MockClient) MigrateVM(ctx context.Context, vm *go_proxmox.VirtualMachine, targetNode string, online bool) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, targetNode, online)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string, bool) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, targetNode, online)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string, bool) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, targetNode, online)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, string, bool) error); ok {
+		r1 = rf(ctx, vm, targetNode, online)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_MigrateVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MigrateVM'
+type MockClient_MigrateVM_Call struct {
+	*mock.Call
+}
+
+// MigrateVM is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - targetNode string
+//   - online bool
+func (_e *MockClient_Expecter) MigrateVM(ctx context.Context, vm interface{}, targetNode interface{}, online interface{}) *MockClient_MigrateVM_Call {
+	return &MockClient_MigrateVM_Call{Call: _e.mock.On("MigrateVM", ctx, vm, targetNode, online)}
+}
+
+func (_c *MockClient_MigrateVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, targetNode string, online bool)) *MockClient_MigrateVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string), args[3].(bool))
+	})
+	return _c
+}
+
+func (_c *MockClient_MigrateVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_MigrateVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_MigrateVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string, bool) (*go_proxmox.Task, error)) *MockClient_MigrateVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PingGuestAgent provides a mock function with given fields: vm
+func (_m *MockClient) PingGuestAgent(ctx context.Context, vm *go_proxmox.VirtualMachine) error {
+	ret := _m.Called(ctx, vm)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_PingGuestAgent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PingGuestAgent'
+type MockClient_PingGuestAgent_Call struct {
+	*mock.Call
+}
+
+// PingGuestAgent is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) PingGuestAgent(ctx context.Context, vm interface{}) *MockClient_PingGuestAgent_Call {
+	return &MockClient_PingGuestAgent_Call{Call: _e.mock.On("PingGuestAgent", ctx, vm)}
+}
+
+func (_c *MockClient_PingGuestAgent_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_PingGuestAgent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_PingGuestAgent_Call) Return(_a0 error) *MockClient_PingGuestAgent_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_PingGuestAgent_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) error) *MockClient_PingGuestAgent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReadGuestFile provides a mock function with given fields: vm, path
+func (_m *MockClient) ReadGuestFile(ctx context.Context, vm *go_proxmox.VirtualMachine, path string) (string, error) {
+	ret := _m.Called(ctx, vm, path)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) (string, error)); ok {
+		return rf(ctx, vm, path)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) string); ok {
+		r0 = rf(ctx, vm, path)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, string) error); ok {
+		r1 = rf(ctx, vm, path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ReadGuestFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReadGuestFile'
+type MockClient_ReadGuestFile_Call struct {
+	*mock.Call
+}
+
+// ReadGuestFile is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - path string
+func (_e *MockClient_Expecter) ReadGuestFile(ctx context.Context, vm interface{}, path interface{}) *MockClient_ReadGuestFile_Call {
+	return &MockClient_ReadGuestFile_Call{Call: _e.mock.On("ReadGuestFile", ctx, vm, path)}
+}
+
+func (_c *MockClient_ReadGuestFile_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, path string)) *MockClient_ReadGuestFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_ReadGuestFile_Call) Return(_a0 string, _a1 error) *MockClient_ReadGuestFile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ReadGuestFile_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string) (string, error)) *MockClient_ReadGuestFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RebootVM provides a mock function with given fields: vm
+func (_m *MockClient) RebootVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_RebootVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RebootVM'
+type MockClient_RebootVM_Call struct {
+	*mock.Call
+}
+
+// RebootVM is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) RebootVM(ctx context.Context, vm interface{}) *MockClient_RebootVM_Call {
+	return &MockClient_RebootVM_Call{Call: _e.mock.On("RebootVM", ctx, vm)}
+}
+
+func (_c *MockClient_RebootVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_RebootVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_RebootVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_RebootVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_RebootVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)) *MockClient_RebootVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoteMigrateVM provides a mock function with given fields: vm, opts
+func (_m *MockClient) RemoteMigrateVM(ctx context.Context, vm *go_proxmox.VirtualMachine, opts proxmox.RemoteMigrateOptions) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, opts)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.RemoteMigrateOptions) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.RemoteMigrateOptions) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.RemoteMigrateOptions) error); ok {
+		r1 = rf(ctx, vm, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_RemoteMigrateVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoteMigrateVM'
+type MockClient_RemoteMigrateVM_Call struct {
+	*mock.Call
+}
+
+// RemoteMigrateVM is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - opts proxmox.RemoteMigrateOptions
+func (_e *MockClient_Expecter) RemoteMigrateVM(ctx context.Context, vm interface{}, opts interface{}) *MockClient_RemoteMigrateVM_Call {
+	return &MockClient_RemoteMigrateVM_Call{Call: _e.mock.On("RemoteMigrateVM", ctx, vm, opts)}
+}
+
+func (_c *MockClient_RemoteMigrateVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, opts proxmox.RemoteMigrateOptions)) *MockClient_RemoteMigrateVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(proxmox.RemoteMigrateOptions))
+	})
+	return _c
+}
+
+func (_c *MockClient_RemoteMigrateVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_RemoteMigrateVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_RemoteMigrateVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, proxmox.RemoteMigrateOptions) (*go_proxmox.Task, error)) *MockClient_RemoteMigrateVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResizeDisk provides a mock function with given fields: vm, disk, size
+func (_m *MockClient) ResizeDisk(ctx context.Context, vm *go_proxmox.VirtualMachine, disk string, size string) error {
+	ret := _m.Called(ctx, vm, disk, size)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string, string) error); ok {
+		r0 = rf(ctx, vm, disk, size)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockClient_ResizeDisk_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResizeDisk'
+type MockClient_ResizeDisk_Call struct {
+	*mock.Call
+}
+
+// ResizeDisk is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - disk string
+//   - size string
+func (_e *MockClient_Expecter) ResizeDisk(ctx context.Context, vm interface{}, disk interface{}, size interface{}) *MockClient_ResizeDisk_Call {
+	return &MockClient_ResizeDisk_Call{Call: _e.mock.On("ResizeDisk", ctx, vm, disk, size)}
+}
+
+func (_c *MockClient_ResizeDisk_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, disk string, size string)) *MockClient_ResizeDisk_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_ResizeDisk_Call) Return(_a0 error) *MockClient_ResizeDisk_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockClient_ResizeDisk_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string, string) error) *MockClient_ResizeDisk_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResumeVM provides a mock function with given fields: vm
+func (_m *MockClient) ResumeVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ResumeVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumeVM'
+type MockClient_ResumeVM_Call struct {
+	*mock.Call
+}
+
+// ResumeVM is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) ResumeVM(ctx context.Context, vm interface{}) *MockClient_ResumeVM_Call {
+	return &MockClient_ResumeVM_Call{Call: _e.mock.On("ResumeVM", ctx, vm)}
+}
+
+func (_c *MockClient_ResumeVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_ResumeVM_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+	})
+	return _c
+}
+
+func (_c *MockClient_ResumeVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_ResumeVM_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ResumeVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)) *MockClient_ResumeVM_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RollbackSnapshot provides a mock function with given fields: vm, name
+func (_m *MockClient) RollbackSnapshot(ctx context.Context, vm *go_proxmox.VirtualMachine, name string) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, name)
+
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, string) error); ok {
+		r1 = rf(ctx, vm, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_RollbackSnapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RollbackSnapshot'
+type MockClient_RollbackSnapshot_Call struct {
+	*mock.Call
+}
+
+// RollbackSnapshot is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - name string
+func (_e *MockClient_Expecter) RollbackSnapshot(ctx context.Context, vm interface{}, name interface{}) *MockClient_RollbackSnapshot_Call {
+	return &MockClient_RollbackSnapshot_Call{Call: _e.mock.On("RollbackSnapshot", ctx, vm, name)}
 }
 
-func (_c *MockClient_ConfigureVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, options ...go_proxmox.VirtualMachineOption)) *MockClient_ConfigureVM_Call {
+func (_c *MockClient_RollbackSnapshot_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, name string)) *MockClient_RollbackSnapshot_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]go_proxmox.VirtualMachineOption, len(args)-1)
-		for i, a := range args[1:] {
-			if a != nil {
-				variadicArgs[i] = a.(go_proxmox.VirtualMachineOption)
-			}
-		}
-		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), variadicArgs...)
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *MockClient_ConfigureVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_ConfigureVM_Call {
+func (_c *MockClient_RollbackSnapshot_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_RollbackSnapshot_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockClient_ConfigureVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, ...go_proxmox.VirtualMachineOption) (*go_proxmox.Task, error)) *MockClient_ConfigureVM_Call {
+func (_c *MockClient_RollbackSnapshot_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)) *MockClient_RollbackSnapshot_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteVM provides a mock function with given fields: nodeName, vmID
-func (_m *MockClient) DeleteVM(ctx context.Context, nodeName string, vmID int64) (*go_proxmox.Task, error) {
-	ret := _m.Called(ctx, nodeName, vmID)
-
-	var r0 *go_proxmox.Task
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int64) (*go_proxmox.Task, error)); ok {
-		return rf(ctx, nodeName, vmID)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *go_proxmox.Task); ok {
-		r0 = rf(ctx, nodeName, vmID)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*go_proxmox.Task)
-		}
-	}
+// SetVMFirewall provides a mock function with given fields: vm, firewall
+func (_m *MockClient) SetVMFirewall(ctx context.Context, vm *go_proxmox.VirtualMachine, firewall proxmox.VMFirewall) error {
+	ret := _m.Called(ctx, vm, firewall)
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
-		r1 = rf(ctx, nodeName, vmID)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.VMFirewall) error); ok {
+		r0 = rf(ctx, vm, firewall)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// MockClient_DeleteVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteVM'
-type MockClient_DeleteVM_Call struct {
+// MockClient_SetVMFirewall_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetVMFirewall'
+type MockClient_SetVMFirewall_Call struct {
 	*mock.Call
 }
 
-// DeleteVM is a helper method to define mock.On call
-//   - nodeName string
-//   - vmID int64
-func (_e *MockClient_Expecter) DeleteVM(ctx context.Context, nodeName interface{}, vmID interface{}) *MockClient_DeleteVM_Call {
-	return &MockClient_DeleteVM_Call{Call: _e.mock.On("DeleteVM", ctx, nodeName, vmID)}
+// SetVMFirewall is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - firewall proxmox.VMFirewall
+func (_e *MockClient_Expecter) SetVMFirewall(ctx context.Context, vm interface{}, firewall interface{}) *MockClient_SetVMFirewall_Call {
+	return &MockClient_SetVMFirewall_Call{Call: _e.mock.On("SetVMFirewall", ctx, vm, firewall)}
 }
 
-func (_c *MockClient_DeleteVM_Call) Run(run func(ctx context.Context, nodeName string, vmID int64)) *MockClient_DeleteVM_Call {
+func (_c *MockClient_SetVMFirewall_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, firewall proxmox.VMFirewall)) *MockClient_SetVMFirewall_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(int64))
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(proxmox.VMFirewall))
 	})
 	return _c
 }
 
-func (_c *MockClient_DeleteVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_DeleteVM_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *MockClient_SetVMFirewall_Call) Return(_a0 error) *MockClient_SetVMFirewall_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockClient_DeleteVM_Call) RunAndReturn(run func(context.Context, string, int64) (*go_proxmox.Task, error)) *MockClient_DeleteVM_Call {
+func (_c *MockClient_SetVMFirewall_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, proxmox.VMFirewall) error) *MockClient_SetVMFirewall_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// FindVMResource provides a mock function with given fields: vmID
-func (_m *MockClient) FindVMResource(ctx context.Context, vmID uint64) (*go_proxmox.ClusterResource, error) {
-	ret := _m.Called(ctx, vmID)
+// ShutdownVM provides a mock function with given fields: vm, opts
+func (_m *MockClient) ShutdownVM(ctx context.Context, vm *go_proxmox.VirtualMachine, opts proxmox.VMStopOptions) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, opts)
 
-	var r0 *go_proxmox.ClusterResource
+	var r0 *go_proxmox.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint64) (*go_proxmox.ClusterResource, error)); ok {
-		return rf(ctx, vmID)
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.VMStopOptions) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, opts)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uint64) *go_proxmox.ClusterResource); ok {
-		r0 = rf(ctx, vmID)
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.VMStopOptions) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, opts)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*go_proxmox.ClusterResource)
+			r0 = ret.Get(0).(*go_proxmox.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
-		r1 = rf(ctx, vmID)
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, proxmox.VMStopOptions) error); ok {
+		r1 = rf(ctx, vm, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -241,51 +2619,52 @@ func (_m *MockClient) FindVMResource(ctx context.Context, vmID uint64) (*go_prox
 	return r0, r1
 }
 
-// MockClient_FindVMResource_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindVMResource'
-type MockClient_FindVMResource_Call struct {
+// MockClient_ShutdownVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ShutdownVM'
+type MockClient_ShutdownVM_Call struct {
 	*mock.Call
 }
 
-// FindVMResource is a helper method to define mock.On call
-//   - vmID uint64
-func (_e *MockClient_Expecter) FindVMResource(ctx context.Context, vmID interface{}) *MockClient_FindVMResource_Call {
-	return &MockClient_FindVMResource_Call{Call: _e.mock.On("FindVMResource", ctx, vmID)}
+// ShutdownVM is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - opts proxmox.VMStopOptions
+func (_e *MockClient_Expecter) ShutdownVM(ctx context.Context, vm interface{}, opts interface{}) *MockClient_ShutdownVM_Call {
+	return &MockClient_ShutdownVM_Call{Call: _e.mock.On("ShutdownVM", ctx, vm, opts)}
 }
 
-func (_c *MockClient_FindVMResource_Call) Run(run func(ctx context.Context, vmID uint64)) *MockClient_FindVMResource_Call {
+func (_c *MockClient_ShutdownVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, opts proxmox.VMStopOptions)) *MockClient_ShutdownVM_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uint64))
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(proxmox.VMStopOptions))
 	})
 	return _c
 }
 
-func (_c *MockClient_FindVMResource_Call) Return(_a0 *go_proxmox.ClusterResource, _a1 error) *MockClient_FindVMResource_Call {
+func (_c *MockClient_ShutdownVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_ShutdownVM_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockClient_FindVMResource_Call) RunAndReturn(run func(context.Context, uint64) (*go_proxmox.ClusterResource, error)) *MockClient_FindVMResource_Call {
+func (_c *MockClient_ShutdownVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, proxmox.VMStopOptions) (*go_proxmox.Task, error)) *MockClient_ShutdownVM_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetReservableMemoryBytes provides a mock function with given fields: nodeName
-func (_m *MockClient) GetReservableMemoryBytes(ctx context.Context, nodeName string) (uint64, error) {
-	ret := _m.Called(ctx, nodeName)
+// StartGuestCommand provides a mock function with given fields: vm, command
+func (_m *MockClient) StartGuestCommand(ctx context.Context, vm *go_proxmox.VirtualMachine, command []string) (int64, error) {
+	ret := _m.Called(ctx, vm, command)
 
-	var r0 uint64
+	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (uint64, error)); ok {
-		return rf(ctx, nodeName)
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, []string) (int64, error)); ok {
+		return rf(ctx, vm, command)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) uint64); ok {
-		r0 = rf(ctx, nodeName)
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, []string) int64); ok {
+		r0 = rf(ctx, vm, command)
 	} else {
-		r0 = ret.Get(0).(uint64)
+		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, nodeName)
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, []string) error); ok {
+		r1 = rf(ctx, vm, command)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -293,53 +2672,54 @@ func (_m *MockClient) GetReservableMemoryBytes(ctx context.Context, nodeName str
 	return r0, r1
 }
 
-// MockClient_GetReservableMemoryBytes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetReservableMemoryBytes'
-type MockClient_GetReservableMemoryBytes_Call struct {
+// MockClient_StartGuestCommand_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartGuestCommand'
+type MockClient_StartGuestCommand_Call struct {
 	*mock.Call
 }
 
-// GetReservableMemoryBytes is a helper method to define mock.On call
-//   - nodeName string
-func (_e *MockClient_Expecter) GetReservableMemoryBytes(ctx context.Context, nodeName interface{}) *MockClient_GetReservableMemoryBytes_Call {
-	return &MockClient_GetReservableMemoryBytes_Call{Call: _e.mock.On("GetReservableMemoryBytes", ctx, nodeName)}
+// StartGuestCommand is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+//   - command []string
+func (_e *MockClient_Expecter) StartGuestCommand(ctx context.Context, vm interface{}, command interface{}) *MockClient_StartGuestCommand_Call {
+	return &MockClient_StartGuestCommand_Call{Call: _e.mock.On("StartGuestCommand", ctx, vm, command)}
 }
 
-func (_c *MockClient_GetReservableMemoryBytes_Call) Run(run func(ctx context.Context, nodeName string)) *MockClient_GetReservableMemoryBytes_Call {
+func (_c *MockClient_StartGuestCommand_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, command []string)) *MockClient_StartGuestCommand_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].([]string))
 	})
 	return _c
 }
 
-func (_c *MockClient_GetReservableMemoryBytes_Call) Return(_a0 uint64, _a1 error) *MockClient_GetReservableMemoryBytes_Call {
+func (_c *MockClient_StartGuestCommand_Call) Return(_a0 int64, _a1 error) *MockClient_StartGuestCommand_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockClient_GetReservableMemoryBytes_Call) RunAndReturn(run func(context.Context, string) (uint64, error)) *MockClient_GetReservableMemoryBytes_Call {
+func (_c *MockClient_StartGuestCommand_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, []string) (int64, error)) *MockClient_StartGuestCommand_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetTask provides a mock function with given fields: upID
-func (_m *MockClient) GetTask(ctx context.Context, upID string) (*go_proxmox.Task, error) {
-	ret := _m.Called(ctx, upID)
+// StartVM provides a mock function with given fields: vm
+func (_m *MockClient) StartVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm)
 
 	var r0 *go_proxmox.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*go_proxmox.Task, error)); ok {
-		return rf(ctx, upID)
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *go_proxmox.Task); ok {
-		r0 = rf(ctx, upID)
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*go_proxmox.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, upID)
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -347,53 +2727,53 @@ func (_m *MockClient) GetTask(ctx context.Context, upID string) (*go_proxmox.Tas
 	return r0, r1
 }
 
-// MockClient_GetTask_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTask'
-type MockClient_GetTask_Call struct {
+// MockClient_StartVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartVM'
+type MockClient_StartVM_Call struct {
 	*mock.Call
 }
 
-// GetTask is a helper method to define mock.On call
-//   - upID string
-func (_e *MockClient_Expecter) GetTask(ctx context.Context, upID interface{}) *MockClient_GetTask_Call {
-	return &MockClient_GetTask_Call{Call: _e.mock.On("GetTask", ctx, upID)}
+// StartVM is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) StartVM(ctx context.Context, vm interface{}) *MockClient_StartVM_Call {
+	return &MockClient_StartVM_Call{Call: _e.mock.On("StartVM", ctx, vm)}
 }
 
-func (_c *MockClient_GetTask_Call) Run(run func(ctx context.Context, upID string)) *MockClient_GetTask_Call {
+func (_c *MockClient_StartVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_StartVM_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
 	})
 	return _c
 }
 
-func (_c *MockClient_GetTask_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_GetTask_Call {
+func (_c *MockClient_StartVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_StartVM_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockClient_GetTask_Call) RunAndReturn(run func(context.Context, string) (*go_proxmox.Task, error)) *MockClient_GetTask_Call {
+func (_c *MockClient_StartVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)) *MockClient_StartVM_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetVM provides a mock function with given fields: nodeName, vmID
-func (_m *MockClient) GetVM(ctx context.Context, nodeName string, vmID int64) (*go_proxmox.VirtualMachine, error) {
-	ret := _m.Called(ctx, nodeName, vmID)
+// SuspendVM provides a mock function with given fields: vm
+func (_m *MockClient) SuspendVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm)
 
-	var r0 *go_proxmox.VirtualMachine
+	var r0 *go_proxmox.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int64) (*go_proxmox.VirtualMachine, error)); ok {
-		return rf(ctx, nodeName, vmID)
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *go_proxmox.VirtualMachine); ok {
-		r0 = rf(ctx, nodeName, vmID)
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*go_proxmox.VirtualMachine)
+			r0 = ret.Get(0).(*go_proxmox.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
-		r1 = rf(ctx, nodeName, vmID)
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine) error); ok {
+		r1 = rf(ctx, vm)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -401,152 +2781,150 @@ func (_m *MockClient) GetVM(ctx context.Context, nodeName string, vmID int64) (*
 	return r0, r1
 }
 
-// MockClient_GetVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVM'
-type MockClient_GetVM_Call struct {
+// MockClient_SuspendVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SuspendVM'
+type MockClient_SuspendVM_Call struct {
 	*mock.Call
 }
 
-// GetVM is a helper method to define mock.On call
-//   - nodeName string
-//   - vmID int64
-func (_e *MockClient_Expecter) GetVM(ctx context.Context, nodeName interface{}, vmID interface{}) *MockClient_GetVM_Call {
-	return &MockClient_GetVM_Call{Call: _e.mock.On("GetVM", ctx, nodeName, vmID)}
+// SuspendVM is a helper method to define mock.On call
+//   - vm *go_proxmox.VirtualMachine
+func (_e *MockClient_Expecter) SuspendVM(ctx context.Context, vm interface{}) *MockClient_SuspendVM_Call {
+	return &MockClient_SuspendVM_Call{Call: _e.mock.On("SuspendVM", ctx, vm)}
 }
 
-func (_c *MockClient_GetVM_Call) Run(run func(ctx context.Context, nodeName string, vmID int64)) *MockClient_GetVM_Call {
+func (_c *MockClient_SuspendVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_SuspendVM_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(int64))
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
 	})
 	return _c
 }
 
-func (_c *MockClient_GetVM_Call) Return(_a0 *go_proxmox.VirtualMachine, _a1 error) *MockClient_GetVM_Call {
+func (_c *MockClient_SuspendVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_SuspendVM_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockClient_GetVM_Call) RunAndReturn(run func(context.Context, string, int64) (*go_proxmox.VirtualMachine, error)) *MockClient_GetVM_Call {
+func (_c *MockClient_SuspendVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)) *MockClient_SuspendVM_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ResizeDisk provides a mock function with given fields: vm, disk, size
-func (_m *MockClient) ResizeDisk(ctx context.Context, vm *go_proxmox.VirtualMachine, disk string, size string) error {
-	ret := _m.Called(ctx, vm, disk, size)
+// TagVM provides a mock function with given fields: vm, tag
+func (_m *MockClient) TagVM(ctx context.Context, vm *go_proxmox.VirtualMachine, tag string) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, vm, tag)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string, string) error); ok {
-		r0 = rf(ctx, vm, disk, size)
+	var r0 *go_proxmox.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, vm, tag)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) *go_proxmox.Task); ok {
+		r0 = rf(ctx, vm, tag)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*go_proxmox.Task)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, *go_proxmox.VirtualMachine, string) error); ok {
+		r1 = rf(ctx, vm, tag)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockClient_ResizeDisk_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResizeDisk'
-type MockClient_ResizeDisk_Call struct {
+// MockClient_TagVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TagVM'
+type MockClient_TagVM_Call struct {
 	*mock.Call
 }
 
-// ResizeDisk is a helper method to define mock.On call
+// TagVM is a helper method to define mock.On call
 //   - vm *go_proxmox.VirtualMachine
-//   - disk string
-//   - size string
-func (_e *MockClient_Expecter) ResizeDisk(ctx context.Context, vm interface{}, disk interface{}, size interface{}) *MockClient_ResizeDisk_Call {
-	return &MockClient_ResizeDisk_Call{Call: _e.mock.On("ResizeDisk", ctx, vm, disk, size)}
+//   - tag string
+func (_e *MockClient_Expecter) TagVM(ctx context.Context, vm interface{}, tag interface{}) *MockClient_TagVM_Call {
+	return &MockClient_TagVM_Call{Call: _e.mock.On("TagVM", ctx, vm, tag)}
 }
 
-func (_c *MockClient_ResizeDisk_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, disk string, size string)) *MockClient_ResizeDisk_Call {
+func (_c *MockClient_TagVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, tag string)) *MockClient_TagVM_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string), args[3].(string))
+		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *MockClient_ResizeDisk_Call) Return(_a0 error) *MockClient_ResizeDisk_Call {
-	_c.Call.Return(_a0)
+func (_c *MockClient_TagVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_TagVM_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockClient_ResizeDisk_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string, string) error) *MockClient_ResizeDisk_Call {
+func (_c *MockClient_TagVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)) *MockClient_TagVM_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ResumeVM provides a mock function with given fields: vm
-func (_m *MockClient) ResumeVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
-	ret := _m.Called(ctx, vm)
-
-	var r0 *go_proxmox.Task
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)); ok {
-		return rf(ctx, vm)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) *go_proxmox.Task); ok {
-		r0 = rf(ctx, vm)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*go_proxmox.Task)
-		}
-	}
+// UpdateBackupJob provides a mock function with given fields: job
+func (_m *MockClient) UpdateBackupJob(ctx context.Context, job proxmox.BackupJob) error {
+	ret := _m.Called(ctx, job)
 
-	if rf, ok := ret.Get(1).(func(*go_proxmox.VirtualMachine) error); ok {
-		r1 = rf(vm)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, proxmox.BackupJob) error); ok {
+		r0 = rf(ctx, job)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// MockClient_ResumeVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumeVM'
-type MockClient_ResumeVM_Call struct {
+// MockClient_UpdateBackupJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateBackupJob'
+type MockClient_UpdateBackupJob_Call struct {
 	*mock.Call
 }
 
-// ResumeVM is a helper method to define mock.On call
-//   - vm *go_proxmox.VirtualMachine
-func (_e *MockClient_Expecter) ResumeVM(ctx context.Context, vm interface{}) *MockClient_ResumeVM_Call {
-	return &MockClient_ResumeVM_Call{Call: _e.mock.On("ResumeVM", ctx, vm)}
+// UpdateBackupJob is a helper method to define mock.On call
+//   - job proxmox.BackupJob
+func (_e *MockClient_Expecter) UpdateBackupJob(ctx context.Context, job interface{}) *MockClient_UpdateBackupJob_Call {
+	return &MockClient_UpdateBackupJob_Call{Call: _e.mock.On("UpdateBackupJob", ctx, job)}
 }
 
-func (_c *MockClient_ResumeVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_ResumeVM_Call {
+func (_c *MockClient_UpdateBackupJob_Call) Run(run func(ctx context.Context, job proxmox.BackupJob)) *MockClient_UpdateBackupJob_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+		run(args[0].(context.Context), args[1].(proxmox.BackupJob))
 	})
 	return _c
 }
 
-func (_c *MockClient_ResumeVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_ResumeVM_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *MockClient_UpdateBackupJob_Call) Return(_a0 error) *MockClient_UpdateBackupJob_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockClient_ResumeVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)) *MockClient_ResumeVM_Call {
+func (_c *MockClient_UpdateBackupJob_Call) RunAndReturn(run func(context.Context, proxmox.BackupJob) error) *MockClient_UpdateBackupJob_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// StartVM provides a mock function with given fields: vm
-func (_m *MockClient) StartVM(ctx context.Context, vm *go_proxmox.VirtualMachine) (*go_proxmox.Task, error) {
-	ret := _m.Called(ctx, vm)
+// UploadISO provides a mock function with given fields: nodeName, storage, filename, iso
+func (_m *MockClient) UploadISO(ctx context.Context, nodeName string, storage string, filename string, iso []byte) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, nodeName, storage, filename, iso)
 
 	var r0 *go_proxmox.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)); ok {
-		return rf(ctx, vm)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []byte) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, nodeName, storage, filename, iso)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine) *go_proxmox.Task); ok {
-		r0 = rf(ctx, vm)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []byte) *go_proxmox.Task); ok {
+		r0 = rf(ctx, nodeName, storage, filename, iso)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*go_proxmox.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*go_proxmox.VirtualMachine) error); ok {
-		r1 = rf(vm)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, []byte) error); ok {
+		r1 = rf(ctx, nodeName, storage, filename, iso)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -554,53 +2932,56 @@ func (_m *MockClient) StartVM(ctx context.Context, vm *go_proxmox.VirtualMachine
 	return r0, r1
 }
 
-// MockClient_StartVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StartVM'
-type MockClient_StartVM_Call struct {
+// MockClient_UploadISO_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UploadISO'
+type MockClient_UploadISO_Call struct {
 	*mock.Call
 }
 
-// StartVM is a helper method to define mock.On call
-//   - vm *go_proxmox.VirtualMachine
-func (_e *MockClient_Expecter) StartVM(ctx context.Context, vm interface{}) *MockClient_StartVM_Call {
-	return &MockClient_StartVM_Call{Call: _e.mock.On("StartVM", ctx, vm)}
+// UploadISO is a helper method to define mock.On call
+//   - nodeName string
+//   - storage string
+//   - filename string
+//   - iso []byte
+func (_e *MockClient_Expecter) UploadISO(ctx context.Context, nodeName interface{}, storage interface{}, filename interface{}, iso interface{}) *MockClient_UploadISO_Call {
+	return &MockClient_UploadISO_Call{Call: _e.mock.On("UploadISO", ctx, nodeName, storage, filename, iso)}
 }
 
-func (_c *MockClient_StartVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine)) *MockClient_StartVM_Call {
+func (_c *MockClient_UploadISO_Call) Run(run func(ctx context.Context, nodeName string, storage string, filename string, iso []byte)) *MockClient_UploadISO_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(*go_proxmox.VirtualMachine))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string), args[4].([]byte))
 	})
 	return _c
 }
 
-func (_c *MockClient_StartVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_StartVM_Call {
+func (_c *MockClient_UploadISO_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_UploadISO_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockClient_StartVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine) (*go_proxmox.Task, error)) *MockClient_StartVM_Call {
+func (_c *MockClient_UploadISO_Call) RunAndReturn(run func(context.Context, string, string, string, []byte) (*go_proxmox.Task, error)) *MockClient_UploadISO_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// TagVM provides a mock function with given fields: vm, tag
-func (_m *MockClient) TagVM(ctx context.Context, vm *go_proxmox.VirtualMachine, tag string) (*go_proxmox.Task, error) {
-	ret := _m.Called(ctx, vm, tag)
+// WaitForTask provides a mock function with given fields: upID, opts
+func (_m *MockClient) WaitForTask(ctx context.Context, upID string, opts proxmox.TaskWaitOptions) (*go_proxmox.Task, error) {
+	ret := _m.Called(ctx, upID, opts)
 
 	var r0 *go_proxmox.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)); ok {
-		return rf(ctx, vm, tag)
+	if rf, ok := ret.Get(0).(func(context.Context, string, proxmox.TaskWaitOptions) (*go_proxmox.Task, error)); ok {
+		return rf(ctx, upID, opts)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *go_proxmox.VirtualMachine, string) *go_proxmox.Task); ok {
-		r0 = rf(ctx, vm, tag)
+	if rf, ok := ret.Get(0).(func(context.Context, string, proxmox.TaskWaitOptions) *go_proxmox.Task); ok {
+		r0 = rf(ctx, upID, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*go_proxmox.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*go_proxmox.VirtualMachine, string) error); ok {
-		r1 = rf(vm, tag)
+	if rf, ok := ret.Get(1).(func(context.Context, string, proxmox.TaskWaitOptions) error); ok {
+		r1 = rf(ctx, upID, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -608,31 +2989,31 @@ func (_m *MockClient) TagVM(ctx context.Context, vm *go_proxmox.VirtualMachine,
 	return r0, r1
 }
 
-// MockClient_TagVM_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TagVM'
-type MockClient_TagVM_Call struct {
+// MockClient_WaitForTask_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WaitForTask'
+type MockClient_WaitForTask_Call struct {
 	*mock.Call
 }
 
-// TagVM is a helper method to define mock.On call
-//   - vm *go_proxmox.VirtualMachine
-//   - tag string
-func (_e *MockClient_Expecter) TagVM(ctx context.Context, vm interface{}, tag interface{}) *MockClient_TagVM_Call {
-	return &MockClient_TagVM_Call{Call: _e.mock.On("TagVM", ctx, vm, tag)}
+// WaitForTask is a helper method to define mock.On call
+//   - upID string
+//   - opts proxmox.TaskWaitOptions
+func (_e *MockClient_Expecter) WaitForTask(ctx context.Context, upID interface{}, opts interface{}) *MockClient_WaitForTask_Call {
+	return &MockClient_WaitForTask_Call{Call: _e.mock.On("WaitForTask", ctx, upID, opts)}
 }
 
-func (_c *MockClient_TagVM_Call) Run(run func(ctx context.Context, vm *go_proxmox.VirtualMachine, tag string)) *MockClient_TagVM_Call {
+func (_c *MockClient_WaitForTask_Call) Run(run func(ctx context.Context, upID string, opts proxmox.TaskWaitOptions)) *MockClient_WaitForTask_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[0].(*go_proxmox.VirtualMachine), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(proxmox.TaskWaitOptions))
 	})
 	return _c
 }
 
-func (_c *MockClient_TagVM_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_TagVM_Call {
+func (_c *MockClient_WaitForTask_Call) Return(_a0 *go_proxmox.Task, _a1 error) *MockClient_WaitForTask_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockClient_TagVM_Call) RunAndReturn(run func(context.Context, *go_proxmox.VirtualMachine, string) (*go_proxmox.Task, error)) *MockClient_TagVM_Call {
+func (_c *MockClient_WaitForTask_Call) RunAndReturn(run func(context.Context, string, proxmox.TaskWaitOptions) (*go_proxmox.Task, error)) *MockClient_WaitForTask_Call {
 	_c.Call.Return(run)
 	return _c
 }
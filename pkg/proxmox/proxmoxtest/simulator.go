@@ -0,0 +1,1734 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmoxtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SimulatorRelease is the Proxmox VE release reported by the simulator.
+	SimulatorRelease = "8.1"
+
+	// SimulatorISOStorage is the storage of every simulated node which holds ISO images.
+	SimulatorISOStorage = "local"
+	// SimulatorImageStorage is the storage of every simulated node which holds VM disks.
+	SimulatorImageStorage = "local-lvm"
+	// SimulatorBridge is the bridge of simulated nodes which do not define their bridges.
+	SimulatorBridge = "vmbr0"
+
+	simulatorAPIPrefix = "/api2/json"
+	simulatorUser      = "root@pam"
+	simulatorFirstVMID = 100
+
+	simulatorStatusRunning = "running"
+	simulatorStatusStopped = "stopped"
+	simulatorStatusPaused  = "paused"
+
+	mib = 1024 * 1024
+)
+
+// SimulatedNode describes a node of the simulated Proxmox VE cluster.
+type SimulatedNode struct {
+	Name        string
+	CPUModel    string
+	CPUs        int
+	MemoryBytes uint64
+	// Description holds the notes of the node, which may contain a `tags:` line.
+	Description string
+	Offline     bool
+	// Bridges are the network bridges of the node. It defaults to SimulatorBridge.
+	Bridges []string
+}
+
+// SimulatedVM is the state of a virtual machine in the simulator.
+type SimulatedVM struct {
+	VMID     uint64
+	Node     string
+	Pool     string
+	Template bool
+	// Status is one of running, stopped or paused. An empty status means stopped.
+	Status string
+	// Config holds the applied config options of the VM in their API representation.
+	Config map[string]any
+	// Pending holds the config options which are applied on the next start of the VM.
+	// A nil value marks an option as pending deletion.
+	Pending map[string]any
+	// Snapshots holds the snapshots of the VM, from the oldest to the newest.
+	Snapshots []SimulatedSnapshot
+	// FirewallOptions holds the firewall options of the VM, like enable and policy_in.
+	FirewallOptions map[string]any
+	// FirewallRules holds the firewall rules of the VM, from the first to the last position.
+	FirewallRules []map[string]any
+	// GuestInterfaces are the network interfaces the QEMU guest agent reports while the VM runs
+	// and its config enables the agent.
+	GuestInterfaces []SimulatedGuestInterface
+	// GuestCommands are the results of the commands the QEMU guest agent runs, by their arguments
+	// joined with spaces. Other commands fail to start, like missing executables.
+	GuestCommands map[string]SimulatedGuestCommand
+	// GuestFiles are the content of the files the QEMU guest agent reads, by their path.
+	GuestFiles map[string]string
+
+	// guestProcesses are the commands started by the QEMU guest agent, the process ID is the index plus one.
+	// They are lost when the VM is stopped or rebooted.
+	guestProcesses []SimulatedGuestCommand
+}
+
+// SimulatedGuestCommand is the result of a command which the QEMU guest agent runs in a virtual machine.
+type SimulatedGuestCommand struct {
+	ExitCode int
+	Output   string
+	// RunningPolls is the number of status requests for which the command is still reported as running.
+	RunningPolls int
+}
+
+// SimulatedGuestInterface is a network interface inside the guest of a virtual machine.
+type SimulatedGuestInterface struct {
+	Name       string
+	MACAddress string
+	// IPAddresses are the addresses of the interface in CIDR notation, like 10.0.0.10/24.
+	IPAddresses []string
+}
+
+// SimulatedSnapshot is a snapshot of a virtual machine in the simulator.
+type SimulatedSnapshot struct {
+	Name        string
+	Description string
+	// VMState is true if the snapshot includes the memory of the running VM.
+	VMState bool
+	Config  map[string]any
+}
+
+type simulatedTask struct {
+	upid       string
+	node       string
+	taskType   string
+	id         string
+	pid        int
+	startTime  int64
+	exitStatus string
+	// runningPolls is the number of status requests for which the task is still reported as running.
+	runningPolls int
+	finished     bool
+	log          []string
+}
+
+type simulatedISO struct {
+	node    string
+	storage string
+	name    string
+	content []byte
+}
+
+// simulatorError is returned by handlers. Proxmox VE reports most errors in the status line.
+type simulatorError struct {
+	status  int
+	message string
+	params  map[string]string
+}
+
+func (e *simulatorError) Error() string {
+	return fmt.Sprintf("%d %s", e.status, e.message)
+}
+
+func errNotFound(format string, args ...any) error {
+	return &simulatorError{status: http.StatusInternalServerError, message: fmt.Sprintf(format, args...)}
+}
+
+func errParameter(param, message string) error {
+	return &simulatorError{
+		status:  http.StatusBadRequest,
+		message: "Parameter verification failed.",
+		params:  map[string]string{param: message},
+	}
+}
+
+// Simulator is an in-memory Proxmox VE API served by an httptest.Server.
+// It covers the nodes, bridges, qemu, firewall, tasks, pools, cluster resources, SDN, backup jobs, ISO and volume storage endpoints
+// used by the provider. All tasks complete immediately and successfully.
+type Simulator struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	nodes   map[string]*SimulatedNode
+	vms     map[uint64]*SimulatedVM
+	tasks   map[string]*simulatedTask
+	isos    map[string]*simulatedISO
+	backups map[string][]string
+	volumes map[string]*SimulatedVolume
+	// sharedStorages hold ISO images and are available on every node.
+	sharedStorages []string
+	pools          map[string]struct{}
+	sdnZones       map[string]string
+	sdnVNets       map[string]*SimulatedSDNVNet
+	backupJobs     map[string]*SimulatedBackupJob
+	securityGroups map[string]struct{}
+	taskCount      int
+	// taskPolls and taskExitStatus apply to new tasks.
+	taskPolls      int
+	taskExitStatus string
+	// noQuorum makes the cluster status report a cluster without quorum.
+	noQuorum bool
+
+	username     string
+	password     string
+	verifyTOTP   func(code string) bool
+	tickets      map[string]string
+	tfaChallenge map[string]struct{}
+	ticketCount  int
+}
+
+// NewSimulator starts a new simulator without any nodes. It must be closed after use.
+func NewSimulator() *Simulator {
+	s := &Simulator{
+		nodes:          make(map[string]*SimulatedNode),
+		vms:            make(map[uint64]*SimulatedVM),
+		tasks:          make(map[string]*simulatedTask),
+		isos:           make(map[string]*simulatedISO),
+		backups:        make(map[string][]string),
+		volumes:        make(map[string]*SimulatedVolume),
+		pools:          make(map[string]struct{}),
+		sdnZones:       make(map[string]string),
+		sdnVNets:       make(map[string]*SimulatedSDNVNet),
+		backupJobs:     make(map[string]*SimulatedBackupJob),
+		securityGroups: make(map[string]struct{}),
+		tickets:        make(map[string]string),
+		tfaChallenge:   make(map[string]struct{}),
+	}
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// URL returns the base URL of the simulator, as used by goproxmox.NewAPIClient.
+func (s *Simulator) URL() string {
+	return s.server.URL
+}
+
+// Close shuts down the simulator.
+func (s *Simulator) Close() {
+	s.server.Close()
+}
+
+// AddNode adds a node to the simulated cluster.
+func (s *Simulator) AddNode(node SimulatedNode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := node
+	s.nodes[n.Name] = &n
+}
+
+// AddPool adds an empty resource pool.
+func (s *Simulator) AddPool(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pools[name] = struct{}{}
+}
+
+// AddVM adds a virtual machine or template. It replaces any VM with the same ID.
+func (s *Simulator) AddVM(vm SimulatedVM) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.vms[vm.VMID] = vm.copy()
+}
+
+// SetCredentials requires all requests to be authenticated with a ticket,
+// which is issued for the given username and password.
+func (s *Simulator) SetCredentials(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.username, s.password = username, password
+}
+
+// RequireTOTP enables two-factor authentication for the user set with SetCredentials.
+// Codes are accepted if verify returns true.
+func (s *Simulator) RequireTOTP(verify func(code string) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.verifyTOTP = verify
+}
+
+// ExpireTickets invalidates all issued tickets.
+func (s *Simulator) ExpireTickets() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tickets = make(map[string]string)
+}
+
+// DelayTasks makes new tasks report as running for the given number of status requests.
+func (s *Simulator) DelayTasks(polls int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.taskPolls = polls
+}
+
+// FailTasks makes new tasks exit with the given status. An empty status lets them succeed again.
+func (s *Simulator) FailTasks(exitStatus string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.taskExitStatus = exitStatus
+}
+
+// LoseQuorum makes the cluster status report whether the simulated cluster has lost quorum.
+func (s *Simulator) LoseQuorum(lost bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.noQuorum = lost
+}
+
+// VM returns a copy of the state of the VM with the given ID.
+func (s *Simulator) VM(vmid uint64) (SimulatedVM, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vm, ok := s.vms[vmid]
+	if !ok {
+		return SimulatedVM{}, false
+	}
+	return *vm.copy(), true
+}
+
+// AddSharedISOStorage adds a storage for ISO images and snippets which is shared by all nodes, like an NFS storage.
+func (s *Simulator) AddSharedISOStorage(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sharedStorages = append(s.sharedStorages, name)
+}
+
+// ISO returns the content of an ISO image uploaded to the ISO storage of a node.
+func (s *Simulator) ISO(node, name string) ([]byte, bool) {
+	return s.StorageISO(node, SimulatorISOStorage, name)
+}
+
+// StorageISO returns the content of an ISO image uploaded to a storage of a node.
+func (s *Simulator) StorageISO(node, storage, name string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	iso, ok := s.isos[isoKey(s.isoNode(node, storage), storage, name)]
+	if !ok {
+		return nil, false
+	}
+	return iso.content, true
+}
+
+// Backups returns the volume IDs of the backups created on the given node.
+func (s *Simulator) Backups(node string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.backups[node]...)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, ok := strings.CutPrefix(r.URL.Path, simulatorAPIPrefix)
+	if !ok {
+		writeSimulatorError(w, &simulatorError{status: http.StatusNotFound, message: "Not Found"})
+		return
+	}
+
+	params, err := requestParams(r)
+	if err != nil {
+		writeSimulatorError(w, &simulatorError{status: http.StatusBadRequest, message: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	var data any
+	if r.Method == http.MethodPost && path == "/access/ticket" {
+		data, err = s.login(params)
+	} else if err = s.authenticate(r); err == nil {
+		data, err = s.route(r.Method, strings.Split(strings.Trim(path, "/"), "/"), params)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		writeSimulatorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func (s *Simulator) login(params map[string]any) (any, error) {
+	username := paramString(params, "username")
+	password := paramString(params, "password")
+	failure := &simulatorError{status: http.StatusUnauthorized, message: "authentication failure"}
+	if s.username == "" || username != s.username {
+		return nil, failure
+	}
+
+	if challenge := paramString(params, "tfa-challenge"); challenge != "" {
+		code, ok := strings.CutPrefix(password, "totp:")
+		if _, known := s.tfaChallenge[challenge]; !known || !ok || s.verifyTOTP == nil || !s.verifyTOTP(code) {
+			return nil, failure
+		}
+		delete(s.tfaChallenge, challenge)
+		return s.issueTicket(username), nil
+	}
+
+	if password != s.password {
+		return nil, failure
+	}
+	if s.verifyTOTP != nil {
+		s.ticketCount++
+		challenge := fmt.Sprintf("PVE:!tfa!%s:%08X", username, s.ticketCount)
+		s.tfaChallenge[challenge] = struct{}{}
+		return map[string]any{"username": username, "ticket": challenge, "CSRFPreventionToken": "", "NeedTFA": 1}, nil
+	}
+	return s.issueTicket(username), nil
+}
+
+func (s *Simulator) issueTicket(username string) map[string]any {
+	s.ticketCount++
+	ticket := fmt.Sprintf("PVE:%s:%08X", username, s.ticketCount)
+	csrfToken := fmt.Sprintf("%08X:simulator", s.ticketCount)
+	s.tickets[ticket] = csrfToken
+	return map[string]any{"username": username, "ticket": ticket, "CSRFPreventionToken": csrfToken}
+}
+
+// authenticate verifies the ticket of a request if credentials are set.
+func (s *Simulator) authenticate(r *http.Request) error {
+	if s.username == "" {
+		return nil
+	}
+
+	unauthorized := &simulatorError{status: http.StatusUnauthorized, message: "No ticket"}
+	cookie, err := r.Cookie("PVEAuthCookie")
+	if err != nil {
+		return unauthorized
+	}
+	csrfToken, ok := s.tickets[cookie.Value]
+	if !ok {
+		return unauthorized
+	}
+	if r.Method != http.MethodGet && r.Header.Get("CSRFPreventionToken") != csrfToken {
+		return &simulatorError{status: http.StatusUnauthorized, message: "Permission check failed (invalid csrf token)"}
+	}
+	return nil
+}
+
+func (s *Simulator) route(method string, p []string, params map[string]any) (any, error) {
+	route := method + " " + strings.Join(p, "/")
+	n := len(p)
+
+	switch {
+	case route == "GET version":
+		return map[string]any{"release": SimulatorRelease, "version": SimulatorRelease + ".0", "repoid": "simulator"}, nil
+	case route == "GET cluster/status":
+		return s.clusterStatus(), nil
+	case route == "GET cluster/nextid":
+		return strconv.FormatUint(s.nextID(), 10), nil
+	case route == "GET cluster/resources":
+		return s.clusterResources(paramString(params, "type")), nil
+	case method == http.MethodGet && n == 2 && p[0] == "pools":
+		return s.pool(p[1], paramString(params, "type"))
+	case n >= 2 && p[0] == "cluster" && p[1] == "sdn":
+		return s.routeSDN(method, p[2:], params)
+	case n >= 2 && p[0] == "cluster" && p[1] == "backup":
+		return s.routeBackupJobs(method, p[2:], params)
+	case route == "GET nodes":
+		return s.nodeList(), nil
+	case n >= 3 && p[0] == "nodes":
+		return s.routeNode(method, p[1], p[2:], params)
+	}
+
+	return nil, &simulatorError{status: http.StatusNotImplemented, message: fmt.Sprintf("Method '%s /%s' not implemented", method, strings.Join(p, "/"))}
+}
+
+func (s *Simulator) routeNode(method, nodeName string, p []string, params map[string]any) (any, error) {
+	node, ok := s.nodes[nodeName]
+	if !ok {
+		return nil, errNotFound("no such cluster node '%s'", nodeName)
+	}
+	if node.Offline {
+		return nil, &simulatorError{status: 595, message: fmt.Sprintf("no route to host '%s'", nodeName)}
+	}
+
+	route := method + " " + strings.Join(p, "/")
+	n := len(p)
+
+	switch {
+	case route == "GET status":
+		return s.nodeStatus(node), nil
+	case route == "GET config":
+		return map[string]any{"description": node.Description, "digest": "simulator"}, nil
+	case route == "GET qemu":
+		return s.vmList(node.Name), nil
+	case route == "GET storage":
+		return s.storages(node.Name), nil
+	case route == "GET network":
+		return nodeBridges(node), nil
+	case route == "POST vzdump":
+		return s.vzdump(node.Name, params)
+	case method == http.MethodGet && n == 3 && p[0] == "storage" && p[2] == "status":
+		return s.storage(node.Name, p[1])
+	case method == http.MethodPost && n == 3 && p[0] == "storage" && p[2] == "upload":
+		return s.uploadISO(node.Name, p[1], params)
+	case method == http.MethodPost && n == 3 && p[0] == "storage" && p[2] == "content":
+		return s.allocateVolume(node.Name, p[1], params)
+	case n >= 4 && p[0] == "storage" && p[2] == "content":
+		// volume IDs contain slashes, like local:iso/image.iso.
+		return s.storageContent(method, node.Name, p[1], strings.Join(p[3:], "/"))
+	case method == http.MethodGet && n == 3 && p[0] == "tasks" && p[2] == "status":
+		return s.taskStatus(node.Name, p[1])
+	case method == http.MethodGet && n == 3 && p[0] == "tasks" && p[2] == "log":
+		return s.taskLog(node.Name, p[1], params)
+	case n >= 2 && p[0] == "qemu":
+		vmid, err := strconv.ParseUint(p[1], 10, 64)
+		if err != nil {
+			return nil, errParameter("vmid", "type check ('integer') failed")
+		}
+		vm, ok := s.vms[vmid]
+		if !ok || vm.Node != node.Name {
+			return nil, errNotFound("Configuration file 'nodes/%s/qemu-server/%d.conf' does not exist", node.Name, vmid)
+		}
+		return s.routeVM(method, vm, p[2:], params)
+	}
+
+	return nil, &simulatorError{status: http.StatusNotImplemented, message: fmt.Sprintf("Method '%s /nodes/%s/%s' not implemented", method, nodeName, strings.Join(p, "/"))}
+}
+
+func (s *Simulator) routeVM(method string, vm *SimulatedVM, p []string, params map[string]any) (any, error) {
+	route := method + " " + strings.Join(p, "/")
+
+	switch {
+	case route == "DELETE ":
+		return s.deleteVM(vm)
+	case route == "GET config":
+		return vm.currentConfig(), nil
+	case route == "POST config", route == "PUT config":
+		if err := s.configureVM(vm, params); err != nil {
+			return nil, err
+		}
+		if method == http.MethodPut {
+			return nil, nil
+		}
+		return s.newTask(vm.Node, "qmconfig", vm.VMID), nil
+	case route == "GET pending":
+		return vm.pendingChanges(), nil
+	case route == "GET status/current":
+		return vm.status(), nil
+	case route == "GET agent/network-get-interfaces":
+		return vm.guestInterfaces()
+	case route == "POST agent/ping":
+		return nil, vm.agentRunning()
+	case route == "POST agent/exec":
+		return vm.startGuestCommand(params)
+	case route == "GET agent/exec-status":
+		return vm.guestCommandStatus(params)
+	case route == "GET agent/file-read":
+		return vm.readGuestFile(params)
+	case method == http.MethodPost && len(p) == 2 && p[0] == "status":
+		return s.changeVMStatus(vm, p[1], params)
+	case route == "POST clone":
+		return s.cloneVM(vm, params)
+	case route == "POST migrate":
+		return s.migrateVM(vm, params)
+	case route == "GET snapshot":
+		return vm.snapshotList(), nil
+	case route == "POST snapshot":
+		return s.createSnapshot(vm, params)
+	case method == http.MethodDelete && len(p) == 2 && p[0] == "snapshot":
+		return s.deleteSnapshot(vm, p[1])
+	case method == http.MethodPost && len(p) == 3 && p[0] == "snapshot" && p[2] == "rollback":
+		return s.rollbackSnapshot(vm, p[1])
+	case route == "PUT resize":
+		return nil, s.resizeDisk(vm, paramString(params, "disk"), paramString(params, "size"))
+	case len(p) >= 2 && p[0] == "firewall":
+		return s.routeVMFirewall(method, vm, p[1:], params)
+	}
+
+	return nil, &simulatorError{status: http.StatusNotImplemented, message: fmt.Sprintf("Method '%s /nodes/%s/qemu/%d/%s' not implemented", method, vm.Node, vm.VMID, strings.Join(p, "/"))}
+}
+
+func (s *Simulator) newTask(node, taskType string, id any) string {
+	s.taskCount++
+	now := time.Now().Unix()
+	task := &simulatedTask{
+		node:         node,
+		taskType:     taskType,
+		id:           fmt.Sprint(id),
+		pid:          s.taskCount,
+		startTime:    now,
+		exitStatus:   "OK",
+		runningPolls: s.taskPolls,
+	}
+	if s.taskExitStatus != "" {
+		task.exitStatus = s.taskExitStatus
+	}
+	task.upid = fmt.Sprintf("UPID:%s:%08X:%08X:%08X:%s:%s:%s:", node, task.pid, task.pid, now, taskType, task.id, simulatorUser)
+	task.log = []string{fmt.Sprintf("%s %s started", taskType, task.id)}
+	if task.runningPolls == 0 {
+		task.finish()
+	}
+	s.tasks[task.upid] = task
+	return task.upid
+}
+
+func (s *Simulator) taskStatus(node, upid string) (any, error) {
+	task, ok := s.tasks[upid]
+	if !ok || task.node != node {
+		return nil, errNotFound("no such task '%s'", upid)
+	}
+
+	if task.runningPolls > 0 {
+		task.runningPolls--
+		task.log = append(task.log, fmt.Sprintf("%s %s in progress", task.taskType, task.id))
+		return map[string]any{
+			"upid":      task.upid,
+			"node":      task.node,
+			"pid":       task.pid,
+			"pstart":    task.pid,
+			"starttime": task.startTime,
+			"type":      task.taskType,
+			"id":        task.id,
+			"user":      simulatorUser,
+			"status":    "running",
+		}, nil
+	}
+	if !task.finished {
+		task.finish()
+	}
+
+	return map[string]any{
+		"upid":       task.upid,
+		"node":       task.node,
+		"pid":        task.pid,
+		"pstart":     task.pid,
+		"starttime":  task.startTime,
+		"endtime":    task.startTime,
+		"type":       task.taskType,
+		"id":         task.id,
+		"user":       simulatorUser,
+		"status":     "stopped",
+		"exitstatus": task.exitStatus,
+	}, nil
+}
+
+// taskLog returns the lines of the task log from the zero based start line, numbered from one like Proxmox VE does.
+func (s *Simulator) taskLog(node, upid string, params map[string]any) (any, error) {
+	task, ok := s.tasks[upid]
+	if !ok || task.node != node {
+		return nil, errNotFound("no such task '%s'", upid)
+	}
+
+	start, _ := strconv.Atoi(paramString(params, "start"))
+	limit, err := strconv.Atoi(paramString(params, "limit"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	lines := []map[string]any{}
+	for i := start; i < len(task.log) && i < start+limit; i++ {
+		lines = append(lines, map[string]any{"n": i + 1, "t": task.log[i]})
+	}
+	return lines, nil
+}
+
+func (t *simulatedTask) finish() {
+	t.finished = true
+	if t.exitStatus == "OK" {
+		t.log = append(t.log, "TASK OK")
+	} else {
+		t.log = append(t.log, "TASK ERROR: "+t.exitStatus)
+	}
+}
+
+func (s *Simulator) sortedNodes() []*SimulatedNode {
+	nodes := make([]*SimulatedNode, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
+
+func (s *Simulator) sortedVMs() []*SimulatedVM {
+	vms := make([]*SimulatedVM, 0, len(s.vms))
+	for _, vm := range s.vms {
+		vms = append(vms, vm)
+	}
+	sort.Slice(vms, func(i, j int) bool { return vms[i].VMID < vms[j].VMID })
+	return vms
+}
+
+func (s *Simulator) clusterStatus() []map[string]any {
+	status := []map[string]any{{
+		"type":    "cluster",
+		"id":      "cluster",
+		"name":    "simulator",
+		"nodes":   len(s.nodes),
+		"quorate": boolInt(!s.noQuorum),
+		"version": 1,
+	}}
+	for i, node := range s.sortedNodes() {
+		status = append(status, map[string]any{
+			"type":   "node",
+			"id":     "node/" + node.Name,
+			"name":   node.Name,
+			"nodeid": i + 1,
+			"ip":     fmt.Sprintf("10.0.0.%d", i+1),
+			"online": boolInt(!node.Offline),
+			"local":  boolInt(i == 0),
+			"level":  "",
+		})
+	}
+	return status
+}
+
+func (s *Simulator) nextID() uint64 {
+	id := uint64(simulatorFirstVMID)
+	for {
+		if _, ok := s.vms[id]; !ok {
+			return id
+		}
+		id++
+	}
+}
+
+func (s *Simulator) clusterResources(resourceType string) []map[string]any {
+	var resources []map[string]any
+	if resourceType == "" || resourceType == "node" {
+		for _, node := range s.sortedNodes() {
+			resources = append(resources, s.nodeResource(node))
+		}
+	}
+	if resourceType == "" || resourceType == "vm" {
+		for _, vm := range s.sortedVMs() {
+			resources = append(resources, vm.resource())
+		}
+	}
+	if resourceType == "" || resourceType == "storage" {
+		for _, node := range s.sortedNodes() {
+			for _, storage := range s.storages(node.Name) {
+				resources = append(resources, map[string]any{
+					"id":      fmt.Sprintf("storage/%s/%s", node.Name, storage["storage"]),
+					"type":    "storage",
+					"node":    node.Name,
+					"storage": storage["storage"],
+					"content": storage["content"],
+					"status":  "available",
+				})
+			}
+		}
+	}
+	return resources
+}
+
+func (s *Simulator) pool(name, resourceType string) (any, error) {
+	if _, ok := s.pools[name]; !ok {
+		return nil, errNotFound("pool '%s' does not exist", name)
+	}
+
+	members := []map[string]any{}
+	if resourceType == "" || resourceType == "qemu" {
+		for _, vm := range s.sortedVMs() {
+			if vm.Pool == name {
+				members = append(members, vm.resource())
+			}
+		}
+	}
+	return map[string]any{"poolid": name, "members": members}, nil
+}
+
+func (s *Simulator) nodeResource(node *SimulatedNode) map[string]any {
+	status := "online"
+	if node.Offline {
+		status = "offline"
+	}
+	return map[string]any{
+		"id":     "node/" + node.Name,
+		"type":   "node",
+		"node":   node.Name,
+		"status": status,
+		"maxcpu": node.CPUs,
+		"maxmem": node.MemoryBytes,
+		"mem":    s.usedMemoryBytes(node.Name),
+		"level":  "",
+	}
+}
+
+func (s *Simulator) nodeList() []map[string]any {
+	nodes := make([]map[string]any, 0, len(s.nodes))
+	for _, node := range s.sortedNodes() {
+		nodes = append(nodes, s.nodeResource(node))
+	}
+	return nodes
+}
+
+func (s *Simulator) nodeStatus(node *SimulatedNode) map[string]any {
+	used := s.usedMemoryBytes(node.Name)
+	free := uint64(0)
+	if used < node.MemoryBytes {
+		free = node.MemoryBytes - used
+	}
+	return map[string]any{
+		"cpuinfo": map[string]any{
+			"model":   node.CPUModel,
+			"cpus":    node.CPUs,
+			"cores":   node.CPUs,
+			"sockets": 1,
+		},
+		"memory": map[string]any{
+			"total": node.MemoryBytes,
+			"used":  used,
+			"free":  free,
+		},
+		"pveversion": "pve-manager/" + SimulatorRelease,
+		"uptime":     1,
+	}
+}
+
+// usedMemoryBytes returns the memory of all running VMs on a node.
+func (s *Simulator) usedMemoryBytes(node string) uint64 {
+	var used uint64
+	for _, vm := range s.vms {
+		if vm.Node == node && vm.Status != "" && vm.Status != simulatorStatusStopped {
+			used += vm.memoryBytes()
+		}
+	}
+	return used
+}
+
+func (s *Simulator) vmList(node string) []map[string]any {
+	vms := []map[string]any{}
+	for _, vm := range s.sortedVMs() {
+		if vm.Node == node {
+			vms = append(vms, vm.status())
+		}
+	}
+	return vms
+}
+
+var compressionExtensions = map[string]string{"0": "", "1": ".lzo", "lzo": ".lzo", "gzip": ".gz", "zstd": ".zst"}
+
+func (s *Simulator) vzdump(node string, params map[string]any) (any, error) {
+	vmid, err := strconv.ParseUint(paramString(params, "vmid"), 10, 64)
+	if err != nil {
+		return nil, errParameter("vmid", "type check ('integer') failed")
+	}
+	if vm, ok := s.vms[vmid]; !ok || vm.Node != node {
+		return nil, errNotFound("guest %d is not on node %s", vmid, node)
+	}
+
+	storageName := paramString(params, "storage")
+	storage, err := s.storage(node, storageName)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(storage.(map[string]any)["content"].(string), "backup") {
+		return nil, errParameter("storage", fmt.Sprintf("storage '%s' does not support backups", storageName))
+	}
+
+	switch mode := paramString(params, "mode"); mode {
+	case "", "snapshot", "suspend", "stop":
+	default:
+		return nil, errParameter("mode", fmt.Sprintf("value '%s' does not have a value in the enumeration 'snapshot, suspend, stop'", mode))
+	}
+	extension, ok := compressionExtensions[paramString(params, "compress")]
+	if !ok && paramString(params, "compress") != "" {
+		return nil, errParameter("compress", fmt.Sprintf("value '%s' does not have a value in the enumeration '0, 1, gzip, lzo, zstd'", paramString(params, "compress")))
+	}
+
+	upid := s.newTask(node, "vzdump", vmid)
+	volid := fmt.Sprintf("%s:backup/vzdump-qemu-%d-%d.vma%s", storageName, vmid, s.taskCount, extension)
+	s.backups[node] = append(s.backups[node], volid)
+	return upid, nil
+}
+
+// nodeBridges returns the bridges of a node. Other interfaces are not simulated.
+func nodeBridges(node *SimulatedNode) []map[string]any {
+	names := node.Bridges
+	if names == nil {
+		names = []string{SimulatorBridge}
+	}
+
+	bridges := make([]map[string]any, 0, len(names))
+	for _, name := range names {
+		bridges = append(bridges, map[string]any{"iface": name, "type": "bridge", "active": 1})
+	}
+	return bridges
+}
+
+func (s *Simulator) storages(node string) []map[string]any {
+	storages := make([]map[string]any, 0, 2+len(s.sharedStorages))
+	for _, name := range append([]string{SimulatorISOStorage, SimulatorImageStorage}, s.sharedStorages...) {
+		storage, _ := s.storage(node, name)
+		storages = append(storages, storage.(map[string]any))
+	}
+	return storages
+}
+
+func (s *Simulator) storage(node, name string) (any, error) {
+	var content, storageType string
+	switch {
+	case name == SimulatorISOStorage:
+		content, storageType = "iso,vztmpl,backup", "dir"
+	case name == SimulatorImageStorage:
+		content, storageType = "images,rootdir", "lvmthin"
+	case s.isSharedStorage(name):
+		content, storageType = "iso,snippets", "nfs"
+	default:
+		return nil, errNotFound("storage '%s' does not exist", name)
+	}
+
+	const total = 1024 * 1024 * mib
+	var used uint64
+	for _, iso := range s.isos {
+		if iso.node == s.isoNode(node, name) && iso.storage == name {
+			used += uint64(len(iso.content))
+		}
+	}
+
+	return map[string]any{
+		"storage":       name,
+		"type":          storageType,
+		"content":       content,
+		"active":        1,
+		"enabled":       1,
+		"shared":        boolInt(s.isSharedStorage(name)),
+		"total":         uint64(total),
+		"used":          used,
+		"avail":         uint64(total) - used,
+		"used_fraction": float64(used) / total,
+	}, nil
+}
+
+func (s *Simulator) uploadISO(node, storage string, params map[string]any) (any, error) {
+	if storage != SimulatorISOStorage && !s.isSharedStorage(storage) {
+		return nil, errParameter("content", fmt.Sprintf("storage '%s' does not support content type 'iso'", storage))
+	}
+	if content := paramString(params, "content"); content != "iso" {
+		return nil, errParameter("content", "only iso uploads are supported by the simulator")
+	}
+
+	file, ok := params["filename"].(simulatedUpload)
+	if !ok {
+		return nil, errParameter("filename", "property is missing and it is not optional")
+	}
+
+	name := file.name
+	isoNode := s.isoNode(node, storage)
+	s.isos[isoKey(isoNode, storage, name)] = &simulatedISO{node: isoNode, storage: storage, name: name, content: file.content}
+	return s.newTask(node, "imgcopy", ""), nil
+}
+
+func (s *Simulator) storageContent(method, node, storage, volid string) (any, error) {
+	if _, ok := s.volumes[volumeKey(node, volid)]; ok {
+		return s.volumeContent(method, node, volid)
+	}
+
+	name, ok := strings.CutPrefix(volid, storage+":iso/")
+	key := isoKey(s.isoNode(node, storage), storage, name)
+	iso, exists := s.isos[key]
+	if !ok || !exists {
+		return nil, errNotFound("volume '%s' does not exist", volid)
+	}
+
+	switch method {
+	case http.MethodGet:
+		return map[string]any{
+			"format": "iso",
+			"size":   len(iso.content),
+			"used":   len(iso.content),
+			"path":   "/var/lib/vz/template/iso/" + name,
+		}, nil
+	case http.MethodDelete:
+		delete(s.isos, key)
+		return s.newTask(node, "imgdel", ""), nil
+	}
+
+	return nil, &simulatorError{status: http.StatusNotImplemented, message: fmt.Sprintf("Method '%s' not implemented", method)}
+}
+
+func (s *Simulator) deleteVM(vm *SimulatedVM) (any, error) {
+	if vm.running() {
+		return nil, errNotFound("VM %d is running - destroy failed", vm.VMID)
+	}
+
+	delete(s.vms, vm.VMID)
+	s.destroyOwnedVolumes(vm)
+	return s.newTask(vm.Node, "qmdestroy", vm.VMID), nil
+}
+
+func (s *Simulator) cloneVM(source *SimulatedVM, params map[string]any) (any, error) {
+	newID, err := strconv.ParseUint(paramString(params, "newid"), 10, 64)
+	if err != nil {
+		return nil, errParameter("newid", "type check ('integer') failed")
+	}
+	if _, ok := s.vms[newID]; ok {
+		return nil, errNotFound("unable to create VM %d: config file already exists", newID)
+	}
+
+	target := paramString(params, "target")
+	if target == "" {
+		target = source.Node
+	}
+	if _, ok := s.nodes[target]; !ok {
+		return nil, errParameter("target", fmt.Sprintf("no such cluster node '%s'", target))
+	}
+
+	pool := paramString(params, "pool")
+	if _, ok := s.pools[pool]; pool != "" && !ok {
+		return nil, errNotFound("pool '%s' does not exist", pool)
+	}
+
+	clone := source.copy()
+	clone.VMID = newID
+	clone.Node = target
+	clone.Pool = pool
+	clone.Template = false
+	clone.Status = simulatorStatusStopped
+	clone.Pending = nil
+	clone.Snapshots = nil
+	clone.GuestInterfaces = nil
+	delete(clone.Config, "template")
+
+	name := paramString(params, "name")
+	if name == "" {
+		name = "Copy-of-VM-" + paramString(clone.Config, "name")
+	}
+	clone.Config["name"] = name
+	if description := paramString(params, "description"); description != "" {
+		clone.Config["description"] = description
+	}
+
+	s.vms[newID] = clone
+	return s.newTask(source.Node, "qmclone", source.VMID), nil
+}
+
+func (s *Simulator) migrateVM(vm *SimulatedVM, params map[string]any) (any, error) {
+	target := paramString(params, "target")
+	node, ok := s.nodes[target]
+	if !ok {
+		return nil, errParameter("target", fmt.Sprintf("no such cluster node '%s'", target))
+	}
+	if target == vm.Node {
+		return nil, errParameter("target", "target is local node.")
+	}
+	if node.Offline {
+		return nil, errNotFound("target node '%s' is not online", target)
+	}
+	if vm.running() && paramString(params, "online") != "1" {
+		return nil, errNotFound("can't migrate running VM without --online")
+	}
+
+	source := vm.Node
+	vm.Node = target
+	return s.newTask(source, "qmigrate", vm.VMID), nil
+}
+
+var snapshotNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// snapshotList returns the snapshots including the current state, like the API does.
+func (vm *SimulatedVM) snapshotList() []map[string]any {
+	snapshots := make([]map[string]any, 0, len(vm.Snapshots)+1)
+	parent := ""
+	for _, snapshot := range vm.Snapshots {
+		entry := map[string]any{
+			"name":        snapshot.Name,
+			"description": snapshot.Description,
+			"vmstate":     boolInt(snapshot.VMState),
+			"snaptime":    1,
+		}
+		if parent != "" {
+			entry["parent"] = parent
+		}
+		snapshots = append(snapshots, entry)
+		parent = snapshot.Name
+	}
+
+	current := map[string]any{"name": "current", "description": "You are here!", "running": boolInt(vm.running())}
+	if parent != "" {
+		current["parent"] = parent
+	}
+	return append(snapshots, current)
+}
+
+func (vm *SimulatedVM) snapshotIndex(name string) int {
+	for i, snapshot := range vm.Snapshots {
+		if snapshot.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Simulator) createSnapshot(vm *SimulatedVM, params map[string]any) (any, error) {
+	name := paramString(params, "snapname")
+	if !snapshotNamePattern.MatchString(name) || name == "current" {
+		return nil, errParameter("snapname", fmt.Sprintf("invalid configuration ID '%s'", name))
+	}
+	if vm.snapshotIndex(name) >= 0 {
+		return nil, errNotFound("snapshot name '%s' already used", name)
+	}
+
+	vm.Snapshots = append(vm.Snapshots, SimulatedSnapshot{
+		Name:        name,
+		Description: paramString(params, "description"),
+		VMState:     paramString(params, "vmstate") == "1" && vm.running(),
+		Config:      copyOptions(vm.Config),
+	})
+	return s.newTask(vm.Node, "qmsnapshot", vm.VMID), nil
+}
+
+func (s *Simulator) deleteSnapshot(vm *SimulatedVM, name string) (any, error) {
+	i := vm.snapshotIndex(name)
+	if i < 0 {
+		return nil, errNotFound("snapshot '%s' does not exist", name)
+	}
+
+	vm.Snapshots = append(vm.Snapshots[:i], vm.Snapshots[i+1:]...)
+	return s.newTask(vm.Node, "qmdelsnapshot", vm.VMID), nil
+}
+
+// rollbackSnapshot restores the config of the snapshot. A VM is only running afterwards
+// if the snapshot includes its memory. Newer snapshots are kept, like Proxmox VE does.
+func (s *Simulator) rollbackSnapshot(vm *SimulatedVM, name string) (any, error) {
+	i := vm.snapshotIndex(name)
+	if i < 0 {
+		return nil, errNotFound("snapshot '%s' does not exist", name)
+	}
+
+	snapshot := vm.Snapshots[i]
+	vm.Config = copyOptions(snapshot.Config)
+	vm.Pending = nil
+	if snapshot.VMState {
+		vm.Status = simulatorStatusRunning
+	} else {
+		vm.Status = simulatorStatusStopped
+	}
+	return s.newTask(vm.Node, "qmrollback", vm.VMID), nil
+}
+
+func (s *Simulator) configureVM(vm *SimulatedVM, params map[string]any) error {
+	for key, value := range params {
+		if key == "digest" || key == "skiplock" || key == "background_delay" {
+			continue
+		}
+		if key == "delete" {
+			for _, k := range strings.Split(paramString(params, key), ",") {
+				vm.setOption(strings.TrimSpace(k), nil)
+			}
+			continue
+		}
+		vm.setOption(key, value)
+	}
+	return nil
+}
+
+func (s *Simulator) changeVMStatus(vm *SimulatedVM, action string, params map[string]any) (any, error) {
+	if vm.Template {
+		return nil, errNotFound("you can't start a vm if it's a template")
+	}
+
+	var taskType string
+	switch action {
+	case "start":
+		if vm.running() {
+			return nil, errNotFound("VM %d already running", vm.VMID)
+		}
+		vm.applyPending()
+		// starting a hibernated VM restores its state.
+		delete(vm.Config, "lock")
+		vm.Status, taskType = simulatorStatusRunning, "qmstart"
+		vm.guestProcesses = nil
+	case "stop", "shutdown":
+		vm.Status, taskType = simulatorStatusStopped, "qm"+action
+		vm.guestProcesses = nil
+	case "reboot", "reset":
+		if !vm.running() {
+			return nil, errNotFound("VM %d not running", vm.VMID)
+		}
+		vm.applyPending()
+		vm.Status, taskType = simulatorStatusRunning, "qm"+action
+		vm.guestProcesses = nil
+	case "suspend":
+		if !vm.running() {
+			return nil, errNotFound("VM %d not running", vm.VMID)
+		}
+		taskType = "qmsuspend"
+		if paramString(params, "todisk") == "1" {
+			vm.Config["lock"] = "suspended"
+			vm.Status = simulatorStatusStopped
+		} else {
+			vm.Status = simulatorStatusPaused
+		}
+	case "resume":
+		vm.Status, taskType = simulatorStatusRunning, "qmresume"
+	default:
+		return nil, &simulatorError{status: http.StatusNotImplemented, message: fmt.Sprintf("Method 'POST status/%s' not implemented", action)}
+	}
+
+	return s.newTask(vm.Node, taskType, vm.VMID), nil
+}
+
+var (
+	diskSizePattern   = regexp.MustCompile(`size=(\d+)([KMGT]?)`)
+	resizeSizePattern = regexp.MustCompile(`^\+?(\d+)([KMGT]?)$`)
+)
+
+func (s *Simulator) resizeDisk(vm *SimulatedVM, disk, size string) error {
+	current := paramString(vm.currentConfig(), disk)
+	if current == "" {
+		return errParameter("disk", fmt.Sprintf("disk '%s' does not exist", disk))
+	}
+
+	m := resizeSizePattern.FindStringSubmatch(size)
+	if m == nil {
+		return errParameter("size", "value does not match the regex pattern")
+	}
+
+	var currentBytes uint64
+	if c := diskSizePattern.FindStringSubmatch(current); c != nil {
+		currentBytes = parseSize(c[1], c[2])
+	}
+	newBytes := parseSize(m[1], m[2])
+	if strings.HasPrefix(size, "+") {
+		newBytes += currentBytes
+	}
+	if newBytes < currentBytes {
+		return errNotFound("shrinking disks is not supported")
+	}
+
+	sizeOption := "size=" + formatSize(newBytes)
+	if diskSizePattern.MatchString(current) {
+		vm.Config[disk] = diskSizePattern.ReplaceAllLiteralString(current, sizeOption)
+	} else {
+		vm.Config[disk] = current + "," + sizeOption
+	}
+	return nil
+}
+
+func (vm *SimulatedVM) copy() *SimulatedVM {
+	c := *vm
+	c.Config = make(map[string]any, len(vm.Config))
+	for k, v := range vm.Config {
+		c.Config[k] = v
+	}
+	if vm.Pending != nil {
+		c.Pending = make(map[string]any, len(vm.Pending))
+		for k, v := range vm.Pending {
+			c.Pending[k] = v
+		}
+	}
+	if vm.Snapshots != nil {
+		c.Snapshots = make([]SimulatedSnapshot, len(vm.Snapshots))
+		for i, snapshot := range vm.Snapshots {
+			c.Snapshots[i] = snapshot
+			c.Snapshots[i].Config = copyOptions(snapshot.Config)
+		}
+	}
+	if vm.FirewallOptions != nil {
+		c.FirewallOptions = copyOptions(vm.FirewallOptions)
+	}
+	if vm.FirewallRules != nil {
+		c.FirewallRules = make([]map[string]any, len(vm.FirewallRules))
+		for i, rule := range vm.FirewallRules {
+			c.FirewallRules[i] = copyOptions(rule)
+		}
+	}
+	if vm.GuestInterfaces != nil {
+		c.GuestInterfaces = make([]SimulatedGuestInterface, len(vm.GuestInterfaces))
+		for i, iface := range vm.GuestInterfaces {
+			c.GuestInterfaces[i] = iface
+			c.GuestInterfaces[i].IPAddresses = append([]string(nil), iface.IPAddresses...)
+		}
+	}
+	if vm.GuestCommands != nil {
+		c.GuestCommands = make(map[string]SimulatedGuestCommand, len(vm.GuestCommands))
+		for k, v := range vm.GuestCommands {
+			c.GuestCommands[k] = v
+		}
+	}
+	if vm.GuestFiles != nil {
+		c.GuestFiles = make(map[string]string, len(vm.GuestFiles))
+		for k, v := range vm.GuestFiles {
+			c.GuestFiles[k] = v
+		}
+	}
+	c.guestProcesses = append([]SimulatedGuestCommand(nil), vm.guestProcesses...)
+	if c.Status == "" {
+		c.Status = simulatorStatusStopped
+	}
+	return &c
+}
+
+func copyOptions(options map[string]any) map[string]any {
+	c := make(map[string]any, len(options))
+	for k, v := range options {
+		c[k] = v
+	}
+	return c
+}
+
+// agentRunning fails like Proxmox VE if the QEMU guest agent cannot be reached, since the VM is not running
+// or its config does not enable the agent. Paused VMs do not respond either.
+func (vm *SimulatedVM) agentRunning() error {
+	// the agent option is [enabled=]<0|1>[,...].
+	enabled, _, _ := strings.Cut(paramString(vm.Config, "agent"), ",")
+	if vm.Status != simulatorStatusRunning || strings.TrimPrefix(enabled, "enabled=") != "1" {
+		return &simulatorError{status: http.StatusInternalServerError, message: "QEMU guest agent is not running"}
+	}
+	return nil
+}
+
+// startGuestCommand starts one of the GuestCommands of the VM and returns its process ID.
+func (vm *SimulatedVM) startGuestCommand(params map[string]any) (any, error) {
+	if err := vm.agentRunning(); err != nil {
+		return nil, err
+	}
+
+	args, _ := params["command"].([]any)
+	command := make([]string, 0, len(args))
+	for _, arg := range args {
+		command = append(command, fmt.Sprint(arg))
+	}
+	result, ok := vm.GuestCommands[strings.Join(command, " ")]
+	if len(command) == 0 || !ok {
+		return nil, &simulatorError{status: http.StatusInternalServerError, message: fmt.Sprintf("Agent error: Failed to execute child process \u201c%s\u201d (No such file or directory)", strings.Join(command, " "))}
+	}
+
+	vm.guestProcesses = append(vm.guestProcesses, result)
+	return map[string]any{"pid": len(vm.guestProcesses)}, nil
+}
+
+// guestCommandStatus returns the status of a process started by the QEMU guest agent.
+func (vm *SimulatedVM) guestCommandStatus(params map[string]any) (any, error) {
+	if err := vm.agentRunning(); err != nil {
+		return nil, err
+	}
+
+	pid, err := strconv.Atoi(paramString(params, "pid"))
+	if err != nil || pid < 1 || pid > len(vm.guestProcesses) {
+		return nil, &simulatorError{status: http.StatusInternalServerError, message: fmt.Sprintf("Agent error: Invalid parameter 'pid' %s", paramString(params, "pid"))}
+	}
+
+	process := &vm.guestProcesses[pid-1]
+	if process.RunningPolls > 0 {
+		process.RunningPolls--
+		return map[string]any{"exited": 0}, nil
+	}
+	return map[string]any{"exited": 1, "exitcode": process.ExitCode, "out-data": process.Output}, nil
+}
+
+// readGuestFile returns the content of one of the GuestFiles of the VM.
+func (vm *SimulatedVM) readGuestFile(params map[string]any) (any, error) {
+	if err := vm.agentRunning(); err != nil {
+		return nil, err
+	}
+
+	path := paramString(params, "file")
+	content, ok := vm.GuestFiles[path]
+	if !ok {
+		return nil, &simulatorError{status: http.StatusInternalServerError, message: fmt.Sprintf("Agent error: Failed to open file '%s': No such file or directory", path)}
+	}
+	return map[string]any{"content": content, "truncated": 0}, nil
+}
+
+// guestInterfaces returns the network interfaces of the guest like the QEMU guest agent,
+// which always reports the loopback interface.
+func (vm *SimulatedVM) guestInterfaces() (any, error) {
+	if err := vm.agentRunning(); err != nil {
+		return nil, err
+	}
+
+	ifaces := []map[string]any{{
+		"name":             "lo",
+		"hardware-address": "00:00:00:00:00:00",
+		"ip-addresses":     []map[string]any{{"ip-address-type": "ipv4", "ip-address": "127.0.0.1", "prefix": 8}},
+	}}
+	for _, iface := range vm.GuestInterfaces {
+		addresses := make([]map[string]any, 0, len(iface.IPAddresses))
+		for _, cidr := range iface.IPAddresses {
+			address, prefix, _ := strings.Cut(cidr, "/")
+			length, _ := strconv.Atoi(prefix)
+			addressType := "ipv4"
+			if strings.Contains(address, ":") {
+				addressType = "ipv6"
+			}
+			addresses = append(addresses, map[string]any{"ip-address-type": addressType, "ip-address": address, "prefix": length})
+		}
+		ifaces = append(ifaces, map[string]any{"name": iface.Name, "hardware-address": strings.ToLower(iface.MACAddress), "ip-addresses": addresses})
+	}
+	return map[string]any{"result": ifaces}, nil
+}
+
+func (vm *SimulatedVM) running() bool {
+	return vm.Status == simulatorStatusRunning || vm.Status == simulatorStatusPaused
+}
+
+// currentConfig returns the config including pending changes, like the API does by default.
+func (vm *SimulatedVM) currentConfig() map[string]any {
+	config := make(map[string]any, len(vm.Config)+len(vm.Pending)+1)
+	for k, v := range vm.Config {
+		config[k] = v
+	}
+	for k, v := range vm.Pending {
+		if v == nil {
+			delete(config, k)
+			continue
+		}
+		config[k] = v
+	}
+	if vm.Template {
+		config["template"] = 1
+	}
+	config["digest"] = "simulator"
+	return config
+}
+
+func (vm *SimulatedVM) pendingChanges() []map[string]any {
+	keys := make(map[string]struct{}, len(vm.Config)+len(vm.Pending))
+	for k := range vm.Config {
+		keys[k] = struct{}{}
+	}
+	for k := range vm.Pending {
+		keys[k] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	changes := make([]map[string]any, 0, len(sorted))
+	for _, k := range sorted {
+		change := map[string]any{"key": k}
+		if v, ok := vm.Config[k]; ok {
+			change["value"] = v
+		}
+		if v, ok := vm.Pending[k]; ok {
+			if v == nil {
+				change["delete"] = 1
+			} else {
+				change["pending"] = v
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// setOption applies an option, or records it as pending if it cannot be hotplugged into the running VM.
+func (vm *SimulatedVM) setOption(key string, value any) {
+	if vm.running() && !vm.hotpluggable(key) {
+		if vm.Pending == nil {
+			vm.Pending = make(map[string]any)
+		}
+		vm.Pending[key] = value
+		return
+	}
+
+	if value == nil {
+		delete(vm.Config, key)
+	} else {
+		vm.Config[key] = value
+	}
+}
+
+var hotplugDevicePattern = regexp.MustCompile(`^(net|ide|sata|scsi|virtio|usb)\d+$`)
+
+// hotpluggable reports whether an option can be changed without a restart,
+// following the hotplug option of the VM.
+func (vm *SimulatedVM) hotpluggable(key string) bool {
+	hotplug := paramString(vm.Config, "hotplug")
+	if hotplug == "" {
+		hotplug = "network,disk,usb"
+	}
+	enabled := func(feature string) bool {
+		for _, f := range strings.Split(hotplug, ",") {
+			if f == feature || f == "1" {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch key {
+	case "sockets", "cores", "cpu", "numa", "bios", "machine", "balloon":
+		return false
+	case "memory":
+		return enabled("memory")
+	case "vcpus":
+		return enabled("cpu")
+	}
+
+	if m := hotplugDevicePattern.FindStringSubmatch(key); m != nil {
+		switch m[1] {
+		case "net":
+			return enabled("network")
+		case "usb":
+			return enabled("usb")
+		default:
+			return enabled("disk")
+		}
+	}
+	return true
+}
+
+func (vm *SimulatedVM) applyPending() {
+	for k, v := range vm.Pending {
+		if v == nil {
+			delete(vm.Config, k)
+			continue
+		}
+		vm.Config[k] = v
+	}
+	vm.Pending = nil
+}
+
+func (vm *SimulatedVM) memoryBytes() uint64 {
+	memory, err := strconv.ParseUint(paramString(vm.Config, "memory"), 10, 64)
+	if err != nil {
+		memory = 512
+	}
+	return memory * mib
+}
+
+func (vm *SimulatedVM) cpus() uint64 {
+	cores, err := strconv.ParseUint(paramString(vm.Config, "cores"), 10, 64)
+	if err != nil {
+		cores = 1
+	}
+	sockets, err := strconv.ParseUint(paramString(vm.Config, "sockets"), 10, 64)
+	if err != nil {
+		sockets = 1
+	}
+	return cores * sockets
+}
+
+func (vm *SimulatedVM) status() map[string]any {
+	status := map[string]any{
+		"vmid":      vm.VMID,
+		"name":      paramString(vm.Config, "name"),
+		"status":    vm.Status,
+		"qmpstatus": vm.Status,
+		"maxmem":    vm.memoryBytes(),
+		"cpus":      vm.cpus(),
+		"tags":      paramString(vm.Config, "tags"),
+	}
+	if vm.Status == simulatorStatusPaused {
+		status["status"] = simulatorStatusRunning
+	}
+	if lock := paramString(vm.Config, "lock"); lock != "" {
+		status["lock"] = lock
+	}
+	if vm.running() {
+		status["mem"] = vm.memoryBytes()
+		status["uptime"] = 1
+	}
+	if vm.Template {
+		status["template"] = 1
+	}
+	return status
+}
+
+func (vm *SimulatedVM) resource() map[string]any {
+	status := simulatorStatusStopped
+	if vm.running() {
+		status = simulatorStatusRunning
+	}
+	return map[string]any{
+		"id":       fmt.Sprintf("qemu/%d", vm.VMID),
+		"type":     "qemu",
+		"vmid":     vm.VMID,
+		"node":     vm.Node,
+		"name":     paramString(vm.Config, "name"),
+		"status":   status,
+		"pool":     vm.Pool,
+		"tags":     paramString(vm.Config, "tags"),
+		"template": boolInt(vm.Template),
+		"maxmem":   vm.memoryBytes(),
+		"maxcpu":   vm.cpus(),
+	}
+}
+
+// simulatedUpload is a file uploaded with a multipart form.
+type simulatedUpload struct {
+	name    string
+	content []byte
+}
+
+// requestParams returns the query, form or JSON body parameters of a request.
+func requestParams(r *http.Request) (map[string]any, error) {
+	params := make(map[string]any)
+	for k, v := range r.URL.Query() {
+		params[k] = v[0]
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "application/json"):
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		var body map[string]any
+		if err := decoder.Decode(&body); err != nil && err != io.EOF {
+			return nil, err
+		}
+		for k, v := range body {
+			params[k] = v
+		}
+	case strings.HasPrefix(contentType, "multipart/form-data"):
+		if err := r.ParseMultipartForm(32 * mib); err != nil {
+			return nil, err
+		}
+		for k, v := range r.MultipartForm.Value {
+			params[k] = v[0]
+		}
+		for k, files := range r.MultipartForm.File {
+			f, err := files[0].Open()
+			if err != nil {
+				return nil, err
+			}
+			content, err := io.ReadAll(f)
+			_ = f.Close()
+			if err != nil {
+				return nil, err
+			}
+			name := files[0].Filename
+			if override, ok := r.MultipartForm.Value["filename"]; ok {
+				name = override[0]
+			}
+			params[k] = simulatedUpload{name: name, content: content}
+		}
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		for k, v := range r.PostForm {
+			params[k] = v[0]
+		}
+	}
+
+	return params, nil
+}
+
+// writeSimulatorError writes an error response. Like Proxmox VE, server errors are reported in the
+// reason phrase of the status line, which net/http does not support, so the connection is hijacked.
+func writeSimulatorError(w http.ResponseWriter, err error) {
+	var e *simulatorError
+	if !errors.As(err, &e) {
+		e = &simulatorError{status: http.StatusInternalServerError, message: err.Error()}
+	}
+
+	if e.status == http.StatusBadRequest {
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		w.WriteHeader(e.status)
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": nil, "errors": e.params, "message": e.message})
+		return
+	}
+
+	if hijacker, ok := w.(http.Hijacker); ok {
+		conn, buf, hijackErr := hijacker.Hijack()
+		if hijackErr == nil {
+			defer conn.Close()
+			message := strings.ReplaceAll(e.message, "\n", " ")
+			_, _ = fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\nContent-Length: 0\r\nConnection: close\r\n\r\n", e.status, message)
+			_ = buf.Flush()
+			return
+		}
+	}
+	http.Error(w, e.message, e.status)
+}
+
+// paramString returns a parameter or config option as a string.
+func paramString(params map[string]any, key string) string {
+	v, ok := params[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func (s *Simulator) isSharedStorage(name string) bool {
+	for _, shared := range s.sharedStorages {
+		if shared == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isoNode returns the node under which the ISO images of a storage are kept, shared storages have none.
+func (s *Simulator) isoNode(node, storage string) string {
+	if s.isSharedStorage(storage) {
+		return ""
+	}
+	return node
+}
+
+func isoKey(node, storage, name string) string {
+	return node + "/" + storage + "/" + name
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func parseSize(value, unit string) uint64 {
+	size, _ := strconv.ParseUint(value, 10, 64)
+	switch unit {
+	case "K":
+		return size * 1024
+	case "M":
+		return size * mib
+	case "G":
+		return size * 1024 * mib
+	case "T":
+		return size * 1024 * 1024 * mib
+	}
+	return size
+}
+
+func formatSize(size uint64) string {
+	for _, unit := range []struct {
+		suffix string
+		bytes  uint64
+	}{{"T", 1024 * 1024 * mib}, {"G", 1024 * mib}, {"M", mib}, {"K", 1024}} {
+		if size >= unit.bytes && size%unit.bytes == 0 {
+			return fmt.Sprintf("%d%s", size/unit.bytes, unit.suffix)
+		}
+	}
+	return strconv.FormatUint(size, 10)
+}
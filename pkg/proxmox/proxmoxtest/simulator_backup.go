@@ -0,0 +1,208 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmoxtest
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SimulatedBackupJob is a scheduled backup job of the simulated cluster.
+type SimulatedBackupJob struct {
+	ID           string
+	Schedule     string
+	Storage      string
+	Mode         string
+	VMIDs        []uint64
+	PruneBackups string
+	Comment      string
+	Enabled      bool
+}
+
+func (j *SimulatedBackupJob) copy() *SimulatedBackupJob {
+	c := *j
+	c.VMIDs = append([]uint64(nil), j.VMIDs...)
+	return &c
+}
+
+// backupJobIDPattern is the format of the IDs of backup jobs, which Proxmox VE calls configid.
+var backupJobIDPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_\-]{1,63}$`)
+
+// AddBackupJob adds a backup job. It replaces any job with the same ID.
+func (s *Simulator) AddBackupJob(job SimulatedBackupJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.backupJobs[job.ID] = job.copy()
+}
+
+// BackupJob returns a copy of the backup job with the given ID.
+func (s *Simulator) BackupJob(id string) (SimulatedBackupJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.backupJobs[id]
+	if !ok {
+		return SimulatedBackupJob{}, false
+	}
+	return *job.copy(), true
+}
+
+func (s *Simulator) routeBackupJobs(method string, p []string, params map[string]any) (any, error) {
+	route := method + " " + strings.Join(p, "/")
+	n := len(p)
+
+	switch {
+	case route == "GET ":
+		return s.backupJobList(), nil
+	case route == "POST ":
+		return nil, s.createBackupJob(params)
+	case method == http.MethodPut && n == 1:
+		return nil, s.updateBackupJob(p[0], params)
+	case method == http.MethodDelete && n == 1:
+		if _, ok := s.backupJobs[p[0]]; !ok {
+			return nil, errNotFound("job '%s' does not exist", p[0])
+		}
+		delete(s.backupJobs, p[0])
+		return nil, nil
+	}
+
+	return nil, &simulatorError{status: http.StatusNotImplemented, message: fmt.Sprintf("Method '%s /cluster/backup/%s' not implemented", method, strings.Join(p, "/"))}
+}
+
+func (s *Simulator) backupJobList() []map[string]any {
+	jobs := []map[string]any{}
+	for _, job := range s.backupJobs {
+		vmids := make([]string, 0, len(job.VMIDs))
+		for _, vmid := range job.VMIDs {
+			vmids = append(vmids, strconv.FormatUint(vmid, 10))
+		}
+		entry := map[string]any{
+			"id":       job.ID,
+			"type":     "vzdump",
+			"schedule": job.Schedule,
+			"storage":  job.Storage,
+			"mode":     job.Mode,
+			"vmid":     strings.Join(vmids, ","),
+			"enabled":  boolInt(job.Enabled),
+		}
+		if job.PruneBackups != "" {
+			entry["prune-backups"] = job.PruneBackups
+		}
+		if job.Comment != "" {
+			entry["comment"] = job.Comment
+		}
+		jobs = append(jobs, entry)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i]["id"].(string) < jobs[j]["id"].(string) })
+	return jobs
+}
+
+func (s *Simulator) createBackupJob(params map[string]any) error {
+	id := paramString(params, "id")
+	if !backupJobIDPattern.MatchString(id) {
+		return errParameter("id", "invalid format - invalid configuration ID")
+	}
+	if _, ok := s.backupJobs[id]; ok {
+		return &simulatorError{status: http.StatusInternalServerError, message: fmt.Sprintf("Job '%s' already exists", id)}
+	}
+
+	job := &SimulatedBackupJob{ID: id, Mode: "snapshot", Enabled: true}
+	if err := s.configureBackupJob(job, params); err != nil {
+		return err
+	}
+	if job.Schedule == "" {
+		return errParameter("schedule", "property is missing and it is not optional")
+	}
+	s.backupJobs[id] = job
+	return nil
+}
+
+func (s *Simulator) updateBackupJob(id string, params map[string]any) error {
+	job, ok := s.backupJobs[id]
+	if !ok {
+		return errNotFound("job '%s' does not exist", id)
+	}
+
+	updated := job.copy()
+	for _, key := range strings.Split(paramString(params, "delete"), ",") {
+		switch key {
+		case "prune-backups":
+			updated.PruneBackups = ""
+		case "comment":
+			updated.Comment = ""
+		}
+	}
+	if err := s.configureBackupJob(updated, params); err != nil {
+		return err
+	}
+	s.backupJobs[id] = updated
+	return nil
+}
+
+// configureBackupJob applies the parameters of creating or updating a backup job.
+func (s *Simulator) configureBackupJob(job *SimulatedBackupJob, params map[string]any) error {
+	if _, ok := params["schedule"]; ok {
+		job.Schedule = paramString(params, "schedule")
+	}
+	if _, ok := params["storage"]; ok {
+		name := paramString(params, "storage")
+		storage, err := s.storage("", name)
+		if err != nil {
+			return errParameter("storage", fmt.Sprintf("storage '%s' does not exist", name))
+		}
+		if !strings.Contains(storage.(map[string]any)["content"].(string), "backup") {
+			return errParameter("storage", fmt.Sprintf("storage '%s' does not support backups", name))
+		}
+		job.Storage = name
+	}
+	if _, ok := params["mode"]; ok {
+		switch mode := paramString(params, "mode"); mode {
+		case "snapshot", "suspend", "stop":
+			job.Mode = mode
+		default:
+			return errParameter("mode", fmt.Sprintf("value '%s' does not have a value in the enumeration 'snapshot, suspend, stop'", mode))
+		}
+	}
+	if _, ok := params["vmid"]; ok {
+		job.VMIDs = nil
+		for _, id := range strings.Split(paramString(params, "vmid"), ",") {
+			vmid, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return errParameter("vmid", fmt.Sprintf("value '%s' does not look like a valid VM ID", id))
+			}
+			job.VMIDs = append(job.VMIDs, vmid)
+		}
+	}
+	if _, ok := params["prune-backups"]; ok {
+		job.PruneBackups = paramString(params, "prune-backups")
+	}
+	if _, ok := params["comment"]; ok {
+		job.Comment = paramString(params, "comment")
+	}
+	if _, ok := params["enabled"]; ok {
+		job.Enabled = paramString(params, "enabled") == "1"
+	}
+	if job.Storage == "" {
+		return errParameter("storage", "property is missing and it is not optional")
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmoxtest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// AddSecurityGroup adds a security group to the firewall of the cluster, which rules of VMs can reference.
+func (s *Simulator) AddSecurityGroup(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.securityGroups[name] = struct{}{}
+}
+
+func (s *Simulator) routeVMFirewall(method string, vm *SimulatedVM, p []string, params map[string]any) (any, error) {
+	route := method + " " + strings.Join(p, "/")
+
+	switch {
+	case route == "GET options":
+		return copyOptions(vm.FirewallOptions), nil
+	case route == "PUT options":
+		return nil, s.setVMFirewallOptions(vm, params)
+	case route == "GET rules":
+		return vm.firewallRuleList(), nil
+	case route == "POST rules":
+		return nil, s.createVMFirewallRule(vm, params)
+	case method == http.MethodDelete && len(p) == 2 && p[0] == "rules":
+		return nil, vm.deleteFirewallRule(p[1])
+	}
+
+	return nil, &simulatorError{status: http.StatusNotImplemented, message: fmt.Sprintf("Method '%s /nodes/%s/qemu/%d/firewall/%s' not implemented", method, vm.Node, vm.VMID, strings.Join(p, "/"))}
+}
+
+func isFirewallAction(action string) bool {
+	return action == "ACCEPT" || action == "DROP" || action == "REJECT"
+}
+
+func (s *Simulator) setVMFirewallOptions(vm *SimulatedVM, params map[string]any) error {
+	if policy := paramString(params, "policy_in"); policy != "" && !isFirewallAction(policy) {
+		return errParameter("policy_in", "value '"+policy+"' does not have a value in the enumeration 'ACCEPT, REJECT, DROP'")
+	}
+
+	if vm.FirewallOptions == nil {
+		vm.FirewallOptions = map[string]any{}
+	}
+	for _, key := range []string{"enable", "policy_in", "policy_out"} {
+		if value := paramString(params, key); value != "" {
+			vm.FirewallOptions[key] = value
+		}
+	}
+	if enable, ok := vm.FirewallOptions["enable"].(string); ok {
+		vm.FirewallOptions["enable"], _ = strconv.Atoi(enable)
+	}
+	return nil
+}
+
+func (vm *SimulatedVM) firewallRuleList() []map[string]any {
+	rules := make([]map[string]any, 0, len(vm.FirewallRules))
+	for i, rule := range vm.FirewallRules {
+		entry := copyOptions(rule)
+		entry["pos"] = i
+		rules = append(rules, entry)
+	}
+	return rules
+}
+
+// createVMFirewallRule inserts a rule at the given position, or at the top like Proxmox VE does.
+func (s *Simulator) createVMFirewallRule(vm *SimulatedVM, params map[string]any) error {
+	ruleType, action := paramString(params, "type"), paramString(params, "action")
+	switch ruleType {
+	case "in", "out":
+		if !isFirewallAction(action) {
+			return errParameter("action", "value does not match the regex pattern")
+		}
+	case "group":
+		if _, ok := s.securityGroups[action]; !ok {
+			return errNotFound("security group '%s' does not exist", action)
+		}
+	default:
+		return errParameter("type", "value '"+ruleType+"' does not have a value in the enumeration 'in, out, forward, group'")
+	}
+
+	rule := map[string]any{}
+	for _, key := range []string{"type", "action", "proto", "dport", "source", "comment", "enable"} {
+		if value := paramString(params, key); value != "" {
+			rule[key] = value
+		}
+	}
+
+	pos := 0
+	if value := paramString(params, "pos"); value != "" {
+		var err error
+		if pos, err = strconv.Atoi(value); err != nil || pos < 0 {
+			return errParameter("pos", "type check ('integer') failed")
+		}
+	}
+	if pos > len(vm.FirewallRules) {
+		pos = len(vm.FirewallRules)
+	}
+	vm.FirewallRules = append(vm.FirewallRules[:pos], append([]map[string]any{rule}, vm.FirewallRules[pos:]...)...)
+	return nil
+}
+
+func (vm *SimulatedVM) deleteFirewallRule(value string) error {
+	pos, err := strconv.Atoi(value)
+	if err != nil || pos < 0 || pos >= len(vm.FirewallRules) {
+		return errNotFound("no rule at position %s", value)
+	}
+	vm.FirewallRules = append(vm.FirewallRules[:pos], vm.FirewallRules[pos+1:]...)
+	return nil
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmoxtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SimulatedSDNVNet is a VNet of the simulated software-defined network.
+type SimulatedSDNVNet struct {
+	Name  string
+	Zone  string
+	Tag   int
+	Alias string
+	// Subnets maps the CIDRs of the subnets of the VNet to their gateway.
+	Subnets map[string]string
+	// Applied is true once the VNet was applied to the nodes.
+	Applied bool
+}
+
+func (v *SimulatedSDNVNet) copy() *SimulatedSDNVNet {
+	c := *v
+	c.Subnets = make(map[string]string, len(v.Subnets))
+	for cidr, gateway := range v.Subnets {
+		c.Subnets[cidr] = gateway
+	}
+	return &c
+}
+
+// AddSDNZone adds a zone of the given type, like simple or vlan, to the software-defined network.
+func (s *Simulator) AddSDNZone(name, zoneType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sdnZones[name] = zoneType
+}
+
+// AddSDNVNet adds a VNet to the software-defined network. It replaces any VNet with the same name.
+func (s *Simulator) AddSDNVNet(vnet SimulatedSDNVNet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sdnVNets[vnet.Name] = vnet.copy()
+}
+
+// SDNVNet returns a copy of the state of the VNet with the given name.
+func (s *Simulator) SDNVNet(name string) (SimulatedSDNVNet, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vnet, ok := s.sdnVNets[name]
+	if !ok {
+		return SimulatedSDNVNet{}, false
+	}
+	return *vnet.copy(), true
+}
+
+func (s *Simulator) routeSDN(method string, p []string, params map[string]any) (any, error) {
+	route := method + " " + strings.Join(p, "/")
+	n := len(p)
+
+	switch {
+	case route == "PUT ":
+		return s.applySDN()
+	case route == "GET zones":
+		return s.sdnZoneList(), nil
+	case route == "GET vnets":
+		return s.sdnVNetList(), nil
+	case route == "POST vnets":
+		return nil, s.createSDNVNet(params)
+	case method == http.MethodDelete && n == 2 && p[0] == "vnets":
+		return nil, s.deleteSDNVNet(p[1])
+	case method == http.MethodGet && n == 3 && p[0] == "vnets" && p[2] == "subnets":
+		return s.sdnSubnetList(p[1])
+	case method == http.MethodPost && n == 3 && p[0] == "vnets" && p[2] == "subnets":
+		return nil, s.createSDNSubnet(p[1], params)
+	case method == http.MethodDelete && n == 4 && p[0] == "vnets" && p[2] == "subnets":
+		return nil, s.deleteSDNSubnet(p[1], p[3])
+	}
+
+	return nil, &simulatorError{status: http.StatusNotImplemented, message: fmt.Sprintf("Method '%s /cluster/sdn/%s' not implemented", method, strings.Join(p, "/"))}
+}
+
+func (s *Simulator) sdnZoneList() []map[string]any {
+	zones := []map[string]any{}
+	for name, zoneType := range s.sdnZones {
+		zones = append(zones, map[string]any{"zone": name, "type": zoneType})
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i]["zone"].(string) < zones[j]["zone"].(string) })
+	return zones
+}
+
+func (s *Simulator) sdnVNetList() []map[string]any {
+	vnets := []map[string]any{}
+	for _, vnet := range s.sdnVNets {
+		entry := map[string]any{"vnet": vnet.Name, "zone": vnet.Zone, "type": "vnet"}
+		if vnet.Tag != 0 {
+			entry["tag"] = vnet.Tag
+		}
+		if vnet.Alias != "" {
+			entry["alias"] = vnet.Alias
+		}
+		if !vnet.Applied {
+			entry["state"] = "new"
+		}
+		vnets = append(vnets, entry)
+	}
+	sort.Slice(vnets, func(i, j int) bool { return vnets[i]["vnet"].(string) < vnets[j]["vnet"].(string) })
+	return vnets
+}
+
+func (s *Simulator) createSDNVNet(params map[string]any) error {
+	name := paramString(params, "vnet")
+	if name == "" {
+		return errParameter("vnet", "property is missing and it is not optional")
+	}
+	if _, ok := s.sdnVNets[name]; ok {
+		return &simulatorError{status: http.StatusInternalServerError, message: fmt.Sprintf("create sdn vnet object failed: vnet '%s' already defined", name)}
+	}
+	zone := paramString(params, "zone")
+	if _, ok := s.sdnZones[zone]; !ok {
+		return errParameter("zone", fmt.Sprintf("zone '%s' does not exist", zone))
+	}
+
+	vnet := &SimulatedSDNVNet{Name: name, Zone: zone, Alias: paramString(params, "alias"), Subnets: map[string]string{}}
+	if tag := paramString(params, "tag"); tag != "" {
+		value, err := strconv.Atoi(tag)
+		if err != nil {
+			return errParameter("tag", "type check ('integer') failed")
+		}
+		vnet.Tag = value
+	}
+	s.sdnVNets[name] = vnet
+	return nil
+}
+
+func (s *Simulator) deleteSDNVNet(name string) error {
+	vnet, ok := s.sdnVNets[name]
+	if !ok {
+		return errNotFound("sdn '%s' does not exist", name)
+	}
+	if len(vnet.Subnets) > 0 {
+		return &simulatorError{status: http.StatusInternalServerError, message: fmt.Sprintf("cannot delete vnet '%s', it still has subnets", name)}
+	}
+	delete(s.sdnVNets, name)
+	return nil
+}
+
+// sdnSubnetID returns the ID of a subnet, which Proxmox VE derives from the zone and the CIDR.
+func sdnSubnetID(zone, cidr string) string {
+	return zone + "-" + strings.ReplaceAll(cidr, "/", "-")
+}
+
+func (s *Simulator) sdnSubnetList(name string) (any, error) {
+	vnet, ok := s.sdnVNets[name]
+	if !ok {
+		return nil, errNotFound("sdn '%s' does not exist", name)
+	}
+
+	subnets := []map[string]any{}
+	for cidr, gateway := range vnet.Subnets {
+		entry := map[string]any{"subnet": sdnSubnetID(vnet.Zone, cidr), "cidr": cidr, "vnet": name, "type": "subnet"}
+		if gateway != "" {
+			entry["gateway"] = gateway
+		}
+		subnets = append(subnets, entry)
+	}
+	sort.Slice(subnets, func(i, j int) bool { return subnets[i]["subnet"].(string) < subnets[j]["subnet"].(string) })
+	return subnets, nil
+}
+
+func (s *Simulator) createSDNSubnet(name string, params map[string]any) error {
+	vnet, ok := s.sdnVNets[name]
+	if !ok {
+		return errNotFound("sdn '%s' does not exist", name)
+	}
+
+	prefix, err := netip.ParsePrefix(paramString(params, "subnet"))
+	if err != nil || prefix.Masked() != prefix {
+		return errParameter("subnet", "invalid format - value does not look like a valid CIDR network")
+	}
+	gateway := paramString(params, "gateway")
+	if gateway != "" {
+		addr, err := netip.ParseAddr(gateway)
+		if err != nil || !prefix.Contains(addr) {
+			return errParameter("gateway", fmt.Sprintf("gateway ip %s is not in subnet %s", gateway, prefix))
+		}
+	}
+	vnet.Subnets[prefix.String()] = gateway
+	vnet.Applied = false
+	return nil
+}
+
+func (s *Simulator) deleteSDNSubnet(name, id string) error {
+	vnet, ok := s.sdnVNets[name]
+	if !ok {
+		return errNotFound("sdn '%s' does not exist", name)
+	}
+	for cidr := range vnet.Subnets {
+		if sdnSubnetID(vnet.Zone, cidr) == id {
+			delete(vnet.Subnets, cidr)
+			return nil
+		}
+	}
+	return errNotFound("sdn subnet '%s' does not exist", id)
+}
+
+// applySDN applies the pending SDN configuration, which runs as a task on the first node.
+func (s *Simulator) applySDN() (any, error) {
+	nodes := s.sortedNodes()
+	if len(nodes) == 0 {
+		return nil, &simulatorError{status: http.StatusInternalServerError, message: "no nodes in cluster"}
+	}
+	for _, vnet := range s.sdnVNets {
+		vnet.Applied = true
+	}
+	return s.newTask(nodes[0].Name, "reloadnetworkall", ""), nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmoxtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
+)
+
+func newSimulatorClient(t *testing.T) (*Simulator, *goproxmox.APIClient) {
+	sim := NewSimulator()
+	t.Cleanup(sim.Close)
+
+	sim.AddNode(SimulatedNode{Name: "pve1", CPUModel: "EPYC", CPUs: 16, MemoryBytes: 64 * 1024 * mib, Description: "tags: ssd"})
+	sim.AddNode(SimulatedNode{Name: "pve2", CPUModel: "EPYC", CPUs: 8, MemoryBytes: 32 * 1024 * mib})
+	sim.AddPool("capmox")
+	sim.AddVM(SimulatedVM{
+		VMID:     9000,
+		Node:     "pve1",
+		Template: true,
+		Config: map[string]any{
+			"name":    "template",
+			"sockets": 1,
+			"cores":   2,
+			"memory":  "2048",
+			"scsi0":   "local-lvm:base-9000-disk-0,size=10G",
+			"net0":    "virtio=A6:23:64:4D:84:CB,bridge=vmbr0",
+			"boot":    "order=scsi0",
+		},
+	})
+
+	client, err := goproxmox.NewAPIClient(context.Background(), logr.Discard(), sim.URL())
+	require.NoError(t, err)
+
+	return sim, client
+}
+
+func cloneSimulatedVM(t *testing.T, client *goproxmox.APIClient) int64 {
+	ctx := context.Background()
+	response, err := client.CloneVM(ctx, 9000, capmox.VMCloneRequest{Node: "pve1", Name: "test", Target: "pve2", Pool: "capmox"})
+	require.NoError(t, err)
+
+	task, err := client.GetTask(ctx, string(response.Task.UPID))
+	require.NoError(t, err)
+	require.True(t, task.IsSuccessful)
+
+	return response.NewID
+}
+
+func TestSimulator_CloneAndConfigure(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+
+	vmID := cloneSimulatedVM(t, client)
+	require.Equal(t, int64(100), vmID)
+
+	vm, err := client.GetVM(ctx, "pve2", vmID)
+	require.NoError(t, err)
+	require.Equal(t, "test", vm.Name)
+	require.Equal(t, 2, vm.VirtualMachineConfig.Cores)
+	require.False(t, bool(vm.Template))
+
+	_, err = client.ConfigureVM(ctx, vm, capmox.VirtualMachineOption{Name: "cores", Value: 4}, capmox.VirtualMachineOption{Name: "memory", Value: 4096})
+	require.NoError(t, err)
+	require.NoError(t, client.ResizeDisk(ctx, vm, "scsi0", "+10G"))
+
+	state, ok := sim.VM(uint64(vmID))
+	require.True(t, ok)
+	require.Equal(t, "local-lvm:base-9000-disk-0,size=20G", state.Config["scsi0"])
+	require.Equal(t, "capmox", state.Pool)
+
+	resource, err := client.FindVMResource(ctx, uint64(vmID))
+	require.NoError(t, err)
+	require.Equal(t, "pve2", resource.Node)
+
+	nodes, err := client.GetPoolNodes(ctx, "capmox")
+	require.NoError(t, err)
+	require.Equal(t, []string{"pve2"}, nodes)
+
+	inventories, err := client.GetNodeInventories(ctx)
+	require.NoError(t, err)
+	require.Len(t, inventories, 2)
+	require.Equal(t, []string{"ssd"}, inventories[0].Tags)
+	require.Equal(t, 8, inventories[1].CPUs)
+	topology := capmox.NewStorageTopology(inventories)
+	require.False(t, topology.IsShared(SimulatorImageStorage))
+	require.True(t, topology.Available(SimulatorImageStorage, "pve2"))
+}
+
+func TestSimulator_PendingChangesOfRunningVM(t *testing.T) {
+	ctx := context.Background()
+	_, client := newSimulatorClient(t)
+	vmID := cloneSimulatedVM(t, client)
+
+	vm, err := client.GetVM(ctx, "pve2", vmID)
+	require.NoError(t, err)
+	_, err = client.StartVM(ctx, vm)
+	require.NoError(t, err)
+
+	vm, err = client.GetVM(ctx, "pve2", vmID)
+	require.NoError(t, err)
+	require.True(t, vm.IsRunning())
+
+	_, err = client.ConfigureVM(ctx, vm, capmox.VirtualMachineOption{Name: "sockets", Value: 2})
+	require.NoError(t, err)
+
+	pending, err := client.GetPendingChanges(ctx, vm)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sockets"}, pending)
+
+	_, err = client.RebootVM(ctx, vm)
+	require.NoError(t, err)
+
+	pending, err = client.GetPendingChanges(ctx, vm)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	memory, err := client.GetNodeMemory(ctx, "pve2", infrav1alpha1.MemoryAccountingUsage)
+	require.NoError(t, err)
+	require.Equal(t, uint64(30*1024*mib), memory.Reservable(0))
+}
+
+func TestSimulator_CloudInitAndDelete(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	vmID := cloneSimulatedVM(t, client)
+
+	vm, err := client.GetVM(ctx, "pve2", vmID)
+	require.NoError(t, err)
+	require.NoError(t, vm.CloudInit(ctx, "ide0", "#cloud-config", "instance-id: test", "", ""))
+
+	_, ok := sim.ISO("pve2", "user-data-100.iso")
+	require.True(t, ok)
+
+	state, _ := sim.VM(uint64(vmID))
+	require.Equal(t, "local:iso/user-data-100.iso,media=cdrom", state.Config["ide0"])
+
+	_, err = client.DeleteVM(ctx, "pve2", vmID, capmox.VMDeleteOptions{Purge: true, DestroyUnreferencedDisks: true})
+	require.NoError(t, err)
+
+	_, ok = sim.ISO("pve2", "user-data-100.iso")
+	require.False(t, ok)
+
+	_, err = client.GetVM(ctx, "pve2", vmID)
+	require.ErrorContains(t, err, "does not exist")
+}
+
+func TestSimulator_GuestAgent(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(SimulatedVM{
+		VMID:   101,
+		Node:   "pve1",
+		Status: "running",
+		Config: map[string]any{"name": "agent", "agent": "enabled=1", "net0": "virtio=A6:23:64:4D:84:CC,bridge=vmbr0"},
+		GuestInterfaces: []SimulatedGuestInterface{
+			{Name: "eth0", MACAddress: "A6:23:64:4D:84:CC", IPAddresses: []string{"10.0.0.10/24", "2001:db8::10/64"}},
+		},
+	})
+
+	vm, err := client.GetVM(ctx, "pve1", 101)
+	require.NoError(t, err)
+	ifaces, err := client.GetGuestNetworkInterfaces(ctx, vm)
+	require.NoError(t, err)
+	require.Equal(t, []capmox.GuestNetworkInterface{
+		{Name: "eth0", MACAddress: "a6:23:64:4d:84:cc", IPAddresses: []string{"10.0.0.10", "2001:db8::10"}},
+	}, ifaces)
+	require.NoError(t, client.PingGuestAgent(ctx, vm))
+
+	_, err = client.ShutdownVM(ctx, vm, capmox.VMStopOptions{})
+	require.NoError(t, err)
+	_, err = client.GetGuestNetworkInterfaces(ctx, vm)
+	require.ErrorContains(t, err, "QEMU guest agent is not running")
+	require.ErrorContains(t, client.PingGuestAgent(ctx, vm), "QEMU guest agent is not running")
+}
+
+func TestSimulator_GuestCommands(t *testing.T) {
+	ctx := context.Background()
+	sim, client := newSimulatorClient(t)
+	sim.AddVM(SimulatedVM{
+		VMID:   101,
+		Node:   "pve1",
+		Status: "running",
+		Config: map[string]any{"name": "agent", "agent": "1"},
+		GuestCommands: map[string]SimulatedGuestCommand{
+			"cloud-init status --wait": {ExitCode: 1, Output: "..\nstatus: error\n", RunningPolls: 1},
+		},
+	})
+
+	vm, err := client.GetVM(ctx, "pve1", 101)
+	require.NoError(t, err)
+	pid, err := client.StartGuestCommand(ctx, vm, []string{"cloud-init", "status", "--wait"})
+	require.NoError(t, err)
+
+	status, err := client.GetGuestCommandStatus(ctx, vm, pid)
+	require.NoError(t, err)
+	require.False(t, status.Exited)
+	status, err = client.GetGuestCommandStatus(ctx, vm, pid)
+	require.NoError(t, err)
+	require.Equal(t, capmox.GuestCommandStatus{Exited: true, ExitCode: 1, Output: "..\nstatus: error\n"}, status)
+
+	_, err = client.StartGuestCommand(ctx, vm, []string{"missing"})
+	require.ErrorContains(t, err, "No such file or directory")
+
+	sim.AddVM(SimulatedVM{
+		VMID:       102,
+		Node:       "pve1",
+		Status:     "running",
+		Config:     map[string]any{"name": "files", "agent": "1"},
+		GuestFiles: map[string]string{"/var/log/cloud-init-output.log": "Cloud-init v. 23.4 finished\n"},
+	})
+	files, err := client.GetVM(ctx, "pve1", 102)
+	require.NoError(t, err)
+	content, err := client.ReadGuestFile(ctx, files, "/var/log/cloud-init-output.log")
+	require.NoError(t, err)
+	require.Equal(t, "Cloud-init v. 23.4 finished\n", content)
+	_, err = client.ReadGuestFile(ctx, files, "/var/log/missing.log")
+	require.ErrorContains(t, err, "No such file or directory")
+
+	// the processes are lost when the VM is rebooted.
+	_, err = client.RebootVM(ctx, vm)
+	require.NoError(t, err)
+	_, err = client.GetGuestCommandStatus(ctx, vm, pid)
+	require.ErrorContains(t, err, "Invalid parameter 'pid'")
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmoxtest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SimulatedVolume is a disk image allocated through the storage API.
+type SimulatedVolume struct {
+	Node    string
+	Storage string
+	Name    string
+	// Owner is the VMID in the name of the volume. VMs only destroy the volumes they own.
+	Owner  uint64
+	SizeGB int
+}
+
+// Volume returns the volume with the given ID on a node, in the format storage:name.
+func (s *Simulator) Volume(node, volid string) (SimulatedVolume, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	volume, ok := s.volumes[volumeKey(node, volid)]
+	if !ok {
+		return SimulatedVolume{}, false
+	}
+	return *volume, true
+}
+
+func volumeKey(node, volid string) string {
+	return node + "/" + volid
+}
+
+func (s *Simulator) allocateVolume(node, storage string, params map[string]any) (any, error) {
+	if storage != SimulatorImageStorage {
+		return nil, errParameter("storage", fmt.Sprintf("storage '%s' does not support content type 'images'", storage))
+	}
+
+	owner, err := strconv.ParseUint(paramString(params, "vmid"), 10, 64)
+	if err != nil {
+		return nil, errParameter("vmid", "type check ('integer') failed")
+	}
+	filename := paramString(params, "filename")
+	if !strings.HasPrefix(filename, fmt.Sprintf("vm-%d-", owner)) {
+		return nil, errParameter("filename", fmt.Sprintf("name must begin with 'vm-%d-'", owner))
+	}
+	size, err := strconv.Atoi(strings.TrimSuffix(paramString(params, "size"), "G"))
+	if err != nil {
+		return nil, errParameter("size", "value does not match the regex pattern")
+	}
+
+	volid := storage + ":" + filename
+	if _, ok := s.volumes[volumeKey(node, volid)]; ok {
+		return nil, errParameter("filename", fmt.Sprintf("volume '%s' already exists", volid))
+	}
+	s.volumes[volumeKey(node, volid)] = &SimulatedVolume{Node: node, Storage: storage, Name: filename, Owner: owner, SizeGB: size}
+	return volid, nil
+}
+
+func (s *Simulator) volumeContent(method, node, volid string) (any, error) {
+	volume := s.volumes[volumeKey(node, volid)]
+	switch method {
+	case http.MethodGet:
+		return map[string]any{
+			"format": "raw",
+			"size":   volume.SizeGB << 30,
+			"used":   0,
+			"path":   "/dev/pve/" + volume.Name,
+		}, nil
+	case http.MethodDelete:
+		delete(s.volumes, volumeKey(node, volid))
+		return s.newTask(node, "imgdel", ""), nil
+	}
+
+	return nil, &simulatorError{status: http.StatusNotImplemented, message: fmt.Sprintf("Method '%s' not implemented", method)}
+}
+
+// destroyOwnedVolumes destroys the volumes owned by a VM which is destroyed.
+func (s *Simulator) destroyOwnedVolumes(vm *SimulatedVM) {
+	for key, volume := range s.volumes {
+		if volume.Node == vm.Node && volume.Owner == vm.VMID {
+			delete(s.volumes, key)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sshtransport accesses Proxmox nodes through SSH, for operations the Proxmox API does not support.
+package sshtransport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	capmox "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+)
+
+const (
+	// PrivateKeyKey is the key of the private key in the secret of the node access,
+	// like in secrets of the type kubernetes.io/ssh-auth.
+	PrivateKeyKey = "ssh-privatekey"
+
+	// KnownHostsKey is the key of the host keys of the nodes in the secret of the node access,
+	// in the format of an OpenSSH known_hosts file.
+	KnownHostsKey = "known_hosts"
+
+	defaultUser = "root"
+	defaultPort = 22
+
+	// handshakeTimeout limits connecting to a node, including the SSH handshake.
+	handshakeTimeout = 30 * time.Second
+)
+
+var _ capmox.NodeTransport = &Transport{}
+
+// Options configure the Transport.
+type Options struct {
+	// User is the user on the nodes. Defaults to root.
+	User string
+	// Port is the SSH port of the nodes. Defaults to 22.
+	Port int32
+	// Addresses are the hostnames or IP addresses of the nodes by their name.
+	// Nodes without an address are reached by their name.
+	Addresses map[string]string
+
+	// PrivateKey is the PEM encoded private key of the user.
+	PrivateKey []byte
+	// KnownHosts holds the host keys of the nodes. Nodes whose key is not known are rejected.
+	KnownHosts []byte
+}
+
+// Transport runs commands on the Proxmox nodes through SSH.
+type Transport struct {
+	config    *ssh.ClientConfig
+	port      int
+	addresses map[string]string
+}
+
+// New returns a Transport which authenticates with the private key, and verifies the nodes by the known hosts.
+func New(opts Options) (*Transport, error) {
+	signer, err := ssh.ParsePrivateKey(opts.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	if len(bytes.TrimSpace(opts.KnownHosts)) == 0 {
+		return nil, errors.New("the host keys of the nodes are required")
+	}
+	hostKeyCallback, err := parseKnownHosts(opts.KnownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid known hosts: %w", err)
+	}
+	hostKeyAlgorithms, err := knownHostKeyAlgorithms(opts.KnownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid known hosts: %w", err)
+	}
+
+	user := opts.User
+	if user == "" {
+		user = defaultUser
+	}
+	port := int(opts.Port)
+	if port == 0 {
+		port = defaultPort
+	}
+
+	return &Transport{
+		config: &ssh.ClientConfig{
+			User:              user,
+			Auth:              []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback:   hostKeyCallback,
+			HostKeyAlgorithms: hostKeyAlgorithms,
+			Timeout:           handshakeTimeout,
+		},
+		port:      port,
+		addresses: opts.Addresses,
+	}, nil
+}
+
+// parseKnownHosts returns a callback which verifies the host keys by the known hosts.
+func parseKnownHosts(knownHosts []byte) (ssh.HostKeyCallback, error) {
+	// knownhosts only reads files, which it parses completely before returning.
+	file, err := os.CreateTemp("", "capmox-known-hosts-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	if _, err := file.Write(knownHosts); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	return knownhosts.New(file.Name())
+}
+
+// knownHostKeyAlgorithms returns the algorithms of the known host keys. Otherwise, the nodes may present
+// a key of another type, which the known hosts do not contain. Certificates of a known authority are
+// accepted with the default algorithms.
+func knownHostKeyAlgorithms(knownHosts []byte) ([]string, error) {
+	var algorithms []string
+	seen := make(map[string]bool)
+	for rest := knownHosts; ; {
+		marker, _, key, _, next, err := ssh.ParseKnownHosts(rest)
+		if errors.Is(err, io.EOF) {
+			return algorithms, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if marker == "cert-authority" {
+			return nil, nil
+		}
+		rest = next
+		if marker == "revoked" {
+			continue
+		}
+
+		types := []string{key.Type()}
+		if key.Type() == ssh.KeyAlgoRSA {
+			types = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+		}
+		for _, t := range types {
+			if !seen[t] {
+				seen[t] = true
+				algorithms = append(algorithms, t)
+			}
+		}
+	}
+}
+
+// WriteVolume writes the content to the file of the volume on the node. The path of the volume is resolved
+// by pvesm on the node, and the file is replaced once the content is written completely.
+func (t *Transport) WriteVolume(ctx context.Context, nodeName, volumeID string, content []byte) error {
+	command := fmt.Sprintf(`path=$(pvesm path %s) && mkdir -p "$(dirname "$path")" && cat > "$path.tmp" && mv "$path.tmp" "$path"`, quote(volumeID))
+	if err := t.run(ctx, nodeName, command, content); err != nil {
+		return fmt.Errorf("cannot write volume %s on node %s: %w", volumeID, nodeName, err)
+	}
+	return nil
+}
+
+// run runs the command on the node with the input on its stdin.
+func (t *Transport) run(ctx context.Context, nodeName, command string, input []byte) error {
+	client, err := t.dial(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = session.Close() }()
+
+	var stderr bytes.Buffer
+	session.Stdin = bytes.NewReader(input)
+	session.Stderr = &stderr
+
+	done := make(chan error, 1)
+	go func() { done <- session.Run(command) }()
+
+	select {
+	case <-ctx.Done():
+		// closing the connection ends the command.
+		_ = client.Close()
+		return ctx.Err()
+	case err := <-done:
+		if output := strings.TrimSpace(stderr.String()); err != nil && output != "" {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return err
+	}
+}
+
+// dial connects to the node.
+func (t *Transport) dial(ctx context.Context, nodeName string) (*ssh.Client, error) {
+	host := nodeName
+	if address := t.addresses[nodeName]; address != "" {
+		host = address
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(t.port))
+
+	dialer := net.Dialer{Timeout: t.config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to node %s: %w", nodeName, err)
+	}
+
+	// the handshake does not observe the context.
+	_ = conn.SetDeadline(time.Now().Add(t.config.Timeout))
+	c, chans, reqs, err := ssh.NewClientConn(conn, address, t.config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("cannot connect to node %s: %w", nodeName, err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// quote quotes the argument for the shell.
+func quote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
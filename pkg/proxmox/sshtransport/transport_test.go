@@ -0,0 +1,213 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sshtransport
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testServer is an SSH server which records the commands it runs and their input.
+type testServer struct {
+	address string
+	hostKey ssh.PublicKey
+
+	mu       sync.Mutex
+	commands []string
+	inputs   [][]byte
+	stderr   string
+	status   uint32
+}
+
+func newTestServer(t *testing.T, clientKey ssh.PublicKey) *testServer {
+	_, hostPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivateKey)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() != "root" || !bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	s := &testServer{address: listener.Addr().String(), hostKey: hostSigner.PublicKey()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		for req := range requests {
+			if req.Type != "exec" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			var exec struct{ Command string }
+			_ = ssh.Unmarshal(req.Payload, &exec)
+			_ = req.Reply(true, nil)
+
+			input, _ := io.ReadAll(channel)
+			s.mu.Lock()
+			s.commands = append(s.commands, exec.Command)
+			s.inputs = append(s.inputs, input)
+			_, _ = channel.Stderr().Write([]byte(s.stderr))
+			status := s.status
+			s.mu.Unlock()
+
+			_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+			_ = channel.Close()
+		}
+	}
+}
+
+func newClientKey(t *testing.T) ([]byte, ssh.PublicKey) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(block), signer.PublicKey()
+}
+
+func newTestTransport(t *testing.T, server *testServer, privateKey []byte, hostKey ssh.PublicKey) *Transport {
+	host, port, err := net.SplitHostPort(server.address)
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	transport, err := New(Options{
+		Port:       int32(portNumber),
+		Addresses:  map[string]string{"pve1": host},
+		PrivateKey: privateKey,
+		KnownHosts: []byte(knownhosts.Line([]string{server.address}, hostKey) + "\n"),
+	})
+	require.NoError(t, err)
+	return transport
+}
+
+func TestTransport_WriteVolume(t *testing.T) {
+	privateKey, publicKey := newClientKey(t)
+	server := newTestServer(t, publicKey)
+	transport := newTestTransport(t, server, privateKey, server.hostKey)
+
+	require.NoError(t, transport.WriteVolume(context.Background(), "pve1", "local:iso/user-data-100.iso", []byte("iso")))
+	require.Equal(t, []string{`path=$(pvesm path 'local:iso/user-data-100.iso') && mkdir -p "$(dirname "$path")" && cat > "$path.tmp" && mv "$path.tmp" "$path"`}, server.commands)
+	require.Equal(t, [][]byte{[]byte("iso")}, server.inputs)
+
+	server.mu.Lock()
+	server.stderr, server.status = "storage 'local' does not exist", 255
+	server.mu.Unlock()
+	err := transport.WriteVolume(context.Background(), "pve1", "local:iso/user-data-100.iso", []byte("iso"))
+	require.ErrorContains(t, err, "cannot write volume local:iso/user-data-100.iso on node pve1")
+	require.ErrorContains(t, err, "storage 'local' does not exist")
+}
+
+func TestTransport_UnknownHostKey(t *testing.T) {
+	privateKey, publicKey := newClientKey(t)
+	server := newTestServer(t, publicKey)
+	_, otherKey := newClientKey(t)
+	transport := newTestTransport(t, server, privateKey, otherKey)
+
+	err := transport.WriteVolume(context.Background(), "pve1", "local:iso/user-data-100.iso", []byte("iso"))
+	require.ErrorContains(t, err, "cannot connect to node pve1")
+	require.ErrorContains(t, err, "key mismatch")
+	require.Empty(t, server.commands)
+}
+
+func TestNew(t *testing.T) {
+	privateKey, publicKey := newClientKey(t)
+
+	_, err := New(Options{PrivateKey: []byte("invalid"), KnownHosts: []byte("pve1 " + string(ssh.MarshalAuthorizedKey(publicKey)))})
+	require.ErrorContains(t, err, "invalid private key")
+
+	_, err = New(Options{PrivateKey: privateKey})
+	require.EqualError(t, err, "the host keys of the nodes are required")
+
+	_, err = New(Options{PrivateKey: privateKey, KnownHosts: []byte("pve1 invalid")})
+	require.ErrorContains(t, err, "invalid known hosts")
+
+	transport, err := New(Options{PrivateKey: privateKey, KnownHosts: []byte("pve1 " + string(ssh.MarshalAuthorizedKey(publicKey)))})
+	require.NoError(t, err)
+	require.Equal(t, "root", transport.config.User)
+	require.Equal(t, 22, transport.port)
+}
+
+func TestKnownHostKeyAlgorithms(t *testing.T) {
+	_, ed25519Key := newClientKey(t)
+	rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaKey, err := ssh.NewPublicKey(&rsaPrivateKey.PublicKey)
+	require.NoError(t, err)
+
+	knownHosts := "# nodes\n" +
+		knownhosts.Line([]string{"pve1"}, ed25519Key) + "\n" +
+		knownhosts.Line([]string{"pve2"}, ed25519Key) + "\n" +
+		knownhosts.Line([]string{"pve2"}, rsaKey) + "\n"
+	algorithms, err := knownHostKeyAlgorithms([]byte(knownHosts))
+	require.NoError(t, err)
+	require.Equal(t, []string{ssh.KeyAlgoED25519, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}, algorithms)
+
+	algorithms, err = knownHostKeyAlgorithms([]byte("@cert-authority *.example.com " + string(ssh.MarshalAuthorizedKey(ed25519Key))))
+	require.NoError(t, err)
+	require.Nil(t, algorithms)
+}
+
+func TestQuote(t *testing.T) {
+	require.Equal(t, `'local:iso/it'\''s.iso'`, quote("local:iso/it's.iso"))
+}
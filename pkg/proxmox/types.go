@@ -16,7 +16,42 @@ limitations under the License.
 
 package proxmox
 
-import "github.com/luthermonson/go-proxmox"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luthermonson/go-proxmox"
+)
+
+const (
+	// DefaultTaskWaitInterval is the default interval in which a task is polled while waiting for it.
+	DefaultTaskWaitInterval = time.Second
+	// DefaultShutdownTimeout is the default duration a guest may take to shut down before the VM is stopped.
+	DefaultShutdownTimeout = time.Minute
+)
+
+// ErrTaskFailed is returned when a task, which was waited for, did not complete successfully.
+var ErrTaskFailed = errors.New("proxmox task failed")
+
+// ErrCircuitOpen is returned by calls which were rejected without contacting the Proxmox API,
+// because it failed repeatedly. Use errors.As with a CircuitOpenError to learn when to retry.
+var ErrCircuitOpen = errors.New("proxmox API is unavailable, circuit breaker is open")
+
+// CircuitOpenError is the error of a call which was rejected by an open circuit breaker.
+type CircuitOpenError struct {
+	// RetryAfter is the duration until the circuit breaker lets a request through again.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s, retrying in %s", ErrCircuitOpen, e.RetryAfter.Round(time.Second))
+}
+
+// Is reports whether the target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
 
 // VMCloneRequest Is the object used to clone a VM.
 type VMCloneRequest struct {
@@ -38,5 +73,374 @@ type VMCloneResponse struct {
 	Task  *proxmox.Task `json:"task,omitempty"`
 }
 
+// StopMode defines how a VM is stopped.
+type StopMode string
+
+const (
+	// StopModeStop stops the VM immediately, like pulling the power plug.
+	StopModeStop StopMode = "Stop"
+	// StopModeShutdown asks the guest to shut down and stops the VM if it did not shut down within the timeout.
+	StopModeShutdown StopMode = "Shutdown"
+)
+
+// VMStopOptions are the options of stopping a VM.
+type VMStopOptions struct {
+	// Mode is the way the VM is stopped. Defaults to StopModeStop.
+	Mode StopMode
+	// Timeout is the duration the guest may take to shut down. Defaults to DefaultShutdownTimeout.
+	Timeout time.Duration
+}
+
+// VMDeleteOptions are the options of deleting a VM.
+type VMDeleteOptions struct {
+	// Stop defines how the VM is stopped before it is deleted, if it is running.
+	Stop VMStopOptions
+	// Purge removes the VM from backup and replication jobs and from HA resources.
+	Purge bool
+	// DestroyUnreferencedDisks destroys the disks owned by the VM on all enabled storages,
+	// even if they are not referenced in its config.
+	DestroyUnreferencedDisks bool
+}
+
+// RemoteMigrateOptions are the options of migrating a VM to another Proxmox VE cluster.
+type RemoteMigrateOptions struct {
+	// TargetEndpoint is the API of the target cluster, in the format
+	// apitoken=PVEAPIToken=<user>@<realm>!<token>=<secret>,host=<address>[,fingerprint=<fingerprint>][,port=<port>].
+	TargetEndpoint string
+	// TargetVMID is the VMID of the VM on the target cluster.
+	TargetVMID int64
+	// TargetStorage maps the storages of the disks to the storages of the target cluster.
+	TargetStorage string
+	// TargetBridge maps the bridges of the network devices to the bridges of the target cluster.
+	TargetBridge string
+	// Delete removes the VM from the source cluster after it was migrated.
+	Delete bool
+}
+
+// ImageDownloadOptions are the options of downloading a disk image to the import content of a storage.
+type ImageDownloadOptions struct {
+	// URL is the location of the image.
+	URL string
+	// Filename is the name of the image on the storage, which must end with the extension of its format.
+	Filename string
+	// Checksum is verified after the image was downloaded, if set.
+	Checksum string
+	// ChecksumAlgorithm is the algorithm of the checksum, for example sha256.
+	ChecksumAlgorithm string
+}
+
+// SnapshotOptions are the options of creating a snapshot.
+type SnapshotOptions struct {
+	Description string
+	// IncludeMemory saves the memory of a running VM, so it continues running after a rollback.
+	IncludeMemory bool
+}
+
+// BackupMode defines how the VM is treated while it is backed up.
+type BackupMode string
+
+const (
+	// BackupModeSnapshot backs up a running VM using a live snapshot.
+	BackupModeSnapshot BackupMode = "snapshot"
+	// BackupModeSuspend suspends the VM while it is backed up.
+	BackupModeSuspend BackupMode = "suspend"
+	// BackupModeStop stops the VM while it is backed up and starts it again afterwards.
+	BackupModeStop BackupMode = "stop"
+)
+
+// BackupOptions are the options of backing up a VM with vzdump.
+type BackupOptions struct {
+	// Storage is the storage the backup is written to. It must support backup content.
+	Storage string
+	// Mode defaults to BackupModeSnapshot.
+	Mode BackupMode
+	// Compression is one of gzip, lzo or zstd. Empty means no compression.
+	Compression string
+	// Notes are attached to the backup. Like in the Proxmox UI, they may contain
+	// template variables like {{guestname}}.
+	Notes string
+}
+
+// BackupJob is a scheduled vzdump job of the Proxmox VE cluster.
+type BackupJob struct {
+	ID string
+	// Schedule is a calendar event, like daily or "sat 02:00".
+	Schedule string
+	// Storage is the storage the backups are written to. It must support backup content.
+	Storage string
+	Mode    BackupMode
+	// VMIDs are the VMs the job backs up.
+	VMIDs []int64
+	// PruneBackups is the retention of the backups, like "keep-daily=7,keep-last=3".
+	// Empty means the retention of the storage applies.
+	PruneBackups string
+	Comment      string
+	Enabled      bool
+}
+
+const (
+	// StorageContentImages is the content type of VM disks.
+	StorageContentImages = "images"
+	// StorageContentISO is the content type of ISO images.
+	StorageContentISO = "iso"
+	// StorageContentSnippets is the content type of snippets, like cloud-init configs.
+	StorageContentSnippets = "snippets"
+	// StorageContentBackup is the content type of vzdump backups.
+	StorageContentBackup = "backup"
+)
+
+// StorageInfo describes a storage as seen from a node.
+type StorageInfo struct {
+	Name    string
+	Type    string
+	Content []string
+	Shared  bool
+	Enabled bool
+	Active  bool
+	// TotalBytes and AvailableBytes are only known for active storages.
+	TotalBytes     uint64
+	AvailableBytes uint64
+}
+
+// HasContent returns true if the storage supports the given content type.
+func (s StorageInfo) HasContent(content string) bool {
+	for _, c := range s.Content {
+		if c == content {
+			return true
+		}
+	}
+	return false
+}
+
+// fileStorageTypes are the storage types which store disk images as files.
+var fileStorageTypes = map[string]bool{"dir": true, "nfs": true, "cifs": true, "glusterfs": true, "btrfs": true}
+
+// IsFileBased returns true if the storage stores disk images as files, whose names need an extension
+// and which support formats other than raw.
+func (s StorageInfo) IsFileBased() bool {
+	return fileStorageTypes[s.Type]
+}
+
+// Validate returns an error explaining how to fix the storage, if it cannot hold volumes of the given
+// content type on the node. Unless the format is empty or raw, it must be supported by the storage as well.
+func (s StorageInfo) Validate(node, content, format string) error {
+	switch {
+	case !s.Enabled:
+		return fmt.Errorf("storage %s is disabled on node %s, enable it or restrict it to other nodes in the storage configuration", s.Name, node)
+	case !s.Active:
+		return fmt.Errorf("storage %s is not active on node %s, make sure it is mounted or reachable from the node", s.Name, node)
+	case !s.HasContent(content):
+		return fmt.Errorf("storage %s on node %s does not support content %s, add it to the content types of the storage or choose another storage",
+			s.Name, node, content)
+	case format != "" && format != "raw" && !s.IsFileBased():
+		return fmt.Errorf("storage %s of type %s only supports raw volumes, use the raw format or a file based storage like dir or nfs for %s volumes",
+			s.Name, s.Type, format)
+	}
+	return nil
+}
+
 // VirtualMachineOption is an alias for VirtualMachineOption to prevent import conflicts.
 type VirtualMachineOption = proxmox.VirtualMachineOption
+
+// NodeInventory describes the live state of a Proxmox node.
+type NodeInventory struct {
+	Name        string
+	CPUModel    string
+	CPUs        int
+	MemoryBytes uint64
+	Tags        []string
+	// Storages are the storages available on the node, sorted by name.
+	Storages []StorageInfo
+}
+
+// StorageTopology tells which nodes can access the volumes of a storage, by the name of the storage and the node.
+// A storage with the same name is either shared by the nodes, like Ceph or NFS, or local to each node,
+// like LVM or ZFS, in which case the volumes with the same ID on different nodes are different volumes.
+type StorageTopology map[string]map[string]StorageInfo
+
+// NewStorageTopology returns the topology of the storages of the inventories.
+func NewStorageTopology(inventories []NodeInventory) StorageTopology {
+	topology := make(StorageTopology)
+	for _, inventory := range inventories {
+		for _, storage := range inventory.Storages {
+			if topology[storage.Name] == nil {
+				topology[storage.Name] = make(map[string]StorageInfo)
+			}
+			topology[storage.Name][inventory.Name] = storage
+		}
+	}
+	return topology
+}
+
+// IsShared returns whether the storage is shared by the nodes which have it.
+func (t StorageTopology) IsShared(storage string) bool {
+	for _, info := range t[storage] {
+		return info.Shared
+	}
+	return false
+}
+
+// Available returns whether the storage is enabled and active on the node.
+func (t StorageTopology) Available(storage, node string) bool {
+	info, ok := t[storage][node]
+	return ok && info.Enabled && info.Active
+}
+
+// Reachable returns whether a volume on the storage of node from can be used on node to,
+// which is the case if the storage is available on node to, and the nodes are the same or share it.
+// It decides whether a VM can be cloned or migrated to another node with its volumes.
+func (t StorageTopology) Reachable(storage, from, to string) bool {
+	return t.Available(storage, to) && (from == to || t.IsShared(storage))
+}
+
+// NodeSummary describes the state of a Proxmox node and the resources allocated to its VMs.
+// Apart from the name, offline nodes only report their state.
+type NodeSummary struct {
+	Name                 string
+	Online               bool
+	Version              string
+	CPUs                 int
+	MemoryBytes          uint64
+	AllocatedCPUs        int
+	AllocatedMemoryBytes uint64
+}
+
+// APIStatus describes the state of the Proxmox API as reported by a probe.
+type APIStatus struct {
+	// Version is the version of the Proxmox VE API, like `8.1.3`.
+	Version string
+	// Clustered is true if the node serving the API is part of a Proxmox cluster.
+	Clustered bool
+	// Quorate is true if the Proxmox cluster has quorum. Standalone nodes are always quorate.
+	Quorate bool
+}
+
+// NodeMemory is the memory of a Proxmox node and the memory its VMs reserve according to an infrav1alpha1.MemoryAccounting.
+// The VMs may reserve more memory than the node has, if it is overcommitted.
+type NodeMemory struct {
+	TotalBytes    uint64
+	ReservedBytes uint64
+}
+
+// Reservable returns the memory which can be reserved by a new VM, if the VMs may reserve the given percentage
+// of the memory of the node in addition to it.
+func (m NodeMemory) Reservable(overcommitPercent int32) uint64 {
+	limit := m.TotalBytes + m.TotalBytes/100*uint64(overcommitPercent)
+	if m.ReservedBytes >= limit {
+		return 0
+	}
+	return limit - m.ReservedBytes
+}
+
+const (
+	// HotplugStaticMemoryMiB is the memory of a VM with memory hotplug which is not plugged in DIMMs.
+	HotplugStaticMemoryMiB = 1024
+
+	hotplugDIMMSizeMiB  = 512
+	hotplugDIMMsPerSize = 32
+	hotplugDIMMSizes    = 8
+)
+
+// ValidateHotplugMemory returns an error if a VM with memory hotplug cannot have the given memory.
+// Proxmox plugs the memory above HotplugStaticMemoryMiB in DIMMs of 512MiB, whose size doubles every 32 DIMMs,
+// so the memory must be reachable by adding whole DIMMs.
+func ValidateHotplugMemory(memoryMiB int64) error {
+	if memoryMiB < HotplugStaticMemoryMiB {
+		return fmt.Errorf("memory must be at least %dMiB with memory hotplug", HotplugStaticMemoryMiB)
+	}
+
+	current := int64(HotplugStaticMemoryMiB)
+	dimmSize := int64(hotplugDIMMSizeMiB)
+	for i := 0; i < hotplugDIMMSizes; i++ {
+		for j := 0; j < hotplugDIMMsPerSize; j++ {
+			if current == memoryMiB {
+				return nil
+			}
+			if current+dimmSize > memoryMiB {
+				return fmt.Errorf("memory of %dMiB cannot be plugged in DIMMs of %dMiB, use %dMiB or %dMiB",
+					memoryMiB, dimmSize, current, current+dimmSize)
+			}
+			current += dimmSize
+		}
+		dimmSize *= 2
+	}
+	if current == memoryMiB {
+		return nil
+	}
+	return fmt.Errorf("memory must not exceed %dMiB with memory hotplug", current)
+}
+
+// TaskWaitOptions configure waiting for a task.
+type TaskWaitOptions struct {
+	// Interval is the interval in which the task is polled. Defaults to DefaultTaskWaitInterval.
+	Interval time.Duration
+	// Timeout limits the duration of the wait. A timeout of 0 waits until the context is cancelled.
+	Timeout time.Duration
+}
+
+// SDNZone is a zone of the software-defined network of the Proxmox VE cluster.
+type SDNZone struct {
+	Name string
+	// Type is the type of the zone, like simple, vlan or vxlan.
+	Type string
+}
+
+// SDNVNet is a virtual network in a zone, which is available as a bridge on the nodes of the zone.
+type SDNVNet struct {
+	Name string
+	Zone string
+	// Tag is the VLAN or VXLAN ID of the VNet. Zero means no tag.
+	Tag int
+	// Alias is a description of the VNet.
+	Alias string
+}
+
+// SDNSubnet is a subnet of a VNet.
+type SDNSubnet struct {
+	// CIDR is the network of the subnet, e.g. 10.0.0.0/24.
+	CIDR    string
+	Gateway string
+}
+
+// VMFirewall is the firewall configuration of a VM.
+type VMFirewall struct {
+	// Enable enables the firewall of the VM. Its network devices must have the firewall enabled as well.
+	Enable bool
+	// PolicyIn is the policy for inbound traffic which matches no rule, like ACCEPT or DROP.
+	// An empty policy keeps the policy of the VM.
+	PolicyIn string
+	// Rules are the rules of the VM, from the first to the last position.
+	Rules []FirewallRule
+}
+
+// FirewallRule is a rule of the Proxmox VE firewall.
+type FirewallRule struct {
+	// Type is in, out or group.
+	Type string
+	// Action is ACCEPT, DROP or REJECT, or the name of the security group for rules of type group.
+	Action string
+	// Proto is the protocol, like tcp or udp.
+	Proto string
+	// DPort is the destination port or port range, like 6443 or 30000:32767.
+	DPort string
+	// Source is the source address, CIDR, alias or IP set.
+	Source  string
+	Comment string
+}
+
+// GuestCommandStatus is the status of a command which the QEMU guest agent runs inside a VM.
+type GuestCommandStatus struct {
+	Exited   bool
+	ExitCode int
+	// Output and ErrorOutput are the standard output and error of the command, once it exited.
+	Output      string
+	ErrorOutput string
+}
+
+// GuestNetworkInterface is a network interface inside a VM, as reported by the QEMU guest agent.
+type GuestNetworkInterface struct {
+	Name       string
+	MACAddress string
+	// IPAddresses are the addresses of the interface, without their prefix length.
+	IPAddresses []string
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxmox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageInfo_Validate(t *testing.T) {
+	lvm := StorageInfo{Name: "local-lvm", Type: "lvmthin", Content: []string{StorageContentImages}, Enabled: true, Active: true}
+	nfs := StorageInfo{Name: "nfs", Type: "nfs", Content: []string{StorageContentImages, StorageContentISO}, Enabled: true, Active: true, Shared: true}
+
+	tests := map[string]struct {
+		storage StorageInfo
+		content string
+		format  string
+		err     string
+	}{
+		"raw on lvm":      {storage: lvm, content: StorageContentImages, format: "raw"},
+		"qcow2 on nfs":    {storage: nfs, content: StorageContentImages, format: "qcow2"},
+		"iso on nfs":      {storage: nfs, content: StorageContentISO},
+		"qcow2 on lvm":    {storage: lvm, content: StorageContentImages, format: "qcow2", err: "storage local-lvm of type lvmthin only supports raw volumes"},
+		"iso on lvm":      {storage: lvm, content: StorageContentISO, err: "storage local-lvm on node pve1 does not support content iso"},
+		"disabled":        {storage: StorageInfo{Name: "nfs", Active: true}, content: StorageContentImages, err: "storage nfs is disabled on node pve1"},
+		"inactive":        {storage: StorageInfo{Name: "nfs", Enabled: true}, content: StorageContentImages, err: "storage nfs is not active on node pve1"},
+		"snippets on nfs": {storage: nfs, content: StorageContentSnippets, err: "does not support content snippets"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.storage.Validate("pve1", test.content, test.format)
+			if test.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+func TestStorageTopology_Reachable(t *testing.T) {
+	lvm := StorageInfo{Name: "local-lvm", Enabled: true, Active: true}
+	nfs := StorageInfo{Name: "nfs", Shared: true, Enabled: true, Active: true}
+	topology := NewStorageTopology([]NodeInventory{
+		{Name: "pve1", Storages: []StorageInfo{lvm, nfs}},
+		{Name: "pve2", Storages: []StorageInfo{lvm, {Name: "nfs", Shared: true, Enabled: true}}},
+		{Name: "pve3", Storages: []StorageInfo{lvm, nfs}},
+	})
+
+	tests := map[string]struct {
+		storage, from, to string
+		reachable         bool
+	}{
+		"local storage of the node":     {storage: "local-lvm", from: "pve1", to: "pve1", reachable: true},
+		"local storage of another node": {storage: "local-lvm", from: "pve1", to: "pve2"},
+		"shared storage":                {storage: "nfs", from: "pve1", to: "pve3", reachable: true},
+		"inactive shared storage":       {storage: "nfs", from: "pve1", to: "pve2"},
+		"unknown node":                  {storage: "nfs", from: "pve1", to: "pve4"},
+		"unknown storage":               {storage: "ceph", from: "pve1", to: "pve1"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.reachable, topology.Reachable(test.storage, test.from, test.to))
+		})
+	}
+	require.True(t, topology.IsShared("nfs"))
+	require.False(t, topology.IsShared("local-lvm"))
+}
+
+func TestNodeMemory_Reservable(t *testing.T) {
+	memory := NodeMemory{TotalBytes: 100, ReservedBytes: 60}
+	require.Equal(t, uint64(40), memory.Reservable(0))
+	require.Equal(t, uint64(140), memory.Reservable(100))
+
+	overcommitted := NodeMemory{TotalBytes: 100, ReservedBytes: 150}
+	require.Zero(t, overcommitted.Reservable(0))
+	require.Equal(t, uint64(50), overcommitted.Reservable(100))
+	require.Zero(t, overcommitted.Reservable(50))
+}
+
+func TestValidateHotplugMemory(t *testing.T) {
+	for _, memory := range []int64{1024, 1536, 2048, 17408, 17408 + 1024, 4178944} {
+		require.NoError(t, ValidateHotplugMemory(memory), memory)
+	}
+
+	require.EqualError(t, ValidateHotplugMemory(512), "memory must be at least 1024MiB with memory hotplug")
+	require.EqualError(t, ValidateHotplugMemory(1800), "memory of 1800MiB cannot be plugged in DIMMs of 512MiB, use 1536MiB or 2048MiB")
+	// the first 32 DIMMs reach 17GiB, the following ones have 1GiB.
+	require.EqualError(t, ValidateHotplugMemory(17408+512), "memory of 17920MiB cannot be plugged in DIMMs of 1024MiB, use 17408MiB or 18432MiB")
+	require.EqualError(t, ValidateHotplugMemory(4178944+512), "memory must not exceed 4178944MiB with memory hotplug")
+}
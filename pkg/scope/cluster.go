@@ -22,6 +22,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -31,6 +32,7 @@ import (
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox"
+	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/sshtransport"
 )
 
 // ClusterScopeParams defines the input parameters used to create a new Scope.
@@ -130,16 +132,45 @@ func (s *ClusterScope) ControlPlaneEndpoint() clusterv1.APIEndpoint {
 
 // PatchObject persists the cluster configuration and status.
 func (s *ClusterScope) PatchObject() error {
+	summarized := []clusterv1.ConditionType{
+		infrav1alpha1.ProxmoxClusterReady,
+		infrav1alpha1.IPPoolsDeletedCondition,
+		infrav1alpha1.SDNVNetReadyCondition,
+	}
 	// always update the readyCondition.
-	conditions.SetSummary(s.ProxmoxCluster,
-		conditions.WithConditions(
-			infrav1alpha1.ProxmoxClusterReady,
-		),
-	)
+	conditions.SetSummary(s.ProxmoxCluster, conditions.WithConditions(summarized...))
+	setV1Beta2Conditions(s.ProxmoxCluster, summarized...)
 
 	return s.patchHelper.Patch(context.TODO(), s.ProxmoxCluster)
 }
 
+// NodeTransport returns the transport to the Proxmox nodes configured by the node access of the ProxmoxCluster,
+// or nil if the cluster has none.
+func (s *ClusterScope) NodeTransport(ctx context.Context) (proxmox.NodeTransport, error) {
+	access := s.ProxmoxCluster.Spec.NodeAccess
+	if access == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: s.ProxmoxCluster.GetNamespace(), Name: access.SecretRef.Name}
+	if err := s.client.Get(ctx, key, secret); err != nil {
+		return nil, errors.Wrapf(err, "unable to get node access secret %s", access.SecretRef.Name)
+	}
+
+	transport, err := sshtransport.New(sshtransport.Options{
+		User:       access.User,
+		Port:       access.Port,
+		Addresses:  access.Addresses,
+		PrivateKey: secret.Data[sshtransport.PrivateKeyKey],
+		KnownHosts: secret.Data[sshtransport.KnownHostsKey],
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid node access secret %s", access.SecretRef.Name)
+	}
+	return transport, nil
+}
+
 // Close closes the current scope persisting the cluster configuration and status.
 func (s *ClusterScope) Close() error {
 	return s.PatchObject()
@@ -17,13 +17,18 @@ limitations under the License.
 package scope
 
 import (
+	"context"
 	"testing"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	v1beta2conditions "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/conditions/v1beta2"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/kubernetes/ipam"
 	"github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/proxmox/goproxmox"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -48,3 +53,44 @@ func TestNewClusterScope_MissingParams(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterScope_NodeTransport(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "node-access", Namespace: "default"}}
+	s := &ClusterScope{
+		client:         fake.NewClientBuilder().WithObjects(secret).Build(),
+		ProxmoxCluster: &infrav1alpha1.ProxmoxCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default"}},
+	}
+
+	transport, err := s.NodeTransport(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, transport)
+
+	s.ProxmoxCluster.Spec.NodeAccess = &infrav1alpha1.NodeAccess{SecretRef: corev1.LocalObjectReference{Name: "missing"}}
+	_, err = s.NodeTransport(context.Background())
+	require.ErrorContains(t, err, "unable to get node access secret missing")
+
+	s.ProxmoxCluster.Spec.NodeAccess.SecretRef.Name = "node-access"
+	_, err = s.NodeTransport(context.Background())
+	require.ErrorContains(t, err, "invalid node access secret node-access: invalid private key")
+}
+
+func TestSetV1Beta2Conditions(t *testing.T) {
+	cluster := &infrav1alpha1.ProxmoxCluster{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	conditions.MarkTrue(cluster, infrav1alpha1.ProxmoxClusterReady)
+	conditions.MarkFalse(cluster, infrav1alpha1.SDNVNetReadyCondition, infrav1alpha1.SDNZoneNotFoundReason, clusterv1.ConditionSeverityError, "zone %s not found", "zone0")
+	conditions.MarkTrue(cluster, infrav1alpha1.ProxmoxAPIReachableCondition)
+
+	setV1Beta2Conditions(cluster, infrav1alpha1.ProxmoxClusterReady, infrav1alpha1.IPPoolsDeletedCondition, infrav1alpha1.SDNVNetReadyCondition)
+
+	require.Len(t, cluster.Status.V1Beta2.Conditions, 5)
+	require.Equal(t, metav1.ConditionTrue, v1beta2conditions.Get(cluster, string(infrav1alpha1.ProxmoxAPIReachableCondition)).Status)
+	paused := v1beta2conditions.Get(cluster, infrav1alpha1.PausedV1Beta2Condition)
+	require.Equal(t, metav1.ConditionFalse, paused.Status)
+	require.Equal(t, infrav1alpha1.NotPausedV1Beta2Reason, paused.Reason)
+
+	ready := v1beta2conditions.Get(cluster, infrav1alpha1.ReadyV1Beta2Condition)
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, infrav1alpha1.NotReadyV1Beta2Reason, ready.Reason)
+	require.Equal(t, "* SDNVNetReady: zone zone0 not found", ready.Message)
+	require.Equal(t, int64(2), ready.ObservedGeneration)
+}
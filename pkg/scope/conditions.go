@@ -0,0 +1,42 @@
+/*
+Copyright 2023 IONOS Cloud.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
+	v1beta2conditions "github.com/ionos-cloud/cluster-api-provider-proxmox/pkg/conditions/v1beta2"
+)
+
+// setV1Beta2Conditions mirrors the v1beta1 conditions of a reconciled object, which is not paused,
+// and summarizes the conditions of the v1beta1 Ready condition in the v1beta2 Ready condition.
+func setV1Beta2Conditions(obj v1beta2conditions.V1Beta1Setter, summarized ...clusterv1.ConditionType) {
+	v1beta2conditions.MirrorV1Beta1(obj, infrav1alpha1.ReadyV1Beta2Condition, infrav1alpha1.PausedV1Beta2Condition)
+	v1beta2conditions.Set(obj, metav1.Condition{
+		Type:   infrav1alpha1.PausedV1Beta2Condition,
+		Status: metav1.ConditionFalse,
+		Reason: infrav1alpha1.NotPausedV1Beta2Reason,
+	})
+
+	conditionTypes := make([]string, 0, len(summarized))
+	for _, conditionType := range summarized {
+		conditionTypes = append(conditionTypes, string(conditionType))
+	}
+	v1beta2conditions.SetSummary(obj, infrav1alpha1.ReadyV1Beta2Condition, conditionTypes...)
+}
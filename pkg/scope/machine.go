@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrav1alpha1 "github.com/ionos-cloud/cluster-api-provider-proxmox/api/v1alpha1"
@@ -213,6 +214,7 @@ func (m *MachineScope) PatchObject() error {
 			infrav1alpha1.VMProvisionedCondition,
 		),
 	)
+	setV1Beta2Conditions(m.ProxmoxMachine, infrav1alpha1.VMProvisionedCondition)
 
 	// Patch the ProxmoxMachine resource.
 	return m.patchHelper.Patch(
@@ -243,3 +245,56 @@ func (m *MachineScope) GetBootstrapSecret(ctx context.Context, secret *corev1.Se
 
 	return m.client.Get(ctx, secretKey, secret)
 }
+
+// CreateOrUpdateSecret creates or updates a secret, by default in the namespace of the machine.
+// A secret in the namespace of the machine is owned by the ProxmoxMachine, a secret in another
+// namespace must be deleted with DeleteSecret.
+// The mutate function sets the desired state of the secret, which contains its current state if it exists.
+func (m *MachineScope) CreateOrUpdateSecret(ctx context.Context, secret *corev1.Secret, mutate func() error) error {
+	if secret.Namespace == "" {
+		secret.Namespace = m.ProxmoxMachine.GetNamespace()
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, m.client, secret, func() error {
+		if err := mutate(); err != nil {
+			return err
+		}
+		if secret.Namespace != m.ProxmoxMachine.GetNamespace() {
+			return nil
+		}
+		return controllerutil.SetControllerReference(m.ProxmoxMachine, secret, m.client.Scheme())
+	})
+	return err
+}
+
+// DeleteSecret deletes the secret with the given namespace and name, if it exists.
+func (m *MachineScope) DeleteSecret(ctx context.Context, namespace, name string) error {
+	secret := &corev1.Secret{}
+	secret.Namespace = namespace
+	secret.Name = name
+	return client.IgnoreNotFound(m.client.Delete(ctx, secret))
+}
+
+// GetSecret obtains the secret with the given name from the namespace of the machine.
+func (m *MachineScope) GetSecret(ctx context.Context, name string, secret *corev1.Secret) error {
+	secretKey := types.NamespacedName{
+		Namespace: m.ProxmoxMachine.GetNamespace(),
+		Name:      name,
+	}
+
+	return m.client.Get(ctx, secretKey, secret)
+}
+
+// ListProxmoxDisks lists the ProxmoxDisks in the namespace of the machine.
+func (m *MachineScope) ListProxmoxDisks(ctx context.Context) ([]infrav1alpha1.ProxmoxDisk, error) {
+	disks := &infrav1alpha1.ProxmoxDiskList{}
+	if err := m.client.List(ctx, disks, client.InNamespace(m.ProxmoxMachine.GetNamespace())); err != nil {
+		return nil, err
+	}
+	return disks.Items, nil
+}
+
+// UpdateProxmoxDiskStatus updates the status of a ProxmoxDisk. The update conflicts if the ProxmoxDisk
+// was changed since it was read, so that it is not attached to two machines at once.
+func (m *MachineScope) UpdateProxmoxDiskStatus(ctx context.Context, disk *infrav1alpha1.ProxmoxDisk) error {
+	return m.client.Status().Update(ctx, disk)
+}